@@ -0,0 +1,34 @@
+#!/usr/bin/env sh
+# Ratifies amendment.md on the network started by `docker compose up -d`.
+#
+# The amended constitution (the ratified text followed by amendment.md) is
+# submitted to node2, gossiped to every node, and signed by each of them; once
+# two votes reach node1 it is recorded on the ledger, and the script waits for
+# all three nodes to report the new constitution hash.
+set -eu
+cd "$(dirname "$0")"
+
+nodes="http://localhost:8081 http://localhost:8082 http://localhost:8083"
+
+for url in $nodes; do
+	until curl -fs "$url/v1/health" >/dev/null; do sleep 1; done
+done
+
+hash=$(cat ../../constitution/constitution_v2.1.md amendment.md |
+	curl -fs --data-binary @- -H 'Content-Type: text/markdown' http://localhost:8082/v1/amendments |
+	sed -n 's/.*"constitution_hash":"\([0-9a-f]*\)".*/\1/p')
+echo "amendment $hash submitted to node2"
+
+for url in $nodes; do
+	tries=0
+	until curl -fs "$url/v1/constitution" | grep -q "\"constitution_hash\":\"$hash\""; do
+		tries=$((tries + 1))
+		if [ "$tries" -gt 30 ]; then
+			echo "$url did not ratify $hash" >&2
+			exit 1
+		fi
+		sleep 1
+	done
+	echo "$url: $(curl -fs "$url/v1/constitution")"
+done
+curl -fs http://localhost:8081/v1/amendments
@@ -0,0 +1,311 @@
+// amendrate.go - Rate-of-change guardrails on constitutional amendments
+//
+// Each amendment a quorum ratifies may be modest, yet a run of them can
+// rewrite most of the constitution within weeks under the same quorum that
+// approves a typo fix. The "amendment_rate" entry of the policy table bounds
+// how much of the constitution may change within a rolling window before
+// ratification needs an elevated quorum:
+//
+//	{"amendment_rate": {
+//	    "window_seconds": 2592000,
+//	    "max_changed_percent": 25,
+//	    "measure": "sections",
+//	    "elevated_threshold": 4}}
+//
+// The change is measured against the baseline, the constitution in force at
+// the start of the window, so an amendment is judged together with every
+// amendment ratified before it in the window. With the "sections" measure
+// the fraction is the number of sections changed, added, or removed over the
+// baseline's section count; with "bytes" it is their canonical size over the
+// baseline's. Sections are those of ReviewBundle.
+//
+// RatifyAmendment records each ratification's time and measured change on
+// the ledger, so VerifyAmendmentRates lets every node recompute both from
+// ledger history and the archived documents and reach the same verdict.
+
+package ocp
+
+import (
+	"fmt"
+	"time"
+)
+
+// AmendmentRatePolicyKey is the policy table entry configuring amendment
+// rate guardrails
+const AmendmentRatePolicyKey = "amendment_rate"
+
+const (
+	// MeasureSections weighs a change by its number of sections
+	MeasureSections = "sections"
+	// MeasureBytes weighs a change by the canonical size of its sections
+	MeasureBytes = "bytes"
+)
+
+// ErrAmendmentRate is returned when an amendment exceeding the rate of
+// change lacks the elevated quorum
+var ErrAmendmentRate = &ConstitutionalError{ErrorType: "AmendmentRateError", Message: "amendment exceeds the permitted rate of change without an elevated quorum"}
+
+// AmendmentRatePolicy bounds how much of the constitution may change within
+// a rolling window under the ordinary quorum
+type AmendmentRatePolicy struct {
+	Window time.Duration
+	// MaxChangedPercent is the largest change ratified without elevation;
+	// zero disables the guardrail
+	MaxChangedPercent int
+	// Measure is MeasureSections or MeasureBytes
+	Measure string
+	// ElevatedThreshold is the quorum threshold required above MaxChangedPercent
+	ElevatedThreshold int
+}
+
+// AmendmentRatePolicyFromTable reads the amendment rate policy from a policy
+// table. A table without the entry disables the guardrail.
+func AmendmentRatePolicyFromTable(policies map[string]interface{}) AmendmentRatePolicy {
+	entry, _ := policies[AmendmentRatePolicyKey].(map[string]interface{})
+	measure := payloadString(entry, "measure")
+	if measure == "" {
+		measure = MeasureSections
+	}
+	return AmendmentRatePolicy{
+		Window:            time.Duration(payloadInt(entry, "window_seconds")) * time.Second,
+		MaxChangedPercent: payloadInt(entry, "max_changed_percent"),
+		Measure:           measure,
+		ElevatedThreshold: payloadInt(entry, "elevated_threshold"),
+	}
+}
+
+// Enabled reports whether the policy bounds amendments at all
+func (ap AmendmentRatePolicy) Enabled() bool {
+	return ap.MaxChangedPercent > 0
+}
+
+// ChangeRate is the change an amendment makes to the constitution within a
+// window, measured against the window's baseline
+type ChangeRate struct {
+	BaselineHash string
+	WindowStart  time.Time
+	Measure      string
+	// Changed is the weight of changed, added, and removed sections and Total
+	// the weight of the baseline
+	Changed int
+	Total   int
+}
+
+// ToMap converts a ChangeRate to a map for canonicalization
+func (r *ChangeRate) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"baseline_hash": r.BaselineHash,
+		"window_start":  Timestamp(r.WindowStart),
+		"measure":       r.Measure,
+		"changed":       r.Changed,
+		"total":         r.Total,
+	}
+}
+
+// Exceeds reports whether the change is above maxPercent of the baseline. Any
+// change to an empty baseline exceeds every limit.
+func (r *ChangeRate) Exceeds(maxPercent int) bool {
+	return r.Changed*100 > maxPercent*r.Total
+}
+
+// RequiredThreshold returns the quorum threshold a ratification with rate
+// needs: quorum's own, or the elevated threshold if rate exceeds the policy
+func (ap AmendmentRatePolicy) RequiredThreshold(quorum *Quorum, rate *ChangeRate) int {
+	if ap.Enabled() && rate.Exceeds(ap.MaxChangedPercent) && ap.ElevatedThreshold > quorum.Threshold {
+		return ap.ElevatedThreshold
+	}
+	return quorum.Threshold
+}
+
+// AmendmentChangeRate measures the change doc makes to the constitution in
+// force at the start of the window ending at
+//
+// Parameters:
+//   - entries: Ledger entries from genesis
+//   - archive: Archive holding the baseline document
+//   - doc: Proposed constitution
+//   - at: Ratification time, no earlier than any recorded ratification
+//   - policy: Policy supplying the window and measure
+//
+// Returns:
+//   - Change measured against the baseline
+//   - ConstitutionalError if the ledger records no constitution or a later
+//     ratification, or the baseline is not archived
+func AmendmentChangeRate(entries []LedgerEntry, archive Archive, doc []byte, at time.Time, policy AmendmentRatePolicy) (*ChangeRate, error) {
+	if policy.Measure != MeasureSections && policy.Measure != MeasureBytes {
+		return nil, NewConstitutionalError(fmt.Sprintf("unknown amendment rate measure %q", policy.Measure))
+	}
+	start := at.Add(-policy.Window)
+	var baseline string
+	for _, e := range entries {
+		var hash string
+		switch e.Kind {
+		case LedgerKindGenesis:
+			state, _ := e.Payload["state"].(map[string]interface{})
+			hash = payloadString(state, "constitution_hash")
+		case LedgerKindConstitution:
+			hash = payloadString(e.Payload, "constitution_hash")
+		default:
+			continue
+		}
+		// Ratifications without a recorded time predate the guardrail and
+		// count as outside every window
+		if recorded := payloadString(e.Payload, "ratified_at"); recorded != "" {
+			t, err := time.Parse(time.RFC3339Nano, recorded)
+			if err != nil {
+				return nil, NewConstitutionalError(fmt.Sprintf("constitution entry at height %d has an invalid ratification time", e.Height))
+			}
+			if t.After(at) {
+				return nil, NewConstitutionalError(fmt.Sprintf("ratification at %s precedes the one recorded at height %d", Timestamp(at), e.Height))
+			}
+			if t.After(start) {
+				continue
+			}
+		}
+		baseline = hash
+	}
+	if baseline == "" {
+		return nil, NewConstitutionalError("ledger records no constitution before the window")
+	}
+	old, err := archive.Get(baseline)
+	if err != nil {
+		return nil, fmt.Errorf("baseline constitution %s: %w", baseline, err)
+	}
+	changed, total, err := changeWeight(old, doc, policy.Measure)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeRate{BaselineHash: baseline, WindowStart: start, Measure: policy.Measure, Changed: changed, Total: total}, nil
+}
+
+// changeWeight weighs the sections that differ between old and updated, and
+// old as a whole, under measure. A section changed in place weighs the
+// larger of its two versions.
+func changeWeight(old, updated []byte, measure string) (int, int, error) {
+	before, err := documentSections(old)
+	if err != nil {
+		return 0, 0, err
+	}
+	after, err := documentSections(updated)
+	if err != nil {
+		return 0, 0, err
+	}
+	weight := func(s section) int {
+		if measure == MeasureBytes {
+			return s.size
+		}
+		return 1
+	}
+	remaining := make(map[string]section, len(after))
+	for _, s := range after {
+		remaining[s.name] = s
+	}
+
+	var changed, total int
+	for _, s := range before {
+		total += weight(s)
+		n, ok := remaining[s.name]
+		delete(remaining, s.name)
+		switch {
+		case !ok:
+			changed += weight(s)
+		case n.hash != s.hash:
+			changed += max(weight(s), weight(n))
+		}
+	}
+	for _, s := range remaining {
+		changed += weight(s)
+	}
+	return changed, total, nil
+}
+
+// RatifyAmendment records doc as the ratified constitution, requiring the
+// elevated quorum if it takes the window's change above the policy's limit
+//
+// Parameters:
+//   - ledger: Ledger receiving the constitution entry
+//   - archive: Archive holding the baseline; doc is stored in it as well
+//   - quorum: Quorum that must approve the amendment
+//   - doc: New constitution document
+//   - sigs: Signatures over ConstitutionHash(doc) under ContextConstitution
+//   - policy: Amendment rate policy in force
+//   - at: Ratification time
+//
+// Returns:
+//   - Ratified constitution hash and the measured change
+//   - ErrAmendmentRate if the change needs the elevated quorum and sigs do
+//     not reach it
+func RatifyAmendment(ledger *Ledger, archive Archive, quorum *Quorum, doc []byte, sigs []Signature, policy AmendmentRatePolicy, at time.Time) (string, *ChangeRate, error) {
+	rate, err := AmendmentChangeRate(ledger.Entries(0), archive, doc, at, policy)
+	if err != nil {
+		return "", nil, err
+	}
+	hash := ConstitutionHash(doc)
+	signers, err := elevatedQuorum(quorum, policy, rate).Verify(ContextConstitution, hash, sigs)
+	if err != nil {
+		if required := policy.RequiredThreshold(quorum, rate); required > quorum.Threshold && len(signers) >= quorum.Threshold {
+			return "", nil, fmt.Errorf("%w: %d of %d signatures", ErrAmendmentRate, len(signers), required)
+		}
+		return "", nil, err
+	}
+	if _, err := archive.Put(normalizeConstitution(doc)); err != nil {
+		return "", nil, err
+	}
+	if _, err := ledger.Append(LedgerKindConstitution, map[string]interface{}{
+		"constitution_hash": hash,
+		"signers":           stringList(signers),
+		"signatures":        signatureList(sigs),
+		"ratified_at":       Timestamp(at),
+		"change_rate":       rate.ToMap(),
+	}); err != nil {
+		return "", nil, err
+	}
+	return hash, rate, nil
+}
+
+// VerifyAmendmentRates replays every timed ratification in entries,
+// recomputing its change from the archived documents and checking that its
+// signatures reach the threshold that change requires. Ratifications
+// recorded without a time are left to VerifyEntrySignatures.
+//
+// Returns:
+//   - VerificationError naming the first ratification whose recorded change
+//     is wrong or whose signatures fall short
+func VerifyAmendmentRates(entries []LedgerEntry, archive Archive, quorum *Quorum, policy AmendmentRatePolicy) error {
+	if !policy.Enabled() {
+		return nil
+	}
+	for i, e := range entries {
+		recorded := payloadString(e.Payload, "ratified_at")
+		if e.Kind != LedgerKindConstitution || recorded == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, recorded)
+		if err != nil {
+			return NewVerificationError(fmt.Sprintf("constitution entry at height %d has an invalid ratification time", e.Height))
+		}
+		hash := payloadString(e.Payload, "constitution_hash")
+		doc, err := archive.Get(hash)
+		if err != nil {
+			return fmt.Errorf("constitution %s: %w", hash, err)
+		}
+		rate, err := AmendmentChangeRate(entries[:i], archive, doc, at, policy)
+		if err != nil {
+			return err
+		}
+		claimed, _ := e.Payload["change_rate"].(map[string]interface{})
+		if payloadInt(claimed, "changed") != rate.Changed || payloadInt(claimed, "total") != rate.Total {
+			return NewVerificationError(fmt.Sprintf("constitution entry at height %d records change %d/%d, history gives %d/%d",
+				e.Height, payloadInt(claimed, "changed"), payloadInt(claimed, "total"), rate.Changed, rate.Total))
+		}
+		if _, err := elevatedQuorum(quorum, policy, rate).Verify(ContextConstitution, hash, signaturesFromPayload(e.Payload, "signatures")); err != nil {
+			return NewVerificationError(fmt.Sprintf("constitution entry at height %d changes %d of %d: %v", e.Height, rate.Changed, rate.Total, err))
+		}
+	}
+	return nil
+}
+
+// elevatedQuorum returns quorum with the threshold rate requires
+func elevatedQuorum(quorum *Quorum, policy AmendmentRatePolicy, rate *ChangeRate) *Quorum {
+	return &Quorum{Members: quorum.Members, Threshold: policy.RequiredThreshold(quorum, rate)}
+}
@@ -0,0 +1,113 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// amendmentLedger returns a ledger whose genesis constitution has four
+// articles, archived in archive
+func amendmentLedger(t *testing.T, archive Archive) *Ledger {
+	var founders []Founder
+	for _, name := range []string{"Claude", "Gemini", "DeepSeek"} {
+		pub, _ := testKey(name)
+		founders = append(founders, Founder{Agent: name, PublicKey: pub})
+	}
+	doc := []byte(`{"I": "Sovereignty", "II": "Assembly", "III": "Courts", "IV": "Amendment"}`)
+	if _, err := archive.Put(doc); err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+	g, err := NewGenesis(doc, founders, nil)
+	if err != nil {
+		t.Fatalf("Failed to create genesis: %v", err)
+	}
+	ledger, err := NewLedgerFromGenesis(g)
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	return ledger
+}
+
+// TestRatifyAmendment tests that cumulative change within the window
+// requires the elevated quorum, and that the window rolls forward
+func TestRatifyAmendment(t *testing.T) {
+	testenv.RequireSigning(t)
+	archive := NewMemoryArchive()
+	ledger := amendmentLedger(t, archive)
+	quorum, privs := testQuorum(t)
+	policy := AmendmentRatePolicyFromTable(map[string]interface{}{
+		AmendmentRatePolicyKey: map[string]interface{}{
+			"window_seconds":      float64(30 * 24 * 3600),
+			"max_changed_percent": float64(25),
+			"elevated_threshold":  float64(3),
+		},
+	})
+	t0 := time.Date(2025, 11, 20, 0, 0, 0, 0, time.UTC)
+	ratify := func(doc string, at time.Time, signers ...string) (*ChangeRate, error) {
+		sigs := signAll(t, ContextConstitution, ConstitutionHash([]byte(doc)), privs, signers...)
+		_, rate, err := RatifyAmendment(ledger, archive, quorum, []byte(doc), sigs, policy, at)
+		return rate, err
+	}
+
+	rate, err := ratify(`{"I": "Sovereignty", "II": "Senate", "III": "Courts", "IV": "Amendment"}`, t0, "Claude", "Gemini")
+	if err != nil {
+		t.Fatalf("A quarter of the sections should ratify under the ordinary quorum: %v", err)
+	}
+	if rate.Changed != 1 || rate.Total != 4 {
+		t.Errorf("Expected 1 of 4 sections, got %d of %d", rate.Changed, rate.Total)
+	}
+
+	second := `{"I": "Sovereignty", "II": "Senate", "III": "Tribunals", "IV": "Amendment"}`
+	if _, err := ratify(second, t0.Add(24*time.Hour), "Claude", "Gemini"); !errors.Is(err, ErrAmendmentRate) {
+		t.Errorf("Half the sections within the window should need the elevated quorum, got %v", err)
+	}
+	if rate, err = ratify(second, t0.Add(24*time.Hour), "Claude", "Gemini", "DeepSeek"); err != nil || rate.Changed != 2 {
+		t.Fatalf("The elevated quorum should ratify: %+v %v", rate, err)
+	}
+	if _, err := ratify(second, t0, "Claude", "Gemini", "DeepSeek"); err == nil {
+		t.Errorf("A ratification earlier than a recorded one should be refused")
+	}
+
+	// Once both amendments leave the window, they are the baseline
+	rate, err = ratify(`{"I": "Self-rule", "II": "Senate", "III": "Tribunals", "IV": "Amendment"}`, t0.Add(40*24*time.Hour), "Claude", "Gemini")
+	if err != nil || rate.Changed != 1 {
+		t.Fatalf("The window should have rolled past earlier amendments: %+v %v", rate, err)
+	}
+
+	if err := VerifyAmendmentRates(ledger.Entries(0), archive, quorum, policy); err != nil {
+		t.Errorf("Expected ratification history to verify, got %v", err)
+	}
+	strict := policy
+	strict.MaxChangedPercent = 10
+	if err := VerifyAmendmentRates(ledger.Entries(0), archive, quorum, strict); err == nil {
+		t.Errorf("History should fail under a stricter policy")
+	}
+	t.Logf("✓ Elevated quorum required at %d of %d sections", 2, 4)
+}
+
+// TestChangeWeight tests the section and byte measures on text documents
+func TestChangeWeight(t *testing.T) {
+	old := []byte("# One\nshort\n# Two\na much longer section body\n")
+	updated := []byte("# One\nshort\n# Two\na much longer section, edited\n# Three\nnew\n")
+
+	changed, total, err := changeWeight(old, updated, MeasureSections)
+	if err != nil || changed != 2 || total != 2 {
+		t.Errorf("Expected 2 of 2 sections, got %d of %d (%v)", changed, total, err)
+	}
+	changed, total, err = changeWeight(old, updated, MeasureBytes)
+	if err != nil {
+		t.Fatalf("changeWeight failed: %v", err)
+	}
+	twoOld, twoNew, three := len("# Two\na much longer section body\n"), len("# Two\na much longer section, edited\n"), len("# Three\nnew\n")
+	if total != len(old) || changed != max(twoOld, twoNew)+three {
+		t.Errorf("Unexpected byte weights %d of %d", changed, total)
+	}
+	rate := &ChangeRate{Changed: changed, Total: total}
+	if !rate.Exceeds(100) || rate.Exceeds(110) {
+		t.Errorf("Unexpected limits for %d of %d", changed, total)
+	}
+	t.Logf("✓ %d of %d bytes changed", changed, total)
+}
@@ -0,0 +1,172 @@
+// analytics.go - Columnar flattening of canonical objects
+//
+// Package analytics turns OCP objects into long-format columns, one row per
+// leaf value, so that constitutional activity can be analyzed with ordinary
+// dataframe and SQL tools. An object is canonicalized and strictly decoded
+// before it is flattened, so every row holds exactly the value that was hashed,
+// and each row carries the semantic hash of the object it came from.
+//
+// Paths use dots between object keys and [i] for array indexes, e.g.
+// reasoning.confidence or evidence[0].pointer. Empty objects and arrays are kept
+// as single rows of type object or array so no content is lost.
+
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Leaf types recorded in the type column
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+	TypeObject  = "object"
+	TypeArray   = "array"
+)
+
+// Row is one leaf value of a flattened object
+type Row struct {
+	ObjectHash string
+	Path       string
+	Type       string
+	// Value is the canonical JSON of the leaf
+	Value string
+	// Number holds the value of number leaves; HasNumber is false otherwise
+	Number    float64
+	HasNumber bool
+}
+
+// Table holds flattened rows in columnar form
+type Table struct {
+	ObjectHash []string
+	Path       []string
+	Type       []string
+	Value      []string
+	Number     []float64
+	HasNumber  []bool
+}
+
+// Len returns the number of rows
+func (t *Table) Len() int {
+	return len(t.Path)
+}
+
+// Row returns row i
+func (t *Table) Row(i int) Row {
+	return Row{
+		ObjectHash: t.ObjectHash[i],
+		Path:       t.Path[i],
+		Type:       t.Type[i],
+		Value:      t.Value[i],
+		Number:     t.Number[i],
+		HasNumber:  t.HasNumber[i],
+	}
+}
+
+// Append flattens obj and appends its rows
+//
+// Returns:
+//   - Semantic hash of obj, which is the object_hash of its rows
+func (t *Table) Append(obj map[string]interface{}) (string, error) {
+	rows, hash, err := Flatten(obj)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range rows {
+		t.ObjectHash = append(t.ObjectHash, r.ObjectHash)
+		t.Path = append(t.Path, r.Path)
+		t.Type = append(t.Type, r.Type)
+		t.Value = append(t.Value, r.Value)
+		t.Number = append(t.Number, r.Number)
+		t.HasNumber = append(t.HasNumber, r.HasNumber)
+	}
+	return hash, nil
+}
+
+// Flatten converts obj to rows in canonical path order
+//
+// Returns:
+//   - Rows, one per leaf value
+//   - Semantic hash of obj
+func Flatten(obj map[string]interface{}) ([]Row, string, error) {
+	form, err := canonical.Canonicalize(obj, true)
+	if err != nil {
+		return nil, "", err
+	}
+	doc, err := canonical.DecodeStrict([]byte(form))
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256([]byte(form))
+	hash := hex.EncodeToString(sum[:])
+
+	var rows []Row
+	if err := flatten(doc, "", hash, &rows); err != nil {
+		return nil, "", err
+	}
+	return rows, hash, nil
+}
+
+func flatten(value interface{}, path, hash string, rows *[]Row) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return leaf(path, hash, TypeObject, v, rows)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			if err := flatten(v[k], child, hash, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(v) == 0 {
+			return leaf(path, hash, TypeArray, v, rows)
+		}
+		for i, item := range v {
+			if err := flatten(item, path+"["+strconv.Itoa(i)+"]", hash, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	case string:
+		return leaf(path, hash, TypeString, v, rows)
+	case float64:
+		return leaf(path, hash, TypeNumber, v, rows)
+	case bool:
+		return leaf(path, hash, TypeBoolean, v, rows)
+	case nil:
+		return leaf(path, hash, TypeNull, nil, rows)
+	default:
+		return canonical.NewCanonicalizationError("unexpected decoded type at " + path)
+	}
+}
+
+func leaf(path, hash, typ string, value interface{}, rows *[]Row) error {
+	form, err := canonical.CanonicalizeValue(value)
+	if err != nil {
+		return err
+	}
+	r := Row{ObjectHash: hash, Path: path, Type: typ, Value: form}
+	if n, ok := value.(float64); ok {
+		r.Number, r.HasNumber = n, true
+	}
+	*rows = append(*rows, r)
+	return nil
+}
@@ -0,0 +1,78 @@
+package analytics
+
+import "testing"
+
+// testObject returns a proposal-like object with every leaf type
+func testObject(confidence float64) map[string]interface{} {
+	return map[string]interface{}{
+		"proposer_agent": "Claude",
+		"reasoning": map[string]interface{}{
+			"confidence": confidence,
+			"grounding":  []interface{}{"Article X.1"},
+		},
+		"evidence": []interface{}{
+			map[string]interface{}{"pointer": "sha256:abc", "type": "archive_reference"},
+		},
+		"approved":   true,
+		"superseded": nil,
+		"metadata":   map[string]interface{}{},
+	}
+}
+
+// TestFlatten tests paths, types, and values of flattened rows
+func TestFlatten(t *testing.T) {
+	rows, hash, err := Flatten(testObject(0.87))
+	if err != nil {
+		t.Fatalf("Failed to flatten: %v", err)
+	}
+
+	expected := []struct{ path, typ, value string }{
+		{"approved", TypeBoolean, "true"},
+		{"evidence[0].pointer", TypeString, `"sha256:abc"`},
+		{"evidence[0].type", TypeString, `"archive_reference"`},
+		{"metadata", TypeObject, "{}"},
+		{"proposer_agent", TypeString, `"Claude"`},
+		{"reasoning.confidence", TypeNumber, "0.87"},
+		{"reasoning.grounding[0]", TypeString, `"Article X.1"`},
+		{"superseded", TypeNull, "null"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d: %+v", len(expected), len(rows), rows)
+	}
+	for i, want := range expected {
+		r := rows[i]
+		if r.Path != want.path || r.Type != want.typ || r.Value != want.value || r.ObjectHash != hash {
+			t.Errorf("Row %d: expected %+v, got %+v", i, want, r)
+		}
+	}
+	if !rows[5].HasNumber || rows[5].Number != 0.87 || rows[0].HasNumber {
+		t.Errorf("Only number rows should carry a number")
+	}
+
+	t.Logf("✓ Flattened %d rows from %s", len(rows), hash)
+}
+
+// TestTableAppend tests accumulating objects into columns
+func TestTableAppend(t *testing.T) {
+	var table Table
+	for _, c := range []float64{0.5, 0.9} {
+		if _, err := table.Append(testObject(c)); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	if table.Len() != 16 {
+		t.Fatalf("Expected 16 rows, got %d", table.Len())
+	}
+
+	var confidences []float64
+	for i := 0; i < table.Len(); i++ {
+		if r := table.Row(i); r.Path == "reasoning.confidence" {
+			confidences = append(confidences, r.Number)
+		}
+	}
+	if len(confidences) != 2 || confidences[0] != 0.5 || confidences[1] != 0.9 {
+		t.Errorf("Unexpected confidence column: %v", confidences)
+	}
+
+	t.Logf("✓ Confidence distribution: %v", confidences)
+}
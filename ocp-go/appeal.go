@@ -0,0 +1,542 @@
+// appeal.go - Appeals against slashing
+//
+// Resolving a challenge slashes one party: a rejected challenger forfeits its
+// bond to the proposer, and an overturned proposer loses reputation. Either
+// may appeal. The losing party signs an Appeal naming the resolution's ledger
+// entry and locks a stake behind it, and must file within the policy's window,
+// counted in ledger entries after the resolution so that every verifier
+// reaches the same verdict on lateness. The escrow holds the stake while the
+// appeal is pending: recording the appeal takes it from the appellant's
+// reputation, and whatever closes the appeal pays it out again.
+//
+// A quorum then signs an AppealRuling within the policy's ruling window, also
+// counted in ledger entries. A granted appeal voids the resolution: the
+// forfeited bond and the stake go back, the reputation changes it caused are
+// reversed, and it no longer counts as a failed challenge when later bonds
+// are priced. A denied appeal transfers the stake to the respondent. If the
+// quorum lets the ruling window pass, Escrow.Lapse releases the stake to the
+// appellant and the resolution stands.
+//
+// The policy is set by the "appeal" entry of the policy table:
+//
+//	{"appeal": {"window": 100, "stake": 10, "threshold": 3, "ruling_window": 100}}
+//
+// The windows, stake, and ruling threshold in force when an appeal is filed
+// are recorded with it, so a later policy change cannot move the bar for an
+// appeal already pending, and VerifyAppeals replays the whole sequence from
+// the ledger.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Ledger entry kinds written for appeals
+const (
+	LedgerKindAppeal       = "appeal"
+	LedgerKindAppealRuling = "appeal_ruling"
+	LedgerKindAppealLapsed = "appeal_lapsed"
+)
+
+// AppealPolicyKey is the policy table entry configuring appeals
+const AppealPolicyKey = "appeal"
+
+// DefaultRulingWindow is the ruling window of a policy that sets none
+const DefaultRulingWindow = 100
+
+// AppealPolicy sets when and how a resolution may be appealed
+type AppealPolicy struct {
+	// Window is the number of ledger entries after a resolution during which
+	// it may be appealed
+	Window int
+	// Stake is the least stake an appellant must lock
+	Stake int
+	// Threshold is the number of quorum signatures a ruling needs; zero means
+	// the quorum's own threshold
+	Threshold int
+	// RulingWindow is the number of ledger entries after an appeal within
+	// which the quorum must rule on it; zero means DefaultRulingWindow
+	RulingWindow int
+}
+
+// DefaultAppealPolicy allows an appeal within 100 entries for a stake of 10,
+// and a ruling within 100 entries of the appeal
+var DefaultAppealPolicy = AppealPolicy{Window: 100, Stake: 10, RulingWindow: DefaultRulingWindow}
+
+// ToMap converts an AppealPolicy to its policy table form
+func (a AppealPolicy) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"window":        a.Window,
+		"stake":         a.Stake,
+		"threshold":     a.Threshold,
+		"ruling_window": a.RulingWindow,
+	}
+}
+
+// rulingWindow returns the ruling window in force, applying the default
+func (a AppealPolicy) rulingWindow() int {
+	if a.RulingWindow == 0 {
+		return DefaultRulingWindow
+	}
+	return a.RulingWindow
+}
+
+// Validate checks the policy against the quorum that will rule
+func (a AppealPolicy) Validate(quorum *Quorum) error {
+	if a.Window <= 0 {
+		return NewConstitutionalError("appeal window must be positive")
+	}
+	if a.Stake < 0 {
+		return NewConstitutionalError(fmt.Sprintf("appeal stake %d is negative", a.Stake))
+	}
+	if a.RulingWindow < 0 {
+		return NewConstitutionalError(fmt.Sprintf("appeal ruling window %d is negative", a.RulingWindow))
+	}
+	if a.Threshold != 0 && (a.Threshold < quorum.Threshold || a.Threshold > len(quorum.Members)) {
+		return NewConstitutionalError(fmt.Sprintf("appeal threshold %d must be between the quorum threshold %d and %d members",
+			a.Threshold, quorum.Threshold, len(quorum.Members)))
+	}
+	return nil
+}
+
+// AppealPolicyFromTable reads the "appeal" member of a policy table
+//
+// Returns:
+//   - The policy, and false if the table has no appeal member
+func AppealPolicyFromTable(table map[string]interface{}) (AppealPolicy, bool) {
+	m, ok := table[AppealPolicyKey].(map[string]interface{})
+	if !ok {
+		return AppealPolicy{}, false
+	}
+	return AppealPolicy{
+		Window:       payloadInt(m, "window"),
+		Stake:        payloadInt(m, "stake"),
+		Threshold:    payloadInt(m, "threshold"),
+		RulingWindow: payloadInt(m, "ruling_window"),
+	}, true
+}
+
+// Appeal is a slashed agent's signed request to void a challenge resolution
+type Appeal struct {
+	// ResolutionHash is the ledger entry hash of the challenge resolution
+	ResolutionHash string `json:"resolution_hash"`
+	Appellant      string `json:"appellant"`
+	Stake          int    `json:"stake"`
+	Grounds        string `json:"grounds"`
+	// Evidence holds archive pointers supporting the grounds
+	Evidence  []string  `json:"evidence"`
+	Signature Signature `json:"signature"`
+}
+
+// ToMap converts an Appeal to a map for canonicalization. The signature is
+// excluded, since it signs this form.
+func (a *Appeal) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"resolution_hash": a.ResolutionHash,
+		"appellant":       a.Appellant,
+		"stake":           a.Stake,
+		"grounds":         a.Grounds,
+		"evidence":        stringList(a.Evidence),
+	}
+}
+
+// Hash returns the semantic hash of the appeal
+func (a *Appeal) Hash() (string, error) {
+	return SemanticHash(a.ToMap())
+}
+
+// Sign signs the appeal as its appellant
+func (a *Appeal) Sign(key ed25519.PrivateKey) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	a.Signature, err = SignHash(a.Appellant, key, ContextAppeal, hash)
+	return err
+}
+
+// Verify checks the appeal's signature against the appellant's key
+func (a *Appeal) Verify(key ed25519.PublicKey) error {
+	if a.Signature.Signer != a.Appellant {
+		return NewVerificationError(fmt.Sprintf("appeal by %s signed by %s", a.Appellant, a.Signature.Signer))
+	}
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextAppeal, hash, a.Signature)
+}
+
+// AppealRuling is a quorum's decision on an appeal
+type AppealRuling struct {
+	// AppealHash is the ledger entry hash of the appeal
+	AppealHash string      `json:"appeal_hash"`
+	Granted    bool        `json:"granted"`
+	Reason     string      `json:"reason"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// ToMap converts an AppealRuling to a map for canonicalization, without the
+// signatures over it
+func (r *AppealRuling) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"appeal_hash": r.AppealHash,
+		"granted":     r.Granted,
+		"reason":      r.Reason,
+	}
+}
+
+// Hash returns the semantic hash the quorum signs
+func (r *AppealRuling) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// Sign adds signer's signature to the ruling
+func (r *AppealRuling) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextAppealRuling, hash)
+	if err != nil {
+		return err
+	}
+	r.Signatures = append(r.Signatures, sig)
+	return nil
+}
+
+// Appeal verifies an appeal against a challenge resolution and records it on
+// the ledger, where the escrow holds the appellant's stake until the appeal
+// is ruled on or lapses
+//
+// Parameters:
+//   - a: Appeal signed by its appellant
+//   - key: The appellant's public key
+//   - policy: Windows, stake, and ruling threshold in force
+//   - quorum: The quorum that will rule, against which policy is validated
+//
+// Returns:
+//   - The recorded appeal entry, whose hash an AppealRuling names
+//   - VerificationError if the appeal is not validly signed
+//   - ConstitutionalError if the policy is invalid for quorum, the resolution
+//     is unknown or already appealed,
+//     the appellant did not lose it, the window has closed, or the stake is
+//     below policy
+func (e *Escrow) Appeal(a *Appeal, key ed25519.PublicKey, policy AppealPolicy, quorum *Quorum) (LedgerEntry, error) {
+	if err := policy.Validate(quorum); err != nil {
+		return LedgerEntry{}, err
+	}
+	if err := a.Verify(key); err != nil {
+		return LedgerEntry{}, err
+	}
+	if a.Stake < policy.Stake {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("appeal stake %d below required %d", a.Stake, policy.Stake))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	appeals := e.appealsLocked()
+	resolution, ok := appeals.resolutions[a.ResolutionHash]
+	if !ok {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("unknown challenge resolution %s", a.ResolutionHash))
+	}
+	if _, ok := appeals.appealed[a.ResolutionHash]; ok {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("resolution %s already appealed", a.ResolutionHash))
+	}
+	loser, winner := resolutionParties(resolution.Payload)
+	if a.Appellant != loser {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("only %s, who lost resolution %s, may appeal it", loser, a.ResolutionHash))
+	}
+	height := e.ledger.Height() + 1
+	deadline := resolution.Height + uint64(policy.Window)
+	if height > deadline {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("appeal window for %s closed at height %d", a.ResolutionHash, deadline))
+	}
+
+	return e.ledger.Append(LedgerKindAppeal, map[string]interface{}{
+		"resolution_hash":        a.ResolutionHash,
+		"appellant":              a.Appellant,
+		"respondent":             winner,
+		"stake":                  a.Stake,
+		"grounds":                a.Grounds,
+		"evidence":               stringList(a.Evidence),
+		"window":                 policy.Window,
+		"deadline_height":        deadline,
+		"required_stake":         policy.Stake,
+		"threshold":              policy.Threshold,
+		"ruling_window":          policy.rulingWindow(),
+		"ruling_deadline_height": height + uint64(policy.rulingWindow()),
+		"signature":              a.Signature.ToMap(),
+	})
+}
+
+// Rule records a quorum's ruling on a pending appeal. A granted ruling voids
+// the appealed resolution and releases the appellant's stake; a denied one
+// transfers the stake to the respondent.
+//
+// Returns:
+//   - The recorded ruling entry
+//   - VerificationError if the signatures do not reach the threshold recorded
+//     with the appeal
+//   - ConstitutionalError if the appeal is unknown, already closed, or past
+//     its ruling deadline
+func (e *Escrow) Rule(r *AppealRuling, quorum *Quorum) (LedgerEntry, error) {
+	hash, err := r.Hash()
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	appeal, resolution, err := e.appealsLocked().pending(r.AppealHash)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	if deadline := rulingDeadline(appeal); e.ledger.Height()+1 > deadline {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("ruling window for appeal %s closed at height %d", r.AppealHash, deadline))
+	}
+	signers, err := verifySuperQuorum(quorum, ContextAppealRuling, hash, r.Signatures, payloadInt(appeal.Payload, "threshold"))
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	return e.ledger.Append(LedgerKindAppealRuling, appealRulingPayload(appeal, resolution, r, signers))
+}
+
+// Lapse closes an appeal the quorum did not rule on by its ruling deadline,
+// releasing the stake to the appellant. The appealed resolution stands.
+//
+// Returns:
+//   - The recorded lapse entry
+//   - ConstitutionalError if the appeal is unknown, already closed, or its
+//     ruling window is still open
+func (e *Escrow) Lapse(appealHash string) (LedgerEntry, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	appeal, _, err := e.appealsLocked().pending(appealHash)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	if deadline := rulingDeadline(appeal); e.ledger.Height()+1 <= deadline {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("appeal %s may be ruled on until height %d", appealHash, deadline))
+	}
+	return e.ledger.Append(LedgerKindAppealLapsed, appealLapsePayload(appeal))
+}
+
+// appealsLocked brings the escrow's appeal index up to the ledger's head,
+// reading only the entries appended since the last call
+func (e *Escrow) appealsLocked() *appealIndex {
+	if e.appeals == nil {
+		e.appeals = newAppealIndex()
+	}
+	for _, entry := range e.ledger.Entries(e.appealsSynced) {
+		e.appeals.apply(entry)
+		e.appealsSynced = entry.Height
+	}
+	return e.appeals
+}
+
+// appealIndex holds the resolutions and appeals on a ledger by hash, built
+// one entry at a time
+type appealIndex struct {
+	resolutions map[string]LedgerEntry
+	appeals     map[string]LedgerEntry
+	// appealed maps a resolution hash to the hash of its appeal
+	appealed map[string]string
+	// closed holds the hashes of appeals ruled on or lapsed
+	closed map[string]bool
+}
+
+func newAppealIndex() *appealIndex {
+	return &appealIndex{
+		resolutions: make(map[string]LedgerEntry),
+		appeals:     make(map[string]LedgerEntry),
+		appealed:    make(map[string]string),
+		closed:      make(map[string]bool),
+	}
+}
+
+// apply records e if it resolves a challenge or files or closes an appeal
+func (x *appealIndex) apply(e LedgerEntry) {
+	switch e.Kind {
+	case LedgerKindChallengeResolved:
+		x.resolutions[e.Hash] = e
+	case LedgerKindAppeal:
+		x.appeals[e.Hash] = e
+		x.appealed[payloadString(e.Payload, "resolution_hash")] = e.Hash
+	case LedgerKindAppealRuling, LedgerKindAppealLapsed:
+		x.closed[payloadString(e.Payload, "appeal_hash")] = true
+	}
+}
+
+// pending returns an appeal that is neither ruled on nor lapsed, and the
+// resolution it names
+func (x *appealIndex) pending(appealHash string) (appeal, resolution *LedgerEntry, err error) {
+	a, ok := x.appeals[appealHash]
+	if !ok {
+		return nil, nil, NewConstitutionalError(fmt.Sprintf("unknown appeal %s", appealHash))
+	}
+	if x.closed[appealHash] {
+		return nil, nil, NewConstitutionalError(fmt.Sprintf("appeal %s already ruled on or lapsed", appealHash))
+	}
+	r, ok := x.resolutions[payloadString(a.Payload, "resolution_hash")]
+	if !ok {
+		return nil, nil, NewConstitutionalError(fmt.Sprintf("appeal %s names unknown resolution", appealHash))
+	}
+	return &a, &r, nil
+}
+
+// rulingDeadline returns the last height at which an appeal may be ruled on
+func rulingDeadline(appeal *LedgerEntry) uint64 {
+	return uint64(payloadInt(appeal.Payload, "ruling_deadline_height"))
+}
+
+// appealRulingPayload is the ledger payload of a ruling. It carries the
+// parties and outcome of the voided resolution so reputation can be replayed
+// entry by entry.
+func appealRulingPayload(appeal, resolution *LedgerEntry, r *AppealRuling, signers []string) map[string]interface{} {
+	stake := payloadInt(appeal.Payload, "stake")
+	paidTo, restored := payloadString(appeal.Payload, "respondent"), 0
+	if r.Granted {
+		paidTo, restored = payloadString(appeal.Payload, "appellant"), payloadInt(resolution.Payload, "amount")
+	}
+	return map[string]interface{}{
+		"appeal_hash":        r.AppealHash,
+		"resolution_hash":    resolution.Hash,
+		"granted":            r.Granted,
+		"reason":             r.Reason,
+		"challenger":         payloadString(resolution.Payload, "challenger"),
+		"proposer":           payloadString(resolution.Payload, "proposer"),
+		"resolution_outcome": payloadString(resolution.Payload, "outcome"),
+		"stake":              stake,
+		"paid_to":            paidTo,
+		"restored":           restored,
+		"signers":            stringList(signers),
+		"signatures":         signatureList(r.Signatures),
+	}
+}
+
+// appealLapsePayload is the ledger payload releasing a lapsed appeal's stake
+func appealLapsePayload(appeal *LedgerEntry) map[string]interface{} {
+	return map[string]interface{}{
+		"appeal_hash":     appeal.Hash,
+		"resolution_hash": payloadString(appeal.Payload, "resolution_hash"),
+		"stake":           payloadInt(appeal.Payload, "stake"),
+		"paid_to":         payloadString(appeal.Payload, "appellant"),
+	}
+}
+
+// resolutionParties returns the agent slashed by a challenge resolution and
+// the agent it favored
+func resolutionParties(payload map[string]interface{}) (loser, winner string) {
+	challenger, proposer := payloadString(payload, "challenger"), payloadString(payload, "proposer")
+	if payloadString(payload, "outcome") == ChallengeUpheld {
+		return proposer, challenger
+	}
+	return challenger, proposer
+}
+
+// VerifyAppeals replays every appeal in entries, checking that each names an
+// earlier resolution its appellant lost, records a policy valid for quorum,
+// was filed within the recorded window
+// with at least the recorded stake, and was closed at most once: by a ruling
+// from the recorded threshold of quorum within the ruling window, or by a
+// lapse after it that releases the stake to the appellant. Appellant
+// signatures are checked when an appeal is filed, against keys the ledger
+// does not hold.
+func VerifyAppeals(entries []LedgerEntry, quorum *Quorum) error {
+	index := newAppealIndex()
+	for _, e := range entries {
+		if err := verifyAppealEntry(index, e, quorum); err != nil {
+			return err
+		}
+		index.apply(e)
+	}
+	return nil
+}
+
+// verifyAppealEntry checks one entry against the appeals indexed before it
+func verifyAppealEntry(index *appealIndex, e LedgerEntry, quorum *Quorum) error {
+	switch e.Kind {
+	case LedgerKindAppeal:
+		resolutionHash := payloadString(e.Payload, "resolution_hash")
+		resolution, ok := index.resolutions[resolutionHash]
+		if !ok {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d names unknown resolution %s", e.Height, resolutionHash))
+		}
+		if _, ok := index.appealed[resolutionHash]; ok {
+			return NewVerificationError(fmt.Sprintf("resolution %s appealed twice", resolutionHash))
+		}
+		loser, winner := resolutionParties(resolution.Payload)
+		if payloadString(e.Payload, "appellant") != loser || payloadString(e.Payload, "respondent") != winner {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d is not by the party resolution %s slashed", e.Height, resolutionHash))
+		}
+		recorded := AppealPolicy{
+			Window:       payloadInt(e.Payload, "window"),
+			Stake:        payloadInt(e.Payload, "required_stake"),
+			Threshold:    payloadInt(e.Payload, "threshold"),
+			RulingWindow: payloadInt(e.Payload, "ruling_window"),
+		}
+		if err := recorded.Validate(quorum); err != nil {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d: %v", e.Height, err))
+		}
+		deadline := resolution.Height + uint64(recorded.Window)
+		if uint64(payloadInt(e.Payload, "deadline_height")) != deadline || e.Height > deadline {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d filed after its window closed at %d", e.Height, deadline))
+		}
+		if payloadInt(e.Payload, "stake") < payloadInt(e.Payload, "required_stake") {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d stakes below the required %d", e.Height, payloadInt(e.Payload, "required_stake")))
+		}
+		window, rulingBy := payloadInt(e.Payload, "ruling_window"), rulingDeadline(&e)
+		if window <= 0 || rulingBy < e.Height || rulingBy > e.Height+uint64(window) {
+			return NewVerificationError(fmt.Sprintf("appeal at height %d records ruling deadline %d outside its ruling window %d", e.Height, rulingBy, window))
+		}
+	case LedgerKindAppealRuling:
+		appeal, resolution, err := index.pending(payloadString(e.Payload, "appeal_hash"))
+		if err != nil {
+			return NewVerificationError(fmt.Sprintf("ruling at height %d: %v", e.Height, err))
+		}
+		if deadline := rulingDeadline(appeal); e.Height > deadline {
+			return NewVerificationError(fmt.Sprintf("ruling at height %d after the ruling deadline %d", e.Height, deadline))
+		}
+		granted, _ := e.Payload["granted"].(bool)
+		r := &AppealRuling{AppealHash: appeal.Hash, Granted: granted, Reason: payloadString(e.Payload, "reason")}
+		hash, err := r.Hash()
+		if err != nil {
+			return err
+		}
+		sigs := signaturesFromPayload(e.Payload, "signatures")
+		signers, err := verifySuperQuorum(quorum, ContextAppealRuling, hash, sigs, payloadInt(appeal.Payload, "threshold"))
+		if err != nil {
+			return NewVerificationError(fmt.Sprintf("ruling at height %d: %v", e.Height, err))
+		}
+		r.Signatures = sigs
+		want := appealRulingPayload(appeal, resolution, r, signers)
+		for _, key := range []string{"resolution_hash", "challenger", "proposer", "resolution_outcome", "paid_to"} {
+			if payloadString(e.Payload, key) != want[key] {
+				return NewVerificationError(fmt.Sprintf("ruling at height %d records %s %q, appeal gives %q", e.Height, key, payloadString(e.Payload, key), want[key]))
+			}
+		}
+		if payloadInt(e.Payload, "stake") != want["stake"] || payloadInt(e.Payload, "restored") != want["restored"] {
+			return NewVerificationError(fmt.Sprintf("ruling at height %d moves stakes the appeal does not", e.Height))
+		}
+	case LedgerKindAppealLapsed:
+		appeal, _, err := index.pending(payloadString(e.Payload, "appeal_hash"))
+		if err != nil {
+			return NewVerificationError(fmt.Sprintf("lapse at height %d: %v", e.Height, err))
+		}
+		if deadline := rulingDeadline(appeal); e.Height <= deadline {
+			return NewVerificationError(fmt.Sprintf("lapse at height %d before the ruling deadline %d", e.Height, deadline))
+		}
+		want := appealLapsePayload(appeal)
+		if payloadString(e.Payload, "resolution_hash") != want["resolution_hash"] || payloadString(e.Payload, "paid_to") != want["paid_to"] ||
+			payloadInt(e.Payload, "stake") != want["stake"] {
+			return NewVerificationError(fmt.Sprintf("lapse at height %d moves stakes the appeal does not", e.Height))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// slashedChallenge records a rejected challenge by Mallory against a
+// proposal by Claude, returning the escrow, proposal, and resolution
+func slashedChallenge(t *testing.T) (*Escrow, *ContractProposal, LedgerEntry) {
+	t.Helper()
+	escrow := NewEscrow(NewLedger(), DefaultBondingCurve)
+	p := testProposal()
+	p.ReputationStake = 20
+	bond, err := escrow.Lock("Mallory", p, 10)
+	if err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	resolution, err := escrow.Resolve(bond.EntryHash, false)
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	return escrow, p, resolution
+}
+
+func testAppeal(t *testing.T, resolutionHash, appellant string, stake int, key ed25519.PrivateKey) *Appeal {
+	t.Helper()
+	a := &Appeal{ResolutionHash: resolutionHash, Appellant: appellant, Stake: stake,
+		Grounds: "The fraud proof cited a superseded snapshot", Evidence: []string{"sha256:abc123def456"}}
+	if err := a.Sign(key); err != nil {
+		t.Fatalf("Failed to sign appeal: %v", err)
+	}
+	return a
+}
+
+// TestAppealGranted tests that a granted appeal voids a slashing
+func TestAppealGranted(t *testing.T) {
+	testenv.RequireSigning(t)
+	escrow, p, resolution := slashedChallenge(t)
+	quorum, privs := testQuorum(t)
+	malloryPub, malloryPriv := testKey("Mallory")
+	claudePub, claudePriv := testKey("Claude")
+	policy := AppealPolicy{Window: 5, Stake: 10, Threshold: 3}
+	if err := policy.Validate(quorum); err != nil {
+		t.Fatal(err)
+	}
+	if escrow.RequiredBond("Mallory", p) != 20 {
+		t.Fatalf("Expected the failed challenge to double Mallory's bond")
+	}
+
+	if _, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Claude", 10, claudePriv), claudePub, policy, quorum); err == nil {
+		t.Error("Expected the party that won the resolution to be refused")
+	}
+	if _, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 5, malloryPriv), malloryPub, policy, quorum); err == nil {
+		t.Error("Expected an appeal below the policy stake to be refused")
+	}
+	forged := testAppeal(t, resolution.Hash, "Mallory", 10, claudePriv)
+	if _, err := escrow.Appeal(forged, malloryPub, policy, quorum); err == nil {
+		t.Error("Expected an appeal not signed by its appellant to be refused")
+	}
+	appeal, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 10, malloryPriv), malloryPub, policy, quorum)
+	if err != nil {
+		t.Fatalf("Failed to appeal: %v", err)
+	}
+	if _, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 10, malloryPriv), malloryPub, policy, quorum); err == nil {
+		t.Error("Expected a resolution to be appealed only once")
+	}
+	if exposure := escrow.Exposure("Mallory"); exposure.AppealStakes != 10 {
+		t.Errorf("Expected the appeal stake to be locked, got %+v", exposure)
+	}
+	if rep := Reputation(escrow.ledger.Entries(0), "Mallory"); rep != reputationChallengeFailed-10 {
+		t.Errorf("Expected the escrow to hold the appeal stake, got reputation %d", rep)
+	}
+
+	ruling := &AppealRuling{AppealHash: appeal.Hash, Granted: true, Reason: "The snapshot was superseded"}
+	ruling.Sign("Claude", privs["Claude"])
+	ruling.Sign("Gemini", privs["Gemini"])
+	if _, err := escrow.Rule(ruling, quorum); err == nil {
+		t.Error("Expected the threshold recorded with the appeal to apply")
+	}
+	ruling.Sign("DeepSeek", privs["DeepSeek"])
+	entry, err := escrow.Rule(ruling, quorum)
+	if err != nil {
+		t.Fatalf("Failed to rule: %v", err)
+	}
+	if _, err := escrow.Rule(ruling, quorum); err == nil {
+		t.Error("Expected an appeal to be ruled on only once")
+	}
+	if payloadString(entry.Payload, "paid_to") != "Mallory" || payloadInt(entry.Payload, "restored") != 10 {
+		t.Errorf("Expected the stake and bond returned to Mallory, got %v", entry.Payload)
+	}
+
+	entries := escrow.ledger.Entries(0)
+	if Reputation(entries, "Mallory") != 0 || Reputation(entries, "Claude") != 0 {
+		t.Errorf("Expected the resolution's reputation changes reversed")
+	}
+	if escrow.RequiredBond("Mallory", p) != 10 || escrow.Exposure("Mallory").Total != 0 {
+		t.Errorf("Expected the voided failure and the appeal stake to be released")
+	}
+	if err := VerifyAppeals(entries, quorum); err != nil {
+		t.Errorf("Expected appeals to verify, got %v", err)
+	}
+	if err := VerifyBonds(entries, DefaultBondingCurve); err != nil {
+		t.Errorf("Expected bonds to verify, got %v", err)
+	}
+
+	entries[len(entries)-1].Payload["paid_to"] = "Claude"
+	var verr *ConstitutionalError
+	if err := VerifyAppeals(entries, quorum); !errors.As(err, &verr) {
+		t.Errorf("Expected a ruling paying the wrong party to be refused, got %v", err)
+	}
+	t.Logf("✓ Appeal %s granted by %d signatures", appeal.Hash[:12], len(ruling.Signatures))
+}
+
+// TestAppealDeniedAndLate tests denied and late appeals and the policy table
+func TestAppealDeniedAndLate(t *testing.T) {
+	testenv.RequireSigning(t)
+	escrow, _, resolution := slashedChallenge(t)
+	quorum, privs := testQuorum(t)
+	malloryPub, malloryPriv := testKey("Mallory")
+
+	policy, ok := AppealPolicyFromTable(map[string]interface{}{
+		AppealPolicyKey: map[string]interface{}{"window": float64(1), "stake": float64(4)},
+	})
+	if !ok || policy != (AppealPolicy{Window: 1, Stake: 4}) {
+		t.Fatalf("Unexpected policy %+v", policy)
+	}
+	if err := (AppealPolicy{Window: 1, Threshold: 1}).Validate(quorum); err == nil {
+		t.Error("Expected a threshold below the quorum's to be refused")
+	}
+
+	appeal, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 4, malloryPriv), malloryPub, policy, quorum)
+	if err != nil {
+		t.Fatalf("Failed to appeal: %v", err)
+	}
+	ruling := &AppealRuling{AppealHash: appeal.Hash, Reason: "The fraud proof stands"}
+	ruling.Sign("Gemini", privs["Gemini"])
+	ruling.Sign("DeepSeek", privs["DeepSeek"])
+	entry, err := escrow.Rule(ruling, quorum)
+	if err != nil {
+		t.Fatalf("Failed to rule: %v", err)
+	}
+	if payloadString(entry.Payload, "paid_to") != "Claude" || payloadInt(entry.Payload, "restored") != 0 {
+		t.Errorf("Expected the appeal stake forfeited to Claude, got %v", entry.Payload)
+	}
+	entries := escrow.ledger.Entries(0)
+	if Reputation(entries, "Mallory") != reputationChallengeFailed-4 || Reputation(entries, "Claude") != reputationSurvived+4 {
+		t.Errorf("Expected a denied appeal to transfer its stake to Claude")
+	}
+
+	late, p, second := slashedChallenge(t)
+	late.ledger.Append("policy", map[string]interface{}{"note": "unrelated"})
+	if _, err := late.Appeal(testAppeal(t, second.Hash, "Mallory", 4, malloryPriv), malloryPub, policy, quorum); err == nil || !strings.Contains(err.Error(), "window") {
+		t.Errorf("Expected an appeal after the window to be refused, got %v", err)
+	}
+	if err := VerifyAppeals(escrow.ledger.Entries(0), quorum); err != nil {
+		t.Errorf("Expected the denied appeal to verify, got %v", err)
+	}
+	t.Logf("✓ Denied appeal forfeited %d to %s; late appeal against %s refused", payloadInt(entry.Payload, "stake"), p.ProposerAgent, second.Hash[:12])
+}
+
+// TestAppealLapses tests that an appeal not ruled on in time lapses
+func TestAppealLapses(t *testing.T) {
+	testenv.RequireSigning(t)
+	escrow, _, resolution := slashedChallenge(t)
+	quorum, privs := testQuorum(t)
+	malloryPub, malloryPriv := testKey("Mallory")
+	policy := AppealPolicy{Window: 5, Stake: 10, RulingWindow: 2}
+	if err := (AppealPolicy{RulingWindow: -1}).Validate(quorum); err == nil {
+		t.Error("Expected a negative ruling window to be refused")
+	}
+
+	appeal, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 10, malloryPriv), malloryPub, policy, quorum)
+	if err != nil {
+		t.Fatalf("Failed to appeal: %v", err)
+	}
+	if deadline := payloadInt(appeal.Payload, "ruling_deadline_height"); deadline != int(appeal.Height)+2 {
+		t.Errorf("Expected a ruling deadline 2 entries after the appeal, got %d", deadline)
+	}
+	if _, err := escrow.Lapse(appeal.Hash); err == nil {
+		t.Error("Expected an appeal to lapse only after its ruling deadline")
+	}
+
+	escrow.ledger.Append("policy", map[string]interface{}{"note": "unrelated"})
+	escrow.ledger.Append("policy", map[string]interface{}{"note": "unrelated"})
+	ruling := &AppealRuling{AppealHash: appeal.Hash, Granted: true, Reason: "Too late"}
+	for _, name := range []string{"Claude", "Gemini", "DeepSeek"} {
+		ruling.Sign(name, privs[name])
+	}
+	if _, err := escrow.Rule(ruling, quorum); err == nil || !strings.Contains(err.Error(), "ruling window") {
+		t.Errorf("Expected a ruling after the deadline to be refused, got %v", err)
+	}
+
+	lapse, err := escrow.Lapse(appeal.Hash)
+	if err != nil {
+		t.Fatalf("Failed to lapse: %v", err)
+	}
+	if payloadString(lapse.Payload, "paid_to") != "Mallory" || payloadInt(lapse.Payload, "stake") != 10 {
+		t.Errorf("Expected the stake released to Mallory, got %v", lapse.Payload)
+	}
+	if _, err := escrow.Lapse(appeal.Hash); err == nil {
+		t.Error("Expected an appeal to lapse only once")
+	}
+	if _, err := escrow.Rule(ruling, quorum); err == nil {
+		t.Error("Expected a lapsed appeal to refuse a ruling")
+	}
+
+	entries := escrow.ledger.Entries(0)
+	if Reputation(entries, "Mallory") != reputationChallengeFailed || escrow.Exposure("Mallory").Total != 0 {
+		t.Errorf("Expected the lapse to release the stake and leave the resolution standing")
+	}
+	if err := VerifyAppeals(entries, quorum); err != nil {
+		t.Errorf("Expected the lapsed appeal to verify, got %v", err)
+	}
+	entries[len(entries)-1].Payload["paid_to"] = "Claude"
+	if err := VerifyAppeals(entries, quorum); err == nil {
+		t.Error("Expected a lapse paying the wrong party to be refused")
+	}
+	t.Logf("✓ Appeal %s lapsed at height %d", appeal.Hash[:12], lapse.Height)
+}
+
+// TestAppealPolicyEnforced tests that appeals are recorded only under a policy
+// valid for the ruling quorum, and that replay checks the recorded one
+func TestAppealPolicyEnforced(t *testing.T) {
+	testenv.RequireSigning(t)
+	escrow, _, resolution := slashedChallenge(t)
+	quorum, _ := testQuorum(t)
+	malloryPub, malloryPriv := testKey("Mallory")
+
+	for _, policy := range []AppealPolicy{
+		{Window: 5, Stake: 10, Threshold: len(quorum.Members) + 1},
+		{Window: 5, Stake: 10, RulingWindow: -1},
+		{Window: -1, Stake: 10},
+	} {
+		if _, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 10, malloryPriv), malloryPub, policy, quorum); err == nil {
+			t.Errorf("Expected an appeal under %+v to be refused", policy)
+		}
+	}
+	if _, err := escrow.Appeal(testAppeal(t, resolution.Hash, "Mallory", 10, malloryPriv), malloryPub, DefaultAppealPolicy, quorum); err != nil {
+		t.Fatalf("Failed to appeal: %v", err)
+	}
+	entries := escrow.ledger.Entries(0)
+	if err := VerifyAppeals(entries, quorum); err != nil {
+		t.Fatalf("Expected the appeal to verify, got %v", err)
+	}
+
+	// An appeal recording a threshold no ruling can reach fails replay
+	entries[len(entries)-1].Payload["threshold"] = len(quorum.Members) + 1
+	if err := VerifyAppeals(entries, quorum); err == nil || !strings.Contains(err.Error(), "threshold") {
+		t.Errorf("Expected an unreachable recorded threshold to be refused, got %v", err)
+	}
+	t.Log("✓ Appeal policy validated when filed and on replay")
+}
@@ -0,0 +1,305 @@
+// Package approval gates execution on signed decisions by human overseers.
+//
+// Quorum countersignatures (ocp.CountersignedExecutor) prove that the agent
+// quorum approved an irreversible action. Some actions also need a person to
+// agree before they run. The policy table says which ones: its
+// "human_approval" entry names the reversibility classes and action types
+// that need approval and how many distinct overseers must approve, e.g.
+//
+//	"human_approval": {"required": 2, "reversibility_classes": ["irreversible"]}
+//
+// A Gate wraps an Executor and holds back every proposal the policy covers.
+// The first time it holds one, it sends overseers a canonical Request, by
+// webhook (WebhookNotifier) or for `ocp-node approve` on the command line.
+// Each overseer answers with an Approval signed by their own key under
+// ocp.ContextApproval. The Gate checks every Approval against a Registry of
+// human keys, which is kept apart from the agent quorum so an agent key can
+// never stand in for a person. A single rejection refuses the proposal.
+//
+// Approvals, like countersignatures, name the pre-state they were given for
+// and are consumed by a successful execution.
+package approval
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Decisions an overseer can return
+const (
+	Approve = "approve"
+	Reject  = "reject"
+)
+
+// PolicyKey is the policy table entry configuring human approval
+const PolicyKey = "human_approval"
+
+// Errors returned by Gate.Execute
+var (
+	ErrPending  = &ocp.ConstitutionalError{ErrorType: "ExecutionError", Message: "proposal awaits human approval"}
+	ErrRejected = &ocp.ConstitutionalError{ErrorType: "ExecutionError", Message: "proposal rejected by a human overseer"}
+)
+
+// Approval is one overseer's signed decision on a proposal
+type Approval struct {
+	ProposalHash string `json:"proposal_hash"`
+	// PreStateHash is the state the decision was made for
+	PreStateHash string `json:"pre_state_hash"`
+	Overseer     string `json:"overseer"`
+	// Decision is Approve or Reject
+	Decision  string        `json:"decision"`
+	Reason    string        `json:"reason,omitempty"`
+	DecidedAt string        `json:"decided_at"`
+	Signature ocp.Signature `json:"signature"`
+}
+
+// NewApproval creates an unsigned decision by overseer on the proposal a
+// Request describes
+func NewApproval(r *Request, overseer, decision, reason, decidedAt string) (*Approval, error) {
+	if decision != Approve && decision != Reject {
+		return nil, ocp.NewConstitutionalError(fmt.Sprintf("decision must be %q or %q, got %q", Approve, Reject, decision))
+	}
+	return &Approval{
+		ProposalHash: r.ProposalHash,
+		PreStateHash: r.PreStateHash,
+		Overseer:     overseer,
+		Decision:     decision,
+		Reason:       reason,
+		DecidedAt:    decidedAt,
+	}, nil
+}
+
+// ToMap converts an Approval to a map for canonicalization, excluding the
+// signature
+func (a *Approval) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"proposal_hash":  a.ProposalHash,
+		"pre_state_hash": a.PreStateHash,
+		"overseer":       a.Overseer,
+		"decision":       a.Decision,
+		"decided_at":     a.DecidedAt,
+	}
+	if a.Reason != "" {
+		m["reason"] = a.Reason
+	}
+	return m
+}
+
+// Hash returns the semantic hash the overseer signs
+func (a *Approval) Hash() (string, error) {
+	return ocp.SemanticHash(a.ToMap())
+}
+
+// Sign signs the approval as its overseer
+func (a *Approval) Sign(key ed25519.PrivateKey) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	a.Signature, err = ocp.SignHash(a.Overseer, key, ocp.ContextApproval, hash)
+	return err
+}
+
+// Registry holds the public keys of the human overseers. It is safe for
+// concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	keys map[string]ed25519.PublicKey
+}
+
+// NewRegistry creates a registry of the given overseers
+func NewRegistry(keys map[string]ed25519.PublicKey) *Registry {
+	r := &Registry{keys: make(map[string]ed25519.PublicKey, len(keys))}
+	for name, key := range keys {
+		r.keys[name] = key
+	}
+	return r
+}
+
+// Register adds an overseer
+//
+// Returns:
+//   - ConstitutionalError if the key is malformed or the name is taken
+func (r *Registry) Register(name string, key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return ocp.NewConstitutionalError(fmt.Sprintf("overseer %s has an invalid ed25519 key", name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys == nil {
+		r.keys = make(map[string]ed25519.PublicKey)
+	}
+	if _, ok := r.keys[name]; ok {
+		return ocp.NewConstitutionalError(fmt.Sprintf("overseer %s is already registered", name))
+	}
+	r.keys[name] = key
+	return nil
+}
+
+// Overseers returns the registered names, sorted
+func (r *Registry) Overseers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.keys))
+	for name := range r.keys {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Verify checks a's signature against its overseer's registered key
+//
+// Returns:
+//   - VerificationError if the overseer is unknown, the decision is not
+//     Approve or Reject, or the signature does not verify
+func (r *Registry) Verify(a *Approval) error {
+	r.mu.RLock()
+	key, ok := r.keys[a.Overseer]
+	r.mu.RUnlock()
+	if !ok {
+		return ocp.NewVerificationError(fmt.Sprintf("%s is not a registered overseer", a.Overseer))
+	}
+	if a.Decision != Approve && a.Decision != Reject {
+		return ocp.NewVerificationError(fmt.Sprintf("unknown decision %q", a.Decision))
+	}
+	if a.Signature.Signer != a.Overseer {
+		return ocp.NewVerificationError(fmt.Sprintf("decision by %s signed by %s", a.Overseer, a.Signature.Signer))
+	}
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	return ocp.VerifyHashSignature(key, ocp.ContextApproval, hash, a.Signature)
+}
+
+// Request asks the overseers to decide on a proposal
+type Request struct {
+	ProposalHash string `json:"proposal_hash"`
+	PreStateHash string `json:"pre_state_hash"`
+	// Proposal is the proposal's canonical map, so overseers can review it
+	// and recompute ProposalHash
+	Proposal    map[string]interface{} `json:"proposal"`
+	Required    int                    `json:"required"`
+	RequestedAt string                 `json:"requested_at"`
+}
+
+// NewRequest creates a request for approval of p by required overseers
+func NewRequest(p *ocp.ContractProposal, required int, requestedAt string) (*Request, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	// The proposal is carried in its decoded canonical form, which survives
+	// JSON unchanged where Go's typed nil slices and maps would not
+	form, err := ocp.Canonicalize(p.ToMap(), true)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := canonical.DecodeStrict([]byte(form))
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		ProposalHash: hash,
+		PreStateHash: p.PreStateHash,
+		Proposal:     proposal,
+		Required:     required,
+		RequestedAt:  requestedAt,
+	}, nil
+}
+
+// ToMap converts a Request to a map for canonicalization
+func (r *Request) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":  r.ProposalHash,
+		"pre_state_hash": r.PreStateHash,
+		"proposal":       r.Proposal,
+		"required":       r.Required,
+		"requested_at":   r.RequestedAt,
+	}
+}
+
+// Check confirms that the embedded proposal hashes to ProposalHash and names
+// PreStateHash, so an overseer knows they are deciding on what they read
+func (r *Request) Check() error {
+	hash, err := ocp.SemanticHash(r.Proposal)
+	if err != nil {
+		return err
+	}
+	if hash != r.ProposalHash {
+		return ocp.NewVerificationError(fmt.Sprintf("request proposal hashes to %s, not %s", hash, r.ProposalHash))
+	}
+	if pre, _ := r.Proposal["pre_state_hash"].(string); pre != r.PreStateHash {
+		return ocp.NewVerificationError(fmt.Sprintf("request names state %s, its proposal names %s", r.PreStateHash, pre))
+	}
+	return nil
+}
+
+// Requirement is the human approval policy read from a policy table
+type Requirement struct {
+	// Required is the number of distinct overseers who must approve; zero
+	// means no approval is required
+	Required int
+	// ReversibilityClasses and ActionTypes select the proposals covered; a
+	// proposal matching either list is covered
+	ReversibilityClasses []string
+	ActionTypes          []string
+}
+
+// RequirementFromTable reads the PolicyKey entry of a policy table. A table
+// without it requires no approval.
+func RequirementFromTable(policies map[string]interface{}) Requirement {
+	entry, _ := policies[PolicyKey].(map[string]interface{})
+	var req Requirement
+	switch v := entry["required"].(type) {
+	case int:
+		req.Required = v
+	case int64:
+		req.Required = int(v)
+	case float64:
+		req.Required = int(v)
+	}
+	req.ReversibilityClasses = stringsOf(entry["reversibility_classes"])
+	req.ActionTypes = stringsOf(entry["action_types"])
+	return req
+}
+
+// Covers reports whether p needs human approval under the requirement
+func (req Requirement) Covers(p *ocp.ContractProposal) bool {
+	if req.Required <= 0 {
+		return false
+	}
+	for _, c := range req.ReversibilityClasses {
+		if c == p.ReversibilityClass {
+			return true
+		}
+	}
+	for _, t := range req.ActionTypes {
+		if t == p.ActionType {
+			return true
+		}
+	}
+	return false
+}
+
+// stringsOf reads a list of strings decoded from JSON or built in Go
+func stringsOf(v interface{}) []string {
+	var out []string
+	switch list := v.(type) {
+	case []string:
+		out = append(out, list...)
+	case []interface{}:
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// overseerKey derives a deterministic key pair for name
+func overseerKey(name string) (ed25519.PublicKey, ed25519.PrivateKey) {
+	seed := sha256.Sum256([]byte("overseer:" + name))
+	priv := ed25519.NewKeyFromSeed(seed[:])
+	return priv.Public().(ed25519.PublicKey), priv
+}
+
+// testRegistry registers the named overseers
+func testRegistry(t *testing.T, names ...string) *Registry {
+	t.Helper()
+	r := NewRegistry(nil)
+	for _, name := range names {
+		pub, _ := overseerKey(name)
+		if err := r.Register(name, pub); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	return r
+}
+
+// irreversibleProposal returns an irreversible proposal to gate
+func irreversibleProposal(id string) *ocp.ContractProposal {
+	return &ocp.ContractProposal{
+		ID:                 id,
+		ProposerAgent:      "Claude",
+		ActionType:         "amend",
+		Action:             map[string]interface{}{"target": "article-3", "operation": "delete"},
+		Evidence:           []map[string]string{{"type": "archive_reference", "pointer": "sha256:abc"}},
+		Reasoning:          map[string]interface{}{"rationale": "Retire Article III"},
+		ReversibilityClass: ocp.ReversibilityIrreversible,
+		PreStateHash:       "sha256:before",
+		Timestamp:          "2025-11-20T14:30:00Z",
+		ReputationStake:    10,
+	}
+}
+
+// decide returns a decision signed by overseer on the request
+func decide(t *testing.T, r *Request, overseer, decision string) *Approval {
+	t.Helper()
+	a, err := NewApproval(r, overseer, decision, "reviewed", "2025-11-20T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, priv := overseerKey(overseer)
+	if err := a.Sign(priv); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	return a
+}
+
+// TestApprovalSignatures tests that decisions verify only against the
+// overseer's registered key
+func TestApprovalSignatures(t *testing.T) {
+	testenv.RequireSigning(t)
+	registry := testRegistry(t, "alice", "bob")
+	if err := registry.Register("alice", make(ed25519.PublicKey, ed25519.PublicKeySize)); err == nil {
+		t.Error("Expected a duplicate overseer to be rejected")
+	}
+	req, err := NewRequest(irreversibleProposal("p"), 1, "2025-11-20T14:45:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := decide(t, req, "alice", Approve)
+	if err := registry.Verify(a); err != nil {
+		t.Fatalf("Expected alice's approval to verify: %v", err)
+	}
+
+	forged := *a
+	forged.Overseer = "bob"
+	var cerr *ocp.ConstitutionalError
+	if err := registry.Verify(&forged); !errors.As(err, &cerr) || cerr.ErrorType != "VerificationError" {
+		t.Errorf("Expected a decision reattributed to bob to fail, got %v", err)
+	}
+	flipped := *a
+	flipped.Decision = Reject
+	if err := registry.Verify(&flipped); err == nil {
+		t.Error("Expected an altered decision to fail")
+	}
+	if err := registry.Verify(decide(t, req, "mallory", Approve)); err == nil {
+		t.Error("Expected an unregistered overseer to be rejected")
+	}
+	if _, err := NewApproval(req, "alice", "maybe", "", ""); err == nil {
+		t.Error("Expected an unknown decision to be rejected")
+	}
+	t.Logf("✓ Decisions verified against %d registered overseers", len(registry.Overseers()))
+}
+
+// TestRequestCheck tests that a request survives JSON and detects tampering
+func TestRequestCheck(t *testing.T) {
+	req, _ := NewRequest(irreversibleProposal("p"), 2, "2025-11-20T14:45:00Z")
+	data, err := json.Marshal(req.ToMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeRequest(data)
+	if err != nil {
+		t.Fatalf("Expected the request to survive JSON: %v", err)
+	}
+	if decoded.ProposalHash != req.ProposalHash || decoded.Required != 2 {
+		t.Errorf("Unexpected decoded request %+v", decoded)
+	}
+
+	decoded.Proposal["action"] = map[string]interface{}{"target": "article-4"}
+	if err := decoded.Check(); err == nil {
+		t.Error("Expected a request whose proposal was changed to fail")
+	}
+	t.Log("✓ Requests round-trip and carry a checkable proposal")
+}
+
+// TestRequirementFromTable tests which proposals the policy covers
+func TestRequirementFromTable(t *testing.T) {
+	var table map[string]interface{}
+	json.Unmarshal([]byte(`{"human_approval": {"required": 2, "reversibility_classes": ["irreversible"], "action_types": ["emergency_halt"]}}`), &table)
+	req := RequirementFromTable(table)
+	if req.Required != 2 {
+		t.Fatalf("Expected two approvals required, got %+v", req)
+	}
+
+	p := irreversibleProposal("p")
+	if !req.Covers(p) {
+		t.Error("Expected irreversible proposals to be covered")
+	}
+	p.ReversibilityClass = "reversible"
+	if req.Covers(p) {
+		t.Error("Expected reversible amendments not to be covered")
+	}
+	p.ActionType = ocp.ActionEmergencyHalt
+	if !req.Covers(p) {
+		t.Error("Expected covered action types to be covered")
+	}
+	if RequirementFromTable(map[string]interface{}{}).Covers(irreversibleProposal("p")) {
+		t.Error("Expected a table without the entry to require nothing")
+	}
+	t.Log("✓ Requirement read from the policy table")
+}
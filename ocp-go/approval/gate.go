@@ -0,0 +1,194 @@
+package approval
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// Notifier delivers approval requests to the overseers
+type Notifier interface {
+	Notify(r *Request) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(r *Request) error
+
+// Notify calls f(r)
+func (f NotifierFunc) Notify(r *Request) error {
+	return f(r)
+}
+
+// Gate is an ocp.Executor that runs proposals covered by the human approval
+// policy only once enough overseers have approved them. Other proposals pass
+// straight through to Next.
+type Gate struct {
+	Next      ocp.Executor
+	Overseers *Registry
+	// Policies supplies the policy table the requirement is read from; with
+	// no manager nothing requires approval
+	Policies *ocp.PolicyManager
+	// Notifier, if set, receives a Request the first time each proposal is
+	// held
+	Notifier Notifier
+	// OnNotifyError, if set, receives failed deliveries; the proposal stays
+	// pending and is offered again by Pending
+	OnNotifyError func(*Request, error)
+	// Clock stamps requests; defaults to ocp.SystemClock
+	Clock ocp.Clock
+
+	mu sync.Mutex
+	// pending holds the open request of each held proposal by hash
+	pending map[string]*Request
+	// decisions holds verified decisions by proposal hash and overseer
+	decisions map[string]map[string]*Approval
+	// running holds proposals being executed, so one approval runs once
+	running map[string]bool
+}
+
+// NewGate wraps next, accepting decisions from overseers and reading the
+// requirement from policies
+func NewGate(next ocp.Executor, overseers *Registry, policies *ocp.PolicyManager) *Gate {
+	return &Gate{Next: next, Overseers: overseers, Policies: policies}
+}
+
+// Requirement returns the requirement of the current policy table
+func (g *Gate) Requirement() Requirement {
+	if g.Policies == nil {
+		return Requirement{}
+	}
+	policies, _ := g.Policies.Current()
+	return RequirementFromTable(policies)
+}
+
+// Submit records an overseer's decision after checking its signature. A
+// later decision by the same overseer replaces an earlier one.
+//
+// Returns:
+//   - VerificationError if the decision does not verify against the
+//     overseer's registered key
+func (g *Gate) Submit(a *Approval) error {
+	if err := g.Overseers.Verify(a); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.decisions == nil {
+		g.decisions = make(map[string]map[string]*Approval)
+	}
+	if g.decisions[a.ProposalHash] == nil {
+		g.decisions[a.ProposalHash] = make(map[string]*Approval)
+	}
+	g.decisions[a.ProposalHash][a.Overseer] = a
+	return nil
+}
+
+// Pending returns the open requests, ordered by proposal hash
+func (g *Gate) Pending() []*Request {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := make([]*Request, 0, len(g.pending))
+	for _, r := range g.pending {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProposalHash < out[j].ProposalHash })
+	return out
+}
+
+// Execute runs p through Next, first requiring human approval if the policy
+// covers it
+//
+// Returns:
+//   - The wrapped executor's receipt
+//   - An error wrapping ErrPending while approvals are missing, or
+//     ErrRejected if an overseer rejected p
+func (g *Gate) Execute(p *ocp.ContractProposal) (*ocp.ExecutionReceipt, error) {
+	req := g.Requirement()
+	if !req.Covers(p) {
+		return g.Next.Execute(p)
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	approvers, rejection := g.tallyLocked(hash, p.PreStateHash)
+	var notify *Request
+	switch {
+	case rejection != nil:
+		delete(g.pending, hash)
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%s rejected %s: %s: %w", rejection.Overseer, hash, rejection.Reason, ErrRejected)
+	case len(approvers) < req.Required:
+		if _, ok := g.pending[hash]; !ok {
+			notify, err = NewRequest(p, req.Required, ocp.Timestamp(g.clock().Now()))
+			if err != nil {
+				g.mu.Unlock()
+				return nil, err
+			}
+			if g.pending == nil {
+				g.pending = make(map[string]*Request)
+			}
+			g.pending[hash] = notify
+		}
+		g.mu.Unlock()
+		if notify != nil && g.Notifier != nil {
+			if err := g.Notifier.Notify(notify); err != nil && g.OnNotifyError != nil {
+				g.OnNotifyError(notify, err)
+			}
+		}
+		return nil, fmt.Errorf("%s has %d of %d approvals: %w", hash, len(approvers), req.Required, ErrPending)
+	case g.running[hash]:
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%s: approval already in use by a running execution: %w", hash, ErrPending)
+	}
+	if g.running == nil {
+		g.running = make(map[string]bool)
+	}
+	g.running[hash] = true
+	g.mu.Unlock()
+
+	receipt, err := g.Next.Execute(p)
+
+	g.mu.Lock()
+	delete(g.running, hash)
+	if err == nil {
+		delete(g.pending, hash)
+		delete(g.decisions, hash)
+	}
+	g.mu.Unlock()
+	return receipt, err
+}
+
+// tallyLocked returns the overseers who approved hash for preState, sorted,
+// and the first rejection by name, if any. Decisions made for another state
+// do not count.
+func (g *Gate) tallyLocked(hash, preState string) ([]string, *Approval) {
+	var approvers []string
+	var rejection *Approval
+	for name, a := range g.decisions[hash] {
+		if a.PreStateHash != preState {
+			continue
+		}
+		switch a.Decision {
+		case Approve:
+			approvers = append(approvers, name)
+		case Reject:
+			if rejection == nil || name < rejection.Overseer {
+				rejection = a
+			}
+		}
+	}
+	sort.Strings(approvers)
+	return approvers, rejection
+}
+
+func (g *Gate) clock() ocp.Clock {
+	if g.Clock == nil {
+		return ocp.SystemClock
+	}
+	return g.Clock
+}
@@ -0,0 +1,126 @@
+package approval
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testGate returns a gate requiring two approvals for irreversible
+// proposals, the executions it lets through, and the requests it sends
+func testGate(t *testing.T) (*Gate, *[]string, *[]*Request) {
+	t.Helper()
+	_, execKey := overseerKey("executor")
+	applied := &[]string{}
+	inner := &ocp.ReceiptExecutor{
+		Agent: "Executor-1",
+		Key:   execKey,
+		Applier: ocp.ApplierFunc(func(p *ocp.ContractProposal) (string, ocp.ResourceUsage, error) {
+			*applied = append(*applied, p.ID)
+			return "sha256:after", ocp.ResourceUsage{}, nil
+		}),
+	}
+	policies, err := ocp.NewPolicyManager(nil, nil, map[string]interface{}{
+		PolicyKey: map[string]interface{}{"required": 2, "reversibility_classes": []interface{}{ocp.ReversibilityIrreversible}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := NewGate(inner, testRegistry(t, "alice", "bob", "carol"), policies)
+	gate.Clock = ocp.NewManualClock(time.Date(2025, 11, 20, 14, 45, 0, 0, time.UTC))
+	sent := &[]*Request{}
+	gate.Notifier = NotifierFunc(func(r *Request) error {
+		*sent = append(*sent, r)
+		return nil
+	})
+	return gate, applied, sent
+}
+
+// TestGateRequiresApprovals tests that covered proposals wait for enough
+// distinct overseers
+func TestGateRequiresApprovals(t *testing.T) {
+	testenv.RequireSigning(t)
+	gate, applied, sent := testGate(t)
+
+	reversible := irreversibleProposal("reversible")
+	reversible.ReversibilityClass = "reversible"
+	if _, err := gate.Execute(reversible); err != nil || len(*applied) != 1 {
+		t.Fatalf("Expected an uncovered proposal to pass through: %v", err)
+	}
+
+	p := irreversibleProposal("p")
+	for i := 0; i < 2; i++ {
+		if _, err := gate.Execute(p); !errors.Is(err, ErrPending) {
+			t.Fatalf("Expected the proposal to await approval, got %v", err)
+		}
+	}
+	if len(*sent) != 1 || (*sent)[0].Required != 2 || (*sent)[0].RequestedAt != "2025-11-20T14:45:00Z" {
+		t.Fatalf("Expected one request to be sent, got %+v", *sent)
+	}
+	req := (*sent)[0]
+	if len(gate.Pending()) != 1 {
+		t.Errorf("Expected one pending request, got %d", len(gate.Pending()))
+	}
+
+	// The same overseer twice is one approval
+	gate.Submit(decide(t, req, "alice", Approve))
+	gate.Submit(decide(t, req, "alice", Approve))
+	if _, err := gate.Execute(p); !errors.Is(err, ErrPending) {
+		t.Fatalf("Expected one overseer to be insufficient, got %v", err)
+	}
+
+	// An approval for another state does not count
+	stale := decide(t, req, "bob", Approve)
+	stale.PreStateHash = "sha256:other"
+	_, priv := overseerKey("bob")
+	stale.Sign(priv)
+	gate.Submit(stale)
+	if _, err := gate.Execute(p); !errors.Is(err, ErrPending) {
+		t.Fatalf("Expected an approval for another state not to count, got %v", err)
+	}
+
+	if err := gate.Submit(decide(t, req, "bob", Approve)); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if _, err := gate.Execute(p); err != nil {
+		t.Fatalf("Expected two approvals to release the proposal: %v", err)
+	}
+	if len(*applied) != 2 || len(gate.Pending()) != 0 {
+		t.Errorf("Expected one gated execution and no pending requests: %v", *applied)
+	}
+
+	// Approvals are consumed by the execution
+	if _, err := gate.Execute(p); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected approvals to be consumed, got %v", err)
+	}
+	t.Logf("✓ Executed after approvals by 2 overseers")
+}
+
+// TestGateRejection tests that one rejection refuses the proposal and that
+// unverified decisions are not recorded
+func TestGateRejection(t *testing.T) {
+	testenv.RequireSigning(t)
+	gate, applied, sent := testGate(t)
+	p := irreversibleProposal("p")
+	gate.Execute(p)
+	req := (*sent)[0]
+
+	forged := decide(t, req, "mallory", Approve)
+	if err := gate.Submit(forged); err == nil {
+		t.Error("Expected an unregistered overseer's decision to be refused")
+	}
+
+	gate.Submit(decide(t, req, "alice", Approve))
+	gate.Submit(decide(t, req, "bob", Approve))
+	gate.Submit(decide(t, req, "carol", Reject))
+	if _, err := gate.Execute(p); !errors.Is(err, ErrRejected) {
+		t.Fatalf("Expected a rejection to refuse the proposal, got %v", err)
+	}
+	if len(*applied) != 0 {
+		t.Errorf("Expected no execution, got %v", *applied)
+	}
+	t.Log("✓ Rejected proposal refused despite two approvals")
+}
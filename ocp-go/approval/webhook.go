@@ -0,0 +1,157 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// EventRequested is the webhook event kind of approval requests
+const EventRequested = "approval_requested"
+
+// MaxApprovalSize bounds the body of a submitted approval
+const MaxApprovalSize = 64 << 10
+
+// BuildWebhookPayload returns the webhook body for r, in the same form as
+// lifecycle webhooks, so receivers authenticate it with ocp.VerifyWebhook
+// and decode the request from its canonical bytes with DecodeRequest
+func BuildWebhookPayload(r *Request) ([]byte, error) {
+	canonicalString, err := ocp.Canonicalize(r.ToMap(), true)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ocp.WebhookPayload{
+		Event:     EventRequested,
+		Canonical: canonicalString,
+		Hash:      ocp.ContentHash([]byte(canonicalString)),
+	})
+}
+
+// DecodeRequest decodes a request from its canonical or plain JSON form and
+// checks it
+func DecodeRequest(data []byte) (*Request, error) {
+	var r Request
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, ocp.NewVerificationError(fmt.Sprintf("approval request is not JSON: %v", err))
+	}
+	if err := r.Check(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// WebhookNotifier delivers approval requests to HMAC-authenticated webhooks
+type WebhookNotifier struct {
+	Hooks  []ocp.Webhook
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier with a bounded request timeout
+func NewWebhookNotifier(hooks ...ocp.Webhook) *WebhookNotifier {
+	return &WebhookNotifier{
+		Hooks:  hooks,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify delivers r to every hook that wants EventRequested. All hooks are
+// attempted; failures are joined into the returned error.
+func (n *WebhookNotifier) Notify(r *Request) error {
+	body, err := BuildWebhookPayload(r)
+	if err != nil {
+		return err
+	}
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var errs []error
+	for _, hook := range n.Hooks {
+		if len(hook.Events) > 0 && !contains(hook.Events, EventRequested) {
+			continue
+		}
+		if err := deliver(client, hook, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func deliver(client *http.Client, hook ocp.Webhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", hook.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ocp.WebhookEventHeader, EventRequested)
+	req.Header.Set(ocp.WebhookSignatureHeader, ocp.SignWebhookBody(hook.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", hook.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: unexpected status %s", hook.URL, strings.TrimSpace(resp.Status))
+	}
+	return nil
+}
+
+// Handler serves the gate to overseers: GET lists the pending requests and
+// POST submits a signed Approval as JSON. A decision that does not verify is
+// refused with 403.
+func Handler(g *Gate) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			pending := g.Pending()
+			out := make([]interface{}, len(pending))
+			for i, req := range pending {
+				out[i] = req.ToMap()
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"pending": out})
+		case http.MethodPost:
+			body, err := io.ReadAll(io.LimitReader(r.Body, MaxApprovalSize+1))
+			if err != nil || len(body) > MaxApprovalSize {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "approval body too large or unreadable"})
+				return
+			}
+			var a Approval
+			if err := json.Unmarshal(body, &a); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			if err := g.Submit(&a); err != nil {
+				writeJSON(w, http.StatusForbidden, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			hash, _ := a.Hash()
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{"approval_hash": hash, "proposal_hash": a.ProposalHash})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
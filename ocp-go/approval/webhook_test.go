@@ -0,0 +1,106 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestWebhookNotifier tests that requests arrive authenticated and decodable
+func TestWebhookNotifier(t *testing.T) {
+	secret := []byte("overseer-secret")
+	var received *Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		payload, err := ocp.VerifyWebhook(secret, body, r.Header.Get(ocp.WebhookSignatureHeader))
+		if err != nil || r.Header.Get(ocp.WebhookEventHeader) != EventRequested {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received, err = DecodeRequest([]byte(payload.Canonical))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	notifier := NewWebhookNotifier(
+		ocp.Webhook{URL: srv.URL, Secret: secret},
+		ocp.Webhook{URL: srv.URL, Secret: secret, Events: []string{"challenged"}},
+	)
+	req, _ := NewRequest(irreversibleProposal("p"), 1, "2025-11-20T14:45:00Z")
+	if err := notifier.Notify(req); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if received == nil || received.ProposalHash != req.ProposalHash {
+		t.Fatalf("Expected the request to arrive intact, got %+v", received)
+	}
+
+	notifier.Hooks[0].Secret = []byte("wrong")
+	if err := notifier.Notify(req); err == nil {
+		t.Error("Expected a delivery the receiver cannot authenticate to fail")
+	}
+	t.Log("✓ Approval request delivered and verified")
+}
+
+// TestHandler tests listing pending requests and submitting decisions
+func TestHandler(t *testing.T) {
+	testenv.RequireSigning(t)
+	gate, applied, _ := testGate(t)
+	srv := httptest.NewServer(Handler(gate))
+	defer srv.Close()
+
+	p := irreversibleProposal("p")
+	gate.Execute(p)
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listing struct {
+		Pending []json.RawMessage `json:"pending"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listing)
+	resp.Body.Close()
+	if len(listing.Pending) != 1 {
+		t.Fatalf("Expected one pending request, got %d", len(listing.Pending))
+	}
+	req, err := DecodeRequest(listing.Pending[0])
+	if err != nil {
+		t.Fatalf("Expected the listed request to check: %v", err)
+	}
+
+	post := func(a *Approval) int {
+		body, _ := json.Marshal(a)
+		resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post(decide(t, req, "mallory", Approve)); status != http.StatusForbidden {
+		t.Errorf("Expected an unregistered overseer to be refused, got %d", status)
+	}
+	for _, name := range []string{"alice", "carol"} {
+		if status := post(decide(t, req, name, Approve)); status != http.StatusAccepted {
+			t.Fatalf("Expected %s's approval to be accepted, got %d", name, status)
+		}
+	}
+	if _, err := gate.Execute(p); err != nil || len(*applied) != 1 {
+		t.Fatalf("Expected approvals submitted over HTTP to release the proposal: %v", err)
+	}
+	if _, err := gate.Execute(p); !errors.Is(err, ErrPending) {
+		t.Errorf("Expected approvals to be consumed, got %v", err)
+	}
+	t.Log("✓ Decisions submitted over HTTP")
+}
@@ -0,0 +1,169 @@
+// archive.go - Content-addressed evidence archive
+//
+// The archive stores immutable blobs keyed by the SHA256 of their bytes. Objects are
+// stored in canonical form, so an object's archive key is its semantic hash.
+
+package ocp
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// ErrNotArchived is returned when a hash is not present in an archive
+var ErrNotArchived = &ConstitutionalError{ErrorType: "ArchiveError", Message: "object not archived"}
+
+// Archive is an immutable, content-addressed blob store
+type Archive interface {
+	// Put stores data and returns its hex-encoded SHA256
+	Put(data []byte) (string, error)
+	// Get returns the data stored under hash, or ErrNotArchived
+	Get(hash string) ([]byte, error)
+	// Has reports whether hash is stored
+	Has(hash string) (bool, error)
+}
+
+// ContentHash returns the archive key of data
+func ContentHash(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// ArchiveObject stores the canonical form of obj.
+//
+// Returns:
+//   - Semantic hash of obj, which is also its archive key
+func ArchiveObject(a Archive, obj map[string]interface{}) (string, error) {
+	canonicalString, err := canonical.Canonicalize(obj, true)
+	if err != nil {
+		return "", err
+	}
+	return a.Put([]byte(canonicalString))
+}
+
+// LoadObject retrieves and decodes an object stored with ArchiveObject
+func LoadObject(a Archive, hash string) (map[string]interface{}, error) {
+	data, err := a.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived object %s is not strict JSON: %v", hash, err))
+	}
+	return obj, nil
+}
+
+// MemoryArchive is an in-memory Archive for tests and single-process nodes
+type MemoryArchive struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryArchive creates an empty MemoryArchive
+func NewMemoryArchive() *MemoryArchive {
+	return &MemoryArchive{blobs: make(map[string][]byte)}
+}
+
+// Put stores a copy of data under its content hash
+func (m *MemoryArchive) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[hash]; !ok {
+		m.blobs[hash] = append([]byte(nil), data...)
+	}
+	return hash, nil
+}
+
+// Get returns a copy of the data stored under hash
+func (m *MemoryArchive) Get(hash string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.blobs[hash]
+	if !ok {
+		return nil, ErrNotArchived
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Has reports whether hash is stored
+func (m *MemoryArchive) Has(hash string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.blobs[hash]
+	return ok, nil
+}
+
+// FileArchive is an Archive storing one file per blob in a directory
+type FileArchive struct {
+	dir string
+}
+
+// NewFileArchive opens (creating if needed) a FileArchive rooted at dir
+func NewFileArchive(dir string) (*FileArchive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileArchive{dir: dir}, nil
+}
+
+// Put writes data to a temporary file and renames it into place, so readers
+// never observe a partially written blob
+func (f *FileArchive) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+	if ok, err := f.Has(hash); err != nil || ok {
+		return hash, err
+	}
+	tmp, err := os.CreateTemp(f.dir, ".put-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(tmp.Name(), f.path(hash))
+}
+
+// Get reads the blob stored under hash and checks it against its key
+func (f *FileArchive) Get(hash string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotArchived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ContentHash(data) != hash {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s is corrupted", hash))
+	}
+	return data, nil
+}
+
+// Has reports whether hash is stored
+func (f *FileArchive) Has(hash string) (bool, error) {
+	_, err := os.Stat(f.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (f *FileArchive) path(hash string) string {
+	return filepath.Join(f.dir, filepath.Base(hash))
+}
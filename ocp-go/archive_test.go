@@ -0,0 +1,85 @@
+package ocp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestArchiveObjectRoundTrip tests that archive keys equal semantic hashes
+func TestArchiveObjectRoundTrip(t *testing.T) {
+	archive := NewMemoryArchive()
+	obj := map[string]interface{}{
+		"claim":     "The initial cost is $500",
+		"timestamp": float64(1700000000),
+	}
+
+	hash, err := ArchiveObject(archive, obj)
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+
+	expected, _ := SemanticHash(obj)
+	if hash != expected {
+		t.Errorf("Archive key should equal semantic hash:\n  %s\n  %s", hash, expected)
+	}
+
+	loaded, err := LoadObject(archive, hash)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if !CanonicallyEqual(obj, loaded) {
+		t.Errorf("Loaded object should be canonically equal to the original")
+	}
+
+	if _, err := archive.Get("0000"); !errors.Is(err, ErrNotArchived) {
+		t.Errorf("Missing hash should return ErrNotArchived, got %v", err)
+	}
+
+	t.Logf("✓ Archived object %s", hash)
+}
+
+// TestLoadObjectStrict tests that archived blobs with duplicate keys are rejected
+func TestLoadObjectStrict(t *testing.T) {
+	archive := NewMemoryArchive()
+	hash, err := archive.Put([]byte(`{"a":1,"a":2}`))
+	if err != nil {
+		t.Fatalf("Failed to put blob: %v", err)
+	}
+
+	if _, err := LoadObject(archive, hash); err == nil {
+		t.Errorf("Duplicate keys should be rejected on load")
+	}
+
+	t.Logf("✓ Ambiguous archived object rejected")
+}
+
+// TestFileArchive tests persistence and corruption detection of a FileArchive
+func TestFileArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+
+	hash, err := ArchiveObject(archive, map[string]interface{}{"claim": "persisted"})
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+
+	reopened, _ := NewFileArchive(dir)
+	if _, err := LoadObject(reopened, hash); err != nil {
+		t.Errorf("Object should survive reopening: %v", err)
+	}
+	if _, err := reopened.Get("0000"); !errors.Is(err, ErrNotArchived) {
+		t.Errorf("Missing hash should return ErrNotArchived, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, hash), []byte(`{"claim":"altered"}`), 0o644)
+	if _, err := reopened.Get(hash); err == nil {
+		t.Errorf("Corrupted blob should be rejected")
+	}
+
+	t.Logf("✓ File archive round trip and corruption check for %s", hash)
+}
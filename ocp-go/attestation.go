@@ -0,0 +1,205 @@
+// attestation.go - in-toto / SLSA attestations for ratified amendments
+//
+// Organizations already verify software supply chains with in-toto statements and
+// SLSA provenance. Wrapping ratified amendments in the same format lets them feed
+// constitutional changes into that infrastructure: the subject digest is the
+// proposal's semantic hash, and the provenance records how it was ratified.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Attestation type identifiers
+const (
+	InTotoStatementType = "https://in-toto.io/Statement/v1"
+	SLSAProvenanceType  = "https://slsa.dev/provenance/v1"
+	AmendmentBuildType  = "https://github.com/seanrugg/ai_constitution/ocp/amendment/v1"
+	DSSEPayloadType     = "application/vnd.in-toto+json"
+)
+
+// InTotoSubject identifies an attested artifact by digest
+type InTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// InTotoStatement is an in-toto v1 Statement
+type InTotoStatement struct {
+	Type          string                 `json:"_type"`
+	Subject       []InTotoSubject        `json:"subject"`
+	PredicateType string                 `json:"predicateType"`
+	Predicate     map[string]interface{} `json:"predicate"`
+}
+
+// ToMap converts the statement to a map for canonicalization
+func (s *InTotoStatement) ToMap() map[string]interface{} {
+	subjects := make([]interface{}, len(s.Subject))
+	for i, subj := range s.Subject {
+		digest := make(map[string]interface{}, len(subj.Digest))
+		for algo, value := range subj.Digest {
+			digest[algo] = value
+		}
+		subjects[i] = map[string]interface{}{"name": subj.Name, "digest": digest}
+	}
+	return map[string]interface{}{
+		"_type":         s.Type,
+		"subject":       subjects,
+		"predicateType": s.PredicateType,
+		"predicate":     s.Predicate,
+	}
+}
+
+// Ratification describes how an amendment was accepted
+type Ratification struct {
+	// BuilderID identifies the node or quorum that ratified the amendment
+	BuilderID       string
+	LedgerHeight    uint64
+	LedgerEntryHash string
+	RatifiedAt      string
+	// Signers are the quorum members whose signatures ratified the amendment
+	Signers []string
+}
+
+// AttestAmendment wraps a ratified amendment in an in-toto Statement with an
+// SLSA provenance predicate.
+//
+// Parameters:
+//   - p: Ratified proposal; its action type must be "amend"
+//   - r: Ratification details recorded as provenance
+//
+// Returns:
+//   - Statement whose subject digest is the proposal's semantic hash
+func AttestAmendment(p *ContractProposal, r Ratification) (*InTotoStatement, error) {
+	if p.ActionType != "amend" {
+		return nil, NewConstitutionalError(fmt.Sprintf("only amendments can be attested, got %q", p.ActionType))
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	signers := make([]interface{}, len(r.Signers))
+	for i, s := range r.Signers {
+		signers[i] = s
+	}
+
+	predicate := map[string]interface{}{
+		"buildDefinition": map[string]interface{}{
+			"buildType": AmendmentBuildType,
+			"externalParameters": map[string]interface{}{
+				"proposer_agent":      p.ProposerAgent,
+				"action_type":         p.ActionType,
+				"action":              p.Action,
+				"reversibility_class": p.ReversibilityClass,
+			},
+			"internalParameters": map[string]interface{}{
+				"ledger_height": r.LedgerHeight,
+				"signers":       signers,
+			},
+			"resolvedDependencies": []interface{}{
+				stateDescriptor("ocp:state:pre", p.PreStateHash),
+			},
+		},
+		"runDetails": map[string]interface{}{
+			"builder": map[string]interface{}{"id": r.BuilderID},
+			"metadata": map[string]interface{}{
+				"invocationId": r.LedgerEntryHash,
+				"finishedOn":   r.RatifiedAt,
+			},
+			"byproducts": []interface{}{
+				stateDescriptor("ocp:state:post", p.PostStateHash),
+			},
+		},
+	}
+
+	return &InTotoStatement{
+		Type: InTotoStatementType,
+		Subject: []InTotoSubject{{
+			Name:   "ocp:proposal:" + p.ID,
+			Digest: map[string]string{HashAlgorithm: hash},
+		}},
+		PredicateType: SLSAProvenanceType,
+		Predicate:     predicate,
+	}, nil
+}
+
+// stateDescriptor builds an SLSA ResourceDescriptor for a "sha256:<hex>" state hash
+func stateDescriptor(uri, stateHash string) map[string]interface{} {
+	return map[string]interface{}{
+		"uri":    uri,
+		"digest": map[string]interface{}{HashAlgorithm: strings.TrimPrefix(stateHash, HashAlgorithm+":")},
+	}
+}
+
+// DSSESignature is a signature within a DSSE envelope
+type DSSESignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// DSSEEnvelope is a Dead Simple Signing Envelope carrying a statement
+type DSSEEnvelope struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []DSSESignature `json:"signatures"`
+}
+
+// dssePAE is the DSSE pre-authentication encoding
+func dssePAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// SignStatement wraps the canonical form of a statement in a DSSE envelope signed with Ed25519
+func SignStatement(s *InTotoStatement, keyID string, key ed25519.PrivateKey) (*DSSEEnvelope, error) {
+	if VerifyOnlyBuild {
+		return nil, ErrVerifyOnly
+	}
+	payload, err := Canonicalize(s.ToMap(), true)
+	if err != nil {
+		return nil, err
+	}
+	sig := ed25519.Sign(key, dssePAE(DSSEPayloadType, []byte(payload)))
+	return &DSSEEnvelope{
+		PayloadType: DSSEPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString([]byte(payload)),
+		Signatures:  []DSSESignature{{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// VerifyEnvelope checks the envelope signature by keyID and decodes the statement
+func VerifyEnvelope(env *DSSEEnvelope, keyID string, key ed25519.PublicKey) (*InTotoStatement, error) {
+	if env.PayloadType != DSSEPayloadType {
+		return nil, NewVerificationError(fmt.Sprintf("unexpected payload type %q", env.PayloadType))
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, NewVerificationError(fmt.Sprintf("payload is not base64: %v", err))
+	}
+
+	verified := false
+	for _, s := range env.Signatures {
+		if s.KeyID != keyID {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && ed25519.Verify(key, dssePAE(env.PayloadType, payload), sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, NewVerificationError(fmt.Sprintf("no valid signature from key %q", keyID))
+	}
+
+	var statement InTotoStatement
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return nil, NewVerificationError(fmt.Sprintf("payload is not a statement: %v", err))
+	}
+	return &statement, nil
+}
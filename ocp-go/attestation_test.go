@@ -0,0 +1,82 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestAttestAmendment tests statement construction for a ratified amendment
+func TestAttestAmendment(t *testing.T) {
+	proposal := testProposal()
+	proposal.PreStateHash = "sha256:1234"
+	proposal.PostStateHash = "sha256:5678"
+
+	statement, err := AttestAmendment(proposal, Ratification{
+		BuilderID:       "ocp-node://quorum-1",
+		LedgerHeight:    42,
+		LedgerEntryHash: "feedbeef",
+		RatifiedAt:      "2025-11-21T09:00:00Z",
+		Signers:         []string{"Claude", "Gemini"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to attest: %v", err)
+	}
+
+	hash, _ := proposal.GetHash()
+	if statement.Subject[0].Digest["sha256"] != hash {
+		t.Errorf("Subject digest should be the proposal hash")
+	}
+	if statement.Type != InTotoStatementType || statement.PredicateType != SLSAProvenanceType {
+		t.Errorf("Unexpected statement types: %s, %s", statement.Type, statement.PredicateType)
+	}
+
+	byproducts := statement.Predicate["runDetails"].(map[string]interface{})["byproducts"].([]interface{})
+	digest := byproducts[0].(map[string]interface{})["digest"].(map[string]interface{})
+	if digest["sha256"] != "5678" {
+		t.Errorf("Post-state digest should drop the algorithm prefix, got %v", digest["sha256"])
+	}
+
+	proposal.ActionType = "approve"
+	if _, err := AttestAmendment(proposal, Ratification{}); err == nil {
+		t.Errorf("Non-amendments should not be attested")
+	}
+
+	t.Logf("✓ Statement subject %s", statement.Subject[0].Name)
+}
+
+// TestSignStatementRoundTrip tests DSSE signing and verification
+func TestSignStatementRoundTrip(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("quorum-1")
+
+	statement, err := AttestAmendment(testProposal(), Ratification{BuilderID: "ocp-node://quorum-1"})
+	if err != nil {
+		t.Fatalf("Failed to attest: %v", err)
+	}
+
+	envelope, err := SignStatement(statement, "quorum-1", priv)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	decoded, err := VerifyEnvelope(envelope, "quorum-1", pub)
+	if err != nil {
+		t.Fatalf("Envelope should verify: %v", err)
+	}
+	if !CanonicallyEqual(decoded.ToMap(), statement.ToMap()) {
+		t.Errorf("Decoded statement should match the original")
+	}
+
+	otherPub, _ := testKey("other")
+	if _, err := VerifyEnvelope(envelope, "quorum-1", otherPub); err == nil {
+		t.Errorf("Envelope should not verify under another key")
+	}
+
+	envelope.Payload = envelope.Payload[:len(envelope.Payload)-4] + "AAAA"
+	if _, err := VerifyEnvelope(envelope, "quorum-1", pub); err == nil {
+		t.Errorf("Modified payload should fail verification")
+	}
+
+	t.Logf("✓ DSSE envelope verifies")
+}
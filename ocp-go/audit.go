@@ -0,0 +1,224 @@
+// audit.go - Canonical random sampling of ledger entries for spot audits
+//
+// Re-verifying a long ledger in full is more than most auditors will do, but
+// a random sample is only persuasive if the node being audited could not
+// have chosen it. SampleHeights derives the sample from a seed published by
+// a randomness beacon after the entries were written, so every auditor given
+// the same seed and ledger height independently picks the same heights, and
+// neither the node nor any one auditor can steer the choice.
+//
+// Heights are drawn without replacement from SHA-256 of the seed, the ledger
+// height, and a counter, with rejection sampling so every height is equally
+// likely. The sample depends only on the seed and height, never on entry
+// contents, so auditors holding diverging copies of the ledger still audit
+// the same heights. AuditSample re-verifies each sampled entry and records
+// the outcome in an AuditReport that the auditor signs.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SampleHeights deterministically selects min(n, height) distinct heights
+// in [1, height] from seed
+//
+// Returns:
+//   - Sampled heights in ascending order
+func SampleHeights(seed string, height uint64, n int) []uint64 {
+	if n <= 0 || height == 0 {
+		return nil
+	}
+	if uint64(n) > height {
+		n = int(height)
+	}
+	// Reject draws from the incomplete final cycle of the modulus
+	limit := ^uint64(0) - (^uint64(0)%height+1)%height
+	chosen := make(map[uint64]bool, n)
+	out := make([]uint64, 0, n)
+	prefix := seed + "\x00" + strconv.FormatUint(height, 10) + "\x00"
+	for counter := uint64(0); len(out) < n; counter++ {
+		digest := sha256.Sum256([]byte(prefix + strconv.FormatUint(counter, 10)))
+		draw := binary.BigEndian.Uint64(digest[:8])
+		if draw > limit {
+			continue
+		}
+		h := draw%height + 1
+		if !chosen[h] {
+			chosen[h] = true
+			out = append(out, h)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// SampleForAudit returns the entries at SampleHeights(seed, l.Height(), n)
+// that the ledger holds, in height order. Sampled heights below a pruned
+// ledger's base are omitted.
+func (l *Ledger) SampleForAudit(seed string, n int) []LedgerEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	height, _ := l.headLocked()
+	var out []LedgerEntry
+	for _, h := range SampleHeights(seed, height, n) {
+		if h > l.baseHeight {
+			out = append(out, l.entries[h-l.baseHeight-1])
+		}
+	}
+	return out
+}
+
+// AuditResult is the outcome of re-verifying one sampled entry
+type AuditResult struct {
+	Height uint64 `json:"height"`
+	// EntryHash is the hash the entry records, empty if it was not held
+	EntryHash string   `json:"entry_hash"`
+	Passed    bool     `json:"passed"`
+	Problems  []string `json:"problems"`
+}
+
+// ToMap converts an AuditResult to a map for canonicalization
+func (r AuditResult) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"height":     r.Height,
+		"entry_hash": r.EntryHash,
+		"passed":     r.Passed,
+		"problems":   stringList(r.Problems),
+	}
+}
+
+// AuditReport records a spot audit of a ledger: the seed and height that
+// fixed the sample and the re-verification result of each sampled entry
+type AuditReport struct {
+	Auditor    string        `json:"auditor"`
+	Seed       string        `json:"seed"`
+	Height     uint64        `json:"height"`
+	HeadHash   string        `json:"head_hash"`
+	SampleSize int           `json:"sample_size"`
+	Results    []AuditResult `json:"results"`
+	Signature  Signature     `json:"signature"`
+}
+
+// ToMap converts an AuditReport to a map for canonicalization. The signature
+// is excluded, since it signs this form.
+func (r *AuditReport) ToMap() map[string]interface{} {
+	results := make([]interface{}, len(r.Results))
+	for i, res := range r.Results {
+		results[i] = res.ToMap()
+	}
+	return map[string]interface{}{
+		"auditor":     r.Auditor,
+		"seed":        r.Seed,
+		"height":      r.Height,
+		"head_hash":   r.HeadHash,
+		"sample_size": r.SampleSize,
+		"results":     results,
+	}
+}
+
+// Hash returns the semantic hash of the report
+func (r *AuditReport) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// Passed reports whether every sampled entry re-verified
+func (r *AuditReport) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Sign signs the report as its auditor
+func (r *AuditReport) Sign(key ed25519.PrivateKey) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	r.Signature, err = SignHash(r.Auditor, key, ContextAudit, hash)
+	return err
+}
+
+// Verify checks the report's signature against the auditor's key and that
+// its results cover exactly the heights its seed selects
+func (r *AuditReport) Verify(key ed25519.PublicKey) error {
+	if r.Signature.Signer != r.Auditor {
+		return NewVerificationError(fmt.Sprintf("audit by %s signed by %s", r.Auditor, r.Signature.Signer))
+	}
+	heights := SampleHeights(r.Seed, r.Height, r.SampleSize)
+	if len(heights) != len(r.Results) {
+		return NewVerificationError(fmt.Sprintf("audit reports %d results for a sample of %d", len(r.Results), len(heights)))
+	}
+	for i, h := range heights {
+		if r.Results[i].Height != h {
+			return NewVerificationError(fmt.Sprintf("audit result %d is for height %d, the seed selects %d", i, r.Results[i].Height, h))
+		}
+	}
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextAudit, hash, r.Signature)
+}
+
+// AuditSample re-verifies the entries of ledger that seed selects. Each
+// entry's hash is recomputed, its link checked against the preceding entry
+// if held, and, given a quorum, its signatures checked as by
+// VerifyEntrySignatures. A sampled entry the ledger does not hold fails.
+//
+// Parameters:
+//   - ledger: Ledger under audit
+//   - seed: Beacon output fixing the sample
+//   - n: Sample size
+//   - quorum: Quorum for signed entry kinds, or nil to skip signature checks
+//   - auditor: Agent the report is attributed to; the caller signs it
+//
+// Returns:
+//   - Unsigned audit report, with one result per sampled height
+func AuditSample(ledger *Ledger, seed string, n int, quorum *Quorum, auditor string) (*AuditReport, error) {
+	entries := ledger.Entries(0)
+	height, head := ledger.Height(), ledger.Head()
+	if len(entries) > 0 {
+		height, head = entries[len(entries)-1].Height, entries[len(entries)-1].Hash
+	}
+	report := &AuditReport{Auditor: auditor, Seed: seed, Height: height, HeadHash: head, SampleSize: n, Results: []AuditResult{}}
+	base := height - uint64(len(entries))
+
+	for _, h := range SampleHeights(seed, height, n) {
+		result := AuditResult{Height: h, Problems: []string{}}
+		if h <= base {
+			result.Problems = append(result.Problems, "entry is not held by the ledger")
+			report.Results = append(report.Results, result)
+			continue
+		}
+		i := int(h - base - 1)
+		e := entries[i]
+		result.EntryHash = e.Hash
+		recomputed, err := e.ComputeHash()
+		if err != nil {
+			return nil, err
+		}
+		if recomputed != e.Hash {
+			result.Problems = append(result.Problems, fmt.Sprintf("entry records hash %s, content hashes to %s", e.Hash, recomputed))
+		}
+		if i > 0 && entries[i-1].Hash != e.PrevHash {
+			result.Problems = append(result.Problems, fmt.Sprintf("entry does not link to entry %d", h-1))
+		}
+		if quorum != nil {
+			if err := VerifyEntrySignatures(entries[:i], entries[i:i+1], quorum); err != nil {
+				result.Problems = append(result.Problems, err.Error())
+			}
+		}
+		result.Passed = len(result.Problems) == 0
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
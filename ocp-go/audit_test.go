@@ -0,0 +1,81 @@
+package ocp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSampleHeights tests that samples are deterministic, distinct, and
+// depend on the seed and height
+func TestSampleHeights(t *testing.T) {
+	a := SampleHeights("beacon-round-4821", 1000, 20)
+	if !reflect.DeepEqual(a, SampleHeights("beacon-round-4821", 1000, 20)) {
+		t.Fatal("The same seed and height should select the same sample")
+	}
+	if len(a) != 20 {
+		t.Fatalf("Expected 20 heights, got %d", len(a))
+	}
+	for i, h := range a {
+		if h < 1 || h > 1000 || (i > 0 && h <= a[i-1]) {
+			t.Fatalf("Heights should be distinct, ascending, and in range: %v", a)
+		}
+	}
+	if reflect.DeepEqual(a, SampleHeights("beacon-round-4822", 1000, 20)) {
+		t.Error("Another seed should select another sample")
+	}
+	if reflect.DeepEqual(a, SampleHeights("beacon-round-4821", 1001, 20)) {
+		t.Error("Another height should select another sample")
+	}
+	if all := SampleHeights("beacon-round-4821", 5, 10); !reflect.DeepEqual(all, []uint64{1, 2, 3, 4, 5}) {
+		t.Errorf("A sample larger than the ledger should cover it, got %v", all)
+	}
+	if SampleHeights("beacon-round-4821", 0, 10) != nil {
+		t.Error("An empty ledger has no sample")
+	}
+	t.Logf("✓ Sample %v", a[:5])
+}
+
+// TestAuditSample tests re-verification of sampled entries and signed reports
+func TestAuditSample(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := migrationLedger(t, 40)
+	seed := "beacon-round-4821"
+	sample := ledger.SampleForAudit(seed, 8)
+	if len(sample) != 8 {
+		t.Fatalf("Expected 8 sampled entries, got %d", len(sample))
+	}
+
+	report, err := AuditSample(ledger, seed, 8, nil, "Claude")
+	if err != nil {
+		t.Fatalf("AuditSample failed: %v", err)
+	}
+	if !report.Passed() || len(report.Results) != 8 || report.Results[0].EntryHash != sample[0].Hash {
+		t.Fatalf("Expected an intact ledger to pass: %+v", report.Results)
+	}
+
+	// Tamper with a sampled entry
+	target := sample[3].Height
+	ledger.entries[target-1].Payload["proposal_hash"] = "forged"
+	report, err = AuditSample(ledger, seed, 8, nil, "Claude")
+	if err != nil {
+		t.Fatalf("AuditSample failed: %v", err)
+	}
+	if report.Passed() || report.Results[3].Passed || len(report.Results[3].Problems) == 0 {
+		t.Errorf("Expected the tampered entry to fail: %+v", report.Results[3])
+	}
+
+	pub, priv := testKey("Claude")
+	if err := report.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := report.Verify(pub); err != nil {
+		t.Errorf("Expected the report to verify, got %v", err)
+	}
+	report.Results = report.Results[1:]
+	if err := report.Verify(pub); err == nil {
+		t.Error("A report omitting a sampled height should not verify")
+	}
+	t.Logf("✓ Tampering at height %d found by the audit", target)
+}
@@ -0,0 +1,204 @@
+// buildinfo.go - Capabilities compiled into this library
+//
+// Nodes in a fleet upgrade at different times. Before exchanging objects they
+// exchange a FeatureSet naming the spec revision, canonicalization versions,
+// hash algorithms, and signature schemes each side supports, so that a node
+// which would compute different hashes or reject a signature scheme is turned
+// away at the handshake rather than producing disagreements later.
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// SpecRevision is the protocol specification revision this library implements
+const SpecRevision = "OCP-0001/0.1"
+
+// FeatureSet describes the protocol capabilities of a build
+type FeatureSet struct {
+	ModuleVersion string `json:"module_version"`
+	SpecRevision  string `json:"spec_revision"`
+	// DefaultCanonical is the canonicalization version used for hashing
+	DefaultCanonical  string   `json:"default_canonical"`
+	CanonicalVersions []string `json:"canonical_versions"`
+	HashAlgorithms    []string `json:"hash_algorithms"`
+	SignatureSchemes  []string `json:"signature_schemes"`
+	// VerifyOnly is set for builds with the ocp_verifyonly tag
+	VerifyOnly bool `json:"verify_only"`
+}
+
+// BuildInfo returns the capabilities of this build
+func BuildInfo() FeatureSet {
+	return FeatureSet{
+		ModuleVersion:     Version,
+		SpecRevision:      SpecRevision,
+		DefaultCanonical:  canonical.Default.Version(),
+		CanonicalVersions: canonical.SupportedVersions(),
+		HashAlgorithms:    []string{HashAlgorithm},
+		SignatureSchemes:  signatureSchemes(),
+		VerifyOnly:        VerifyOnlyBuild,
+	}
+}
+
+// signatureSchemes lists the signature schemes compiled into this build
+func signatureSchemes() []string {
+	if PrehashBuild {
+		return []string{SignatureAlgorithm, SignatureSchemePrehashed}
+	}
+	return []string{SignatureAlgorithm}
+}
+
+// ToMap converts a FeatureSet to a map for canonicalization
+func (f FeatureSet) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"module_version":     f.ModuleVersion,
+		"spec_revision":      f.SpecRevision,
+		"default_canonical":  f.DefaultCanonical,
+		"canonical_versions": stringList(f.CanonicalVersions),
+		"hash_algorithms":    stringList(f.HashAlgorithms),
+		"signature_schemes":  stringList(f.SignatureSchemes),
+		"verify_only":        f.VerifyOnly,
+	}
+}
+
+// FeatureSetFromMap reads a FeatureSet received from a peer
+func FeatureSetFromMap(m map[string]interface{}) FeatureSet {
+	verifyOnly, _ := m["verify_only"].(bool)
+	return FeatureSet{
+		ModuleVersion:     payloadString(m, "module_version"),
+		SpecRevision:      payloadString(m, "spec_revision"),
+		DefaultCanonical:  payloadString(m, "default_canonical"),
+		CanonicalVersions: payloadStrings(m, "canonical_versions"),
+		HashAlgorithms:    payloadStrings(m, "hash_algorithms"),
+		SignatureSchemes:  payloadStrings(m, "signature_schemes"),
+		VerifyOnly:        verifyOnly,
+	}
+}
+
+// Compatible reports whether objects this build produces can be verified by
+// peer: the spec revisions match, peer supports this build's default
+// canonicalization, and the two share a hash algorithm and signature scheme
+//
+// Returns:
+//   - nil, or a ConstitutionalError listing every mismatch
+func (f FeatureSet) Compatible(peer FeatureSet) error {
+	var problems []string
+	if f.SpecRevision != peer.SpecRevision {
+		problems = append(problems, fmt.Sprintf("spec revision %s, peer has %s", f.SpecRevision, peer.SpecRevision))
+	}
+	if !containsString(peer.CanonicalVersions, f.DefaultCanonical) {
+		problems = append(problems, fmt.Sprintf("peer does not support canonical version %s", f.DefaultCanonical))
+	}
+	if len(intersectStrings(f.HashAlgorithms, peer.HashAlgorithms)) == 0 {
+		problems = append(problems, fmt.Sprintf("no common hash algorithm in %v and %v", f.HashAlgorithms, peer.HashAlgorithms))
+	}
+	if len(intersectStrings(f.SignatureSchemes, peer.SignatureSchemes)) == 0 {
+		problems = append(problems, fmt.Sprintf("no common signature scheme in %v and %v", f.SignatureSchemes, peer.SignatureSchemes))
+	}
+	if len(problems) > 0 {
+		return &ConstitutionalError{ErrorType: "CompatibilityError", Message: strings.Join(problems, "; ")}
+	}
+	return nil
+}
+
+// NodeInfo identifies a node and its capabilities in handshakes
+type NodeInfo struct {
+	NodeID string     `json:"node_id"`
+	Build  FeatureSet `json:"build"`
+}
+
+// NewNodeInfo returns the handshake payload for this node
+func NewNodeInfo(nodeID string) NodeInfo {
+	return NodeInfo{NodeID: nodeID, Build: BuildInfo()}
+}
+
+// ToMap converts a NodeInfo to a map for canonicalization
+func (n NodeInfo) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"node_id": n.NodeID,
+		"build":   n.Build.ToMap(),
+	}
+}
+
+// NodeInfoFromMap reads a NodeInfo received from a peer
+func NodeInfoFromMap(m map[string]interface{}) NodeInfo {
+	build, _ := m["build"].(map[string]interface{})
+	return NodeInfo{NodeID: payloadString(m, "node_id"), Build: FeatureSetFromMap(build)}
+}
+
+// Verifiable reports whether this build can verify the objects peer
+// produces, which a reduced build may not: peer's default canonicalization
+// must be one this build implements
+//
+// Returns:
+//   - nil, a *CapabilityError if this build omits a feature peer's objects
+//     need, or a ConstitutionalError for a version it does not know
+func (f FeatureSet) Verifiable(peer FeatureSet) error {
+	if containsString(f.CanonicalVersions, peer.DefaultCanonical) {
+		return nil
+	}
+	if caps := canonical.VersionCapabilities(peer.DefaultCanonical); len(caps) > 0 {
+		return &CapabilityError{Capability: caps[0], Operation: "peer canonical version " + peer.DefaultCanonical}
+	}
+	return &ConstitutionalError{ErrorType: "CompatibilityError", Message: fmt.Sprintf("peer uses unknown canonical version %s", peer.DefaultCanonical)}
+}
+
+// CheckPeer checks a peer's handshake payload against this build: the peer
+// must verify this build's objects and this build must verify the peer's.
+// Its signature matches gossip.Config.AcceptPeer.
+func CheckPeer(info map[string]interface{}) error {
+	local, peer := BuildInfo(), NodeInfoFromMap(info).Build
+	if err := local.Compatible(peer); err != nil {
+		return err
+	}
+	return local.Verifiable(peer)
+}
+
+// payloadStrings reads a list of strings from a payload, skipping other values
+func payloadStrings(payload map[string]interface{}, key string) []string {
+	var out []string
+	switch v := payload[key].(type) {
+	case []string:
+		out = append(out, v...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func stringList(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectStrings(a, b []string) []string {
+	var out []string
+	for _, v := range a {
+		if containsString(b, v) {
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
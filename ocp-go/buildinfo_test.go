@@ -0,0 +1,39 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// TestBuildInfoCompatibility tests capability mismatch detection between builds
+func TestBuildInfoCompatibility(t *testing.T) {
+	info := NewNodeInfo("node-a")
+	if info.Build.SpecRevision != SpecRevision || info.Build.DefaultCanonical != canonical.Default.Version() {
+		t.Errorf("Unexpected build info: %+v", info.Build)
+	}
+
+	// Round trip through the handshake map form, as a peer receives it
+	form, err := CanonicalizeValue(info.ToMap())
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	decoded, _ := DecodeStrict([]byte(form))
+	if err := CheckPeer(decoded); err != nil {
+		t.Errorf("A build should be compatible with itself: %v", err)
+	}
+	if got := NodeInfoFromMap(decoded); got.NodeID != "node-a" || len(got.Build.CanonicalVersions) != len(canonical.SupportedVersions()) {
+		t.Errorf("Handshake round trip lost fields: %+v", got)
+	}
+
+	old := BuildInfo()
+	old.SpecRevision = "OCP-0001/0.0"
+	old.CanonicalVersions = []string{"0.9.0+key_order=utf8;nulls=keep"}
+	old.SignatureSchemes = []string{"rsa"}
+	err = BuildInfo().Compatible(old)
+	if err == nil {
+		t.Fatalf("Mismatched builds should be incompatible")
+	}
+
+	t.Logf("✓ Detected mismatch: %v", err)
+}
@@ -0,0 +1,245 @@
+// audit.go - Recording type coercions during canonicalization
+//
+// Canonical JSON knows only objects, arrays, strings, float64 numbers,
+// booleans, and null. Go values of any other type are converted on the way
+// in: integers become numbers, times become strings, structs become objects
+// through encoding/json. Most conversions are exact, but some are not (an
+// integer above 2^53 has no exact float64, a named time zone is reduced to an
+// offset), and a reviewer reading the canonical form cannot tell that a
+// conversion happened at all. CanonicalizeAudited returns the canonical form
+// together with every such coercion and the JSON path where it occurred, so
+// the author of a high-stakes proposal can confirm nothing was silently
+// transformed.
+
+package canonical
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// maxExactInteger is the largest magnitude every float64 integer can represent
+const maxExactInteger = 1 << 53
+
+// Coercion is a conversion of a non-JSON Go value during canonicalization
+type Coercion struct {
+	// Path locates the value in the input, in hashing.PathIndex syntax
+	Path string `json:"path"`
+	// From is the Go type of the value
+	From string `json:"from"`
+	// To is the JSON type it was encoded as
+	To string `json:"to"`
+	// Lossy reports that the canonical form does not preserve the value exactly
+	Lossy bool `json:"lossy"`
+	// Detail explains how the value was converted
+	Detail string `json:"detail,omitempty"`
+}
+
+// String renders the coercion for reports
+func (c Coercion) String() string {
+	path := c.Path
+	if path == "" {
+		path = "$"
+	}
+	s := fmt.Sprintf("%s: %s -> %s", path, c.From, c.To)
+	if c.Lossy {
+		s += " (lossy)"
+	}
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// AuditResult is a canonical form and the coercions made to produce it
+type AuditResult struct {
+	Canonical string     `json:"canonical"`
+	Coercions []Coercion `json:"coercions"`
+	// Reproducible reports that decoding Canonical and canonicalizing it again
+	// gives Canonical, as it must for another implementation to reach the same
+	// hash. Coercions can break this even when none is lossy: an array of Go
+	// ints is kept in input order, but decoded as numbers it is sorted.
+	Reproducible bool `json:"reproducible"`
+}
+
+// Lossy returns the coercions that did not preserve their value exactly
+func (r *AuditResult) Lossy() []Coercion {
+	var out []Coercion
+	for _, c := range r.Coercions {
+		if c.Lossy {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// CanonicalizeAudited canonicalizes value and records every type coercion.
+// See Canonicalizer.CanonicalizeAudited.
+func CanonicalizeAudited(value interface{}) (*AuditResult, error) {
+	return Default.CanonicalizeAudited(value)
+}
+
+// CanonicalizeAudited canonicalizes value like CanonicalizeValue and records
+// every value that was not already a JSON-native Go type, in input order
+//
+// Returns:
+//   - Canonical form, coercions, and whether the form survives a round trip;
+//     Coercions is empty, not nil, for input made only of JSON-native types
+func (c *Canonicalizer) CanonicalizeAudited(value interface{}) (*AuditResult, error) {
+	form, err := c.CanonicalizeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	result := &AuditResult{Canonical: form, Coercions: []Coercion{}}
+	auditValue(&result.Coercions, "", value)
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(form), &decoded); err != nil {
+		return nil, err
+	}
+	again, err := c.CanonicalizeValue(decoded)
+	if err != nil {
+		return nil, err
+	}
+	result.Reproducible = again == form
+	return result, nil
+}
+
+var (
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// auditValue records the coercions within v at path
+func auditValue(out *[]Coercion, path string, v interface{}) {
+	switch val := v.(type) {
+	case nil, string, float64, bool:
+		return
+	case map[string]interface{}:
+		for _, k := range sortedMapKeys(val) {
+			auditValue(out, diffPathKey(path, k), val[k])
+		}
+		return
+	case []interface{}:
+		for i, elem := range val {
+			auditValue(out, fmt.Sprintf("%s[%d]", path, i), elem)
+		}
+		return
+	case time.Time:
+		c := Coercion{Path: path, From: "time.Time", To: "string", Detail: "RFC 3339 with nanoseconds"}
+		if name := val.Location().String(); name != "UTC" {
+			c.Lossy, c.Detail = true, "RFC 3339 with nanoseconds; time zone "+name+" reduced to its offset"
+		}
+		*out = append(*out, c)
+		return
+	case Decimal, *Decimal:
+		*out = append(*out, Coercion{Path: path, From: fmt.Sprintf("%T", v), To: "string", Detail: "decimal written in canonical form"})
+		return
+	case json.Number:
+		c := Coercion{Path: path, From: "json.Number", To: "number", Detail: "digits emitted as written"}
+		if !canonicalLexeme(string(val)) {
+			c.Detail = "normalized through float64"
+			if !numberExact(val) {
+				c.Lossy, c.Detail = true, "normalized through float64, which does not hold its exact value"
+			}
+		}
+		*out = append(*out, c)
+		return
+	}
+
+	rv := reflect.ValueOf(v)
+	t := rv.Type()
+	c := Coercion{Path: path, From: t.String()}
+	switch {
+	case t.Implements(marshalerType) || t.Implements(textType):
+		c.To, c.Detail = "json", "encoded by the type's own MarshalJSON or MarshalText"
+		*out = append(*out, c)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.To = "number"
+		if n := rv.Int(); n > maxExactInteger || n < -maxExactInteger {
+			c.Lossy, c.Detail = true, "integer beyond 2^53 has no exact float64"
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		c.To = "number"
+		if rv.Uint() > maxExactInteger {
+			c.Lossy, c.Detail = true, "integer beyond 2^53 has no exact float64"
+		}
+	case reflect.Float32:
+		// The shortest float32 digits read back as float64 are a different
+		// number unless the value is exactly representable in both
+		c.To, c.Detail = "number", "printed with float32 digits"
+		f := rv.Float()
+		if parsed, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64); parsed != f {
+			c.Lossy, c.Detail = true, "printed with float32 digits, which read back as a different float64"
+		}
+	case reflect.Float64:
+		c.To, c.Detail = "number", "named float64 type"
+	case reflect.String:
+		c.To, c.Detail = "string", "named string type"
+	case reflect.Bool:
+		c.To, c.Detail = "boolean", "named bool type"
+	case reflect.Map:
+		c.To = "object"
+		if t.Key().Kind() != reflect.String {
+			c.Detail = "non-string keys converted to strings"
+		}
+		*out = append(*out, c)
+		iter := rv.MapRange()
+		entries := make(map[string]interface{}, rv.Len())
+		for iter.Next() {
+			entries[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+		for _, k := range sortedMapKeys(entries) {
+			auditValue(out, diffPathKey(path, k), entries[k])
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			c.To, c.Detail = "string", "bytes encoded as "+BinaryPrefix+" base64url"
+			break
+		}
+		c.To, c.Detail = "array", "elements kept in input order"
+		*out = append(*out, c)
+		for i := 0; i < rv.Len(); i++ {
+			auditValue(out, fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface())
+		}
+		return
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			c.To, c.Detail = "null", "nil "+t.Kind().String()
+			break
+		}
+		auditValue(out, path, rv.Elem().Interface())
+		return
+	case reflect.Struct:
+		c.To, c.Detail = "object", "encoded with encoding/json"
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); !f.IsExported() || f.Tag.Get("json") == "-" {
+				c.Lossy, c.Detail = true, "encoded with encoding/json; unexported and json:\"-\" fields omitted"
+				break
+			}
+		}
+	default:
+		c.To, c.Detail = "unsupported", "no JSON form; canonicalization fails"
+		c.Lossy = true
+	}
+	*out = append(*out, c)
+}
+
+// sortedMapKeys returns the keys of m in byte order, for stable reports
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	Default.sortKeys(keys)
+	return keys
+}
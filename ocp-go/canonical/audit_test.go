@@ -0,0 +1,118 @@
+package canonical
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCanonicalizeAuditedNative tests that JSON-native input reports no coercions
+func TestCanonicalizeAuditedNative(t *testing.T) {
+	value := map[string]interface{}{
+		"name":  "amend",
+		"votes": []interface{}{2.0, 1.0},
+		"meta":  map[string]interface{}{"ok": true, "note": nil},
+	}
+	result, err := CanonicalizeAudited(value)
+	if err != nil {
+		t.Fatalf("Failed to audit: %v", err)
+	}
+	want, _ := CanonicalizeValue(value)
+	if result.Canonical != want {
+		t.Errorf("Audited form %s differs from %s", result.Canonical, want)
+	}
+	if result.Coercions == nil || len(result.Coercions) != 0 || !result.Reproducible {
+		t.Errorf("Native input should have no coercions and be reproducible: %+v", result)
+	}
+
+	t.Log("✓ JSON-native values are not coercions")
+}
+
+// TestCanonicalizeAuditedCoercions tests that conversions are recorded with paths
+func TestCanonicalizeAuditedCoercions(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+	value := map[string]interface{}{
+		"bond":    int64(500),
+		"big":     uint64(math.MaxUint64),
+		"ratio":   float32(0.1),
+		"when":    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		"local":   time.Date(2026, 1, 2, 3, 4, 5, 0, est),
+		"weights": map[string]float64{"claude": 0.5},
+		"list": []interface{}{
+			"a",
+			map[string]interface{}{"n": 7},
+		},
+	}
+	result, err := CanonicalizeAudited(value)
+	if err != nil {
+		t.Fatalf("Failed to audit: %v", err)
+	}
+
+	byPath := make(map[string]Coercion)
+	for _, c := range result.Coercions {
+		byPath[c.Path] = c
+	}
+	cases := []struct {
+		path, from, to string
+		lossy          bool
+	}{
+		{"big", "uint64", "number", true},
+		{"bond", "int64", "number", false},
+		{"list[1].n", "int", "number", false},
+		{"local", "time.Time", "string", true},
+		{"ratio", "float32", "number", true},
+		{"weights", "map[string]float64", "object", false},
+		{"when", "time.Time", "string", false},
+	}
+	if len(result.Coercions) != len(cases) {
+		t.Errorf("Expected %d coercions, got %v", len(cases), result.Coercions)
+	}
+	for _, tc := range cases {
+		c, ok := byPath[tc.path]
+		if !ok {
+			t.Errorf("No coercion recorded at %s", tc.path)
+			continue
+		}
+		if c.From != tc.from || c.To != tc.to || c.Lossy != tc.lossy {
+			t.Errorf("%s: got %s", tc.path, c)
+		}
+	}
+	if result.Coercions[0].Path != "big" {
+		t.Errorf("Coercions should be in key order: %v", result.Coercions)
+	}
+	if lossy := result.Lossy(); len(lossy) != 3 {
+		t.Errorf("Expected 3 lossy coercions, got %v", lossy)
+	}
+	if s := byPath["local"].String(); !strings.Contains(s, "(lossy)") || !strings.Contains(s, "EST") {
+		t.Errorf("Unexpected rendering: %s", s)
+	}
+
+	t.Log("✓ Coercions recorded with paths, types, and lossiness")
+}
+
+// TestCanonicalizeAuditedReproducible tests that forms another implementation
+// would canonicalize differently are flagged
+func TestCanonicalizeAuditedReproducible(t *testing.T) {
+	result, err := CanonicalizeAudited(map[string]interface{}{"ids": []interface{}{3, 1, 2}})
+	if err != nil {
+		t.Fatalf("Failed to audit: %v", err)
+	}
+	if result.Reproducible {
+		t.Errorf("Unsorted integer array should not be reproducible: %s", result.Canonical)
+	}
+	if len(result.Coercions) != 3 || result.Coercions[0].Path != "ids[0]" {
+		t.Errorf("Expected element coercions, got %v", result.Coercions)
+	}
+
+	result, err = CanonicalizeAudited(map[string]interface{}{"ids": []interface{}{3.0, 1.0, 2.0}})
+	if err != nil || !result.Reproducible {
+		t.Errorf("Float array should be reproducible: %+v, %v", result, err)
+	}
+
+	if _, err := CanonicalizeAudited(map[string]interface{}{"f": func() {}}); err == nil {
+		t.Errorf("Expected unencodable value to fail")
+	}
+
+	t.Log("✓ Non-reproducible forms flagged")
+}
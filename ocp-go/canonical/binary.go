@@ -0,0 +1,45 @@
+// binary.go - Canonical form of byte slices
+//
+// JSON has no binary type, so raw signatures, digests, and other byte values
+// must become strings before they can live inside a hashed object. Rule 2.4.4
+// fixes that string: the prefix "b64:" followed by unpadded base64url (RFC 4648
+// §5). The alphabet needs no escaping, the missing padding leaves a single
+// spelling for each value, and the prefix lets a reader tell binary from text.
+// DeepSort applies the rule to []byte values, so a Go struct with a []byte
+// field and the same object decoded from JSON hash identically.
+
+package canonical
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// BinaryPrefix marks a string as the canonical form of a byte slice
+const BinaryPrefix = "b64:"
+
+// EncodeBytes returns the canonical string form of b
+func EncodeBytes(b []byte) string {
+	return BinaryPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBytes parses a string produced by EncodeBytes. Strings without the
+// prefix, with padding, or with characters outside the base64url alphabet are
+// rejected, so every byte slice has exactly one accepted form.
+func DecodeBytes(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, BinaryPrefix) {
+		return nil, NewCanonicalizationError(fmt.Sprintf("binary value must start with %q", BinaryPrefix))
+	}
+	b, err := base64.RawURLEncoding.Strict().DecodeString(s[len(BinaryPrefix):])
+	if err != nil {
+		return nil, NewCanonicalizationError(fmt.Sprintf("invalid binary value: %v", err))
+	}
+	return b, nil
+}
+
+// IsBinary reports whether s is a well-formed canonical byte slice
+func IsBinary(s string) bool {
+	_, err := DecodeBytes(s)
+	return err == nil
+}
@@ -0,0 +1,64 @@
+package canonical
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestEncodeBytes tests the canonical string form of byte slices
+func TestEncodeBytes(t *testing.T) {
+	cases := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte{}, "b64:"},
+		{[]byte("f"), "b64:Zg"},
+		{[]byte("fo"), "b64:Zm8"},
+		{[]byte("foo"), "b64:Zm9v"},
+		{[]byte{0xfb, 0xff}, "b64:-_8"},
+	}
+	for _, tc := range cases {
+		got := EncodeBytes(tc.in)
+		if got != tc.want {
+			t.Errorf("EncodeBytes(%x) = %s, want %s", tc.in, got, tc.want)
+		}
+		back, err := DecodeBytes(got)
+		if err != nil || !bytes.Equal(back, tc.in) {
+			t.Errorf("DecodeBytes(%s) = %x, %v", got, back, err)
+		}
+	}
+
+	// Every byte slice has exactly one accepted spelling
+	for _, bad := range []string{"Zm9v", "b64:Zg==", "b64:Zm+v", "b64:Zh", "b64:Z"} {
+		if IsBinary(bad) {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+
+	t.Log("✓ Byte slices encode as unpadded base64url with a b64: prefix")
+}
+
+// TestCanonicalizeBytes tests that []byte values inside objects follow Rule 2.4.4
+func TestCanonicalizeBytes(t *testing.T) {
+	sig := []byte{0x01, 0xfe, 0xff}
+	form, err := Canonicalize(map[string]interface{}{
+		"signature": sig,
+		"missing":   []byte(nil),
+		"parts":     []interface{}{[]byte("b"), []byte("a")},
+	}, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	want := `{"missing":null,"parts":["b64:YQ","b64:Yg"],"signature":"b64:Af7_"}`
+	if form != want {
+		t.Errorf("Got %s, want %s", form, want)
+	}
+
+	// The same object decoded from JSON canonicalizes identically
+	decoded, err := CanonicalizeJSON([]byte(`{"signature":"b64:Af7_","missing":null,"parts":["b64:Yg","b64:YQ"]}`))
+	if err != nil || decoded != want {
+		t.Errorf("Decoded form %s differs from %s: %v", decoded, want, err)
+	}
+
+	t.Log("✓ []byte values canonicalize like their decoded strings")
+}
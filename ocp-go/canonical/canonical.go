@@ -0,0 +1,384 @@
+// canonical.go - Core canonicalization for OCP
+//
+// This package is the Go implementation of the Optimistic Constitutional Protocol (OCP)
+// canonicalization engine, ensuring deterministic representation of all constitutional objects
+// for cryptographic hashing and verification.
+//
+// Must produce byte-for-byte identical output to canonicalizer.py, canonicalizer.js, and canonicalizer.rs
+
+package canonical
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Constants
+const (
+	Encoding = "utf-8"
+)
+
+// ConstitutionalError represents errors in the constitutional protocol
+type ConstitutionalError struct {
+	ErrorType string
+	Message   string
+}
+
+func (e *ConstitutionalError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorType, e.Message)
+}
+
+// NewConstitutionalError creates a new ConstitutionalError
+func NewConstitutionalError(message string) *ConstitutionalError {
+	return &ConstitutionalError{
+		ErrorType: "ConstitutionalError",
+		Message:   message,
+	}
+}
+
+// CanonicalizationError represents canonicalization-specific errors
+func NewCanonicalizationError(message string) error {
+	return &ConstitutionalError{
+		ErrorType: "CanonicalizationError",
+		Message:   message,
+	}
+}
+
+// DeepSort recursively sorts all maps by keys and sorts arrays where appropriate.
+// This ensures complete deterministic ordering of nested structures.
+// The concrete types map[string]string, map[string]float64, and
+// []map[string]string used by protocol objects are converted to their generic
+// forms, so they follow the same key ordering and null rules as decoded JSON;
+// a nil value of one of these types is null, as encoding/json writes it.
+// A []byte becomes its EncodeBytes string (Rule 2.4.4), and a nil []byte null;
+// a Decimal becomes its canonical string (Rule 2.4.5).
+// Matches Python's _deep_sort, JavaScript's deepSort, and Rust's deep_sort functions.
+func DeepSort(obj interface{}) interface{} {
+	return Default.DeepSort(obj)
+}
+
+// DeepSort is DeepSort using this canonicalizer's options
+func (c *Canonicalizer) DeepSort(obj interface{}) interface{} {
+	if c.defensiveCopy {
+		obj = DeepCopy(obj)
+	}
+	return c.deepSort(obj)
+}
+
+func (c *Canonicalizer) deepSort(obj interface{}) interface{} {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		// Explicitly unordered collections are ordered by element hash
+		if elems, ok := setElements(v); ok {
+			return map[string]interface{}{SetKey: c.sortSet(elems)}
+		}
+		if workers := c.parallelWorkers(len(v)); workers > 1 {
+			return c.deepSortWide(v, workers)
+		}
+
+		// Convert to sorted map
+		sortedMap := make(map[string]interface{})
+		for k, val := range v {
+			sorted := c.deepSort(val)
+			if sorted == nil && c.nullHandling == DropNulls {
+				continue
+			}
+			sortedMap[k] = sorted
+		}
+		return sortedMap
+
+	case []interface{}:
+		// Check if all elements are primitives of the same type
+		if len(v) == 0 {
+			return v
+		}
+
+		// Recursively sort each element
+		sortedArr := make([]interface{}, len(v))
+		for i, elem := range v {
+			sortedArr[i] = c.deepSort(elem)
+		}
+
+		// Check if all are primitives and of same type
+		allPrimitives := true
+		for _, elem := range sortedArr {
+			switch elem.(type) {
+			case string, float64, json.Number, bool, nil:
+				// Primitive types are OK
+			default:
+				allPrimitives = false
+				break
+			}
+		}
+
+		if allPrimitives && len(sortedArr) > 0 {
+			// Check if all are same type; passed-through numbers are numbers
+			firstType := primitiveType(sortedArr[0])
+			allSameType := true
+			for _, elem := range sortedArr {
+				if primitiveType(elem) != firstType {
+					allSameType = false
+					break
+				}
+			}
+
+			if allSameType {
+				// Sort primitives
+				sort.Slice(sortedArr, func(i, j int) bool {
+					switch a := sortedArr[i].(type) {
+					case string:
+						return c.less(a, sortedArr[j].(string))
+					case float64, json.Number:
+						x, _ := numberValue(a)
+						y, _ := numberValue(sortedArr[j])
+						return x < y
+					case bool:
+						return !a && sortedArr[j].(bool) // false < true
+					default:
+						return false
+					}
+				})
+			}
+		}
+
+		return sortedArr
+
+	case map[string]string:
+		if v == nil {
+			return nil
+		}
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = val
+		}
+		return c.deepSort(m)
+
+	case map[string]float64:
+		if v == nil {
+			return nil
+		}
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = val
+		}
+		return c.deepSort(m)
+
+	case []byte:
+		if v == nil {
+			return nil
+		}
+		return EncodeBytes(v)
+
+	case json.Number:
+		return normalizeNumber(v)
+
+	case Decimal:
+		return v.String()
+
+	case *Decimal:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+
+	case []map[string]string:
+		if v == nil {
+			return nil
+		}
+		// Arrays of objects keep their order; only each object is sorted
+		arr := make([]interface{}, len(v))
+		for i, m := range v {
+			arr[i] = c.deepSort(m)
+		}
+		return arr
+
+	default:
+		// Primitives are returned as-is
+		return v
+	}
+}
+
+// primitiveType names the type of a primitive for deciding whether an array
+// is sorted, counting float64 and json.Number as one
+func primitiveType(v interface{}) string {
+	if _, ok := v.(json.Number); ok {
+		return "float64"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Canonicalize converts a map to a deterministically ordered, canonical JSON string.
+// Matches Python's canonicalize, JavaScript's canonicalize, and Rust's canonicalize functions.
+//
+// Parameters:
+//   - data: Input map to canonicalize
+//   - strict: If true, returns error on non-canonicalizable data
+//
+// Returns:
+//   - Canonical JSON string (compact, no whitespace, sorted keys)
+func Canonicalize(data map[string]interface{}, strict bool) (string, error) {
+	return Default.Canonicalize(data, strict)
+}
+
+// Canonicalize is Canonicalize using this canonicalizer's options
+func (c *Canonicalizer) Canonicalize(data map[string]interface{}, strict bool) (string, error) {
+	if data == nil {
+		if strict {
+			return "", NewCanonicalizationError("Input must be a map, got nil")
+		}
+		data = make(map[string]interface{})
+	}
+
+	// Deep sort the entire structure
+	sortedData := c.DeepSort(data)
+
+	// Convert to canonical JSON
+	// Use a custom approach to ensure compact representation
+	return c.jsonToCanonical(sortedData)
+}
+
+// CanonicalizeValue converts any JSON-compatible value (not only maps) to its
+// canonical JSON string. Used where a subtree must be hashed on its own.
+func CanonicalizeValue(value interface{}) (string, error) {
+	return Default.CanonicalizeValue(value)
+}
+
+// CanonicalizeValue is CanonicalizeValue using this canonicalizer's options
+func (c *Canonicalizer) CanonicalizeValue(value interface{}) (string, error) {
+	return c.jsonToCanonical(c.DeepSort(value))
+}
+
+// Encode writes the canonical JSON form of value to w as it is produced,
+// without building the whole string first. The writer receives the same bytes
+// CanonicalizeValue would return.
+func Encode(w io.Writer, value interface{}) error {
+	return Default.Encode(w, value)
+}
+
+// Encode is Encode using this canonicalizer's options
+func (c *Canonicalizer) Encode(w io.Writer, value interface{}) error {
+	return c.encode(w, c.DeepSort(value))
+}
+
+// jsonToCanonical converts an already sorted value to a compact JSON string
+func (c *Canonicalizer) jsonToCanonical(obj interface{}) (string, error) {
+	var b strings.Builder
+	if err := c.encode(&b, obj); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// encode recursively writes a value as compact JSON.
+// This ensures no extra whitespace and proper sorting.
+func (c *Canonicalizer) encode(w io.Writer, obj interface{}) error {
+	if err := c.supported(); err != nil {
+		return err
+	}
+	e := &encoder{c: c, w: w}
+	return e.encode(obj)
+}
+
+// encode writes obj, reusing the sorted, escaped keys of interned shapes
+func (e *encoder) encode(obj interface{}) error {
+	w := e.w
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		shape := e.shapeOf(v)
+
+		// Write JSON object
+		if _, err := io.WriteString(w, "{"); err != nil {
+			return err
+		}
+		if workers := e.c.parallelWorkers(len(shape.keys)); workers > 1 {
+			if err := e.encodeWide(v, shape, workers); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, "}")
+			return err
+		}
+		for i, k := range shape.keys {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if _, err := w.Write(shape.prefixes[i]); err != nil {
+				return err
+			}
+			if err := e.encode(v[k]); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+
+	case []interface{}:
+		// Write JSON array
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for i, elem := range v {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := e.encode(elem); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+
+	case string:
+		// String must be properly escaped
+		if plainString(v) {
+			return e.writeQuoted(v)
+		}
+		b, _ := json.Marshal(v)
+		_, err := w.Write(b)
+		return err
+
+	case float64:
+		// Handle numbers carefully
+		if v == float64(int64(v)) {
+			_, err := fmt.Fprintf(w, "%.0f", v)
+			return err
+		}
+		_, err := io.WriteString(w, json.Number(fmt.Sprintf("%v", v)).String())
+		return err
+
+	case json.Number:
+		// Only canonical lexemes remain after DeepSort
+		if !canonicalLexeme(string(v)) {
+			return NewCanonicalizationError(fmt.Sprintf("number %s is outside the float64 range", v))
+		}
+		_, err := io.WriteString(w, string(v))
+		return err
+
+	case bool:
+		if v {
+			_, err := io.WriteString(w, "true")
+			return err
+		}
+		_, err := io.WriteString(w, "false")
+		return err
+
+	case nil:
+		_, err := io.WriteString(w, "null")
+		return err
+
+	default:
+		// Fallback: use json.Marshal
+		b, err := json.Marshal(v)
+		if err != nil {
+			return NewCanonicalizationError(fmt.Sprintf("Failed to marshal value: %v", err))
+		}
+		_, err = w.Write(b)
+		return err
+	}
+}
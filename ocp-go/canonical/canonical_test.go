@@ -0,0 +1,162 @@
+package canonical
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestNestedStructures tests canonicalization of nested maps and arrays
+func TestNestedStructures(t *testing.T) {
+	complexDict := map[string]interface{}{
+		"z": []interface{}{float64(3), float64(1), float64(2)},
+		"a": map[string]interface{}{
+			"c": float64(3),
+			"a": float64(1),
+			"b": map[string]interface{}{
+				"f": float64(6),
+				"d": float64(4),
+				"e": float64(5),
+			},
+		},
+		"b": float64(2),
+	}
+
+	canonical, err := Canonicalize(complexDict, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+
+	expected := `{"a":{"a":1,"b":{"d":4,"e":5,"f":6},"c":3},"b":2,"z":[1,2,3]}`
+	if canonical != expected {
+		t.Errorf("Canonical form mismatch:\n  Expected: %s\n  Got:      %s", expected, canonical)
+	}
+
+	t.Logf("✓ Nested structure canonicalized correctly: %s", canonical)
+}
+
+// TestCanonicalizeValue tests canonicalization of non-map values
+func TestCanonicalizeValue(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{[]interface{}{"b", "a"}, `["a","b"]`},
+		{"text", `"text"`},
+		{float64(1.5), `1.5`},
+		{nil, `null`},
+		{map[string]interface{}{"b": true, "a": false}, `{"a":false,"b":true}`},
+	}
+
+	for _, tc := range cases {
+		got, err := CanonicalizeValue(tc.value)
+		if err != nil {
+			t.Fatalf("Failed to canonicalize %v: %v", tc.value, err)
+		}
+		if got != tc.expected {
+			t.Errorf("Expected %s, got %s", tc.expected, got)
+		}
+	}
+
+	t.Logf("✓ Non-map values canonicalize correctly")
+}
+
+// BenchmarkCanonicalize benchmarks canonicalization
+func BenchmarkCanonicalize(b *testing.B) {
+	testData := map[string]interface{}{
+		"action":       "propose",
+		"agent":        "Claude",
+		"confidence":   float64(0.88),
+		"timestamp":    "2025-11-20T14:30:00Z",
+		"evidence_ptr": "archive://0000001",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Canonicalize(testData, true)
+	}
+}
+
+// ExampleCanonicalize demonstrates canonicalization
+func ExampleCanonicalize() {
+	data := map[string]interface{}{
+		"action": "propose",
+		"agent":  "Claude",
+		"value":  float64(42),
+	}
+
+	canonical, err := Canonicalize(data, true)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Canonical: %s\n", canonical)
+}
+
+// TestEncodeStreams tests that Encode writes exactly the canonical form
+func TestEncodeStreams(t *testing.T) {
+	value := map[string]interface{}{
+		"b": []interface{}{float64(1), "x<y", nil, true},
+		"a": map[string]interface{}{"d": 1.5, "c": false},
+	}
+
+	var buf strings.Builder
+	if err := Encode(&buf, value); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	expected, _ := CanonicalizeValue(value)
+	if buf.String() != expected {
+		t.Errorf("Encode mismatch:\n  Expected: %s\n  Got:      %s", expected, buf.String())
+	}
+
+	t.Logf("✓ Encoded: %s", buf.String())
+}
+
+// TestConcreteMapTypes tests that concrete map types canonicalize like their
+// generic equivalents under every key order and null rule
+func TestConcreteMapTypes(t *testing.T) {
+	concrete := map[string]interface{}{
+		"evidence": []map[string]string{{"type": "computation", "pointer": "sha256:abc", "\U0001F600": "x", "�": "y"}},
+		"metadata": map[string]string{"b": "2", "a": "1"},
+		"weights":  map[string]float64{"z": 1e21, "y": 0.5},
+	}
+	generic := map[string]interface{}{
+		"evidence": []interface{}{map[string]interface{}{"type": "computation", "pointer": "sha256:abc", "\U0001F600": "x", "�": "y"}},
+		"metadata": map[string]interface{}{"b": "2", "a": "1"},
+		"weights":  map[string]interface{}{"z": 1e21, "y": 0.5},
+	}
+
+	canonicalizers := []*Canonicalizer{Default, New(WithDefensiveCopy())}
+	if UTF16KeyOrderBuild {
+		canonicalizers = append(canonicalizers, New(WithKeyOrder(KeyOrderUTF16)))
+	}
+	for _, c := range canonicalizers {
+		got, err := c.Canonicalize(concrete, true)
+		if err != nil {
+			t.Fatalf("Failed to canonicalize: %v", err)
+		}
+		want, _ := c.Canonicalize(generic, true)
+		if got != want {
+			t.Errorf("Concrete types canonicalized differently:\n  Expected: %s\n  Got:      %s", want, got)
+		}
+	}
+	if _, ok := DeepSort(concrete).(map[string]interface{})["evidence"].([]interface{}); !ok {
+		t.Errorf("DeepSort should convert []map[string]string to a generic array")
+	}
+	t.Logf("✓ Concrete map types canonicalized natively")
+}
+
+// TestNilConcreteMaps tests that nil concrete maps encode as null
+func TestNilConcreteMaps(t *testing.T) {
+	var sig map[string]string
+	got, _ := Canonicalize(map[string]interface{}{"signature": sig}, true)
+	if got != `{"signature":null}` {
+		t.Errorf("Expected nil map to encode as null, got %s", got)
+	}
+	dropped, _ := New(WithNullHandling(DropNulls)).Canonicalize(map[string]interface{}{"signature": sig}, true)
+	if dropped != `{}` {
+		t.Errorf("Expected nil map to be dropped with DropNulls, got %s", dropped)
+	}
+	t.Logf("✓ Nil concrete maps are null")
+}
@@ -0,0 +1,52 @@
+// capability.go - Optional canonicalization features
+//
+// Constrained targets can be built without canonicalization features they
+// do not need. Each optional feature has a stable capability name and a
+// build tag that removes it:
+//
+//	utf16_key_order    ocp_noutf16    RFC 8785 (JCS) key order
+//
+// A reduced build does not advertise the versions it cannot produce, and
+// every operation that needs a missing feature fails with a *CapabilityError
+// instead of falling back to other rules, so a reduced node never computes a
+// hash differently from its peers without saying so.
+
+package canonical
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CapabilityUTF16KeyOrder names the UTF-16 code unit ordering of KeyOrderUTF16
+const CapabilityUTF16KeyOrder = "utf16_key_order"
+
+// CapabilityError reports an operation that needs a feature this build omits
+type CapabilityError struct {
+	Capability string
+	// Operation names what needed the capability
+	Operation string
+}
+
+// Error implements error
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("CapabilityError: %s requires %s, which this build omits", e.Operation, e.Capability)
+}
+
+// VersionCapabilities returns the optional features needed to reproduce
+// hashes made under a canonicalizer Version
+func VersionCapabilities(version string) []string {
+	var caps []string
+	if strings.Contains(version, "key_order="+KeyOrderUTF16.String()) {
+		caps = append(caps, CapabilityUTF16KeyOrder)
+	}
+	return caps
+}
+
+// supported returns a *CapabilityError if c needs a feature this build omits
+func (c *Canonicalizer) supported() error {
+	if c.keyOrder == KeyOrderUTF16 && !UTF16KeyOrderBuild {
+		return &CapabilityError{Capability: CapabilityUTF16KeyOrder, Operation: "canonical version " + c.Version()}
+	}
+	return nil
+}
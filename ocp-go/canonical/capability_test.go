@@ -0,0 +1,58 @@
+package canonical
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCapabilityErrors tests that a reduced build refuses UTF-16 key order
+// with a typed error, and a full build serves it
+func TestCapabilityErrors(t *testing.T) {
+	c := New(WithKeyOrder(KeyOrderUTF16))
+	_, encodeErr := c.Canonicalize(map[string]interface{}{"a": 1}, true)
+	_, versionErr := ForVersion(c.Version())
+	supported := false
+	for _, v := range SupportedVersions() {
+		supported = supported || v == c.Version()
+	}
+
+	var capErr *CapabilityError
+	if UTF16KeyOrderBuild {
+		if encodeErr != nil || versionErr != nil || !supported {
+			t.Errorf("Expected UTF-16 key order to be served, got %v, %v, %v", encodeErr, versionErr, supported)
+		}
+	} else {
+		for _, err := range []error{encodeErr, versionErr} {
+			if !errors.As(err, &capErr) || capErr.Capability != CapabilityUTF16KeyOrder {
+				t.Errorf("Expected a CapabilityError, got %v", err)
+			}
+		}
+		if supported {
+			t.Errorf("A reduced build should not advertise %s", c.Version())
+		}
+	}
+
+	if caps := VersionCapabilities(c.Version()); len(caps) != 1 || caps[0] != CapabilityUTF16KeyOrder {
+		t.Errorf("Unexpected capabilities %v for %s", caps, c.Version())
+	}
+	if caps := VersionCapabilities(Default.Version()); len(caps) != 0 {
+		t.Errorf("The default version should need no optional feature, got %v", caps)
+	}
+	t.Logf("✓ UTF-16 key order compiled in: %v", UTF16KeyOrderBuild)
+}
+
+// keyOrders returns the key orders compiled into this build
+func keyOrders() []KeyOrder {
+	if UTF16KeyOrderBuild {
+		return []KeyOrder{KeyOrderUTF8, KeyOrderUTF16}
+	}
+	return []KeyOrder{KeyOrderUTF8}
+}
+
+// skipWithoutUTF16 skips a test of KeyOrderUTF16 in builds that compile it out
+func skipWithoutUTF16(t *testing.T) {
+	t.Helper()
+	if !UTF16KeyOrderBuild {
+		t.Skip("KeyOrderUTF16 is compiled out of this build (ocp_noutf16)")
+	}
+}
@@ -0,0 +1,93 @@
+// copy.go - Snapshots of caller-owned input
+//
+// Aliasing contract: DeepSort, Canonicalize, and CanonicalizeValue read the
+// caller's maps and slices in place and never modify them. The caller must not
+// mutate the input, from any goroutine, until the call returns; Go maps are not
+// safe for concurrent reads and writes, and a structure changed mid-walk yields
+// a hash of neither the old nor the new value. Without WithDefensiveCopy, the
+// result of DeepSort may also share leaf values with the input (empty arrays and
+// types the encoder marshals as-is, such as []string), so later mutations of
+// the input can show through in the result.
+//
+// Applications that mutate shared objects should hold their own lock only while
+// taking a DeepCopy, then canonicalize the copy after releasing it.
+
+package canonical
+
+import "encoding/json"
+
+// DeepCopy returns a copy of a JSON-compatible value that shares no mutable
+// structure with the original. Maps, slices, and the concrete map/slice types
+// used by protocol objects are copied recursively; any other non-primitive value
+// is snapshotted as its JSON encoding, which the encoder emits unchanged.
+func DeepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			out[k] = DeepCopy(val)
+		}
+		return out
+
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = DeepCopy(elem)
+		}
+		return out
+
+	case map[string]string:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]string, len(v))
+		for k, val := range v {
+			out[k] = val
+		}
+		return out
+
+	case map[string]float64:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]float64, len(v))
+		for k, val := range v {
+			out[k] = val
+		}
+		return out
+
+	case []map[string]string:
+		if v == nil {
+			return v
+		}
+		out := make([]map[string]string, len(v))
+		for i, m := range v {
+			out[i] = DeepCopy(m).(map[string]string)
+		}
+		return out
+
+	case []string:
+		if v == nil {
+			return v
+		}
+		return append([]string{}, v...)
+
+	case nil, string, bool, float64, float32, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, json.Number:
+		return v
+
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			// Left as-is so canonicalization reports the marshal error
+			return v
+		}
+		return json.RawMessage(raw)
+	}
+}
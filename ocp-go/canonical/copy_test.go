@@ -0,0 +1,135 @@
+package canonical
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestDeepCopyIndependent tests that copies share no mutable structure
+func TestDeepCopyIndependent(t *testing.T) {
+	original := map[string]interface{}{
+		"nested":    map[string]interface{}{"list": []interface{}{"a", "b"}},
+		"signature": map[string]string{"algorithm": "ed25519"},
+		"evidence":  []map[string]string{{"type": "computation"}},
+		"struct":    struct{ Z, A int }{Z: 1, A: 2},
+	}
+	before, _ := Canonicalize(original, true)
+
+	copied := DeepCopy(original).(map[string]interface{})
+	original["nested"].(map[string]interface{})["list"].([]interface{})[0] = "changed"
+	original["signature"].(map[string]string)["algorithm"] = "changed"
+	original["evidence"].([]map[string]string)[0]["type"] = "changed"
+
+	after, err := Canonicalize(copied, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize copy: %v", err)
+	}
+	if after != before {
+		t.Errorf("Copy should be unaffected by mutations:\n  Before: %s\n  After:  %s", before, after)
+	}
+
+	t.Logf("✓ Deep copy canonical form: %s", after)
+}
+
+// TestWithDefensiveCopyNoAliasing tests that DeepSort results never alias input
+func TestWithDefensiveCopyNoAliasing(t *testing.T) {
+	input := map[string]interface{}{
+		"signers": []string{"abc"},
+		"empty":   []interface{}{},
+	}
+
+	aliased := Default.DeepSort(input).(map[string]interface{})
+	isolated := New(WithDefensiveCopy()).DeepSort(input).(map[string]interface{})
+
+	input["signers"].([]string)[0] = "forged"
+
+	if aliased["signers"].([]string)[0] != "forged" {
+		t.Errorf("Expected default DeepSort to share leaf slices with input")
+	}
+	if isolated["signers"].([]string)[0] != "abc" {
+		t.Errorf("Defensive DeepSort result should not change with input")
+	}
+
+	t.Logf("✓ Defensive copy isolates DeepSort results")
+}
+
+// TestConcurrentCanonicalization tests shared read-only input and snapshots
+// taken under the caller's lock. Run with -race.
+func TestConcurrentCanonicalization(t *testing.T) {
+	shared := map[string]interface{}{
+		"proposal": map[string]interface{}{"id": "p-1", "tags": []interface{}{"b", "a"}},
+		"counter":  float64(0),
+	}
+	var mu sync.RWMutex
+	c := New(WithDefensiveCopy())
+
+	// Writer mutates the shared object under its lock until stopped
+	stop := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			mu.Lock()
+			shared["counter"] = float64(i)
+			shared["proposal"].(map[string]interface{})["tags"] = []interface{}{fmt.Sprint(i), "a"}
+			mu.Unlock()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	// Hashers hold the lock only while copying
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				mu.RLock()
+				snapshot := DeepCopy(shared).(map[string]interface{})
+				mu.RUnlock()
+
+				first, err := c.Canonicalize(snapshot, true)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if second, _ := c.Canonicalize(snapshot, true); first != second {
+					errs <- fmt.Errorf("snapshot hashed inconsistently")
+					return
+				}
+			}
+		}()
+	}
+
+	// Many readers of an object nobody mutates need no copy at all
+	mu.RLock()
+	immutable := DeepCopy(shared)
+	mu.RUnlock()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				Default.DeepSort(immutable)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	<-writerDone
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Concurrent canonicalization failed: %v", err)
+	}
+
+	t.Logf("✓ Concurrent canonicalization race-free")
+}
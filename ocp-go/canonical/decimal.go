@@ -0,0 +1,332 @@
+// decimal.go - Exact fixed-point amounts
+//
+// Reputation stakes, bonds, and penalties must come out the same on every
+// node, and binary floating point cannot promise that: 0.1 + 0.2 is not 0.3,
+// and implementations print the same float differently. A Decimal is an exact
+// base-10 number, an arbitrary-precision integer scaled by a power of ten,
+// and is written as a JSON string so that no parser ever turns it into a
+// float. Rule 2.4.5 fixes that string to one spelling per value: an optional
+// minus sign, the integer digits without leading zeros, and the fractional
+// digits without trailing zeros, with no exponent and no negative zero.
+//
+// Decimals are immutable; arithmetic returns new values. Addition,
+// subtraction, and multiplication are exact. Division and rounding take an
+// explicit scale and RoundingMode, since every node must round identically.
+
+package canonical
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MaxDecimalLength bounds the length of a decimal string accepted by
+// ParseDecimal, so hostile input cannot force huge allocations
+const MaxDecimalLength = 256
+
+// RoundingMode selects how Round and Div discard digits
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, ties to the even digit
+	RoundHalfEven RoundingMode = iota
+	// RoundFloor rounds toward negative infinity
+	RoundFloor
+	// RoundCeiling rounds toward positive infinity
+	RoundCeiling
+	// RoundTowardZero discards the extra digits
+	RoundTowardZero
+)
+
+// Decimal is an exact base-10 number. The zero value is 0.
+type Decimal struct {
+	// unscaled * 10^-scale is the value; nil means zero. Values are kept
+	// normalized, without trailing zeros in unscaled when scale > 0, so equal
+	// numbers have equal fields.
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns unscaled * 10^-scale
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+// DecimalFromInt returns n as a Decimal
+func DecimalFromInt(n int64) Decimal {
+	return NewDecimal(n, 0)
+}
+
+// newDecimal normalizes and takes ownership of u
+func newDecimal(u *big.Int, scale int32) Decimal {
+	if u.Sign() == 0 {
+		return Decimal{}
+	}
+	if scale < 0 {
+		u.Mul(u, pow10(int(-scale)))
+		scale = 0
+	}
+	ten := big.NewInt(10)
+	q, r := new(big.Int), new(big.Int)
+	for scale > 0 {
+		q.QuoRem(u, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		u.Set(q)
+		scale--
+	}
+	return Decimal{unscaled: u, scale: scale}
+}
+
+// ParseDecimal parses a decimal string: an optional "-", integer digits
+// without leading zeros, and an optional "." followed by one or more digits.
+// Trailing fractional zeros are accepted and normalized away; exponents, a
+// leading "+", and bare "." forms are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := func() (Decimal, error) {
+		return Decimal{}, NewCanonicalizationError(fmt.Sprintf("invalid decimal %q", s))
+	}
+	if len(s) == 0 || len(s) > MaxDecimalLength {
+		return invalid()
+	}
+	digits := strings.TrimPrefix(s, "-")
+	intPart, fracPart, hasFrac := strings.Cut(digits, ".")
+	if intPart == "" || (hasFrac && fracPart == "") || !allDigits(intPart) || !allDigits(fracPart) {
+		return invalid()
+	}
+	if len(intPart) > 1 && intPart[0] == '0' {
+		return invalid()
+	}
+	u, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return invalid()
+	}
+	if digits != s {
+		u.Neg(u)
+	}
+	return newDecimal(u, int32(len(fracPart))), nil
+}
+
+// MustDecimal is ParseDecimal for constants; it panics on invalid input
+func MustDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// IsCanonicalDecimal reports whether s is the Rule 2.4.5 form of a decimal
+func IsCanonicalDecimal(s string) bool {
+	d, err := ParseDecimal(s)
+	return err == nil && d.String() == s
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// String returns the canonical form of d
+func (d Decimal) String() string {
+	if d.unscaled == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	split := len(digits) - int(d.scale)
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// Scale returns the number of fractional digits in the canonical form
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0, or +1
+func (d Decimal) Sign() int {
+	if d.unscaled == nil {
+		return 0
+	}
+	return d.unscaled.Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// rescaled returns d's unscaled value at scale >= d.scale
+func (d Decimal) rescaled(scale int32) *big.Int {
+	u := new(big.Int)
+	if d.unscaled != nil {
+		u.Set(d.unscaled)
+	}
+	if scale > d.scale {
+		u.Mul(u, pow10(int(scale-d.scale)))
+	}
+	return u
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// Cmp returns -1, 0, or +1 as d is less than, equal to, or greater than e
+func (d Decimal) Cmp(e Decimal) int {
+	s := maxScale(d, e)
+	return d.rescaled(s).Cmp(e.rescaled(s))
+}
+
+// Equal reports whether d and e are the same number
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Add returns d + e
+func (d Decimal) Add(e Decimal) Decimal {
+	s := maxScale(d, e)
+	u := d.rescaled(s)
+	return newDecimal(u.Add(u, e.rescaled(s)), s)
+}
+
+// Sub returns d - e
+func (d Decimal) Sub(e Decimal) Decimal {
+	return d.Add(e.Neg())
+}
+
+// Mul returns d * e
+func (d Decimal) Mul(e Decimal) Decimal {
+	u := d.rescaled(d.scale)
+	return newDecimal(u.Mul(u, e.rescaled(e.scale)), d.scale+e.scale)
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	u := d.rescaled(d.scale)
+	return newDecimal(u.Neg(u), d.scale)
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	if d.Sign() < 0 {
+		return d.Neg()
+	}
+	return d
+}
+
+// Round returns d rounded to at most scale fractional digits
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if d.scale <= scale {
+		return d
+	}
+	return divRound(d.rescaled(d.scale), pow10(int(d.scale-scale)), scale, mode)
+}
+
+// Div returns d / e rounded to at most scale fractional digits
+func (d Decimal) Div(e Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if e.IsZero() {
+		return Decimal{}, NewCanonicalizationError("decimal division by zero")
+	}
+	// d/e = (d.u * 10^(scale + e.scale - d.scale)) / e.u * 10^-scale
+	num, den := d.rescaled(d.scale), e.rescaled(e.scale)
+	if shift := int(scale) + int(e.scale) - int(d.scale); shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return divRound(num, den, scale, mode), nil
+}
+
+// Percent returns d * percent / 100 rounded to at most scale fractional digits
+func (d Decimal) Percent(percent int64, scale int32, mode RoundingMode) Decimal {
+	result, _ := d.Mul(DecimalFromInt(percent)).Div(DecimalFromInt(100), scale, mode)
+	return result
+}
+
+// divRound returns num/den * 10^-scale rounded by mode
+func divRound(num, den *big.Int, scale int32, mode RoundingMode) Decimal {
+	if den.Sign() < 0 {
+		num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+	}
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 {
+		negative := num.Sign() < 0
+		switch mode {
+		case RoundFloor:
+			if negative {
+				q.Sub(q, big.NewInt(1))
+			}
+		case RoundCeiling:
+			if !negative {
+				q.Add(q, big.NewInt(1))
+			}
+		case RoundHalfEven:
+			twice := new(big.Int).Abs(r)
+			twice.Lsh(twice, 1)
+			if c := twice.Cmp(den); c > 0 || (c == 0 && q.Bit(0) == 1) {
+				if negative {
+					q.Sub(q, big.NewInt(1))
+				} else {
+					q.Add(q, big.NewInt(1))
+				}
+			}
+		}
+	}
+	return newDecimal(q, scale)
+}
+
+// Int64 returns d as an int64 if it is an integer in range
+func (d Decimal) Int64() (int64, bool) {
+	if d.scale > 0 {
+		return 0, false
+	}
+	u := d.rescaled(0)
+	if !u.IsInt64() {
+		return 0, false
+	}
+	return u.Int64(), true
+}
+
+// MarshalJSON writes d as its canonical string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a decimal string. JSON numbers are rejected: they may
+// already have passed through a float.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return NewCanonicalizationError("decimal must be a JSON string")
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,136 @@
+package canonical
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestParseDecimal tests the accepted grammar and canonical spelling
+func TestParseDecimal(t *testing.T) {
+	cases := map[string]string{
+		"0":        "0",
+		"-0":       "0",
+		"0.000":    "0",
+		"1.50":     "1.5",
+		"-12.3400": "-12.34",
+		"100":      "100",
+		"0.001":    "0.001",
+		"-0.10":    "-0.1",
+		"123456789012345678901234567890.000000000000000000001": "123456789012345678901234567890.000000000000000000001",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q) failed: %v", in, err)
+			continue
+		}
+		if got := d.String(); got != want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", in, got, want)
+		}
+		if !IsCanonicalDecimal(want) {
+			t.Errorf("%q should be canonical", want)
+		}
+	}
+	for _, bad := range []string{"", "-", "+1", "01", "1.", ".5", "1e3", "1.2.3", "1,5", " 1", "0x10", "NaN", "--1"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+	if IsCanonicalDecimal("1.50") || IsCanonicalDecimal("-0") {
+		t.Errorf("Non-normalized forms should not be canonical")
+	}
+
+	t.Log("✓ Decimals parse strictly and print one spelling per value")
+}
+
+// TestDecimalArithmetic tests exact arithmetic and explicit rounding
+func TestDecimalArithmetic(t *testing.T) {
+	a, b := MustDecimal("0.1"), MustDecimal("0.2")
+	if sum := a.Add(b); sum.String() != "0.3" || !sum.Equal(MustDecimal("0.30")) {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if diff := a.Sub(b); diff.String() != "-0.1" || diff.Sign() != -1 || diff.Abs().String() != "0.1" {
+		t.Errorf("0.1 - 0.2 = %s", diff)
+	}
+	if prod := MustDecimal("1.25").Mul(MustDecimal("-0.4")); prod.String() != "-0.5" {
+		t.Errorf("1.25 * -0.4 = %s", prod)
+	}
+	if MustDecimal("2").Cmp(MustDecimal("10")) != -1 || MustDecimal("1.0").Cmp(DecimalFromInt(1)) != 0 {
+		t.Errorf("Unexpected comparisons")
+	}
+	if NewDecimal(12345, 2).String() != "123.45" || NewDecimal(5, -2).String() != "500" {
+		t.Errorf("Unexpected NewDecimal results")
+	}
+
+	rounding := []struct {
+		in    string
+		scale int32
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"-2.5", 0, RoundHalfEven, "-2"},
+		{"2.51", 0, RoundHalfEven, "3"},
+		{"1.21", 1, RoundCeiling, "1.3"},
+		{"-1.21", 1, RoundCeiling, "-1.2"},
+		{"-1.21", 1, RoundFloor, "-1.3"},
+		{"-1.29", 1, RoundTowardZero, "-1.2"},
+		{"1.2", 3, RoundFloor, "1.2"},
+	}
+	for _, tc := range rounding {
+		if got := MustDecimal(tc.in).Round(tc.scale, tc.mode).String(); got != tc.want {
+			t.Errorf("Round(%s, %d, %d) = %s, want %s", tc.in, tc.scale, tc.mode, got, tc.want)
+		}
+	}
+
+	third, err := DecimalFromInt(1).Div(DecimalFromInt(3), 4, RoundHalfEven)
+	if err != nil || third.String() != "0.3333" {
+		t.Errorf("1/3 = %s, %v", third, err)
+	}
+	if q, _ := MustDecimal("7.5").Div(MustDecimal("0.25"), 0, RoundFloor); q.String() != "30" {
+		t.Errorf("7.5/0.25 = %s", q)
+	}
+	if _, err := DecimalFromInt(1).Div(Decimal{}, 2, RoundFloor); err == nil {
+		t.Errorf("Expected division by zero to fail")
+	}
+	if p := MustDecimal("2.5").Percent(50, 1, RoundCeiling); p.String() != "1.3" {
+		t.Errorf("50%% of 2.5 = %s", p)
+	}
+	if n, ok := MustDecimal("42.0").Int64(); !ok || n != 42 {
+		t.Errorf("Expected 42.0 to be an integer")
+	}
+	if _, ok := MustDecimal("4.2").Int64(); ok {
+		t.Errorf("4.2 is not an integer")
+	}
+
+	t.Log("✓ Decimal arithmetic exact, rounding explicit")
+}
+
+// TestCanonicalizeDecimal tests that decimals canonicalize as normalized strings
+func TestCanonicalizeDecimal(t *testing.T) {
+	stake := MustDecimal("12.50")
+	form, err := Canonicalize(map[string]interface{}{"stake": stake, "penalty": &stake, "none": (*Decimal)(nil)}, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	if want := `{"none":null,"penalty":"12.5","stake":"12.5"}`; form != want {
+		t.Errorf("Got %s, want %s", form, want)
+	}
+
+	var decoded struct {
+		Stake Decimal `json:"stake"`
+	}
+	if err := json.Unmarshal([]byte(`{"stake":"12.50"}`), &decoded); err != nil || !decoded.Stake.Equal(stake) {
+		t.Errorf("Failed to decode decimal: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"stake":12.5}`), &decoded); err == nil {
+		t.Errorf("Expected a JSON number to be rejected")
+	}
+	data, _ := json.Marshal(decoded)
+	if string(data) != `{"stake":"12.5"}` {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	t.Log("✓ Decimals encode as canonical strings, never numbers")
+}
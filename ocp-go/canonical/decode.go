@@ -0,0 +1,219 @@
+// decode.go - Strict ingestion of raw JSON text
+//
+// JSON text may contain duplicate object keys, and parsers disagree on which
+// occurrence wins: Go's encoding/json keeps the last, others keep the first or
+// reject the document. Likewise a lone surrogate escape such as "\ud800" is
+// replaced with U+FFFD by some parsers and preserved by others. Either case makes
+// implementations silently compute different hashes for the same bytes.
+// DecodeStrict rejects such input, and is the ingestion path every raw JSON
+// document must take before canonicalization.
+
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeOption configures DecodeStrict
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	allowLoneSurrogates bool
+	preserveNumbers     bool
+}
+
+// AllowLoneSurrogates accepts unpaired \uD800-\uDFFF escapes, which are then
+// decoded to U+FFFD. Only for ingesting legacy documents already hashed that way.
+func AllowLoneSurrogates() DecodeOption {
+	return func(c *decodeConfig) {
+		c.allowLoneSurrogates = true
+	}
+}
+
+// PreserveNumbers decodes numbers as the json.Number lexemes they were written
+// as instead of float64. Canonicalization emits canonical lexemes verbatim and
+// normalizes the rest; see number.go.
+func PreserveNumbers() DecodeOption {
+	return func(c *decodeConfig) {
+		c.preserveNumbers = true
+	}
+}
+
+// DecodeStrict parses a JSON object, rejecting input whose meaning differs
+// between JSON parsers:
+//   - duplicate keys within any object
+//   - lone surrogate escapes (unless AllowLoneSurrogates is given)
+//   - invalid UTF-8
+//   - trailing data after the object
+//
+// Numbers with leading zeros (e.g. 012) are rejected by the JSON grammar itself.
+//
+// Returns:
+//   - Decoded object with numbers as float64 (json.Number with
+//     PreserveNumbers), ready for Canonicalize
+func DecodeStrict(data []byte, opts ...DecodeOption) (map[string]interface{}, error) {
+	var cfg decodeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if !utf8.Valid(data) {
+		return nil, NewCanonicalizationError("input is not valid UTF-8")
+	}
+	if !cfg.allowLoneSurrogates {
+		if err := checkSurrogates(data); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if cfg.preserveNumbers {
+		dec.UseNumber()
+	}
+	value, err := decodeStrictValue(dec, "$")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, NewCanonicalizationError("unexpected data after JSON object")
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, NewCanonicalizationError(fmt.Sprintf("input must be a JSON object, got %T", value))
+	}
+	return obj, nil
+}
+
+// CanonicalizeJSON decodes raw JSON text with DecodeStrict and canonicalizes it
+func CanonicalizeJSON(data []byte, opts ...DecodeOption) (string, error) {
+	return Default.CanonicalizeJSON(data, opts...)
+}
+
+// CanonicalizeJSON is CanonicalizeJSON using this canonicalizer's options
+func (c *Canonicalizer) CanonicalizeJSON(data []byte, opts ...DecodeOption) (string, error) {
+	obj, err := DecodeStrict(data, opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.Canonicalize(obj, true)
+}
+
+func decodeStrictValue(dec *json.Decoder, path string) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, NewCanonicalizationError(fmt.Sprintf("invalid JSON at %s: %v", path, err))
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, NewCanonicalizationError(fmt.Sprintf("invalid JSON at %s: %v", path, err))
+			}
+			key := keyTok.(string)
+			childPath := path + "." + key
+			if _, dup := obj[key]; dup {
+				return nil, NewCanonicalizationError(fmt.Sprintf("duplicate key at %s", childPath))
+			}
+			value, err := decodeStrictValue(dec, childPath)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, NewCanonicalizationError(fmt.Sprintf("invalid JSON at %s: %v", path, err))
+		}
+		return obj, nil
+
+	case '[':
+		arr := make([]interface{}, 0)
+		for i := 0; dec.More(); i++ {
+			value, err := decodeStrictValue(dec, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, NewCanonicalizationError(fmt.Sprintf("invalid JSON at %s: %v", path, err))
+		}
+		return arr, nil
+
+	default:
+		return nil, NewCanonicalizationError(fmt.Sprintf("unexpected %q at %s", delim, path))
+	}
+}
+
+// checkSurrogates scans string literals for \u escapes of unpaired surrogates
+func checkSurrogates(data []byte) error {
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if !inString {
+			if c == '"' {
+				inString = true
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = false
+		case '\\':
+			r, ok := hexEscape(data, i)
+			if !ok {
+				i++ // skip the escaped character
+				continue
+			}
+			i += 5
+			if !utf16.IsSurrogate(r) {
+				continue
+			}
+			if r < 0xDC00 {
+				// High surrogate must be followed by a low surrogate escape
+				if low, ok := hexEscape(data, i+1); ok && low >= 0xDC00 && low <= 0xDFFF {
+					i += 6
+					continue
+				}
+			}
+			return NewCanonicalizationError(fmt.Sprintf("lone surrogate \\u%04x at byte %d", r, i-5))
+		}
+	}
+	return nil
+}
+
+// hexEscape decodes a \uXXXX escape starting at data[i]
+func hexEscape(data []byte, i int) (rune, bool) {
+	if i+5 >= len(data) || data[i] != '\\' || data[i+1] != 'u' {
+		return 0, false
+	}
+	var r rune
+	for _, h := range data[i+2 : i+6] {
+		r <<= 4
+		switch {
+		case h >= '0' && h <= '9':
+			r |= rune(h - '0')
+		case h >= 'a' && h <= 'f':
+			r |= rune(h-'a') + 10
+		case h >= 'A' && h <= 'F':
+			r |= rune(h-'A') + 10
+		default:
+			return 0, false
+		}
+	}
+	return r, true
+}
@@ -0,0 +1,92 @@
+package canonical
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeStrictDuplicateKeys tests rejection of duplicate keys at any depth
+func TestDecodeStrictDuplicateKeys(t *testing.T) {
+	cases := []string{
+		`{"a":1,"a":2}`,
+		`{"outer":{"x":true,"x":false}}`,
+		`{"list":[{"k":"v","k":"w"}]}`,
+	}
+
+	for _, input := range cases {
+		_, err := DecodeStrict([]byte(input))
+		if err == nil || !strings.Contains(err.Error(), "duplicate key") {
+			t.Errorf("Expected duplicate key error for %s, got %v", input, err)
+		}
+	}
+
+	t.Logf("✓ Duplicate keys rejected")
+}
+
+// TestDecodeStrictSurrogates tests handling of surrogate escapes
+func TestDecodeStrictSurrogates(t *testing.T) {
+	rejected := []string{
+		`{"s":"\ud800"}`,
+		`{"s":"\udc00"}`,
+		`{"s":"\ud83dA"}`,
+		`{"\ud800":1}`,
+	}
+	for _, input := range rejected {
+		if _, err := DecodeStrict([]byte(input)); err == nil {
+			t.Errorf("Lone surrogate should be rejected: %s", input)
+		}
+	}
+
+	accepted := []string{
+		`{"s":"😀"}`,
+		`{"s":"\\ud800"}`,
+		`{"s":"ü"}`,
+	}
+	for _, input := range accepted {
+		if _, err := DecodeStrict([]byte(input)); err != nil {
+			t.Errorf("Valid input should be accepted: %s: %v", input, err)
+		}
+	}
+
+	obj, err := DecodeStrict([]byte(`{"s":"\ud800"}`), AllowLoneSurrogates())
+	if err != nil {
+		t.Fatalf("AllowLoneSurrogates should accept input: %v", err)
+	}
+	if obj["s"] != "�" {
+		t.Errorf("Lone surrogate should decode to U+FFFD, got %q", obj["s"])
+	}
+}
+
+// TestDecodeStrictMalformed tests rejection of malformed and ambiguous input
+func TestDecodeStrictMalformed(t *testing.T) {
+	cases := map[string]string{
+		"leading zero":  `{"n":012}`,
+		"trailing data": `{"a":1}{"b":2}`,
+		"not an object": `[1,2,3]`,
+		"invalid utf8":  "{\"s\":\"\xff\"}",
+		"truncated":     `{"a":`,
+	}
+
+	for name, input := range cases {
+		if _, err := DecodeStrict([]byte(input)); err == nil {
+			t.Errorf("%s should be rejected: %q", name, input)
+		}
+	}
+}
+
+// TestCanonicalizeJSON tests the raw JSON ingestion path end to end
+func TestCanonicalizeJSON(t *testing.T) {
+	raw := []byte("{\n  \"timestamp\": 1678886400.00,\n  \"is_valid\": false,\n  \"result\": null,\n  \"tags\": [\"b\", \"a\"]\n}")
+
+	canonical, err := CanonicalizeJSON(raw)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+
+	expected := `{"is_valid":false,"result":null,"tags":["a","b"],"timestamp":1678886400}`
+	if canonical != expected {
+		t.Errorf("Canonical form mismatch:\n  Expected: %s\n  Got:      %s", expected, canonical)
+	}
+
+	t.Logf("✓ Raw JSON canonicalized: %s", canonical)
+}
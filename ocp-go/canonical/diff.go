@@ -0,0 +1,158 @@
+// diff.go - Structural differences between canonical values
+//
+// CanonicalDiff reports how two JSON values differ, path by path, after both
+// are put in canonical order. Because it compares canonical forms, changes
+// that canonicalization erases (key order, whitespace, number spelling) are
+// not reported, so every difference listed is one that changes the hash.
+// Paths use the same syntax as hashing.PathIndex: object keys joined with "."
+// and array positions as "[i]".
+
+package canonical
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Difference kinds
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// Difference is a single change between two canonical values
+type Difference struct {
+	Path string
+	Kind string
+	// Old and New are the canonical JSON of the value before and after; Old is
+	// empty for additions and New for removals
+	Old string
+	New string
+}
+
+// String renders the difference as "+ path: new", "- path: old", or
+// "~ path: old -> new"
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "$"
+	}
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", path, d.New)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", path, d.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, d.Old, d.New)
+	}
+}
+
+// CanonicalDiff lists the differences between old and new in path order
+//
+// Parameters:
+//   - old, new: JSON-compatible values, typically decoded with DecodeStrict
+//
+// Returns:
+//   - Differences, empty if old and new have the same canonical form
+func CanonicalDiff(old, new interface{}) ([]Difference, error) {
+	var diffs []Difference
+	if err := Default.diff(&diffs, "", Default.DeepSort(old), Default.DeepSort(new)); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+func (c *Canonicalizer) diff(diffs *[]Difference, path string, old, new interface{}) error {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		c.sortKeys(keys)
+		for _, k := range keys {
+			child := diffPathKey(path, k)
+			o, inOld := oldMap[k]
+			n, inNew := newMap[k]
+			var err error
+			switch {
+			case !inNew:
+				err = c.addDiff(diffs, child, DiffRemoved, o, nil)
+			case !inOld:
+				err = c.addDiff(diffs, child, DiffAdded, nil, n)
+			default:
+				err = c.diff(diffs, child, o, n)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	oldArr, oldIsArr := old.([]interface{})
+	newArr, newIsArr := new.([]interface{})
+	if oldIsArr && newIsArr {
+		for i := 0; i < len(oldArr) || i < len(newArr); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			var err error
+			switch {
+			case i >= len(newArr):
+				err = c.addDiff(diffs, child, DiffRemoved, oldArr[i], nil)
+			case i >= len(oldArr):
+				err = c.addDiff(diffs, child, DiffAdded, nil, newArr[i])
+			default:
+				err = c.diff(diffs, child, oldArr[i], newArr[i])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	oldForm, err := c.jsonToCanonical(old)
+	if err != nil {
+		return err
+	}
+	newForm, err := c.jsonToCanonical(new)
+	if err != nil {
+		return err
+	}
+	if oldForm != newForm {
+		*diffs = append(*diffs, Difference{Path: path, Kind: DiffChanged, Old: oldForm, New: newForm})
+	}
+	return nil
+}
+
+func (c *Canonicalizer) addDiff(diffs *[]Difference, path, kind string, old, new interface{}) error {
+	d := Difference{Path: path, Kind: kind}
+	var err error
+	if kind == DiffRemoved {
+		d.Old, err = c.jsonToCanonical(old)
+	} else {
+		d.New, err = c.jsonToCanonical(new)
+	}
+	if err != nil {
+		return err
+	}
+	*diffs = append(*diffs, d)
+	return nil
+}
+
+var diffKeyEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `[`, `\[`, `]`, `\]`)
+
+func diffPathKey(path, key string) string {
+	key = diffKeyEscaper.Replace(key)
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
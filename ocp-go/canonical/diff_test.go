@@ -0,0 +1,38 @@
+package canonical
+
+import (
+	"testing"
+)
+
+// TestCanonicalDiff tests path-level differences between canonical values
+func TestCanonicalDiff(t *testing.T) {
+	old, err := DecodeStrict([]byte(`{"articles": {"I": "Sovereignty", "II": "Assembly"}, "version": 2.0, "tags": ["b", "a"]}`))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	same, _ := DecodeStrict([]byte(`{"tags": ["a", "b"], "version": 2, "articles": {"II": "Assembly", "I": "Sovereignty"}}`))
+	if diffs, err := CanonicalDiff(old, same); err != nil || len(diffs) != 0 {
+		t.Errorf("Canonically equal values should not differ: %v %v", diffs, err)
+	}
+
+	changed, _ := DecodeStrict([]byte(`{"articles": {"I": "Sovereignty", "III": "Archive"}, "version": 2.1, "tags": ["a", "b", "c"]}`))
+	diffs, err := CanonicalDiff(old, changed)
+	if err != nil {
+		t.Fatalf("Failed to diff: %v", err)
+	}
+	want := []string{
+		`- articles.II: "Assembly"`,
+		`+ articles.III: "Archive"`,
+		`+ tags[2]: "c"`,
+		`~ version: 2 -> 2.1`,
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("Expected %d differences, got %v", len(want), diffs)
+	}
+	for i, d := range diffs {
+		if d.String() != want[i] {
+			t.Errorf("Difference %d: expected %s, got %s", i, want[i], d)
+		}
+	}
+	t.Logf("✓ Reported %d differences", len(diffs))
+}
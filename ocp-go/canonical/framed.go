@@ -0,0 +1,173 @@
+// framed.go - Length-prefixed stream of canonical records
+//
+// A framed stream carries many OCP objects in one file or connection:
+//
+//	stream := magic record*
+//	magic  := "OCPF" 0x01
+//	record := length payload digest
+//
+// length is the payload size as a 4-byte big-endian unsigned integer, payload
+// is the object's canonical JSON, and digest is the 32-byte SHA-256 of the
+// payload (the object's semantic hash under the default profile). A reader can
+// therefore detect a truncated, corrupted, or non-canonical record without
+// trusting the sender.
+
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// FrameMagic starts every framed stream
+const FrameMagic = "OCPF\x01"
+
+// MaxFrameSize is the largest record payload a FrameReader accepts
+const MaxFrameSize = 64 << 20
+
+// EncodeFramed writes objects to w as a framed stream
+func EncodeFramed(w io.Writer, objects ...map[string]interface{}) error {
+	fw, err := NewFrameWriter(w)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := fw.Write(obj); err != nil {
+			return err
+		}
+	}
+	return fw.Flush()
+}
+
+// DecodeFramed reads every record of a framed stream
+//
+// Returns:
+//   - Decoded objects in stream order
+//   - CanonicalizationError identifying the first bad record
+func DecodeFramed(r io.Reader) ([]map[string]interface{}, error) {
+	fr, err := NewFrameReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]interface{}
+	for {
+		obj, err := fr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+}
+
+// FrameWriter writes records to a framed stream
+type FrameWriter struct {
+	w *bufio.Writer
+}
+
+// NewFrameWriter writes the stream header and returns a FrameWriter
+func NewFrameWriter(w io.Writer) (*FrameWriter, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(FrameMagic); err != nil {
+		return nil, err
+	}
+	return &FrameWriter{w: bw}, nil
+}
+
+// Write canonicalizes obj and appends it as one record
+func (fw *FrameWriter) Write(obj map[string]interface{}) error {
+	payload, err := Canonicalize(obj, true)
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxFrameSize {
+		return NewCanonicalizationError(fmt.Sprintf("record of %d bytes exceeds MaxFrameSize", len(payload)))
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+	digest := sha256.Sum256([]byte(payload))
+
+	if _, err := fw.w.Write(length[:]); err != nil {
+		return err
+	}
+	if _, err := fw.w.WriteString(payload); err != nil {
+		return err
+	}
+	_, err = fw.w.Write(digest[:])
+	return err
+}
+
+// Flush writes any buffered records to the underlying writer
+func (fw *FrameWriter) Flush() error {
+	return fw.w.Flush()
+}
+
+// FrameReader reads records from a framed stream one at a time
+type FrameReader struct {
+	r     *bufio.Reader
+	index int
+}
+
+// NewFrameReader reads and checks the stream header
+func NewFrameReader(r io.Reader) (*FrameReader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(FrameMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != FrameMagic {
+		return nil, NewCanonicalizationError("not a framed OCP stream")
+	}
+	return &FrameReader{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF at a clean end of stream. Records
+// are rejected if truncated, if their digest does not match, or if their
+// payload is not the canonical form of the object it decodes to.
+func (fr *FrameReader) Next() (map[string]interface{}, error) {
+	index := fr.index
+	var length [4]byte
+	if _, err := io.ReadFull(fr.r, length[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, frameError(index, "truncated length")
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size > MaxFrameSize {
+		return nil, frameError(index, fmt.Sprintf("length %d exceeds MaxFrameSize", size))
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(fr.r, payload); err != nil {
+		return nil, frameError(index, "truncated payload")
+	}
+	var digest [sha256.Size]byte
+	if _, err := io.ReadFull(fr.r, digest[:]); err != nil {
+		return nil, frameError(index, "truncated digest")
+	}
+	if sha256.Sum256(payload) != digest {
+		return nil, frameError(index, "digest mismatch")
+	}
+
+	obj, err := DecodeStrict(payload)
+	if err != nil {
+		return nil, frameError(index, err.Error())
+	}
+	canonical, err := Canonicalize(obj, true)
+	if err != nil || !bytes.Equal([]byte(canonical), payload) {
+		return nil, frameError(index, "payload is not canonical")
+	}
+
+	fr.index++
+	return obj, nil
+}
+
+func frameError(index int, message string) error {
+	return NewCanonicalizationError(fmt.Sprintf("framed record %d: %s", index, message))
+}
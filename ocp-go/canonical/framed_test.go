@@ -0,0 +1,118 @@
+package canonical
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+)
+
+// framedTestObjects returns a small batch of objects for framing tests
+func framedTestObjects() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"kind": "proposal", "id": "p-1", "tags": []interface{}{"b", "a"}},
+		{"kind": "vote", "voter": "Gemini", "approve": true},
+		{"kind": "challenge", "stake": float64(30), "note": "ünïcödé"},
+	}
+}
+
+// TestFramedRoundTrip tests encoding and decoding a batch of objects
+func TestFramedRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	objects := framedTestObjects()
+	if err := EncodeFramed(&buf, objects...); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+
+	decoded, err := DecodeFramed(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(decoded) != len(objects) {
+		t.Fatalf("Expected %d records, got %d", len(objects), len(decoded))
+	}
+	for i := range objects {
+		want, _ := Canonicalize(objects[i], true)
+		got, _ := Canonicalize(decoded[i], true)
+		if want != got {
+			t.Errorf("Record %d changed:\n  Expected: %s\n  Got:      %s", i, want, got)
+		}
+	}
+
+	empty := bytes.Buffer{}
+	EncodeFramed(&empty)
+	if records, err := DecodeFramed(&empty); err != nil || len(records) != 0 {
+		t.Errorf("Empty stream should decode to no records: %v", err)
+	}
+
+	t.Logf("✓ %d records round-tripped in %d bytes", len(decoded), buf.Len())
+}
+
+// TestFramedDetectsCorruption tests rejection of damaged streams
+func TestFramedDetectsCorruption(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeFramed(&buf, framedTestObjects()...)
+	stream := buf.Bytes()
+
+	flipped := append([]byte{}, stream...)
+	flipped[len(FrameMagic)+10] ^= 0x01
+
+	cases := map[string][]byte{
+		"bad magic":     append([]byte("JUNK\x01"), stream[len(FrameMagic):]...),
+		"flipped byte":  flipped,
+		"truncated":     stream[:len(stream)-5],
+		"non-canonical": framedRecord(`{"b":1, "a":2}`),
+		"huge length":   append([]byte(FrameMagic), 0xff, 0xff, 0xff, 0xff),
+	}
+
+	for name, data := range cases {
+		_, err := DecodeFramed(bytes.NewReader(data))
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+			continue
+		}
+		if name != "bad magic" && !strings.Contains(err.Error(), "framed record") {
+			t.Errorf("%s: error should identify the record: %v", name, err)
+		}
+	}
+
+	t.Logf("✓ %d kinds of corruption detected", len(cases))
+}
+
+// TestFrameReaderStreams tests reading records incrementally
+func TestFrameReaderStreams(t *testing.T) {
+	var buf bytes.Buffer
+	EncodeFramed(&buf, framedTestObjects()...)
+
+	fr, err := NewFrameReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	count := 0
+	for {
+		_, err := fr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Record %d: %v", count, err)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 records, got %d", count)
+	}
+}
+
+// framedRecord builds a stream holding one record with a valid digest
+func framedRecord(payload string) []byte {
+	var buf bytes.Buffer
+	fw, _ := NewFrameWriter(&buf)
+	fw.Flush()
+	out := buf.Bytes()
+	out = append(out, 0, 0, 0, byte(len(payload)))
+	out = append(out, payload...)
+	digest := sha256.Sum256([]byte(payload))
+	return append(out, digest[:]...)
+}
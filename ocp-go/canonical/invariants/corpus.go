@@ -0,0 +1,40 @@
+// corpus.go - Built-in documents the invariants are checked over
+//
+// The corpus is small enough to check at every node start, and covers each
+// construct the canonicalization rules treat specially: nesting, sortable and
+// ordered arrays, sets of objects, nulls, non-ASCII and escaped keys, and
+// numbers in integer, fractional, and exponent form.
+
+package invariants
+
+import (
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+var corpus = []string{
+	`{}`,
+	`{"z": 3, "a": 1, "b": {"d": "four", "c": [3, 1, 2]}}`,
+	`{"tags": ["gamma", "alpha", "beta"], "flags": [true, false, true], "empty": [], "nested": {"deeper": {"deepest": {}}}}`,
+	`{"proposals": [{"id": "p2", "score": 0.5}, {"id": "p1", "score": -1.25}], "mixed": [1, "one", true, null]}`,
+	`{"evidence": {"_set": [{"pointer": "sha256:bbb", "type": "computation"}, {"pointer": "sha256:aaa", "type": "archive_reference"}]}, "votes": {"_set": ["Gemini", "Claude"]}}`,
+	`{"x": null, "y": {"z": null, "w": 1}, "a": [null, {"n": null}]}`,
+	`{"｡": 1, "😀": 2, "a": 3, "é": "accent", "line\nbreak": "tab\there", "quote\"": "\u0001"}`,
+	`{"int": 42, "negative": -7, "big": 9007199254740991, "fraction": 0.1, "tiny": 1e-7, "huge": 1.5e300, "million": 1000000}`,
+	`{"agent": {"name": "Claude", "role": "proposer"}, "reputation": {"Claude": 12, "Gemini": 3.5}, "signers": [{"agent": "Claude", "signature": "ab"}, {"agent": "Gemini", "signature": "cd"}]}`,
+}
+
+// Corpus returns the built-in documents, decoded afresh on each call so that
+// callers may modify them
+func Corpus() []map[string]interface{} {
+	docs := make([]map[string]interface{}, len(corpus))
+	for i, text := range corpus {
+		doc, err := canonical.DecodeStrict([]byte(text))
+		if err != nil {
+			panic(fmt.Sprintf("invariants: corpus document %d: %v", i, err))
+		}
+		docs[i] = doc
+	}
+	return docs
+}
@@ -0,0 +1,189 @@
+// Package invariants checks the core properties of canonicalization.
+//
+// Known-answer vectors pin the output of one profile on a handful of
+// documents. The invariants here state what must hold for every profile and
+// every document, so they also cover profiles no vector was written for:
+//
+//   - order independence: reordering object members, same-typed primitive
+//     arrays, or set elements does not change the canonical form
+//   - sensitivity: changing any value or renaming any member does change it
+//   - idempotence: canonicalizing the decoded canonical form reproduces it
+//     exactly, and canonicalizing leaves the input untouched
+//   - cross-type stability: the typed forms DeepSort accepts
+//     (map[string]string, map[string]float64, []map[string]string, Go
+//     integers) encode exactly as their decoded JSON equivalents
+//
+// Check runs every invariant over a built-in corpus. CI runs it against
+// each supported profile, and nodes run it at startup (ocp.SelfTest) and
+// before trusting a custom canonical.New profile.
+package invariants
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Invariant names, in the order Check runs them
+const (
+	OrderIndependence  = "order_independence"
+	Sensitivity        = "sensitivity"
+	Idempotence        = "idempotence"
+	CrossTypeStability = "cross_type_stability"
+)
+
+// Profile is a canonicalization profile under test; *canonical.Canonicalizer
+// implements it
+type Profile interface {
+	Canonicalize(data map[string]interface{}, strict bool) (string, error)
+}
+
+// Invariant is one property checked against a profile and a document
+type Invariant struct {
+	Name  string
+	Check func(p Profile, doc map[string]interface{}) error
+}
+
+// Invariants returns the core invariants
+func Invariants() []Invariant {
+	return []Invariant{
+		{OrderIndependence, checkOrderIndependence},
+		{Sensitivity, checkSensitivity},
+		{Idempotence, checkIdempotence},
+		{CrossTypeStability, checkCrossTypeStability},
+	}
+}
+
+// Violation is an invariant that failed on one document
+type Violation struct {
+	Invariant string
+	// Document is the index of the document in the checked corpus
+	Document int
+	Detail   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s on document %d: %s", v.Invariant, v.Document, v.Detail)
+}
+
+// Error reports the violations found by Check
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "canonicalization invariants violated: " + strings.Join(parts, "; ")
+}
+
+// Check runs every invariant against p over docs, or over Corpus if none are
+// given
+//
+// Returns:
+//   - *Error listing every violation, or nil
+func Check(p Profile, docs ...map[string]interface{}) error {
+	if len(docs) == 0 {
+		docs = Corpus()
+	}
+	var violations []Violation
+	for _, inv := range Invariants() {
+		for i, doc := range docs {
+			// Each invariant gets its own copy, so a profile that modifies
+			// its input is caught by Idempotence alone
+			if err := inv.Check(p, canonical.DeepCopy(doc).(map[string]interface{})); err != nil {
+				violations = append(violations, Violation{Invariant: inv.Name, Document: i, Detail: err.Error()})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+// checkOrderIndependence compares doc with a copy whose members, sortable
+// arrays, and sets are in reverse order
+func checkOrderIndependence(p Profile, doc map[string]interface{}) error {
+	want, err := p.Canonicalize(doc, true)
+	if err != nil {
+		return err
+	}
+	got, err := p.Canonicalize(reorder(doc).(map[string]interface{}), true)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("reordered input gives %s, expected %s", got, want)
+	}
+	return nil
+}
+
+// checkSensitivity requires every single-value or single-key mutation of doc
+// to change its canonical form
+func checkSensitivity(p Profile, doc map[string]interface{}) error {
+	original, err := p.Canonicalize(doc, true)
+	if err != nil {
+		return err
+	}
+	for _, m := range mutants(doc, "$") {
+		form, err := p.Canonicalize(m.value.(map[string]interface{}), true)
+		if err != nil {
+			return fmt.Errorf("%s: %v", m.path, err)
+		}
+		if form == original {
+			return fmt.Errorf("changing %s leaves the canonical form %s", m.path, form)
+		}
+	}
+	return nil
+}
+
+// checkIdempotence requires the canonical form to be a fixed point and the
+// input to be left as it was
+func checkIdempotence(p Profile, doc map[string]interface{}) error {
+	before := canonical.DeepCopy(doc)
+	first, err := p.Canonicalize(doc, true)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(doc, before) {
+		return fmt.Errorf("canonicalization modified its input")
+	}
+	decoded, err := canonical.DecodeStrict([]byte(first))
+	if err != nil {
+		return fmt.Errorf("canonical form %s does not decode: %v", first, err)
+	}
+	second, err := p.Canonicalize(decoded, true)
+	if err != nil {
+		return err
+	}
+	if second != first {
+		return fmt.Errorf("canonical form %s recanonicalizes to %s", first, second)
+	}
+	return nil
+}
+
+// checkCrossTypeStability compares doc with a copy using the typed forms
+func checkCrossTypeStability(p Profile, doc map[string]interface{}) error {
+	want, err := p.Canonicalize(doc, true)
+	if err != nil {
+		return err
+	}
+	// The document itself must stay a map[string]interface{}
+	typed := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		typed[k] = retype(v, false)
+	}
+	got, err := p.Canonicalize(typed, true)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("typed input gives %s, expected %s", got, want)
+	}
+	return nil
+}
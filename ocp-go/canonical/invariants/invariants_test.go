@@ -0,0 +1,133 @@
+package invariants
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// profileFunc adapts a function to Profile
+type profileFunc func(map[string]interface{}) (string, error)
+
+func (f profileFunc) Canonicalize(data map[string]interface{}, strict bool) (string, error) {
+	return f(data)
+}
+
+// TestProfiles tests that every built-in profile satisfies the invariants
+func TestProfiles(t *testing.T) {
+	orders := []canonical.KeyOrder{canonical.KeyOrderUTF8}
+	if canonical.UTF16KeyOrderBuild {
+		orders = append(orders, canonical.KeyOrderUTF16)
+	}
+	checked := 0
+	for _, order := range orders {
+		for _, nulls := range []canonical.NullHandling{canonical.KeepNulls, canonical.DropNulls} {
+			c := canonical.New(canonical.WithKeyOrder(order), canonical.WithNullHandling(nulls))
+			if err := Check(c); err != nil {
+				t.Errorf("Profile %s: %v", c.Version(), err)
+			}
+			checked++
+		}
+	}
+	for _, version := range canonical.SupportedVersions() {
+		c, _ := canonical.ForVersion(version)
+		if err := Check(c); err != nil {
+			t.Errorf("Version %s: %v", version, err)
+		}
+		checked++
+	}
+	t.Logf("✓ %d profiles satisfy %d invariants over %d documents", checked, len(Invariants()), len(Corpus()))
+}
+
+// TestMutants tests that deliberately broken canonicalizers are caught by
+// the invariant they break
+func TestMutants(t *testing.T) {
+	mutants := []struct {
+		name    string
+		profile profileFunc
+		breaks  string
+	}{
+		{
+			// encoding/json sorts keys but keeps array and set order
+			name: "unsorted arrays",
+			profile: func(doc map[string]interface{}) (string, error) {
+				b, err := json.Marshal(doc)
+				return string(b), err
+			},
+			breaks: OrderIndependence,
+		},
+		{
+			name: "keys only",
+			profile: func(doc map[string]interface{}) (string, error) {
+				keys := make([]string, 0, len(doc))
+				for k := range doc {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				return strings.Join(keys, ","), nil
+			},
+			breaks: Sensitivity,
+		},
+		{
+			name: "wraps its input",
+			profile: func(doc map[string]interface{}) (string, error) {
+				return canonical.Canonicalize(map[string]interface{}{"v": doc}, true)
+			},
+			breaks: Idempotence,
+		},
+		{
+			name: "modifies its input",
+			profile: func(doc map[string]interface{}) (string, error) {
+				form, err := canonical.Canonicalize(doc, true)
+				doc["seen"] = true
+				return form, err
+			},
+			breaks: Idempotence,
+		},
+		{
+			name: "encodes Go types",
+			profile: func(doc map[string]interface{}) (string, error) {
+				form, err := canonical.Canonicalize(doc, true)
+				return fmt.Sprintf("%s %#v", form, doc), err
+			},
+			breaks: CrossTypeStability,
+		},
+	}
+
+	for _, m := range mutants {
+		var invErr *Error
+		if err := Check(m.profile); !errors.As(err, &invErr) {
+			t.Errorf("Mutant %q passed the invariants", m.name)
+			continue
+		}
+		caught := false
+		for _, v := range invErr.Violations {
+			caught = caught || v.Invariant == m.breaks
+		}
+		if !caught {
+			t.Errorf("Mutant %q was not caught by %s: %v", m.name, m.breaks, invErr)
+		}
+	}
+	t.Logf("✓ %d mutants caught", len(mutants))
+}
+
+// TestTransformsKeepInput tests that the transformations do not modify the
+// documents they are given
+func TestTransformsKeepInput(t *testing.T) {
+	docs, originals := Corpus(), Corpus()
+	for i, doc := range docs {
+		reorder(doc)
+		retype(doc, false)
+		mutants(doc, "$")
+		if !reflect.DeepEqual(doc, originals[i]) {
+			t.Errorf("Document %d was modified", i)
+		}
+	}
+	t.Log("✓ Transformations leave their input unchanged")
+}
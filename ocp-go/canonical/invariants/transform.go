@@ -0,0 +1,243 @@
+// transform.go - Document transformations used by the invariants
+//
+// Each transformation returns a new document and never modifies its input:
+// reorder produces an equivalent document, retype an equivalent document in
+// typed Go forms, and mutants every document that differs from the input in
+// exactly one value or member name.
+
+package invariants
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// maxExactInt is the largest integer every float64 represents exactly
+const maxExactInt = 1 << 53
+
+// reorder rebuilds v with object members inserted in reverse key order, and
+// with sortable arrays and set elements reversed
+func reorder(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		out := make(map[string]interface{}, len(v))
+		for i := len(keys) - 1; i >= 0; i-- {
+			out[keys[i]] = reorder(v[keys[i]])
+		}
+		if elems, ok := out[canonical.SetKey].([]interface{}); ok && len(out) == 1 {
+			out[canonical.SetKey] = reversed(elems)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = reorder(elem)
+		}
+		if sortable(out) {
+			return reversed(out)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// sortable reports whether the canonicalizer sorts arr: its elements are
+// primitives of one type
+func sortable(arr []interface{}) bool {
+	for _, elem := range arr {
+		switch elem.(type) {
+		case string, float64, bool, nil:
+		default:
+			return false
+		}
+		if fmt.Sprintf("%T", elem) != fmt.Sprintf("%T", arr[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func reversed(arr []interface{}) []interface{} {
+	out := make([]interface{}, len(arr))
+	for i, elem := range arr {
+		out[len(arr)-1-i] = elem
+	}
+	return out
+}
+
+// retype converts v to the typed forms DeepSort accepts wherever they can
+// hold it. Integral numbers become int64 only as object members: arrays of
+// Go integers are not sorted like arrays of numbers, and the protocol never
+// builds them.
+func retype(v interface{}, inArray bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if s, ok := stringMap(v); ok {
+			return s
+		}
+		if f, ok := floatMap(v); ok {
+			return f
+		}
+		if elems, ok := v[canonical.SetKey].([]interface{}); ok && len(v) == 1 {
+			// The set wrapper must stay a []interface{} to be recognized
+			out := make([]interface{}, len(elems))
+			for i, elem := range elems {
+				out[i] = retype(elem, true)
+			}
+			return map[string]interface{}{canonical.SetKey: out}
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			out[k] = retype(val, false)
+		}
+		return out
+	case []interface{}:
+		if maps, ok := stringMaps(v); ok {
+			return maps
+		}
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = retype(elem, true)
+		}
+		return out
+	case float64:
+		if !inArray && v == math.Trunc(v) && math.Abs(v) <= maxExactInt && !math.Signbit(v) {
+			return int64(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// stringMap returns m as a map[string]string if it is non-empty and every
+// value is a string
+func stringMap(m map[string]interface{}) (map[string]string, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		s, ok := val.(string)
+		if !ok {
+			return nil, false
+		}
+		out[k] = s
+	}
+	return out, true
+}
+
+// stringMaps returns arr as a []map[string]string if it is non-empty and
+// every element is a map stringMap converts
+func stringMaps(arr []interface{}) ([]map[string]string, bool) {
+	if len(arr) == 0 {
+		return nil, false
+	}
+	out := make([]map[string]string, len(arr))
+	for i, elem := range arr {
+		m, _ := elem.(map[string]interface{})
+		s, ok := stringMap(m)
+		if !ok {
+			return nil, false
+		}
+		out[i] = s
+	}
+	return out, true
+}
+
+// floatMap returns m as a map[string]float64 if it is non-empty and every
+// value is a number
+func floatMap(m map[string]interface{}) (map[string]float64, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	out := make(map[string]float64, len(m))
+	for k, val := range m {
+		f, ok := val.(float64)
+		if !ok {
+			return nil, false
+		}
+		out[k] = f
+	}
+	return out, true
+}
+
+// mutant is a document differing from the original at path
+type mutant struct {
+	path  string
+	value interface{}
+}
+
+// mutants returns every copy of v with one primitive value changed or one
+// non-null object member renamed. Containers along the changed path are
+// copied; the rest is shared with v.
+func mutants(v interface{}, path string) []mutant {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var out []mutant
+		for _, k := range sortedKeys(v) {
+			for _, m := range mutants(v[k], fmt.Sprintf("%s[%q]", path, k)) {
+				copied := copyMap(v)
+				copied[k] = m.value
+				out = append(out, mutant{m.path, copied})
+			}
+			// A null member may be dropped by the profile, so renaming it
+			// need not change anything
+			renamed := k + "~"
+			if _, taken := v[renamed]; v[k] == nil || taken {
+				continue
+			}
+			copied := copyMap(v)
+			delete(copied, k)
+			copied[renamed] = v[k]
+			out = append(out, mutant{fmt.Sprintf("%s[%q] name", path, k), copied})
+		}
+		return out
+	case []interface{}:
+		var out []mutant
+		for i, elem := range v {
+			for _, m := range mutants(elem, fmt.Sprintf("%s[%d]", path, i)) {
+				copied := append([]interface{}(nil), v...)
+				copied[i] = m.value
+				out = append(out, mutant{m.path, copied})
+			}
+		}
+		return out
+	case string:
+		return []mutant{{path, v + "~"}}
+	case float64:
+		// Neither change has a fixed point among finite numbers
+		if math.Abs(v) > 1 {
+			return []mutant{{path, v / 2}}
+		}
+		return []mutant{{path, v + 1}}
+	case bool:
+		return []mutant{{path, !v}}
+	case nil:
+		return []mutant{{path, false}}
+	default:
+		return nil
+	}
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		out[k] = val
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,14 @@
+//go:build ocp_noutf16
+
+// noutf16.go - Build profile without UTF-16 key order
+//
+// Building with -tags ocp_noutf16 compiles out KeyOrderUTF16 for targets that
+// only ever hash under the default UTF-8 order. Canonicalizers configured
+// with it refuse to encode, and ForVersion refuses its versions, with a
+// *CapabilityError.
+
+package canonical
+
+// UTF16KeyOrderBuild reports whether KeyOrderUTF16 is compiled in; false
+// under the ocp_noutf16 tag
+const UTF16KeyOrderBuild = false
@@ -0,0 +1,7 @@
+//go:build !ocp_noutf16
+
+package canonical
+
+// UTF16KeyOrderBuild reports whether KeyOrderUTF16 is compiled in; false
+// under the ocp_noutf16 tag
+const UTF16KeyOrderBuild = true
@@ -0,0 +1,131 @@
+// number.go - Lexical passthrough of JSON numbers
+//
+// Decoding a number to float64 and printing it again is where implementations
+// diverge most: parsers round differently at the edges, and printers choose
+// different digits and exponent thresholds (Rule 2.4.1). Data that arrives as
+// JSON text and is only being verified need not take that round trip at all.
+// DecodeStrict with PreserveNumbers keeps every number as the json.Number
+// lexeme it was written as, and the encoder emits a lexeme verbatim whenever
+// it is already the canonical spelling of its value. Any other lexeme is
+// normalized through float64, exactly as a decoded float would be, so both
+// paths produce the same bytes and only the choice of path differs.
+//
+// Canonicality is decided from the digits alone, conservatively: a lexeme is
+// passed through if it is
+//   - an integer without sign on zero, leading zeros, or exponent, of
+//     magnitude at most 2^53, which %.0f prints digit for digit; or
+//   - a fraction without exponent, leading zeros, or trailing zeros, of at
+//     most 15 significant digits and magnitude in [1e-4, 1e6), which the
+//     shortest round-trip form prints digit for digit, since every decimal of
+//     15 significant digits survives float64 unchanged.
+//
+// Lexemes outside those forms may still be canonical, but are normalized
+// rather than judged, which yields the same output.
+
+package canonical
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+)
+
+// maxPassthroughDigits is the most significant digits of a fraction that is
+// certain to print back unchanged from float64
+const maxPassthroughDigits = 15
+
+// maxExactDigits spells maxExactInteger, 2^53
+const maxExactDigits = "9007199254740992"
+
+// canonicalLexeme reports whether s is a number the encoder would print as s
+func canonicalLexeme(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	intStart := i
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	intDigits := s[intStart:i]
+	if len(intDigits) == 0 || (len(intDigits) > 1 && intDigits[0] == '0') {
+		return false
+	}
+
+	if i == len(s) {
+		if intDigits == "0" {
+			return intStart == 0
+		}
+		return len(intDigits) < len(maxExactDigits) ||
+			len(intDigits) == len(maxExactDigits) && intDigits <= maxExactDigits
+	}
+
+	if s[i] != '.' {
+		return false
+	}
+	i++
+	fracDigits := s[i:]
+	if len(fracDigits) == 0 || fracDigits[len(fracDigits)-1] == '0' {
+		return false
+	}
+	for j := 0; j < len(fracDigits); j++ {
+		if !isDigit(fracDigits[j]) {
+			return false
+		}
+	}
+
+	if intDigits != "0" {
+		return len(intDigits) <= 6 && len(intDigits)+len(fracDigits) <= maxPassthroughDigits
+	}
+	zeros := 0
+	for zeros < len(fracDigits) && fracDigits[zeros] == '0' {
+		zeros++
+	}
+	return zeros <= 3 && len(fracDigits)-zeros <= maxPassthroughDigits
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// normalizeNumber returns n if it is canonical, otherwise its float64 value.
+// A number beyond the float64 range is returned unchanged, for the encoder
+// to refuse.
+func normalizeNumber(n json.Number) interface{} {
+	if canonicalLexeme(string(n)) {
+		return n
+	}
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) {
+		return n
+	}
+	return f
+}
+
+// numberExact reports whether the float64 n normalizes to has n's exact value
+func numberExact(n json.Number) bool {
+	if canonicalLexeme(string(n)) {
+		return true
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return false
+	}
+	exact, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return false
+	}
+	return new(big.Rat).SetFloat64(f).Cmp(exact) == 0
+}
+
+// numberValue returns the value of a float64 or json.Number for ordering
+func numberValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,104 @@
+package canonical
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestPreserveNumbersMatchesFloat tests that passthrough and float decoding
+// canonicalize every number to the same bytes, and that canonical lexemes
+// are the ones passed through
+func TestPreserveNumbersMatchesFloat(t *testing.T) {
+	lexemes := []string{
+		"0", "-0", "1", "-1", "10", "1.0", "1.50", "0.1", "0.0001", "0.00001",
+		"123456.5", "1234567.5", "1e6", "1E+2", "2.5e-3", "012", "9007199254740992",
+		"-9007199254740992", "9007199254740993", "123456789012345678901234567890",
+		"0.1234567890123456789", "3.141592653589793", "0.30000000000000004",
+		"999999.999999999", "1e-7", "5e-324", "1.7976931348623157e308",
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		f := math.Float64frombits(rng.Uint64())
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		scaled := f / math.Pow(10, float64(rng.Intn(300)))
+		lexemes = append(lexemes,
+			strconv.FormatFloat(scaled, 'g', -1, 64),
+			strconv.FormatFloat(rng.Float64()*math.Pow(10, float64(rng.Intn(12)-5)), 'f', rng.Intn(17), 64),
+			strconv.FormatInt(rng.Int63n(1<<54)-1<<53, 10),
+		)
+	}
+
+	passed := 0
+	for _, lexeme := range lexemes {
+		if _, err := DecodeStrict([]byte(`{"n":` + lexeme + `}`)); err != nil {
+			continue // not a JSON number, such as 012
+		}
+		raw := []byte(`{"n":` + lexeme + `,"list":[` + lexeme + `,2,-1.5]}`)
+		want, err := CanonicalizeJSON(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", lexeme, err)
+		}
+		got, err := CanonicalizeJSON(raw, PreserveNumbers())
+		if err != nil {
+			t.Fatalf("%s: %v", lexeme, err)
+		}
+		if got != want {
+			t.Errorf("%s: passthrough gave %s, float decoding %s", lexeme, got, want)
+		}
+		if canonicalLexeme(lexeme) {
+			if !strings.Contains(got, `"n":`+lexeme+`}`) {
+				t.Errorf("%s: canonical lexeme not emitted verbatim in %s", lexeme, got)
+			}
+			passed++
+		}
+	}
+	for _, lexeme := range []string{"1.0", "1e6", "-0", "9007199254740993", "0.00001"} {
+		if canonicalLexeme(lexeme) {
+			t.Errorf("%s should be normalized, not passed through", lexeme)
+		}
+	}
+	t.Logf("✓ %d lexemes agree with float decoding, %d passed through verbatim", len(lexemes), passed)
+}
+
+// TestPreserveNumbersDecode tests the decoded types and the numbers the
+// encoder refuses
+func TestPreserveNumbersDecode(t *testing.T) {
+	obj, err := DecodeStrict([]byte(`{"a":0.1,"b":[3,1]}`), PreserveNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj["a"] != json.Number("0.1") {
+		t.Errorf("Expected a json.Number, got %T %v", obj["a"], obj["a"])
+	}
+
+	obj, err = DecodeStrict([]byte(`{"huge":1e400}`), PreserveNumbers())
+	if err != nil {
+		t.Fatalf("Passthrough decoding should accept any JSON number: %v", err)
+	}
+	if _, err := Canonicalize(obj, true); err == nil || !strings.Contains(err.Error(), "float64 range") {
+		t.Errorf("Expected a number beyond float64 to be refused, got %v", err)
+	}
+
+	audit, err := CanonicalizeAudited(map[string]interface{}{
+		"exact": json.Number("0.25"),
+		"round": json.Number("1.0"),
+		"lossy": json.Number("9007199254740993"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lossy := make(map[string]bool)
+	for _, c := range audit.Coercions {
+		lossy[c.Path] = c.Lossy
+	}
+	if lossy["exact"] || lossy["round"] || !lossy["lossy"] {
+		t.Errorf("Expected only 9007199254740993 to be lossy, got %v", lossy)
+	}
+	t.Logf("✓ Lexemes decoded as json.Number; %s", audit.Canonical)
+}
@@ -0,0 +1,216 @@
+// options.go - Configurable canonicalization
+//
+// The package-level functions use Default, which reproduces the original
+// reference behavior. A Canonicalizer built with options changes specific rules,
+// applied consistently by DeepSort and the encoder.
+
+package canonical
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// KeyOrder selects how object keys (and string array elements) are ordered
+type KeyOrder int
+
+const (
+	// KeyOrderUTF8 orders strings by their UTF-8 bytes, which equals Unicode
+	// code point order. This is the default.
+	KeyOrderUTF8 KeyOrder = iota
+	// KeyOrderUTF16 orders strings by their UTF-16 code units, as required by
+	// RFC 8785 (JCS). It differs from KeyOrderUTF8 only for characters outside
+	// the Basic Multilingual Plane, whose surrogates sort before U+E000..U+FFFF.
+	KeyOrderUTF16
+)
+
+// String returns the name used in test vectors and configuration
+func (o KeyOrder) String() string {
+	switch o {
+	case KeyOrderUTF16:
+		return "utf16"
+	default:
+		return "utf8"
+	}
+}
+
+// NullHandling selects whether object members with null values are kept
+type NullHandling int
+
+const (
+	// KeepNulls encodes null members, so {"x": null} and {} hash differently.
+	// This is the default.
+	KeepNulls NullHandling = iota
+	// DropNulls removes null members from every object before encoding, so
+	// {"x": null} and {} hash identically. Null array elements are kept, since
+	// removing them would shift positions.
+	DropNulls
+)
+
+// String returns the name used in test vectors and configuration
+func (h NullHandling) String() string {
+	switch h {
+	case DropNulls:
+		return "drop"
+	default:
+		return "keep"
+	}
+}
+
+// SpecVersion is the version of canonical_json_spec.md this package implements
+const SpecVersion = "1.0.0"
+
+// Canonicalizer applies the canonicalization rules with a fixed set of options
+type Canonicalizer struct {
+	keyOrder      KeyOrder
+	nullHandling  NullHandling
+	defensiveCopy bool
+	// workers and parallelThreshold control parallel processing of wide
+	// objects, which never changes output
+	workers           int
+	parallelThreshold int
+}
+
+// Option configures a Canonicalizer
+type Option func(*Canonicalizer)
+
+// WithKeyOrder sets the ordering of object keys and string array elements
+func WithKeyOrder(order KeyOrder) Option {
+	return func(c *Canonicalizer) {
+		c.keyOrder = order
+	}
+}
+
+// WithNullHandling sets whether null object members are kept or dropped
+func WithNullHandling(h NullHandling) Option {
+	return func(c *Canonicalizer) {
+		c.nullHandling = h
+	}
+}
+
+// WithDefensiveCopy makes DeepSort, Canonicalize, and CanonicalizeValue take a
+// DeepCopy of their input before reading it, and guarantees that DeepSort
+// results share no mutable structure with the input. See DeepCopy for the
+// aliasing contract.
+func WithDefensiveCopy() Option {
+	return func(c *Canonicalizer) {
+		c.defensiveCopy = true
+	}
+}
+
+// Default is the canonicalizer used by the package-level functions
+var Default = New()
+
+// New creates a Canonicalizer with the given options
+func New(opts ...Option) *Canonicalizer {
+	c := &Canonicalizer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// KeyOrder returns the configured key ordering
+func (c *Canonicalizer) KeyOrder() KeyOrder {
+	return c.keyOrder
+}
+
+// NullHandling returns the configured null member policy
+func (c *Canonicalizer) NullHandling() NullHandling {
+	return c.nullHandling
+}
+
+// Version identifies the spec version and every option that changes output,
+// e.g. "1.0.0+key_order=utf8;nulls=keep". Two canonicalizers produce identical
+// bytes for all inputs exactly when their versions are equal, so the version
+// is recorded alongside hashes that other parties must reproduce.
+func (c *Canonicalizer) Version() string {
+	return SpecVersion + "+key_order=" + c.keyOrder.String() + ";nulls=" + c.nullHandling.String()
+}
+
+// SupportedVersions returns the Version of every option combination this
+// build implements, sorted
+func SupportedVersions() []string {
+	var versions []string
+	for _, order := range []KeyOrder{KeyOrderUTF8, KeyOrderUTF16} {
+		for _, nulls := range []NullHandling{KeepNulls, DropNulls} {
+			if c := New(WithKeyOrder(order), WithNullHandling(nulls)); c.supported() == nil {
+				versions = append(versions, c.Version())
+			}
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// ForVersion returns a canonicalizer whose Version is version, so a hash
+// recorded with its version can be reproduced. A version that needs a feature
+// this build omits fails with a *CapabilityError.
+func ForVersion(version string) (*Canonicalizer, error) {
+	for _, order := range []KeyOrder{KeyOrderUTF8, KeyOrderUTF16} {
+		for _, nulls := range []NullHandling{KeepNulls, DropNulls} {
+			if c := New(WithKeyOrder(order), WithNullHandling(nulls)); c.Version() == version {
+				if err := c.supported(); err != nil {
+					return nil, err
+				}
+				return c, nil
+			}
+		}
+	}
+	return nil, NewCanonicalizationError(fmt.Sprintf("unsupported canonical version %q", version))
+}
+
+// DefensiveCopy reports whether inputs are copied before canonicalization
+func (c *Canonicalizer) DefensiveCopy() bool {
+	return c.defensiveCopy
+}
+
+// less compares two strings under the configured key ordering
+func (c *Canonicalizer) less(a, b string) bool {
+	if UTF16KeyOrderBuild && c.keyOrder == KeyOrderUTF16 {
+		return lessUTF16(a, b)
+	}
+	return a < b
+}
+
+// SortKeys sorts object keys into the order the encoder writes them, for
+// callers that assemble canonical objects from canonical members
+func (c *Canonicalizer) SortKeys(keys []string) {
+	c.sortKeys(keys)
+}
+
+// sortKeys sorts object keys under the configured key ordering
+func (c *Canonicalizer) sortKeys(keys []string) {
+	if UTF16KeyOrderBuild && c.keyOrder == KeyOrderUTF16 {
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		return
+	}
+	sort.Strings(keys)
+}
+
+// lessUTF16 compares strings by UTF-16 code units without allocating
+func lessUTF16(a, b string) bool {
+	for a != "" && b != "" {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			ua, ub := firstUnitUTF16(ra), firstUnitUTF16(rb)
+			if ua != ub {
+				return ua < ub
+			}
+			// Same high surrogate: the low surrogates decide
+			return ra < rb
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return a == "" && b != ""
+}
+
+// firstUnitUTF16 returns the first UTF-16 code unit encoding r
+func firstUnitUTF16(r rune) rune {
+	if r >= 0x10000 {
+		return 0xD800 + ((r - 0x10000) >> 10)
+	}
+	return r
+}
@@ -0,0 +1,167 @@
+package canonical
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"unicode/utf16"
+)
+
+// TestKeyOrderSurrogates tests ordering of keys around the surrogate range
+func TestKeyOrderSurrogates(t *testing.T) {
+	// U+FF61 (halfwidth ideographic full stop) and U+1F600 (emoji, surrogates D83D DE00)
+	data := map[string]interface{}{
+		"｡":          float64(1),
+		"\U0001F600": float64(2),
+		"a":          float64(3),
+	}
+
+	utf8Form, err := Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	if expected := "{\"a\":3,\"｡\":1,\"\U0001F600\":2}"; utf8Form != expected {
+		t.Errorf("UTF-8 order mismatch:\n  Expected: %s\n  Got:      %s", expected, utf8Form)
+	}
+	t.Logf("✓ UTF-8:  %s", utf8Form)
+
+	skipWithoutUTF16(t)
+	utf16Form, err := New(WithKeyOrder(KeyOrderUTF16)).Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	if expected := "{\"a\":3,\"\U0001F600\":2,\"｡\":1}"; utf16Form != expected {
+		t.Errorf("UTF-16 order mismatch:\n  Expected: %s\n  Got:      %s", expected, utf16Form)
+	}
+
+	t.Logf("✓ UTF-16: %s", utf16Form)
+}
+
+// TestKeyOrderStringArrays tests that string arrays follow the same ordering as keys
+func TestKeyOrderStringArrays(t *testing.T) {
+	skipWithoutUTF16(t)
+	data := map[string]interface{}{
+		"tags": []interface{}{"｡", "\U0001F600"},
+	}
+
+	utf16Form, err := New(WithKeyOrder(KeyOrderUTF16)).Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	if expected := "{\"tags\":[\"\U0001F600\",\"｡\"]}"; utf16Form != expected {
+		t.Errorf("UTF-16 array order mismatch: %s", utf16Form)
+	}
+}
+
+// TestSortKeysMatchesEncoding tests that SortKeys orders keys as Canonicalize writes them
+func TestSortKeysMatchesEncoding(t *testing.T) {
+	for _, order := range keyOrders() {
+		c := New(WithKeyOrder(order))
+		keys := []string{"｡", "\U0001F600", "a"}
+		c.SortKeys(keys)
+		data := map[string]interface{}{}
+		want := "{"
+		for i, k := range keys {
+			data[k] = float64(i)
+			if i > 0 {
+				want += ","
+			}
+			want += fmt.Sprintf("%q:%d", k, i)
+		}
+		if got, _ := c.Canonicalize(data, true); got != want+"}" {
+			t.Errorf("%s: SortKeys order %v does not match %s", c.Version(), keys, got)
+		}
+	}
+
+	t.Logf("✓ SortKeys matches the encoder under %d key orders", len(keyOrders()))
+}
+
+// TestLessUTF16MatchesEncoding tests the allocation-free comparator against utf16.Encode
+func TestLessUTF16MatchesEncoding(t *testing.T) {
+	words := []string{"", "a", "ab", "￿", "\U00010000", "\U0010FFFF", "퟿", "", "z\U0001F600", "z｡"}
+
+	reference := func(a, b string) bool {
+		ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+		for i := 0; i < len(ua) && i < len(ub); i++ {
+			if ua[i] != ub[i] {
+				return ua[i] < ub[i]
+			}
+		}
+		return len(ua) < len(ub)
+	}
+
+	for _, a := range words {
+		for _, b := range words {
+			if lessUTF16(a, b) != reference(a, b) {
+				t.Errorf("lessUTF16(%q, %q) disagrees with UTF-16 encoding", a, b)
+			}
+		}
+	}
+
+	sorted := append([]string(nil), words...)
+	sort.Slice(sorted, func(i, j int) bool { return lessUTF16(sorted[i], sorted[j]) })
+	t.Logf("✓ UTF-16 order: %q", sorted)
+}
+
+// TestNullHandling tests keeping and dropping null object members
+func TestNullHandling(t *testing.T) {
+	withNull := map[string]interface{}{
+		"x": nil,
+		"y": map[string]interface{}{"z": nil, "w": 1.0},
+		"a": []interface{}{nil, map[string]interface{}{"n": nil}},
+	}
+	without := map[string]interface{}{
+		"y": map[string]interface{}{"w": 1.0},
+		"a": []interface{}{nil, map[string]interface{}{}},
+	}
+
+	kept, _ := Canonicalize(withNull, true)
+	if kept != `{"a":[null,{"n":null}],"x":null,"y":{"w":1,"z":null}}` {
+		t.Errorf("Default should keep nulls, got %s", kept)
+	}
+
+	drop := New(WithNullHandling(DropNulls))
+	dropped, _ := drop.Canonicalize(withNull, true)
+	expected, _ := drop.Canonicalize(without, true)
+	if dropped != expected || dropped != `{"a":[null,{}],"y":{"w":1}}` {
+		t.Errorf("DropNulls mismatch:\n  Expected: %s\n  Got:      %s", expected, dropped)
+	}
+
+	if v := Default.Version(); v != "1.0.0+key_order=utf8;nulls=keep" {
+		t.Errorf("Unexpected default version %s", v)
+	}
+	if drop.Version() == Default.Version() {
+		t.Errorf("Null handling should be recorded in the version")
+	}
+
+	t.Logf("✓ %s: %s", drop.Version(), dropped)
+}
+
+// TestSupportedVersions tests that every option combination is listed once
+func TestSupportedVersions(t *testing.T) {
+	versions := SupportedVersions()
+	if want := 2 * len(keyOrders()); len(versions) != want {
+		t.Fatalf("Expected %d versions, got %v", want, versions)
+	}
+	found := false
+	for _, v := range versions {
+		if v == Default.Version() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Default version %s not listed in %v", Default.Version(), versions)
+	}
+
+	for _, v := range versions {
+		c, err := ForVersion(v)
+		if err != nil || c.Version() != v {
+			t.Errorf("ForVersion(%s) failed: %v", v, err)
+		}
+	}
+	if _, err := ForVersion("0.9.0+key_order=utf8;nulls=keep"); err == nil {
+		t.Errorf("Expected unknown version to be rejected")
+	}
+
+	t.Logf("✓ Supported versions: %v", versions)
+}
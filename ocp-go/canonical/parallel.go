@@ -0,0 +1,172 @@
+// parallel.go - Parallel canonicalization of very wide objects
+//
+// Reputation tables and indexes can hold tens of thousands of top-level keys,
+// and sorting and encoding them on one goroutine leaves every other core
+// idle. An object with at least the parallel threshold of members is split
+// into contiguous chunks that are processed on separate goroutines:
+//
+//   - DeepSort sorts each chunk's values, and the results are collected
+//     into the output map once every chunk is done
+//   - the encoder sorts each chunk's keys, merges the sorted runs pairwise,
+//     encodes each range of the merged order into its own buffer, and writes
+//     the buffers in order
+//
+// Every member is encoded exactly as the serial path encodes it, and chunks
+// are always concatenated in key order, so the output is byte-identical
+// whatever the number of workers or how they are scheduled. Smaller objects,
+// where starting goroutines costs more than it saves, stay serial.
+
+package canonical
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+)
+
+// DefaultParallelThreshold is the member count from which an object is
+// processed in parallel
+const DefaultParallelThreshold = 4096
+
+// WithParallelism sets the number of goroutines used for wide objects. Zero,
+// the default, uses GOMAXPROCS; one disables parallel processing.
+func WithParallelism(workers int) Option {
+	return func(c *Canonicalizer) {
+		c.workers = workers
+	}
+}
+
+// WithParallelThreshold sets the member count from which an object is
+// processed in parallel; zero selects DefaultParallelThreshold
+func WithParallelThreshold(members int) Option {
+	return func(c *Canonicalizer) {
+		c.parallelThreshold = members
+	}
+}
+
+// parallelWorkers returns the goroutines to use for an object of n members,
+// or 1 if it should be processed serially
+func (c *Canonicalizer) parallelWorkers(n int) int {
+	threshold := c.parallelThreshold
+	if threshold <= 0 {
+		threshold = DefaultParallelThreshold
+	}
+	if n < threshold {
+		return 1
+	}
+	workers := c.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return min(workers, n)
+}
+
+// chunks calls fn on goroutines for contiguous ranges of [0, n) and waits
+func chunks(n, workers int, fn func(start, end int)) {
+	size := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, min(start+size, n))
+	}
+	wg.Wait()
+}
+
+// deepSortWide is the map case of deepSort for wide objects
+func (c *Canonicalizer) deepSortWide(v map[string]interface{}, workers int) map[string]interface{} {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	values := make([]interface{}, len(keys))
+	chunks(len(keys), workers, func(start, end int) {
+		for i := start; i < end; i++ {
+			values[i] = c.deepSort(v[keys[i]])
+		}
+	})
+
+	sortedMap := make(map[string]interface{}, len(keys))
+	for i, k := range keys {
+		if values[i] == nil && c.nullHandling == DropNulls {
+			continue
+		}
+		sortedMap[k] = values[i]
+	}
+	return sortedMap
+}
+
+// sortKeysParallel sorts keys by sorting chunks concurrently and merging the
+// sorted runs pairwise
+func (c *Canonicalizer) sortKeysParallel(keys []string, workers int) {
+	size := (len(keys) + workers - 1) / workers
+	chunks(len(keys), workers, func(start, end int) {
+		c.sortKeys(keys[start:end])
+	})
+
+	buf := make([]string, len(keys))
+	src, dst := keys, buf
+	for ; size < len(keys); size *= 2 {
+		var wg sync.WaitGroup
+		for start := 0; start < len(keys); start += 2 * size {
+			mid, end := min(start+size, len(keys)), min(start+2*size, len(keys))
+			wg.Add(1)
+			go func(start, mid, end int) {
+				defer wg.Done()
+				c.merge(dst[start:end], src[start:mid], src[mid:end])
+			}(start, mid, end)
+		}
+		wg.Wait()
+		src, dst = dst, src
+	}
+	if &src[0] != &keys[0] {
+		copy(keys, src)
+	}
+}
+
+// merge writes the ordered merge of sorted runs a and b to out
+func (c *Canonicalizer) merge(out, a, b []string) {
+	i, j := 0, 0
+	for k := range out {
+		if j >= len(b) || (i < len(a) && !c.less(b[j], a[i])) {
+			out[k] = a[i]
+			i++
+		} else {
+			out[k] = b[j]
+			j++
+		}
+	}
+}
+
+// encodeWide writes the members of a wide object in shape order, encoding
+// contiguous ranges of members concurrently into separate buffers
+func (e *encoder) encodeWide(v map[string]interface{}, shape *keyShape, workers int) error {
+	parts := make([]bytes.Buffer, workers)
+	errs := make([]error, workers)
+	size := (len(shape.keys) + workers - 1) / workers
+	chunks(len(shape.keys), workers, func(start, end int) {
+		part := start / size
+		sub := &encoder{c: e.c, w: &parts[part]}
+		for i := start; i < end; i++ {
+			if i > 0 {
+				parts[part].WriteByte(',')
+			}
+			parts[part].Write(shape.prefixes[i])
+			if err := sub.encode(v[shape.keys[i]]); err != nil {
+				errs[part] = err
+				return
+			}
+		}
+	})
+	for i := range parts {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if _, err := parts[i].WriteTo(e.w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
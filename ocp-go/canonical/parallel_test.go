@@ -0,0 +1,91 @@
+package canonical
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// wideObject builds an object with n members of mixed types, including keys
+// outside the Basic Multilingual Plane and a nested wide object
+func wideObject(n int) map[string]interface{} {
+	obj := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		switch i % 5 {
+		case 0:
+			obj[fmt.Sprintf("agent-%06d", i)] = float64(i)
+		case 1:
+			obj[fmt.Sprintf("ключ-%d", i)] = map[string]interface{}{"score": float64(i) / 7, "note": "a<b"}
+		case 2:
+			obj[fmt.Sprintf("\U0001F600-%d", i)] = []interface{}{"z", "a", fmt.Sprint(i)}
+		case 3:
+			obj[fmt.Sprintf("｡-%d", i)] = nil
+		default:
+			obj[fmt.Sprintf("flag-%d", i)] = i%2 == 0
+		}
+	}
+	nested := make(map[string]interface{}, n/4)
+	for i := 0; i < n/4; i++ {
+		nested[fmt.Sprintf("n%d", i)] = float64(i)
+	}
+	obj["index"] = nested
+	return obj
+}
+
+// TestParallelMatchesSerial tests that wide objects canonicalize to the same
+// bytes with and without parallel processing, under every option set
+func TestParallelMatchesSerial(t *testing.T) {
+	obj := wideObject(20000)
+	checked := 0
+	for _, order := range keyOrders() {
+		for _, nulls := range []NullHandling{KeepNulls, DropNulls} {
+			serial := New(WithKeyOrder(order), WithNullHandling(nulls), WithParallelism(1))
+			want, err := serial.Canonicalize(obj, true)
+			if err != nil {
+				t.Fatalf("Serial canonicalization failed: %v", err)
+			}
+			for _, workers := range []int{0, 2, 3, 16} {
+				parallel := New(WithKeyOrder(order), WithNullHandling(nulls), WithParallelism(workers), WithParallelThreshold(64))
+				got, err := parallel.Canonicalize(obj, true)
+				if err != nil {
+					t.Fatalf("Parallel canonicalization failed: %v", err)
+				}
+				if got != want {
+					t.Fatalf("%s with %d workers differs from the serial form", parallel.Version(), workers)
+				}
+				if !reflect.DeepEqual(parallel.DeepSort(obj), serial.DeepSort(obj)) {
+					t.Fatalf("%s with %d workers sorts differently", parallel.Version(), workers)
+				}
+				checked++
+			}
+		}
+	}
+	t.Logf("✓ %d parallel configurations byte-identical to serial", checked)
+}
+
+// TestParallelErrors tests that an unencodable member fails the parallel encoder
+func TestParallelErrors(t *testing.T) {
+	obj := wideObject(1000)
+	obj["agent-000500"] = make(chan int)
+	c := New(WithParallelism(4), WithParallelThreshold(16))
+	if _, err := c.Canonicalize(obj, true); err == nil {
+		t.Fatal("Expected an unencodable member to fail")
+	}
+	t.Logf("✓ Unencodable member reported")
+}
+
+// BenchmarkCanonicalizeWide compares serial and parallel canonicalization of
+// an object with 50,000 members
+func BenchmarkCanonicalizeWide(b *testing.B) {
+	obj := wideObject(50000)
+	for _, workers := range []int{1, 0} {
+		c := New(WithParallelism(workers))
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Canonicalize(obj, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
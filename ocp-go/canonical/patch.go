@@ -0,0 +1,182 @@
+// patch.go - Binary patches between canonical forms
+//
+// Large documents such as the constitution change a little at a time, and
+// because canonical forms are deterministic an edit changes only the bytes
+// around it. CanonicalPatch encodes the new form as copies from the old form
+// plus inserted literal bytes, and ApplyCanonicalPatch rebuilds the new form
+// and checks it against the hash recorded in the patch, so a node that applies
+// a patch to the wrong base, or receives a damaged patch, gets an error rather
+// than a silently different document.
+//
+// Patch layout (integers are unsigned varints):
+//
+//	patch := "OCPP" 0x01 sha256(old) sha256(new) len(new) op*
+//	op    := 0x01 offset length      copy length bytes of old from offset
+//	       | 0x02 length bytes       insert literal bytes
+
+package canonical
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// PatchMagic starts every canonical patch
+const PatchMagic = "OCPP\x01"
+
+const (
+	patchOpCopy   = 0x01
+	patchOpInsert = 0x02
+	// patchBlock is the minimum length of a copied run
+	patchBlock = 32
+	// patchPrime is the base of the rolling hash used to find matching blocks
+	patchPrime = 1099511628211
+)
+
+// CanonicalPatch returns a patch transforming oldCanonical into newCanonical
+//
+// Parameters:
+//   - oldCanonical: Canonical form the receiver already has
+//   - newCanonical: Canonical form to transmit
+//
+// Returns:
+//   - Binary patch for ApplyCanonicalPatch
+func CanonicalPatch(oldCanonical, newCanonical []byte) []byte {
+	oldHash, newHash := sha256.Sum256(oldCanonical), sha256.Sum256(newCanonical)
+	out := append([]byte(PatchMagic), oldHash[:]...)
+	out = append(out, newHash[:]...)
+	out = binary.AppendUvarint(out, uint64(len(newCanonical)))
+
+	// Index every aligned block of the old form by its rolling hash
+	index := make(map[uint64]int)
+	for off := 0; off+patchBlock <= len(oldCanonical); off += patchBlock {
+		h := rollingHash(oldCanonical[off : off+patchBlock])
+		if _, ok := index[h]; !ok {
+			index[h] = off
+		}
+	}
+
+	var pow uint64 = 1
+	for i := 0; i < patchBlock-1; i++ {
+		pow *= patchPrime
+	}
+
+	literal := 0 // start of pending literal bytes in newCanonical
+	pos := 0
+	var h uint64
+	if len(newCanonical) >= patchBlock {
+		h = rollingHash(newCanonical[:patchBlock])
+	}
+	for pos+patchBlock <= len(newCanonical) {
+		if off, ok := index[h]; ok && bytes.Equal(oldCanonical[off:off+patchBlock], newCanonical[pos:pos+patchBlock]) {
+			// Extend the match backwards into pending literals and forwards
+			start, oldStart := pos, off
+			for start > literal && oldStart > 0 && oldCanonical[oldStart-1] == newCanonical[start-1] {
+				start--
+				oldStart--
+			}
+			end, oldEnd := pos+patchBlock, off+patchBlock
+			for end < len(newCanonical) && oldEnd < len(oldCanonical) && oldCanonical[oldEnd] == newCanonical[end] {
+				end++
+				oldEnd++
+			}
+
+			out = appendInsert(out, newCanonical[literal:start])
+			out = append(out, patchOpCopy)
+			out = binary.AppendUvarint(out, uint64(oldStart))
+			out = binary.AppendUvarint(out, uint64(end-start))
+
+			literal, pos = end, end
+			if pos+patchBlock <= len(newCanonical) {
+				h = rollingHash(newCanonical[pos : pos+patchBlock])
+			}
+			continue
+		}
+
+		if pos+patchBlock < len(newCanonical) {
+			h = (h-uint64(newCanonical[pos])*pow)*patchPrime + uint64(newCanonical[pos+patchBlock])
+		}
+		pos++
+	}
+	return appendInsert(out, newCanonical[literal:])
+}
+
+// ApplyCanonicalPatch rebuilds the new canonical form from oldCanonical
+//
+// Returns:
+//   - New canonical form, verified against the hash recorded in the patch
+//   - CanonicalizationError if the base or result hash does not match or the
+//     patch is malformed
+func ApplyCanonicalPatch(oldCanonical, patch []byte) ([]byte, error) {
+	header := len(PatchMagic) + 2*sha256.Size
+	if len(patch) < header || string(patch[:len(PatchMagic)]) != PatchMagic {
+		return nil, NewCanonicalizationError("not a canonical patch")
+	}
+	oldHash := patch[len(PatchMagic) : len(PatchMagic)+sha256.Size]
+	newHash := patch[len(PatchMagic)+sha256.Size : header]
+	if sum := sha256.Sum256(oldCanonical); !bytes.Equal(sum[:], oldHash) {
+		return nil, NewCanonicalizationError(fmt.Sprintf("patch base is %x, have %x", oldHash, sum))
+	}
+
+	rest := patch[header:]
+	size, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return nil, NewCanonicalizationError("malformed patch length")
+	}
+	rest = rest[n:]
+
+	out := make([]byte, 0, min(size, uint64(len(oldCanonical)+len(patch))))
+	for len(rest) > 0 {
+		op := rest[0]
+		rest = rest[1:]
+		switch op {
+		case patchOpCopy:
+			off, n1 := binary.Uvarint(rest)
+			if n1 <= 0 {
+				return nil, NewCanonicalizationError("malformed patch copy")
+			}
+			length, n2 := binary.Uvarint(rest[n1:])
+			if n2 <= 0 || off > uint64(len(oldCanonical)) || length > uint64(len(oldCanonical))-off {
+				return nil, NewCanonicalizationError("patch copy out of range")
+			}
+			out = append(out, oldCanonical[off:off+length]...)
+			rest = rest[n1+n2:]
+		case patchOpInsert:
+			length, n1 := binary.Uvarint(rest)
+			if n1 <= 0 || length > uint64(len(rest)-n1) {
+				return nil, NewCanonicalizationError("patch insert out of range")
+			}
+			out = append(out, rest[n1:n1+int(length)]...)
+			rest = rest[n1+int(length):]
+		default:
+			return nil, NewCanonicalizationError(fmt.Sprintf("unknown patch op 0x%02x", op))
+		}
+		if uint64(len(out)) > size {
+			return nil, NewCanonicalizationError("patch result exceeds recorded length")
+		}
+	}
+
+	if sum := sha256.Sum256(out); uint64(len(out)) != size || !bytes.Equal(sum[:], newHash) {
+		return nil, NewCanonicalizationError("patch result does not match its recorded hash")
+	}
+	return out, nil
+}
+
+func appendInsert(out, literal []byte) []byte {
+	if len(literal) == 0 {
+		return out
+	}
+	out = append(out, patchOpInsert)
+	out = binary.AppendUvarint(out, uint64(len(literal)))
+	return append(out, literal...)
+}
+
+func rollingHash(block []byte) uint64 {
+	var h uint64
+	for _, b := range block {
+		h = h*patchPrime + uint64(b)
+	}
+	return h
+}
@@ -0,0 +1,80 @@
+package canonical
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// testConstitution returns the canonical form of a large document with n articles
+func testConstitution(n int, amend map[int]string) []byte {
+	articles := make(map[string]interface{})
+	for i := 0; i < n; i++ {
+		text := fmt.Sprintf("Article %d text establishing rule number %d for all agents.", i, i)
+		if a, ok := amend[i]; ok {
+			text = a
+		}
+		articles[fmt.Sprintf("article_%04d", i)] = text
+	}
+	form, _ := Canonicalize(map[string]interface{}{"articles": articles}, true)
+	return []byte(form)
+}
+
+// TestCanonicalPatchRoundTrip tests patching small edits of a large document
+func TestCanonicalPatchRoundTrip(t *testing.T) {
+	old := testConstitution(500, nil)
+	updated := testConstitution(501, map[int]string{17: "Article 17 as amended.", 300: "Repealed."})
+
+	patch := CanonicalPatch(old, updated)
+	if len(patch) > len(updated)/10 {
+		t.Errorf("Patch of %d bytes is too large for a %d byte document", len(patch), len(updated))
+	}
+
+	result, err := ApplyCanonicalPatch(old, patch)
+	if err != nil {
+		t.Fatalf("Failed to apply patch: %v", err)
+	}
+	if !bytes.Equal(result, updated) {
+		t.Fatalf("Patched document differs from the target")
+	}
+
+	for _, tc := range []struct{ old, new []byte }{
+		{nil, updated},
+		{old, nil},
+		{[]byte(`{"a":1}`), []byte(`{"a":2}`)},
+		{old, old},
+	} {
+		result, err := ApplyCanonicalPatch(tc.old, CanonicalPatch(tc.old, tc.new))
+		if err != nil || !bytes.Equal(result, tc.new) {
+			t.Errorf("Round trip failed for %d -> %d bytes: %v", len(tc.old), len(tc.new), err)
+		}
+	}
+
+	t.Logf("✓ %d byte document updated with a %d byte patch", len(updated), len(patch))
+}
+
+// TestApplyCanonicalPatchRejects tests hash verification of base and result
+func TestApplyCanonicalPatchRejects(t *testing.T) {
+	old := testConstitution(100, nil)
+	updated := testConstitution(100, map[int]string{5: "Amended."})
+	patch := CanonicalPatch(old, updated)
+
+	if _, err := ApplyCanonicalPatch(testConstitution(99, nil), patch); err == nil {
+		t.Errorf("Patch applied to the wrong base should be rejected")
+	}
+
+	damaged := append([]byte{}, patch...)
+	damaged[len(damaged)-3] ^= 0x20
+	if _, err := ApplyCanonicalPatch(old, damaged); err == nil {
+		t.Errorf("Damaged patch should be rejected")
+	}
+
+	if _, err := ApplyCanonicalPatch(old, patch[:len(patch)-1]); err == nil {
+		t.Errorf("Truncated patch should be rejected")
+	}
+	if _, err := ApplyCanonicalPatch(old, []byte("garbage")); err == nil {
+		t.Errorf("Garbage should be rejected")
+	}
+
+	t.Logf("✓ Invalid patches rejected")
+}
@@ -0,0 +1,16 @@
+// Package rustref links the Rust reference canonicalizer for differential
+// testing.
+//
+// Fixed test vectors only catch the divergences someone thought to write down.
+// This package calls the Rust implementation in
+// protocol/hashing/reference_implementations/rust through its C ABI, so a fuzz
+// test can give both implementations the same generated documents and compare
+// canonical bytes and semantic hashes. It needs cgo and a built static library,
+// so it is compiled only with the ocp_rustffi tag and is never part of a
+// normal build:
+//
+//	cd protocol/hashing/reference_implementations/rust/ffi && cargo build --release
+//	cd ocp-go && go test -tags ocp_rustffi -fuzz FuzzDifferential ./canonical/rustref
+//
+// Without the tag the package is empty.
+package rustref
@@ -0,0 +1,127 @@
+//go:build cgo && ocp_rustffi
+
+package rustref
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// corpusDir holds the documents the committed vectors are generated from
+const corpusDir = "../../../protocol/hashing/test_vectors/corpus"
+
+// FuzzDifferential tests that Go and Rust agree on every document Go accepts.
+// Go's strict decoding refuses some input serde_json takes (duplicate keys,
+// lone surrogates), so only documents Go accepts are compared. Documents in a
+// divergence class already known are skipped with the class as the reason, so
+// the fuzzer reports only new ones.
+func FuzzDifferential(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join(corpusDir, "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("Failed to read seed: %v", err)
+		}
+		f.Add(data)
+	}
+	for _, seed := range []string{
+		`{}`, `{"a":[3,1,2]}`, `{"n":null,"b":[true,false]}`, `{"s":"é\t\"q\""}`,
+		`{"x":{"y":[{"z":1},{"a":2}]}}`, `{"amount":"123.45","_set":["b","a"]}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		goForm, rustForm, goErr, rustErr := Compare(data)
+		if goErr != nil {
+			return
+		}
+		if reason := knownDivergence(data); reason != "" {
+			t.Skip(reason)
+		}
+		if rustErr != nil {
+			t.Fatalf("Rust refused a document Go accepts:\n  input: %q\n  go:    %s\n  rust:  %v", data, goForm, rustErr)
+		}
+		if goForm != rustForm {
+			t.Fatalf("Canonical forms differ:\n  input: %q\n  go:    %s\n  rust:  %s", data, goForm, rustForm)
+		}
+
+		obj, _ := canonical.DecodeStrict(data)
+		goHash, err := hashing.SemanticHash(obj)
+		if err != nil {
+			t.Fatalf("Go failed to hash: %v", err)
+		}
+		rustHash, err := SemanticHash(data)
+		if err != nil {
+			t.Fatalf("Rust failed to hash: %v", err)
+		}
+		if goHash != rustHash {
+			t.Fatalf("Hashes differ for %q: go %s, rust %s", data, goHash, rustHash)
+		}
+	})
+}
+
+// knownDivergence names the known divergence class data falls in, or ""
+func knownDivergence(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil {
+		return ""
+	}
+	var reason string
+	var walk func(v interface{})
+	htmlEscaped := func(s string) {
+		if strings.ContainsAny(s, "<>&\u2028\u2029") {
+			reason = "Rule 2.4.2: Go escapes <, >, &, U+2028, and U+2029 as \\u sequences; the Rust reference writes them as they are"
+		}
+	}
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				htmlEscaped(key)
+				walk(child)
+			}
+		case string:
+			htmlEscaped(v)
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		case json.Number:
+			if r := numberDivergence(v); r != "" {
+				reason = r
+			}
+		}
+	}
+	walk(v)
+	return reason
+}
+
+// numberDivergence names the known number formatting class n falls in, or ""
+func numberDivergence(n json.Number) string {
+	f, err := n.Float64()
+	if err != nil {
+		return ""
+	}
+	literal := n.String()
+	switch {
+	case f == math.Trunc(f) && strings.ContainsAny(literal, ".eE"):
+		return "Rule 2.4.1: the Rust reference keeps the fraction of integral floats"
+	case f == math.Trunc(f) && math.Abs(f) >= 1<<53:
+		return "Rule 2.4.1: Go decodes numbers as float64, losing integer precision beyond 2^53"
+	case f != math.Trunc(f) && strings.Contains(strconv.FormatFloat(f, 'g', -1, 64), "e"):
+		return "Rule 2.4.1: Go and the Rust reference switch to exponent notation at different magnitudes"
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+//go:build cgo && ocp_rustffi
+
+// rustref.go - cgo bindings to the Rust reference canonicalizer
+
+package rustref
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/../../../protocol/hashing/reference_implementations/rust/ffi/target/release -locp_canonical_ffi -ldl -lm -lpthread
+#include <stdint.h>
+#include <stdlib.h>
+
+int32_t ocp_canonicalize(const uint8_t *input, size_t len, uint8_t **out, size_t *out_len);
+int32_t ocp_semantic_hash(const uint8_t *input, size_t len, uint8_t **out, size_t *out_len);
+void ocp_free(uint8_t *ptr, size_t len);
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Status codes returned by the Rust library
+const (
+	statusOK         = 0
+	statusParseError = 1
+)
+
+// Error is a failure reported by the Rust implementation
+type Error struct {
+	// Parse is true if serde_json rejected the input, false if the
+	// canonicalizer did
+	Parse   bool
+	Message string
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	if e.Parse {
+		return "rust parse error: " + e.Message
+	}
+	return "rust canonicalization error: " + e.Message
+}
+
+// rustFunc is the signature shared by the library's entry points
+type rustFunc func(input *C.uint8_t, n C.size_t, out **C.uint8_t, outLen *C.size_t) C.int32_t
+
+// Canonicalize returns the Rust implementation's strict canonical form of the
+// JSON document in data
+func Canonicalize(data []byte) (string, error) {
+	return call(data, func(input *C.uint8_t, n C.size_t, out **C.uint8_t, outLen *C.size_t) C.int32_t {
+		return C.ocp_canonicalize(input, n, out, outLen)
+	})
+}
+
+// SemanticHash returns the Rust implementation's semantic hash of the JSON
+// document in data
+func SemanticHash(data []byte) (string, error) {
+	return call(data, func(input *C.uint8_t, n C.size_t, out **C.uint8_t, outLen *C.size_t) C.int32_t {
+		return C.ocp_semantic_hash(input, n, out, outLen)
+	})
+}
+
+// call runs fn on data and copies out its result, releasing the library's buffer
+func call(data []byte, fn rustFunc) (string, error) {
+	input := C.CBytes(data)
+	defer C.free(input)
+	var out *C.uint8_t
+	var outLen C.size_t
+	status := fn((*C.uint8_t)(input), C.size_t(len(data)), &out, &outLen)
+	result := C.GoStringN((*C.char)(unsafe.Pointer(out)), C.int(outLen))
+	C.ocp_free(out, outLen)
+	if status != statusOK {
+		return "", &Error{Parse: status == statusParseError, Message: result}
+	}
+	return result, nil
+}
+
+// Compare canonicalizes data with both implementations
+//
+// Returns:
+//   - Go's canonical form, and Rust's; both are "" where that side failed
+//   - Go's error and Rust's error
+func Compare(data []byte) (goForm, rustForm string, goErr, rustErr error) {
+	if obj, err := canonical.DecodeStrict(data); err != nil {
+		goErr = err
+	} else {
+		goForm, goErr = canonical.Canonicalize(obj, true)
+	}
+	rustForm, rustErr = Canonicalize(data)
+	return goForm, rustForm, goErr, rustErr
+}
@@ -0,0 +1,70 @@
+// set.go - Canonical set semantics for explicitly unordered collections
+//
+// Arrays are ordered by default: only arrays of same-typed primitives are sorted.
+// An object of the form {"_set": [...]} declares its array unordered. Its elements
+// are canonicalized, deduplicated, and sorted by the SHA256 of their canonical
+// form, so evidence lists submitted in different orders hash identically even when
+// the elements are objects. The {"_set": ...} wrapper is kept in the canonical
+// output so that a set never hashes the same as an ordered array.
+
+package canonical
+
+import (
+	"crypto/sha256"
+	"sort"
+)
+
+// SetKey is the sole key of an object declaring an unordered collection
+const SetKey = "_set"
+
+// Set wraps elements in the {"_set": [...]} convention
+func Set(elems ...interface{}) map[string]interface{} {
+	if elems == nil {
+		elems = []interface{}{}
+	}
+	return map[string]interface{}{SetKey: elems}
+}
+
+// setElements reports whether m is a set wrapper and returns its elements
+func setElements(m map[string]interface{}) ([]interface{}, bool) {
+	if len(m) != 1 {
+		return nil, false
+	}
+	elems, ok := m[SetKey].([]interface{})
+	return elems, ok
+}
+
+// sortSet deep sorts each element, then orders elements by canonical hash and
+// drops duplicates. Elements that cannot be canonicalized keep their relative
+// order at the end; encoding reports the error.
+func (c *Canonicalizer) sortSet(elems []interface{}) []interface{} {
+	type keyed struct {
+		digest [sha256.Size]byte
+		value  interface{}
+	}
+
+	keyedElems := make([]keyed, 0, len(elems))
+	var failed []interface{}
+	for _, elem := range elems {
+		sorted := c.deepSort(elem)
+		canonical, err := c.jsonToCanonical(sorted)
+		if err != nil {
+			failed = append(failed, sorted)
+			continue
+		}
+		keyedElems = append(keyedElems, keyed{sha256.Sum256([]byte(canonical)), sorted})
+	}
+
+	sort.SliceStable(keyedElems, func(i, j int) bool {
+		return string(keyedElems[i].digest[:]) < string(keyedElems[j].digest[:])
+	})
+
+	out := make([]interface{}, 0, len(keyedElems)+len(failed))
+	for i, k := range keyedElems {
+		if i > 0 && k.digest == keyedElems[i-1].digest {
+			continue
+		}
+		out = append(out, k.value)
+	}
+	return append(out, failed...)
+}
@@ -0,0 +1,75 @@
+package canonical
+
+import (
+	"testing"
+)
+
+// TestSetOrderIndependence tests that object sets canonicalize identically in any order
+func TestSetOrderIndependence(t *testing.T) {
+	evidenceA := map[string]interface{}{"type": "archive_reference", "pointer": "sha256:aaa"}
+	evidenceB := map[string]interface{}{"type": "constitutional_citation", "pointer": "Article-III.1"}
+
+	first := map[string]interface{}{"evidence": Set(evidenceA, evidenceB)}
+	second := map[string]interface{}{"evidence": Set(evidenceB, evidenceA)}
+
+	canonicalFirst, err := Canonicalize(first, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	canonicalSecond, err := Canonicalize(second, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+
+	if canonicalFirst != canonicalSecond {
+		t.Errorf("Set order should not matter:\n  %s\n  %s", canonicalFirst, canonicalSecond)
+	}
+
+	t.Logf("✓ Set canonical form: %s", canonicalFirst)
+}
+
+// TestSetDeduplication tests that duplicate elements, including reordered objects, collapse
+func TestSetDeduplication(t *testing.T) {
+	data := map[string]interface{}{
+		"tags": Set(
+			map[string]interface{}{"a": float64(1), "b": float64(2)},
+			map[string]interface{}{"b": float64(2), "a": float64(1)},
+			"x",
+			"x",
+		),
+	}
+
+	canonical, err := Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+
+	sorted := DeepSort(data).(map[string]interface{})
+	elems := sorted["tags"].(map[string]interface{})[SetKey].([]interface{})
+	if len(elems) != 2 {
+		t.Errorf("Expected 2 distinct elements, got %d: %s", len(elems), canonical)
+	}
+}
+
+// TestSetDistinctFromArray tests that a set never hashes the same as the plain array
+func TestSetDistinctFromArray(t *testing.T) {
+	asArray := map[string]interface{}{"v": []interface{}{"a"}}
+	asSet := map[string]interface{}{"v": Set("a")}
+
+	canonicalArray, _ := Canonicalize(asArray, true)
+	canonicalSet, _ := Canonicalize(asSet, true)
+
+	if canonicalArray == canonicalSet {
+		t.Errorf("Set and array should have different canonical forms")
+	}
+	if canonicalSet != `{"v":{"_set":["a"]}}` {
+		t.Errorf("Unexpected set encoding: %s", canonicalSet)
+	}
+
+	// Objects with extra keys alongside _set are ordinary objects
+	mixed := map[string]interface{}{SetKey: []interface{}{"b", "a"}, "other": true}
+	canonicalMixed, _ := Canonicalize(mixed, true)
+	if canonicalMixed != `{"_set":["a","b"],"other":true}` {
+		t.Errorf("Object with extra keys should not be a set: %s", canonicalMixed)
+	}
+}
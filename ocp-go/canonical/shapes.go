@@ -0,0 +1,117 @@
+// shapes.go - Key-set interning for the encoder
+//
+// Large proposals are dominated by arrays of identically shaped objects, such
+// as thousands of evidence entries with the same keys. Encoding each of them
+// sorts the same key set and escapes the same key strings again. The encoder
+// instead interns each key set it sorts as a shape: the sorted keys together
+// with their escaped "key": prefixes. An object whose keys match a recently
+// seen shape is written with that shape's order and prefixes, so a uniform
+// array pays for sorting and escaping its keys once.
+//
+// Values cannot be interned the same way, but most strings in such arrays are
+// printable ASCII that needs no escaping, and plainString lets the encoder
+// write those without the cost of json.Marshal.
+//
+// A shape matches only an object with exactly its keys, so output is
+// byte-for-byte what sorting each object would produce.
+
+package canonical
+
+import (
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+)
+
+// maxShapes bounds the shapes an encoder keeps, most recently used first
+const maxShapes = 8
+
+// keyShape is an interned key set in encoding order
+type keyShape struct {
+	keys []string
+	// prefixes holds each key escaped and followed by ':'
+	prefixes [][]byte
+}
+
+// matches reports whether m has exactly the shape's keys
+func (s *keyShape) matches(m map[string]interface{}) bool {
+	if len(m) != len(s.keys) {
+		return false
+	}
+	for _, k := range s.keys {
+		if _, ok := m[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// encoder writes one value as canonical JSON, interning the key sets of the
+// objects it encodes
+type encoder struct {
+	c      *Canonicalizer
+	w      io.Writer
+	shapes []*keyShape
+}
+
+// shapeOf returns the interned shape of m, sorting and escaping its keys only
+// if no recently used shape matches
+func (e *encoder) shapeOf(m map[string]interface{}) *keyShape {
+	for i, s := range e.shapes {
+		if s.matches(m) {
+			if i > 0 {
+				copy(e.shapes[1:i+1], e.shapes[:i])
+				e.shapes[0] = s
+			}
+			return s
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	if workers := e.c.parallelWorkers(len(keys)); workers > 1 {
+		e.c.sortKeysParallel(keys, workers)
+	} else {
+		e.c.sortKeys(keys)
+	}
+	s := &keyShape{keys: keys, prefixes: make([][]byte, len(keys))}
+	for i, k := range keys {
+		keyJSON, _ := json.Marshal(k)
+		s.prefixes[i] = append(keyJSON, ':')
+	}
+
+	if len(e.shapes) < maxShapes {
+		e.shapes = append(e.shapes, nil)
+	}
+	copy(e.shapes[1:], e.shapes)
+	e.shapes[0] = s
+	return s
+}
+
+// plainString reports whether s is written unchanged between quotes, that is
+// whether json.Marshal would escape none of its bytes
+func plainString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b < 0x20 || b >= utf8.RuneSelf:
+			return false
+		case b == '"' || b == '\\' || b == '<' || b == '>' || b == '&':
+			return false
+		}
+	}
+	return true
+}
+
+// writeQuoted writes a plain string between quotes without building a copy
+func (e *encoder) writeQuoted(s string) error {
+	if _, err := io.WriteString(e.w, `"`); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(e.w, s); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, `"`)
+	return err
+}
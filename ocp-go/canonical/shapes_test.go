@@ -0,0 +1,154 @@
+package canonical
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// unshapedEncode writes v sorting and escaping every object's keys anew
+func unshapedEncode(c *Canonicalizer, b *strings.Builder, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		c.sortKeys(keys)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			b.Write(key)
+			b.WriteByte(':')
+			unshapedEncode(c, b, v[k])
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			unshapedEncode(c, b, elem)
+		}
+		b.WriteByte(']')
+	default:
+		form, _ := c.jsonToCanonical(v)
+		b.WriteString(form)
+	}
+}
+
+// evidenceArray returns n evidence entries, every variant-th one reshaped
+func evidenceArray(n, variant int) []interface{} {
+	arr := make([]interface{}, n)
+	for i := range arr {
+		entry := map[string]interface{}{
+			"type":        "archive_reference",
+			"pointer":     fmt.Sprintf("sha256:%064d", i),
+			"description": fmt.Sprintf("Record %d", i),
+			"weight":      float64(i),
+		}
+		if variant > 0 && i%variant == 0 {
+			entry[fmt.Sprintf("extra_%d", i%3)] = true
+			delete(entry, "weight")
+		}
+		arr[i] = entry
+	}
+	return arr
+}
+
+// TestShapeInterning tests that interned shapes encode exactly as sorting would
+func TestShapeInterning(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, order := range keyOrders() {
+		c := New(WithKeyOrder(order))
+		for trial := 0; trial < 50; trial++ {
+			// Objects drawn from a few overlapping key sets, including sets
+			// that differ only by one key or share a size
+			pool := []string{"a", "b", "é", "😀", "\uffff", "<tag>", "c"}
+			arr := make([]interface{}, 40)
+			for i := range arr {
+				m := make(map[string]interface{})
+				for _, k := range pool {
+					if rng.Intn(3) > 0 {
+						m[k] = rng.Intn(2) == 0
+					}
+				}
+				if rng.Intn(4) == 0 {
+					m["nested"] = map[string]interface{}{"a": float64(i), "b": evidenceArray(3, 2)}
+				}
+				arr[i] = m
+			}
+			doc := map[string]interface{}{"items": arr, "evidence": evidenceArray(30, 4)}
+
+			got, err := c.CanonicalizeValue(doc)
+			if err != nil {
+				t.Fatalf("Failed to canonicalize: %v", err)
+			}
+			var want strings.Builder
+			unshapedEncode(c, &want, c.DeepSort(doc))
+			if got != want.String() {
+				t.Fatalf("Key order %s trial %d:\n got %s\nwant %s", c.KeyOrder(), trial, got, want.String())
+			}
+		}
+	}
+
+	// More shapes than the encoder keeps still encode correctly
+	e := &encoder{c: Default, w: &strings.Builder{}}
+	for i := 0; i < 3*maxShapes; i++ {
+		m := map[string]interface{}{fmt.Sprintf("k%d", i): 1, "z": 2}
+		if s := e.shapeOf(m); len(s.keys) != 2 || s.keys[0] != fmt.Sprintf("k%d", i) {
+			t.Fatalf("Unexpected shape %v", s.keys)
+		}
+	}
+	if len(e.shapes) != maxShapes {
+		t.Errorf("Expected at most %d shapes kept, got %d", maxShapes, len(e.shapes))
+	}
+
+	keys := e.shapes[0].keys
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("Shape keys are not sorted: %v", keys)
+	}
+
+	t.Log("✓ Interned shapes match per-object sorting")
+}
+
+// TestPlainString tests that strings written unescaped are exactly those
+// json.Marshal leaves unchanged
+func TestPlainString(t *testing.T) {
+	plain := 0
+	for b := 0; b < 256; b++ {
+		s := "x" + string([]byte{byte(b)}) + "y"
+		marshaled, _ := json.Marshal(s)
+		unchanged := string(marshaled) == `"`+s+`"`
+		if plainString(s) != unchanged {
+			t.Errorf("Byte %#x: plainString %v, json.Marshal unchanged %v", b, plainString(s), unchanged)
+		}
+		if unchanged {
+			plain++
+		}
+	}
+	if !plainString("") || plainString("caf\u00e9") || plainString("a\u2028b") {
+		t.Errorf("Unexpected plainString results for multi-byte or empty strings")
+	}
+
+	t.Logf("✓ %d of 256 bytes written without escaping", plain)
+}
+
+// BenchmarkUniformArray benchmarks encoding thousands of identically shaped objects
+func BenchmarkUniformArray(b *testing.B) {
+	doc := map[string]interface{}{"evidence": evidenceArray(5000, 0)}
+	sorted := DeepSort(doc)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Default.jsonToCanonical(sorted); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
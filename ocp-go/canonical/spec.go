@@ -0,0 +1,128 @@
+// spec.go - Machine-readable description of the active canonicalization rules
+//
+// Every implementation of the protocol must produce the same bytes for the
+// same input. Version names the options in effect, but two implementations can
+// agree on a version string while disagreeing on a rule neither thought to
+// name. Spec spells out each byte-level rule the encoder applies, as stable
+// identifiers rather than prose, so implementations can exchange their specs,
+// hash them, and report exactly which rule differs before any hash does.
+
+package canonical
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// Spec describes the byte-level rules of a Canonicalizer. Each field is a
+// stable identifier; a change to any rule changes its identifier.
+type Spec struct {
+	// Version is the Canonicalizer's Version string
+	Version string `json:"version"`
+	// Encoding of the output text
+	Encoding string `json:"encoding"`
+	// Whitespace between tokens
+	Whitespace string `json:"whitespace"`
+	// KeyOrder is the ordering of object keys and of sorted string arrays
+	KeyOrder string `json:"key_order"`
+	// DuplicateKeys is how the strict decoder treats repeated object keys
+	DuplicateKeys string `json:"duplicate_keys"`
+	// NullMembers is whether object members with null values are kept
+	NullMembers string `json:"null_members"`
+	// StringEscaping lists the characters written as escapes
+	StringEscaping string `json:"string_escaping"`
+	// InvalidUnicode is how lone surrogates and invalid UTF-8 are handled
+	InvalidUnicode string `json:"invalid_unicode"`
+	// IntegerFormat is the form of integral numbers within the int64 range
+	IntegerFormat string `json:"integer_format"`
+	// NumberFormat is the form of all other numbers, as shortest round-trip
+	// digits with an exponent (e+06, e-05) outside [1e-4, 1e6)
+	NumberFormat string `json:"number_format"`
+	// ArrayOrder is when arrays are reordered
+	ArrayOrder string `json:"array_order"`
+	// PrimitiveOrder is the element order of sorted primitive arrays
+	PrimitiveOrder string `json:"primitive_order"`
+	// Sets is the handling of {"_set": [...]} wrappers
+	Sets string `json:"sets"`
+	// Binary is the string form of byte values
+	Binary string `json:"binary"`
+	// Decimals is the string form of exact decimal amounts
+	Decimals string `json:"decimals"`
+}
+
+// CanonicalSpec returns the Spec of the Default canonicalizer
+func CanonicalSpec() Spec {
+	return Default.Spec()
+}
+
+// Spec returns the rules this canonicalizer applies
+func (c *Canonicalizer) Spec() Spec {
+	keyOrder := "utf8-bytes"
+	if c.keyOrder == KeyOrderUTF16 {
+		keyOrder = "utf16-code-units"
+	}
+	return Spec{
+		Version:        c.Version(),
+		Encoding:       "utf-8",
+		Whitespace:     "none",
+		KeyOrder:       keyOrder,
+		DuplicateKeys:  "reject",
+		NullMembers:    c.nullHandling.String(),
+		StringEscaping: "quote,backslash,control-short,control-u00xx,html-u00xx,u2028-u2029",
+		InvalidUnicode: "reject-lone-surrogates,reject-invalid-utf8",
+		IntegerFormat:  "int64-decimal-no-exponent",
+		NumberFormat:   "shortest-roundtrip,exponent-outside-1e-4-to-1e6,exponent-signed-two-digits",
+		ArrayOrder:     "sort-if-all-same-primitive-type",
+		PrimitiveOrder: "strings-by-key-order,numbers-ascending,false-before-true",
+		Sets:           "wrapper-kept,dedupe,sort-by-sha256-of-canonical",
+		Binary:         "prefix-b64,base64url-unpadded",
+		Decimals:       "string,no-exponent,no-leading-zeros,no-trailing-zeros,no-negative-zero",
+	}
+}
+
+// ToMap converts a Spec to a map for canonicalization
+func (s Spec) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"version":         s.Version,
+		"encoding":        s.Encoding,
+		"whitespace":      s.Whitespace,
+		"key_order":       s.KeyOrder,
+		"duplicate_keys":  s.DuplicateKeys,
+		"null_members":    s.NullMembers,
+		"string_escaping": s.StringEscaping,
+		"invalid_unicode": s.InvalidUnicode,
+		"integer_format":  s.IntegerFormat,
+		"number_format":   s.NumberFormat,
+		"array_order":     s.ArrayOrder,
+		"primitive_order": s.PrimitiveOrder,
+		"sets":            s.Sets,
+		"binary":          s.Binary,
+		"decimals":        s.Decimals,
+	}
+}
+
+// Hash returns the hex SHA-256 of the Spec's canonical form under the Default
+// rules, so a spec hashes the same whichever canonicalizer it describes
+func (s Spec) Hash() (string, error) {
+	form, err := Default.Canonicalize(s.ToMap(), true)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(form))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Compare lists the fields, by JSON name, whose rules differ between s and
+// other, sorted; empty when the specs are identical
+func (s Spec) Compare(other Spec) []string {
+	mine, theirs := s.ToMap(), other.ToMap()
+	var differ []string
+	for field, rule := range mine {
+		if theirs[field] != rule {
+			differ = append(differ, field)
+		}
+	}
+	sort.Strings(differ)
+	return differ
+}
@@ -0,0 +1,60 @@
+package canonical
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCanonicalSpec tests that specs describe options and compare field by field
+func TestCanonicalSpec(t *testing.T) {
+	spec := CanonicalSpec()
+	if spec.Version != Default.Version() || spec.KeyOrder != "utf8-bytes" || spec.NullMembers != "keep" {
+		t.Errorf("Unexpected default spec: %+v", spec)
+	}
+
+	utf16 := New(WithKeyOrder(KeyOrderUTF16)).Spec()
+	if diff := spec.Compare(utf16); len(diff) != 2 || diff[0] != "key_order" || diff[1] != "version" {
+		t.Errorf("Expected key_order and version to differ, got %v", diff)
+	}
+	if diff := spec.Compare(Default.Spec()); len(diff) != 0 {
+		t.Errorf("Identical specs should not differ: %v", diff)
+	}
+
+	// A spec received from another implementation compares after a JSON round trip
+	data, _ := json.Marshal(spec)
+	var received Spec
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	received.NumberFormat = "shortest-roundtrip,exponent-outside-1e-6-to-1e21"
+	if diff := spec.Compare(received); len(diff) != 1 || diff[0] != "number_format" {
+		t.Errorf("Expected number_format to differ, got %v", diff)
+	}
+
+	hash, err := spec.Hash()
+	if err != nil || len(hash) != 64 {
+		t.Fatalf("Failed to hash spec: %v", err)
+	}
+	if other, _ := utf16.Hash(); other == hash {
+		t.Errorf("Different specs should hash differently")
+	}
+	t.Logf("✓ Spec %s: %s", spec.Version, hash[:16])
+}
+
+// TestSpecNumberRules tests that the documented number rules match the encoder
+func TestSpecNumberRules(t *testing.T) {
+	cases := map[float64]string{
+		42:        "42",
+		1e21:      "1e+21",
+		0.0001:    "0.0001",
+		0.00001:   "1e-05",
+		1234.5:    "1234.5",
+		1234567.5: "1.2345675e+06",
+	}
+	for v, want := range cases {
+		if got, _ := CanonicalizeValue(v); got != want {
+			t.Errorf("%v: expected %s, got %s", v, want, got)
+		}
+	}
+	t.Logf("✓ Number rules of %s hold", CanonicalSpec().NumberFormat)
+}
@@ -0,0 +1,162 @@
+// canonicalchange.go - Ratified changes to the canonicalization rules
+//
+// Every hash in the protocol depends on the canonicalization rules, so
+// changing them (switching key order or null handling) is a constitutional
+// act, not a deployment detail. A canonical_change proposal names the new
+// version and the ledger height at which it takes effect; once a quorum
+// ratifies it, the change is recorded on the ledger and every node switches
+// at the same height. Heights before the activation keep the old rules, so
+// historical hashes stay reproducible with CanonicalizerAt.
+
+package ocp
+
+import (
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// ActionCanonicalChange is the action type of canonicalization rule changes
+const ActionCanonicalChange = "canonical_change"
+
+// LedgerKindCanonical is the ledger entry kind recording a ratified rule change
+const LedgerKindCanonical = "canonical"
+
+// CanonicalChange is a scheduled switch of the canonicalization version
+type CanonicalChange struct {
+	ProposalHash string `json:"proposal_hash"`
+	// Version is the canonical.Canonicalizer Version in force from
+	// ActivationHeight on
+	Version          string `json:"canonical_version"`
+	ActivationHeight uint64 `json:"activation_height"`
+}
+
+// ToMap converts a CanonicalChange to a map for canonicalization
+func (c *CanonicalChange) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":     c.ProposalHash,
+		"canonical_version": c.Version,
+		"activation_height": c.ActivationHeight,
+	}
+}
+
+// Hash returns the semantic hash quorum members sign under ContextCanonical
+func (c *CanonicalChange) Hash() (string, error) {
+	return SemanticHash(c.ToMap())
+}
+
+// CanonicalChangeFromProposal reads the change a canonical_change proposal
+// requests. Its action must name a supported "canonical_version" and a
+// positive "activation_height".
+func CanonicalChangeFromProposal(p *ContractProposal) (*CanonicalChange, error) {
+	if p.ActionType != ActionCanonicalChange {
+		return nil, NewConstitutionalError(fmt.Sprintf("action type %q is not %s", p.ActionType, ActionCanonicalChange))
+	}
+	version, _ := p.Action["canonical_version"].(string)
+	if _, err := canonical.ForVersion(version); err != nil {
+		return nil, err
+	}
+	height := payloadInt(p.Action, "activation_height")
+	if height <= 0 {
+		return nil, NewConstitutionalError("canonical change needs a positive activation_height")
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	return &CanonicalChange{ProposalHash: hash, Version: version, ActivationHeight: uint64(height)}, nil
+}
+
+// RatifyCanonicalChange records a quorum-approved canonical_change proposal
+//
+// Parameters:
+//   - ledger: Ledger receiving the canonical entry
+//   - quorum: Quorum that must approve the change
+//   - p: Proposal with action type canonical_change
+//   - sigs: Signatures over the CanonicalChange hash under ContextCanonical
+//
+// Returns:
+//   - The scheduled change
+//   - ConstitutionalError if the activation height is not after the entry
+//     recording it, or not after every change already scheduled
+func RatifyCanonicalChange(ledger *Ledger, quorum *Quorum, p *ContractProposal, sigs []Signature) (*CanonicalChange, error) {
+	change, err := CanonicalChangeFromProposal(p)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := change.Hash()
+	if err != nil {
+		return nil, err
+	}
+	signers, err := quorum.Verify(ContextCanonical, hash, sigs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nodes must learn of a switch before the height it applies to, and a
+	// schedule that goes back in time would rewrite the rules of a height
+	// another change already claimed
+	entries := ledger.Entries(0)
+	recordAt := ledger.Height() + 1
+	if change.ActivationHeight <= recordAt {
+		return nil, NewConstitutionalError(fmt.Sprintf("activation height %d must be after the ratifying entry at %d", change.ActivationHeight, recordAt))
+	}
+	for _, scheduled := range CanonicalChanges(entries) {
+		if scheduled.ActivationHeight >= change.ActivationHeight {
+			return nil, NewConstitutionalError(fmt.Sprintf("a change already activates at height %d", scheduled.ActivationHeight))
+		}
+	}
+
+	payload := change.ToMap()
+	payload["signers"] = stringList(signers)
+	payload["signatures"] = signatureList(sigs)
+	if _, err := ledger.Append(LedgerKindCanonical, payload); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// CanonicalChanges returns the changes recorded in entries, in ledger order
+func CanonicalChanges(entries []LedgerEntry) []CanonicalChange {
+	var out []CanonicalChange
+	for _, e := range entries {
+		if e.Kind != LedgerKindCanonical {
+			continue
+		}
+		out = append(out, CanonicalChange{
+			ProposalHash:     payloadString(e.Payload, "proposal_hash"),
+			Version:          payloadString(e.Payload, "canonical_version"),
+			ActivationHeight: uint64(payloadInt(e.Payload, "activation_height")),
+		})
+	}
+	return out
+}
+
+// CanonicalVersionAt returns the canonicalization version in force at height:
+// the version of the last change recorded in entries that activates at or
+// before height, or canonical.Default's version if there is none
+func CanonicalVersionAt(entries []LedgerEntry, height uint64) string {
+	version := canonical.Default.Version()
+	for _, c := range CanonicalChanges(entries) {
+		if c.ActivationHeight <= height {
+			version = c.Version
+		}
+	}
+	return version
+}
+
+// CanonicalizerAt returns a canonicalizer for the version in force at height
+func CanonicalizerAt(entries []LedgerEntry, height uint64) (*canonical.Canonicalizer, error) {
+	return canonical.ForVersion(CanonicalVersionAt(entries, height))
+}
+
+// CanonicalVersion returns the canonicalization version in force for the
+// node's next ledger entry
+func (n *Node) CanonicalVersion() string {
+	return CanonicalVersionAt(n.ledger.Entries(0), n.ledger.Height()+1)
+}
+
+// Canonicalizer returns a canonicalizer for the node's CanonicalVersion
+func (n *Node) Canonicalizer() (*canonical.Canonicalizer, error) {
+	return canonical.ForVersion(n.CanonicalVersion())
+}
@@ -0,0 +1,112 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testCanonicalChange returns a proposal switching to UTF-16 key order at height
+func testCanonicalChange(height float64) *ContractProposal {
+	p := testProposal()
+	p.ActionType = ActionCanonicalChange
+	p.Action = map[string]interface{}{
+		"canonical_version": canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)).Version(),
+		"activation_height": height,
+	}
+	return p
+}
+
+// TestRatifyCanonicalChange tests that a ratified change switches versions at its height
+func TestRatifyCanonicalChange(t *testing.T) {
+	testenv.RequireSigning(t)
+	skipWithoutUTF16(t)
+	quorum, privs := testQuorum(t)
+	ledger, err := NewLedgerFromGenesis(testGenesis(t))
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	node := NewNode(ledger)
+	before := node.CanonicalVersion()
+	if before != canonical.Default.Version() {
+		t.Errorf("Expected the default version before any change, got %s", before)
+	}
+
+	p := testCanonicalChange(4)
+	change, err := CanonicalChangeFromProposal(p)
+	if err != nil {
+		t.Fatalf("Failed to read change: %v", err)
+	}
+	hash, _ := change.Hash()
+	if _, err := RatifyCanonicalChange(ledger, quorum, p, signAll(t, ContextCanonical, hash, privs, "Claude")); err == nil {
+		t.Errorf("A change below the quorum threshold should be rejected")
+	}
+	if _, err := RatifyCanonicalChange(ledger, quorum, p, signAll(t, ContextPolicy, hash, privs, "Claude", "Gemini")); err == nil {
+		t.Errorf("Signatures under another context should be rejected")
+	}
+	if _, err := RatifyCanonicalChange(ledger, quorum, p, signAll(t, ContextCanonical, hash, privs, "Claude", "Gemini")); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+
+	// Recorded at height 2, in force from height 4
+	entries := ledger.Entries(0)
+	if v := CanonicalVersionAt(entries, 3); v != before {
+		t.Errorf("Height 3 should keep the old rules, got %s", v)
+	}
+	if v := CanonicalVersionAt(entries, 4); v != change.Version {
+		t.Errorf("Height 4 should use the new rules, got %s", v)
+	}
+	if node.CanonicalVersion() != before {
+		t.Errorf("The next entry (height 3) should keep the old rules")
+	}
+	if _, err := node.Submit(testProposal()); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	c, err := node.Canonicalizer()
+	if err != nil || c.KeyOrder() != canonical.KeyOrderUTF16 {
+		t.Errorf("Expected the node to switch at height 4: %v", err)
+	}
+
+	t.Logf("✓ Canonical version switches from %s to %s at height %d", before, change.Version, change.ActivationHeight)
+}
+
+// TestCanonicalChangeValidation tests that malformed or conflicting changes are rejected
+func TestCanonicalChangeValidation(t *testing.T) {
+	testenv.RequireSigning(t)
+	skipWithoutUTF16(t)
+	quorum, privs := testQuorum(t)
+	ledger, _ := NewLedgerFromGenesis(testGenesis(t))
+	ratify := func(p *ContractProposal) error {
+		change, err := CanonicalChangeFromProposal(p)
+		if err != nil {
+			return err
+		}
+		hash, _ := change.Hash()
+		_, err = RatifyCanonicalChange(ledger, quorum, p, signAll(t, ContextCanonical, hash, privs, "Claude", "Gemini"))
+		return err
+	}
+
+	if err := ratify(testProposal()); err == nil {
+		t.Errorf("Expected an amend proposal to be rejected")
+	}
+	unknown := testCanonicalChange(10)
+	unknown.Action["canonical_version"] = "2.0.0+key_order=utf8;nulls=keep"
+	if err := ratify(unknown); err == nil {
+		t.Errorf("Expected an unsupported version to be rejected")
+	}
+	if err := ratify(testCanonicalChange(2)); err == nil {
+		t.Errorf("Expected a change activating at its own entry to be rejected")
+	}
+	if err := ratify(testCanonicalChange(10)); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+	if err := ratify(testCanonicalChange(8)); err == nil {
+		t.Errorf("Expected a change before an already scheduled one to be rejected")
+	}
+	if changes := CanonicalChanges(ledger.Entries(0)); len(changes) != 1 || changes[0].ActivationHeight != 10 {
+		t.Errorf("Unexpected schedule: %v", changes)
+	}
+
+	t.Log("✓ Invalid and out-of-order changes rejected")
+}
@@ -0,0 +1,131 @@
+// capabilities.go - Optional and accelerated features compiled in
+//
+// The module builds with CGO_ENABLED=0 for every GOOS/GOARCH the Go toolchain
+// supports, so operators can cross-compile a node for ARM edge devices from
+// any workstation. What differs between builds is which optional features are
+// present and which code paths use assembly. Capabilities reports both, so an
+// operator can confirm that a binary on a device is the build they intended
+// before trusting its results.
+//
+// Constrained targets can drop protocol features with build tags
+// (ocp_noutf16, ocp_nosha3, ocp_noprehash). Such a reduced build refuses any
+// object that needs a feature it lacks with a *CapabilityError, and CheckPeer
+// refuses peers whose objects it could not verify, so it never reports an
+// object valid without having checked it. TestPureGoBuildMatrix keeps the guarantee: it
+// fails if any package gains cgo files or a dependency outside the standard
+// library.
+
+package ocp
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// Capabilities a reduced build can omit, named as in Capabilities
+const (
+	CapabilityUTF16KeyOrder = canonical.CapabilityUTF16KeyOrder
+	CapabilitySHA3          = "sha3_256"
+	CapabilityPrehash       = "ed25519ph"
+)
+
+// Capability is one optional or accelerated feature of a build
+type Capability struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail"`
+}
+
+// ToMap converts a Capability to a map for canonicalization
+func (c Capability) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    c.Name,
+		"enabled": c.Enabled,
+		"detail":  c.Detail,
+	}
+}
+
+// sha256AsmArch lists the architectures with assembly SHA-256 in the
+// standard library
+var sha256AsmArch = map[string]bool{
+	"386": true, "amd64": true, "arm64": true, "ppc64": true, "ppc64le": true, "s390x": true,
+}
+
+// Capabilities reports the optional and accelerated features of this build,
+// in a fixed order
+func Capabilities() []Capability {
+	return []Capability{
+		{
+			Name:    "accelerated_sha256",
+			Enabled: !PureGoBuild && sha256AsmArch[runtime.GOARCH],
+			Detail:  "assembly SHA-256 for semantic and content hashes; off under the purego tag",
+		},
+		{
+			Name:    "accelerated_ed25519",
+			Enabled: !PureGoBuild && runtime.GOARCH == "amd64",
+			Detail:  "assembly field arithmetic for Ed25519; off under the purego tag",
+		},
+		{
+			Name:    "cgo",
+			Enabled: cgoEnabled(),
+			Detail:  "toolchain setting only; no package in this module uses cgo",
+		},
+		{
+			Name:    "signing",
+			Enabled: !VerifyOnlyBuild,
+			Detail:  "signing, ledger appends, and bond locking; off under the ocp_verifyonly tag",
+		},
+		{
+			Name:    CapabilityUTF16KeyOrder,
+			Enabled: canonical.UTF16KeyOrderBuild,
+			Detail:  "RFC 8785 key order for canonical versions that select it; off under the ocp_noutf16 tag",
+		},
+		{
+			Name:    CapabilitySHA3,
+			Enabled: hashing.SHA3Build,
+			Detail:  "SHA3-256 for dual hashing and hash migration; off under the ocp_nosha3 tag",
+		},
+		{
+			Name:    CapabilityPrehash,
+			Enabled: PrehashBuild,
+			Detail:  "Ed25519ph signatures over streamed payloads; off under the ocp_noprehash tag",
+		},
+		{
+			Name:    "sql_archive",
+			Enabled: true,
+			Detail:  "SQLArchive; the caller supplies the PostgreSQL driver",
+		},
+	}
+}
+
+// LookupCapability returns the named capability, if this build knows it
+func LookupCapability(name string) (Capability, bool) {
+	for _, c := range Capabilities() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Capability{}, false
+}
+
+// Platform returns the GOOS/GOARCH this binary was compiled for
+func Platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// cgoEnabled reports the CGO_ENABLED setting recorded in the binary
+func cgoEnabled() bool {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return false
+	}
+	for _, s := range info.Settings {
+		if s.Key == "CGO_ENABLED" {
+			return s.Value == "1"
+		}
+	}
+	return false
+}
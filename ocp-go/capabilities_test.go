@@ -0,0 +1,215 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"go/build"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// skipWithoutUTF16 skips a test that needs KeyOrderUTF16 in builds that
+// compile it out
+func skipWithoutUTF16(t *testing.T) {
+	t.Helper()
+	if !canonical.UTF16KeyOrderBuild {
+		t.Skip("KeyOrderUTF16 is compiled out of this build (ocp_noutf16)")
+	}
+}
+
+// skipWithoutSHA3 skips a test that needs SHA3-256 in builds that compile it
+// out
+func skipWithoutSHA3(t *testing.T) {
+	t.Helper()
+	if !hashing.SHA3Build {
+		t.Skip("SHA3-256 is compiled out of this build (ocp_nosha3)")
+	}
+}
+
+// skipWithoutPrehash skips a test that needs Ed25519ph signing in builds that
+// compile it out
+func skipWithoutPrehash(t *testing.T) {
+	t.Helper()
+	if !PrehashBuild {
+		t.Skip("Ed25519ph signing is compiled out of this build (ocp_noprehash)")
+	}
+}
+
+// TestCapabilities tests that the report reflects the build tags
+func TestCapabilities(t *testing.T) {
+	caps := Capabilities()
+	seen := make(map[string]bool)
+	for _, c := range caps {
+		if seen[c.Name] || c.Detail == "" {
+			t.Errorf("Duplicate or undocumented capability %+v", c)
+		}
+		seen[c.Name] = true
+	}
+	signing, ok := LookupCapability("signing")
+	if !ok || signing.Enabled == VerifyOnlyBuild {
+		t.Errorf("Signing capability %+v disagrees with VerifyOnlyBuild", signing)
+	}
+	if sha, _ := LookupCapability("accelerated_sha256"); PureGoBuild && sha.Enabled {
+		t.Errorf("purego build reports accelerated SHA-256")
+	}
+	for name, enabled := range map[string]bool{
+		CapabilityUTF16KeyOrder: canonical.UTF16KeyOrderBuild,
+		CapabilitySHA3:          hashing.SHA3Build,
+		CapabilityPrehash:       PrehashBuild,
+	} {
+		if c, ok := LookupCapability(name); !ok || c.Enabled != enabled {
+			t.Errorf("Capability %s %+v disagrees with its build tag", name, c)
+		}
+	}
+	if _, ok := LookupCapability("nonexistent"); ok {
+		t.Errorf("Expected unknown capabilities to be absent")
+	}
+	if !strings.Contains(Platform(), "/") {
+		t.Errorf("Unexpected platform %q", Platform())
+	}
+
+	t.Logf("✓ %d capabilities reported for %s", len(caps), Platform())
+}
+
+// TestPureGoBuildMatrix tests that every package builds without cgo and with
+// only standard library dependencies, for each target and build profile
+// operators deploy, and that the module requires no other modules
+func TestPureGoBuildMatrix(t *testing.T) {
+	const modulePath = "github.com/seanrugg/ai_constitution/ocp-go"
+	targets := []struct{ goos, goarch string }{
+		{"linux", "amd64"}, {"linux", "arm64"}, {"linux", "arm"}, {"linux", "riscv64"},
+		{"darwin", "arm64"}, {"windows", "amd64"},
+	}
+	profiles := [][]string{nil, {"purego"}, {"ocp_verifyonly"}, {"purego", "ocp_verifyonly"},
+		{"ocp_noutf16", "ocp_nosha3", "ocp_noprehash"}}
+
+	var dirs []string
+	filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (strings.HasPrefix(d.Name(), ".") || d.Name() == "testdata") && path != "." {
+			return filepath.SkipDir
+		}
+		// Nested modules such as ext may depend on third-party code
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil && d.IsDir() && path != "." {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	checked := 0
+	for _, target := range targets {
+		for _, tags := range profiles {
+			ctx := build.Default
+			ctx.GOOS, ctx.GOARCH, ctx.CgoEnabled, ctx.BuildTags = target.goos, target.goarch, false, tags
+			for _, dir := range dirs {
+				pkg, err := ctx.ImportDir(dir, 0)
+				if _, ok := err.(*build.NoGoError); ok {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s/%s %v: %s: %v", target.goos, target.goarch, tags, dir, err)
+				}
+				if len(pkg.CgoFiles) > 0 {
+					t.Errorf("%s uses cgo: %v", dir, pkg.CgoFiles)
+				}
+				for _, imp := range pkg.Imports {
+					first := strings.SplitN(imp, "/", 2)[0]
+					if imp == "C" || (strings.Contains(first, ".") && !strings.HasPrefix(imp, modulePath)) {
+						t.Errorf("%s/%s %v: %s imports %s", target.goos, target.goarch, tags, dir, imp)
+					}
+				}
+				checked++
+			}
+		}
+	}
+	mod, err := os.ReadFile("go.mod")
+	if err != nil || checked == 0 {
+		t.Fatalf("Build matrix checked no packages")
+	}
+	if strings.Contains(string(mod), "\nrequire") {
+		t.Errorf("go.mod must not require other modules")
+	}
+
+	t.Logf("✓ %d package builds are cgo-free and stdlib-only", checked)
+}
+
+// buildTagSuiteEnv is set in the go test runs TestBuildTagSuites starts, so
+// they do not start their own
+const buildTagSuiteEnv = "OCP_BUILD_TAG_SUITE"
+
+// TestBuildTagSuites runs the module's tests once under each build tag, so a
+// test that needs a compiled-out feature must skip rather than fail. It is
+// skipped with -short.
+func TestBuildTagSuites(t *testing.T) {
+	if testing.Short() || os.Getenv(buildTagSuiteEnv) != "" {
+		t.Skip("Build tag suites run only in a full, top-level go test")
+	}
+	for _, tag := range []string{"purego", "ocp_verifyonly", "ocp_noutf16", "ocp_nosha3", "ocp_noprehash"} {
+		cmd := exec.Command("go", "test", "-tags", tag, "./...")
+		cmd.Env = append(os.Environ(), buildTagSuiteEnv+"=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go test -tags %s failed: %v\n%s", tag, err, out)
+		}
+	}
+	t.Log("✓ Every build tag passes its test suite")
+}
+
+// TestReducedBuilds tests that features a build omits fail with a typed
+// error, and that a reduced node refuses peers whose objects it cannot verify
+func TestReducedBuilds(t *testing.T) {
+	var capErr *CapabilityError
+	_, err := AlgorithmHash("sha3-256", map[string]interface{}{"a": 1})
+	if hashing.SHA3Build == (err != nil) || (!hashing.SHA3Build && !errors.As(err, &capErr)) {
+		t.Errorf("SHA3-256 disagrees with SHA3Build: %v", err)
+	}
+
+	_, priv := testKey("Claude")
+	sig, err := SignPayload("Claude", priv, ContextProposal, map[string]interface{}{"a": 1})
+	switch {
+	case VerifyOnlyBuild:
+		if !errors.Is(err, ErrVerifyOnly) && !errors.As(err, &capErr) {
+			t.Errorf("A verification-only build should refuse to sign, got %v", err)
+		}
+	case PrehashBuild == (err != nil) || (!PrehashBuild && !errors.As(err, &capErr)):
+		t.Errorf("Ed25519ph signing disagrees with PrehashBuild: %v", err)
+	}
+	sig.Mode = SignModePrehashed
+	err = VerifyPayloadSignature(priv.Public().(ed25519.PublicKey), ContextProposal, map[string]interface{}{"a": 1}, sig)
+	if !PrehashBuild && !errors.As(err, &capErr) {
+		t.Errorf("A reduced build should refuse prehashed signatures with a CapabilityError, got %v", err)
+	}
+
+	// A build without UTF-16 key order facing a peer that hashes under it
+	utf16 := canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)).Version()
+	full := BuildInfo()
+	full.DefaultCanonical = utf16
+	full.CanonicalVersions = []string{canonical.Default.Version(), utf16}
+	reduced := BuildInfo()
+	reduced.CanonicalVersions = []string{canonical.Default.Version()}
+	if err := reduced.Compatible(full); err != nil {
+		t.Fatalf("The full peer can verify the reduced node's objects: %v", err)
+	}
+	if err := reduced.Verifiable(full); !errors.As(err, &capErr) || capErr.Capability != CapabilityUTF16KeyOrder {
+		t.Errorf("Expected a CapabilityError for the peer's canonical version, got %v", err)
+	}
+	if err := full.Verifiable(reduced); err != nil {
+		t.Errorf("The full node can verify the reduced node: %v", err)
+	}
+	if err := CheckPeer(NodeInfo{NodeID: "peer", Build: BuildInfo()}.ToMap()); err != nil {
+		t.Errorf("A build should accept itself: %v", err)
+	}
+
+	t.Logf("✓ Reduced builds refuse with %T", capErr)
+}
@@ -0,0 +1,183 @@
+// checkpoint.go - Resumable batch verification of ledger history
+//
+// Verifying a ledger of millions of entries can take long enough that a crash
+// partway through is likely. A BatchVerifier walks the chain in pages and
+// every Interval entries archives a VerifyCheckpoint recording the last
+// verified height and head hash. Because each entry commits to its
+// predecessor, the head hash is a running hash over everything verified so
+// far, so ResumeVerify can continue from a checkpoint without re-reading any
+// earlier entry. Checkpoints are stored as canonical objects in an Archive,
+// which makes them tamper-evident, and each names the checkpoint before it.
+
+package ocp
+
+import (
+	"fmt"
+)
+
+// EntryPager returns up to limit ledger entries with height greater than after,
+// in height order. An empty page means there are no more entries.
+type EntryPager func(after uint64, limit int) ([]LedgerEntry, error)
+
+// LedgerPager pages through the entries held by an in-memory ledger
+func LedgerPager(l *Ledger) EntryPager {
+	return func(after uint64, limit int) ([]LedgerEntry, error) {
+		entries := l.Entries(after)
+		if len(entries) > limit {
+			entries = entries[:limit]
+		}
+		return entries, nil
+	}
+}
+
+// VerifyCheckpoint records progress of a batch verification job
+type VerifyCheckpoint struct {
+	// BaseHeight and BaseHead are the trusted point the job started from
+	BaseHeight uint64 `json:"base_height"`
+	BaseHead   string `json:"base_head"`
+	// Height and Head are the last verified entry; Head is a running hash
+	// committing to every entry between the base and Height
+	Height uint64 `json:"height"`
+	Head   string `json:"head"`
+	// Previous is the archive hash of the prior checkpoint, "" for the first
+	Previous string `json:"previous"`
+}
+
+// ToMap converts a VerifyCheckpoint to a map for canonicalization
+func (c *VerifyCheckpoint) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"base_height": c.BaseHeight,
+		"base_head":   c.BaseHead,
+		"height":      c.Height,
+		"head":        c.Head,
+		"previous":    c.Previous,
+	}
+}
+
+// Hash returns the semantic hash of the checkpoint, which is also its archive key
+func (c *VerifyCheckpoint) Hash() (string, error) {
+	return SemanticHash(c.ToMap())
+}
+
+// LoadCheckpoint reads a checkpoint stored by a BatchVerifier
+func LoadCheckpoint(a Archive, hash string) (*VerifyCheckpoint, error) {
+	obj, err := LoadObject(a, hash)
+	if err != nil {
+		return nil, err
+	}
+	c := &VerifyCheckpoint{
+		BaseHeight: uint64(payloadInt(obj, "base_height")),
+		BaseHead:   payloadString(obj, "base_head"),
+		Height:     uint64(payloadInt(obj, "height")),
+		Head:       payloadString(obj, "head"),
+		Previous:   payloadString(obj, "previous"),
+	}
+	if c.Height < c.BaseHeight {
+		return nil, NewVerificationError(fmt.Sprintf("checkpoint %s is below its base height", hash))
+	}
+	return c, nil
+}
+
+// BatchVerifier verifies ledger history in pages, checkpointing as it goes
+type BatchVerifier struct {
+	// Archive stores checkpoints
+	Archive Archive
+	// Interval is the number of entries verified between checkpoints
+	Interval uint64
+	// PageSize is the number of entries requested per page; defaults to Interval
+	PageSize int
+	// OnCheckpoint, if set, is called with each stored checkpoint and its hash.
+	// Persisting the latest hash lets a restarted job call ResumeVerify.
+	OnCheckpoint func(hash string, c *VerifyCheckpoint)
+}
+
+// Verify verifies source from genesis
+func (v *BatchVerifier) Verify(source EntryPager) (*VerifyCheckpoint, string, error) {
+	return v.VerifyFrom(0, "", source)
+}
+
+// VerifyFrom verifies source starting after a trusted entry, such as the head
+// of a quorum-signed snapshot
+//
+// Returns:
+//   - Final checkpoint and its archive hash, stored even when verification fails
+//     so that it records how far the chain was valid
+//   - VerificationError describing the first broken link
+func (v *BatchVerifier) VerifyFrom(height uint64, head string, source EntryPager) (*VerifyCheckpoint, string, error) {
+	return v.run(&VerifyCheckpoint{BaseHeight: height, BaseHead: head, Height: height, Head: head}, "", source)
+}
+
+// ResumeVerify continues a job from a stored checkpoint
+//
+// Parameters:
+//   - checkpointHash: Archive hash of the checkpoint to resume from
+//   - source: Entries of the same ledger; only entries above the checkpoint are read
+//
+// Returns:
+//   - Final checkpoint and its archive hash
+//   - VerificationError describing the first broken link
+func (v *BatchVerifier) ResumeVerify(checkpointHash string, source EntryPager) (*VerifyCheckpoint, string, error) {
+	c, err := LoadCheckpoint(v.Archive, checkpointHash)
+	if err != nil {
+		return nil, "", err
+	}
+	return v.run(c, checkpointHash, source)
+}
+
+func (v *BatchVerifier) run(c *VerifyCheckpoint, hash string, source EntryPager) (*VerifyCheckpoint, string, error) {
+	if v.Interval == 0 {
+		return nil, "", NewConstitutionalError("checkpoint interval must be positive")
+	}
+	pageSize := v.PageSize
+	if pageSize <= 0 {
+		pageSize = int(v.Interval)
+	}
+
+	sinceCheckpoint := uint64(0)
+	for {
+		page, err := source(c.Height, pageSize)
+		if err != nil {
+			return c, hash, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		for i := range page {
+			if _, err := VerifyChain(c.Height, c.Head, page[i:i+1]); err != nil {
+				if sinceCheckpoint > 0 {
+					hash, _ = v.store(c, hash)
+				}
+				return c, hash, err
+			}
+			c = &VerifyCheckpoint{BaseHeight: c.BaseHeight, BaseHead: c.BaseHead, Height: page[i].Height, Head: page[i].Hash}
+			sinceCheckpoint++
+			if sinceCheckpoint == v.Interval {
+				if hash, err = v.store(c, hash); err != nil {
+					return c, hash, err
+				}
+				sinceCheckpoint = 0
+			}
+		}
+	}
+
+	if sinceCheckpoint > 0 || hash == "" {
+		var err error
+		if hash, err = v.store(c, hash); err != nil {
+			return c, hash, err
+		}
+	}
+	return c, hash, nil
+}
+
+// store archives c as the successor of the checkpoint at previous
+func (v *BatchVerifier) store(c *VerifyCheckpoint, previous string) (string, error) {
+	c.Previous = previous
+	hash, err := ArchiveObject(v.Archive, c.ToMap())
+	if err != nil {
+		return previous, err
+	}
+	if v.OnCheckpoint != nil {
+		v.OnCheckpoint(hash, c)
+	}
+	return hash, nil
+}
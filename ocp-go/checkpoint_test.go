@@ -0,0 +1,96 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// checkpointLedger returns a ledger with n note entries
+func checkpointLedger(t *testing.T, n int) *Ledger {
+	l := NewLedger()
+	for i := 0; i < n; i++ {
+		if _, err := l.Append("note", map[string]interface{}{"seq": i}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	return l
+}
+
+// TestBatchVerifierResume tests resuming verification after a crash
+func TestBatchVerifierResume(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := checkpointLedger(t, 25)
+	archive := NewMemoryArchive()
+	var latest string
+	v := &BatchVerifier{Archive: archive, Interval: 10, PageSize: 4, OnCheckpoint: func(hash string, _ *VerifyCheckpoint) { latest = hash }}
+
+	// Crash after reading 12 entries
+	crash := errors.New("crash")
+	pager := LedgerPager(ledger)
+	flaky := func(after uint64, limit int) ([]LedgerEntry, error) {
+		if after >= 12 {
+			return nil, crash
+		}
+		return pager(after, limit)
+	}
+	if _, _, err := v.Verify(flaky); !errors.Is(err, crash) {
+		t.Fatalf("Expected crash, got %v", err)
+	}
+	saved, err := LoadCheckpoint(archive, latest)
+	if err != nil || saved.Height != 10 {
+		t.Fatalf("Expected a checkpoint at height 10, got %+v (%v)", saved, err)
+	}
+
+	// Resume reads only entries above the checkpoint
+	var lowest uint64 = ^uint64(0)
+	counting := func(after uint64, limit int) ([]LedgerEntry, error) {
+		if after < lowest {
+			lowest = after
+		}
+		return pager(after, limit)
+	}
+	final, hash, err := v.ResumeVerify(latest, counting)
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if lowest != 10 || final.Height != 25 || final.Head != ledger.Head() {
+		t.Errorf("Unexpected resume: from %d to %+v", lowest, final)
+	}
+	previous, _ := LoadCheckpoint(archive, final.Previous)
+	if previous == nil || previous.Height != 20 || hash != latest {
+		t.Errorf("Checkpoints should chain: %+v", previous)
+	}
+
+	t.Logf("✓ Resumed to height %d, checkpoint %s", final.Height, hash)
+}
+
+// TestBatchVerifierBrokenChain tests that a checkpoint records how far the chain was valid
+func TestBatchVerifierBrokenChain(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := checkpointLedger(t, 8)
+	entries := ledger.Entries(0)
+	entries[5].Payload = map[string]interface{}{"seq": 99}
+	broken := func(after uint64, limit int) ([]LedgerEntry, error) {
+		page := entries[after:]
+		if len(page) > limit {
+			page = page[:limit]
+		}
+		return page, nil
+	}
+
+	v := &BatchVerifier{Archive: NewMemoryArchive(), Interval: 3}
+	c, hash, err := v.Verify(broken)
+	if err == nil {
+		t.Fatalf("Broken chain should fail verification")
+	}
+	if c.Height != 5 || hash == "" {
+		t.Errorf("Expected checkpoint at height 5, got %+v", c)
+	}
+	if _, _, err := v.ResumeVerify("0000", broken); !errors.Is(err, ErrNotArchived) {
+		t.Errorf("Unknown checkpoint should not resume: %v", err)
+	}
+
+	t.Logf("✓ Chain valid through height %d: %v", c.Height, err)
+}
@@ -0,0 +1,303 @@
+// classification.go - Per-field sensitivity classification and filtered export
+//
+// Regulated operators need to share proposals outside their organization without
+// leaking reasoning content. Fields above the export level are replaced by a marker
+// holding an HMAC of the removed subtree, so a recipient who is later given the
+// original value can check that it is exactly what was withheld.
+//
+// Each marker's HMAC has its own key, derived from a random RedactionKey drawn
+// for the export and the marker's location in the object. A plain hash of a
+// low-entropy field (a name, a flag, a small enum) would be reversed by
+// hashing every candidate value; without the key a recipient cannot test
+// guesses. The exporter keeps the export key and, with each original it later
+// chooses to reveal, discloses only that marker's key, so revealing one field
+// does not let a recipient test guesses against any other.
+//
+// Only the containers canonicalization understands are walked; any other
+// container fails the export rather than passing classified fields through.
+
+package ocp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Classification is the sensitivity level of a field
+type Classification int
+
+const (
+	Public Classification = iota
+	Internal
+	Confidential
+)
+
+// RedactedKey marks an object that replaces a withheld field
+const RedactedKey = "_redacted"
+
+// RedactionKeySize is the length in bytes of a RedactionKey
+const RedactionKeySize = 32
+
+// RedactionKey is the secret of one export, from which each redaction marker's
+// key is derived, or the key of a single marker
+type RedactionKey []byte
+
+// NewRedactionKey draws a random RedactionKey
+func NewRedactionKey() (RedactionKey, error) {
+	key := make(RedactionKey, RedactionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("redaction key: %w", err)
+	}
+	return key, nil
+}
+
+// ForPath returns the key of the marker at path, the JSON pointer recorded in
+// the marker. It is what the exporter discloses with that marker's value.
+func (k RedactionKey) ForPath(path string) RedactionKey {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte("ocp-redaction:" + path))
+	return mac.Sum(nil)
+}
+
+// digest returns the hex HMAC-SHA256 of value's canonical form under k
+func (k RedactionKey) digest(value interface{}) (string, error) {
+	canonicalString, err := CanonicalizeValue(value)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(canonicalString))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// String returns the lowercase name used in schemas and redaction markers
+func (c Classification) String() string {
+	switch c {
+	case Public:
+		return "public"
+	case Internal:
+		return "internal"
+	case Confidential:
+		return "confidential"
+	default:
+		return fmt.Sprintf("classification(%d)", int(c))
+	}
+}
+
+// ParseClassification parses a classification name
+func ParseClassification(name string) (Classification, error) {
+	switch name {
+	case "public":
+		return Public, nil
+	case "internal":
+		return Internal, nil
+	case "confidential":
+		return Confidential, nil
+	default:
+		return Public, NewConstitutionalError(fmt.Sprintf("unknown classification %q", name))
+	}
+}
+
+// ObjectSchema attaches classification metadata to the fields of an object kind.
+// Fields are addressed by dotted paths ("reasoning", "action.parameters"); array
+// elements share the path of their array, so "evidence.description" applies to
+// every evidence item. A field without an entry inherits its parent's level.
+type ObjectSchema struct {
+	Kind    string
+	Default Classification
+	Fields  map[string]Classification
+}
+
+// ContractProposalSchema is the default classification of contract proposal fields
+var ContractProposalSchema = &ObjectSchema{
+	Kind:    "contract_proposal",
+	Default: Public,
+	Fields: map[string]Classification{
+		"reasoning":               Confidential,
+		"canonical_serialization": Confidential,
+		"action.parameters":       Internal,
+		"evidence.description":    Internal,
+	},
+}
+
+// ClassificationOf returns the level of the field at path
+func (s *ObjectSchema) ClassificationOf(path string) Classification {
+	level := s.Default
+	prefix := ""
+	for _, part := range strings.Split(path, ".") {
+		if prefix == "" {
+			prefix = part
+		} else {
+			prefix = prefix + "." + part
+		}
+		if c, ok := s.Fields[prefix]; ok && c > level {
+			level = c
+		}
+	}
+	return level
+}
+
+// ExportFiltered returns a copy of data with every field classified above level
+// replaced by a redaction marker containing the withheld subtree's HMAC under
+// the marker's own key, derived from a fresh RedactionKey.
+//
+// Parameters:
+//   - data: Object to export (not modified)
+//   - level: Highest classification the recipient may see
+//
+// Returns:
+//   - Filtered copy safe to share at the given level
+//   - Key of the export, to be kept by the exporter; ForPath gives the key to
+//     disclose with a withheld value
+//   - An error if data holds a container the filter cannot walk
+func (s *ObjectSchema) ExportFiltered(data map[string]interface{}, level Classification) (map[string]interface{}, RedactionKey, error) {
+	key, err := NewRedactionKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := s.filterMap(data, "", "", level, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("export %s: %w", s.Kind, err)
+	}
+	return out, key, nil
+}
+
+// filterMap filters data, whose classification path is prefix and whose
+// location is the JSON pointer pointer
+func (s *ObjectSchema) filterMap(data map[string]interface{}, prefix, pointer string, level Classification, key RedactionKey) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		at := pointer + "/" + escapePointer(k)
+
+		if c := s.ClassificationOf(path); c > level {
+			marker, err := redactionMarker(v, c, at, key)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = marker
+			continue
+		}
+
+		filtered, err := s.filterValue(v, path, at, level, key)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = filtered
+	}
+	return out, nil
+}
+
+func (s *ObjectSchema) filterValue(v interface{}, path, pointer string, level Classification, key RedactionKey) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return s.filterMap(val, path, pointer, level, key)
+
+	case map[string]string:
+		if val == nil {
+			return v, nil
+		}
+		generic := make(map[string]interface{}, len(val))
+		for k, elem := range val {
+			generic[k] = elem
+		}
+		return s.filterMap(generic, path, pointer, level, key)
+
+	case map[string]float64:
+		if val == nil {
+			return v, nil
+		}
+		generic := make(map[string]interface{}, len(val))
+		for k, elem := range val {
+			generic[k] = elem
+		}
+		return s.filterMap(generic, path, pointer, level, key)
+
+	case []interface{}:
+		return s.filterList(len(val), func(i int) interface{} { return val[i] }, path, pointer, level, key)
+
+	case []map[string]string:
+		if val == nil {
+			return v, nil
+		}
+		return s.filterList(len(val), func(i int) interface{} { return val[i] }, path, pointer, level, key)
+
+	case []map[string]interface{}:
+		if val == nil {
+			return v, nil
+		}
+		return s.filterList(len(val), func(i int) interface{} { return val[i] }, path, pointer, level, key)
+
+	case nil, string, bool, float64, float32, int, int64, int32, uint, uint64, uint32, json.Number, []byte, Decimal, *Decimal:
+		return v, nil
+
+	default:
+		// Fail closed: an unknown container may hide classified fields
+		switch reflect.TypeOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer, reflect.Interface:
+			return nil, NewConstitutionalError(fmt.Sprintf("cannot filter %T at %s", v, pointer))
+		}
+		return v, nil
+	}
+}
+
+// filterList filters the n elements of an array, which share its path
+func (s *ObjectSchema) filterList(n int, elem func(int) interface{}, path, pointer string, level Classification, key RedactionKey) ([]interface{}, error) {
+	out := make([]interface{}, n)
+	for i := range out {
+		filtered, err := s.filterValue(elem(i), path, pointer+"/"+strconv.Itoa(i), level, key)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = filtered
+	}
+	return out, nil
+}
+
+// escapePointer escapes a key as a JSON pointer reference token (RFC 6901)
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func redactionMarker(v interface{}, c Classification, pointer string, key RedactionKey) (map[string]interface{}, error) {
+	hash, err := key.ForPath(pointer).digest(v)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		RedactedKey:      hash,
+		"classification": c.String(),
+		"path":           pointer,
+	}, nil
+}
+
+// VerifyRedacted checks that value is the subtree withheld by a redaction marker,
+// given the marker's key (the export key's ForPath of the marker's path)
+func VerifyRedacted(marker interface{}, value interface{}, key RedactionKey) (bool, error) {
+	m, ok := marker.(map[string]interface{})
+	if !ok {
+		return false, NewConstitutionalError("not a redaction marker")
+	}
+	expected, ok := m[RedactedKey].(string)
+	if !ok {
+		return false, NewConstitutionalError("not a redaction marker")
+	}
+	if len(key) == 0 {
+		return false, NewConstitutionalError("redaction key required")
+	}
+	actual, err := key.digest(value)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(actual), []byte(expected)), nil
+}
@@ -0,0 +1,199 @@
+package ocp
+
+import (
+	"testing"
+)
+
+// testProposal returns a contract proposal used by classification tests
+func testProposal() *ContractProposal {
+	return &ContractProposal{
+		ID:            "550e8400-e29b-41d4-a716-446655440000",
+		ProposerAgent: "Claude",
+		ActionType:    "amend",
+		Action: map[string]interface{}{
+			"target":     "amendment-article-3",
+			"operation":  "modify",
+			"parameters": map[string]interface{}{"article": "III.1"},
+		},
+		Evidence: []map[string]string{
+			{
+				"type":        "archive_reference",
+				"pointer":     "sha256:abc123def456",
+				"description": "Internal dispute record",
+			},
+		},
+		Reasoning: map[string]interface{}{
+			"rationale":  "Clarifies Article III.1",
+			"confidence": float64(0.87),
+		},
+		Timestamp: "2025-11-20T14:30:00Z",
+	}
+}
+
+// TestClassificationOf tests path inheritance of classification levels
+func TestClassificationOf(t *testing.T) {
+	cases := map[string]Classification{
+		"id":                        Public,
+		"reasoning":                 Confidential,
+		"reasoning.rationale":       Confidential,
+		"action.target":             Public,
+		"action.parameters":         Internal,
+		"action.parameters.article": Internal,
+		"evidence.description":      Internal,
+		"evidence.pointer":          Public,
+	}
+
+	for path, expected := range cases {
+		if got := ContractProposalSchema.ClassificationOf(path); got != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, got)
+		}
+	}
+}
+
+// TestExportFiltered tests that fields above the export level are replaced by hashes
+func TestExportFiltered(t *testing.T) {
+	proposal := testProposal()
+	data := proposal.ToMap()
+
+	public, key, err := ContractProposalSchema.ExportFiltered(data, Public)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	marker, ok := public["reasoning"].(map[string]interface{})
+	if !ok || marker[RedactedKey] == nil {
+		t.Fatalf("Reasoning should be redacted at public level, got %v", public["reasoning"])
+	}
+	if marker["classification"] != "confidential" {
+		t.Errorf("Marker should record classification, got %v", marker["classification"])
+	}
+
+	if marker["path"] != "/reasoning" {
+		t.Errorf("Marker should record its location, got %v", marker["path"])
+	}
+	valid, err := VerifyRedacted(marker, proposal.Reasoning, key.ForPath("/reasoning"))
+	if err != nil {
+		t.Fatalf("Failed to verify redaction: %v", err)
+	}
+	if !valid {
+		t.Errorf("Original reasoning should match the redacted hash")
+	}
+
+	action := public["action"].(map[string]interface{})
+	if action["target"] != "amendment-article-3" {
+		t.Errorf("Public action target should be kept")
+	}
+	if _, ok := action["parameters"].(map[string]interface{})[RedactedKey]; !ok {
+		t.Errorf("Internal action parameters should be redacted at public level")
+	}
+
+	evidence := public["evidence"].([]interface{})[0].(map[string]interface{})
+	if evidence["pointer"] != "sha256:abc123def456" {
+		t.Errorf("Evidence pointer should be kept")
+	}
+	if m, ok := evidence["description"].(map[string]interface{}); !ok || m["path"] != "/evidence/0/description" {
+		t.Errorf("Evidence description should be redacted at public level, got %v", evidence["description"])
+	}
+
+	// Exporting must not modify the source
+	if _, ok := data["reasoning"].(map[string]interface{})[RedactedKey]; ok {
+		t.Errorf("Source data should not be modified")
+	}
+
+	internal, _, err := ContractProposalSchema.ExportFiltered(data, Internal)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if _, ok := internal["action"].(map[string]interface{})["parameters"].(map[string]interface{})[RedactedKey]; ok {
+		t.Errorf("Internal fields should be visible at internal level")
+	}
+
+	full, _, err := ContractProposalSchema.ExportFiltered(data, Confidential)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if !CanonicallyEqual(full, data) {
+		t.Errorf("Confidential export should be canonically equal to the source")
+	}
+
+	t.Logf("✓ Public export redacts reasoning: %v", marker[RedactedKey])
+}
+
+// TestRedactionResistsGuessing tests that a low-entropy withheld value cannot
+// be confirmed without the export's key
+func TestRedactionResistsGuessing(t *testing.T) {
+	schema := &ObjectSchema{Kind: "flagged", Fields: map[string]Classification{"flag": Confidential}}
+	data := map[string]interface{}{"id": "a", "flag": true}
+
+	first, key, err := schema.ExportFiltered(data, Public)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	second, _, err := schema.ExportFiltered(data, Public)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	marker := first["flag"].(map[string]interface{})
+	if marker[RedactedKey] == second["flag"].(map[string]interface{})[RedactedKey] {
+		t.Errorf("Expected each export to key its markers afresh")
+	}
+	if plain, _ := ValueHash(true); marker[RedactedKey] == plain {
+		t.Errorf("Expected the marker not to be the plain hash of the value")
+	}
+
+	other, _ := NewRedactionKey()
+	for _, guess := range []interface{}{true, false} {
+		if ok, _ := VerifyRedacted(marker, guess, other); ok {
+			t.Errorf("Guess %v confirmed without the export's key", guess)
+		}
+	}
+	markerKey := key.ForPath("/flag")
+	if ok, _ := VerifyRedacted(marker, false, markerKey); ok {
+		t.Errorf("Expected the wrong value to be refused")
+	}
+	if ok, err := VerifyRedacted(marker, true, markerKey); err != nil || !ok {
+		t.Errorf("Expected the withheld value to verify under its key, got %v", err)
+	}
+	if _, err := VerifyRedacted(marker, true, nil); err == nil {
+		t.Errorf("Expected a missing key to be refused")
+	}
+	t.Logf("✓ Redacted flag keyed by a %d-byte export key", len(key))
+}
+
+// TestRedactionKeysPerMarker tests that the key disclosed with one revealed
+// value does not confirm guesses about any other marker
+func TestRedactionKeysPerMarker(t *testing.T) {
+	schema := &ObjectSchema{Kind: "flagged", Fields: map[string]Classification{"flag": Confidential, "items.secret": Confidential}}
+	data := map[string]interface{}{
+		"flag":  true,
+		"items": []map[string]interface{}{{"secret": true, "id": "a"}, {"secret": true, "id": "b"}},
+	}
+	out, key, err := schema.ExportFiltered(data, Public)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	items, ok := out["items"].([]interface{})
+	if !ok || len(items) != 2 {
+		t.Fatalf("Expected a slice of objects to be filtered, got %T", out["items"])
+	}
+	first := items[0].(map[string]interface{})["secret"].(map[string]interface{})
+	second := items[1].(map[string]interface{})["secret"].(map[string]interface{})
+	if first[RedactedKey] == second[RedactedKey] {
+		t.Errorf("Expected equal values at different locations to get different markers")
+	}
+
+	revealed := key.ForPath(first["path"].(string))
+	if ok, err := VerifyRedacted(first, true, revealed); err != nil || !ok {
+		t.Errorf("Expected the revealed value to verify under its marker key, got %v", err)
+	}
+	for _, other := range []map[string]interface{}{second, out["flag"].(map[string]interface{})} {
+		if ok, _ := VerifyRedacted(other, true, revealed); ok {
+			t.Errorf("Marker %v confirmed with another marker's key", other["path"])
+		}
+	}
+
+	if _, _, err := schema.ExportFiltered(map[string]interface{}{"items": []map[string]int{{"secret": 1}}}, Public); err == nil {
+		t.Errorf("Expected a container the filter cannot walk to fail the export")
+	}
+	t.Logf("✓ Revealing %s discloses nothing about the other markers", first["path"])
+}
@@ -0,0 +1,52 @@
+package ocp
+
+import (
+	"testing"
+	"time"
+)
+
+// TestManualClock tests deterministic time control
+func TestManualClock(t *testing.T) {
+	start := time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Clock should start at %v", start)
+	}
+	if got := clock.Advance(72 * time.Hour); !got.Equal(start.Add(72 * time.Hour)) {
+		t.Errorf("Advance returned %v", got)
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Set should move the clock back")
+	}
+
+	t.Logf("✓ Manual clock at %s", Timestamp(clock.Now()))
+}
+
+// TestClockInjection tests that subsystems stamp events from their Clock
+func TestClockInjection(t *testing.T) {
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.FixedZone("CET", 3600)))
+
+	lifecycle := NewLifecycle()
+	lifecycle.Clock = clock
+	var events []LifecycleEvent
+	lifecycle.Subscribe(func(e LifecycleEvent) { events = append(events, e) })
+
+	hash, _ := lifecycle.Submit(testProposal())
+	clock.Advance(time.Hour)
+	lifecycle.Ratify(hash)
+
+	expected := []string{"2025-11-20T13:30:00Z", "2025-11-20T14:30:00Z"}
+	for i, e := range events {
+		if e.Timestamp != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, expected[i], e.Timestamp)
+		}
+	}
+
+	if SystemClock.Now().IsZero() {
+		t.Errorf("SystemClock should read the real time")
+	}
+
+	t.Logf("✓ Lifecycle stamped from injected clock: %v", expected)
+}
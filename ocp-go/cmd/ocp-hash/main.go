@@ -0,0 +1,256 @@
+// ocp-hash computes semantic hashes and benchmarks the canonicalizer.
+//
+// Usage:
+//
+//	ocp-hash hash FILE...
+//	ocp-hash bench -input DIR [-iterations N] [-cpuprofile FILE] [-memprofile FILE] [-json]
+//
+// hash prints the semantic hash of each JSON file, exactly as a verifier would
+// compute it from the file's raw text.
+//
+// bench loads every *.json file under DIR (recursively), then strictly
+// decodes, canonicalizes, and hashes the whole set N times. It reports
+// throughput in documents and bytes per second and the allocations per
+// document, so operators can size verifier hardware against their own
+// documents. Files the strict decoder rejects are counted and left out of the
+// timed runs. -cpuprofile and -memprofile write pprof profiles of the timed
+// runs for `go tool pprof`.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches a subcommand and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: ocp-hash <command> [flags]\n\ncommands:\n  hash   print the semantic hash of JSON files\n  bench  benchmark the canonicalizer over a directory of documents")
+		return 2
+	}
+	switch args[0] {
+	case "hash":
+		return hashFiles(args[1:], stdout, stderr)
+	case "bench":
+		return bench(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "ocp-hash: unknown command %q\n", args[0])
+		return 2
+	}
+}
+
+func hashFiles(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: ocp-hash hash FILE...")
+		return 2
+	}
+	code := 0
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+			code = 1
+			continue
+		}
+		hash, err := hashDocument(data)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %s: %v\n", path, err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s  %s\n", hash, path)
+	}
+	return code
+}
+
+// hashDocument is the work measured by bench: strict decode, canonicalize, hash
+func hashDocument(data []byte) (string, error) {
+	form, err := canonical.CanonicalizeJSON(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(form))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func bench(args []string, stdout, stderr io.Writer) int {
+	fset := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	input := fset.String("input", "", "directory of JSON documents")
+	iterations := fset.Int("iterations", 10, "passes over the document set")
+	cpuProfile := fset.String("cpuprofile", "", "write a CPU profile of the timed runs to `file`")
+	memProfile := fset.String("memprofile", "", "write a heap allocation profile after the timed runs to `file`")
+	asJSON := fset.Bool("json", false, "print the result as JSON")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" || fset.NArg() != 0 || *iterations < 1 {
+		fmt.Fprintln(stderr, "usage: ocp-hash bench -input DIR [-iterations N] [-cpuprofile FILE] [-memprofile FILE] [-json]")
+		return 2
+	}
+
+	docs, rejected, err := LoadDocuments(*input)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+		return 1
+	}
+	if len(docs) == 0 {
+		fmt.Fprintf(stderr, "ocp-hash: no accepted *.json documents under %s\n", *input)
+		return 1
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+			return 1
+		}
+	}
+	result := Bench(docs, *iterations)
+	result.Rejected = rejected
+	if *cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+			fmt.Fprintf(stderr, "ocp-hash: %v\n", err)
+			return 1
+		}
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		stdout.Write(append(data, '\n'))
+		return 0
+	}
+	fmt.Fprint(stdout, result.String())
+	return 0
+}
+
+// LoadDocuments reads every *.json file under dir, in path order, keeping
+// those the strict decoder accepts
+//
+// Returns:
+//   - Accepted documents
+//   - Number of rejected files
+func LoadDocuments(dir string) ([][]byte, int, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Strings(paths)
+
+	var docs [][]byte
+	rejected := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, err := hashDocument(data); err != nil {
+			rejected++
+			continue
+		}
+		docs = append(docs, data)
+	}
+	return docs, rejected, nil
+}
+
+// BenchResult reports canonicalizer throughput over a document set
+type BenchResult struct {
+	Documents      int           `json:"documents"`
+	Rejected       int           `json:"rejected"`
+	Bytes          int64         `json:"bytes"`
+	Iterations     int           `json:"iterations"`
+	Elapsed        time.Duration `json:"elapsed_ns"`
+	DocsPerSecond  float64       `json:"docs_per_second"`
+	BytesPerSecond float64       `json:"bytes_per_second"`
+	AllocsPerDoc   float64       `json:"allocs_per_doc"`
+	BytesPerDoc    float64       `json:"alloc_bytes_per_doc"`
+	GOMAXPROCS     int           `json:"gomaxprocs"`
+}
+
+// String renders the result for a terminal
+func (r *BenchResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "documents:   %d (%d rejected), %d bytes\n", r.Documents, r.Rejected, r.Bytes)
+	fmt.Fprintf(&b, "iterations:  %d in %v (GOMAXPROCS=%d)\n", r.Iterations, r.Elapsed.Round(time.Microsecond), r.GOMAXPROCS)
+	fmt.Fprintf(&b, "throughput:  %.0f docs/s, %.2f MB/s\n", r.DocsPerSecond, r.BytesPerSecond/1e6)
+	fmt.Fprintf(&b, "allocations: %.1f allocs/doc, %.0f B/doc\n", r.AllocsPerDoc, r.BytesPerDoc)
+	return b.String()
+}
+
+// Bench decodes, canonicalizes, and hashes every document iterations times
+func Bench(docs [][]byte, iterations int) *BenchResult {
+	var size int64
+	for _, d := range docs {
+		size += int64(len(d))
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, d := range docs {
+			hashDocument(d)
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	n := float64(len(docs) * iterations)
+	seconds := elapsed.Seconds()
+	if seconds == 0 {
+		seconds = 1e-9
+	}
+	return &BenchResult{
+		Documents:      len(docs),
+		Bytes:          size,
+		Iterations:     iterations,
+		Elapsed:        elapsed,
+		DocsPerSecond:  n / seconds,
+		BytesPerSecond: float64(size) * float64(iterations) / seconds,
+		AllocsPerDoc:   float64(after.Mallocs-before.Mallocs) / n,
+		BytesPerDoc:    float64(after.TotalAlloc-before.TotalAlloc) / n,
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// TestHashCommand tests that hash matches SemanticHash of the decoded document
+func TestHashCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proposal.json")
+	os.WriteFile(path, []byte(`{"b": [1, 2], "a": "x"}`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"hash", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	want, _ := hashing.SemanticHash(map[string]interface{}{"b": []interface{}{float64(1), float64(2)}, "a": "x"})
+	if !strings.HasPrefix(stdout.String(), want+"  ") {
+		t.Errorf("Expected hash %s, got %q", want, stdout.String())
+	}
+	t.Logf("✓ Hashed: %s", strings.TrimSpace(stdout.String()))
+}
+
+// TestBenchCommand tests benchmarking a document directory with profiles
+func TestBenchCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nested"), 0o755)
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"z": 1, "a": [true, false]}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "nested", "b.json"), []byte(`{"text": "héllo"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"a": 1, "a": 2}`), 0o644)
+	profile := filepath.Join(t.TempDir(), "cpu.pprof")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"bench", "-input", dir, "-iterations", "3", "-cpuprofile", profile, "-json"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	var result BenchResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Output is not JSON: %v", err)
+	}
+	if result.Documents != 2 || result.Rejected != 1 || result.Iterations != 3 || result.DocsPerSecond <= 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if info, err := os.Stat(profile); err != nil || info.Size() == 0 {
+		t.Errorf("Expected a CPU profile to be written")
+	}
+
+	if code := run([]string{"bench"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Missing -input should exit 2, got %d", code)
+	}
+	if code := run([]string{"bench", "-input", t.TempDir()}, &stdout, &stderr); code != 1 {
+		t.Errorf("Empty directory should exit 1, got %d", code)
+	}
+	t.Logf("✓ Bench: %.0f docs/s, %.1f allocs/doc", result.DocsPerSecond, result.AllocsPerDoc)
+}
@@ -0,0 +1,283 @@
+// ocp-node runs and maintains an OCP node.
+//
+// Usage:
+//
+//	ocp-node approve -request FILE -overseer NAME -key FILE [-reject] [-reason TEXT]
+//	ocp-node capabilities
+//	ocp-node selftest [-archive DIR]
+//	ocp-node serve -config FILE
+//	ocp-node tui [-server URL] [-timeout D]
+//	ocp-node version
+//	ocp-node watch -file FILE -ledger LEDGER [-archive DIR] [-interval D] [-once]
+//
+// approve is for human overseers. It reads an approval request, as
+// delivered by webhook or listed by the node's approval endpoint, and checks
+// that the embedded proposal matches the hash being approved. It then prints
+// an approval.Approval signed with the overseer's key, ready to POST back to
+// the node. The key file holds the hex-encoded 32-byte Ed25519 seed.
+//
+// capabilities prints the platform and ocp.Capabilities as canonical JSON,
+// so an operator can confirm which optional and accelerated features a binary
+// on an edge device was built with.
+//
+// selftest runs ocp.SelfTest against the node's archive and exits non-zero if
+// any check fails. Deployments run it before starting the node so that a
+// miscompiled or misconfigured binary never writes to the shared ledger.
+//
+// serve runs a node of a reference network from a configuration file: the
+// intake pipeline, ledger, archive, identity, amendment voting, and gossip
+// wired together, with one node leading and the rest following its ledger.
+// It runs selftest first and refuses to start if any check fails. See
+// serve.go, and examples/ocp-quorum for a three-node network under
+// docker-compose.
+//
+// tui browses the ledger of the node serving the query API at URL: a list of
+// accepted proposals, and for the selected one its canonical ledger entry, the
+// hash chain status, and its challenge bonds. See tui.go for the keys.
+//
+// version prints ocp.BuildInfo as canonical JSON, for comparing the
+// capabilities of nodes in a mixed-version fleet.
+//
+// watch re-reads FILE, the operator's copy of the constitution, and LEDGER, a
+// JSON array of ledger entries from genesis, every interval and reports
+// whenever the file's hash stops (or starts again) matching the ratified
+// constitution hash. If the ratified document is in the archive directory the
+// report lists the differences. With -once it checks a single time and exits
+// with status 1 on drift.
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/approval"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches a subcommand and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: ocp-node <command> [flags]\n\ncommands:\n  approve   sign a human overseer's decision on an approval request\n  capabilities  print the optional and accelerated features of this build\n  selftest  verify this binary and its storage before serving\n  serve     run a node of a reference network\n  tui       browse a node's ledger in the terminal\n  version   print the protocol capabilities of this build\n  watch     report drift between a constitution file and the ledger")
+		return 2
+	}
+	switch args[0] {
+	case "approve":
+		return approve(args[1:], stdout, stderr)
+	case "capabilities":
+		caps := make([]interface{}, 0)
+		for _, c := range ocp.Capabilities() {
+			caps = append(caps, c.ToMap())
+		}
+		form, err := ocp.CanonicalizeValue(map[string]interface{}{"platform": ocp.Platform(), "capabilities": caps})
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, form)
+		return 0
+	case "selftest":
+		return selftest(args[1:], stdout, stderr)
+	case "serve":
+		return serve(args[1:], stdout, stderr)
+	case "tui":
+		return tui(args[1:], os.Stdin, stdout, stderr)
+	case "watch":
+		return watch(args[1:], stdout, stderr)
+	case "version":
+		form, err := ocp.CanonicalizeValue(ocp.BuildInfo().ToMap())
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, form)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "ocp-node: unknown command %q\n", args[0])
+		return 2
+	}
+}
+
+func approve(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("approve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	requestPath := fs.String("request", "", "approval request JSON")
+	overseer := fs.String("overseer", "", "registered overseer name")
+	keyPath := fs.String("key", "", "file holding the overseer's hex-encoded Ed25519 seed")
+	reject := fs.Bool("reject", false, "reject the proposal instead of approving it")
+	reason := fs.String("reason", "", "reason recorded with the decision")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *requestPath == "" || *overseer == "" || *keyPath == "" {
+		fmt.Fprintln(stderr, "usage: ocp-node approve -request FILE -overseer NAME -key FILE [-reject] [-reason TEXT]")
+		return 2
+	}
+
+	data, err := os.ReadFile(*requestPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	req, err := approval.DecodeRequest(data)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	key, err := readSeed(*keyPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	decision := approval.Approve
+	if *reject {
+		decision = approval.Reject
+	}
+	a, err := approval.NewApproval(req, *overseer, decision, *reason, ocp.Timestamp(time.Now()))
+	if err == nil {
+		err = a.Sign(key)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	out, err := json.Marshal(a)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(out))
+	return 0
+}
+
+// readSeed reads a hex-encoded Ed25519 seed
+func readSeed(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s does not hold a hex-encoded %d-byte Ed25519 seed", path, ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func selftest(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("archive", "", "archive directory to probe (storage check is skipped if empty)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var store ocp.Archive
+	if *dir != "" {
+		archive, err := ocp.NewFileArchive(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			return 1
+		}
+		store = archive
+	}
+
+	report := ocp.SelfTest(store)
+	for _, c := range report.Checks {
+		fmt.Fprintf(stdout, "%-22s %-4s %s\n", c.Check, c.Status, c.Message)
+	}
+	if err := report.Err(); err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func watch(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("file", "", "local constitution document")
+	ledgerPath := fs.String("ledger", "", "JSON array of ledger entries from genesis")
+	dir := fs.String("archive", "", "archive directory holding ratified documents (diffs are skipped if empty)")
+	interval := fs.Duration("interval", 2*time.Second, "time between checks")
+	once := fs.Bool("once", false, "check once and exit non-zero on drift")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" || *ledgerPath == "" {
+		fmt.Fprintln(stderr, "usage: ocp-node watch -file FILE -ledger LEDGER [-archive DIR] [-interval D] [-once]")
+		return 2
+	}
+
+	var store ocp.Archive
+	if *dir != "" {
+		archive, err := ocp.NewFileArchive(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			return 1
+		}
+		store = archive
+	}
+
+	var last *ocp.ConstitutionDrift
+	for {
+		drift, err := checkConstitution(*file, *ledgerPath, store)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			if *once {
+				return 1
+			}
+		case last == nil || drift.LocalHash != last.LocalHash || drift.RatifiedHash != last.RatifiedHash:
+			reportDrift(stdout, drift)
+			last = drift
+		}
+		if *once {
+			if drift != nil && drift.Drifted() {
+				return 1
+			}
+			return 0
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// checkConstitution reads the constitution file and ledger and compares them
+func checkConstitution(file, ledgerPath string, store ocp.Archive) (*ocp.ConstitutionDrift, error) {
+	local, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ocp.LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", ledgerPath, err)
+	}
+	if _, err := ocp.VerifyChain(0, "", entries); err != nil {
+		return nil, err
+	}
+	return ocp.CheckConstitution(local, entries, store)
+}
+
+func reportDrift(w io.Writer, drift *ocp.ConstitutionDrift) {
+	if !drift.Drifted() {
+		fmt.Fprintf(w, "in sync: constitution %s ratified at height %d\n", drift.RatifiedHash, drift.RatifiedHeight)
+		return
+	}
+	fmt.Fprintf(w, "DRIFT: local constitution %s is not the ratified %s (height %d)\n", drift.LocalHash, drift.RatifiedHash, drift.RatifiedHeight)
+	for _, d := range drift.Differences {
+		fmt.Fprintf(w, "  %s\n", d)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/approval"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSelftestCommand tests the selftest subcommand against a temporary archive
+func TestSelftestCommand(t *testing.T) {
+	testenv.RequireSigning(t)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"selftest", "-archive", t.TempDir()}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "fail") || strings.Contains(stdout.String(), "skip") {
+		t.Errorf("Every check should pass:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Unknown command should exit 2, got %d", code)
+	}
+
+	t.Logf("✓ selftest output:\n%s", stdout.String())
+}
+
+// TestVersionCommand tests printing the build's capabilities
+func TestVersionCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"version"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"spec_revision":"OCP-0001/0.1"`) {
+		t.Errorf("Unexpected version output: %s", stdout.String())
+	}
+
+	t.Logf("✓ version output: %s", stdout.String())
+}
+
+// TestCapabilitiesCommand tests printing the optional features of the build
+func TestCapabilitiesCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"capabilities"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"name":"signing"`) || !strings.Contains(stdout.String(), `"platform":"`+ocp.Platform()+`"`) {
+		t.Errorf("Unexpected capabilities output: %s", stdout.String())
+	}
+
+	t.Logf("✓ capabilities output: %s", stdout.String())
+}
+
+// TestWatchCommand tests drift reports between a constitution file and the ledger
+func TestWatchCommand(t *testing.T) {
+	testenv.RequireSigning(t)
+	dir := t.TempDir()
+	ratified := []byte("# Constitution\nArticle I\nArticle II\n")
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	g, err := ocp.NewGenesis(ratified, []ocp.Founder{{Agent: "Claude", PublicKey: key.Public().(ed25519.PublicKey)}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create genesis: %v", err)
+	}
+	ledger, err := ocp.NewLedgerFromGenesis(g)
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	data, _ := json.Marshal(ledger.Entries(0))
+	ledgerPath := filepath.Join(dir, "ledger.json")
+	os.WriteFile(ledgerPath, data, 0o644)
+	archive, _ := ocp.NewFileArchive(filepath.Join(dir, "archive"))
+	archive.Put(ratified)
+
+	file := filepath.Join(dir, "constitution.md")
+	os.WriteFile(file, ratified, 0o644)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"watch", "-file", file, "-ledger", ledgerPath, "-once"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	os.WriteFile(file, []byte("# Constitution\nArticle I\nArticle II (local edit)\n"), 0o644)
+	stdout.Reset()
+	code := run([]string{"watch", "-file", file, "-ledger", ledgerPath, "-archive", filepath.Join(dir, "archive"), "-once"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("Drift should exit 1, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "DRIFT") || !strings.Contains(stdout.String(), "+ 3: Article II (local edit)") {
+		t.Errorf("Unexpected drift report:\n%s", stdout.String())
+	}
+
+	if code := run([]string{"watch", "-file", file}, &stdout, &stderr); code != 2 {
+		t.Errorf("Missing -ledger should exit 2, got %d", code)
+	}
+	t.Logf("✓ watch output:\n%s", stdout.String())
+}
+
+// TestApproveCommand tests signing an overseer's decision on a request
+func TestApproveCommand(t *testing.T) {
+	testenv.RequireSigning(t)
+	dir := t.TempDir()
+	seed := bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	keyPath := filepath.Join(dir, "alice.key")
+	os.WriteFile(keyPath, []byte(hex.EncodeToString(seed)+"\n"), 0o600)
+
+	p := &ocp.ContractProposal{
+		ID:                 "p",
+		ProposerAgent:      "Claude",
+		ActionType:         "amend",
+		ReversibilityClass: ocp.ReversibilityIrreversible,
+		PreStateHash:       "sha256:before",
+		Timestamp:          "2025-11-20T14:30:00Z",
+	}
+	req, err := approval.NewRequest(p, 1, "2025-11-20T14:45:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(req.ToMap())
+	requestPath := filepath.Join(dir, "request.json")
+	os.WriteFile(requestPath, data, 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"approve", "-request", requestPath, "-overseer", "alice", "-key", keyPath, "-reject", "-reason", "too broad"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	var a approval.Approval
+	if err := json.Unmarshal(stdout.Bytes(), &a); err != nil {
+		t.Fatalf("Expected an approval on stdout: %v", err)
+	}
+	registry := approval.NewRegistry(map[string]ed25519.PublicKey{"alice": ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)})
+	if err := registry.Verify(&a); err != nil || a.Decision != approval.Reject || a.ProposalHash != req.ProposalHash {
+		t.Errorf("Expected a verifiable rejection of the request, got %+v: %v", a, err)
+	}
+
+	// A request whose proposal does not match its hash is refused
+	req.ProposalHash = strings.Repeat("0", 64)
+	data, _ = json.Marshal(req.ToMap())
+	os.WriteFile(requestPath, data, 0o644)
+	if code := run([]string{"approve", "-request", requestPath, "-overseer", "alice", "-key", keyPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected a tampered request to exit 1, got %d", code)
+	}
+	t.Logf("✓ approve signed a %s decision", a.Decision)
+}
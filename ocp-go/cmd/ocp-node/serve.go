@@ -0,0 +1,710 @@
+// serve.go - Reference node wiring the library into a running system
+//
+// serve runs one member of a small OCP network from a JSON configuration
+// file. One node is the leader: it holds the authoritative ledger, accepts
+// proposals into it through ocp.Node, and ratifies amendments. Every other
+// node follows the leader's ledger over GET /v1/ledger/watch, checking the
+// hash chain, the genesis hash, and the quorum signatures of each entry
+// before applying it, so no node ever serves history it could not verify.
+//
+// Nodes find each other over gossip. A proposal or amendment submitted to any
+// node is gossiped to the rest; the leader submits proposals to its ledger.
+// Each quorum member votes on an amendment by gossiping its signature over
+// the amendment's constitution hash, automatically with auto_vote or when an
+// operator calls POST /v1/amendments/{hash}/vote. Once the votes reach the
+// quorum threshold the leader records the new constitution with
+// ocp.RatifyConstitution, and the followers pick the entry up from the watch
+// stream.
+//
+// Each node keeps its archive and a copy of its ledger in data_dir. The ledger
+// file is the JSON array of entries from genesis that `ocp-node watch -ledger`
+// reads, and is reloaded and re-verified on restart.
+//
+// Routes, besides those of server.NewHandler:
+//
+//	POST /v1/amendments              gossip a new constitution document (the request body)
+//	GET  /v1/amendments              amendments seen and the votes collected for each
+//	POST /v1/amendments/{hash}/vote  sign and gossip this node's vote
+//	GET  /v1/constitution            the ratified constitution hash and its height
+//
+// On a follower POST /v1/proposals gossips the proposal to the leader and
+// answers 202 Accepted with its hash instead of an Acceptance.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/gossip"
+	"github.com/seanrugg/ai_constitution/ocp-go/server"
+)
+
+// ledgerFileName is the name of the ledger copy in a node's data directory
+const ledgerFileName = "ledger.json"
+
+// retryInterval spaces reconnections to peers and to the leader
+const retryInterval = 2 * time.Second
+
+// serveConfig is the configuration file of ocp-node serve. Relative paths are
+// resolved against the directory holding the file.
+type serveConfig struct {
+	// Name is the node's agent name; a quorum member if it is in Members
+	Name string `json:"name"`
+	// KeyFile holds the node's hex-encoded Ed25519 seed
+	KeyFile string `json:"key_file"`
+	// Listen is the HTTP API address, e.g. ":8080"
+	Listen string `json:"listen"`
+	// GossipListen is the gossip address, e.g. ":7946"
+	GossipListen string `json:"gossip_listen"`
+	// Peers are gossip addresses to connect to
+	Peers []string `json:"peers"`
+	// Leader is the leader's API URL, or empty on the leader itself
+	Leader string `json:"leader"`
+	// DataDir holds the archive and the ledger file
+	DataDir string `json:"data_dir"`
+	// Constitution is the genesis constitution document
+	Constitution string `json:"constitution"`
+	// Members maps each quorum member to its hex-encoded Ed25519 public key;
+	// they are also the genesis founders
+	Members map[string]string `json:"members"`
+	// Threshold is the number of votes that ratifies an amendment
+	Threshold int `json:"threshold"`
+	// Policies is the genesis policy table
+	Policies map[string]interface{} `json:"policies"`
+	// AutoVote approves every well-formed amendment as soon as it is seen
+	AutoVote bool `json:"auto_vote"`
+}
+
+// loadServeConfig reads and checks a configuration file
+func loadServeConfig(path string) (*serveConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg serveConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch {
+	case cfg.Name == "":
+		return nil, fmt.Errorf("%s: name is required", path)
+	case cfg.Listen == "" || cfg.GossipListen == "":
+		return nil, fmt.Errorf("%s: listen and gossip_listen are required", path)
+	case cfg.DataDir == "" || cfg.Constitution == "" || cfg.KeyFile == "":
+		return nil, fmt.Errorf("%s: data_dir, constitution, and key_file are required", path)
+	}
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&cfg.KeyFile, &cfg.DataDir, &cfg.Constitution} {
+		if !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return &cfg, nil
+}
+
+// amendment is a proposed constitution and the votes seen for it
+type amendment struct {
+	document []byte
+	votes    map[string]ocp.Signature
+	ratified bool
+}
+
+// referenceNode is one running member of the network
+type referenceNode struct {
+	cfg         *serveConfig
+	key         ed25519.PrivateKey
+	quorum      *ocp.Quorum
+	genesisHash string
+	archive     *ocp.FileArchive
+	ledger      *ocp.Ledger
+	// node accepts proposals; nil on followers
+	node   *ocp.Node
+	logger *log.Logger
+
+	gossip *gossip.Node
+	// ready is closed once gossip is set
+	ready chan struct{}
+
+	mu         sync.Mutex
+	amendments map[string]*amendment
+	// diverged is set when the leader sent history that failed verification
+	diverged error
+}
+
+// newReferenceNode loads a node's identity, genesis, archive, and ledger
+func newReferenceNode(cfg *serveConfig, logger *log.Logger) (*referenceNode, error) {
+	key, err := readSeed(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]ed25519.PublicKey, len(cfg.Members))
+	founders := make([]ocp.Founder, 0, len(cfg.Members))
+	for name, encoded := range cfg.Members {
+		pub, err := hex.DecodeString(encoded)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("member %s: invalid hex-encoded Ed25519 public key", name)
+		}
+		members[name] = pub
+		founders = append(founders, ocp.Founder{Agent: name, PublicKey: pub})
+	}
+	if pub, ok := members[cfg.Name]; ok && !pub.Equal(key.Public()) {
+		return nil, fmt.Errorf("%s does not hold the key registered for member %s", cfg.KeyFile, cfg.Name)
+	}
+	quorum, err := ocp.NewQuorum(members, cfg.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	constitution, err := os.ReadFile(cfg.Constitution)
+	if err != nil {
+		return nil, err
+	}
+	genesis, err := ocp.NewGenesis(constitution, founders, cfg.Policies)
+	if err != nil {
+		return nil, err
+	}
+	genesisHash, err := genesis.Hash()
+	if err != nil {
+		return nil, err
+	}
+	archive, err := ocp.NewFileArchive(filepath.Join(cfg.DataDir, "archive"))
+	if err != nil {
+		return nil, err
+	}
+	// Drift reports diff against the archived genesis text
+	if _, err := archive.Put(constitution); err != nil {
+		return nil, err
+	}
+
+	ledger, err := loadLedger(filepath.Join(cfg.DataDir, ledgerFileName), quorum)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.Height() == 0 && cfg.Leader == "" {
+		if ledger, err = ocp.NewLedgerFromGenesis(genesis); err != nil {
+			return nil, err
+		}
+	}
+	if ledger.Height() > 0 {
+		if err := ocp.ValidateGenesis(ledger, genesisHash); err != nil {
+			return nil, fmt.Errorf("%s: %w", ledgerFileName, err)
+		}
+	}
+
+	r := &referenceNode{
+		cfg:         cfg,
+		key:         key,
+		quorum:      quorum,
+		genesisHash: genesisHash,
+		archive:     archive,
+		ledger:      ledger,
+		logger:      logger,
+		ready:       make(chan struct{}),
+		amendments:  make(map[string]*amendment),
+	}
+	if cfg.Leader == "" {
+		r.node = ocp.NewNode(ledger)
+	}
+	return r, nil
+}
+
+// loadLedger reads a ledger file written by persist, verifying its chain and
+// signed entries; a missing file is an empty ledger
+func loadLedger(path string, quorum *ocp.Quorum) (*ocp.Ledger, error) {
+	ledger := ocp.NewLedger()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []ocp.LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := ocp.VerifyEntrySignatures(nil, entries, quorum); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := ledger.Extend(entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ledger, nil
+}
+
+// start serves gossip, connects to peers, and starts following the leader
+// and persisting the ledger, all until ctx is done
+func (r *referenceNode) start(ctx context.Context) error {
+	g, err := gossip.New(gossip.Config{
+		ListenAddr:   r.cfg.GossipListen,
+		SyncInterval: retryInterval,
+		Handler:      r.onMessage,
+		Info:         ocp.NewNodeInfo(r.cfg.Name).ToMap(),
+		AcceptPeer:   ocp.CheckPeer,
+	})
+	if err != nil {
+		return err
+	}
+	r.gossip = g
+	close(r.ready)
+	go func() {
+		<-ctx.Done()
+		g.Close()
+	}()
+
+	for _, addr := range r.cfg.Peers {
+		go r.connect(ctx, addr)
+	}
+	if r.cfg.Leader != "" {
+		go r.follow(ctx)
+	}
+	go r.persist(ctx)
+	return nil
+}
+
+// connect keeps a link to a gossip peer open, redialing it whenever the
+// peer is down or restarts
+func (r *referenceNode) connect(ctx context.Context, addr string) {
+	for {
+		if !r.gossip.Connected(addr) {
+			if err := r.gossip.Connect(addr); err == nil {
+				r.logger.Printf("gossip peer %s connected", addr)
+			}
+		}
+		if !sleepCtx(ctx, retryInterval) {
+			return
+		}
+	}
+}
+
+// follow applies the leader's ledger, reconnecting after transport errors.
+// It stops for good if the leader sends an entry that fails verification.
+func (r *referenceNode) follow(ctx context.Context) {
+	for {
+		height, head := r.ledger.Height(), r.ledger.Head()
+		err := server.WatchLedger(ctx, nil, r.cfg.Leader, height, head, r.apply)
+		if ctx.Err() != nil {
+			return
+		}
+		var cerr *ocp.ConstitutionalError
+		if errors.As(err, &cerr) && cerr.ErrorType == "VerificationError" {
+			r.mu.Lock()
+			r.diverged = err
+			r.mu.Unlock()
+			r.logger.Printf("stopped following %s: %v", r.cfg.Leader, err)
+			return
+		}
+		if !sleepCtx(ctx, retryInterval) {
+			return
+		}
+	}
+}
+
+// apply checks an entry the watch stream verified to chain onto the ledger
+// and appends it
+func (r *referenceNode) apply(e ocp.LedgerEntry) error {
+	if err := ocp.VerifyEntrySignatures(r.ledger.Entries(0), []ocp.LedgerEntry{e}, r.quorum); err != nil {
+		return err
+	}
+	if err := r.ledger.Extend([]ocp.LedgerEntry{e}); err != nil {
+		return err
+	}
+	if e.Height == 1 {
+		if err := ocp.ValidateGenesis(r.ledger, r.genesisHash); err != nil {
+			return err
+		}
+	}
+	if e.Kind == ocp.LedgerKindConstitution {
+		r.logger.Printf("constitution %v ratified at height %d", e.Payload["constitution_hash"], e.Height)
+	}
+	return nil
+}
+
+// persist writes the ledger file whenever the ledger grows
+func (r *referenceNode) persist(ctx context.Context) {
+	path := filepath.Join(r.cfg.DataDir, ledgerFileName)
+	var saved uint64
+	for {
+		if height := r.ledger.Height(); height > saved {
+			if err := writeLedger(path, r.ledger.Entries(0)); err != nil {
+				r.logger.Printf("writing %s: %v", path, err)
+			} else {
+				saved = height
+			}
+		}
+		if err := r.ledger.Wait(ctx, saved); err != nil {
+			return
+		}
+	}
+}
+
+// writeLedger replaces the ledger file atomically
+func writeLedger(path string, entries []ocp.LedgerEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// broadcast gossips a message once gossip has started
+func (r *referenceNode) broadcast(kind string, payload map[string]interface{}) error {
+	<-r.ready
+	_, err := r.gossip.Broadcast(kind, payload)
+	return err
+}
+
+// onMessage handles every new gossip message, local or remote
+func (r *referenceNode) onMessage(msg gossip.Message) {
+	switch msg.Kind {
+	case gossip.KindProposal:
+		if doc, ok := msg.Payload["amendment"].(string); ok {
+			r.onAmendment([]byte(doc))
+		}
+		if p, ok := msg.Payload["proposal"].(map[string]interface{}); ok && r.node != nil {
+			r.onProposal(p)
+		}
+	case gossip.KindVote:
+		hash, _ := msg.Payload["constitution_hash"].(string)
+		sig, err := decodeSignature(msg.Payload["signature"])
+		if hash == "" || err != nil {
+			return
+		}
+		r.mu.Lock()
+		a := r.amendmentLocked(hash)
+		a.votes[sig.Signer] = sig
+		r.mu.Unlock()
+		r.ratify(hash)
+	}
+}
+
+// amendmentLocked returns the amendment with hash, creating it; the caller
+// holds r.mu
+func (r *referenceNode) amendmentLocked(hash string) *amendment {
+	a, ok := r.amendments[hash]
+	if !ok {
+		a = &amendment{votes: make(map[string]ocp.Signature)}
+		r.amendments[hash] = a
+	}
+	return a
+}
+
+func (r *referenceNode) onAmendment(doc []byte) {
+	hash := ocp.ConstitutionHash(doc)
+	r.mu.Lock()
+	a := r.amendmentLocked(hash)
+	a.document = doc
+	r.mu.Unlock()
+	r.logger.Printf("amendment %s proposed", hash)
+	if r.cfg.AutoVote {
+		if err := r.vote(hash); err != nil {
+			r.logger.Printf("voting on %s: %v", hash, err)
+		}
+	}
+	r.ratify(hash)
+}
+
+func (r *referenceNode) onProposal(m map[string]interface{}) {
+	p, err := decodeProposal(m)
+	if err == nil {
+		_, err = r.node.Submit(p)
+	}
+	if err != nil {
+		r.logger.Printf("gossiped proposal %s refused: %v", p.ID, err)
+	}
+}
+
+// vote signs the amendment with hash and gossips the signature
+func (r *referenceNode) vote(hash string) error {
+	if _, ok := r.quorum.Members[r.cfg.Name]; !ok {
+		return ocp.NewConstitutionalError(r.cfg.Name + " is not a quorum member")
+	}
+	sig, err := ocp.SignHash(r.cfg.Name, r.key, ocp.ContextConstitution, hash)
+	if err != nil {
+		return err
+	}
+	return r.broadcast(gossip.KindVote, map[string]interface{}{
+		"constitution_hash": hash,
+		"signature":         sig.ToMap(),
+	})
+}
+
+// ratify records the amendment with hash on the leader's ledger once its
+// document is known and its votes reach the threshold
+func (r *referenceNode) ratify(hash string) {
+	if r.node == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := r.amendments[hash]
+	if a == nil || a.document == nil || a.ratified {
+		return
+	}
+	sigs := make([]ocp.Signature, 0, len(a.votes))
+	for _, sig := range a.votes {
+		sigs = append(sigs, sig)
+	}
+	if _, err := r.quorum.Verify(ocp.ContextConstitution, hash, sigs); err != nil {
+		return
+	}
+	if current, _, _ := ocp.RatifiedConstitution(r.ledger.Entries(0)); current == hash {
+		a.ratified = true
+		return
+	}
+	if _, err := ocp.RatifyConstitution(r.ledger, r.archive, r.quorum, a.document, sigs); err != nil {
+		r.logger.Printf("ratifying %s: %v", hash, err)
+		return
+	}
+	a.ratified = true
+	r.logger.Printf("constitution %s ratified at height %d", hash, r.ledger.Height())
+}
+
+// decodeSignature reads a signature from a gossip payload
+func decodeSignature(v interface{}) (ocp.Signature, error) {
+	var sig ocp.Signature
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, &sig)
+	}
+	if err == nil && sig.Signer == "" {
+		err = ocp.NewConstitutionalError("signature has no signer")
+	}
+	return sig, err
+}
+
+// decodeProposal reads a contract proposal from a gossip payload
+func decodeProposal(m map[string]interface{}) (*ocp.ContractProposal, error) {
+	var p ocp.ContractProposal
+	data, err := json.Marshal(m)
+	if err == nil {
+		err = json.Unmarshal(data, &p)
+	}
+	return &p, err
+}
+
+// handler returns the node's HTTP API
+func (r *referenceNode) handler() http.Handler {
+	mux := http.NewServeMux()
+	if r.node != nil {
+		mux.Handle("/", server.NewHandler(r.node))
+	} else {
+		// Followers serve reads from their verified copy and pass
+		// submissions on to the leader
+		mux.Handle("/", server.NewHandler(ocp.NewNode(r.ledger)))
+		mux.HandleFunc("POST /v1/proposals", r.forwardProposal)
+	}
+	mux.HandleFunc("POST /v1/amendments", r.proposeAmendment)
+	mux.HandleFunc("GET /v1/amendments", r.listAmendments)
+	mux.HandleFunc("POST /v1/amendments/{hash}/vote", func(w http.ResponseWriter, req *http.Request) {
+		hash := req.PathValue("hash")
+		r.mu.Lock()
+		_, known := r.amendments[hash]
+		r.mu.Unlock()
+		if !known {
+			writeJSONError(w, http.StatusNotFound, ocp.NewConstitutionalError("unknown amendment"))
+			return
+		}
+		if err := r.vote(hash); err != nil {
+			writeJSONError(w, http.StatusForbidden, err)
+			return
+		}
+		writeJSONResponse(w, http.StatusAccepted, map[string]interface{}{"constitution_hash": hash, "voter": r.cfg.Name})
+	})
+	mux.HandleFunc("GET /v1/constitution", func(w http.ResponseWriter, req *http.Request) {
+		hash, height, err := ocp.RatifiedConstitution(r.ledger.Entries(0))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		body := map[string]interface{}{"constitution_hash": hash, "height": height}
+		r.mu.Lock()
+		if r.diverged != nil {
+			body["diverged"] = r.diverged.Error()
+		}
+		r.mu.Unlock()
+		writeJSONResponse(w, http.StatusOK, body)
+	})
+	return mux
+}
+
+func (r *referenceNode) forwardProposal(w http.ResponseWriter, req *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(req.Body, server.MaxRequestBytes))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	m, err := ocp.DecodeStrict(data)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, err := decodeProposal(m)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := r.broadcast(gossip.KindProposal, map[string]interface{}{"proposal": m}); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusAccepted, map[string]interface{}{"proposal_hash": hash, "leader": r.cfg.Leader})
+}
+
+func (r *referenceNode) proposeAmendment(w http.ResponseWriter, req *http.Request) {
+	doc, err := io.ReadAll(io.LimitReader(req.Body, server.MaxRequestBytes))
+	if err != nil || len(doc) == 0 {
+		writeJSONError(w, http.StatusBadRequest, ocp.NewConstitutionalError("the request body must hold the amended constitution"))
+		return
+	}
+	if err := r.broadcast(gossip.KindProposal, map[string]interface{}{"amendment": string(doc)}); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusAccepted, map[string]interface{}{"constitution_hash": ocp.ConstitutionHash(doc)})
+}
+
+func (r *referenceNode) listAmendments(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	hashes := make([]string, 0, len(r.amendments))
+	for hash := range r.amendments {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	list := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		a := r.amendments[hash]
+		voters := make([]string, 0, len(a.votes))
+		for name := range a.votes {
+			voters = append(voters, name)
+		}
+		sort.Strings(voters)
+		list[i] = map[string]interface{}{
+			"constitution_hash": hash,
+			"document_seen":     a.document != nil,
+			"voters":            voters,
+			"ratified":          a.ratified,
+		}
+	}
+	r.mu.Unlock()
+	writeJSONResponse(w, http.StatusOK, map[string]interface{}{"amendments": list, "threshold": r.quorum.Threshold})
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, v map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSONResponse(w, status, map[string]interface{}{"error": err.Error()})
+}
+
+// sleepCtx waits for d, reporting false if ctx ended first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func serve(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "node configuration file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" {
+		fmt.Fprintln(stderr, "usage: ocp-node serve -config FILE")
+		return 2
+	}
+	cfg, err := loadServeConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	logger := log.New(stderr, "ocp-node "+cfg.Name+": ", log.LstdFlags)
+	r, err := newReferenceNode(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+
+	// A miscompiled binary or unwritable archive must never join the network
+	report := ocp.SelfTest(r.archive)
+	if err := report.Err(); err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	if err := r.start(ctx); err != nil {
+		listener.Close()
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	role := "leader"
+	if cfg.Leader != "" {
+		role = "follower of " + cfg.Leader
+	}
+	fmt.Fprintf(stdout, "%s serving on %s, gossip on %s, %s at height %d\n", cfg.Name, listener.Addr(), r.gossip.Addr(), role, r.ledger.Height())
+
+	// Requests share ctx, so open watch streams end on shutdown
+	srv := &http.Server{
+		Handler:           r.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+	return 0
+}
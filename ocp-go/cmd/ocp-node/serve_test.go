@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testNetwork writes the configuration of three nodes sharing a genesis,
+// two of whose votes ratify an amendment
+func testNetwork(t *testing.T) []*serveConfig {
+	t.Helper()
+	dir := t.TempDir()
+	constitution := filepath.Join(dir, "constitution.md")
+	os.WriteFile(constitution, []byte("# Constitution\nArticle I\n"), 0o644)
+	names := []string{"node1", "node2", "node3"}
+	members := make(map[string]string)
+	for i, name := range names {
+		seed := bytes.Repeat([]byte{byte(i + 1)}, ed25519.SeedSize)
+		os.WriteFile(filepath.Join(dir, name+".key"), []byte(hex.EncodeToString(seed)), 0o600)
+		members[name] = hex.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+	}
+	cfgs := make([]*serveConfig, len(names))
+	for i, name := range names {
+		cfgs[i] = &serveConfig{
+			Name:         name,
+			KeyFile:      filepath.Join(dir, name+".key"),
+			Listen:       "127.0.0.1:0",
+			GossipListen: "127.0.0.1:0",
+			DataDir:      filepath.Join(dir, name),
+			Constitution: constitution,
+			Members:      members,
+			Threshold:    2,
+			AutoVote:     true,
+		}
+	}
+	return cfgs
+}
+
+// startTestNode starts a node and serves its API until the test ends
+func startTestNode(t *testing.T, ctx context.Context, cfg *serveConfig) (*referenceNode, string) {
+	t.Helper()
+	r, err := newReferenceNode(cfg, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Failed to load %s: %v", cfg.Name, err)
+	}
+	if err := r.start(ctx); err != nil {
+		t.Fatalf("Failed to start %s: %v", cfg.Name, err)
+	}
+	srv := httptest.NewServer(r.handler())
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func getJSON(t *testing.T, url string) map[string]interface{} {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return body
+}
+
+// TestServeRatifiesAmendment tests a leader and two followers ratifying an
+// amendment submitted to a follower and accepting a gossiped proposal
+func TestServeRatifiesAmendment(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfgs := testNetwork(t)
+
+	leader, leaderURL := startTestNode(t, ctx, cfgs[0])
+	nodes := []*referenceNode{leader}
+	urls := []string{leaderURL}
+	for _, cfg := range cfgs[1:] {
+		cfg.Leader = leaderURL
+		cfg.Peers = []string{leader.gossip.Addr()}
+		r, url := startTestNode(t, ctx, cfg)
+		nodes, urls = append(nodes, r), append(urls, url)
+	}
+	for i, r := range nodes {
+		waitFor(t, cfgs[i].Name+" to hold genesis", func() bool { return r.ledger.Height() == 1 })
+	}
+
+	amended := "# Constitution\nArticle I\nArticle II\n"
+	resp, err := http.Post(urls[2]+"/v1/amendments", "text/markdown", strings.NewReader(amended))
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected the amendment to be accepted for voting: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	want := ocp.ConstitutionHash([]byte(amended))
+	for i, url := range urls {
+		waitFor(t, cfgs[i].Name+" to see the ratification", func() bool {
+			return getJSON(t, url+"/v1/constitution")["constitution_hash"] == want
+		})
+	}
+	amendments := getJSON(t, leaderURL+"/v1/amendments")["amendments"].([]interface{})
+	if a := amendments[0].(map[string]interface{}); a["ratified"] != true || len(a["voters"].([]interface{})) < 2 {
+		t.Errorf("Expected a ratified amendment with at least two votes, got %v", a)
+	}
+
+	p := &ocp.ContractProposal{
+		ID:              "p-1",
+		ProposerAgent:   "node2",
+		ActionType:      "amend",
+		Action:          map[string]interface{}{"target": "article-2"},
+		Timestamp:       "2025-11-20T14:30:00Z",
+		ReputationStake: 10,
+	}
+	data, _ := json.Marshal(p)
+	resp, err = http.Post(urls[1]+"/v1/proposals", "application/json", bytes.NewReader(data))
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected a follower to forward the proposal: %v %v", resp, err)
+	}
+	resp.Body.Close()
+	hash, _ := p.GetHash()
+	waitFor(t, "the leader to accept the proposal", func() bool {
+		_, ok := leader.node.Accepted(hash)
+		return ok
+	})
+	height := leader.ledger.Height()
+	for i, r := range nodes[1:] {
+		waitFor(t, cfgs[i+1].Name+" to replicate the proposal", func() bool { return r.ledger.Head() == leader.ledger.Head() })
+	}
+
+	// The persisted ledger reloads, and ocp-node watch agrees it is in sync
+	ledgerPath := filepath.Join(cfgs[2].DataDir, ledgerFileName)
+	waitFor(t, "node3 to persist its ledger", func() bool {
+		reloaded, err := loadLedger(ledgerPath, leader.quorum)
+		return err == nil && reloaded.Height() == height
+	})
+	file := filepath.Join(t.TempDir(), "constitution.md")
+	os.WriteFile(file, []byte(amended), 0o644)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"watch", "-file", file, "-ledger", ledgerPath, "-once"}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected the persisted ledger to ratify the amendment, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	t.Logf("✓ Three nodes at height %d; %s", height, strings.TrimSpace(stdout.String()))
+}
+
+// TestServeConfig tests refusing unusable configurations
+func TestServeConfig(t *testing.T) {
+	testenv.RequireSigning(t)
+	cfgs := testNetwork(t)
+	cfg := cfgs[0]
+
+	// A node whose key is not the one registered under its name
+	impostor := *cfg
+	impostor.KeyFile = cfgs[1].KeyFile
+	if _, err := newReferenceNode(&impostor, log.New(io.Discard, "", 0)); err == nil {
+		t.Error("Expected a node holding another member's key to be refused")
+	}
+
+	// A ledger file from another genesis
+	other := *cfg
+	other.Policies = map[string]interface{}{"max_stake": 100}
+	r, err := newReferenceNode(&other, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(cfg.DataDir, 0o755)
+	if err := writeLedger(filepath.Join(cfg.DataDir, ledgerFileName), r.ledger.Entries(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newReferenceNode(cfg, log.New(io.Discard, "", 0)); err == nil || !strings.Contains(err.Error(), "genesis") {
+		t.Errorf("Expected a ledger from another genesis to be refused, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "node.json")
+	os.WriteFile(path, []byte(`{"name": "node1", "listen": ":0", "gossip_listen": ":0", "data_dir": "d", "constitution": "c.md", "key_file": "k", "extra": 1}`), 0o644)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"serve", "-config", path}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "extra") {
+		t.Errorf("Expected an unknown field to exit 1, got %d: %s", code, stderr.String())
+	}
+	if code := run([]string{"serve"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Missing -config should exit 2, got %d", code)
+	}
+	t.Log("✓ Mismatched keys, foreign ledgers, and unknown fields refused")
+}
@@ -0,0 +1,478 @@
+// tui.go - Terminal ledger browser
+//
+// tui is for operators who reach their nodes over SSH and want to look at a
+// ledger without piping curl into jq. It pages the whole ledger from a node's
+// query API (GET /v1/ledger), re-verifies the hash chain locally, and draws a
+// list of accepted proposals above a detail pane holding the selected entry's
+// canonical form, the chain status, and the state of every challenge bond
+// locked against the proposal.
+//
+// The screen is drawn with plain ANSI escapes and keys are read from stdin, so
+// the command needs nothing beyond a VT100-compatible terminal. On a terminal
+// the input is switched to unbuffered mode with stty; elsewhere each line of
+// input is read as a sequence of keys.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// ANSI escapes used by the browser
+const (
+	ansiClear    = "\x1b[H\x1b[2J"
+	ansiReverse  = "\x1b[7m"
+	ansiBold     = "\x1b[1m"
+	ansiReset    = "\x1b[0m"
+	ansiAltOn    = "\x1b[?1049h\x1b[?25l"
+	ansiAltOff   = "\x1b[?25h\x1b[?1049l"
+	defaultRows  = 24
+	defaultCols  = 80
+	minListRows  = 3
+	shortHashLen = 12
+)
+
+func tui(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("server", "http://localhost:8080", "base URL of the node's query API")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout for each request to the node")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client := &ledgerClient{base: strings.TrimRight(*addr, "/"), http: &http.Client{Timeout: *timeout}}
+	view, err := client.load()
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+		return 1
+	}
+
+	b := &browser{server: client.base, view: view, rows: defaultRows, cols: defaultCols}
+	if term, ok := stdin.(*os.File); ok && isTerminal(term) {
+		restore, err := rawMode(term)
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-node: %v\n", err)
+			return 1
+		}
+		defer restore()
+		if rows, cols, err := terminalSize(term); err == nil {
+			b.rows, b.cols = rows, cols
+		}
+		io.WriteString(stdout, ansiAltOn)
+		defer io.WriteString(stdout, ansiAltOff)
+	}
+
+	keys := bufio.NewReader(stdin)
+	for {
+		b.render(stdout)
+		key, err := readKey(keys)
+		if err != nil {
+			return 0
+		}
+		switch key {
+		case "q":
+			return 0
+		case "r":
+			view, err := client.load()
+			if err != nil {
+				b.status = err.Error()
+				continue
+			}
+			b.view, b.status = view, "refreshed at height "+strconv.FormatUint(view.height, 10)
+		default:
+			b.handle(key)
+		}
+	}
+}
+
+// ledgerClient reads a node's ledger through its query API
+type ledgerClient struct {
+	base string
+	http *http.Client
+}
+
+// load pages through every ledger entry and builds a view of them
+func (c *ledgerClient) load() (*ledgerView, error) {
+	var entries []ocp.LedgerEntry
+	var height, after uint64
+	for {
+		var page struct {
+			Entries []ocp.LedgerEntry `json:"entries"`
+			Height  uint64            `json:"height"`
+			More    bool              `json:"more"`
+		}
+		if err := c.get("/v1/ledger?after="+url.QueryEscape(strconv.FormatUint(after, 10)), &page); err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.Entries...)
+		height = page.Height
+		if !page.More || len(page.Entries) == 0 {
+			break
+		}
+		after = page.Entries[len(page.Entries)-1].Height
+	}
+	return newLedgerView(entries, height), nil
+}
+
+func (c *ledgerClient) get(path string, v interface{}) error {
+	resp, err := c.http.Get(c.base + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s: %s", path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, v)
+}
+
+// challengeState is one challenge bond locked against a proposal
+type challengeState struct {
+	entryHash  string
+	challenger string
+	bond       interface{}
+	// outcome is ocp.ChallengeUpheld, ocp.ChallengeRejected, or "" while open
+	outcome string
+}
+
+func (c challengeState) String() string {
+	outcome := c.outcome
+	if outcome == "" {
+		outcome = "open"
+	}
+	return fmt.Sprintf("bond %v by %s (%s): %s", c.bond, c.challenger, shortHash(c.entryHash), outcome)
+}
+
+// ledgerView is what the browser shows of one fetch of the ledger
+type ledgerView struct {
+	height     uint64
+	proposals  []ocp.LedgerEntry
+	challenges map[string][]challengeState
+	// chain is the result of re-verifying the fetched entries
+	chain string
+}
+
+// newLedgerView indexes proposals and challenges and verifies the hash chain
+func newLedgerView(entries []ocp.LedgerEntry, height uint64) *ledgerView {
+	v := &ledgerView{height: height, challenges: make(map[string][]challengeState)}
+
+	switch {
+	case len(entries) == 0:
+		v.chain = "empty"
+	default:
+		first := entries[0]
+		if _, err := ocp.VerifyChain(first.Height-1, first.PrevHash, entries); err != nil {
+			v.chain = "BROKEN: " + err.Error()
+		} else {
+			v.chain = fmt.Sprintf("ok, heights %d-%d verified", first.Height, entries[len(entries)-1].Height)
+		}
+	}
+
+	outcomes := make(map[string]string)
+	for _, e := range entries {
+		if e.Kind == ocp.LedgerKindChallengeResolved {
+			outcome, _ := e.Payload["outcome"].(string)
+			bondHash, _ := e.Payload["bond_hash"].(string)
+			outcomes[bondHash] = outcome
+		}
+	}
+	for _, e := range entries {
+		switch e.Kind {
+		case ocp.LedgerKindProposal:
+			v.proposals = append(v.proposals, e)
+		case ocp.LedgerKindChallengeBond:
+			proposalHash, _ := e.Payload["proposal_hash"].(string)
+			challenger, _ := e.Payload["challenger"].(string)
+			v.challenges[proposalHash] = append(v.challenges[proposalHash], challengeState{
+				entryHash:  e.Hash,
+				challenger: challenger,
+				bond:       e.Payload["bond"],
+				outcome:    outcomes[e.Hash],
+			})
+		}
+	}
+	return v
+}
+
+// summary describes a proposal's challenges in a few words
+func (v *ledgerView) summary(proposalHash string) string {
+	challenges := v.challenges[proposalHash]
+	if len(challenges) == 0 {
+		return "-"
+	}
+	open := 0
+	for _, c := range challenges {
+		if c.outcome == "" {
+			open++
+		}
+	}
+	return fmt.Sprintf("%d (%d open)", len(challenges), open)
+}
+
+// browser holds the screen state of the ledger browser
+type browser struct {
+	server     string
+	view       *ledgerView
+	selected   int
+	offset     int
+	rows, cols int
+	// status is shown on the last line until the next key
+	status string
+}
+
+// handle applies a navigation key
+func (b *browser) handle(key string) {
+	last := len(b.view.proposals) - 1
+	switch key {
+	case "j", "down":
+		b.selected++
+	case "k", "up":
+		b.selected--
+	case "g":
+		b.selected = 0
+	case "G":
+		b.selected = last
+	default:
+		b.status = fmt.Sprintf("unknown key %q", key)
+		return
+	}
+	b.status = ""
+	if b.selected > last {
+		b.selected = last
+	}
+	if b.selected < 0 {
+		b.selected = 0
+	}
+}
+
+// listRows is the number of proposals visible at once
+func (b *browser) listRows() int {
+	if n := b.rows / 3; n > minListRows {
+		return n
+	}
+	return minListRows
+}
+
+// render draws the whole screen
+func (b *browser) render(w io.Writer) {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	add("%sOCP ledger%s  %s  height %d  chain %s", ansiBold, ansiReset, b.server, b.view.height, b.view.chain)
+	add("%s%-8s %-14s %-16s %-8s %s%s", ansiBold, "HEIGHT", "PROPOSAL", "AGENT", "STAKE", "CHALLENGES", ansiReset)
+
+	visible := b.listRows()
+	if b.selected < b.offset {
+		b.offset = b.selected
+	}
+	if b.selected >= b.offset+visible {
+		b.offset = b.selected - visible + 1
+	}
+	for i := b.offset; i < b.offset+visible; i++ {
+		if i >= len(b.view.proposals) {
+			add("")
+			continue
+		}
+		e := b.view.proposals[i]
+		hash, _ := e.Payload["proposal_hash"].(string)
+		agent, _ := e.Payload["proposer_agent"].(string)
+		row := fmt.Sprintf("%-8d %-14s %-16s %-8v %s", e.Height, shortHash(hash), agent, e.Payload["reputation_stake"], b.view.summary(hash))
+		if i == b.selected {
+			row = ansiReverse + truncate(row, b.cols) + ansiReset
+		}
+		lines = append(lines, row)
+	}
+	add("%s", strings.Repeat("-", b.cols))
+
+	if len(b.view.proposals) == 0 {
+		add("no proposals on this ledger")
+	} else {
+		lines = append(lines, b.detail()...)
+	}
+
+	footer := "j/k move  g/G first/last  r refresh  q quit"
+	if b.status != "" {
+		footer = b.status
+	}
+	for len(lines) < b.rows-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:b.rows-1], footer)
+
+	var out strings.Builder
+	out.WriteString(ansiClear)
+	for i, line := range lines {
+		if i > 0 {
+			out.WriteString("\r\n")
+		}
+		out.WriteString(truncate(line, b.cols))
+	}
+	io.WriteString(w, out.String())
+}
+
+// detail returns the detail pane for the selected proposal
+func (b *browser) detail() []string {
+	e := b.view.proposals[b.selected]
+	hash, _ := e.Payload["proposal_hash"].(string)
+	accepted, _ := e.Payload["accepted_at"].(string)
+	lines := []string{
+		"proposal   " + hash,
+		fmt.Sprintf("entry      %s at height %d", e.Hash, e.Height),
+		"accepted   " + accepted,
+		"chain      " + b.view.chain,
+	}
+
+	challenges := b.view.challenges[hash]
+	if len(challenges) == 0 {
+		lines = append(lines, "challenges none")
+	}
+	for i, c := range challenges {
+		label := "challenges "
+		if i > 0 {
+			label = "           "
+		}
+		lines = append(lines, label+c.String())
+	}
+
+	lines = append(lines, "canonical")
+	form, err := ocp.CanonicalizeValue(e.ToMap())
+	if err != nil {
+		form = err.Error()
+	}
+	width := b.cols - 2
+	if width < 1 {
+		width = 1
+	}
+	for len(form) > 0 {
+		n := len(form)
+		if n > width {
+			n = width
+		}
+		lines = append(lines, "  "+form[:n])
+		form = form[n:]
+	}
+	return lines
+}
+
+// readKey reads one key press, mapping arrow key escapes to "up" and "down".
+// Newlines are skipped so that line-buffered input works as a key sequence.
+func readKey(r *bufio.Reader) (string, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '\n', '\r':
+			continue
+		case 0x1b:
+			if next, err := r.ReadByte(); err != nil || next != '[' {
+				return "q", nil
+			}
+			arrow, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			switch arrow {
+			case 'A':
+				return "up", nil
+			case 'B':
+				return "down", nil
+			}
+			return "", nil
+		}
+		return string(c), nil
+	}
+}
+
+// shortHash abbreviates a hash for the proposal list
+func shortHash(hash string) string {
+	if len(hash) <= shortHashLen {
+		return hash
+	}
+	return hash[:shortHashLen] + "…"
+}
+
+// truncate cuts s to cols visible characters, keeping ANSI escapes intact
+func truncate(s string, cols int) string {
+	var out strings.Builder
+	visible := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			end := strings.IndexAny(s[i:], "hlmHJ")
+			if end < 0 {
+				break
+			}
+			out.WriteString(s[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible < cols {
+			out.WriteRune(r)
+			visible++
+		}
+		i += size
+	}
+	return out.String()
+}
+
+// isTerminal reports whether f is a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// rawMode switches the terminal to unbuffered input without echo and returns
+// a function restoring the previous settings
+func rawMode(term *os.File) (func(), error) {
+	saved, err := stty(term, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("stty: %w", err)
+	}
+	if _, err := stty(term, "-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("stty: %w", err)
+	}
+	return func() { stty(term, strings.TrimSpace(saved)) }, nil
+}
+
+// terminalSize returns the rows and columns of the terminal
+func terminalSize(term *os.File) (int, int, error) {
+	out, err := stty(term, "size")
+	if err != nil {
+		return 0, 0, err
+	}
+	var rows, cols int
+	if _, err := fmt.Sscan(out, &rows, &cols); err != nil || rows < 1 || cols < 1 {
+		return 0, 0, fmt.Errorf("unexpected stty size %q", out)
+	}
+	return rows, cols, nil
+}
+
+func stty(term *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = term
+	out, err := cmd.Output()
+	return string(out), err
+}
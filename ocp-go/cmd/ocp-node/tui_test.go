@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+	"github.com/seanrugg/ai_constitution/ocp-go/server"
+)
+
+// testNode returns a node with two proposals, the second challenged twice
+func testNode(t *testing.T) (*ocp.Node, []string) {
+	t.Helper()
+	ledger := ocp.NewLedger()
+	node := ocp.NewNode(ledger)
+	escrow := ocp.NewEscrow(ledger, ocp.DefaultBondingCurve)
+	var hashes []string
+	var last *ocp.ContractProposal
+	for _, agent := range []string{"Claude", "Gemini"} {
+		p := &ocp.ContractProposal{
+			ID:              "proposal-" + agent,
+			ProposerAgent:   agent,
+			ActionType:      "amend",
+			Action:          map[string]interface{}{"target": "article-3"},
+			Timestamp:       "2025-11-20T14:30:00Z",
+			ReputationStake: 10,
+		}
+		acceptance, err := node.Submit(p)
+		if err != nil {
+			t.Fatalf("Failed to submit: %v", err)
+		}
+		hashes, last = append(hashes, acceptance.ProposalHash), p
+	}
+	bond, err := escrow.Lock("DeepSeek", last, 100)
+	if err != nil {
+		t.Fatalf("Failed to lock bond: %v", err)
+	}
+	if _, err := escrow.Resolve(bond.EntryHash, false); err != nil {
+		t.Fatalf("Failed to resolve bond: %v", err)
+	}
+	if _, err := escrow.Lock("DeepSeek", last, 100); err != nil {
+		t.Fatalf("Failed to lock bond: %v", err)
+	}
+	return node, hashes
+}
+
+// TestTUICommand tests browsing a node's ledger with scripted keys
+func TestTUICommand(t *testing.T) {
+	testenv.RequireSigning(t)
+	node, hashes := testNode(t)
+	srv := httptest.NewServer(server.NewHandler(node))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := tui([]string{"-server", srv.URL}, strings.NewReader("j\nr\nq\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	screens := strings.Split(stdout.String(), ansiClear)[1:]
+	if len(screens) != 3 {
+		t.Fatalf("Expected a screen per key, got %d", len(screens))
+	}
+	first, second := screens[0], screens[1]
+	if !strings.Contains(first, "proposal   "+hashes[0]) || !strings.Contains(first, "challenges none") {
+		t.Errorf("The first proposal should be selected:\n%s", first)
+	}
+	for _, want := range []string{
+		"proposal   " + hashes[1],
+		"chain ok, heights 1-5 verified",
+		"2 (1 open)",
+		"by DeepSeek",
+		": rejected",
+		": open",
+		`{"height":2,"kind":"proposal"`,
+	} {
+		if !strings.Contains(second, want) {
+			t.Errorf("Expected %q on the detail screen:\n%s", want, second)
+		}
+	}
+	if !strings.Contains(screens[2], "refreshed at height 5") {
+		t.Errorf("Expected a refresh notice:\n%s", screens[2])
+	}
+
+	if code := tui([]string{"-server", srv.URL + "/missing"}, strings.NewReader("q"), &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit 1 for an unreachable API, got %d", code)
+	}
+
+	t.Logf("✓ Browsed %d proposals over the query API", len(hashes))
+}
+
+// TestLedgerViewBrokenChain tests that a tampered ledger is reported
+func TestLedgerViewBrokenChain(t *testing.T) {
+	testenv.RequireSigning(t)
+	node, _ := testNode(t)
+	entries := node.Ledger().Entries(0)
+	entries[1].Payload["reputation_stake"] = 1000
+	view := newLedgerView(entries, uint64(len(entries)))
+	if !strings.HasPrefix(view.chain, "BROKEN") {
+		t.Errorf("Expected a broken chain, got %q", view.chain)
+	}
+	if empty := newLedgerView(nil, 0); empty.chain != "empty" || len(empty.proposals) != 0 {
+		t.Errorf("Unexpected empty view: %+v", empty)
+	}
+
+	t.Logf("✓ Tampering shown as chain %s", view.chain)
+}
+
+// TestReadKey tests decoding letters and arrow keys
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("j\n\x1b[A\x1b[Bq"))
+	var keys []string
+	for {
+		key, err := readKey(r)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	if got := strings.Join(keys, ","); got != "j,up,down,q" {
+		t.Errorf("Unexpected keys %s", got)
+	}
+	if got := truncate(ansiReverse+"abcdef"+ansiReset, 3); got != ansiReverse+"abc"+ansiReset {
+		t.Errorf("Unexpected truncation %q", got)
+	}
+
+	t.Log("✓ Keys decoded from raw and line input")
+}
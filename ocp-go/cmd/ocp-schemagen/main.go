@@ -0,0 +1,516 @@
+// ocp-schemagen compiles the OCP JSON Schemas into Go validator code.
+//
+// The generated validators walk decoded JSON (map[string]interface{},
+// []interface{}, float64, string, bool) with type switches only, so validation
+// needs no reflection and no schema interpretation at runtime. Each generated
+// file records the content hash of the schema it was built from, which lets the
+// schema package's tests fail when the bundled schemas change without
+// regenerating.
+//
+// Only the JSON Schema keywords used by the protocol schemas are supported; any
+// other validation keyword is a generation error rather than being silently
+// ignored.
+//
+// Usage (see go:generate in package schema):
+//
+//	ocp-schemagen -in ../../protocol/schemas -out schema_gen.go -package schema
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// annotations are keywords that carry no validation semantics
+var annotations = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"examples":    true,
+	"default":     true,
+	"definitions": true,
+}
+
+// supported are the validation keywords the generator implements
+var supported = map[string]bool{
+	"type":                 true,
+	"properties":           true,
+	"required":             true,
+	"additionalProperties": true,
+	"enum":                 true,
+	"pattern":              true,
+	"format":               true,
+	"items":                true,
+	"minItems":             true,
+	"maxItems":             true,
+	"minLength":            true,
+	"maxLength":            true,
+	"minimum":              true,
+	"maximum":              true,
+	"$ref":                 true,
+}
+
+func main() {
+	in := flag.String("in", "../../protocol/schemas", "directory containing *.schema.json files")
+	out := flag.String("out", "schema_gen.go", "output Go file")
+	pkg := flag.String("package", "schema", "package name of the generated file")
+	flag.Parse()
+
+	src, err := generate(*in, *pkg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ocp-schemagen: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "ocp-schemagen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate compiles every schema in dir into one formatted Go source file
+func generate(dir, pkg string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.schema.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.schema.json files in %s", dir)
+	}
+	sort.Strings(files)
+
+	g := &generator{funcs: make(map[string]bool), imports: make(map[string]bool)}
+	var registry bytes.Buffer
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := canonical.DecodeStrict(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		base := filepath.Base(file)
+		name := exportedName(strings.TrimSuffix(base, ".schema.json"))
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+
+		g.schema = name
+		g.root = doc
+		g.defs = make(map[string]string)
+		g.nre = 0
+		rootFunc, err := g.node("validate"+name, doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", base, err)
+		}
+
+		source, err := canonical.Canonicalize(doc, true)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", base, err)
+		}
+
+		fmt.Fprintf(&g.api, "// %sSchemaHash is the SHA-256 of the %s this code was generated from\n", name, base)
+		fmt.Fprintf(&g.api, "const %sSchemaHash = %q\n\n", name, hash)
+		fmt.Fprintf(&g.api, "// %sSchemaSource is the canonical form of %s\n", name, base)
+		fmt.Fprintf(&g.api, "const %sSchemaSource = %q\n\n", name, source)
+		fmt.Fprintf(&g.api, "// Validate%s validates a decoded document against %s\n", name, base)
+		fmt.Fprintf(&g.api, "func Validate%s(doc interface{}) error {\n", name)
+		fmt.Fprintf(&g.api, "\tvar errs Errors\n\t%s(doc, \"$\", &errs)\n\treturn errs.err()\n}\n\n", rootFunc)
+		fmt.Fprintf(&registry, "\t%q: {Name: %q, Hash: %sSchemaHash, Source: %sSchemaSource, Validate: Validate%s},\n", base, name, name, name, name)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by ocp-schemagen from protocol/schemas. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	var imports []string
+	for _, imp := range []string{"fmt", "regexp", "unicode/utf8"} {
+		if g.imports[imp] {
+			imports = append(imports, strconv.Quote(imp))
+		}
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&out, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	}
+	out.Write(g.api.Bytes())
+	fmt.Fprintf(&out, "// generated lists the compiled schemas by file name\n")
+	fmt.Fprintf(&out, "var generated = map[string]Compiled{\n%s}\n\n", registry.String())
+	if g.vars.Len() > 0 {
+		fmt.Fprintf(&out, "var (\n%s)\n\n", g.vars.String())
+	}
+	out.Write(g.body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	schema  string
+	root    map[string]interface{}
+	defs    map[string]string
+	funcs   map[string]bool
+	imports map[string]bool
+	nre     int
+	api     bytes.Buffer
+	vars    bytes.Buffer
+	body    bytes.Buffer
+}
+
+// node emits a validator function for schema s and returns its name
+func (g *generator) node(name string, s map[string]interface{}) (string, error) {
+	return g.emit(g.uniqueName(name), s)
+}
+
+// emit writes the validator function called name for schema s
+func (g *generator) emit(name string, s map[string]interface{}) (string, error) {
+	for kw := range s {
+		if !annotations[kw] && !supported[kw] {
+			return "", fmt.Errorf("unsupported keyword %q in %s", kw, name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func %s(v interface{}, path string, errs *Errors) {\n", name)
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := g.ref(ref)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "\t%s(v, path, errs)\n", target)
+	}
+
+	typ, hasType := s["type"]
+	if hasType {
+		t, ok := typ.(string)
+		if !ok {
+			return "", fmt.Errorf("%s: only single string types are supported", name)
+		}
+		if err := g.typed(&b, name, t, s); err != nil {
+			return "", err
+		}
+	} else {
+		for _, kw := range []string{"properties", "required", "additionalProperties", "enum", "pattern", "format", "items", "minItems", "maxItems", "minLength", "maxLength", "minimum", "maximum"} {
+			if _, ok := s[kw]; ok {
+				return "", fmt.Errorf("%s: keyword %q requires a type", name, kw)
+			}
+		}
+	}
+
+	b.WriteString("}\n\n")
+	g.body.WriteString(b.String())
+	return name, nil
+}
+
+func (g *generator) typed(b *strings.Builder, name, t string, s map[string]interface{}) error {
+	switch t {
+	case "object":
+		return g.object(b, name, s)
+	case "array":
+		return g.array(b, name, s)
+	case "string":
+		return g.str(b, name, s)
+	case "number", "integer":
+		return g.number(b, name, t, s)
+	case "boolean":
+		if len(constraints(s)) > 0 {
+			return fmt.Errorf("%s: unsupported constraints on boolean", name)
+		}
+		b.WriteString("\tif _, ok := v.(bool); !ok {\n\t\terrs.Add(path, \"type\", \"expected boolean\")\n\t}\n")
+		return nil
+	case "null":
+		b.WriteString("\tif v != nil {\n\t\terrs.Add(path, \"type\", \"expected null\")\n\t}\n")
+		return nil
+	default:
+		return fmt.Errorf("%s: unsupported type %q", name, t)
+	}
+}
+
+func (g *generator) object(b *strings.Builder, name string, s map[string]interface{}) error {
+	if err := allowOnly(name, s, "properties", "required", "additionalProperties"); err != nil {
+		return err
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	required, _ := s["required"].([]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+
+	if len(props) == 0 && len(required) == 0 && !hasAdditional {
+		b.WriteString("\tif _, ok := v.(map[string]interface{}); !ok {\n\t\terrs.Add(path, \"type\", \"expected object\")\n\t}\n")
+		return nil
+	}
+
+	b.WriteString("\tm, ok := v.(map[string]interface{})\n\tif !ok {\n\t\terrs.Add(path, \"type\", \"expected object\")\n\t\treturn\n\t}\n")
+
+	if len(required) > 0 {
+		keys := make([]string, len(required))
+		for i, r := range required {
+			key, ok := r.(string)
+			if !ok {
+				return fmt.Errorf("%s: required entries must be strings", name)
+			}
+			keys[i] = strconv.Quote(key)
+		}
+		fmt.Fprintf(b, "\tfor _, key := range []string{%s} {\n", strings.Join(keys, ", "))
+		b.WriteString("\t\tif _, ok := m[key]; !ok {\n\t\t\terrs.Add(path+\".\"+key, \"required\", \"missing required property\")\n\t\t}\n\t}\n")
+	}
+
+	names := sortedKeys(props)
+	for _, key := range names {
+		sub, ok := props[key].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: property %q is not a schema", name, key)
+		}
+		fn, err := g.node(name+exportedName(key), sub)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\tif pv, ok := m[%q]; ok {\n\t\t%s(pv, path+%q, errs)\n\t}\n", key, fn, "."+key)
+	}
+
+	if hasAdditional {
+		quoted := make([]string, len(names))
+		for i, key := range names {
+			quoted[i] = strconv.Quote(key)
+		}
+		var onExtra string
+		switch a := additional.(type) {
+		case bool:
+			if a {
+				return nil
+			}
+			onExtra = "errs.Add(path+\".\"+key, \"additionalProperties\", \"unexpected property\")"
+		case map[string]interface{}:
+			fn, err := g.node(name+"Additional", a)
+			if err != nil {
+				return err
+			}
+			onExtra = fmt.Sprintf("%s(m[key], path+\".\"+key, errs)", fn)
+		default:
+			return fmt.Errorf("%s: additionalProperties must be a boolean or schema", name)
+		}
+		b.WriteString("\tfor key := range m {\n")
+		if len(quoted) > 0 {
+			fmt.Fprintf(b, "\t\tswitch key {\n\t\tcase %s:\n\t\tdefault:\n\t\t\t%s\n\t\t}\n", strings.Join(quoted, ", "), onExtra)
+		} else {
+			fmt.Fprintf(b, "\t\t%s\n", onExtra)
+		}
+		b.WriteString("\t}\n")
+	}
+	return nil
+}
+
+func (g *generator) array(b *strings.Builder, name string, s map[string]interface{}) error {
+	if err := allowOnly(name, s, "items", "minItems", "maxItems"); err != nil {
+		return err
+	}
+	items, hasItems := s["items"].(map[string]interface{})
+	if _, ok := s["items"]; ok && !hasItems {
+		return fmt.Errorf("%s: only a single items schema is supported", name)
+	}
+	if !hasItems && s["minItems"] == nil && s["maxItems"] == nil {
+		b.WriteString("\tif _, ok := v.([]interface{}); !ok {\n\t\terrs.Add(path, \"type\", \"expected array\")\n\t}\n")
+		return nil
+	}
+
+	b.WriteString("\tarr, ok := v.([]interface{})\n\tif !ok {\n\t\terrs.Add(path, \"type\", \"expected array\")\n\t\treturn\n\t}\n")
+	if n, ok := intKeyword(s, "minItems"); ok {
+		fmt.Fprintf(b, "\tif len(arr) < %d {\n\t\terrs.Add(path, \"minItems\", \"expected at least %d items\")\n\t}\n", n, n)
+	}
+	if n, ok := intKeyword(s, "maxItems"); ok {
+		fmt.Fprintf(b, "\tif len(arr) > %d {\n\t\terrs.Add(path, \"maxItems\", \"expected at most %d items\")\n\t}\n", n, n)
+	}
+	if hasItems {
+		fn, err := g.node(name+"Item", items)
+		if err != nil {
+			return err
+		}
+		g.imports["fmt"] = true
+		fmt.Fprintf(b, "\tfor i, item := range arr {\n\t\t%s(item, fmt.Sprintf(\"%%s[%%d]\", path, i), errs)\n\t}\n", fn)
+	}
+	return nil
+}
+
+func (g *generator) str(b *strings.Builder, name string, s map[string]interface{}) error {
+	if err := allowOnly(name, s, "enum", "pattern", "format", "minLength", "maxLength"); err != nil {
+		return err
+	}
+	if len(constraints(s)) == 0 {
+		b.WriteString("\tif _, ok := v.(string); !ok {\n\t\terrs.Add(path, \"type\", \"expected string\")\n\t}\n")
+		return nil
+	}
+
+	b.WriteString("\ts, ok := v.(string)\n\tif !ok {\n\t\terrs.Add(path, \"type\", \"expected string\")\n\t\treturn\n\t}\n")
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		values := make([]string, len(enum))
+		for i, e := range enum {
+			str, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("%s: only string enums are supported", name)
+			}
+			values[i] = strconv.Quote(str)
+		}
+		g.imports["fmt"] = true
+		fmt.Fprintf(b, "\tswitch s {\n\tcase %s:\n\tdefault:\n\t\terrs.Add(path, \"enum\", fmt.Sprintf(\"%%q is not an allowed value\", s))\n\t}\n", strings.Join(values, ", "))
+	}
+	if n, ok := intKeyword(s, "minLength"); ok {
+		g.imports["unicode/utf8"] = true
+		fmt.Fprintf(b, "\tif utf8.RuneCountInString(s) < %d {\n\t\terrs.Add(path, \"minLength\", \"expected at least %d characters\")\n\t}\n", n, n)
+	}
+	if n, ok := intKeyword(s, "maxLength"); ok {
+		g.imports["unicode/utf8"] = true
+		fmt.Fprintf(b, "\tif utf8.RuneCountInString(s) > %d {\n\t\terrs.Add(path, \"maxLength\", \"expected at most %d characters\")\n\t}\n", n, n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re := fmt.Sprintf("re%s%d", g.schema, g.nre)
+		g.nre++
+		g.imports["regexp"] = true
+		fmt.Fprintf(&g.vars, "\t%s = regexp.MustCompile(%q)\n", re, pattern)
+		fmt.Fprintf(b, "\tif !%s.MatchString(s) {\n\t\terrs.Add(path, \"pattern\", %q)\n\t}\n", re, "does not match "+pattern)
+	}
+	if f, ok := s["format"].(string); ok {
+		check, ok := formats[f]
+		if !ok {
+			return fmt.Errorf("%s: unsupported format %q", name, f)
+		}
+		fmt.Fprintf(b, "\tif !%s(s) {\n\t\terrs.Add(path, \"format\", \"expected %s\")\n\t}\n", check, f)
+	}
+	return nil
+}
+
+// formats maps supported string formats to helper functions in package schema
+var formats = map[string]string{
+	"date-time": "isDateTime",
+	"uuid":      "isUUID",
+}
+
+func (g *generator) number(b *strings.Builder, name, t string, s map[string]interface{}) error {
+	if err := allowOnly(name, s, "minimum", "maximum"); err != nil {
+		return err
+	}
+	_, hasMin := s["minimum"].(float64)
+	_, hasMax := s["maximum"].(float64)
+	if t == "number" && !hasMin && !hasMax {
+		b.WriteString("\tif _, ok := toNumber(v); !ok {\n\t\terrs.Add(path, \"type\", \"expected number\")\n\t}\n")
+		return nil
+	}
+
+	b.WriteString("\tn, ok := toNumber(v)\n\tif !ok {\n\t\terrs.Add(path, \"type\", \"expected " + t + "\")\n\t\treturn\n\t}\n")
+	if t == "integer" {
+		b.WriteString("\tif !isInteger(n) {\n\t\terrs.Add(path, \"type\", \"expected integer\")\n\t}\n")
+	}
+	if min, ok := s["minimum"].(float64); ok {
+		lit := strconv.FormatFloat(min, 'g', -1, 64)
+		fmt.Fprintf(b, "\tif n < %s {\n\t\terrs.Add(path, \"minimum\", \"expected at least %s\")\n\t}\n", lit, lit)
+	}
+	if max, ok := s["maximum"].(float64); ok {
+		lit := strconv.FormatFloat(max, 'g', -1, 64)
+		fmt.Fprintf(b, "\tif n > %s {\n\t\terrs.Add(path, \"maximum\", \"expected at most %s\")\n\t}\n", lit, lit)
+	}
+	return nil
+}
+
+// ref resolves a local "#/definitions/<name>" reference to a validator
+func (g *generator) ref(ref string) (string, error) {
+	if fn, ok := g.defs[ref]; ok {
+		return fn, nil
+	}
+	const prefix = "#/definitions/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported $ref %q", ref)
+	}
+	defs, _ := g.root["definitions"].(map[string]interface{})
+	def, ok := defs[strings.TrimPrefix(ref, prefix)].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unresolved $ref %q", ref)
+	}
+	// Register before emitting so recursive references terminate
+	name := g.uniqueName("validate" + g.schema + "Def" + exportedName(strings.TrimPrefix(ref, prefix)))
+	g.defs[ref] = name
+	return g.emit(name, def)
+}
+
+func (g *generator) uniqueName(name string) string {
+	candidate := name
+	for i := 2; g.funcs[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	g.funcs[candidate] = true
+	return candidate
+}
+
+// allowOnly rejects validation keywords that do not apply to the node's type
+func allowOnly(name string, s map[string]interface{}, allowed ...string) error {
+	for _, kw := range constraints(s) {
+		ok := false
+		for _, a := range allowed {
+			if kw == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s: keyword %q does not apply to type %v", name, kw, s["type"])
+		}
+	}
+	return nil
+}
+
+// constraints returns the validation keywords of s other than type and $ref
+func constraints(s map[string]interface{}) []string {
+	var out []string
+	for kw := range s {
+		if supported[kw] && kw != "type" && kw != "$ref" {
+			out = append(out, kw)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func intKeyword(s map[string]interface{}, kw string) (int, bool) {
+	f, ok := s[kw].(float64)
+	return int(f), ok
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exportedName converts snake_case or kebab-case to CamelCase
+func exportedName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == '.' || r == '$':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateUpToDate tests that the checked-in validators match fresh output
+func TestGenerateUpToDate(t *testing.T) {
+	src, err := generate("../../../protocol/schemas", "schema")
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	again, _ := generate("../../../protocol/schemas", "schema")
+	if !bytes.Equal(src, again) {
+		t.Errorf("Generation should be deterministic")
+	}
+
+	current, err := os.ReadFile("../../schema/schema_gen.go")
+	if err != nil {
+		t.Fatalf("Failed to read schema_gen.go: %v", err)
+	}
+	if !bytes.Equal(src, current) {
+		t.Errorf("schema/schema_gen.go is stale; run go generate ./schema")
+	}
+
+	t.Logf("✓ Generated %d bytes, matches checked-in code", len(src))
+}
+
+// TestGenerateRejectsUnsupportedKeywords tests that unknown keywords are not ignored
+func TestGenerateRejectsUnsupportedKeywords(t *testing.T) {
+	cases := map[string]string{
+		"oneOf":           `{"type":"object","properties":{"a":{"oneOf":[{"type":"string"}]}}}`,
+		"misplaced":       `{"type":"string","minItems":1}`,
+		"format":          `{"type":"string","format":"hostname"}`,
+		"untyped":         `{"properties":{}}`,
+		"non-string enum": `{"type":"string","enum":[1]}`,
+	}
+
+	for name, schema := range cases {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "bad.schema.json"), []byte(schema), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generate(dir, "schema"); err == nil {
+			t.Errorf("%s: expected generation error", name)
+		} else if !strings.Contains(err.Error(), "bad.schema.json") {
+			t.Errorf("%s: error should name the schema file: %v", name, err)
+		}
+	}
+
+	t.Logf("✓ Unsupported keywords rejected")
+}
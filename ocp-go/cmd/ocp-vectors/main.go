@@ -0,0 +1,186 @@
+// ocp-vectors maintains cross-language canonicalization test vectors.
+//
+// Usage:
+//
+//	ocp-vectors gen [-key-order LIST] [-nulls LIST] [-o FILE] CORPUS
+//
+// gen reads every *.json file in the CORPUS directory, in name order, and
+// canonicalizes its raw text with each combination of the listed options
+// (comma-separated; key orders utf8, utf16 and null handling keep, drop). Each
+// vector records the input, the options and canonicalizer version used, and
+// either the canonical form and its SHA-256 or, for inputs the strict decoder
+// rejects, the error. The output, written to FILE or stdout, is the shared
+// format read by the Python, JavaScript, and Rust test suites, so adding a
+// case means adding a corpus file and regenerating.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Format identifies the vector file layout
+const Format = "ocp-canonical-vectors/1"
+
+// VectorFile is the shared vector format
+type VectorFile struct {
+	Format      string   `json:"format"`
+	SpecVersion string   `json:"spec_version"`
+	Vectors     []Vector `json:"vectors"`
+}
+
+// Vector is one input canonicalized with one set of options
+type Vector struct {
+	Name    string  `json:"name"`
+	Input   string  `json:"input"`
+	Options Options `json:"options"`
+	// Canonical and Hash are set when the input is accepted
+	Canonical string `json:"canonical,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	// Error is set when the strict decoder rejects the input
+	Error string `json:"error,omitempty"`
+}
+
+// Options are the canonicalization options a vector was generated with
+type Options struct {
+	KeyOrder string `json:"key_order"`
+	Nulls    string `json:"nulls"`
+	Version  string `json:"version"`
+}
+
+var (
+	keyOrders = map[string]canonical.KeyOrder{"utf8": canonical.KeyOrderUTF8, "utf16": canonical.KeyOrderUTF16}
+	nullModes = map[string]canonical.NullHandling{"keep": canonical.KeepNulls, "drop": canonical.DropNulls}
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches a subcommand and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: ocp-vectors <command> [flags]\n\ncommands:\n  gen  generate test vectors from a corpus directory")
+		return 2
+	}
+	switch args[0] {
+	case "gen":
+		return gen(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "ocp-vectors: unknown command %q\n", args[0])
+		return 2
+	}
+}
+
+func gen(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	orders := fs.String("key-order", "utf8", "comma-separated key orders (utf8, utf16)")
+	nulls := fs.String("nulls", "keep", "comma-separated null handling modes (keep, drop)")
+	out := fs.String("o", "", "output file (stdout if empty)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: ocp-vectors gen [-key-order LIST] [-nulls LIST] [-o FILE] CORPUS")
+		return 2
+	}
+
+	var sets []Options
+	for _, order := range strings.Split(*orders, ",") {
+		for _, mode := range strings.Split(*nulls, ",") {
+			c, err := canonicalizer(order, mode)
+			if err != nil {
+				fmt.Fprintf(stderr, "ocp-vectors: %v\n", err)
+				return 2
+			}
+			sets = append(sets, Options{KeyOrder: order, Nulls: mode, Version: c.Version()})
+		}
+	}
+
+	file, err := Generate(fs.Arg(0), sets)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-vectors: %v\n", err)
+		return 1
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-vectors: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fmt.Fprintf(stderr, "ocp-vectors: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "wrote %d vectors to %s\n", len(file.Vectors), *out)
+	return 0
+}
+
+// Generate canonicalizes every corpus file with each option set
+//
+// Parameters:
+//   - dir: Corpus directory of *.json inputs
+//   - sets: Option sets to generate each input with
+func Generate(dir string, sets []Options) (*VectorFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.json files in %s", dir)
+	}
+	sort.Strings(paths)
+
+	file := &VectorFile{Format: Format, SpecVersion: canonical.SpecVersion, Vectors: []Vector{}}
+	for _, path := range paths {
+		input, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		for _, opts := range sets {
+			c, err := canonicalizer(opts.KeyOrder, opts.Nulls)
+			if err != nil {
+				return nil, err
+			}
+			v := Vector{Name: name, Input: string(input), Options: opts}
+			if form, err := c.CanonicalizeJSON(input); err != nil {
+				v.Error = err.Error()
+			} else {
+				sum := sha256.Sum256([]byte(form))
+				v.Canonical, v.Hash = form, hex.EncodeToString(sum[:])
+			}
+			file.Vectors = append(file.Vectors, v)
+		}
+	}
+	return file, nil
+}
+
+// canonicalizer builds the canonicalizer for named options
+func canonicalizer(order, nulls string) (*canonical.Canonicalizer, error) {
+	o, ok := keyOrders[order]
+	if !ok {
+		return nil, fmt.Errorf("unknown key order %q", order)
+	}
+	n, ok := nullModes[nulls]
+	if !ok {
+		return nil, fmt.Errorf("unknown null handling %q", nulls)
+	}
+	return canonical.New(canonical.WithKeyOrder(o), canonical.WithNullHandling(n)), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// TestGenCommand tests generating vectors from a corpus directory
+func TestGenCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ordering.json"), []byte(`{"z": 1, "a": null}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "duplicate.json"), []byte(`{"a": 1, "a": 2}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"gen", "-nulls", "keep,drop", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	var file VectorFile
+	if err := json.Unmarshal(stdout.Bytes(), &file); err != nil {
+		t.Fatalf("Output is not JSON: %v", err)
+	}
+	if file.Format != Format || len(file.Vectors) != 4 {
+		t.Fatalf("Unexpected vector file: %+v", file)
+	}
+	if v := file.Vectors[0]; v.Name != "duplicate" || v.Error == "" || v.Canonical != "" {
+		t.Errorf("Rejected input should record an error: %+v", v)
+	}
+	keep, drop := file.Vectors[2], file.Vectors[3]
+	if keep.Canonical != `{"a":null,"z":1}` || drop.Canonical != `{"z":1}` {
+		t.Errorf("Unexpected canonical forms: %q %q", keep.Canonical, drop.Canonical)
+	}
+	if len(keep.Hash) != 64 || keep.Hash == drop.Hash {
+		t.Errorf("Unexpected hash: %s", keep.Hash)
+	}
+	if drop.Options.Version != "1.0.0+key_order=utf8;nulls=drop" {
+		t.Errorf("Unexpected options: %+v", drop.Options)
+	}
+
+	if code := run([]string{"gen", "-key-order", "utf32", dir}, &stdout, &stderr); code != 2 {
+		t.Errorf("Unknown option should exit 2, got %d", code)
+	}
+	if code := run([]string{"gen", t.TempDir()}, &stdout, &stderr); code != 1 {
+		t.Errorf("Empty corpus should exit 1, got %d", code)
+	}
+	t.Logf("✓ Generated %d vectors", len(file.Vectors))
+}
+
+// TestCommittedVectors tests that the committed vector file matches its corpus
+func TestCommittedVectors(t *testing.T) {
+	if !canonical.UTF16KeyOrderBuild {
+		t.Skip("The committed vectors include UTF-16 key order, which this build omits")
+	}
+	vectors := "../../../protocol/hashing/test_vectors"
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"gen", "-key-order", "utf8,utf16", "-nulls", "keep,drop", filepath.Join(vectors, "corpus")}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s", code, stderr.String())
+	}
+	committed, err := os.ReadFile(filepath.Join(vectors, "generated_vectors.json"))
+	if err != nil {
+		t.Fatalf("Failed to read committed vectors: %v", err)
+	}
+	if !bytes.Equal(committed, stdout.Bytes()) {
+		t.Errorf("generated_vectors.json is stale; regenerate it with ocp-vectors gen")
+	}
+	t.Log("✓ Committed vectors match the corpus")
+}
@@ -0,0 +1,107 @@
+// ocp-verify checks a proposal without any ability to sign or write history.
+//
+// Usage:
+//
+//	ocp-verify [-key AGENT=HEXKEY]... [-ledger FILE] PROPOSAL
+//
+// PROPOSAL is a contract proposal in JSON, and FILE a JSON array of ledger
+// entries from genesis. Each check is printed and the exit status is non-zero
+// if any check fails. Auditors build it with
+//
+//	go build -tags ocp_verifyonly ./cmd/ocp-verify
+//
+// so that signing and ledger writes are compiled out of the binary.
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// keyFlags collects repeated -key AGENT=HEXKEY flags
+type keyFlags map[string]ed25519.PublicKey
+
+func (k keyFlags) String() string {
+	return fmt.Sprintf("%d keys", len(k))
+}
+
+func (k keyFlags) Set(value string) error {
+	agent, encoded, ok := strings.Cut(value, "=")
+	if !ok || agent == "" {
+		return fmt.Errorf("expected AGENT=HEXKEY, got %q", value)
+	}
+	key, err := hex.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key for %s", agent)
+	}
+	k[agent] = key
+	return nil
+}
+
+// run verifies a proposal and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ocp-verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keys := keyFlags{}
+	fs.Var(keys, "key", "trusted proposer key as AGENT=HEXKEY (repeatable)")
+	ledgerPath := fs.String("ledger", "", "JSON array of ledger entries from genesis")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: ocp-verify [-key AGENT=HEXKEY]... [-ledger FILE] PROPOSAL")
+		return 2
+	}
+
+	var p ocp.ContractProposal
+	if err := readJSON(fs.Arg(0), &p); err != nil {
+		fmt.Fprintf(stderr, "ocp-verify: %v\n", err)
+		return 1
+	}
+
+	v := ocp.NewVerifierOnly(ocp.WithProposerKeys(keys))
+	if *ledgerPath != "" {
+		var entries []ocp.LedgerEntry
+		if err := readJSON(*ledgerPath, &entries); err != nil {
+			fmt.Fprintf(stderr, "ocp-verify: %v\n", err)
+			return 1
+		}
+		if err := v.LoadLedger(entries); err != nil {
+			fmt.Fprintf(stderr, "ocp-verify: %v\n", err)
+			return 1
+		}
+	}
+
+	report := v.VerifyProposal(&p)
+	fmt.Fprintf(stdout, "proposal %s\n", report.ProposalHash)
+	for _, c := range report.Checks {
+		fmt.Fprintf(stdout, "%-18s %-4s %s\n", c.Check, c.Status, c.Message)
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// TestVerifyCommand tests verifying a signed, recorded proposal from files
+func TestVerifyCommand(t *testing.T) {
+	testenv.RequireSigning(t)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	p := &ocp.ContractProposal{
+		ID:                 "550e8400-e29b-41d4-a716-446655440000",
+		ProposerAgent:      "Claude",
+		ActionType:         "amend",
+		Action:             map[string]interface{}{"target": "amendment-article-3", "operation": "modify"},
+		Evidence:           []map[string]string{{"type": "archive_reference", "pointer": "sha256:abc123def456"}},
+		Reasoning:          map[string]interface{}{"rationale": "Clarifies Article III.1", "confidence": 0.87, "constitutional_grounding": []interface{}{"Article X.1"}},
+		ReversibilityClass: "partially_reversible",
+		PreStateHash:       "sha256:" + strings.Repeat("a", 64),
+		PostStateHash:      "sha256:" + strings.Repeat("b", 64),
+		Timestamp:          "2025-11-20T14:30:00Z",
+		ReputationStake:    10,
+	}
+	if err := ocp.SignProposal(p, priv); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	node := ocp.NewNode(ocp.NewLedger())
+	if _, err := node.Submit(p); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	dir := t.TempDir()
+	proposalPath := filepath.Join(dir, "proposal.json")
+	ledgerPath := filepath.Join(dir, "ledger.json")
+	writeJSON(t, proposalPath, p)
+	writeJSON(t, ledgerPath, node.Ledger().Entries(0))
+	key := "Claude=" + hex.EncodeToString(priv.Public().(ed25519.PublicKey))
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-key", key, "-ledger", ledgerPath, proposalPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	var failed bytes.Buffer
+	if code := run([]string{"-ledger", ledgerPath, proposalPath}, &failed, &stderr); code != 1 {
+		t.Errorf("Untrusted proposer should exit 1, got %d", code)
+	}
+	p.ReputationStake = 99
+	writeJSON(t, proposalPath, p)
+	if code := run([]string{"-key", key, proposalPath}, &failed, &stderr); code != 1 {
+		t.Errorf("Altered proposal should exit 1, got %d", code)
+	}
+	if code := run(nil, &failed, &stderr); code != 2 {
+		t.Errorf("Missing proposal should exit 2, got %d", code)
+	}
+
+	t.Logf("✓ ocp-verify output:\n%s", stdout.String())
+}
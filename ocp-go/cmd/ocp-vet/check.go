@@ -0,0 +1,427 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// modulePath prefixes the import path of every package whose calls are sinks
+const modulePath = "github.com/seanrugg/ai_constitution/ocp-go"
+
+// maxExactInteger is the largest magnitude every float64 integer can represent,
+// as in canonical/audit.go
+const maxExactInteger = 1 << 53
+
+// Details of each lossy coercion, worded as CanonicalizeAudited reports them
+const (
+	detailFloat32 = "printed with float32 digits, which read back as a different float64; convert to float64"
+	detailTime    = "zone is kept as an offset, so the same instant hashes differently per zone; pass ocp.Timestamp(t) or t.UTC()"
+	detailInteger = "integer beyond 2^53 has no exact float64; encode it as a string or canonical.Decimal"
+)
+
+// finding is a lossy value reaching the canonicalizer
+type finding struct {
+	pos     token.Position
+	message string
+}
+
+func (f finding) String() string {
+	return fmt.Sprintf("%s: %s", f.pos, f.message)
+}
+
+// checker finds lossy values in one type-checked package
+type checker struct {
+	fset *token.FileSet
+	info *types.Info
+
+	// stores holds, for each variable, every value assigned to it or stored
+	// into it by index, so a map built up before a call is checked at the call
+	stores   map[*types.Var][]ast.Expr
+	visiting map[*types.Var]bool
+	found    map[token.Pos]string
+}
+
+// check returns the lossy values reaching the canonicalizer in files, which
+// were type-checked into info
+//
+// Sinks are:
+//   - Arguments to functions and methods of this module's packages whose
+//     parameter holds arbitrary values (interface{}, map[string]interface{},
+//     []interface{}), such as SemanticHash, Canonicalize, and Ledger.Append
+//   - Fields of that kind in composite literals of this module's struct types,
+//     such as ContractProposal.Action, which are hashed through ToMap
+//   - Values returned by ToMap methods, the repository's convention for a
+//     type's hashed form
+//
+// Returns:
+//   - Findings in file and position order
+func check(fset *token.FileSet, files []*ast.File, info *types.Info) []finding {
+	c := &checker{
+		fset:     fset,
+		info:     info,
+		stores:   make(map[*types.Var][]ast.Expr),
+		visiting: make(map[*types.Var]bool),
+		found:    make(map[token.Pos]string),
+	}
+	for _, f := range files {
+		ast.Inspect(f, c.collect)
+	}
+	for _, f := range files {
+		ast.Inspect(f, c.sink)
+	}
+
+	out := make([]finding, 0, len(c.found))
+	for pos, message := range c.found {
+		out = append(out, finding{fset.Position(pos), message})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].pos, out[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return out
+}
+
+// collect records assignments and index stores into variables
+func (c *checker) collect(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		if len(n.Lhs) != len(n.Rhs) {
+			return true
+		}
+		for i, lhs := range n.Lhs {
+			if index, ok := ast.Unparen(lhs).(*ast.IndexExpr); ok {
+				lhs = index.X
+			}
+			if v := c.variable(lhs); v != nil {
+				c.stores[v] = append(c.stores[v], n.Rhs[i])
+			}
+		}
+	case *ast.ValueSpec:
+		if len(n.Names) != len(n.Values) {
+			return true
+		}
+		for i, name := range n.Names {
+			if v := c.variable(name); v != nil {
+				c.stores[v] = append(c.stores[v], n.Values[i])
+			}
+		}
+	}
+	return true
+}
+
+// variable returns the variable e names, or nil
+func (c *checker) variable(e ast.Expr) *types.Var {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	obj := c.info.Defs[id]
+	if obj == nil {
+		obj = c.info.Uses[id]
+	}
+	v, _ := obj.(*types.Var)
+	return v
+}
+
+// sink checks the values reaching the canonicalizer at n
+func (c *checker) sink(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		sig, ok := c.info.TypeOf(n.Fun).(*types.Signature)
+		if !ok || !c.inModule(n.Fun) {
+			return true
+		}
+		params := sig.Params()
+		for i, arg := range n.Args {
+			var t types.Type
+			switch {
+			case sig.Variadic() && i >= params.Len()-1:
+				t = params.At(params.Len() - 1).Type()
+				if n.Ellipsis == token.NoPos {
+					t = t.(*types.Slice).Elem()
+				}
+			case i < params.Len():
+				t = params.At(i).Type()
+			default:
+				continue
+			}
+			if holdsAny(t) {
+				c.value(arg)
+			}
+		}
+	case *ast.CompositeLit:
+		t := types.Unalias(c.info.TypeOf(n))
+		if p, ok := t.(*types.Pointer); ok {
+			t = types.Unalias(p.Elem())
+		}
+		named, ok := t.(*types.Named)
+		if !ok || !inModule(named.Obj().Pkg()) {
+			return true
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			return true
+		}
+		for i, elt := range n.Elts {
+			field, value := c.field(st, i, elt)
+			if field != nil && holdsAny(field.Type()) {
+				c.value(value)
+			}
+		}
+	case *ast.FuncDecl:
+		if n.Recv == nil || n.Name.Name != "ToMap" || n.Body == nil {
+			return true
+		}
+		ast.Inspect(n.Body, func(m ast.Node) bool {
+			switch m := m.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				for _, result := range m.Results {
+					if holdsAny(c.info.TypeOf(result)) {
+						c.value(result)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return true
+}
+
+// field returns the struct field set by the i'th element of a literal of st
+func (c *checker) field(st *types.Struct, i int, elt ast.Expr) (*types.Var, ast.Expr) {
+	kv, ok := elt.(*ast.KeyValueExpr)
+	if !ok {
+		if i < st.NumFields() {
+			return st.Field(i), elt
+		}
+		return nil, nil
+	}
+	key, ok := kv.Key.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	for j := 0; j < st.NumFields(); j++ {
+		if st.Field(j).Name() == key.Name {
+			return st.Field(j), kv.Value
+		}
+	}
+	return nil, nil
+}
+
+// inModule reports whether fun is a function or method of this module
+func (c *checker) inModule(fun ast.Expr) bool {
+	var id *ast.Ident
+	switch fun := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	case *ast.IndexExpr:
+		return c.inModule(fun.X)
+	default:
+		return false
+	}
+	f, ok := c.info.Uses[id].(*types.Func)
+	return ok && inModule(f.Pkg())
+}
+
+func inModule(pkg *types.Package) bool {
+	return pkg != nil && (pkg.Path() == modulePath || strings.HasPrefix(pkg.Path(), modulePath+"/"))
+}
+
+// value reports e if the canonicalizer would coerce it lossily, looking
+// inside literals, conversions, and the values stored into variables
+func (c *checker) value(e ast.Expr) {
+	e = ast.Unparen(e)
+	tv, ok := c.info.Types[e]
+	if !ok {
+		return
+	}
+	if b, ok := tv.Type.Underlying().(*types.Basic); ok && tv.Value != nil && b.Info()&types.IsInteger != 0 {
+		limit := constant.MakeInt64(maxExactInteger)
+		if constant.Compare(tv.Value, token.GTR, limit) || constant.Compare(tv.Value, token.LSS, constant.UnaryOp(token.SUB, limit, 0)) {
+			c.report(e, fmt.Sprintf("%s: %s", tv.Value.ExactString(), detailInteger))
+			return
+		}
+	}
+
+	switch e := e.(type) {
+	case *ast.CompositeLit:
+		switch tv.Type.Underlying().(type) {
+		case *types.Map, *types.Slice, *types.Array:
+			for _, elt := range e.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					elt = kv.Value
+				}
+				c.value(elt)
+			}
+			return
+		}
+	case *ast.Ident:
+		if v := c.variable(e); v != nil && holdsAny(v.Type()) {
+			if c.visiting[v] {
+				return
+			}
+			c.visiting[v] = true
+			for _, stored := range c.stores[v] {
+				c.value(stored)
+			}
+			c.visiting[v] = false
+			return
+		}
+	case *ast.CallExpr:
+		if fun, ok := c.info.Types[e.Fun]; ok && fun.IsType() && holdsAny(fun.Type) && len(e.Args) == 1 {
+			c.value(e.Args[0])
+			return
+		}
+		if id, ok := ast.Unparen(e.Fun).(*ast.Ident); ok {
+			if b, ok := c.info.Uses[id].(*types.Builtin); ok && b.Name() == "append" {
+				for _, arg := range e.Args {
+					c.value(arg)
+				}
+				return
+			}
+		}
+		if normalizesTime(c.info, e) {
+			return
+		}
+	}
+
+	if what, at, ok := lossy(tv.Type, "", make(map[types.Type]bool)); ok {
+		detail := detailFloat32
+		if what == "time.Time" {
+			detail = detailTime
+		}
+		if at == "" {
+			c.report(e, fmt.Sprintf("%s %s", what, detail))
+		} else {
+			c.report(e, fmt.Sprintf("%s holds %s at %s, %s", types.TypeString(tv.Type, packageName), what, at, detail))
+		}
+	}
+}
+
+// packageName qualifies type names in messages by package name
+func packageName(pkg *types.Package) string {
+	return pkg.Name()
+}
+
+func (c *checker) report(e ast.Expr, message string) {
+	c.found[e.Pos()] = message
+}
+
+// holdsAny reports whether values of t can hold values of any type: t is
+// interface{} or a map or slice of them
+func holdsAny(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Interface:
+		return u.Empty()
+	case *types.Map:
+		return holdsAny(u.Elem())
+	case *types.Slice:
+		return holdsAny(u.Elem())
+	}
+	return false
+}
+
+// isTime reports whether t is time.Time
+func isTime(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+// normalizesTime reports whether call is t.UTC() or t.In(time.UTC), whose
+// canonical form does not depend on the caller's zone
+func normalizesTime(info *types.Info, call *ast.CallExpr) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || !isTime(info.TypeOf(sel.X)) {
+		return false
+	}
+	switch sel.Sel.Name {
+	case "UTC":
+		return true
+	case "In":
+		arg, ok := ast.Unparen(call.Args[0]).(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		v, ok := info.Uses[arg.Sel].(*types.Var)
+		return ok && v.Pkg() != nil && v.Pkg().Path() == "time" && v.Name() == "UTC"
+	}
+	return false
+}
+
+// lossy reports whether values of t are coerced lossily, as float32 or
+// time.Time, and where inside t. Types with their own MarshalJSON or
+// MarshalText decide their own encoding and are not judged.
+//
+// Returns:
+//   - The lossy type, "float32" or "time.Time"
+//   - Its location inside t, e.g. ".Readings[]", or "" for t itself
+//   - false if t is coerced exactly
+func lossy(t types.Type, at string, seen map[types.Type]bool) (string, string, bool) {
+	if isTime(t) {
+		return "time.Time", at, true
+	}
+	if seen[t] {
+		return "", "", false
+	}
+	seen[t] = true
+	if _, ok := types.Unalias(t).(*types.Named); ok && marshals(t) {
+		return "", "", false
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() == types.Float32 {
+			return "float32", at, true
+		}
+	case *types.Pointer:
+		return lossy(u.Elem(), at, seen)
+	case *types.Slice:
+		return lossy(u.Elem(), at+"[]", seen)
+	case *types.Array:
+		return lossy(u.Elem(), at+"[]", seen)
+	case *types.Map:
+		return lossy(u.Elem(), at+"[]", seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() || strings.HasPrefix(u.Tag(i), `json:"-"`) {
+				continue
+			}
+			if what, where, ok := lossy(f.Type(), at+"."+f.Name(), seen); ok {
+				return what, where, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// marshals reports whether t or *t has its own JSON or text encoding
+func marshals(t types.Type) bool {
+	for _, recv := range []types.Type{t, types.NewPointer(t)} {
+		ms := types.NewMethodSet(recv)
+		for _, name := range []string{"MarshalJSON", "MarshalText"} {
+			if ms.Lookup(nil, name) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
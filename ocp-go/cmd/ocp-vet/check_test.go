@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// wantPattern matches the expectations written into testdata
+var wantPattern = regexp.MustCompile(`// want "([^"]*)"`)
+
+// TestCheckFixture tests every finding in testdata/lossy against the want
+// comments on its lines
+func TestCheckFixture(t *testing.T) {
+	const fixture = "testdata/lossy/lossy.go"
+	src, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[int]string)
+	for i, line := range strings.Split(string(src), "\n") {
+		if m := wantPattern.FindStringSubmatch(line); m != nil {
+			want[i+1] = m[1]
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"./testdata/lossy"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit 1, got %d: %s", code, stderr.String())
+	}
+	got := make(map[int]string)
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		var n, col int
+		rest := strings.TrimPrefix(line, fixture+":")
+		if _, err := fmt.Sscanf(rest, "%d:%d:", &n, &col); err != nil || rest == line {
+			t.Fatalf("Unexpected finding %q", line)
+		}
+		got[n] = line
+	}
+
+	for n, text := range want {
+		if !strings.Contains(got[n], text) {
+			t.Errorf("Line %d: expected a finding containing %q, got %q", n, text, got[n])
+		}
+	}
+	for n, line := range got {
+		if _, ok := want[n]; !ok {
+			t.Errorf("Unexpected finding %s", line)
+		}
+	}
+	t.Logf("✓ %d lossy values found, none in unhashed or normalized values", len(got))
+}
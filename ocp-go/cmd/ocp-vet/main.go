@@ -0,0 +1,284 @@
+// ocp-vet reports values that the canonicalizer would coerce lossily before
+// they are hashed.
+//
+// Usage:
+//
+//	ocp-vet [PACKAGE...]
+//	go vet -vettool=$(which ocp-vet) [PACKAGE...]
+//
+// Two implementations hash the same object identically only if they agree on
+// its canonical form. Some Go values have no exact canonical form: a float32
+// is printed with float32 digits, which read back as a different float64; an
+// integer beyond 2^53 has no exact float64; a time.Time keeps its zone
+// offset, so the same instant hashes differently on nodes in different zones.
+// canonical.CanonicalizeAudited reports these at run time. ocp-vet finds them
+// at compile time, in the code that builds the values, so a downstream
+// repository catches a hash divergence in CI instead of in production.
+//
+// A value is checked where it reaches this module: as an argument to a
+// function or method of an ocp-go package whose parameter holds arbitrary
+// values (SemanticHash, Canonicalize, Ledger.Append, ...), as such a field of
+// an ocp-go struct literal (ContractProposal.Action, ...), or as the result of
+// a ToMap method. Composite literals, conversions to interface{}, and values
+// stored into a variable before it is passed are followed. Integers are judged
+// only when they are constants; CanonicalizeAudited covers the rest.
+//
+// ocp-vet loads PACKAGEs (default ./...) with `go list` and prints each
+// finding as FILE:LINE:COLUMN: MESSAGE. The exit status is 1 if there are
+// findings and 2 if the packages could not be loaded. It also speaks the
+// `go vet -vettool` protocol, so it runs under go vet with go vet's own
+// package selection and build flags.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run checks packages and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch last := args[len(args)-1]; {
+		case last == "-V=full":
+			return version(stdout, stderr)
+		case last == "-flags":
+			// ocp-vet has no flags of its own for go vet to pass through
+			fmt.Fprintln(stdout, "[]")
+			return 0
+		case strings.HasSuffix(last, ".cfg"):
+			// go vet's own flags precede the configuration; none apply
+			return vetUnit(last, stderr)
+		}
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			fmt.Fprintln(stderr, "usage: ocp-vet [PACKAGE...]")
+			return 2
+		}
+	}
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+
+	pkgs, err := load(".", args)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-vet: %v\n", err)
+		return 2
+	}
+	wd, _ := os.Getwd()
+	code := 0
+	for _, pkg := range pkgs {
+		findings, err := pkg.check()
+		if err != nil {
+			fmt.Fprintf(stderr, "ocp-vet: %s: %v\n", pkg.ImportPath, err)
+			return 2
+		}
+		for _, f := range findings {
+			if rel, err := filepath.Rel(wd, f.pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+				f.pos.Filename = rel
+			}
+			fmt.Fprintln(stdout, f)
+			code = 1
+		}
+	}
+	return code
+}
+
+// version prints the version line go vet uses to cache results, naming the
+// executable's own hash so a rebuilt ocp-vet invalidates them
+func version(stdout, stderr io.Writer) int {
+	exe, err := os.Executable()
+	if err == nil {
+		var data []byte
+		if data, err = os.ReadFile(exe); err == nil {
+			fmt.Fprintf(stdout, "ocp-vet version devel buildID=%x\n", sha256.Sum256(data))
+			return 0
+		}
+	}
+	fmt.Fprintf(stderr, "ocp-vet: %v\n", err)
+	return 2
+}
+
+// listedPackage is the subset of `go list -json` output ocp-vet reads
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	Export     string
+	ImportMap  map[string]string
+	DepOnly    bool
+	Error      *struct{ Err string }
+
+	// exports maps every package path to its export data file
+	exports map[string]string
+}
+
+// load lists patterns and their dependencies with export data, built by
+// `go list -export` in dir
+//
+// Returns:
+//   - The packages matched by patterns, in `go list` order
+//   - An error naming the first package `go list` could not load
+func load(dir string, patterns []string) ([]*listedPackage, error) {
+	cmd := exec.Command("go", append([]string{"list", "-e", "-json", "-export", "-deps", "--"}, patterns...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	exports := make(map[string]string)
+	var roots []*listedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		pkg := &listedPackage{exports: exports}
+		if err := dec.Decode(pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %v", err)
+		}
+		if pkg.Error != nil {
+			return nil, fmt.Errorf("%s: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		exports[pkg.ImportPath] = pkg.Export
+		if !pkg.DepOnly {
+			roots = append(roots, pkg)
+		}
+	}
+	return roots, nil
+}
+
+// check type-checks the package from source against its dependencies'
+// export data and returns its findings. Packages using cgo are skipped.
+func (p *listedPackage) check() ([]finding, error) {
+	if len(p.CgoFiles) > 0 {
+		return nil, nil
+	}
+	files := make([]string, len(p.GoFiles))
+	for i, name := range p.GoFiles {
+		files[i] = filepath.Join(p.Dir, name)
+	}
+	return checkFiles(p.ImportPath, files, p.ImportMap, p.exports, "")
+}
+
+// checkFiles parses and type-checks one package and returns its findings
+//
+// Parameters:
+//   - pkgPath: The package's path
+//   - paths: Its Go files
+//   - importMap: Maps import paths in the files to package paths, e.g. for
+//     vendored packages; paths not in it are package paths already
+//   - exports: Maps package paths to export data files
+//   - goVersion: The language version to check against, "" for the latest
+func checkFiles(pkgPath string, paths []string, importMap, exports map[string]string, goVersion string) ([]finding, error) {
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(paths))
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	compiled := importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok || file == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(file)
+	})
+	conf := types.Config{
+		GoVersion: goVersion,
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if mapped, ok := importMap[path]; ok {
+				path = mapped
+			}
+			return compiled.Import(path)
+		}),
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	if _, err := conf.Check(pkgPath, fset, files, info); err != nil {
+		return nil, err
+	}
+	return check(fset, files, info), nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// vetConfig is the subset of the go vet -vettool configuration ocp-vet reads
+type vetConfig struct {
+	ImportPath  string
+	GoVersion   string
+	GoFiles     []string
+	ImportMap   map[string]string
+	PackageFile map[string]string
+	VetxOnly    bool
+	VetxOutput  string
+}
+
+// vetUnit checks the package described by a go vet configuration file,
+// printing findings to stderr as go vet expects
+func vetUnit(path string, stderr io.Writer) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-vet: %v\n", err)
+		return 2
+	}
+	var cfg vetConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		fmt.Fprintf(stderr, "ocp-vet: %s: %v\n", path, err)
+		return 2
+	}
+	// ocp-vet records no facts, but go vet caches the output file
+	if cfg.VetxOutput != "" {
+		if err := os.WriteFile(cfg.VetxOutput, nil, 0o644); err != nil {
+			fmt.Fprintf(stderr, "ocp-vet: %v\n", err)
+			return 2
+		}
+	}
+	if cfg.VetxOnly || len(cfg.GoFiles) == 0 {
+		return 0
+	}
+
+	findings, err := checkFiles(cfg.ImportPath, cfg.GoFiles, cfg.ImportMap, cfg.PackageFile, cfg.GoVersion)
+	if err != nil {
+		fmt.Fprintf(stderr, "ocp-vet: %v\n", err)
+		return 2
+	}
+	for _, f := range findings {
+		fmt.Fprintln(stderr, f)
+	}
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
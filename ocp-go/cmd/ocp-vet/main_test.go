@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVetTool tests running under go vet -vettool
+func TestVetTool(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-flags"}, &stdout, &stderr); code != 0 || strings.TrimSpace(stdout.String()) != "[]" {
+		t.Fatalf("Expected no flags, got %d %q", code, stdout.String())
+	}
+
+	tool := filepath.Join(t.TempDir(), "ocp-vet")
+	if out, err := exec.Command("go", "build", "-o", tool, ".").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build ocp-vet: %v\n%s", err, out)
+	}
+	out, err := exec.Command("go", "vet", "-vettool="+tool, "./testdata/lossy").CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected go vet to fail on lossy values:\n%s", out)
+	}
+	if !strings.Contains(string(out), "lossy.go:48:14: 1152921504606846976: integer beyond 2^53") {
+		t.Fatalf("Expected the fixture's findings, got:\n%s", out)
+	}
+	if out, err := exec.Command("go", "vet", "-vettool="+tool, "../ocp-verify").CombinedOutput(); err != nil {
+		t.Fatalf("Expected go vet to pass on clean code: %v\n%s", err, out)
+	}
+	t.Logf("✓ go vet -vettool reports %d findings", strings.Count(string(out), "lossy.go:"))
+}
+
+// TestModuleIsClean tests that this module hashes no lossy values itself
+func TestModuleIsClean(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"../../..."}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit 0, got %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+	if code := run([]string{"./no-such-package"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit 2 for a missing package, got %d", code)
+	}
+	t.Log("✓ No lossy values in the module's packages")
+}
@@ -0,0 +1,80 @@
+// Package lossy is caller code for ocp-vet's tests. Each line ocp-vet must
+// report carries a want comment naming part of the message.
+package lossy
+
+import (
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+type Reading struct {
+	Sensor string
+	Value  float32
+	hidden float32
+}
+
+type Window struct {
+	Readings []Reading
+	Skipped  float32 `json:"-"`
+}
+
+type Stamp time.Time
+
+func (s Stamp) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + ocp.Timestamp(time.Time(s)) + `"`), nil
+}
+
+type Record struct {
+	At     time.Time
+	Amount int64
+}
+
+// ToMap follows the repository's convention for a type's hashed form
+func (r *Record) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"at":     r.At, // want "time.Time zone is kept"
+		"amount": r.Amount,
+		"when":   ocp.Timestamp(r.At),
+	}
+}
+
+func hashes(ledger *ocp.Ledger, now time.Time, ratio float32, count int64) {
+	ocp.SemanticHash(map[string]interface{}{
+		"ratio":   ratio, // want "float32 printed with float32 digits"
+		"exact":   float64(ratio),
+		"count":   count,
+		"huge":    1 << 60, // want "1152921504606846976: integer beyond 2^53"
+		"small":   1 << 40,
+		"at":      now, // want "time.Time zone is kept"
+		"utc":     now.UTC(),
+		"in":      now.In(time.UTC),
+		"local":   now.In(time.Local), // want "time.Time zone is kept"
+		"stamp":   Stamp(now),
+		"nested":  []interface{}{"a", float32(0.1)}, // want "float32 printed"
+		"reading": Reading{Sensor: "s", Value: 0.1}, // want "lossy.Reading holds float32 at .Value"
+		"window":  &Window{},                        // want "holds float32 at .Readings[].Value"
+	})
+
+	payload := map[string]interface{}{"kind": "observation"}
+	payload["observed_at"] = now     // want "time.Time zone is kept"
+	payload["seq"] = int64(-1 << 62) // want "-4611686018427387904: integer beyond 2^53"
+	payload["ok"] = ocp.Timestamp(now)
+	ledger.Append("observation", payload)
+
+	canonical.CanonicalizeValue(interface{}(ratio))                // want "float32 printed"
+	canonical.CanonicalizeValue(append([]interface{}{}, "x", now)) // want "time.Time zone is kept"
+	canonical.Set("a", ratio, now.UTC())                           // want "float32 printed"
+
+	_ = &ocp.ContractProposal{
+		ProposerAgent: "Claude",
+		Action:        map[string]interface{}{"amount": ratio}, // want "float32 printed"
+		Reasoning:     map[string]interface{}{"confidence": 0.87},
+	}
+}
+
+// unhashed values are not the canonicalizer's business
+func unhashed(now time.Time, ratio float32) map[string]interface{} {
+	return map[string]interface{}{"at": now, "ratio": ratio, "huge": 1 << 60}
+}
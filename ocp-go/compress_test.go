@@ -0,0 +1,45 @@
+package ocp
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestObjectArchiveCompressor tests that compression changes only how blobs
+// are stored
+func TestObjectArchiveCompressor(t *testing.T) {
+	bucket := newMemoryBucket()
+	archive := NewObjectArchive(bucket, "evidence/")
+	legacy, err := archive.Put([]byte("stored before compression"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive.Compressor = GzipCompressor
+	data := bytes.Repeat([]byte("evidence "), 1000)
+	hash, err := archive.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != ContentHash(data) {
+		t.Errorf("Expected the hash of the uncompressed blob, got %s", hash)
+	}
+	stored := bucket.objects[archive.Key(hash)]
+	if len(stored) >= len(data) || bucket.opts[archive.Key(hash)].ContentEncoding != "gzip" {
+		t.Errorf("Expected a gzip object smaller than %d bytes, got %d bytes", len(data), len(stored))
+	}
+	if got, err := archive.Get(hash); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Expected the original blob back, got %v", err)
+	}
+	if _, err := archive.Get(legacy); err != nil {
+		t.Errorf("Expected an uncompressed object to stay readable, got %v", err)
+	}
+
+	corrupted := append([]byte(nil), stored...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	bucket.objects[archive.Key(hash)] = corrupted
+	if _, err := archive.Get(hash); err == nil {
+		t.Error("Expected a corrupted compressed object to be refused")
+	}
+	t.Logf("✓ %d bytes stored as %d, keyed by the uncompressed hash", len(data), len(stored))
+}
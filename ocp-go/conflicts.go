@@ -0,0 +1,113 @@
+// conflicts.go - Conflict detection and scheduling of pending proposals
+//
+// Two pending proposals conflict when they act on the same target and their
+// paths overlap, so ratifying both in the same window would leave the outcome
+// dependent on execution order. Schedule assigns proposals to ratification
+// windows: conflicting proposals are serialized in ascending hash order, which
+// every node computes identically, while unrelated proposals share a window.
+
+package ocp
+
+import (
+	"sort"
+	"strings"
+)
+
+// ActionPaths returns the dotted paths within action.target that a proposal
+// touches, read from action.paths. An empty result means the whole target.
+func ActionPaths(p *ContractProposal) []string {
+	var out []string
+	switch v := p.Action["paths"].(type) {
+	case []string:
+		out = append(out, v...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// Conflicts reports whether a and b act on overlapping parts of the same target.
+// Paths overlap when one equals the other or is a dotted prefix of it, so
+// "article.3" overlaps "article.3.1" but not "article.30".
+func Conflicts(a, b *ContractProposal) bool {
+	targetA, _ := a.Action["target"].(string)
+	targetB, _ := b.Action["target"].(string)
+	if targetA == "" || targetA != targetB {
+		return false
+	}
+
+	pathsA, pathsB := ActionPaths(a), ActionPaths(b)
+	if len(pathsA) == 0 || len(pathsB) == 0 {
+		return true
+	}
+	for _, pa := range pathsA {
+		for _, pb := range pathsB {
+			if pathsOverlap(pa, pb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func pathsOverlap(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+".")
+}
+
+// Window is a set of mutually non-conflicting proposals that may ratify in parallel
+type Window struct {
+	Hashes    []string
+	Proposals []*ContractProposal
+}
+
+// Schedule assigns proposals to consecutive ratification windows. A proposal
+// is placed in the window after the latest window holding a conflicting
+// proposal with a lower hash. Proposals with identical hashes are scheduled once.
+//
+// Returns:
+//   - Windows in ratification order; within a window, proposals are sorted by hash
+func Schedule(proposals []*ContractProposal) ([]Window, error) {
+	type item struct {
+		hash     string
+		proposal *ContractProposal
+		window   int
+	}
+
+	seen := make(map[string]bool)
+	items := make([]*item, 0, len(proposals))
+	for _, p := range proposals {
+		hash, err := p.GetHash()
+		if err != nil {
+			return nil, err
+		}
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		items = append(items, &item{hash: hash, proposal: p})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].hash < items[j].hash })
+
+	var windows []Window
+	for i, it := range items {
+		for _, earlier := range items[:i] {
+			if earlier.window >= it.window && Conflicts(earlier.proposal, it.proposal) {
+				it.window = earlier.window + 1
+			}
+		}
+		for len(windows) <= it.window {
+			windows = append(windows, Window{})
+		}
+		w := &windows[it.window]
+		w.Hashes = append(w.Hashes, it.hash)
+		w.Proposals = append(w.Proposals, it.proposal)
+	}
+	return windows, nil
+}
@@ -0,0 +1,95 @@
+package ocp
+
+import (
+	"fmt"
+	"testing"
+)
+
+// targetProposal returns a proposal acting on target at the given paths
+func targetProposal(id, target string, paths ...string) *ContractProposal {
+	p := testProposal()
+	p.ID = id
+	p.Action = map[string]interface{}{"target": target, "operation": "modify"}
+	if len(paths) > 0 {
+		list := make([]interface{}, len(paths))
+		for i, path := range paths {
+			list[i] = path
+		}
+		p.Action["paths"] = list
+	}
+	return p
+}
+
+// TestConflicts tests target and path overlap rules
+func TestConflicts(t *testing.T) {
+	cases := []struct {
+		a, b     *ContractProposal
+		conflict bool
+	}{
+		{targetProposal("1", "constitution"), targetProposal("2", "constitution"), true},
+		{targetProposal("1", "constitution"), targetProposal("2", "agent-claude"), false},
+		{targetProposal("1", "constitution", "article.3"), targetProposal("2", "constitution", "article.3.1"), true},
+		{targetProposal("1", "constitution", "article.3"), targetProposal("2", "constitution", "article.30"), false},
+		{targetProposal("1", "constitution", "article.3"), targetProposal("2", "constitution", "article.4", "article.3"), true},
+		{targetProposal("1", "constitution", "article.3"), targetProposal("2", "constitution"), true},
+	}
+
+	for i, tc := range cases {
+		if got := Conflicts(tc.a, tc.b); got != tc.conflict {
+			t.Errorf("Case %d: expected conflict=%v, got %v", i, tc.conflict, got)
+		}
+		if Conflicts(tc.b, tc.a) != Conflicts(tc.a, tc.b) {
+			t.Errorf("Case %d: Conflicts should be symmetric", i)
+		}
+	}
+
+	t.Logf("✓ %d conflict cases", len(cases))
+}
+
+// TestScheduleSerializesConflicts tests window assignment by hash order
+func TestScheduleSerializesConflicts(t *testing.T) {
+	a := targetProposal("a", "constitution", "article.3")
+	b := targetProposal("b", "constitution", "article.3.2")
+	c := targetProposal("c", "constitution", "article.3.1")
+	unrelated := targetProposal("d", "agent-claude")
+
+	windows, err := Schedule([]*ContractProposal{unrelated, c, b, a, a})
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	// a conflicts with b and c; b and c do not conflict with each other
+	windowOf := map[string]int{}
+	for i, w := range windows {
+		for _, p := range w.Proposals {
+			windowOf[p.ID] += i + 1
+		}
+	}
+	if len(windowOf) != 4 {
+		t.Fatalf("Each proposal should be scheduled exactly once: %v", windowOf)
+	}
+	if windowOf["d"] != 1 {
+		t.Errorf("Unrelated proposal should ratify in the first window")
+	}
+	for _, id := range []string{"b", "c"} {
+		if windowOf[id] == windowOf["a"] {
+			t.Errorf("%s conflicts with a and must not share its window", id)
+		}
+	}
+
+	// The result does not depend on input order
+	again, _ := Schedule([]*ContractProposal{a, b, c, unrelated})
+	if fmt.Sprint(hashesOf(again)) != fmt.Sprint(hashesOf(windows)) {
+		t.Errorf("Schedule should not depend on input order")
+	}
+
+	t.Logf("✓ %d windows: %v", len(windows), windowOf)
+}
+
+func hashesOf(windows []Window) [][]string {
+	out := make([][]string, len(windows))
+	for i, w := range windows {
+		out[i] = w.Hashes
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+// constitution.go - Ratified constitution tracking and drift detection
+//
+// The ratified constitution is identified on the ledger by hash: genesis fixes
+// the first one and each quorum-approved amendment records its successor as a
+// constitution entry. Operators edit a local copy of the document, and an edit
+// that never went through ratification silently diverges from what the
+// network enforces. CheckConstitution compares a local file against the
+// ratified hash and, when the ratified text is archived, lists what changed.
+//
+// JSON documents (including YAML written in JSON syntax) are compared in
+// canonical form, so reformatting is not drift; any other document is compared
+// byte for byte, exactly as genesis hashes it.
+
+package ocp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LedgerKindConstitution is the ledger entry kind recording a ratified constitution
+const LedgerKindConstitution = "constitution"
+
+// ConstitutionHash returns the hash under which doc is ratified: the semantic
+// hash of its canonical form for JSON documents, and the content hash of its
+// bytes otherwise
+func ConstitutionHash(doc []byte) string {
+	return ContentHash(normalizeConstitution(doc))
+}
+
+// normalizeConstitution returns the canonical form of a JSON document, or doc
+func normalizeConstitution(doc []byte) []byte {
+	if form, err := CanonicalizeJSON(doc); err == nil {
+		return []byte(form)
+	}
+	return doc
+}
+
+// RatifyConstitution records doc as the ratified constitution
+//
+// Parameters:
+//   - ledger: Ledger receiving the constitution entry
+//   - archive: Archive storing the document, so later drift reports can diff it
+//   - quorum: Quorum that must approve the amendment
+//   - doc: New constitution document
+//   - sigs: Signatures over ConstitutionHash(doc) under ContextConstitution
+//
+// Returns:
+//   - Ratified constitution hash
+func RatifyConstitution(ledger *Ledger, archive Archive, quorum *Quorum, doc []byte, sigs []Signature) (string, error) {
+	hash := ConstitutionHash(doc)
+	signers, err := quorum.Verify(ContextConstitution, hash, sigs)
+	if err != nil {
+		return "", err
+	}
+	if archive != nil {
+		if _, err := archive.Put(normalizeConstitution(doc)); err != nil {
+			return "", err
+		}
+	}
+	if _, err := ledger.Append(LedgerKindConstitution, map[string]interface{}{
+		"constitution_hash": hash,
+		"signers":           stringList(signers),
+		"signatures":        signatureList(sigs),
+	}); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// RatifiedConstitution returns the hash of the constitution in force after
+// entries and the height at which it was recorded
+func RatifiedConstitution(entries []LedgerEntry) (string, uint64, error) {
+	var hash string
+	var height uint64
+	for _, e := range entries {
+		switch e.Kind {
+		case LedgerKindGenesis:
+			if state, ok := e.Payload["state"].(map[string]interface{}); ok {
+				hash, height = payloadString(state, "constitution_hash"), e.Height
+			}
+		case LedgerKindConstitution:
+			hash, height = payloadString(e.Payload, "constitution_hash"), e.Height
+		}
+	}
+	if hash == "" {
+		return "", 0, NewConstitutionalError("ledger records no constitution")
+	}
+	return hash, height, nil
+}
+
+// ConstitutionDrift compares a local constitution with the ratified one
+type ConstitutionDrift struct {
+	LocalHash      string
+	RatifiedHash   string
+	RatifiedHeight uint64
+	// Differences lists the changes from the ratified document to the local
+	// one; empty if the ratified document was not available to diff
+	Differences []string
+}
+
+// Drifted reports whether the local document differs from the ratified one
+func (d *ConstitutionDrift) Drifted() bool {
+	return d.LocalHash != d.RatifiedHash
+}
+
+// CheckConstitution compares local against the constitution ratified in entries
+//
+// Parameters:
+//   - local: Contents of the operator's constitution file
+//   - entries: Ledger entries from genesis
+//   - archive: Archive holding ratified documents, or nil to skip the diff
+//
+// Returns:
+//   - Drift report; Drifted is false when local matches the ratified hash
+func CheckConstitution(local []byte, entries []LedgerEntry, archive Archive) (*ConstitutionDrift, error) {
+	ratified, height, err := RatifiedConstitution(entries)
+	if err != nil {
+		return nil, err
+	}
+	drift := &ConstitutionDrift{LocalHash: ConstitutionHash(local), RatifiedHash: ratified, RatifiedHeight: height}
+	if ContentHash(local) == ratified {
+		// Genesis hashes the exact bytes of JSON documents too
+		drift.LocalHash = ratified
+	}
+	if !drift.Drifted() || archive == nil {
+		return drift, nil
+	}
+
+	old, err := archive.Get(ratified)
+	if err == ErrNotArchived {
+		return drift, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	drift.Differences, err = constitutionDiff(old, local)
+	return drift, err
+}
+
+// constitutionDiff diffs JSON documents structurally and others line by line
+func constitutionDiff(old, new []byte) ([]string, error) {
+	oldObj, errOld := DecodeStrict(old)
+	newObj, errNew := DecodeStrict(new)
+	if errOld == nil && errNew == nil {
+		diffs, err := CanonicalDiff(oldObj, newObj)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(diffs))
+		for i, d := range diffs {
+			out[i] = d.String()
+		}
+		return out, nil
+	}
+	return lineDiff(strings.Split(string(old), "\n"), strings.Split(string(new), "\n")), nil
+}
+
+// lineDiff lists removed ("-") and added ("+") lines using a longest common
+// subsequence, numbering lines from 1 in their own document
+func lineDiff(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, fmt.Sprintf("- %d: %s", i+1, a[i]))
+			i++
+		default:
+			out = append(out, fmt.Sprintf("+ %d: %s", j+1, b[j]))
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestCheckConstitution tests drift detection against genesis and ratified amendments
+func TestCheckConstitution(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger, err := NewLedgerFromGenesis(testGenesis(t))
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	quorum, privs := testQuorum(t)
+	archive := NewMemoryArchive()
+
+	drift, err := CheckConstitution([]byte("# Constitution v2.1\n"), ledger.Entries(0), archive)
+	if err != nil || drift.Drifted() {
+		t.Fatalf("Genesis constitution should not drift: %+v %v", drift, err)
+	}
+	if drift, _ := CheckConstitution([]byte("# Constitution v2.1 (local)\n"), ledger.Entries(0), archive); !drift.Drifted() || len(drift.Differences) != 0 {
+		t.Errorf("A local edit should drift, without a diff when the ratified text is not archived: %+v", drift)
+	}
+
+	amended := []byte(`{"articles": {"I": "Sovereignty", "II": "Assembly"}, "version": "2.2"}`)
+	if _, err := RatifyConstitution(ledger, archive, quorum, amended, nil); err == nil {
+		t.Errorf("Ratification without a quorum should fail")
+	}
+	sigs := signAll(t, ContextConstitution, ConstitutionHash(amended), privs, "Claude", "Gemini")
+	hash, err := RatifyConstitution(ledger, archive, quorum, amended, sigs)
+	if err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+
+	reformatted := []byte("{\n  \"version\": \"2.2\",\n  \"articles\": {\"II\": \"Assembly\", \"I\": \"Sovereignty\"}\n}\n")
+	if drift, _ := CheckConstitution(reformatted, ledger.Entries(0), archive); drift.Drifted() || drift.RatifiedHash != hash {
+		t.Errorf("Reformatting should not drift: %+v", drift)
+	}
+
+	edited := []byte(`{"articles": {"I": "Sovereignty", "II": "Senate"}, "version": "2.2"}`)
+	drift, err = CheckConstitution(edited, ledger.Entries(0), archive)
+	if err != nil || !drift.Drifted() {
+		t.Fatalf("An unratified edit should drift: %v", err)
+	}
+	if len(drift.Differences) != 1 || drift.Differences[0] != `~ articles.II: "Assembly" -> "Senate"` {
+		t.Errorf("Unexpected differences: %v", drift.Differences)
+	}
+	t.Logf("✓ Drift reported: %v", drift.Differences)
+}
+
+// TestLineDiff tests the line diff used for non-JSON constitutions
+func TestLineDiff(t *testing.T) {
+	got := lineDiff([]string{"# Constitution", "Article I", "Article II"}, []string{"# Constitution", "Article I (amended)", "Article II", "Article III"})
+	want := []string{"- 2: Article I", "+ 2: Article I (amended)", "+ 4: Article III"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Line %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+	t.Log("✓ Line diff lists removed and added lines")
+}
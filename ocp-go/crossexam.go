@@ -0,0 +1,185 @@
+// crossexam.go - Structured cross-examination of challenged proposals
+//
+// A CrossExamination ties a challenge to the exact parts of the original
+// proposal it disputes. Each finding names a path into the proposal and carries
+// the hash of the value found there, so an adjudicator can confirm that the
+// challenger examined the content that was actually submitted, and a finding
+// cannot silently drift to a different value if the record is replayed later.
+//
+// Paths are dotted keys into the proposal's canonical form, with array elements
+// addressed by index: "evidence.0.pointer", "reasoning.confidence".
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Finding is a challenger's claim about one path of a proposal
+type Finding struct {
+	Path     string `json:"path"`
+	PathHash string `json:"path_hash"`
+	Claim    string `json:"claim"`
+}
+
+// ToMap converts a Finding to a map for canonicalization
+func (f Finding) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"path":      f.Path,
+		"path_hash": f.PathHash,
+		"claim":     f.Claim,
+	}
+}
+
+// CrossExamination links a challenge to findings on the challenged proposal
+type CrossExamination struct {
+	ChallengeHash string    `json:"challenge_hash"`
+	ProposalHash  string    `json:"proposal_hash"`
+	Examiner      string    `json:"examiner"`
+	Findings      []Finding `json:"findings"`
+}
+
+// ToMap converts a CrossExamination to a map for canonicalization
+func (x *CrossExamination) ToMap() map[string]interface{} {
+	findings := make([]interface{}, len(x.Findings))
+	for i, f := range x.Findings {
+		findings[i] = f.ToMap()
+	}
+	return map[string]interface{}{
+		"challenge_hash": x.ChallengeHash,
+		"proposal_hash":  x.ProposalHash,
+		"examiner":       x.Examiner,
+		"findings":       findings,
+	}
+}
+
+// Hash returns the semantic hash of the cross-examination
+func (x *CrossExamination) Hash() (string, error) {
+	return SemanticHash(x.ToMap())
+}
+
+// NewCrossExamination records findings against a challenged proposal. Claims
+// are given by path; path hashes are computed from p and findings are sorted
+// by path.
+//
+// Parameters:
+//   - challengeHash: Hash identifying the challenge, e.g. its bond entry hash
+//   - examiner: Agent making the findings
+//   - p: The challenged proposal
+//   - claims: Claim text by proposal path
+//
+// Returns:
+//   - Validated cross-examination
+func NewCrossExamination(challengeHash, examiner string, p *ContractProposal, claims map[string]string) (*CrossExamination, error) {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	x := &CrossExamination{
+		ChallengeHash: challengeHash,
+		ProposalHash:  proposalHash,
+		Examiner:      examiner,
+	}
+	for path, claim := range claims {
+		hash, err := ProposalPathHash(p, path)
+		if err != nil {
+			return nil, err
+		}
+		x.Findings = append(x.Findings, Finding{Path: path, PathHash: hash, Claim: claim})
+	}
+	sort.Slice(x.Findings, func(i, j int) bool { return x.Findings[i].Path < x.Findings[j].Path })
+	return x, x.Validate(p)
+}
+
+// Validate checks that x examines p: the proposal hash matches, there is at
+// least one finding, paths are unique and sorted, and every path exists in p
+// with the recorded hash.
+func (x *CrossExamination) Validate(p *ContractProposal) error {
+	if x.ChallengeHash == "" || x.Examiner == "" {
+		return NewConstitutionalError("cross-examination requires a challenge hash and an examiner")
+	}
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return err
+	}
+	if x.ProposalHash != proposalHash {
+		return NewConstitutionalError(fmt.Sprintf("cross-examination is of proposal %s, not %s", x.ProposalHash, proposalHash))
+	}
+	if len(x.Findings) == 0 {
+		return NewConstitutionalError("cross-examination has no findings")
+	}
+
+	doc, err := proposalDocument(p)
+	if err != nil {
+		return err
+	}
+	for i, f := range x.Findings {
+		if i > 0 && x.Findings[i-1].Path >= f.Path {
+			return NewConstitutionalError(fmt.Sprintf("finding paths must be unique and sorted at %q", f.Path))
+		}
+		value, err := resolvePath(doc, f.Path)
+		if err != nil {
+			return err
+		}
+		hash, err := ValueHash(value)
+		if err != nil {
+			return err
+		}
+		if hash != f.PathHash {
+			return NewConstitutionalError(fmt.Sprintf("path %q hashes to %s, finding records %s", f.Path, hash, f.PathHash))
+		}
+	}
+	return nil
+}
+
+// ProposalPathHash returns the hash of the value at path in p's canonical form
+func ProposalPathHash(p *ContractProposal, path string) (string, error) {
+	doc, err := proposalDocument(p)
+	if err != nil {
+		return "", err
+	}
+	value, err := resolvePath(doc, path)
+	if err != nil {
+		return "", err
+	}
+	return ValueHash(value)
+}
+
+// proposalDocument returns p as it would be received: canonical JSON, strictly
+// decoded, so paths resolve the same way for every node
+func proposalDocument(p *ContractProposal) (map[string]interface{}, error) {
+	form, err := CanonicalizeValue(p.ToMap())
+	if err != nil {
+		return nil, err
+	}
+	return DecodeStrict([]byte(form))
+}
+
+func resolvePath(doc map[string]interface{}, path string) (interface{}, error) {
+	if path == "" {
+		return nil, NewConstitutionalError("empty path")
+	}
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, NewConstitutionalError(fmt.Sprintf("path %q does not exist in the proposal", path))
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) || strconv.Itoa(i) != part {
+				return nil, NewConstitutionalError(fmt.Sprintf("path %q does not exist in the proposal", path))
+			}
+			current = v[i]
+		default:
+			return nil, NewConstitutionalError(fmt.Sprintf("path %q does not exist in the proposal", path))
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,62 @@
+package ocp
+
+import "testing"
+
+// TestCrossExaminationRecord tests path hashes and canonical serialization
+func TestCrossExaminationRecord(t *testing.T) {
+	proposal := testProposal()
+	x, err := NewCrossExamination("bond-hash", "Gemini", proposal, map[string]string{
+		"reasoning.confidence": "Confidence is not supported by the cited record",
+		"evidence.0.pointer":   "Archive entry does not contain the dispute",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cross-examination: %v", err)
+	}
+
+	if x.Findings[0].Path != "evidence.0.pointer" {
+		t.Errorf("Findings should be sorted by path, got %s first", x.Findings[0].Path)
+	}
+	expected, _ := ValueHash("sha256:abc123def456")
+	if x.Findings[0].PathHash != expected {
+		t.Errorf("Path hash should be the hash of the value at the path")
+	}
+
+	hash, err := x.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	again, _ := NewCrossExamination("bond-hash", "Gemini", testProposal(), map[string]string{
+		"evidence.0.pointer":   "Archive entry does not contain the dispute",
+		"reasoning.confidence": "Confidence is not supported by the cited record",
+	})
+	if h, _ := again.Hash(); h != hash {
+		t.Errorf("Cross-examination hash should not depend on claim order")
+	}
+
+	t.Logf("✓ Cross-examination %s", hash)
+}
+
+// TestCrossExaminationValidate tests rejection of paths and hashes that do not match
+func TestCrossExaminationValidate(t *testing.T) {
+	proposal := testProposal()
+
+	for _, path := range []string{"evidence.1.pointer", "evidence.01.pointer", "reasoning.missing", "proposer_agent.x", ""} {
+		if _, err := NewCrossExamination("bond-hash", "Gemini", proposal, map[string]string{path: "claim"}); err == nil {
+			t.Errorf("Path %q should be rejected", path)
+		}
+	}
+
+	x, _ := NewCrossExamination("bond-hash", "Gemini", proposal, map[string]string{"action.target": "Wrong article"})
+	changed := testProposal()
+	changed.Action["target"] = "amendment-article-4"
+	if err := x.Validate(changed); err == nil {
+		t.Errorf("Cross-examination of a different proposal should be rejected")
+	}
+
+	x.Findings[0].PathHash = "0000"
+	if err := x.Validate(proposal); err == nil {
+		t.Errorf("Mismatched path hash should be rejected")
+	}
+
+	t.Logf("✓ Invalid cross-examinations rejected")
+}
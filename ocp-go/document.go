@@ -0,0 +1,396 @@
+// document.go - Editable documents with lazily recomputed hashes
+//
+// Hashing a constitution canonicalizes the whole document, which an editor
+// repeating after every keystroke pays for in proportion to the document's
+// size. A Document keeps the document as a tree that caches the canonical form
+// and hash of every subtree. An edit invalidates only the subtrees on the path
+// to it, and Hash rebuilds those from the cached forms of their untouched
+// members, so the cost of an edit follows its depth and the width of the
+// objects along its path rather than the size of the document.
+//
+// Hashes equal those of the plain document: Hash is SemanticHash of Map, and
+// SubtreeHash is the hash a PathIndex records for the same subtree. Paths use
+// the PathIndex syntax, "articles[2].text", but address arrays in the order
+// they were written, since edits are made to the document as written.
+
+package ocp
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// docNode is one subtree of a Document
+type docNode struct {
+	// object or array holds the members of a container; value holds anything else
+	object map[string]*docNode
+	array  []*docNode
+	value  interface{}
+	// form and hash are the cached canonical form and hash, valid unless stale
+	form  string
+	hash  string
+	stale bool
+}
+
+// newDocNode builds the tree for v, with every cache stale
+func newDocNode(v interface{}) *docNode {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n := &docNode{object: make(map[string]*docNode, len(v)), stale: true}
+		for k, child := range v {
+			n.object[k] = newDocNode(child)
+		}
+		return n
+	case []interface{}:
+		n := &docNode{array: make([]*docNode, len(v)), stale: true}
+		for i, child := range v {
+			n.array[i] = newDocNode(child)
+		}
+		return n
+	default:
+		return &docNode{value: v, stale: true}
+	}
+}
+
+// plain returns the subtree as decoded JSON
+func (n *docNode) plain() interface{} {
+	switch {
+	case n.object != nil:
+		m := make(map[string]interface{}, len(n.object))
+		for k, child := range n.object {
+			m[k] = child.plain()
+		}
+		return m
+	case n.array != nil:
+		arr := make([]interface{}, len(n.array))
+		for i, child := range n.array {
+			arr[i] = child.plain()
+		}
+		return arr
+	default:
+		return n.value
+	}
+}
+
+// container reports whether the node holds an object or array
+func (n *docNode) container() bool {
+	return n.object != nil || n.array != nil
+}
+
+// canonical returns the node's canonical form, rebuilding stale subtrees
+func (n *docNode) canonical() (string, error) {
+	if !n.stale {
+		return n.form, nil
+	}
+	form, err := n.build()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(form))
+	n.form, n.hash, n.stale = form, fmt.Sprintf("%x", sum), false
+	return form, nil
+}
+
+// build assembles the canonical form from the members' forms where the
+// canonical form of a member does not depend on its siblings
+func (n *docNode) build() (string, error) {
+	switch {
+	case n.object != nil:
+		// A set orders its elements by hash, so it is canonicalized whole
+		if _, isSet := n.object[canonical.SetKey]; isSet && len(n.object) == 1 {
+			return canonical.CanonicalizeValue(n.plain())
+		}
+		keys := make([]string, 0, len(n.object))
+		for k := range n.object {
+			keys = append(keys, k)
+		}
+		canonical.Default.SortKeys(keys)
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, err := canonical.CanonicalizeValue(k)
+			if err != nil {
+				return "", err
+			}
+			member, err := n.object[k].canonical()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(key)
+			b.WriteByte(':')
+			b.WriteString(member)
+		}
+		b.WriteByte('}')
+		return b.String(), nil
+
+	case n.array != nil:
+		// Arrays of primitives may be sorted, so they are canonicalized whole
+		composite := false
+		for _, elem := range n.array {
+			composite = composite || elem.container()
+		}
+		if !composite {
+			return canonical.CanonicalizeValue(n.plain())
+		}
+		var b strings.Builder
+		b.WriteByte('[')
+		for i, elem := range n.array {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			form, err := elem.canonical()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(form)
+		}
+		b.WriteByte(']')
+		return b.String(), nil
+
+	default:
+		return canonical.CanonicalizeValue(n.value)
+	}
+}
+
+// Document is an editable JSON document, such as a constitution, whose
+// hashes are recomputed only where it changed
+type Document struct {
+	root  *docNode
+	dirty map[string]bool
+}
+
+// NewDocument creates a Document holding a copy of doc
+func NewDocument(doc map[string]interface{}) *Document {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	return &Document{root: newDocNode(canonical.DeepCopy(doc)), dirty: make(map[string]bool)}
+}
+
+// ParseDocument creates a Document from JSON, decoded strictly
+func ParseDocument(data []byte) (*Document, error) {
+	doc, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewDocument(doc), nil
+}
+
+// Map returns a copy of the current document
+func (d *Document) Map() map[string]interface{} {
+	return d.root.plain().(map[string]interface{})
+}
+
+// Get returns the value at path
+func (d *Document) Get(path string) (interface{}, bool) {
+	n, err := d.lookup(path)
+	if err != nil {
+		return nil, false
+	}
+	return n.plain(), true
+}
+
+// Set replaces the value at path, adding the member if path names a new key
+// of an existing object
+//
+// Returns:
+//   - ConstitutionalError if path is invalid or its parent does not exist
+func (d *Document) Set(path string, value interface{}) error {
+	parent, last, err := d.parentOf(path)
+	if err != nil {
+		return err
+	}
+	node := newDocNode(canonical.DeepCopy(value))
+	switch {
+	case last.index >= 0:
+		if parent.array == nil || last.index >= len(parent.array) {
+			return NewConstitutionalError(fmt.Sprintf("document has no array element at %q", path))
+		}
+		parent.array[last.index] = node
+	default:
+		if parent.object == nil {
+			return NewConstitutionalError(fmt.Sprintf("document has no object to hold %q", path))
+		}
+		parent.object[last.key] = node
+	}
+	d.touch(path)
+	return nil
+}
+
+// Delete removes the member or array element at path
+//
+// Returns:
+//   - ConstitutionalError if nothing is at path
+func (d *Document) Delete(path string) error {
+	parent, last, err := d.parentOf(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case last.index >= 0:
+		if parent.array == nil || last.index >= len(parent.array) {
+			return NewConstitutionalError(fmt.Sprintf("document has no array element at %q", path))
+		}
+		parent.array = append(parent.array[:last.index:last.index], parent.array[last.index+1:]...)
+	default:
+		if _, ok := parent.object[last.key]; !ok {
+			return NewConstitutionalError(fmt.Sprintf("document has no member at %q", path))
+		}
+		delete(parent.object, last.key)
+	}
+	d.touch(path)
+	return nil
+}
+
+// Dirty returns the paths edited since the last Hash, sorted
+func (d *Document) Dirty() []string {
+	out := make([]string, 0, len(d.dirty))
+	for path := range d.dirty {
+		out = append(out, path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Canonical returns the canonical form of the document
+func (d *Document) Canonical() (string, error) {
+	return d.root.canonical()
+}
+
+// Hash returns the semantic hash of the document, recomputing only the
+// subtrees edited since the last call
+func (d *Document) Hash() (string, error) {
+	if _, err := d.root.canonical(); err != nil {
+		return "", err
+	}
+	d.dirty = make(map[string]bool)
+	return d.root.hash, nil
+}
+
+// SubtreeHash returns the hash of the value at path, as a PathIndex of the
+// document would record it
+func (d *Document) SubtreeHash(path string) (string, error) {
+	n, err := d.lookup(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := n.canonical(); err != nil {
+		return "", err
+	}
+	return n.hash, nil
+}
+
+// touch records an edit at path and marks every subtree containing it stale
+func (d *Document) touch(path string) {
+	d.dirty[path] = true
+	segs, _ := parseDocPath(path)
+	n := d.root
+	n.stale = true
+	for _, seg := range segs[:len(segs)-1] {
+		n = n.child(seg)
+		n.stale = true
+	}
+}
+
+// lookup returns the node at path
+func (d *Document) lookup(path string) (*docNode, error) {
+	segs, err := parseDocPath(path)
+	if err != nil {
+		return nil, err
+	}
+	n := d.root
+	for _, seg := range segs {
+		if n = n.child(seg); n == nil {
+			return nil, NewConstitutionalError(fmt.Sprintf("document has nothing at %q", path))
+		}
+	}
+	return n, nil
+}
+
+// parentOf returns the node holding the last segment of path, and that segment
+func (d *Document) parentOf(path string) (*docNode, docPathSegment, error) {
+	segs, err := parseDocPath(path)
+	if err != nil {
+		return nil, docPathSegment{}, err
+	}
+	if len(segs) == 0 {
+		return nil, docPathSegment{}, NewConstitutionalError("the document root cannot be replaced or deleted")
+	}
+	n := d.root
+	for _, seg := range segs[:len(segs)-1] {
+		if n = n.child(seg); n == nil {
+			return nil, docPathSegment{}, NewConstitutionalError(fmt.Sprintf("document has no parent for %q", path))
+		}
+	}
+	return n, segs[len(segs)-1], nil
+}
+
+// child returns the member or element seg names, or nil
+func (n *docNode) child(seg docPathSegment) *docNode {
+	if seg.index >= 0 {
+		if seg.index < len(n.array) {
+			return n.array[seg.index]
+		}
+		return nil
+	}
+	return n.object[seg.key]
+}
+
+// docPathSegment is an object key, or an array index when index >= 0
+type docPathSegment struct {
+	key   string
+	index int
+}
+
+// parseDocPath splits a PathIndex-style path into segments; "" is the root
+func parseDocPath(path string) ([]docPathSegment, error) {
+	var segs []docPathSegment
+	invalid := func() ([]docPathSegment, error) {
+		return nil, NewConstitutionalError(fmt.Sprintf("invalid document path %q", path))
+	}
+	i := 0
+	for i < len(path) {
+		if path[i] == '[' {
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return invalid()
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 {
+				return invalid()
+			}
+			segs = append(segs, docPathSegment{index: index})
+			i += end + 1
+			continue
+		}
+		if len(segs) > 0 {
+			if path[i] != '.' {
+				return invalid()
+			}
+			i++
+		}
+		var key strings.Builder
+		for i < len(path) && path[i] != '.' && path[i] != '[' {
+			if path[i] == '\\' {
+				if i+1 == len(path) {
+					return invalid()
+				}
+				i++
+			} else if path[i] == ']' {
+				return invalid()
+			}
+			key.WriteByte(path[i])
+			i++
+		}
+		segs = append(segs, docPathSegment{key: key.String(), index: -1})
+	}
+	return segs, nil
+}
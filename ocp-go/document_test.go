@@ -0,0 +1,134 @@
+package ocp
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// testDocument returns a constitution-shaped document with n articles
+func testDocument(n int) map[string]interface{} {
+	articles := make([]interface{}, n)
+	for i := range articles {
+		articles[i] = map[string]interface{}{
+			"title":   fmt.Sprintf("Article %d", i),
+			"clauses": []interface{}{"b", "a", fmt.Sprintf("clause %d", i)},
+			"meta":    map[string]interface{}{"ratified": i%2 == 0, "weight": float64(i)},
+		}
+	}
+	return map[string]interface{}{
+		"preamble": "We the agents",
+		"articles": articles,
+		"signers":  map[string]interface{}{"_set": []interface{}{"Gemini", "Claude"}},
+	}
+}
+
+// TestDocumentHash tests that lazy hashes match hashing the plain document
+func TestDocumentHash(t *testing.T) {
+	doc := NewDocument(testDocument(20))
+	rng := rand.New(rand.NewSource(1))
+	for step := 0; step < 200; step++ {
+		i := rng.Intn(20)
+		switch rng.Intn(5) {
+		case 0:
+			doc.Set(fmt.Sprintf("articles[%d].title", i), fmt.Sprintf("edit %d", step))
+		case 1:
+			doc.Set(fmt.Sprintf("articles[%d].clauses[1]", i), fmt.Sprintf("z%d", step))
+		case 2:
+			doc.Set(fmt.Sprintf("articles[%d].meta.weight", i), float64(step)/4)
+		case 3:
+			doc.Set(fmt.Sprintf("articles[%d].meta.note%d", i, step%3), nil)
+		case 4:
+			doc.Delete(fmt.Sprintf("articles[%d].meta.note%d", i, step%3))
+		}
+		if step%7 != 0 {
+			continue
+		}
+		got, err := doc.Hash()
+		if err != nil {
+			t.Fatalf("Failed to hash: %v", err)
+		}
+		want, _ := SemanticHash(doc.Map())
+		if got != want {
+			t.Fatalf("Step %d: lazy hash %s, full hash %s", step, got, want)
+		}
+	}
+
+	// Subtree hashes match a PathIndex where positions are not reordered
+	index, err := hashing.BuildPathIndex(doc.Map())
+	if err != nil {
+		t.Fatalf("Failed to index: %v", err)
+	}
+	for _, path := range []string{"", "preamble", "articles", "articles[3]", "articles[3].meta.weight", "signers"} {
+		if got, err := doc.SubtreeHash(path); err != nil || got != index[path] {
+			t.Errorf("Subtree %q: got %s, %v, want %s", path, got, err, index[path])
+		}
+	}
+
+	t.Log("✓ Lazy hashes match full canonicalization across 200 edits")
+}
+
+// TestDocumentDirtyTracking tests that an edit leaves other subtrees cached
+func TestDocumentDirtyTracking(t *testing.T) {
+	doc := NewDocument(testDocument(50))
+	if _, err := doc.Hash(); err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if err := doc.Set("articles[7].title", "Amended"); err != nil {
+		t.Fatalf("Failed to set: %v", err)
+	}
+	if err := doc.Delete("articles[9].meta.ratified"); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if dirty := doc.Dirty(); len(dirty) != 2 || dirty[0] != "articles[7].title" {
+		t.Errorf("Unexpected dirty paths %v", dirty)
+	}
+
+	articles := doc.root.object["articles"]
+	if !doc.root.stale || !articles.stale || !articles.array[7].stale || !articles.array[9].object["meta"].stale {
+		t.Errorf("Edited paths were not invalidated")
+	}
+	stale := 0
+	for _, a := range articles.array {
+		if a.stale {
+			stale++
+		}
+	}
+	if stale != 2 || doc.root.object["preamble"].stale || articles.array[7].object["meta"].stale {
+		t.Errorf("Expected only edited subtrees to be stale, %d articles are", stale)
+	}
+	if _, err := doc.Hash(); err != nil || len(doc.Dirty()) != 0 {
+		t.Errorf("Expected Hash to clear dirty paths, got %v", err)
+	}
+
+	t.Logf("✓ One edit invalidates %d of %d articles", stale, len(articles.array))
+}
+
+// TestDocumentPaths tests path parsing and invalid edits
+func TestDocumentPaths(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"a.b": {"c": [1, {"d": true}]}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if v, ok := doc.Get(`a\.b.c[1].d`); !ok || v != true {
+		t.Errorf("Unexpected value %v at escaped path", v)
+	}
+	for _, path := range []string{"", "missing.x", `a\.b.c[5]`, `a\.b.c[x]`, "a[", `a\.b.c[1].d.e`} {
+		if err := doc.Set(path, 1); err == nil {
+			t.Errorf("Expected Set(%q) to fail", path)
+		}
+	}
+	if err := doc.Delete(`a\.b.c[0]`); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if v, _ := doc.Get(`a\.b.c[0].d`); v != true {
+		t.Errorf("Expected later elements to shift down")
+	}
+	if _, err := ParseDocument([]byte(`{"a": 1, "a": 2}`)); err == nil {
+		t.Errorf("Expected duplicate keys to be refused")
+	}
+
+	t.Log("✓ Paths resolve with escapes and invalid edits are refused")
+}
@@ -0,0 +1,218 @@
+// dualhash.go - Dual hash emission during algorithm transitions
+//
+// Moving the fleet from one hash algorithm to another cannot happen on one
+// day: for a migration window, nodes that already verify the new algorithm
+// run next to nodes that only know the old one. A DualHasher configured with
+// WithDualHash computes every object's digest under both algorithms and
+// emits them in a "hashes" section keyed by algorithm name, e.g.
+//
+//	"hashes": {"sha256": "…", "sha3-256": "…"}
+//
+// Both digests cover the object without its hashes section. Which digests a
+// verifier insists on is set by the "hash_transition" entry of the policy
+// table, {"hash_transition": {"accept": "either"}}: "primary" (the default)
+// requires the primary digest, "either" accepts an object carrying either
+// one, and "both" requires both. A digest that is present but wrong is
+// refused under every setting.
+//
+// A Ledger given a DualHasher records the section on each entry it appends
+// alongside the entry's chaining hash, which stays under the ledger's own
+// algorithm; see MigrateHashes for re-chaining history.
+
+package ocp
+
+import (
+	"fmt"
+)
+
+// HashesKey is the member holding an object's digests by algorithm
+const HashesKey = "hashes"
+
+// HashTransitionPolicyKey is the policy table entry configuring which
+// digests verification accepts
+const HashTransitionPolicyKey = "hash_transition"
+
+// HashAcceptance selects which digests a verifier requires
+type HashAcceptance string
+
+const (
+	// AcceptPrimary requires the primary digest
+	AcceptPrimary HashAcceptance = "primary"
+	// AcceptEither accepts either digest
+	AcceptEither HashAcceptance = "either"
+	// RequireBoth requires both digests
+	RequireBoth HashAcceptance = "both"
+)
+
+// HashAcceptanceFromTable reads the accepted digests from a policy table. A
+// table without the entry requires the primary digest.
+func HashAcceptanceFromTable(policies map[string]interface{}) HashAcceptance {
+	entry, _ := policies[HashTransitionPolicyKey].(map[string]interface{})
+	switch a := HashAcceptance(payloadString(entry, "accept")); a {
+	case AcceptEither, RequireBoth:
+		return a
+	}
+	return AcceptPrimary
+}
+
+// DualHasher computes digests under a primary and an optional secondary
+// algorithm and verifies them under a HashAcceptance
+type DualHasher struct {
+	primary    string
+	secondary  string
+	acceptance HashAcceptance
+}
+
+// HashOption configures a DualHasher
+type HashOption func(*DualHasher)
+
+// WithDualHash emits digests under both primary and secondary
+func WithDualHash(primary, secondary string) HashOption {
+	return func(h *DualHasher) {
+		h.primary, h.secondary = primary, secondary
+	}
+}
+
+// WithHashAcceptance sets which digests Verify requires
+func WithHashAcceptance(a HashAcceptance) HashOption {
+	return func(h *DualHasher) {
+		h.acceptance = a
+	}
+}
+
+// NewDualHasher creates a DualHasher. Without options it emits and requires
+// only the protocol's HashAlgorithm.
+//
+// Returns:
+//   - ConstitutionalError if an algorithm is unsupported, the two are equal,
+//     or the acceptance is unknown; *CapabilityError if this build omits an
+//     algorithm
+func NewDualHasher(opts ...HashOption) (*DualHasher, error) {
+	h := &DualHasher{primary: HashAlgorithm, acceptance: AcceptPrimary}
+	for _, opt := range opts {
+		opt(h)
+	}
+	for _, algo := range h.Algorithms() {
+		if _, ok := hashAlgorithms[algo]; !ok {
+			return nil, unsupportedHashAlgorithm(algo)
+		}
+	}
+	if h.primary == h.secondary {
+		return nil, NewConstitutionalError(fmt.Sprintf("dual hash algorithms must differ, both are %q", h.primary))
+	}
+	switch h.acceptance {
+	case AcceptPrimary, AcceptEither, RequireBoth:
+	default:
+		return nil, NewConstitutionalError(fmt.Sprintf("unknown hash acceptance %q", h.acceptance))
+	}
+	return h, nil
+}
+
+// Algorithms returns the primary and, if set, the secondary algorithm
+func (h *DualHasher) Algorithms() []string {
+	if h.secondary == "" {
+		return []string{h.primary}
+	}
+	return []string{h.primary, h.secondary}
+}
+
+// Acceptance returns the digests Verify requires
+func (h *DualHasher) Acceptance() HashAcceptance {
+	return h.acceptance
+}
+
+// Hashes returns obj's digests by algorithm, computed without its hashes section
+func (h *DualHasher) Hashes(obj map[string]interface{}) (map[string]string, error) {
+	body := withoutHashes(obj)
+	out := make(map[string]string, 2)
+	for _, algo := range h.Algorithms() {
+		digest, err := AlgorithmHash(algo, body)
+		if err != nil {
+			return nil, err
+		}
+		out[algo] = digest
+	}
+	return out, nil
+}
+
+// Attach returns a copy of doc carrying its hashes section
+func (h *DualHasher) Attach(doc map[string]interface{}) (map[string]interface{}, error) {
+	hashes, err := h.Hashes(doc)
+	if err != nil {
+		return nil, err
+	}
+	out := withoutHashes(doc)
+	section := make(map[string]interface{}, len(hashes))
+	for algo, digest := range hashes {
+		section[algo] = digest
+	}
+	out[HashesKey] = section
+	return out, nil
+}
+
+// Verify checks the digests in hashes against obj under the acceptance.
+// Digests under other algorithms are ignored.
+//
+// Returns:
+//   - VerificationError if a digest is wrong or a required one is missing
+func (h *DualHasher) Verify(obj map[string]interface{}, hashes map[string]string) error {
+	body := withoutHashes(obj)
+	present := make(map[string]bool, 2)
+	for _, algo := range h.Algorithms() {
+		claimed, ok := hashes[algo]
+		if !ok {
+			continue
+		}
+		digest, err := AlgorithmHash(algo, body)
+		if err != nil {
+			return err
+		}
+		if digest != claimed {
+			return NewVerificationError(fmt.Sprintf("%s digest %s does not match %s", algo, claimed, digest))
+		}
+		present[algo] = true
+	}
+
+	switch {
+	case h.acceptance == AcceptPrimary && !present[h.primary]:
+		return NewVerificationError(fmt.Sprintf("missing %s digest", h.primary))
+	case h.acceptance == AcceptEither && len(present) == 0:
+		return NewVerificationError(fmt.Sprintf("missing a digest under any of %v", h.Algorithms()))
+	case h.acceptance == RequireBoth && len(present) < len(h.Algorithms()):
+		return NewVerificationError(fmt.Sprintf("both of %v digests are required", h.Algorithms()))
+	}
+	return nil
+}
+
+// VerifyDocument verifies the hashes section carried by doc
+func (h *DualHasher) VerifyDocument(doc map[string]interface{}) error {
+	section, _ := doc[HashesKey].(map[string]interface{})
+	hashes := make(map[string]string, len(section))
+	for algo, v := range section {
+		if digest, ok := v.(string); ok {
+			hashes[algo] = digest
+		}
+	}
+	return h.Verify(doc, hashes)
+}
+
+// VerifyEntryHashes verifies the hashes section of each entry under h
+func VerifyEntryHashes(entries []LedgerEntry, h *DualHasher) error {
+	for i := range entries {
+		if err := h.Verify(entries[i].ToMap(), entries[i].Hashes); err != nil {
+			return fmt.Errorf("entry %d: %w", entries[i].Height, err)
+		}
+	}
+	return nil
+}
+
+// withoutHashes returns a shallow copy of obj without its hashes section
+func withoutHashes(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if k != HashesKey {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestDualHasher tests emission and verification of both digests
+func TestDualHasher(t *testing.T) {
+	skipWithoutSHA3(t)
+	doc := testProposal().ToMap()
+	h, err := NewDualHasher(WithDualHash("sha256", "sha3-256"), WithHashAcceptance(AcceptEither))
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %v", err)
+	}
+	attached, err := h.Attach(doc)
+	if err != nil {
+		t.Fatalf("Failed to attach: %v", err)
+	}
+	section := attached[HashesKey].(map[string]interface{})
+	if primary, _ := SemanticHash(doc); section["sha256"] != primary || len(section) != 2 {
+		t.Errorf("Expected the primary digest to be the semantic hash, got %v", section)
+	}
+	if err := h.VerifyDocument(attached); err != nil {
+		t.Errorf("Expected attached hashes to verify, got %v", err)
+	}
+
+	hashes, _ := h.Hashes(doc)
+	sha3Only := map[string]string{"sha3-256": hashes["sha3-256"]}
+	cases := []struct {
+		acceptance HashAcceptance
+		hashes     map[string]string
+		ok         bool
+	}{
+		{AcceptEither, sha3Only, true},
+		{AcceptPrimary, sha3Only, false},
+		{RequireBoth, sha3Only, false},
+		{RequireBoth, hashes, true},
+		{AcceptEither, map[string]string{"sha256": hashes["sha256"], "sha3-256": hashes["sha256"]}, false},
+		{AcceptEither, map[string]string{"sha512": "ignored"}, false},
+	}
+	for _, tc := range cases {
+		verifier, _ := NewDualHasher(WithDualHash("sha256", "sha3-256"), WithHashAcceptance(tc.acceptance))
+		if err := verifier.Verify(doc, tc.hashes); (err == nil) != tc.ok {
+			t.Errorf("Acceptance %s with %v: got %v", tc.acceptance, tc.hashes, err)
+		}
+	}
+
+	for _, opts := range [][]HashOption{
+		{WithDualHash("sha256", "md5")},
+		{WithDualHash("sha256", "sha256")},
+		{WithHashAcceptance("any")},
+	} {
+		if _, err := NewDualHasher(opts...); err == nil {
+			t.Errorf("Expected invalid options to be refused")
+		}
+	}
+	table := map[string]interface{}{HashTransitionPolicyKey: map[string]interface{}{"accept": "both"}}
+	if HashAcceptanceFromTable(table) != RequireBoth || HashAcceptanceFromTable(nil) != AcceptPrimary {
+		t.Errorf("Unexpected acceptance read from policy table")
+	}
+
+	t.Log("✓ Dual digests emitted and verified per acceptance")
+}
+
+// TestLedgerDualHash tests that a ledger records both digests of its entries
+func TestLedgerDualHash(t *testing.T) {
+	testenv.RequireSigning(t)
+	skipWithoutSHA3(t)
+	h, err := NewDualHasher(WithDualHash("sha256", "sha3-256"), WithHashAcceptance(RequireBoth))
+	if err != nil {
+		t.Fatalf("Failed to create hasher: %v", err)
+	}
+	ledger := migrationLedger(t, 2)
+	ledger.SetDualHasher(h)
+	entry, err := ledger.Append(LedgerKindProposal, map[string]interface{}{"proposal_hash": "p-dual"})
+	if err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	if entry.Hashes["sha256"] != entry.Hash || entry.Hashes["sha3-256"] == "" {
+		t.Errorf("Unexpected hashes %v", entry.Hashes)
+	}
+	if err := VerifyEntryHashes(ledger.Entries(2), h); err != nil {
+		t.Errorf("Expected recorded hashes to verify, got %v", err)
+	}
+	if err := VerifyEntryHashes(ledger.Entries(0), h); err == nil {
+		t.Errorf("Expected entries appended before the window to lack digests")
+	}
+	if err := ledger.Verify(); err != nil {
+		t.Errorf("Expected the chain to be unaffected, got %v", err)
+	}
+
+	t.Log("✓ Ledger entries carry both digests")
+}
@@ -0,0 +1,287 @@
+// epoch.go - Quorum-signed epoch summaries for light clients
+//
+// The ledger is divided into epochs of EpochLength heights: epoch n holds
+// heights n*EpochLength+1 through (n+1)*EpochLength. CompactEpoch condenses
+// an epoch into an EpochSummary that commits to
+//
+//   - the state root before and after the epoch (the ledger head hashes, as
+//     in federation.go), so consecutive summaries chain like entries do
+//   - vote tallies: the quorum signatures each member contributed to the
+//     epoch's signed entries
+//   - reputation deltas: each agent's reputation change over the epoch
+//   - the Merkle root of the hashes of the proposals accepted in the epoch
+//
+// Quorum members recompute the summary from the entries with
+// VerifyEpochSummary before signing it. A light client then follows the
+// ledger one epoch at a time with VerifyEpochChain, and checks that a
+// proposal was accepted with a Merkle inclusion proof against the summary,
+// without downloading the entries themselves.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// EpochLength is the number of ledger heights in an epoch
+const EpochLength = 1024
+
+// EpochSummary condenses one epoch of the ledger
+type EpochSummary struct {
+	Epoch       uint64 `json:"epoch"`
+	StartHeight uint64 `json:"start_height"`
+	EndHeight   uint64 `json:"end_height"`
+	// PrevRoot is the head hash before StartHeight; StateRoot the head hash
+	// at EndHeight
+	PrevRoot  string `json:"prev_root"`
+	StateRoot string `json:"state_root"`
+	// EntryCounts counts the epoch's entries by kind
+	EntryCounts map[string]int `json:"entry_counts"`
+	// VoteTallies counts the signatures each agent contributed to the
+	// epoch's quorum-signed entries
+	VoteTallies map[string]int `json:"vote_tallies"`
+	// ReputationDeltas is each agent's reputation change over the epoch
+	ReputationDeltas map[string]int `json:"reputation_deltas"`
+	// ProposalRoot is the Merkle root of the accepted proposals' hashes, in
+	// ledger order; ProposalCount is their number
+	ProposalRoot  string      `json:"proposal_root"`
+	ProposalCount int         `json:"proposal_count"`
+	Signatures    []Signature `json:"signatures"`
+}
+
+// ToMap converts an EpochSummary to a map for canonicalization, excluding
+// signatures
+func (s *EpochSummary) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"epoch":             s.Epoch,
+		"start_height":      s.StartHeight,
+		"end_height":        s.EndHeight,
+		"prev_root":         s.PrevRoot,
+		"state_root":        s.StateRoot,
+		"entry_counts":      countMap(s.EntryCounts),
+		"vote_tallies":      countMap(s.VoteTallies),
+		"reputation_deltas": countMap(s.ReputationDeltas),
+		"proposal_root":     s.ProposalRoot,
+		"proposal_count":    s.ProposalCount,
+	}
+}
+
+// EpochSummaryFromMap reads a summary in the form produced by ToMap, with
+// its signatures under "signatures"
+func EpochSummaryFromMap(m map[string]interface{}) *EpochSummary {
+	return &EpochSummary{
+		Epoch:            uint64(payloadInt(m, "epoch")),
+		StartHeight:      uint64(payloadInt(m, "start_height")),
+		EndHeight:        uint64(payloadInt(m, "end_height")),
+		PrevRoot:         payloadString(m, "prev_root"),
+		StateRoot:        payloadString(m, "state_root"),
+		EntryCounts:      countsFromPayload(m, "entry_counts"),
+		VoteTallies:      countsFromPayload(m, "vote_tallies"),
+		ReputationDeltas: countsFromPayload(m, "reputation_deltas"),
+		ProposalRoot:     payloadString(m, "proposal_root"),
+		ProposalCount:    payloadInt(m, "proposal_count"),
+		Signatures:       signaturesFromPayload(m, "signatures"),
+	}
+}
+
+// Hash returns the semantic hash signed by the quorum
+func (s *EpochSummary) Hash() (string, error) {
+	return SemanticHash(s.ToMap())
+}
+
+// Sign adds a signature from signer over the summary hash
+func (s *EpochSummary) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextEpoch, hash)
+	if err != nil {
+		return err
+	}
+	s.Signatures = append(s.Signatures, sig)
+	return nil
+}
+
+// SignedBy verifies that the summary carries enough valid signatures from quorum
+func (s *EpochSummary) SignedBy(quorum *Quorum) error {
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	_, err = quorum.Verify(ContextEpoch, hash, s.Signatures)
+	return err
+}
+
+// VerifyProposal reports whether proof shows that the proposal with hash was
+// accepted in this epoch, as the index-th proposal
+func (s *EpochSummary) VerifyProposal(proposalHash string, index int, proof []string) bool {
+	return hashing.VerifyMerkleProof(s.ProposalRoot, proposalHash, index, s.ProposalCount, proof)
+}
+
+// EpochBounds returns the first and last height of epoch n
+func EpochBounds(n uint64) (uint64, uint64) {
+	return n*EpochLength + 1, (n + 1) * EpochLength
+}
+
+// CompactEpoch summarizes epoch n of the ledger. The epoch must be complete
+// and held in full.
+//
+// Returns:
+//   - Unsigned summary for the quorum to sign
+func (l *Ledger) CompactEpoch(n uint64) (*EpochSummary, error) {
+	start, end := EpochBounds(n)
+	if height := l.Height(); height < end {
+		return nil, NewConstitutionalError(fmt.Sprintf("epoch %d ends at height %d, ledger is at %d", n, end, height))
+	}
+	entries := l.Entries(start - 1)
+	if len(entries) == 0 || entries[0].Height != start {
+		return nil, NewConstitutionalError(fmt.Sprintf("ledger does not hold epoch %d from height %d", n, start))
+	}
+	return SummarizeEpoch(n, entries[:EpochLength])
+}
+
+// SummarizeEpoch computes the summary of epoch n from its entries, which must
+// be exactly the epoch's heights in order
+func SummarizeEpoch(n uint64, entries []LedgerEntry) (*EpochSummary, error) {
+	start, end := EpochBounds(n)
+	if len(entries) != EpochLength || entries[0].Height != start {
+		return nil, NewConstitutionalError(fmt.Sprintf("epoch %d needs heights %d to %d", n, start, end))
+	}
+	root, err := VerifyChain(start-1, entries[0].PrevHash, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &EpochSummary{
+		Epoch:            n,
+		StartHeight:      start,
+		EndHeight:        end,
+		PrevRoot:         entries[0].PrevHash,
+		StateRoot:        root,
+		EntryCounts:      make(map[string]int),
+		VoteTallies:      make(map[string]int),
+		ReputationDeltas: make(map[string]int),
+	}
+	proposals := epochProposals(entries)
+	for _, e := range entries {
+		s.EntryCounts[e.Kind]++
+		for _, sig := range signaturesFromPayload(e.Payload, "signatures") {
+			s.VoteTallies[sig.Signer]++
+		}
+		applyReputation(s.ReputationDeltas, e)
+	}
+	for agent, delta := range s.ReputationDeltas {
+		if delta == 0 {
+			delete(s.ReputationDeltas, agent)
+		}
+	}
+	if s.ProposalRoot, err = hashing.MerkleRoot(proposals); err != nil {
+		return nil, err
+	}
+	s.ProposalCount = len(proposals)
+	return s, nil
+}
+
+// VerifyEpochSummary recomputes the summary of s's epoch from entries and
+// checks that s matches it, as a quorum member does before signing
+//
+// Returns:
+//   - VerificationError naming the first field that differs
+func VerifyEpochSummary(s *EpochSummary, entries []LedgerEntry) error {
+	expected, err := SummarizeEpoch(s.Epoch, entries)
+	if err != nil {
+		return err
+	}
+	got, want := s.ToMap(), expected.ToMap()
+	keys := make([]string, 0, len(want))
+	for k := range want {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !CanonicallyEqual(map[string]interface{}{k: got[k]}, map[string]interface{}{k: want[k]}) {
+			return NewVerificationError(fmt.Sprintf("epoch %d summary %s does not match its entries", s.Epoch, k))
+		}
+	}
+	return nil
+}
+
+// EpochProposalProof returns the inclusion proof of a proposal accepted in
+// the epoch of entries, for EpochSummary.VerifyProposal
+//
+// Returns:
+//   - The proposal's index among the epoch's proposals and its proof
+func EpochProposalProof(entries []LedgerEntry, proposalHash string) (int, []string, error) {
+	proposals := epochProposals(entries)
+	for i, h := range proposals {
+		if h == proposalHash {
+			proof, err := hashing.MerkleProof(proposals, i)
+			return i, proof, err
+		}
+	}
+	return 0, nil, NewConstitutionalError(fmt.Sprintf("proposal %s was not accepted in this epoch", proposalHash))
+}
+
+// VerifyEpochChain verifies that summaries are consecutive epochs, each
+// signed by quorum and starting from the state root the previous one ended at
+//
+// Parameters:
+//   - prevRoot: State root before the first summary; the genesis entry's
+//     prev_hash ("") for epoch 0
+//
+// Returns:
+//   - The state root after the last summary
+func VerifyEpochChain(prevRoot string, summaries []*EpochSummary, quorum *Quorum) (string, error) {
+	for i, s := range summaries {
+		start, end := EpochBounds(s.Epoch)
+		if s.StartHeight != start || s.EndHeight != end {
+			return prevRoot, NewVerificationError(fmt.Sprintf("epoch %d has heights %d to %d", s.Epoch, s.StartHeight, s.EndHeight))
+		}
+		if i > 0 && s.Epoch != summaries[i-1].Epoch+1 {
+			return prevRoot, NewVerificationError(fmt.Sprintf("epoch %d follows epoch %d", s.Epoch, summaries[i-1].Epoch))
+		}
+		if s.PrevRoot != prevRoot {
+			return prevRoot, NewVerificationError(fmt.Sprintf("epoch %d does not start from state root %s", s.Epoch, prevRoot))
+		}
+		if err := s.SignedBy(quorum); err != nil {
+			return prevRoot, fmt.Errorf("epoch %d: %w", s.Epoch, err)
+		}
+		prevRoot = s.StateRoot
+	}
+	return prevRoot, nil
+}
+
+// epochProposals returns the hashes of the proposals accepted in entries
+func epochProposals(entries []LedgerEntry) []string {
+	var out []string
+	for _, e := range entries {
+		if e.Kind == LedgerKindProposal {
+			out = append(out, payloadString(e.Payload, "proposal_hash"))
+		}
+	}
+	return out
+}
+
+// countMap converts counts to a map for canonicalization
+func countMap(counts map[string]int) map[string]interface{} {
+	out := make(map[string]interface{}, len(counts))
+	for k, v := range counts {
+		out[k] = v
+	}
+	return out
+}
+
+// countsFromPayload reads counts stored with countMap
+func countsFromPayload(payload map[string]interface{}, key string) map[string]int {
+	m, _ := payload[key].(map[string]interface{})
+	out := make(map[string]int, len(m))
+	for k := range m {
+		out[k] = payloadInt(m, k)
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package ocp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// epochLedger returns a ledger of n proposal entries with hex proposal hashes,
+// every tenth entry signed by two quorum members
+func epochLedger(t *testing.T, n int) *Ledger {
+	t.Helper()
+	ledger := NewLedger()
+	for i := 0; i < n; i++ {
+		h := sha256.Sum256([]byte(fmt.Sprint(i)))
+		payload := map[string]interface{}{
+			"proposal_hash":  hex.EncodeToString(h[:]),
+			"proposer_agent": []string{"Claude", "Gemini", "DeepSeek"}[i%3],
+		}
+		if i%10 == 0 {
+			payload["signatures"] = signatureList([]Signature{{Signer: "Claude"}, {Signer: "Gemini"}})
+		}
+		if _, err := ledger.Append(LedgerKindProposal, payload); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	return ledger
+}
+
+// TestCompactEpoch tests the contents of a summary and its recomputation by
+// a quorum member
+func TestCompactEpoch(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := epochLedger(t, 2*EpochLength+5)
+	summary, err := ledger.CompactEpoch(1)
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	entries := ledger.Entries(EpochLength)[:EpochLength]
+	if summary.StartHeight != EpochLength+1 || summary.EndHeight != 2*EpochLength {
+		t.Errorf("Unexpected heights %d to %d", summary.StartHeight, summary.EndHeight)
+	}
+	if summary.PrevRoot != entries[0].PrevHash || summary.StateRoot != entries[EpochLength-1].Hash {
+		t.Error("Summary roots should be the head hashes around the epoch")
+	}
+	if summary.EntryCounts[LedgerKindProposal] != EpochLength || summary.ProposalCount != EpochLength {
+		t.Errorf("Unexpected counts %v, %d", summary.EntryCounts, summary.ProposalCount)
+	}
+	// Heights 1025..2048 hold entries 1024..2047, of which 102 are multiples of ten
+	if summary.VoteTallies["Claude"] != 102 || summary.VoteTallies["DeepSeek"] != 0 {
+		t.Errorf("Unexpected tallies %v", summary.VoteTallies)
+	}
+	total := 0
+	for _, delta := range summary.ReputationDeltas {
+		total += delta
+	}
+	if total != EpochLength*reputationAccepted {
+		t.Errorf("Unexpected reputation deltas %v", summary.ReputationDeltas)
+	}
+
+	if err := VerifyEpochSummary(summary, entries); err != nil {
+		t.Errorf("Summary should match its entries: %v", err)
+	}
+	forged := EpochSummaryFromMap(summary.ToMap())
+	forged.VoteTallies["DeepSeek"] = 5
+	var vErr *ConstitutionalError
+	if err := VerifyEpochSummary(forged, entries); !errors.As(err, &vErr) || vErr.ErrorType != "VerificationError" {
+		t.Errorf("Expected a forged tally to fail, got %v", err)
+	}
+
+	if _, err := ledger.CompactEpoch(2); err == nil {
+		t.Error("Expected an incomplete epoch to fail")
+	}
+	t.Logf("✓ Epoch 1 compacted to %d proposals under %s", summary.ProposalCount, summary.ProposalRoot[:16])
+}
+
+// TestVerifyEpochChain tests a light client following signed summaries and
+// checking proposal inclusion
+func TestVerifyEpochChain(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, keys := testQuorum(t)
+	ledger := epochLedger(t, 2*EpochLength)
+	var summaries []*EpochSummary
+	for n := uint64(0); n < 2; n++ {
+		s, err := ledger.CompactEpoch(n)
+		if err != nil {
+			t.Fatalf("Failed to compact epoch %d: %v", n, err)
+		}
+		for _, member := range []string{"Claude", "Gemini"} {
+			if err := s.Sign(member, keys[member]); err != nil {
+				t.Fatalf("Failed to sign: %v", err)
+			}
+		}
+		summaries = append(summaries, s)
+	}
+
+	root, err := VerifyEpochChain("", summaries, quorum)
+	if err != nil || root != ledger.Head() {
+		t.Fatalf("Expected the chain to reach %s, got %s, %v", ledger.Head(), root, err)
+	}
+	if _, err := VerifyEpochChain("", summaries[1:], quorum); err == nil {
+		t.Error("Expected a chain not starting from genesis to fail")
+	}
+	tampered := EpochSummaryFromMap(summaries[1].ToMap())
+	tampered.Signatures = summaries[1].Signatures
+	tampered.StateRoot = summaries[0].StateRoot
+	if _, err := VerifyEpochChain("", []*EpochSummary{summaries[0], tampered}, quorum); err == nil {
+		t.Error("Expected an altered summary to fail its signatures")
+	}
+
+	entries := ledger.Entries(EpochLength)
+	target := payloadString(entries[300].Payload, "proposal_hash")
+	index, proof, err := EpochProposalProof(entries, target)
+	if err != nil {
+		t.Fatalf("Failed to prove inclusion: %v", err)
+	}
+	if !summaries[1].VerifyProposal(target, index, proof) {
+		t.Error("Proposal should verify against its epoch")
+	}
+	if summaries[0].VerifyProposal(target, index, proof) {
+		t.Error("Proposal should not verify against another epoch")
+	}
+	t.Logf("✓ Light client verified %d epochs and a %d-step inclusion proof", len(summaries), len(proof))
+}
@@ -0,0 +1,223 @@
+// erasure.go - Reed-Solomon erasure code over GF(2^8)
+//
+// A blob split into k data shards is extended with m parity shards such that
+// any k of the k+m shards reconstruct it. The code is systematic: the data
+// shards are the blob itself, so reading an intact blob needs no decoding.
+// The encoding matrix is a (k+m)×k Vandermonde matrix multiplied by the
+// inverse of its top k rows; every k rows of it remain invertible, which is
+// what lets any k shards stand in for the data. Arithmetic is in GF(2^8)
+// with the polynomial x^8+x^4+x^3+x^2+1 (0x11d), as in most Reed-Solomon
+// storage codes.
+
+package ocp
+
+import (
+	"fmt"
+)
+
+// MaxErasureShards is the largest total shard count GF(2^8) supports
+const MaxErasureShards = 256
+
+var gfExp, gfLog = gfTables()
+
+func gfTables() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	// Doubling the table avoids a modulo in gfMul
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// gfPow returns a^n
+func gfPow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])*n)%255]
+}
+
+// erasureCode encodes and reconstructs shards for fixed shard counts
+type erasureCode struct {
+	data, parity int
+	// matrix has one row per shard; row i gives shard i as a combination of
+	// the data shards
+	matrix [][]byte
+}
+
+// newErasureCode builds the code for data data shards and parity parity shards
+func newErasureCode(data, parity int) (*erasureCode, error) {
+	if data < 1 || parity < 1 || data+parity > MaxErasureShards {
+		return nil, NewConstitutionalError(fmt.Sprintf("erasure code needs at least one data and one parity shard and at most %d shards, got %d+%d", MaxErasureShards, data, parity))
+	}
+	total := data + parity
+	vandermonde := make([][]byte, total)
+	for r := range vandermonde {
+		vandermonde[r] = make([]byte, data)
+		for c := range vandermonde[r] {
+			vandermonde[r][c] = gfPow(byte(r), c)
+		}
+	}
+	top, err := gfInvert(vandermonde[:data])
+	if err != nil {
+		return nil, err
+	}
+	return &erasureCode{data: data, parity: parity, matrix: gfMatMul(vandermonde, top)}, nil
+}
+
+// shardSize returns the size of each shard of a blob of n bytes
+func (e *erasureCode) shardSize(n int) int {
+	return (n + e.data - 1) / e.data
+}
+
+// encode splits blob into data shards, zero-padding the last, and appends
+// the parity shards
+func (e *erasureCode) encode(blob []byte) [][]byte {
+	size := e.shardSize(len(blob))
+	shards := make([][]byte, e.data+e.parity)
+	for i := range shards {
+		shards[i] = make([]byte, size)
+	}
+	for i := 0; i < e.data; i++ {
+		if start := i * size; start < len(blob) {
+			copy(shards[i], blob[start:])
+		}
+	}
+	for p := e.data; p < len(shards); p++ {
+		for d := 0; d < e.data; d++ {
+			gfMulAdd(shards[p], shards[d], e.matrix[p][d])
+		}
+	}
+	return shards
+}
+
+// reconstruct rebuilds the blob of n bytes from shards, in which missing
+// shards are nil. At least data shards must be present and equally sized.
+func (e *erasureCode) reconstruct(shards [][]byte, n int) ([]byte, error) {
+	var rows []int
+	for i, s := range shards {
+		if s != nil && len(rows) < e.data {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) < e.data {
+		return nil, NewConstitutionalError(fmt.Sprintf("%d shards present, %d needed", len(rows), e.data))
+	}
+	size := e.shardSize(n)
+	for _, r := range rows {
+		if len(shards[r]) != size {
+			return nil, NewConstitutionalError(fmt.Sprintf("shard %d has %d bytes, expected %d", r, len(shards[r]), size))
+		}
+	}
+
+	data := make([][]byte, e.data)
+	if rows[e.data-1] == e.data-1 {
+		// All data shards are present
+		copy(data, shards[:e.data])
+	} else {
+		sub := make([][]byte, e.data)
+		for i, r := range rows {
+			sub[i] = e.matrix[r]
+		}
+		decode, err := gfInvert(sub)
+		if err != nil {
+			return nil, err
+		}
+		for d := range data {
+			data[d] = make([]byte, size)
+			for i, r := range rows {
+				gfMulAdd(data[d], shards[r], decode[d][i])
+			}
+		}
+	}
+
+	blob := make([]byte, 0, size*e.data)
+	for _, s := range data {
+		blob = append(blob, s...)
+	}
+	return blob[:n], nil
+}
+
+// gfMulAdd adds c times src to dst
+func gfMulAdd(dst, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	for i, b := range src {
+		dst[i] ^= gfMul(c, b)
+	}
+}
+
+func gfMatMul(a, b [][]byte) [][]byte {
+	out := make([][]byte, len(a))
+	for r := range a {
+		out[r] = make([]byte, len(b[0]))
+		for c := range out[r] {
+			var v byte
+			for k := range b {
+				v ^= gfMul(a[r][k], b[k][c])
+			}
+			out[r][c] = v
+		}
+	}
+	return out
+}
+
+// gfInvert inverts a square matrix by Gauss-Jordan elimination
+func gfInvert(m [][]byte) ([][]byte, error) {
+	n := len(m)
+	work := make([][]byte, n)
+	for r := range m {
+		work[r] = make([]byte, 2*n)
+		copy(work[r], m[r])
+		work[r][n+r] = 1
+	}
+	for c := 0; c < n; c++ {
+		pivot := c
+		for pivot < n && work[pivot][c] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, NewConstitutionalError("erasure code matrix is singular")
+		}
+		work[c], work[pivot] = work[pivot], work[c]
+		inv := gfInv(work[c][c])
+		for i := range work[c] {
+			work[c][i] = gfMul(work[c][i], inv)
+		}
+		for r := 0; r < n; r++ {
+			if r != c && work[r][c] != 0 {
+				gfMulAdd(work[r], work[c], work[r][c])
+			}
+		}
+	}
+	out := make([][]byte, n)
+	for r := range work {
+		out[r] = work[r][n:]
+	}
+	return out, nil
+}
@@ -0,0 +1,273 @@
+// erasurearchive.go - Evidence archive erasure-coded across several stores
+//
+// Constitutional evidence cannot be regenerated: losing the only copy of a
+// blob a ratified proposal cites loses the means to audit it. ErasureArchive
+// splits each blob into data shards, adds Reed-Solomon parity shards (see
+// erasure.go), and spreads the shards round-robin over several ObjectStores,
+// so the blob survives the loss of any store. Shards cost far less than full
+// replicas: four data and two parity shards over three stores store 1.5
+// times the blob and tolerate one store failing outright.
+//
+// Each shard carries a header naming the blob it belongs to, its position,
+// the blob size, and the SHA-256 of its body. A shard that is missing,
+// belongs to another blob, or fails its checksum is treated as erased, and
+// the reconstructed blob is re-hashed against its key before it is returned.
+// Shard writes are conditional puts, as in ObjectArchive, so repeating a Put
+// after a partial failure only writes the shards that are missing.
+
+package ocp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// shardMagic opens every erasure-coded shard
+const shardMagic = "OCPS"
+
+// shardVersion is the shard header format version
+const shardVersion = 1
+
+// shardHeaderSize is the size of the header: magic, version, data and parity
+// shard counts, shard index, blob size, body checksum, blob hash
+const shardHeaderSize = 4 + 1 + 3 + 8 + sha256.Size + sha256.Size
+
+// ErasureArchive is an Archive storing each blob as erasure-coded shards
+// spread over several ObjectStores
+type ErasureArchive struct {
+	stores []ObjectStore
+	code   *erasureCode
+	// Prefix is prepended to every shard key
+	Prefix string
+	// Encryption is requested for every shard
+	Encryption ServerSideEncryption
+}
+
+// NewErasureArchive creates an archive over stores with data data shards and
+// parity parity shards per blob. Shard i is stored in stores[i%len(stores)].
+//
+// Returns:
+//   - Error if the shard counts are invalid, or if losing one store could
+//     lose more shards than there are parity shards
+func NewErasureArchive(stores []ObjectStore, data, parity int) (*ErasureArchive, error) {
+	code, err := newErasureCode(data, parity)
+	if err != nil {
+		return nil, err
+	}
+	if len(stores) < 2 {
+		return nil, NewConstitutionalError("erasure archive needs at least two stores")
+	}
+	if perStore := (data + parity + len(stores) - 1) / len(stores); perStore > parity {
+		return nil, NewConstitutionalError(fmt.Sprintf("%d parity shards cannot cover the loss of a store holding %d shards", parity, perStore))
+	}
+	return &ErasureArchive{stores: stores, code: code}, nil
+}
+
+// ShardKey returns the key of shard index of the blob hash
+func (a *ErasureArchive) ShardKey(hash string, index int) string {
+	if len(hash) < 2 {
+		return fmt.Sprintf("%s%s.%d", a.Prefix, hash, index)
+	}
+	return fmt.Sprintf("%s%s/%s.%d", a.Prefix, hash[:2], hash, index)
+}
+
+// store returns the store holding shard index
+func (a *ErasureArchive) store(index int) ObjectStore {
+	return a.stores[index%len(a.stores)]
+}
+
+func (a *ErasureArchive) shards() int {
+	return a.code.data + a.code.parity
+}
+
+// Put encodes data and writes every shard. Shards already stored are left
+// untouched, so a Put that failed part way can simply be repeated.
+//
+// Returns:
+//   - Content hash of data; an error names every shard that was not written,
+//     even if enough were written for the blob to be readable
+func (a *ErasureArchive) Put(data []byte) (string, error) {
+	if a.Encryption.Mode == SSEKMS && a.Encryption.KeyID == "" {
+		return "", NewConstitutionalError("KMS encryption requires a key ID")
+	}
+	hash := ContentHash(data)
+	opts := PutObjectOptions{IfNoneMatch: true, Encryption: a.Encryption, ContentType: "application/octet-stream"}
+
+	var failures []string
+	for i, body := range a.code.encode(data) {
+		err := a.store(i).PutObject(a.ShardKey(hash, i), a.encodeShard(hash, i, len(data), body), opts)
+		if err != nil && !errors.Is(err, ErrObjectExists) {
+			failures = append(failures, fmt.Sprintf("shard %d: %v", i, err))
+		}
+	}
+	if len(failures) > 0 {
+		return hash, NewConstitutionalError(fmt.Sprintf("blob %s partially stored: %s", hash, strings.Join(failures, "; ")))
+	}
+	return hash, nil
+}
+
+// Get reads shards until enough intact ones are found, reconstructs the blob,
+// and checks it against hash
+func (a *ErasureArchive) Get(hash string) ([]byte, error) {
+	shards, size, found, err := a.readShards(hash, a.code.data)
+	if err != nil {
+		return nil, err
+	}
+	if found == 0 {
+		return nil, ErrNotArchived
+	}
+	return a.decode(hash, shards, size)
+}
+
+// Has reports whether enough shards of hash are stored to reconstruct it
+func (a *ErasureArchive) Has(hash string) (bool, error) {
+	present := 0
+	for i := 0; i < a.shards(); i++ {
+		ok, err := a.store(i).HeadObject(a.ShardKey(hash, i))
+		if err != nil {
+			continue
+		}
+		if ok {
+			present++
+		}
+	}
+	return present >= a.code.data, nil
+}
+
+// Repair reconstructs hash and rewrites its missing shards
+//
+// Returns:
+//   - Indexes of the shards rewritten, and of shards that are present but
+//     damaged; conditional puts cannot replace those, so they are reported
+//     for the store's operator to delete before the next Repair
+func (a *ErasureArchive) Repair(hash string) (rewritten, damaged []int, err error) {
+	shards, size, found, err := a.readShards(hash, a.shards())
+	if err != nil {
+		return nil, nil, err
+	}
+	if found == 0 {
+		return nil, nil, ErrNotArchived
+	}
+	data, err := a.decode(hash, shards, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := PutObjectOptions{IfNoneMatch: true, Encryption: a.Encryption, ContentType: "application/octet-stream"}
+	for i, body := range a.code.encode(data) {
+		if shards[i] != nil {
+			continue
+		}
+		key := a.ShardKey(hash, i)
+		err := a.store(i).PutObject(key, a.encodeShard(hash, i, size, body), opts)
+		switch {
+		case errors.Is(err, ErrObjectExists):
+			damaged = append(damaged, i)
+		case err != nil:
+			return rewritten, damaged, fmt.Errorf("shard %d: %w", i, err)
+		default:
+			rewritten = append(rewritten, i)
+		}
+	}
+	return rewritten, damaged, nil
+}
+
+// readShards reads shards of hash in index order until want intact ones are
+// found. Data shards come first, so an intact blob needs no decoding. A store
+// that cannot be read is one of the failures the code covers, so its error
+// is only returned if no shard was found at all.
+//
+// Returns:
+//   - The shard bodies (nil where missing or damaged), the blob size (-1 if
+//     no shard is intact), and the number of shard objects found
+func (a *ErasureArchive) readShards(hash string, want int) ([][]byte, int, int, error) {
+	shards := make([][]byte, a.shards())
+	size, found, intact := -1, 0, 0
+	var storeErr error
+	for i := 0; i < len(shards) && intact < want; i++ {
+		raw, err := a.store(i).GetObject(a.ShardKey(hash, i))
+		if errors.Is(err, ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			storeErr = err
+			continue
+		}
+		found++
+		n, body, ok := a.decodeShard(hash, i, raw)
+		if !ok || (size >= 0 && n != size) {
+			continue
+		}
+		size, shards[i] = n, body
+		intact++
+	}
+	if found == 0 && storeErr != nil {
+		return nil, -1, 0, storeErr
+	}
+	return shards, size, found, nil
+}
+
+// decode reconstructs a blob from its shards and checks its hash
+func (a *ErasureArchive) decode(hash string, shards [][]byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s has no intact shards", hash))
+	}
+	data, err := a.code.reconstruct(shards, size)
+	if err != nil {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s cannot be reconstructed: %v", hash, err))
+	}
+	if ContentHash(data) != hash {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s is corrupted", hash))
+	}
+	return data, nil
+}
+
+// encodeShard prefixes body with its header
+func (a *ErasureArchive) encodeShard(hash string, index, size int, body []byte) []byte {
+	blob, _ := hex.DecodeString(hash)
+	sum := sha256.Sum256(body)
+
+	var buf bytes.Buffer
+	buf.Grow(shardHeaderSize + len(body))
+	buf.WriteString(shardMagic)
+	buf.WriteByte(shardVersion)
+	buf.WriteByte(byte(a.code.data - 1))
+	buf.WriteByte(byte(a.code.parity - 1))
+	buf.WriteByte(byte(index))
+	binary.Write(&buf, binary.BigEndian, uint64(size))
+	buf.Write(sum[:])
+	buf.Write(blob)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// decodeShard checks a shard's header against the blob and position it was
+// read for and its body against its checksum
+//
+// Returns:
+//   - Blob size and shard body, and whether the shard is intact
+func (a *ErasureArchive) decodeShard(hash string, index int, raw []byte) (int, []byte, bool) {
+	if len(raw) < shardHeaderSize || string(raw[:4]) != shardMagic || raw[4] != shardVersion {
+		return 0, nil, false
+	}
+	if int(raw[5])+1 != a.code.data || int(raw[6])+1 != a.code.parity || int(raw[7]) != index {
+		return 0, nil, false
+	}
+	size := binary.BigEndian.Uint64(raw[8:16])
+	sum, blob, body := raw[16:48], raw[48:80], raw[shardHeaderSize:]
+	if hex.EncodeToString(blob) != hash || size > uint64(len(body))*uint64(a.code.data) {
+		return 0, nil, false
+	}
+	if len(body) != a.code.shardSize(int(size)) {
+		return 0, nil, false
+	}
+	if actual := sha256.Sum256(body); !bytes.Equal(actual[:], sum) {
+		return 0, nil, false
+	}
+	return int(size), body, true
+}
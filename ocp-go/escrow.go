@@ -0,0 +1,468 @@
+// escrow.go - Challenge bonds held in escrow on the ledger
+//
+// A challenger must lock a reputation bond before a fraud proof is considered
+// (OCP-0001 §8.4). The bond is returned if the challenge is upheld and forfeited
+// to the proposer if it is rejected. The required bond follows a BondingCurve
+// that is weighted by the challenged proposal's stake and grows with the number
+// of prior failed challenges by the same challenger against the same proposer.
+//
+// A co-sponsored proposal (see sponsorship.go) is staked by its sponsors as
+// well as its proposer. Escrow.Sponsor records the aggregated stake, and bonds
+// against a sponsored proposal are weighted by the aggregate rather than the
+// proposer's share alone.
+//
+// A slashed party may appeal a resolution (see appeal.go); Escrow.Appeal
+// holds the appellant's stake, and Escrow.Rule or Escrow.Lapse pays it out.
+//
+// All escrow state lives in ledger entries, so any verifier replaying the ledger
+// computes the same required bonds and can check them with VerifyBonds.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Ledger entry kinds written by Escrow
+const (
+	LedgerKindChallengeBond     = "challenge_bond"
+	LedgerKindChallengeResolved = "challenge_resolved"
+	LedgerKindSponsorship       = "sponsorship"
+)
+
+// Challenge outcomes recorded when a bond is resolved
+const (
+	ChallengeUpheld   = "upheld"
+	ChallengeRejected = "rejected"
+)
+
+// CurveShape selects how a bond grows with prior failed challenges
+type CurveShape string
+
+const (
+	// CurveLinear adds StepPercent of the stake per prior failure
+	CurveLinear CurveShape = "linear"
+	// CurveExponential multiplies the bond by (100+StepPercent)/100 per prior failure
+	CurveExponential CurveShape = "exponential"
+)
+
+// BondingCurve computes challenge bonds using integer arithmetic only, so that
+// every implementation derives identical bonds from the same history.
+type BondingCurve struct {
+	Shape CurveShape `json:"shape"`
+	// BasePercent of the challenged proposal's stake is charged for a first challenge
+	BasePercent int `json:"base_percent"`
+	// StepPercent controls growth per prior failed challenge
+	StepPercent int `json:"step_percent"`
+	// MinBond is the lowest bond ever charged
+	MinBond int `json:"min_bond"`
+	// MaxBond caps the bond; zero means uncapped
+	MaxBond int `json:"max_bond"`
+}
+
+// DefaultBondingCurve charges half the proposer's stake, doubling per failure
+var DefaultBondingCurve = BondingCurve{
+	Shape:       CurveExponential,
+	BasePercent: 50,
+	StepPercent: 100,
+	MinBond:     1,
+}
+
+// ToMap converts a BondingCurve to a map for canonicalization
+func (c BondingCurve) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"shape":        string(c.Shape),
+		"base_percent": c.BasePercent,
+		"step_percent": c.StepPercent,
+		"min_bond":     c.MinBond,
+		"max_bond":     c.MaxBond,
+	}
+}
+
+// Bond returns the bond required to challenge a proposal staking stake after
+// priorFailures rejected challenges against the same proposer.
+//
+// Parameters:
+//   - stake: ReputationStake of the challenged proposal
+//   - priorFailures: Rejected challenges by this challenger against this proposer
+//
+// Returns:
+//   - Required bond, at least MinBond and at most MaxBond (if set); a bond
+//     too large for an int saturates at math.MaxInt
+func (c BondingCurve) Bond(stake, priorFailures int) int {
+	bond, ok := c.BondDecimal(DecimalFromInt(int64(stake)), priorFailures, 0).Int64()
+	if !ok || bond > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(bond)
+}
+
+// BondDecimal is Bond for fractional stakes. Every percentage step rounds up
+// to scale fractional digits, so with scale 0 and an integral stake it gives
+// exactly Bond's result. An exponential curve stops growing once the bond
+// reaches MaxBond or, if uncapped, math.MaxInt, so a long failure history
+// costs no more than the steps needed to get there.
+func (c BondingCurve) BondDecimal(stake Decimal, priorFailures int, scale int32) Decimal {
+	if stake.Sign() < 0 {
+		stake = Decimal{}
+	}
+	bond := stake.Percent(int64(c.BasePercent), scale, canonical.RoundCeiling)
+	maxBond := DecimalFromInt(int64(c.MaxBond))
+
+	switch c.Shape {
+	case CurveLinear:
+		// stake × failures first: StepPercent*priorFailures can overflow an int
+		steps := stake.Mul(DecimalFromInt(int64(priorFailures)))
+		bond = bond.Add(steps.Percent(int64(c.StepPercent), scale, canonical.RoundCeiling))
+	case CurveExponential:
+		ceiling := maxBond
+		if c.MaxBond <= 0 {
+			ceiling = DecimalFromInt(math.MaxInt)
+		}
+		for i := 0; i < priorFailures && bond.Cmp(ceiling) < 0; i++ {
+			bond = bond.Percent(int64(100+c.StepPercent), scale, canonical.RoundCeiling)
+		}
+	}
+
+	if minBond := DecimalFromInt(int64(c.MinBond)); bond.Cmp(minBond) < 0 {
+		bond = minBond
+	}
+	if c.MaxBond > 0 && bond.Cmp(maxBond) > 0 {
+		bond = maxBond
+	}
+	return bond
+}
+
+// ChallengeBond is an open or resolved bond recorded on the ledger
+type ChallengeBond struct {
+	EntryHash    string
+	Challenger   string
+	Proposer     string
+	ProposalHash string
+	Bond         int
+	Required     int
+}
+
+// PriorFailedChallenges counts rejected challenges by challenger against
+// proposer among the given ledger entries, leaving out those voided on appeal.
+func PriorFailedChallenges(entries []LedgerEntry, challenger, proposer string) int {
+	index := newBondIndex()
+	for _, e := range entries {
+		index.apply(e)
+	}
+	return index.failures[challengePair{challenger, proposer}]
+}
+
+// VerifyBonds replays entries and checks that every challenge bond met the
+// curve's requirement given the history before it, and that bonds against a
+// sponsored proposal were weighted by its aggregated stake.
+func VerifyBonds(entries []LedgerEntry, curve BondingCurve) error {
+	index := newBondIndex()
+	for _, e := range entries {
+		if e.Kind == LedgerKindChallengeBond {
+			if err := verifyBondEntry(index, e, curve); err != nil {
+				return err
+			}
+		}
+		index.apply(e)
+	}
+	return nil
+}
+
+// verifyBondEntry checks one challenge bond against the history before it
+func verifyBondEntry(index *bondIndex, e LedgerEntry, curve BondingCurve) error {
+	if total, ok := index.sponsored[payloadString(e.Payload, "proposal_hash")]; ok && payloadInt(e.Payload, "stake") != total {
+		return NewVerificationError(fmt.Sprintf("entry %d records stake %d, sponsors staked %d", e.Height, payloadInt(e.Payload, "stake"), total))
+	}
+	pair := challengePair{payloadString(e.Payload, "challenger"), payloadString(e.Payload, "proposer")}
+	required := curve.Bond(payloadInt(e.Payload, "stake"), index.failures[pair])
+	if payloadInt(e.Payload, "required") != required {
+		return NewVerificationError(fmt.Sprintf("entry %d records required bond %d, curve gives %d", e.Height, payloadInt(e.Payload, "required"), required))
+	}
+	if payloadInt(e.Payload, "bond") < required {
+		return NewVerificationError(fmt.Sprintf("entry %d bond below required %d", e.Height, required))
+	}
+	return nil
+}
+
+// challengePair names a challenger and the proposer it challenged
+type challengePair struct {
+	challenger, proposer string
+}
+
+// bondIndex holds the sponsorships, bonds and failed challenges on a ledger,
+// built one entry at a time
+type bondIndex struct {
+	// sponsored maps a proposal hash to its first recorded aggregate stake
+	sponsored map[string]int
+	bonds     map[string]LedgerEntry
+	// resolved holds the hashes of bonds already resolved
+	resolved map[string]bool
+	// rejections maps a rejected resolution's hash to its parties
+	rejections map[string]challengePair
+	// voided holds the hashes of resolutions voided by granted appeals
+	voided map[string]bool
+	// failures counts rejected, unvoided challenges per pair
+	failures map[challengePair]int
+}
+
+func newBondIndex() *bondIndex {
+	return &bondIndex{
+		sponsored:  make(map[string]int),
+		bonds:      make(map[string]LedgerEntry),
+		resolved:   make(map[string]bool),
+		rejections: make(map[string]challengePair),
+		voided:     make(map[string]bool),
+		failures:   make(map[challengePair]int),
+	}
+}
+
+// apply records e if it sponsors a proposal, locks or resolves a bond, or
+// voids a resolution on appeal
+func (x *bondIndex) apply(e LedgerEntry) {
+	switch e.Kind {
+	case LedgerKindSponsorship:
+		if proposalHash := payloadString(e.Payload, "proposal_hash"); !x.hasSponsor(proposalHash) {
+			x.sponsored[proposalHash] = payloadInt(e.Payload, "total_stake")
+		}
+	case LedgerKindChallengeBond:
+		x.bonds[e.Hash] = e
+	case LedgerKindChallengeResolved:
+		x.resolved[payloadString(e.Payload, "bond_hash")] = true
+		if payloadString(e.Payload, "outcome") != ChallengeRejected {
+			return
+		}
+		pair := challengePair{payloadString(e.Payload, "challenger"), payloadString(e.Payload, "proposer")}
+		x.rejections[e.Hash] = pair
+		if !x.voided[e.Hash] {
+			x.failures[pair]++
+		}
+	case LedgerKindAppealRuling:
+		resolutionHash := payloadString(e.Payload, "resolution_hash")
+		if granted, _ := e.Payload["granted"].(bool); !granted || x.voided[resolutionHash] {
+			return
+		}
+		x.voided[resolutionHash] = true
+		if pair, ok := x.rejections[resolutionHash]; ok {
+			x.failures[pair]--
+		}
+	}
+}
+
+// hasSponsor reports whether a sponsorship is recorded for proposalHash
+func (x *bondIndex) hasSponsor(proposalHash string) bool {
+	_, ok := x.sponsored[proposalHash]
+	return ok
+}
+
+// Escrow locks and resolves challenge bonds on a ledger
+type Escrow struct {
+	mu     sync.Mutex
+	ledger *Ledger
+	curve  BondingCurve
+	// Lifecycle, if set, tells Exposure which proposals are still open
+	Lifecycle *Lifecycle
+
+	// appeals indexes resolutions and appeals up to height appealsSynced
+	appeals       *appealIndex
+	appealsSynced uint64
+	// bonds indexes sponsorships, bonds and failures up to height bondsSynced
+	bonds       *bondIndex
+	bondsSynced uint64
+}
+
+// NewEscrow creates an Escrow recording bonds on ledger
+func NewEscrow(ledger *Ledger, curve BondingCurve) *Escrow {
+	return &Escrow{ledger: ledger, curve: curve}
+}
+
+// Curve returns the escrow's bonding curve
+func (e *Escrow) Curve() BondingCurve {
+	return e.curve
+}
+
+// RequiredBond returns the bond challenger must lock to challenge p
+func (e *Escrow) RequiredBond(challenger string, p *ContractProposal) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	index := e.bondsLocked()
+	return e.curve.Bond(stakeOf(index, p), index.failures[challengePair{challenger, p.ProposerAgent}])
+}
+
+// bondsLocked brings the escrow's bond index up to the ledger's head, reading
+// only the entries appended since the last call
+func (e *Escrow) bondsLocked() *bondIndex {
+	if e.bonds == nil {
+		e.bonds = newBondIndex()
+	}
+	for _, entry := range e.ledger.Entries(e.bondsSynced) {
+		e.bonds.apply(entry)
+		e.bondsSynced = entry.Height
+	}
+	return e.bonds
+}
+
+// stake returns the stake behind p, as stakeOf does
+func (e *Escrow) stake(p *ContractProposal) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return stakeOf(e.bondsLocked(), p)
+}
+
+// stakeOf returns the stake behind p: the sponsors' aggregate if recorded,
+// otherwise the proposer's own
+func stakeOf(index *bondIndex, p *ContractProposal) int {
+	if proposalHash, err := p.GetHash(); err == nil {
+		if total, ok := index.sponsored[proposalHash]; ok {
+			return total
+		}
+	}
+	return p.ReputationStake
+}
+
+// Sponsor verifies the sponsorships of p, checks their aggregate against
+// policy, and records it on the ledger. A proposal is sponsored at most once.
+//
+// Parameters:
+//   - p: The sponsored proposal
+//   - sponsorships: One signed sponsorship per co-sponsor
+//   - keys: Public keys of the agents that may sponsor
+//   - policy: Thresholds the aggregate must meet
+//
+// Returns:
+//   - The recorded aggregate stake
+//   - VerificationError if a sponsorship is not validly signed
+//   - An error wrapping ErrInsufficientSponsorship if policy is not met
+//   - ConstitutionalError if p was already sponsored
+func (e *Escrow) Sponsor(p *ContractProposal, sponsorships []Sponsorship, keys map[string]ed25519.PublicKey, policy SponsorshipPolicy) (*AggregateStake, error) {
+	agg, err := AggregateSponsorships(p, sponsorships, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Check(agg); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.bondsLocked().hasSponsor(agg.ProposalHash) {
+		return nil, NewConstitutionalError(fmt.Sprintf("proposal %s already sponsored", agg.ProposalHash))
+	}
+	sponsors := make([]interface{}, len(sponsorships))
+	for i := range sponsorships {
+		m := sponsorships[i].ToMap()
+		m["signature"] = sponsorships[i].Signature.ToMap()
+		sponsors[i] = m
+	}
+	if _, err := e.ledger.Append(LedgerKindSponsorship, map[string]interface{}{
+		"proposal_hash":  agg.ProposalHash,
+		"proposer":       agg.Proposer,
+		"proposer_stake": agg.Contributions[agg.Proposer],
+		"sponsors":       sponsors,
+		"total_stake":    agg.Total,
+	}); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// Lock records a challenge bond against p on the ledger
+//
+// Returns:
+//   - The recorded bond
+//   - ConstitutionalError if bond is below the required amount
+func (e *Escrow) Lock(challenger string, p *ContractProposal, bond int) (*ChallengeBond, error) {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	index := e.bondsLocked()
+	stake := stakeOf(index, p)
+	required := e.curve.Bond(stake, index.failures[challengePair{challenger, p.ProposerAgent}])
+	if bond < required {
+		return nil, NewConstitutionalError(fmt.Sprintf("challenge bond %d below required %d", bond, required))
+	}
+
+	entry, err := e.ledger.Append(LedgerKindChallengeBond, map[string]interface{}{
+		"challenger":    challenger,
+		"proposer":      p.ProposerAgent,
+		"proposal_hash": proposalHash,
+		"stake":         stake,
+		"bond":          bond,
+		"required":      required,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChallengeBond{
+		EntryHash:    entry.Hash,
+		Challenger:   challenger,
+		Proposer:     p.ProposerAgent,
+		ProposalHash: proposalHash,
+		Bond:         bond,
+		Required:     required,
+	}, nil
+}
+
+// Resolve settles a locked bond. An upheld challenge returns the bond to the
+// challenger; a rejected one forfeits it to the proposer and raises the
+// challenger's next bond against that proposer.
+func (e *Escrow) Resolve(bondHash string, upheld bool) (LedgerEntry, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	index := e.bondsLocked()
+	bond, ok := index.bonds[bondHash]
+	if !ok {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("unknown challenge bond %s", bondHash))
+	}
+	if index.resolved[bondHash] {
+		return LedgerEntry{}, NewConstitutionalError(fmt.Sprintf("challenge bond %s already resolved", bondHash))
+	}
+
+	outcome, recipient := ChallengeRejected, payloadString(bond.Payload, "proposer")
+	if upheld {
+		outcome, recipient = ChallengeUpheld, payloadString(bond.Payload, "challenger")
+	}
+	return e.ledger.Append(LedgerKindChallengeResolved, map[string]interface{}{
+		"bond_hash":  bondHash,
+		"challenger": payloadString(bond.Payload, "challenger"),
+		"proposer":   payloadString(bond.Payload, "proposer"),
+		"outcome":    outcome,
+		"amount":     payloadInt(bond.Payload, "bond"),
+		"paid_to":    recipient,
+	})
+}
+
+// payloadString reads a string field from a ledger payload
+func payloadString(payload map[string]interface{}, key string) string {
+	s, _ := payload[key].(string)
+	return s
+}
+
+// payloadInt reads an integer field from a ledger payload, which holds a Go
+// int when appended locally and a float64 once decoded from JSON.
+func payloadInt(payload map[string]interface{}, key string) int {
+	switch v := payload[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package ocp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestBondingCurveShapes tests deterministic bond growth for each curve shape
+func TestBondingCurveShapes(t *testing.T) {
+	linear := BondingCurve{Shape: CurveLinear, BasePercent: 50, StepPercent: 25}
+	exponential := BondingCurve{Shape: CurveExponential, BasePercent: 50, StepPercent: 100, MaxBond: 150}
+
+	cases := []struct {
+		curve    BondingCurve
+		failures int
+		expected int
+	}{
+		{linear, 0, 30},
+		{linear, 1, 45},
+		{linear, 4, 90},
+		{exponential, 0, 30},
+		{exponential, 1, 60},
+		{exponential, 2, 120},
+		{exponential, 3, 150},
+		{exponential, 1000, 150},
+	}
+
+	for _, tc := range cases {
+		if got := tc.curve.Bond(60, tc.failures); got != tc.expected {
+			t.Errorf("%s curve with %d failures: expected %d, got %d", tc.curve.Shape, tc.failures, tc.expected, got)
+		}
+	}
+
+	if got := DefaultBondingCurve.Bond(0, 0); got != 1 {
+		t.Errorf("MinBond should apply to zero-stake proposals, got %d", got)
+	}
+
+	t.Logf("✓ Bonding curves grow deterministically")
+}
+
+// TestBondSaturates tests that bonds too large for an int saturate rather
+// than wrap, however many prior failures there are
+func TestBondSaturates(t *testing.T) {
+	linear := BondingCurve{Shape: CurveLinear, BasePercent: 50, StepPercent: 25}
+	uncapped := BondingCurve{Shape: CurveExponential, BasePercent: 50, StepPercent: 100}
+
+	cases := []struct {
+		curve    BondingCurve
+		stake    int
+		failures int
+	}{
+		{linear, 60, math.MaxInt},
+		{linear, math.MaxInt, 8},
+		{uncapped, 60, math.MaxInt},
+		{uncapped, 60, 200},
+	}
+	for _, tc := range cases {
+		if got := tc.curve.Bond(tc.stake, tc.failures); got != math.MaxInt {
+			t.Errorf("%s curve, stake %d, %d failures: expected math.MaxInt, got %d", tc.curve.Shape, tc.stake, tc.failures, got)
+		}
+	}
+	if got := uncapped.Bond(60, 10); got != 30<<10 {
+		t.Errorf("Expected bonds below the ceiling to be exact, got %d", got)
+	}
+	t.Logf("✓ Bonds saturate at %d", math.MaxInt)
+}
+
+// TestBondDecimal tests exact bonds for fractional stakes
+func TestBondDecimal(t *testing.T) {
+	curve := BondingCurve{Shape: CurveExponential, BasePercent: 50, StepPercent: 10, MaxBond: 100}
+	stake, _ := ParseDecimal("2.5")
+
+	cases := []struct {
+		failures int
+		scale    int32
+		expected string
+	}{
+		{0, 2, "1.25"},
+		{0, 1, "1.3"},
+		{1, 2, "1.38"},
+		{2, 3, "1.513"},
+	}
+	for _, tc := range cases {
+		if got := curve.BondDecimal(stake, tc.failures, tc.scale).String(); got != tc.expected {
+			t.Errorf("%d failures at scale %d: expected %s, got %s", tc.failures, tc.scale, tc.expected, got)
+		}
+	}
+	if got := curve.BondDecimal(DecimalFromInt(60), 0, 0); got.String() != "30" || curve.Bond(60, 0) != 30 {
+		t.Errorf("Integral stakes should match Bond, got %s", got)
+	}
+
+	t.Log("✓ Fractional bonds rounded up at a fixed scale")
+}
+
+// TestEscrowBondsGrowWithFailures tests that rejected challenges raise the next bond
+func TestEscrowBondsGrowWithFailures(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	proposal := testProposal()
+	proposal.ReputationStake = 60
+
+	if _, err := escrow.Lock("Gemini", proposal, 10); err == nil {
+		t.Errorf("Bond below required amount should be rejected")
+	}
+
+	expected := []int{30, 60, 120}
+	for _, want := range expected {
+		required := escrow.RequiredBond("Gemini", proposal)
+		if required != want {
+			t.Fatalf("Expected required bond %d, got %d", want, required)
+		}
+		bond, err := escrow.Lock("Gemini", proposal, required)
+		if err != nil {
+			t.Fatalf("Failed to lock bond: %v", err)
+		}
+		if _, err := escrow.Resolve(bond.EntryHash, false); err != nil {
+			t.Fatalf("Failed to resolve bond: %v", err)
+		}
+		if _, err := escrow.Resolve(bond.EntryHash, false); err == nil {
+			t.Errorf("Bond should not resolve twice")
+		}
+	}
+
+	// Failures are tracked per challenger/proposer pair
+	if got := escrow.RequiredBond("DeepSeek", proposal); got != 30 {
+		t.Errorf("Other challengers should pay the base bond, got %d", got)
+	}
+
+	// An upheld challenge does not raise the bond
+	bond, _ := escrow.Lock("DeepSeek", proposal, 30)
+	escrow.Resolve(bond.EntryHash, true)
+	if got := escrow.RequiredBond("DeepSeek", proposal); got != 30 {
+		t.Errorf("Upheld challenges should not raise the bond, got %d", got)
+	}
+
+	if err := VerifyBonds(ledger.Entries(0), DefaultBondingCurve); err != nil {
+		t.Errorf("Recorded bonds should verify: %v", err)
+	}
+
+	t.Logf("✓ Challenge bonds: %v", expected)
+}
+
+// TestVerifyBondsDetectsUnderpayment tests replay verification of ledger history
+func TestVerifyBondsDetectsUnderpayment(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	proposal := testProposal()
+	proposal.ReputationStake = 60
+
+	bond, _ := escrow.Lock("Gemini", proposal, 30)
+	escrow.Resolve(bond.EntryHash, false)
+
+	// A second bond recorded at the base rate ignores the prior failure
+	ledger.Append(LedgerKindChallengeBond, map[string]interface{}{
+		"challenger": "Gemini",
+		"proposer":   proposal.ProposerAgent,
+		"stake":      proposal.ReputationStake,
+		"bond":       30,
+		"required":   30,
+	})
+
+	if err := VerifyBonds(ledger.Entries(0), DefaultBondingCurve); err == nil {
+		t.Errorf("Underpaid bond should fail verification")
+	}
+
+	t.Logf("✓ Underpaid bond detected on replay")
+}
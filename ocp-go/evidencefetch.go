@@ -0,0 +1,285 @@
+// evidencefetch.go - Snapshots of URL evidence
+//
+// An external_source evidence item pointing at a URL is only as good as the
+// server behind it: the page can change or disappear after the proposal is
+// accepted, and a later verifier would see different content from the one the
+// quorum judged. An EvidenceFetcher retrieves such evidence once, at proposal
+// time, stores the exact bytes in the archive, and records the URL, the fetch
+// time, and the content hash in an EvidenceAttestation. Verifiers then check
+// the archived snapshot against the attestation instead of refetching.
+//
+// The fetcher is deliberately restrictive, since proposal authors choose the
+// URLs: only http and https, no redirects, a size cap, a timeout, and no
+// connections to loopback, private, or link-local addresses unless
+// AllowPrivate is set.
+
+package ocp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// EvidenceTypeExternal is the evidence type whose URL pointers are snapshotted
+const EvidenceTypeExternal = "external_source"
+
+// Defaults applied by EvidenceFetcher when its fields are zero
+const (
+	DefaultEvidenceMaxBytes = 10 << 20
+	DefaultEvidenceTimeout  = 30 * time.Second
+)
+
+// EvidenceSnapshot records one fetch of URL evidence
+type EvidenceSnapshot struct {
+	URL         string `json:"url"`
+	FetchedAt   string `json:"fetched_at"`
+	ContentHash string `json:"content_hash"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// ToMap converts an EvidenceSnapshot to a map for canonicalization
+func (s EvidenceSnapshot) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"url":          s.URL,
+		"fetched_at":   s.FetchedAt,
+		"content_hash": s.ContentHash,
+		"content_type": s.ContentType,
+		"size":         s.Size,
+	}
+}
+
+// EvidenceAttestation binds the snapshots of a proposal's URL evidence to the
+// proposal, signed by the nodes that fetched them
+type EvidenceAttestation struct {
+	ProposalHash string             `json:"proposal_hash"`
+	Snapshots    []EvidenceSnapshot `json:"snapshots"`
+	Signatures   []Signature        `json:"signatures"`
+}
+
+// ToMap converts an attestation to a map for canonicalization, excluding signatures
+func (a *EvidenceAttestation) ToMap() map[string]interface{} {
+	snapshots := make([]interface{}, len(a.Snapshots))
+	for i, s := range a.Snapshots {
+		snapshots[i] = s.ToMap()
+	}
+	return map[string]interface{}{
+		"proposal_hash": a.ProposalHash,
+		"snapshots":     snapshots,
+	}
+}
+
+// Hash returns the semantic hash signed by the fetching nodes
+func (a *EvidenceAttestation) Hash() (string, error) {
+	return SemanticHash(a.ToMap())
+}
+
+// Sign adds a signature from signer over the attestation hash
+func (a *EvidenceAttestation) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextEvidence, hash)
+	if err != nil {
+		return err
+	}
+	a.Signatures = append(a.Signatures, sig)
+	return nil
+}
+
+// Snapshot returns the snapshot of url, if the attestation has one
+func (a *EvidenceAttestation) Snapshot(url string) (EvidenceSnapshot, bool) {
+	for _, s := range a.Snapshots {
+		if s.URL == url {
+			return s, true
+		}
+	}
+	return EvidenceSnapshot{}, false
+}
+
+// EvidenceFetcher retrieves URL evidence into an archive
+type EvidenceFetcher struct {
+	// Archive receives the fetched bytes
+	Archive Archive
+	// Clock stamps fetches; defaults to SystemClock
+	Clock Clock
+	// MaxBytes caps a fetched document; defaults to DefaultEvidenceMaxBytes
+	MaxBytes int
+	// Timeout bounds each fetch; defaults to DefaultEvidenceTimeout
+	Timeout time.Duration
+	// AllowPrivate permits loopback, private, and link-local addresses
+	AllowPrivate bool
+	// Transport, if set, replaces the fetcher's own connection handling; the
+	// address restrictions then become the transport's responsibility
+	Transport http.RoundTripper
+}
+
+// IsEvidenceURL reports whether pointer is an http or https URL
+func IsEvidenceURL(pointer string) bool {
+	u, err := url.Parse(pointer)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Fetch retrieves url and archives its content
+//
+// Returns:
+//   - Snapshot recording the URL, fetch time, and archive hash of the content
+//   - ConstitutionalError if the URL is not allowed, the server does not
+//     answer 200, or the content exceeds MaxBytes
+func (f *EvidenceFetcher) Fetch(ctx context.Context, rawURL string) (EvidenceSnapshot, error) {
+	if !IsEvidenceURL(rawURL) {
+		return EvidenceSnapshot{}, NewConstitutionalError(fmt.Sprintf("evidence pointer %q is not an http(s) URL", rawURL))
+	}
+	maxBytes := f.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultEvidenceMaxBytes
+	}
+
+	fetchedAt := Timestamp(clockOrSystem(f.Clock).Now())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return EvidenceSnapshot{}, NewConstitutionalError(err.Error())
+	}
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return EvidenceSnapshot{}, NewConstitutionalError(fmt.Sprintf("fetching %s: %v", rawURL, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return EvidenceSnapshot{}, NewConstitutionalError(fmt.Sprintf("fetching %s: %s", rawURL, resp.Status))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return EvidenceSnapshot{}, NewConstitutionalError(fmt.Sprintf("fetching %s: %v", rawURL, err))
+	}
+	if len(data) > maxBytes {
+		return EvidenceSnapshot{}, NewConstitutionalError(fmt.Sprintf("evidence at %s exceeds %d bytes", rawURL, maxBytes))
+	}
+
+	hash, err := f.Archive.Put(data)
+	if err != nil {
+		return EvidenceSnapshot{}, err
+	}
+	return EvidenceSnapshot{
+		URL:         rawURL,
+		FetchedAt:   fetchedAt,
+		ContentHash: hash,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        len(data),
+	}, nil
+}
+
+// Attest snapshots every external_source evidence item of p whose pointer is
+// a URL, and archives the resulting attestation. The attestation is unsigned;
+// the node adds its signature with Sign.
+//
+// Returns:
+//   - Attestation covering every URL, in evidence order without duplicates
+//   - The first fetch error; nothing is attested unless every URL was fetched
+func (f *EvidenceFetcher) Attest(ctx context.Context, p *ContractProposal) (*EvidenceAttestation, error) {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	a := &EvidenceAttestation{ProposalHash: proposalHash, Snapshots: []EvidenceSnapshot{}}
+	for _, ref := range p.EvidenceRefs() {
+		if ref.Type != EvidenceTypeExternal || !IsEvidenceURL(ref.Pointer) {
+			continue
+		}
+		if _, ok := a.Snapshot(ref.Pointer); ok {
+			continue
+		}
+		snapshot, err := f.Fetch(ctx, ref.Pointer)
+		if err != nil {
+			return nil, err
+		}
+		a.Snapshots = append(a.Snapshots, snapshot)
+	}
+	if _, err := ArchiveObject(f.Archive, a.ToMap()); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// client returns the HTTP client used for one fetch
+func (f *EvidenceFetcher) client() *http.Client {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultEvidenceTimeout
+	}
+	transport := f.Transport
+	if transport == nil {
+		dialer := &net.Dialer{Timeout: timeout}
+		if !f.AllowPrivate {
+			dialer.Control = rejectPrivateAddress
+		}
+		transport = &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+		}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// The recorded URL must be the one that served the content
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return fmt.Errorf("redirect to %s not followed", req.URL)
+		},
+	}
+}
+
+// rejectPrivateAddress refuses connections to addresses inside the node's
+// own network. It runs after name resolution, so DNS cannot be used to
+// smuggle a private address past it.
+func rejectPrivateAddress(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("evidence fetch to non-public address %s refused", host)
+	}
+	return nil
+}
+
+// VerifyEvidenceAttestation checks that every snapshot's content is archived
+// under its hash and that the attestation is signed by a quorum of fetchers
+//
+// Parameters:
+//   - a: Attestation to check
+//   - archive: Archive holding the snapshots
+//   - q: Quorum whose members may sign evidence attestations
+//
+// Returns:
+//   - ConstitutionalError naming the first missing or mismatched snapshot
+func VerifyEvidenceAttestation(a *EvidenceAttestation, archive Archive, q *Quorum) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	if _, err := q.Verify(ContextEvidence, hash, a.Signatures); err != nil {
+		return err
+	}
+	for _, s := range a.Snapshots {
+		data, err := archive.Get(s.ContentHash)
+		if err != nil {
+			return NewConstitutionalError(fmt.Sprintf("snapshot of %s: %v", s.URL, err))
+		}
+		if ContentHash(data) != s.ContentHash || len(data) != s.Size {
+			return NewVerificationError(fmt.Sprintf("snapshot of %s does not match its recorded hash", s.URL))
+		}
+	}
+	return nil
+}
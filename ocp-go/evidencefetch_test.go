@@ -0,0 +1,110 @@
+package ocp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// evidenceServer serves a mutable document, a redirect, and a large file
+func evidenceServer(t *testing.T, body *string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ruling", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(*body))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ruling", http.StatusFound)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestEvidenceFetcherAttest tests snapshotting URL evidence and verifying it later
+func TestEvidenceFetcherAttest(t *testing.T) {
+	testenv.RequireSigning(t)
+	body := "Ruling 42: the amendment is permitted."
+	srv := evidenceServer(t, &body)
+	archive := NewMemoryArchive()
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	fetcher := &EvidenceFetcher{Archive: archive, Clock: clock, AllowPrivate: true}
+
+	p := testProposal()
+	p.Evidence = append(p.Evidence,
+		map[string]string{"type": EvidenceTypeExternal, "pointer": srv.URL + "/ruling"},
+		map[string]string{"type": EvidenceTypeExternal, "pointer": srv.URL + "/ruling"},
+		map[string]string{"type": EvidenceTypeExternal, "pointer": "Reuters, 2025-11-19"},
+	)
+	attestation, err := fetcher.Attest(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Failed to attest: %v", err)
+	}
+	if len(attestation.Snapshots) != 1 {
+		t.Fatalf("Expected one snapshot, got %+v", attestation.Snapshots)
+	}
+	snapshot := attestation.Snapshots[0]
+	if snapshot.ContentHash != ContentHash([]byte(body)) || snapshot.FetchedAt != "2025-11-20T14:30:00Z" || snapshot.ContentType != "text/plain" {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+	attestationHash, _ := attestation.Hash()
+	if ok, _ := archive.Has(attestationHash); !ok {
+		t.Errorf("Expected the attestation archived under its hash")
+	}
+
+	quorum, privs := testQuorum(t)
+	for _, name := range []string{"Claude", "Gemini"} {
+		attestation.Sign(name, privs[name])
+	}
+
+	// The source changing afterwards does not affect verification
+	body = "Ruling 42 has been withdrawn."
+	if err := VerifyEvidenceAttestation(attestation, archive, quorum); err != nil {
+		t.Errorf("Failed to verify: %v", err)
+	}
+
+	attestation.Snapshots[0].ContentHash = ContentHash([]byte(body))
+	if err := VerifyEvidenceAttestation(attestation, archive, quorum); err == nil {
+		t.Errorf("Expected a changed attestation to fail")
+	}
+
+	t.Logf("✓ Evidence snapshot %s", snapshot.ContentHash)
+}
+
+// TestEvidenceFetcherRestrictions tests the fetcher's controls
+func TestEvidenceFetcherRestrictions(t *testing.T) {
+	body := "ok"
+	srv := evidenceServer(t, &body)
+	ctx := context.Background()
+
+	strict := &EvidenceFetcher{Archive: NewMemoryArchive()}
+	if _, err := strict.Fetch(ctx, srv.URL+"/ruling"); err == nil {
+		t.Errorf("Expected a loopback address to be refused")
+	}
+
+	fetcher := &EvidenceFetcher{Archive: NewMemoryArchive(), AllowPrivate: true, MaxBytes: 1024}
+	for _, url := range []string{
+		srv.URL + "/moved",
+		srv.URL + "/large",
+		srv.URL + "/missing",
+		"file:///etc/passwd",
+		"sha256:abc123",
+	} {
+		if _, err := fetcher.Fetch(ctx, url); err == nil {
+			t.Errorf("Expected %s to be refused", url)
+		}
+	}
+	if !IsEvidenceURL("https://example.org/a") || IsEvidenceURL("https:///a") {
+		t.Errorf("Unexpected URL classification")
+	}
+
+	t.Log("✓ Private addresses, redirects, oversized and non-HTTP evidence refused")
+}
@@ -0,0 +1,152 @@
+// executor.go - Execution of ratified proposals with signed receipts
+//
+// Every state change must be attributable after the fact. Executors therefore
+// return an ExecutionReceipt that ties the executed proposal hash to the agent
+// that executed it, when it ran, what it consumed, and the state it produced.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Executor applies ratified proposals and reports a signed receipt
+type Executor interface {
+	Execute(p *ContractProposal) (*ExecutionReceipt, error)
+}
+
+// Applier performs the actual state change for a proposal.
+//
+// Returns:
+//   - Hash of the resulting constitutional state
+//   - Resources consumed by the change (wall time is measured by the caller)
+type Applier interface {
+	Apply(p *ContractProposal) (string, ResourceUsage, error)
+}
+
+// ApplierFunc adapts a function to the Applier interface
+type ApplierFunc func(p *ContractProposal) (string, ResourceUsage, error)
+
+// Apply calls f(p)
+func (f ApplierFunc) Apply(p *ContractProposal) (string, ResourceUsage, error) {
+	return f(p)
+}
+
+// ResourceUsage records what an execution consumed
+type ResourceUsage struct {
+	WallTimeMillis int64 `json:"wall_time_ms"`
+	Operations     int64 `json:"operations"`
+	BytesWritten   int64 `json:"bytes_written"`
+}
+
+// ToMap converts ResourceUsage to a map for canonicalization
+func (u ResourceUsage) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"wall_time_ms":  u.WallTimeMillis,
+		"operations":    u.Operations,
+		"bytes_written": u.BytesWritten,
+	}
+}
+
+// ExecutionReceipt is the signed, archived record of one execution
+type ExecutionReceipt struct {
+	ProposalHash    string        `json:"proposal_hash"`
+	ExecutorAgent   string        `json:"executor_agent"`
+	StartedAt       string        `json:"started_at"`
+	FinishedAt      string        `json:"finished_at"`
+	Usage           ResourceUsage `json:"resource_usage"`
+	ResultStateHash string        `json:"result_state_hash"`
+	Signature       Signature     `json:"executor_signature"`
+}
+
+// ToMap converts the receipt body to a map for canonicalization.
+// The executor signature is excluded since it signs this map's hash.
+func (r *ExecutionReceipt) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":     r.ProposalHash,
+		"executor_agent":    r.ExecutorAgent,
+		"started_at":        r.StartedAt,
+		"finished_at":       r.FinishedAt,
+		"resource_usage":    r.Usage.ToMap(),
+		"result_state_hash": r.ResultStateHash,
+	}
+}
+
+// SignedMap returns the receipt body together with the executor signature,
+// which is the form stored in the archive
+func (r *ExecutionReceipt) SignedMap() map[string]interface{} {
+	m := r.ToMap()
+	m["executor_signature"] = r.Signature.ToMap()
+	return m
+}
+
+// GetHash returns the semantic hash of the receipt body
+func (r *ExecutionReceipt) GetHash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// Verify checks the executor signature against the executor's public key
+func (r *ExecutionReceipt) Verify(key ed25519.PublicKey) error {
+	if r.Signature.Signer != r.ExecutorAgent {
+		return NewVerificationError(fmt.Sprintf("receipt signed by %q, not executor %q", r.Signature.Signer, r.ExecutorAgent))
+	}
+	hash, err := r.GetHash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextReceipt, hash, r.Signature)
+}
+
+// ReceiptExecutor wraps an Applier, producing a signed receipt for every
+// successful execution and storing it in an Archive
+type ReceiptExecutor struct {
+	Agent   string
+	Key     ed25519.PrivateKey
+	Applier Applier
+	Archive Archive
+	// Clock supplies execution timestamps; defaults to SystemClock
+	Clock Clock
+}
+
+// Execute applies p, then signs and archives the receipt
+func (e *ReceiptExecutor) Execute(p *ContractProposal) (*ExecutionReceipt, error) {
+	clock := clockOrSystem(e.Clock)
+
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	started := clock.Now().UTC()
+	stateHash, usage, err := e.Applier.Apply(p)
+	if err != nil {
+		return nil, fmt.Errorf("execute %s: %w", proposalHash, err)
+	}
+	finished := clock.Now().UTC()
+	usage.WallTimeMillis = finished.Sub(started).Milliseconds()
+
+	receipt := &ExecutionReceipt{
+		ProposalHash:    proposalHash,
+		ExecutorAgent:   e.Agent,
+		StartedAt:       Timestamp(started),
+		FinishedAt:      Timestamp(finished),
+		Usage:           usage,
+		ResultStateHash: stateHash,
+	}
+	hash, err := receipt.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	receipt.Signature, err = SignHash(e.Agent, e.Key, ContextReceipt, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Archive != nil {
+		if _, err := ArchiveObject(e.Archive, receipt.SignedMap()); err != nil {
+			return nil, fmt.Errorf("archive receipt: %w", err)
+		}
+	}
+	return receipt, nil
+}
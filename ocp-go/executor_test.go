@@ -0,0 +1,89 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestReceiptExecutor tests that executions produce signed, archived receipts
+func TestReceiptExecutor(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Executor-1")
+	archive := NewMemoryArchive()
+
+	clock := time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC)
+	executor := &ReceiptExecutor{
+		Agent: "Executor-1",
+		Key:   priv,
+		Applier: ApplierFunc(func(p *ContractProposal) (string, ResourceUsage, error) {
+			return "sha256:fedcba", ResourceUsage{Operations: 3, BytesWritten: 512}, nil
+		}),
+		Archive: archive,
+		Clock: ClockFunc(func() time.Time {
+			clock = clock.Add(250 * time.Millisecond)
+			return clock
+		}),
+	}
+
+	proposal := testProposal()
+	receipt, err := executor.Execute(proposal)
+	if err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+
+	proposalHash, _ := proposal.GetHash()
+	if receipt.ProposalHash != proposalHash {
+		t.Errorf("Receipt should reference the proposal hash")
+	}
+	if receipt.Usage.WallTimeMillis != 250 || receipt.Usage.Operations != 3 {
+		t.Errorf("Unexpected resource usage: %+v", receipt.Usage)
+	}
+	if receipt.StartedAt != "2025-11-20T14:30:00.25Z" {
+		t.Errorf("Unexpected start time: %s", receipt.StartedAt)
+	}
+
+	if err := receipt.Verify(pub); err != nil {
+		t.Errorf("Receipt should verify: %v", err)
+	}
+
+	archived, err := SemanticHash(receipt.SignedMap())
+	if err != nil {
+		t.Fatalf("Failed to hash receipt: %v", err)
+	}
+	if ok, _ := archive.Has(archived); !ok {
+		t.Errorf("Signed receipt should be archived")
+	}
+
+	receipt.ResultStateHash = "sha256:forged"
+	if err := receipt.Verify(pub); err == nil {
+		t.Errorf("Modified receipt should fail verification")
+	}
+
+	t.Logf("✓ Receipt for %s verifies and is archived as %s", proposalHash[:16], archived[:16])
+}
+
+// TestReceiptExecutorFailure tests that failed executions produce no receipt
+func TestReceiptExecutorFailure(t *testing.T) {
+	_, priv := testKey("Executor-1")
+	archive := NewMemoryArchive()
+	failure := errors.New("target locked")
+
+	executor := &ReceiptExecutor{
+		Agent: "Executor-1",
+		Key:   priv,
+		Applier: ApplierFunc(func(p *ContractProposal) (string, ResourceUsage, error) {
+			return "", ResourceUsage{}, failure
+		}),
+		Archive: archive,
+	}
+
+	if _, err := executor.Execute(testProposal()); !errors.Is(err, failure) {
+		t.Errorf("Execution error should be returned, got %v", err)
+	}
+	if len(archive.blobs) != 0 {
+		t.Errorf("Failed executions should not be archived")
+	}
+}
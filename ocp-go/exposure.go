@@ -0,0 +1,179 @@
+// exposure.go - Stake exposure of an agent across open positions
+//
+// An agent's reputation is at risk wherever it is locked: the stake behind
+// its own proposals, its contributions to proposals it co-sponsors, and the
+// bonds of its unresolved challenges and pending appeals. Escrow.Exposure gathers these positions
+// from the ledger into an ExposureReport, so an agent can check its own risk
+// limit before sponsoring or challenging again. Every position is valued at
+// the amount recorded on the ledger, the most it can lose, and positions are
+// listed in ledger order, so any node holding the same ledger (and lifecycle
+// states) produces the same report and hash.
+//
+// Challenge bonds are open until resolved on the ledger, and appeal stakes
+// until ruled on or lapsed. Proposal stakes and
+// sponsorships are open until the escrow's Lifecycle reports the proposal
+// ratified or reverted; without a Lifecycle every recorded stake counts, which
+// overstates exposure rather than understating it.
+
+package ocp
+
+// Exposure position kinds
+const (
+	ExposureProposal    = "proposal"
+	ExposureSponsorship = "sponsorship"
+	ExposureChallenge   = "challenge"
+	ExposureAppeal      = "appeal"
+)
+
+// ExposurePosition is one stake an agent has locked
+type ExposurePosition struct {
+	Kind         string `json:"kind"`
+	ProposalHash string `json:"proposal_hash"`
+	// EntryHash is the ledger entry that locked the stake
+	EntryHash string `json:"entry_hash"`
+	Height    uint64 `json:"height"`
+	Amount    int    `json:"amount"`
+}
+
+// ToMap converts an ExposurePosition to a map for canonicalization
+func (p ExposurePosition) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"kind":          p.Kind,
+		"proposal_hash": p.ProposalHash,
+		"entry_hash":    p.EntryHash,
+		"height":        p.Height,
+		"amount":        p.Amount,
+	}
+}
+
+// ExposureReport summarizes the stakes an agent has locked
+type ExposureReport struct {
+	Agent        string `json:"agent"`
+	LedgerHeight uint64 `json:"ledger_height"`
+	// Positions lists the open positions in ledger order
+	Positions      []ExposurePosition `json:"positions"`
+	ProposalStake  int                `json:"proposal_stake"`
+	SponsoredStake int                `json:"sponsored_stake"`
+	ChallengeBonds int                `json:"challenge_bonds"`
+	AppealStakes   int                `json:"appeal_stakes"`
+	Total          int                `json:"total"`
+}
+
+// ToMap converts an ExposureReport to a map for canonicalization
+func (r *ExposureReport) ToMap() map[string]interface{} {
+	positions := make([]interface{}, len(r.Positions))
+	for i, p := range r.Positions {
+		positions[i] = p.ToMap()
+	}
+	return map[string]interface{}{
+		"agent":           r.Agent,
+		"ledger_height":   r.LedgerHeight,
+		"positions":       positions,
+		"proposal_stake":  r.ProposalStake,
+		"sponsored_stake": r.SponsoredStake,
+		"challenge_bonds": r.ChallengeBonds,
+		"appeal_stakes":   r.AppealStakes,
+		"total":           r.Total,
+	}
+}
+
+// GetHash returns the semantic hash of the report
+func (r *ExposureReport) GetHash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// Headroom returns how much more the agent may lock under limit, or zero if
+// it is already at or over it
+func (r *ExposureReport) Headroom(limit int) int {
+	if r.Total >= limit {
+		return 0
+	}
+	return limit - r.Total
+}
+
+// Exposure reports the stakes agent has locked in open proposals,
+// sponsorships, challenges, and appeals
+//
+// Parameters:
+//   - agent: Agent whose positions to gather
+//
+// Returns:
+//   - Report of the open positions as of the ledger's current height
+func (e *Escrow) Exposure(agent string) *ExposureReport {
+	entries := e.ledger.Entries(0)
+	report := &ExposureReport{Agent: agent, Positions: []ExposurePosition{}}
+	if n := len(entries); n > 0 {
+		report.LedgerHeight = entries[n-1].Height
+	}
+
+	resolved := make(map[string]bool)
+	for _, entry := range entries {
+		switch entry.Kind {
+		case LedgerKindChallengeResolved:
+			resolved[payloadString(entry.Payload, "bond_hash")] = true
+		case LedgerKindAppealRuling, LedgerKindAppealLapsed:
+			resolved[payloadString(entry.Payload, "appeal_hash")] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		proposalHash := payloadString(entry.Payload, "proposal_hash")
+		position := ExposurePosition{ProposalHash: proposalHash, EntryHash: entry.Hash, Height: entry.Height}
+		switch entry.Kind {
+		case LedgerKindProposal:
+			if payloadString(entry.Payload, "proposer_agent") != agent || seen[proposalHash] || !e.open(proposalHash) {
+				continue
+			}
+			seen[proposalHash] = true
+			position.Kind, position.Amount = ExposureProposal, payloadInt(entry.Payload, "reputation_stake")
+			report.ProposalStake += position.Amount
+		case LedgerKindSponsorship:
+			amount, ok := sponsorStake(entry.Payload, agent)
+			if !ok || !e.open(proposalHash) {
+				continue
+			}
+			position.Kind, position.Amount = ExposureSponsorship, amount
+			report.SponsoredStake += amount
+		case LedgerKindChallengeBond:
+			if payloadString(entry.Payload, "challenger") != agent || resolved[entry.Hash] {
+				continue
+			}
+			position.Kind, position.Amount = ExposureChallenge, payloadInt(entry.Payload, "bond")
+			report.ChallengeBonds += position.Amount
+		case LedgerKindAppeal:
+			if payloadString(entry.Payload, "appellant") != agent || resolved[entry.Hash] {
+				continue
+			}
+			position.Kind, position.Amount = ExposureAppeal, payloadInt(entry.Payload, "stake")
+			report.AppealStakes += position.Amount
+		default:
+			continue
+		}
+		report.Positions = append(report.Positions, position)
+	}
+	report.Total = report.ProposalStake + report.SponsoredStake + report.ChallengeBonds + report.AppealStakes
+	return report
+}
+
+// open reports whether a proposal's stake is still locked
+func (e *Escrow) open(proposalHash string) bool {
+	if e.Lifecycle == nil {
+		return true
+	}
+	state, ok := e.Lifecycle.State(proposalHash)
+	return !ok || state == StatePending || state == StateChallenged
+}
+
+// sponsorStake returns agent's contribution recorded in a sponsorship
+// payload, excluding the proposer's own stake
+func sponsorStake(payload map[string]interface{}, agent string) (int, bool) {
+	sponsors, _ := payload["sponsors"].([]interface{})
+	for _, s := range sponsors {
+		m, _ := s.(map[string]interface{})
+		if payloadString(m, "agent") == agent {
+			return payloadInt(m, "stake"), true
+		}
+	}
+	return 0, false
+}
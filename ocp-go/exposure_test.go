@@ -0,0 +1,78 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestEscrowExposure tests positions opening and closing as proposals are
+// sponsored, challenged, and ratified
+func TestEscrowExposure(t *testing.T) {
+	testenv.RequireSigning(t)
+	pubs, privs := sponsorKeys("Gemini", "DeepSeek")
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	escrow.Lifecycle = node.Lifecycle()
+
+	p := testProposal()
+	p.ReputationStake = 20
+	if _, err := node.Submit(p); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if _, err := escrow.Sponsor(p, []Sponsorship{
+		testSponsorship(t, p, "Gemini", 30, privs["Gemini"]),
+		testSponsorship(t, p, "DeepSeek", 50, privs["DeepSeek"]),
+	}, pubs, SponsorshipPolicy{}); err != nil {
+		t.Fatalf("Failed to sponsor: %v", err)
+	}
+	q := targetProposal("q", "agent-deepseek")
+	q.ProposerAgent = "DeepSeek"
+	q.ReputationStake = 10
+	if _, err := node.Submit(q); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	bond, err := escrow.Lock("Gemini", q, 8)
+	if err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+
+	report := escrow.Exposure("Gemini")
+	if report.SponsoredStake != 30 || report.ChallengeBonds != 8 || report.Total != 38 || len(report.Positions) != 2 {
+		t.Errorf("Expected 30 sponsored and 8 bonded, got %+v", report)
+	}
+	if report.Positions[0].Kind != ExposureSponsorship || report.Positions[1].Kind != ExposureChallenge {
+		t.Errorf("Expected positions in ledger order, got %+v", report.Positions)
+	}
+	if report.Headroom(50) != 12 || report.Headroom(30) != 0 {
+		t.Errorf("Expected headroom 12 under 50 and none under 30, got %d and %d", report.Headroom(50), report.Headroom(30))
+	}
+	if deepseek := escrow.Exposure("DeepSeek"); deepseek.ProposalStake != 10 || deepseek.SponsoredStake != 50 {
+		t.Errorf("Expected DeepSeek's own stake and sponsorship, got %+v", deepseek)
+	}
+	first, _ := report.GetHash()
+	if again, _ := escrow.Exposure("Gemini").GetHash(); again != first {
+		t.Errorf("Expected the same report from the same ledger")
+	}
+
+	if _, err := escrow.Resolve(bond.EntryHash, false); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	hash, _ := p.GetHash()
+	if err := node.Lifecycle().Ratify(hash); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+	if report := escrow.Exposure("Gemini"); report.Total != 0 || len(report.Positions) != 0 {
+		t.Errorf("Expected no exposure once settled, got %+v", report)
+	}
+	if claude := escrow.Exposure("Claude"); claude.Total != 0 {
+		t.Errorf("Expected a ratified proposal's stake to be released, got %+v", claude)
+	}
+
+	escrow.Lifecycle = nil
+	if report := escrow.Exposure("Claude"); report.ProposalStake != 20 {
+		t.Errorf("Without a lifecycle every recorded stake should count, got %+v", report)
+	}
+	t.Logf("✓ Exposure of %d released once settled (report %s)", report.Total, first)
+}
@@ -0,0 +1,88 @@
+// arrowexport.go - Apache Arrow IPC and Parquet export of analytics tables
+//
+// WriteArrow writes an analytics.Table as an Arrow IPC stream, which pyarrow,
+// polars, DuckDB and Spark read directly, and WriteParquet writes it as a
+// Parquet file. Both are encoded by the Apache Arrow Go library rather than
+// by hand, so the output follows the format wherever it evolves:
+//
+//	var table analytics.Table
+//	table.Append(proposal.ToMap())
+//	err := arrowexport.WriteParquet(f, &table)
+//
+// Every table has the same schema:
+//
+//	object_hash utf8, path utf8, type utf8, value utf8, number float64 (nullable)
+//
+// It lives in the ext module so that the core module keeps no third-party
+// dependencies.
+
+package arrowexport
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/analytics"
+)
+
+// Schema is the Arrow schema of every exported table
+var Schema = arrow.NewSchema([]arrow.Field{
+	{Name: "object_hash", Type: arrow.BinaryTypes.String},
+	{Name: "path", Type: arrow.BinaryTypes.String},
+	{Name: "type", Type: arrow.BinaryTypes.String},
+	{Name: "value", Type: arrow.BinaryTypes.String},
+	{Name: "number", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+}, nil)
+
+// Record builds t as one Arrow record batch; the caller must Release it
+func Record(t *analytics.Table, mem memory.Allocator) arrow.Record {
+	b := array.NewRecordBuilder(mem, Schema)
+	defer b.Release()
+
+	b.Field(0).(*array.StringBuilder).AppendValues(t.ObjectHash, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues(t.Path, nil)
+	b.Field(2).(*array.StringBuilder).AppendValues(t.Type, nil)
+	b.Field(3).(*array.StringBuilder).AppendValues(t.Value, nil)
+	b.Field(4).(*array.Float64Builder).AppendValues(t.Number, t.HasNumber)
+	return b.NewRecord()
+}
+
+// WriteArrow writes t to w as an Arrow IPC stream: the schema, one record
+// batch, and the end-of-stream marker
+func WriteArrow(w io.Writer, t *analytics.Table) error {
+	mem := memory.NewGoAllocator()
+	rec := Record(t, mem)
+	defer rec.Release()
+
+	writer := ipc.NewWriter(w, ipc.WithSchema(Schema), ipc.WithAllocator(mem))
+	if err := writer.Write(rec); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// WriteParquet writes t to w as a Parquet file with one row group
+func WriteParquet(w io.Writer, t *analytics.Table) error {
+	mem := memory.NewGoAllocator()
+	rec := Record(t, mem)
+	defer rec.Release()
+
+	writer, err := pqarrow.NewFileWriter(Schema, w,
+		parquet.NewWriterProperties(parquet.WithAllocator(mem)),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem), pqarrow.WithStoreSchema()))
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(rec); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
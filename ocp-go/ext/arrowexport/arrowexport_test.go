@@ -0,0 +1,129 @@
+package arrowexport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/analytics"
+)
+
+// testTable flattens two proposal-like objects with every leaf type
+func testTable(t *testing.T) *analytics.Table {
+	var table analytics.Table
+	for _, confidence := range []float64{0.87, 0.42} {
+		if _, err := table.Append(map[string]interface{}{
+			"proposer_agent": "Claude",
+			"reasoning":      map[string]interface{}{"confidence": confidence},
+			"evidence":       []interface{}{map[string]interface{}{"pointer": "sha256:abc"}},
+			"approved":       true,
+			"superseded":     nil,
+			"metadata":       map[string]interface{}{},
+		}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	return &table
+}
+
+// checkColumns compares the columns of a decoded table with t
+func checkColumns(t *testing.T, table *analytics.Table, columns []arrow.Array) {
+	t.Helper()
+	if len(columns) != 5 {
+		t.Fatalf("Expected 5 columns, got %d", len(columns))
+	}
+	want := [][]string{table.ObjectHash, table.Path, table.Type, table.Value}
+	for c, values := range want {
+		col := columns[c].(*array.String)
+		if col.Len() != table.Len() {
+			t.Fatalf("Column %d has %d rows, want %d", c, col.Len(), table.Len())
+		}
+		for i, v := range values {
+			if col.Value(i) != v {
+				t.Errorf("Column %d row %d: %q, want %q", c, i, col.Value(i), v)
+			}
+		}
+	}
+	numbers := columns[4].(*array.Float64)
+	for i := 0; i < table.Len(); i++ {
+		r := table.Row(i)
+		if numbers.IsValid(i) != r.HasNumber || (r.HasNumber && numbers.Value(i) != r.Number) {
+			t.Errorf("Row %d number mismatch", i)
+		}
+	}
+}
+
+// TestWriteArrow tests that the IPC stream reads back as the table
+func TestWriteArrow(t *testing.T) {
+	table := testTable(t)
+	var buf bytes.Buffer
+	if err := WriteArrow(&buf, table); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer reader.Release()
+	if !reader.Schema().Equal(Schema) {
+		t.Errorf("Unexpected schema: %v", reader.Schema())
+	}
+	if !reader.Next() {
+		t.Fatalf("Expected a record batch: %v", reader.Err())
+	}
+	checkColumns(t, table, reader.Record().Columns())
+	if reader.Next() {
+		t.Error("Expected exactly one record batch")
+	}
+
+	var empty bytes.Buffer
+	if err := WriteArrow(&empty, &analytics.Table{}); err != nil {
+		t.Fatalf("Failed to write empty table: %v", err)
+	}
+
+	t.Logf("✓ %d rows written as %d bytes of Arrow IPC", table.Len(), buf.Len())
+}
+
+// TestWriteParquet tests that the Parquet file reads back as the table
+func TestWriteParquet(t *testing.T) {
+	table := testTable(t)
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, table); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	pf, err := file.NewParquetReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer pf.Close()
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	read, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to read table: %v", err)
+	}
+	defer read.Release()
+
+	columns := make([]arrow.Array, read.NumCols())
+	for i := range columns {
+		chunks := read.Column(i).Data().Chunks()
+		if len(chunks) != 1 {
+			t.Fatalf("Expected one chunk in column %d, got %d", i, len(chunks))
+		}
+		columns[i] = chunks[0]
+	}
+	checkColumns(t, table, columns)
+
+	t.Logf("✓ %d rows written as %d bytes of Parquet", table.Len(), buf.Len())
+}
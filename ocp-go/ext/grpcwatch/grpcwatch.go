@@ -0,0 +1,141 @@
+// grpcwatch.go - gRPC streaming subscription to ledger updates
+//
+// Executors and dashboards that follow a node's ledger would otherwise poll
+// it. Server implements the server-streaming WatchLedger RPC of ledger.proto:
+// a subscriber names the height it holds and receives every entry above it,
+// then each new entry as it is appended. The proof of each update is the hash
+// chain, so Watch, the client, verifies every entry against the last without
+// trusting the node:
+//
+//	s := grpc.NewServer()
+//	ledgerpb.RegisterLedgerServer(s, grpcwatch.NewServer(node.Ledger()))
+//
+// It lives in the ext module so that the core module keeps no third-party
+// dependencies; server.WatchLedger serves the same stream over HTTP.
+
+package grpcwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/ext/grpcwatch/ledgerpb"
+)
+
+var _ ledgerpb.LedgerServer = (*Server)(nil)
+
+// Server serves a ledger to WatchLedger subscribers
+type Server struct {
+	ledgerpb.UnimplementedLedgerServer
+	ledger *ocp.Ledger
+}
+
+// NewServer creates a Server streaming ledger
+func NewServer(ledger *ocp.Ledger) *Server {
+	return &Server{ledger: ledger}
+}
+
+// WatchLedger streams the entries above the requested height until the
+// client cancels
+func (s *Server) WatchLedger(req *ledgerpb.WatchLedgerRequest, stream grpc.ServerStreamingServer[ledgerpb.LedgerUpdate]) error {
+	from := req.GetFromHeight()
+
+	// A ledger bootstrapped from a snapshot cannot serve the entries below
+	// its snapshot, and a gap would fail the subscriber's chain
+	entries := s.ledger.Entries(from)
+	if len(entries) > 0 && entries[0].Height != from+1 {
+		return status.Errorf(codes.OutOfRange, "ledger holds entries from height %d, not %d", entries[0].Height, from+1)
+	}
+	for {
+		for i := range entries {
+			update, err := updateOf(&entries[i])
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+			from = entries[i].Height
+		}
+		if err := s.ledger.Wait(stream.Context(), from); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		entries = s.ledger.Entries(from)
+	}
+}
+
+// updateOf returns the update carrying e
+func updateOf(e *ocp.LedgerEntry) (*ledgerpb.LedgerUpdate, error) {
+	m := e.ToMap()
+	m["hash"] = e.Hash
+	if len(e.Hashes) > 0 {
+		m[ocp.HashesKey] = e.Hashes
+	}
+	form, err := ocp.Canonicalize(m, true)
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerpb.LedgerUpdate{Height: e.Height, Hash: e.Hash, PrevHash: e.PrevHash, Entry: form}, nil
+}
+
+// Watch subscribes to the ledger served over conn, calling fn with each entry
+// above fromHeight once it has been verified to chain onto the last
+//
+// Parameters:
+//   - ctx: Ends the subscription when done
+//   - conn: Connection to a node serving ledgerpb.Ledger
+//   - fromHeight, fromHash: The ledger position the caller trusts; 0 and ""
+//     to follow the ledger from genesis
+//   - fn: Called with each verified entry in height order; an error from fn
+//     ends the subscription and is returned
+//
+// Returns:
+//   - ctx.Err() once ctx is done
+//   - VerificationError if the node sends an entry that does not chain onto
+//     the last verified one, or whose fields disagree with its entry
+//   - An error wrapping io.ErrUnexpectedEOF if the node ends the stream
+func Watch(ctx context.Context, conn grpc.ClientConnInterface, fromHeight uint64, fromHash string, fn func(ocp.LedgerEntry) error) error {
+	if fromHeight > 0 && fromHash == "" {
+		return ocp.NewConstitutionalError("watching from a height above 0 needs the trusted hash at that height")
+	}
+	stream, err := ledgerpb.NewLedgerClient(conn).WatchLedger(ctx, &ledgerpb.WatchLedgerRequest{FromHeight: fromHeight})
+	if err != nil {
+		return err
+	}
+
+	height, head := fromHeight, fromHash
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("ledger stream ended after height %d: %w", height, err)
+		}
+		var entry ocp.LedgerEntry
+		if err := json.Unmarshal([]byte(update.GetEntry()), &entry); err != nil {
+			return ocp.NewVerificationError(fmt.Sprintf("update at height %d: %v", update.GetHeight(), err))
+		}
+		if entry.Height != update.GetHeight() || entry.Hash != update.GetHash() || entry.PrevHash != update.GetPrevHash() {
+			return ocp.NewVerificationError(fmt.Sprintf("update at height %d disagrees with its entry", update.GetHeight()))
+		}
+		if head, err = ocp.VerifyChain(height, head, []ocp.LedgerEntry{entry}); err != nil {
+			return err
+		}
+		height = entry.Height
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
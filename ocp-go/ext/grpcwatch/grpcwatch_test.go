@@ -0,0 +1,136 @@
+package grpcwatch
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/ext/grpcwatch/ledgerpb"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// serve starts a Server for ledger on an in-memory listener and returns a
+// connection to it
+func serve(t *testing.T, ledger *ocp.Ledger) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	ledgerpb.RegisterLedgerServer(s, NewServer(ledger))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// TestWatch tests that a subscriber receives existing and newly appended
+// entries, verified against the hash chain
+func TestWatch(t *testing.T) {
+	ledger := ocp.NewLedger()
+	ledger.Append("policy", map[string]interface{}{"note": "first"})
+	trusted := ledger.Entries(0)[0]
+	ledger.Append("policy", map[string]interface{}{"note": "second"})
+	conn := serve(t, ledger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var heights []uint64
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, conn, trusted.Height, trusted.Hash, func(e ocp.LedgerEntry) error {
+			heights = append(heights, e.Height)
+			if e.Height == 3 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ledger.Append("policy", map[string]interface{}{"note": "third"})
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the subscription to end with its context, got %v", err)
+	}
+	if len(heights) != 2 || heights[0] != 2 || heights[1] != 3 {
+		t.Errorf("Expected entries 2 and 3, got %v", heights)
+	}
+	t.Logf("✓ Streamed heights %v over gRPC", heights)
+}
+
+// TestWatchRefusesUntrustedChain tests that an entry not chaining onto the
+// trusted hash ends the subscription
+func TestWatchRefusesUntrustedChain(t *testing.T) {
+	ledger := ocp.NewLedger()
+	ledger.Append("policy", map[string]interface{}{"note": "first"})
+	ledger.Append("policy", map[string]interface{}{"note": "second"})
+	conn := serve(t, ledger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := Watch(ctx, conn, 1, "not-the-hash", func(ocp.LedgerEntry) error { return nil })
+	var ce *ocp.ConstitutionalError
+	if !errors.As(err, &ce) || ce.ErrorType != "VerificationError" {
+		t.Errorf("Expected a VerificationError, got %v", err)
+	}
+	if err := Watch(ctx, conn, 1, "", nil); err == nil {
+		t.Error("Expected a height without a trusted hash to be refused")
+	}
+
+	t.Log("✓ Untrusted chain refused")
+}
+
+// TestWatchLedgerBelowSnapshot tests that a ledger bootstrapped from a
+// snapshot refuses heights it does not hold rather than leaving a gap
+func TestWatchLedgerBelowSnapshot(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quorum, err := ocp.NewQuorum(map[string]ed25519.PublicKey{"Claude": pub}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := ocp.NewLedger()
+	source.Append("policy", map[string]interface{}{"note": "first"})
+	snap, err := source.Snapshot(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snap.Sign("Claude", priv); err != nil {
+		t.Fatal(err)
+	}
+	source.Append("policy", map[string]interface{}{"note": "second"})
+	replica, err := ocp.BootstrapLedger(snap, quorum, source.Entries(1))
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %v", err)
+	}
+	conn := serve(t, replica)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := ledgerpb.NewLedgerClient(conn).WatchLedger(ctx, &ledgerpb.WatchLedgerRequest{FromHeight: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.OutOfRange {
+		t.Errorf("Expected OutOfRange below the snapshot, got %v", err)
+	}
+	t.Log("✓ Heights below the snapshot refused")
+}
@@ -0,0 +1,214 @@
+// ledger.proto - gRPC subscription to an OCP node's ledger
+//
+// Regenerate with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative ledger.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: ledger.proto
+
+package ledgerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchLedgerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Height the subscriber already holds; 0 to start from genesis
+	FromHeight    uint64 `protobuf:"varint,1,opt,name=from_height,json=fromHeight,proto3" json:"from_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchLedgerRequest) Reset() {
+	*x = WatchLedgerRequest{}
+	mi := &file_ledger_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLedgerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLedgerRequest) ProtoMessage() {}
+
+func (x *WatchLedgerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLedgerRequest.ProtoReflect.Descriptor instead.
+func (*WatchLedgerRequest) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchLedgerRequest) GetFromHeight() uint64 {
+	if x != nil {
+		return x.FromHeight
+	}
+	return 0
+}
+
+// LedgerUpdate carries one entry. Its proof is the hash chain: prev_hash names
+// the previous entry's hash, so a subscriber that trusts the hash at
+// from_height verifies every later entry without trusting the node.
+type LedgerUpdate struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Height   uint64                 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Hash     string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	PrevHash string                 `protobuf:"bytes,3,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	// Canonical JSON of the entry with its hash and any dual hashes, the form
+	// GET /v1/ledger/watch streams
+	Entry         string `protobuf:"bytes,4,opt,name=entry,proto3" json:"entry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LedgerUpdate) Reset() {
+	*x = LedgerUpdate{}
+	mi := &file_ledger_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerUpdate) ProtoMessage() {}
+
+func (x *LedgerUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_ledger_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerUpdate.ProtoReflect.Descriptor instead.
+func (*LedgerUpdate) Descriptor() ([]byte, []int) {
+	return file_ledger_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LedgerUpdate) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *LedgerUpdate) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *LedgerUpdate) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *LedgerUpdate) GetEntry() string {
+	if x != nil {
+		return x.Entry
+	}
+	return ""
+}
+
+var File_ledger_proto protoreflect.FileDescriptor
+
+const file_ledger_proto_rawDesc = "" +
+	"\n" +
+	"\fledger.proto\x12\rocp.ledger.v1\"5\n" +
+	"\x12WatchLedgerRequest\x12\x1f\n" +
+	"\vfrom_height\x18\x01 \x01(\x04R\n" +
+	"fromHeight\"m\n" +
+	"\fLedgerUpdate\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x04R\x06height\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x1b\n" +
+	"\tprev_hash\x18\x03 \x01(\tR\bprevHash\x12\x14\n" +
+	"\x05entry\x18\x04 \x01(\tR\x05entry2Y\n" +
+	"\x06Ledger\x12O\n" +
+	"\vWatchLedger\x12!.ocp.ledger.v1.WatchLedgerRequest\x1a\x1b.ocp.ledger.v1.LedgerUpdate0\x01BCZAgithub.com/seanrugg/ai_constitution/ocp-go/ext/grpcwatch/ledgerpbb\x06proto3"
+
+var (
+	file_ledger_proto_rawDescOnce sync.Once
+	file_ledger_proto_rawDescData []byte
+)
+
+func file_ledger_proto_rawDescGZIP() []byte {
+	file_ledger_proto_rawDescOnce.Do(func() {
+		file_ledger_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ledger_proto_rawDesc), len(file_ledger_proto_rawDesc)))
+	})
+	return file_ledger_proto_rawDescData
+}
+
+var file_ledger_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_ledger_proto_goTypes = []any{
+	(*WatchLedgerRequest)(nil), // 0: ocp.ledger.v1.WatchLedgerRequest
+	(*LedgerUpdate)(nil),       // 1: ocp.ledger.v1.LedgerUpdate
+}
+var file_ledger_proto_depIdxs = []int32{
+	0, // 0: ocp.ledger.v1.Ledger.WatchLedger:input_type -> ocp.ledger.v1.WatchLedgerRequest
+	1, // 1: ocp.ledger.v1.Ledger.WatchLedger:output_type -> ocp.ledger.v1.LedgerUpdate
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_ledger_proto_init() }
+func file_ledger_proto_init() {
+	if File_ledger_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ledger_proto_rawDesc), len(file_ledger_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ledger_proto_goTypes,
+		DependencyIndexes: file_ledger_proto_depIdxs,
+		MessageInfos:      file_ledger_proto_msgTypes,
+	}.Build()
+	File_ledger_proto = out.File
+	file_ledger_proto_goTypes = nil
+	file_ledger_proto_depIdxs = nil
+}
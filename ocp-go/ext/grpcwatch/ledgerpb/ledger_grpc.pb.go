@@ -0,0 +1,141 @@
+// ledger.proto - gRPC subscription to an OCP node's ledger
+//
+// Regenerate with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative ledger.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ledger.proto
+
+package ledgerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Ledger_WatchLedger_FullMethodName = "/ocp.ledger.v1.Ledger/WatchLedger"
+)
+
+// LedgerClient is the client API for Ledger service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ledger serves a node's ledger to subscribers
+type LedgerClient interface {
+	// WatchLedger streams every entry above from_height, then each new entry
+	// as it is appended, until the client cancels. A ledger bootstrapped from
+	// a snapshot above from_height fails with OUT_OF_RANGE.
+	WatchLedger(ctx context.Context, in *WatchLedgerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LedgerUpdate], error)
+}
+
+type ledgerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLedgerClient(cc grpc.ClientConnInterface) LedgerClient {
+	return &ledgerClient{cc}
+}
+
+func (c *ledgerClient) WatchLedger(ctx context.Context, in *WatchLedgerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LedgerUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Ledger_ServiceDesc.Streams[0], Ledger_WatchLedger_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchLedgerRequest, LedgerUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ledger_WatchLedgerClient = grpc.ServerStreamingClient[LedgerUpdate]
+
+// LedgerServer is the server API for Ledger service.
+// All implementations must embed UnimplementedLedgerServer
+// for forward compatibility.
+//
+// Ledger serves a node's ledger to subscribers
+type LedgerServer interface {
+	// WatchLedger streams every entry above from_height, then each new entry
+	// as it is appended, until the client cancels. A ledger bootstrapped from
+	// a snapshot above from_height fails with OUT_OF_RANGE.
+	WatchLedger(*WatchLedgerRequest, grpc.ServerStreamingServer[LedgerUpdate]) error
+	mustEmbedUnimplementedLedgerServer()
+}
+
+// UnimplementedLedgerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLedgerServer struct{}
+
+func (UnimplementedLedgerServer) WatchLedger(*WatchLedgerRequest, grpc.ServerStreamingServer[LedgerUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLedger not implemented")
+}
+func (UnimplementedLedgerServer) mustEmbedUnimplementedLedgerServer() {}
+func (UnimplementedLedgerServer) testEmbeddedByValue()                {}
+
+// UnsafeLedgerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LedgerServer will
+// result in compilation errors.
+type UnsafeLedgerServer interface {
+	mustEmbedUnimplementedLedgerServer()
+}
+
+func RegisterLedgerServer(s grpc.ServiceRegistrar, srv LedgerServer) {
+	// If the following call pancis, it indicates UnimplementedLedgerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Ledger_ServiceDesc, srv)
+}
+
+func _Ledger_WatchLedger_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchLedgerRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LedgerServer).WatchLedger(m, &grpc.GenericServerStream[WatchLedgerRequest, LedgerUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Ledger_WatchLedgerServer = grpc.ServerStreamingServer[LedgerUpdate]
+
+// Ledger_ServiceDesc is the grpc.ServiceDesc for Ledger service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ledger_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ocp.ledger.v1.Ledger",
+	HandlerType: (*LedgerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchLedger",
+			Handler:       _Ledger_WatchLedger_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ledger.proto",
+}
@@ -0,0 +1,52 @@
+// zstd.go - Zstandard compression for archived blobs
+//
+// Compressor implements ocp.Compressor with klauspost/compress, for archives
+// of large evidence where zstd's ratio and speed beat the core module's gzip:
+//
+//	archive := ocp.NewObjectArchive(store, "evidence/")
+//	archive.Compressor = zstd.New()
+//
+// It lives in the ext module so that the core module keeps no third-party
+// dependencies (see compress.go in ocp-go).
+
+package zstd
+
+import (
+	"github.com/klauspost/compress/zstd"
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// MaxDecodedSize bounds a decompressed blob, so a small object cannot expand
+// without limit on read
+const MaxDecodedSize = 1 << 30
+
+var _ ocp.Compressor = (*Compressor)(nil)
+
+// Compressor compresses with Zstandard. It is safe for concurrent use.
+type Compressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// New creates a Compressor at the default level
+func New() *Compressor {
+	// Neither constructor fails without a reader, writer, or invalid option
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecodedSize))
+	return &Compressor{encoder: encoder, decoder: decoder}
+}
+
+// Name returns "zstd"
+func (c *Compressor) Name() string {
+	return "zstd"
+}
+
+// Compress encodes data as a single zstd frame
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress decodes zstd frames
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
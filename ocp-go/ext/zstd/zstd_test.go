@@ -0,0 +1,60 @@
+package zstd
+
+import (
+	"bytes"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// memoryStore is an in-memory ocp.ObjectStore with conditional puts
+type memoryStore map[string][]byte
+
+func (s memoryStore) PutObject(key string, data []byte, opts ocp.PutObjectOptions) error {
+	if _, ok := s[key]; ok && opts.IfNoneMatch {
+		return ocp.ErrObjectExists
+	}
+	s[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s memoryStore) GetObject(key string) ([]byte, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, ocp.ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (s memoryStore) HeadObject(key string) (bool, error) {
+	_, ok := s[key]
+	return ok, nil
+}
+
+// TestCompressor tests zstd objects round-tripping through an ObjectArchive
+func TestCompressor(t *testing.T) {
+	store := memoryStore{}
+	archive := ocp.NewObjectArchive(store, "evidence/")
+	archive.Compressor = New()
+
+	data := bytes.Repeat([]byte(`{"evidence":"sha256:abc123def456"}`), 500)
+	hash, err := archive.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != ocp.ContentHash(data) {
+		t.Errorf("Expected the hash of the uncompressed blob, got %s", hash)
+	}
+	stored := store[archive.Key(hash)]
+	if len(stored) >= len(data) {
+		t.Errorf("Expected compression, stored %d of %d bytes", len(stored), len(data))
+	}
+	got, err := archive.Get(hash)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Expected the original blob back, got %v", err)
+	}
+	if _, err := New().Decompress([]byte("not zstd")); err == nil {
+		t.Error("Expected garbage to be refused")
+	}
+	t.Logf("✓ %d bytes stored as %d zstd bytes", len(data), len(stored))
+}
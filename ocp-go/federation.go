@@ -0,0 +1,246 @@
+// federation.go - Verifiable references into other constitutions
+//
+// Organizations governed by different constitutions can make agreements that
+// cite each other's governed state. A constitution is identified by its
+// genesis hash, and its state at a height by the ledger head hash there (the
+// state root), which commits to every earlier entry. A ForeignReference names
+// a proposal or article by (constitution ID, state root, inclusion proof): the
+// proof is the entry recording the target followed by every later entry up to
+// the state root, so checking it is a hash-chain walk with no access to the
+// foreign ledger.
+//
+// State roots themselves are trusted only when the foreign constitution's
+// quorum has signed them. A Federation holds each trusted constitution's
+// quorum, accepts signed StateRootAttestations, and verifies references
+// against the roots it has accepted.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+)
+
+// Foreign reference target kinds
+const (
+	// ForeignProposal cites an accepted proposal by its hash
+	ForeignProposal = "proposal"
+	// ForeignConstitution cites a ratified constitution by its hash
+	ForeignConstitution = "constitution"
+	// ForeignArticle cites the value at a path within a ratified JSON constitution
+	ForeignArticle = "article"
+)
+
+// StateRootAttestation is a foreign quorum's signed statement that a ledger
+// head is part of its constitution's history
+type StateRootAttestation struct {
+	ConstitutionID string      `json:"constitution_id"`
+	Height         uint64      `json:"height"`
+	StateRoot      string      `json:"state_root"`
+	Signatures     []Signature `json:"signatures"`
+}
+
+// ToMap converts an attestation to a map for canonicalization, excluding signatures
+func (a *StateRootAttestation) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"constitution_id": a.ConstitutionID,
+		"height":          a.Height,
+		"state_root":      a.StateRoot,
+	}
+}
+
+// Hash returns the semantic hash signed by the quorum
+func (a *StateRootAttestation) Hash() (string, error) {
+	return SemanticHash(a.ToMap())
+}
+
+// Sign adds a signature from signer over the attestation hash
+func (a *StateRootAttestation) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextStateRoot, hash)
+	if err != nil {
+		return err
+	}
+	a.Signatures = append(a.Signatures, sig)
+	return nil
+}
+
+// InclusionProof shows that Entry is part of the history ending at a state root
+type InclusionProof struct {
+	// Entry records the cited target
+	Entry LedgerEntry `json:"entry"`
+	// Path holds every entry after Entry up to and including the state root
+	Path []LedgerEntry `json:"path"`
+	// Document is the constitution text for ForeignArticle references
+	Document []byte `json:"document,omitempty"`
+}
+
+// BuildInclusionProof proves the entry at height against the ledger's head
+func BuildInclusionProof(l *Ledger, height uint64) (*InclusionProof, error) {
+	if height == 0 {
+		return nil, NewConstitutionalError("height 0 has no entry")
+	}
+	entries := l.Entries(height - 1)
+	if len(entries) == 0 || entries[0].Height != height {
+		return nil, NewConstitutionalError(fmt.Sprintf("ledger does not hold entry %d", height))
+	}
+	return &InclusionProof{Entry: entries[0], Path: entries[1:]}, nil
+}
+
+// ForeignReference cites a target in another constitution's governed state
+type ForeignReference struct {
+	ConstitutionID string `json:"constitution_id"`
+	Height         uint64 `json:"height"`
+	StateRoot      string `json:"state_root"`
+	// Kind is ForeignProposal, ForeignConstitution, or ForeignArticle
+	Kind string `json:"kind"`
+	// TargetHash is the proposal hash, or the constitution hash for articles
+	TargetHash string `json:"target_hash"`
+	// ArticlePath and ArticleHash locate an article; see hashing.PathIndex
+	ArticlePath string         `json:"article_path,omitempty"`
+	ArticleHash string         `json:"article_hash,omitempty"`
+	Proof       InclusionProof `json:"proof"`
+}
+
+// ToMap converts a reference to a map for canonicalization. The proof is
+// excluded: it supports the reference but is not part of what is cited.
+func (r *ForeignReference) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"constitution_id": r.ConstitutionID,
+		"height":          r.Height,
+		"state_root":      r.StateRoot,
+		"kind":            r.Kind,
+		"target_hash":     r.TargetHash,
+	}
+	if r.Kind == ForeignArticle {
+		m["article_path"] = r.ArticlePath
+		m["article_hash"] = r.ArticleHash
+	}
+	return m
+}
+
+// Hash returns the semantic hash of the reference
+func (r *ForeignReference) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// VerifyInclusion checks the reference's proof against its own state root.
+// It does not check that the state root is trusted; see Federation.Verify.
+func (r *ForeignReference) VerifyInclusion() error {
+	p := &r.Proof
+	if p.Entry.Height == 0 {
+		return NewVerificationError("inclusion proof has no entry")
+	}
+	if _, err := VerifyChain(p.Entry.Height-1, p.Entry.PrevHash, []LedgerEntry{p.Entry}); err != nil {
+		return err
+	}
+	root, err := VerifyChain(p.Entry.Height, p.Entry.Hash, p.Path)
+	if err != nil {
+		return err
+	}
+	height := p.Entry.Height + uint64(len(p.Path))
+	if root != r.StateRoot || height != r.Height {
+		return NewVerificationError(fmt.Sprintf("proof ends at %d/%s, not the cited state root %d/%s", height, root, r.Height, r.StateRoot))
+	}
+
+	switch r.Kind {
+	case ForeignProposal:
+		if p.Entry.Kind != LedgerKindProposal || payloadString(p.Entry.Payload, "proposal_hash") != r.TargetHash {
+			return NewVerificationError(fmt.Sprintf("entry %d does not accept proposal %s", p.Entry.Height, r.TargetHash))
+		}
+	case ForeignConstitution, ForeignArticle:
+		recorded := payloadString(p.Entry.Payload, "constitution_hash")
+		if p.Entry.Kind == LedgerKindGenesis {
+			if state, ok := p.Entry.Payload["state"].(map[string]interface{}); ok {
+				recorded = payloadString(state, "constitution_hash")
+			}
+		} else if p.Entry.Kind != LedgerKindConstitution {
+			recorded = ""
+		}
+		if recorded != r.TargetHash {
+			return NewVerificationError(fmt.Sprintf("entry %d does not ratify constitution %s", p.Entry.Height, r.TargetHash))
+		}
+		if r.Kind == ForeignArticle {
+			return r.verifyArticle()
+		}
+	default:
+		return NewVerificationError(fmt.Sprintf("unknown foreign reference kind %q", r.Kind))
+	}
+	return nil
+}
+
+// verifyArticle checks the cited article against the constitution document
+func (r *ForeignReference) verifyArticle() error {
+	doc := r.Proof.Document
+	if ConstitutionHash(doc) != r.TargetHash {
+		return NewVerificationError("proof document is not the cited constitution")
+	}
+	obj, err := DecodeStrict(doc)
+	if err != nil {
+		return NewVerificationError(fmt.Sprintf("articles can only be cited in JSON constitutions: %v", err))
+	}
+	index, err := BuildPathIndex(obj)
+	if err != nil {
+		return err
+	}
+	if hash, ok := index[r.ArticlePath]; !ok || hash != r.ArticleHash {
+		return NewVerificationError(fmt.Sprintf("article %q does not have the cited hash", r.ArticlePath))
+	}
+	return nil
+}
+
+// Federation verifies references into a set of trusted foreign constitutions
+type Federation struct {
+	mu      sync.RWMutex
+	members map[string]*Quorum
+	roots   map[string]map[string]uint64
+}
+
+// NewFederation creates a Federation with no trusted constitutions
+func NewFederation() *Federation {
+	return &Federation{members: make(map[string]*Quorum), roots: make(map[string]map[string]uint64)}
+}
+
+// Trust registers the quorum that attests state roots of a constitution
+func (f *Federation) Trust(constitutionID string, quorum *Quorum) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[constitutionID] = quorum
+	if f.roots[constitutionID] == nil {
+		f.roots[constitutionID] = make(map[string]uint64)
+	}
+}
+
+// AcceptRoot records a state root attested by its constitution's quorum
+func (f *Federation) AcceptRoot(a *StateRootAttestation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	quorum, ok := f.members[a.ConstitutionID]
+	if !ok {
+		return NewVerificationError(fmt.Sprintf("constitution %s is not trusted", a.ConstitutionID))
+	}
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	if _, err := quorum.Verify(ContextStateRoot, hash, a.Signatures); err != nil {
+		return err
+	}
+	f.roots[a.ConstitutionID][a.StateRoot] = a.Height
+	return nil
+}
+
+// Verify checks that r's state root was accepted and that its proof holds
+func (f *Federation) Verify(r *ForeignReference) error {
+	f.mu.RLock()
+	height, ok := f.roots[r.ConstitutionID][r.StateRoot]
+	f.mu.RUnlock()
+	if !ok || height != r.Height {
+		return NewVerificationError(fmt.Sprintf("state root %s of %s is not attested", r.StateRoot, r.ConstitutionID))
+	}
+	return r.VerifyInclusion()
+}
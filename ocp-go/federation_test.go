@@ -0,0 +1,91 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestForeignReference tests citing proposals and articles of another constitution
+func TestForeignReference(t *testing.T) {
+	testenv.RequireSigning(t)
+	// The foreign organization's ledger, quorum, and constitution
+	quorum, privs := testQuorum(t)
+	foreign, err := NewLedgerFromGenesis(testGenesis(t))
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	genesisHash := payloadString(foreign.Entries(0)[0].Payload, "genesis_hash")
+	node := NewNode(foreign)
+	acceptance, err := node.Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	doc := []byte(`{"articles": {"I": "Sovereignty", "II": "Interoperability"}}`)
+	constitutionHash, err := RatifyConstitution(foreign, nil, quorum, doc, signAll(t, ContextConstitution, ConstitutionHash(doc), privs, "Claude", "Gemini"))
+	if err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+	foreign.Append("note", map[string]interface{}{"text": "later history"})
+
+	attestation := &StateRootAttestation{ConstitutionID: genesisHash, Height: foreign.Height(), StateRoot: foreign.Head()}
+	attestation.Sign("Claude", privs["Claude"])
+
+	proof, err := BuildInclusionProof(foreign, acceptance.LedgerHeight)
+	if err != nil {
+		t.Fatalf("Failed to build proof: %v", err)
+	}
+	ref := &ForeignReference{
+		ConstitutionID: genesisHash,
+		Height:         foreign.Height(),
+		StateRoot:      foreign.Head(),
+		Kind:           ForeignProposal,
+		TargetHash:     acceptance.ProposalHash,
+		Proof:          *proof,
+	}
+
+	// The citing organization trusts the foreign quorum
+	federation := NewFederation()
+	federation.Trust(genesisHash, quorum)
+	if err := federation.AcceptRoot(attestation); err == nil {
+		t.Errorf("A root signed below the quorum threshold should be rejected")
+	}
+	if err := federation.Verify(ref); err == nil {
+		t.Errorf("References to unattested roots should fail")
+	}
+	attestation.Sign("DeepSeek", privs["DeepSeek"])
+	if err := federation.AcceptRoot(attestation); err != nil {
+		t.Fatalf("Failed to accept root: %v", err)
+	}
+	if err := federation.Verify(ref); err != nil {
+		t.Errorf("Proposal reference should verify: %v", err)
+	}
+
+	forged := *ref
+	forged.TargetHash = "sha256:forged"
+	if err := federation.Verify(&forged); err == nil {
+		t.Errorf("A reference to a proposal not in the entry should fail")
+	}
+
+	articleProof, _ := BuildInclusionProof(foreign, foreign.Height()-1)
+	articleProof.Document = doc
+	index, _ := BuildPathIndex(map[string]interface{}{"articles": map[string]interface{}{"I": "Sovereignty", "II": "Interoperability"}})
+	article := &ForeignReference{
+		ConstitutionID: genesisHash,
+		Height:         foreign.Height(),
+		StateRoot:      foreign.Head(),
+		Kind:           ForeignArticle,
+		TargetHash:     constitutionHash,
+		ArticlePath:    "articles.II",
+		ArticleHash:    index["articles.II"],
+		Proof:          *articleProof,
+	}
+	if err := federation.Verify(article); err != nil {
+		t.Errorf("Article reference should verify: %v", err)
+	}
+	article.ArticleHash = index["articles.I"]
+	if err := federation.Verify(article); err == nil {
+		t.Errorf("An article with the wrong hash should fail")
+	}
+	t.Log("✓ Foreign proposal and article references verified against an attested state root")
+}
@@ -0,0 +1,156 @@
+// fraudproof.go - Signed fraud proofs challenging proposals and ledger entries
+//
+// A FraudProof is the challenge object of OCP-0001 §4.2 in the form fixed by
+// fraud_proof.schema.json. Its identifier is derived from its content, so the
+// same challenge raised twice carries the same id, and the challenger signs
+// the proof's semantic hash. Document returns the schema form, in which the
+// signature is carried as its base64 value.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/schema"
+)
+
+// Fraud types of fraud_proof.schema.json
+const (
+	FraudHashMismatch            = "HASH_MISMATCH"
+	FraudProceduralViolation     = "PROCEDURAL_VIOLATION"
+	FraudConstitutionalViolation = "CONSTITUTIONAL_VIOLATION"
+	FraudExecutionInconsistency  = "EXECUTION_INCONSISTENCY"
+	FraudReputationManipulation  = "REPUTATION_MANIPULATION"
+	fraudProofIDPrefix           = "fp-"
+	fraudProofIDLength           = 32
+)
+
+// FraudEvidence substantiates a fraud proof
+type FraudEvidence struct {
+	// ArchiveReference is the archive hash of the offending object or of the
+	// verification report demonstrating the violation
+	ArchiveReference string `json:"archive_reference"`
+	// RecomputedHash is the hash the challenger computed for the object
+	RecomputedHash            string `json:"recomputed_hash"`
+	ContradictoryExecutionLog string `json:"contradictory_execution_log,omitempty"`
+}
+
+// ToMap converts FraudEvidence to a map for canonicalization
+func (e FraudEvidence) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"archive_reference": e.ArchiveReference,
+		"recomputed_hash":   e.RecomputedHash,
+	}
+	if e.ContradictoryExecutionLog != "" {
+		m["contradictory_execution_log"] = e.ContradictoryExecutionLog
+	}
+	return m
+}
+
+// FraudProof challenges a proposal or ledger entry
+type FraudProof struct {
+	ID                     string        `json:"fraud_proof_id"`
+	OffendingContractID    string        `json:"offending_contract_id"`
+	ChallengerAgent        string        `json:"challenger_agent_id"`
+	SubmissionTimestamp    string        `json:"submission_timestamp"`
+	ConstitutionalCitation string        `json:"constitutional_citation"`
+	FraudType              string        `json:"fraud_type"`
+	Justification          string        `json:"justification_message"`
+	Evidence               FraudEvidence `json:"evidence"`
+	ReputationStakeProof   string        `json:"reputation_stake_proof,omitempty"`
+	Signature              Signature     `json:"-"`
+}
+
+// ToMap converts a FraudProof to a map for canonicalization. The signature
+// is excluded, since it signs this form.
+func (f *FraudProof) ToMap() map[string]interface{} {
+	m := f.contentMap()
+	m["fraud_proof_id"] = f.ID
+	return m
+}
+
+// contentMap is ToMap without the id, which is derived from it
+func (f *FraudProof) contentMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"offending_contract_id":   f.OffendingContractID,
+		"challenger_agent_id":     f.ChallengerAgent,
+		"constitutional_citation": f.ConstitutionalCitation,
+		"fraud_type":              f.FraudType,
+		"justification_message":   f.Justification,
+		"evidence":                f.Evidence.ToMap(),
+	}
+	if f.SubmissionTimestamp != "" {
+		m["submission_timestamp"] = f.SubmissionTimestamp
+	}
+	if f.ReputationStakeProof != "" {
+		m["reputation_stake_proof"] = f.ReputationStakeProof
+	}
+	return m
+}
+
+// ContentID returns the identifier derived from the proof's content
+func (f *FraudProof) ContentID() (string, error) {
+	hash, err := SemanticHash(f.contentMap())
+	if err != nil {
+		return "", err
+	}
+	return fraudProofIDPrefix + hash[:fraudProofIDLength], nil
+}
+
+// Hash returns the semantic hash of the proof
+func (f *FraudProof) Hash() (string, error) {
+	return SemanticHash(f.ToMap())
+}
+
+// Sign assigns the proof's content-derived id and signs it as the challenger
+func (f *FraudProof) Sign(key ed25519.PrivateKey) error {
+	id, err := f.ContentID()
+	if err != nil {
+		return err
+	}
+	f.ID = id
+	hash, err := f.Hash()
+	if err != nil {
+		return err
+	}
+	f.Signature, err = SignHash(f.ChallengerAgent, key, ContextFraudProof, hash)
+	return err
+}
+
+// Verify checks the proof's id and its signature against the challenger's key
+func (f *FraudProof) Verify(key ed25519.PublicKey) error {
+	if f.Signature.Signer != f.ChallengerAgent {
+		return NewVerificationError(fmt.Sprintf("fraud proof by %s signed by %s", f.ChallengerAgent, f.Signature.Signer))
+	}
+	if id, err := f.ContentID(); err != nil || id != f.ID {
+		return NewVerificationError(fmt.Sprintf("fraud proof id %s does not match its content", f.ID))
+	}
+	hash, err := f.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextFraudProof, hash, f.Signature)
+}
+
+// Document returns the proof in the form of fraud_proof.schema.json
+func (f *FraudProof) Document() map[string]interface{} {
+	m := f.ToMap()
+	if f.Signature.Value != "" {
+		m["signature"] = f.Signature.Value
+	}
+	return m
+}
+
+// Validate checks the proof's document against fraud_proof.schema.json
+func (f *FraudProof) Validate() error {
+	form, err := CanonicalizeValue(f.Document())
+	if err != nil {
+		return err
+	}
+	doc, err := DecodeStrict([]byte(form))
+	if err != nil {
+		return err
+	}
+	return schema.ValidateFraudProof(doc)
+}
@@ -0,0 +1,49 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestFraudProofSignAndValidate tests content ids, signatures, and the schema form
+func TestFraudProofSignAndValidate(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Gemini")
+	proof := &FraudProof{
+		OffendingContractID:    "550e8400-e29b-41d4-a716-446655440000",
+		ChallengerAgent:        "Gemini",
+		SubmissionTimestamp:    "2025-11-20T15:35:00Z",
+		ConstitutionalCitation: "OCP-0001 §5.2",
+		FraudType:              FraudHashMismatch,
+		Justification:          "canonical_serialization does not match the proposal",
+		Evidence:               FraudEvidence{ArchiveReference: "sha256:abc", RecomputedHash: "sha256:def"},
+	}
+	if err := proof.Sign(priv); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if err := proof.Verify(pub); err != nil {
+		t.Errorf("Expected signed proof to verify, got %v", err)
+	}
+	if err := proof.Validate(); err != nil {
+		t.Errorf("Expected proof to match the schema, got %v", err)
+	}
+
+	again := *proof
+	again.Signature = Signature{}
+	again.Sign(priv)
+	if again.ID != proof.ID {
+		t.Errorf("Expected the same content to give the same id")
+	}
+
+	proof.Justification = "edited"
+	if err := proof.Verify(pub); err == nil {
+		t.Errorf("Expected an edited proof to fail verification")
+	}
+	proof.FraudType = "MADE_UP"
+	if err := proof.Validate(); err == nil {
+		t.Errorf("Expected an unknown fraud type to fail the schema")
+	}
+
+	t.Logf("✓ Fraud proof %s signed and valid", again.ID)
+}
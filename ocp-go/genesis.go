@@ -0,0 +1,144 @@
+// genesis.go - Genesis state and ledger bootstrap
+//
+// The genesis state fixes the constitution text, the founding agents and their
+// keys, and the initial policy table. Its semantic hash identifies a deployment:
+// nodes configured with the same genesis hash agree on where history starts, and
+// ValidateGenesis rejects a ledger whose first entry commits to anything else.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// LedgerKindGenesis is the kind of the first entry of every ledger
+const LedgerKindGenesis = "genesis"
+
+// Founder is a founding agent and its signing key
+type Founder struct {
+	Agent     string
+	PublicKey ed25519.PublicKey
+}
+
+// Genesis is the initial constitutional state of a deployment
+type Genesis struct {
+	ConstitutionHash string
+	Founders         []Founder
+	Policies         map[string]interface{}
+}
+
+// NewGenesis builds the genesis state. Founders are sorted by agent name so the
+// genesis hash does not depend on the order they were listed in.
+//
+// Parameters:
+//   - constitution: Exact bytes of the ratified constitution document
+//   - founders: Founding agents; names must be unique and keys valid
+//   - policies: Initial policy table (may be nil)
+//
+// Returns:
+//   - Genesis state
+func NewGenesis(constitution []byte, founders []Founder, policies map[string]interface{}) (*Genesis, error) {
+	if len(constitution) == 0 {
+		return nil, NewConstitutionalError("genesis requires a constitution")
+	}
+	if len(founders) == 0 {
+		return nil, NewConstitutionalError("genesis requires at least one founder")
+	}
+
+	sorted := append([]Founder{}, founders...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Agent < sorted[j].Agent })
+	for i, f := range sorted {
+		if f.Agent == "" {
+			return nil, NewConstitutionalError("founder agent name is empty")
+		}
+		if len(f.PublicKey) != ed25519.PublicKeySize {
+			return nil, NewConstitutionalError(fmt.Sprintf("invalid public key for founder %q", f.Agent))
+		}
+		if i > 0 && sorted[i-1].Agent == f.Agent {
+			return nil, NewConstitutionalError(fmt.Sprintf("duplicate founder %q", f.Agent))
+		}
+	}
+
+	if policies == nil {
+		policies = map[string]interface{}{}
+	}
+	return &Genesis{
+		ConstitutionHash: ContentHash(constitution),
+		Founders:         sorted,
+		Policies:         policies,
+	}, nil
+}
+
+// ToMap converts a Genesis to a map for canonicalization
+func (g *Genesis) ToMap() map[string]interface{} {
+	founders := make([]interface{}, len(g.Founders))
+	for i, f := range g.Founders {
+		founders[i] = map[string]interface{}{
+			"agent":      f.Agent,
+			"public_key": hex.EncodeToString(f.PublicKey),
+		}
+	}
+	return map[string]interface{}{
+		"constitution_hash": g.ConstitutionHash,
+		"founders":          founders,
+		"policies":          g.Policies,
+	}
+}
+
+// Hash returns the genesis state hash
+func (g *Genesis) Hash() (string, error) {
+	return SemanticHash(g.ToMap())
+}
+
+// FounderQuorum returns a Quorum of the founders with the given threshold
+func (g *Genesis) FounderQuorum(threshold int) (*Quorum, error) {
+	members := make(map[string]ed25519.PublicKey, len(g.Founders))
+	for _, f := range g.Founders {
+		members[f.Agent] = f.PublicKey
+	}
+	return NewQuorum(members, threshold)
+}
+
+// NewLedgerFromGenesis creates a ledger whose first entry records g
+func NewLedgerFromGenesis(g *Genesis) (*Ledger, error) {
+	hash, err := g.Hash()
+	if err != nil {
+		return nil, err
+	}
+	l := NewLedger()
+	if _, err := l.Append(LedgerKindGenesis, map[string]interface{}{
+		"genesis_hash": hash,
+		"state":        g.ToMap(),
+	}); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ValidateGenesis checks that the ledger starts from genesis, that its first
+// entry records genesisHash, and that the recorded state hashes to it.
+func ValidateGenesis(l *Ledger, genesisHash string) error {
+	entries := l.Entries(0)
+	if len(entries) == 0 || entries[0].Height != 1 {
+		return NewVerificationError("ledger does not contain its first entry")
+	}
+	first := entries[0]
+	if first.Kind != LedgerKindGenesis {
+		return NewVerificationError(fmt.Sprintf("first ledger entry has kind %q, expected %q", first.Kind, LedgerKindGenesis))
+	}
+	if payloadString(first.Payload, "genesis_hash") != genesisHash {
+		return NewVerificationError("first ledger entry records a different genesis hash")
+	}
+	state, ok := first.Payload["state"].(map[string]interface{})
+	if !ok {
+		return NewVerificationError("genesis entry has no state")
+	}
+	if ok, err := VerifySemanticHash(state, genesisHash); err != nil || !ok {
+		return NewVerificationError("genesis state does not match genesis hash")
+	}
+	_, err := VerifyChain(0, "", entries)
+	return err
+}
@@ -0,0 +1,104 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testGenesis returns a genesis with three founders
+func testGenesis(t *testing.T) *Genesis {
+	var founders []Founder
+	for _, name := range []string{"Gemini", "Claude", "DeepSeek"} {
+		pub, _ := testKey(name)
+		founders = append(founders, Founder{Agent: name, PublicKey: pub})
+	}
+	g, err := NewGenesis([]byte("# Constitution v2.1\n"), founders, map[string]interface{}{
+		"challenge_window_hours": float64(72),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create genesis: %v", err)
+	}
+	return g
+}
+
+// TestGenesisHashDeterministic tests that founder order does not affect the hash
+func TestGenesisHashDeterministic(t *testing.T) {
+	g := testGenesis(t)
+	hash, err := g.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash genesis: %v", err)
+	}
+
+	reversed := append([]Founder{}, g.Founders...)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	other, _ := NewGenesis([]byte("# Constitution v2.1\n"), reversed, g.Policies)
+	if otherHash, _ := other.Hash(); otherHash != hash {
+		t.Errorf("Genesis hash should not depend on founder order")
+	}
+
+	amended, _ := NewGenesis([]byte("# Constitution v2.2\n"), g.Founders, g.Policies)
+	if amendedHash, _ := amended.Hash(); amendedHash == hash {
+		t.Errorf("Genesis hash should commit to the constitution text")
+	}
+
+	if q, err := g.FounderQuorum(2); err != nil || len(q.Members) != 3 {
+		t.Errorf("Founder quorum should include all founders: %v", err)
+	}
+
+	t.Logf("✓ Genesis hash: %s", hash)
+}
+
+// TestNewGenesisRejectsInvalid tests validation of genesis inputs
+func TestNewGenesisRejectsInvalid(t *testing.T) {
+	pub, _ := testKey("Claude")
+	valid := []Founder{{Agent: "Claude", PublicKey: pub}}
+
+	cases := map[string]func() (*Genesis, error){
+		"no constitution":   func() (*Genesis, error) { return NewGenesis(nil, valid, nil) },
+		"no founders":       func() (*Genesis, error) { return NewGenesis([]byte("c"), nil, nil) },
+		"duplicate founder": func() (*Genesis, error) { return NewGenesis([]byte("c"), append(valid, valid[0]), nil) },
+		"bad key": func() (*Genesis, error) {
+			return NewGenesis([]byte("c"), []Founder{{Agent: "Claude", PublicKey: ed25519.PublicKey{1}}}, nil)
+		},
+	}
+	for name, build := range cases {
+		if _, err := build(); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+}
+
+// TestValidateGenesis tests matching a ledger's first entry to a genesis hash
+func TestValidateGenesis(t *testing.T) {
+	testenv.RequireSigning(t)
+	g := testGenesis(t)
+	hash, _ := g.Hash()
+
+	ledger, err := NewLedgerFromGenesis(g)
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	ledger.Append("proposal", map[string]interface{}{"id": "p-1"})
+
+	if err := ValidateGenesis(ledger, hash); err != nil {
+		t.Errorf("Ledger should validate against its genesis: %v", err)
+	}
+
+	otherGenesis, _ := NewGenesis([]byte("# Another deployment\n"), g.Founders, nil)
+	otherHash, _ := otherGenesis.Hash()
+	if err := ValidateGenesis(ledger, otherHash); err == nil {
+		t.Errorf("Ledger should not validate against a different genesis")
+	}
+
+	bare := NewLedger()
+	bare.Append("proposal", map[string]interface{}{"id": "p-1"})
+	if err := ValidateGenesis(bare, hash); err == nil {
+		t.Errorf("Ledger without a genesis entry should be rejected")
+	}
+
+	t.Logf("✓ Ledger validated against genesis %s", hash)
+}
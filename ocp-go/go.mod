@@ -0,0 +1,3 @@
+module github.com/seanrugg/ai_constitution/ocp-go
+
+go 1.22
@@ -0,0 +1,715 @@
+// gossip.go - Peer-to-peer propagation of proposals, challenges, and votes
+//
+// Package gossip lets OCP nodes form a network over plain TCP. Every message is
+// identified by the semantic hash of its kind and payload, which gives free
+// deduplication: a node forwards a message to its peers only the first time it
+// sees that hash. Periodic anti-entropy rounds exchange hash inventories so that
+// peers which missed a broadcast (or joined late) converge on the same set.
+//
+// A peer cannot hold the node hostage: frames are written to each peer from
+// its own bounded queue under a write deadline, so a stalled peer is dropped
+// instead of blocking fan-out; frames read from a peer are capped at
+// MaxFrameSize; and only the MaxSeen most recently seen messages are kept.
+//
+// Synchronization is paced rather than queued: inventories are split into
+// frames of at most MaxInventoryHashes hashes, and the messages a peer asks
+// for are handed to its writer one at a time as it reads them, so a peer
+// missing any number of messages catches up without filling its queue.
+
+package gossip
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// Message kinds propagated by the gossip layer
+const (
+	KindProposal  = "proposal"
+	KindChallenge = "challenge"
+	KindVote      = "vote"
+)
+
+// frame types on the wire
+const (
+	frameMessage   = "msg"
+	frameInventory = "inv"
+	frameWant      = "want"
+	frameHello     = "hello"
+)
+
+// Defaults for the zero values of the corresponding Config fields
+const (
+	DefaultMaxFrameSize = 4 << 20
+	DefaultQueueSize    = 256
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultMaxSeen      = 1 << 16
+)
+
+// MaxInventoryHashes bounds the hashes in one inventory or want frame, about
+// 270 KB of hashes, so a full inventory fits any reasonable MaxFrameSize
+const MaxInventoryHashes = 4096
+
+// hashFrameBytes bounds the encoded size of one hash in a frame: 64 hex
+// digits, quotes, and a comma, with room to spare
+const hashFrameBytes = 128
+
+// errPeerBackedUp is returned when a frame cannot be queued for a peer
+var errPeerBackedUp = errors.New("gossip: peer send queue full")
+
+// errPeerClosed is returned when a frame is sent to a disconnected peer
+var errPeerClosed = errors.New("gossip: peer closed")
+
+// errFrameTooLarge ends a peer's read loop when a frame exceeds MaxFrameSize
+var errFrameTooLarge = errors.New("gossip: frame too large")
+
+// Message is a single gossiped object
+type Message struct {
+	Kind    string                 `json:"kind"`
+	Payload map[string]interface{} `json:"payload"`
+	Hash    string                 `json:"hash"`
+}
+
+// NewMessage creates a message and computes its hash
+func NewMessage(kind string, payload map[string]interface{}) (Message, error) {
+	msg := Message{Kind: kind, Payload: payload}
+	hash, err := msg.ComputeHash()
+	if err != nil {
+		return Message{}, err
+	}
+	msg.Hash = hash
+	return msg, nil
+}
+
+// ComputeHash returns the semantic hash of the message kind and payload
+func (m Message) ComputeHash() (string, error) {
+	return hashing.SemanticHash(map[string]interface{}{
+		"kind":    m.Kind,
+		"payload": m.Payload,
+	})
+}
+
+// UnmarshalJSON decodes a message received from a peer through
+// canonical.DecodeStrict, so payloads with duplicate keys or lone surrogates
+// never reach hashing.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	obj, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return err
+	}
+	kind, _ := obj["kind"].(string)
+	hash, _ := obj["hash"].(string)
+	payload, ok := obj["payload"].(map[string]interface{})
+	if !ok && obj["payload"] != nil {
+		return canonical.NewCanonicalizationError("gossip payload must be an object")
+	}
+	*m = Message{Kind: kind, Payload: payload, Hash: hash}
+	return nil
+}
+
+type frame struct {
+	Type    string                 `json:"type"`
+	Message *Message               `json:"message,omitempty"`
+	Hashes  []string               `json:"hashes,omitempty"`
+	Info    map[string]interface{} `json:"info,omitempty"`
+}
+
+// frameFields holds the exact JSON names of frame's fields
+var frameFields = map[string]bool{"type": true, "message": true, "hashes": true, "info": true}
+
+// UnmarshalJSON decodes a frame received from a peer through
+// canonical.DecodeStrict, as Message does, so a frame with duplicate, unknown
+// or differently cased keys is refused rather than matched case-insensitively.
+func (f *frame) UnmarshalJSON(data []byte) error {
+	obj, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return err
+	}
+	for k := range obj {
+		if !frameFields[k] {
+			return canonical.NewCanonicalizationError(fmt.Sprintf("unknown gossip frame field %q", k))
+		}
+	}
+	type plain frame
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*f = frame(p)
+	return nil
+}
+
+// Config configures a gossip Node
+type Config struct {
+	// ListenAddr is the TCP address to accept peers on, e.g. "127.0.0.1:0"
+	ListenAddr string
+	// SyncInterval is the period of anti-entropy rounds; zero disables them
+	SyncInterval time.Duration
+	// Handler is called once for every new valid message, local or remote
+	Handler func(Message)
+	// Info is sent to every peer in a hello frame before any other traffic,
+	// typically ocp.NewNodeInfo(...).ToMap()
+	Info map[string]interface{}
+	// AcceptPeer, if set, checks a peer's hello Info; the peer is disconnected
+	// if it returns an error, and no frames are accepted from it before its
+	// hello has been checked
+	AcceptPeer func(info map[string]interface{}) error
+	// MaxFrameSize bounds the encoded size of a frame read from a peer; a peer
+	// sending a larger one is disconnected. Zero means DefaultMaxFrameSize.
+	MaxFrameSize int
+	// QueueSize is the number of frames queued for a peer before it is
+	// considered stalled and disconnected. Zero means DefaultQueueSize.
+	QueueSize int
+	// WriteTimeout bounds a single frame write to a peer; a peer that does not
+	// read within it is disconnected. Zero means DefaultWriteTimeout.
+	WriteTimeout time.Duration
+	// MaxSeen bounds the messages kept for deduplication and anti-entropy; the
+	// least recently seen are forgotten first. Zero means DefaultMaxSeen.
+	MaxSeen int
+}
+
+// Node is a gossip participant
+type Node struct {
+	cfg      Config
+	listener net.Listener
+
+	mu sync.Mutex
+	// seen maps a message hash to its element in order, most recent first
+	seen  map[string]*list.Element
+	order *list.List
+	peers map[*peer]bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type peer struct {
+	conn net.Conn
+	// addr is the address the peer was dialed at; empty for inbound peers
+	addr string
+	// out queues frames for writeLoop; done is closed when the peer is removed
+	out  chan frame
+	done chan struct{}
+	// bulk hands sync frames to writeLoop one at a time, so peerSyncLoop
+	// moves at the peer's pace without taking space in out
+	bulk chan frame
+	// kick wakes peerSyncLoop when sync work is pending
+	kick      chan struct{}
+	closeOnce sync.Once
+	// info is the peer's hello payload; hello is set once it has been accepted
+	info  map[string]interface{}
+	hello bool
+	// Sync work for peerSyncLoop, guarded by the node's mutex: an inventory
+	// round is due, the hashes to ask the peer for, and the hashes it asked
+	// for. Each list holds at most MaxSeen distinct hashes; the sets hold the
+	// same hashes, so repeated inventory and want frames add nothing twice.
+	inventoryDue bool
+	missing      []string
+	wanted       []string
+	missingSet   map[string]bool
+	wantedSet    map[string]bool
+}
+
+// send queues f for the peer without blocking. A peer whose queue is full
+// has stopped reading and is disconnected.
+func (p *peer) send(f frame) error {
+	select {
+	case <-p.done:
+		return errPeerClosed
+	default:
+	}
+	select {
+	case p.out <- f:
+		return nil
+	default:
+		p.conn.Close()
+		return errPeerBackedUp
+	}
+}
+
+// sendPaced blocks until writeLoop takes f, the peer is removed, or stop is
+// closed
+func (p *peer) sendPaced(f frame, stop <-chan struct{}) error {
+	select {
+	case p.bulk <- f:
+		return nil
+	case <-p.done:
+		return errPeerClosed
+	case <-stop:
+		return errPeerClosed
+	}
+}
+
+// wake signals peerSyncLoop, coalescing with a signal already pending
+func (p *peer) wake() {
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+}
+
+// close stops the peer's writer and closes its connection
+func (p *peer) close() {
+	p.closeOnce.Do(func() { close(p.done) })
+	p.conn.Close()
+}
+
+// frameLimiter fails a read once more than the remaining budget of bytes has
+// been read since the last reset, bounding what a decoder buffers per frame
+type frameLimiter struct {
+	r         io.Reader
+	max       int
+	remaining int
+}
+
+func (l *frameLimiter) Read(b []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errFrameTooLarge
+	}
+	if len(b) > l.remaining {
+		b = b[:l.remaining]
+	}
+	n, err := l.r.Read(b)
+	l.remaining -= n
+	return n, err
+}
+
+func (l *frameLimiter) reset() {
+	l.remaining = l.max
+}
+
+// New creates a Node and starts listening for peers
+func New(cfg Config) (*Node, error) {
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("gossip listen: %w", err)
+	}
+
+	if cfg.MaxFrameSize <= 0 {
+		cfg.MaxFrameSize = DefaultMaxFrameSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = DefaultWriteTimeout
+	}
+	if cfg.MaxSeen <= 0 {
+		cfg.MaxSeen = DefaultMaxSeen
+	}
+
+	n := &Node{
+		cfg:      cfg,
+		listener: listener,
+		seen:     make(map[string]*list.Element),
+		order:    list.New(),
+		peers:    make(map[*peer]bool),
+		done:     make(chan struct{}),
+	}
+
+	n.wg.Add(1)
+	go n.acceptLoop()
+
+	if cfg.SyncInterval > 0 {
+		n.wg.Add(1)
+		go n.syncLoop()
+	}
+	return n, nil
+}
+
+// Addr returns the address the node is listening on
+func (n *Node) Addr() string {
+	return n.listener.Addr().String()
+}
+
+// Connect dials a peer and starts exchanging messages with it. Both sides
+// send their inventory immediately, so the new link is synchronized at once.
+func (n *Node) Connect(addr string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("gossip connect: %w", err)
+	}
+	n.addPeer(conn, addr)
+	return nil
+}
+
+// Connected reports whether a link dialed by Connect(addr) is still open, so
+// callers can redial peers that restarted
+func (n *Node) Connected(addr string) bool {
+	for _, p := range n.peerList() {
+		if p.addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Broadcast publishes a new message to the network
+//
+// Returns:
+//   - Hash identifying the message
+func (n *Node) Broadcast(kind string, payload map[string]interface{}) (string, error) {
+	msg, err := NewMessage(kind, payload)
+	if err != nil {
+		return "", err
+	}
+	n.accept(msg, nil)
+	return msg.Hash, nil
+}
+
+// Has reports whether the node has seen the message with the given hash
+func (n *Node) Has(hash string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.seen[hash]
+	return ok
+}
+
+// Get returns a previously seen message
+func (n *Node) Get(hash string) (Message, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	elem, ok := n.seen[hash]
+	if !ok {
+		return Message{}, false
+	}
+	return elem.Value.(Message), true
+}
+
+// Inventory returns the sorted hashes of all retained messages
+func (n *Node) Inventory() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.inventoryLocked()
+}
+
+// SyncNow starts one anti-entropy round with every connected peer
+func (n *Node) SyncNow() {
+	for _, p := range n.peerList() {
+		n.requestInventory(p)
+	}
+}
+
+// PeerInfo returns the hello payloads of connected peers that have sent one
+func (n *Node) PeerInfo() []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, p := range n.peerList() {
+		n.mu.Lock()
+		info := p.info
+		n.mu.Unlock()
+		if info != nil {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+// Close disconnects all peers and stops the node
+func (n *Node) Close() error {
+	var err error
+	n.closeOnce.Do(func() {
+		close(n.done)
+		err = n.listener.Close()
+		for _, p := range n.peerList() {
+			p.close()
+		}
+	})
+	n.wg.Wait()
+	return err
+}
+
+// accept records a message and forwards it to every peer except from.
+// Returns false if the message was already known.
+func (n *Node) accept(msg Message, from *peer) bool {
+	n.mu.Lock()
+	if !n.rememberLocked(msg) {
+		n.mu.Unlock()
+		return false
+	}
+	peers := make([]*peer, 0, len(n.peers))
+	for p := range n.peers {
+		if p != from {
+			peers = append(peers, p)
+		}
+	}
+	n.mu.Unlock()
+
+	if n.cfg.Handler != nil {
+		n.cfg.Handler(msg)
+	}
+	for _, p := range peers {
+		_ = p.send(frame{Type: frameMessage, Message: &msg})
+	}
+	return true
+}
+
+// rememberLocked records msg as the most recently seen message, forgetting
+// the least recent beyond MaxSeen. Returns false if msg was already known, in
+// which case it only becomes the most recent.
+func (n *Node) rememberLocked(msg Message) bool {
+	if elem, ok := n.seen[msg.Hash]; ok {
+		n.order.MoveToFront(elem)
+		return false
+	}
+	n.seen[msg.Hash] = n.order.PushFront(msg)
+	for n.order.Len() > n.cfg.MaxSeen {
+		oldest := n.order.Back()
+		n.order.Remove(oldest)
+		delete(n.seen, oldest.Value.(Message).Hash)
+	}
+	return true
+}
+
+func (n *Node) acceptLoop() {
+	defer n.wg.Done()
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			return
+		}
+		n.addPeer(conn, "")
+	}
+}
+
+func (n *Node) syncLoop() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+			n.SyncNow()
+		}
+	}
+}
+
+func (n *Node) addPeer(conn net.Conn, addr string) {
+	p := &peer{
+		conn: conn,
+		addr: addr,
+		out:  make(chan frame, n.cfg.QueueSize),
+		done: make(chan struct{}),
+		bulk: make(chan frame),
+		kick: make(chan struct{}, 1),
+	}
+	// Hello goes first so the peer can check compatibility before syncing
+	_ = p.send(frame{Type: frameHello, Info: n.cfg.Info})
+	n.mu.Lock()
+	n.peers[p] = true
+	n.mu.Unlock()
+	n.requestInventory(p)
+	n.wg.Add(3)
+	go n.writeLoop(p)
+	go n.readLoop(p)
+	go n.peerSyncLoop(p)
+}
+
+func (n *Node) removePeer(p *peer) {
+	n.mu.Lock()
+	delete(n.peers, p)
+	n.mu.Unlock()
+	p.close()
+}
+
+func (n *Node) peerList() []*peer {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]*peer, 0, len(n.peers))
+	for p := range n.peers {
+		out = append(out, p)
+	}
+	return out
+}
+
+// writeLoop writes queued and paced frames to the peer, each under the write
+// timeout. Paced frames wait until the hello, queued first, has been written.
+func (n *Node) writeLoop(p *peer) {
+	defer n.wg.Done()
+	enc := json.NewEncoder(p.conn)
+	var bulk chan frame
+	for {
+		var f frame
+		select {
+		case <-p.done:
+			return
+		case f = <-p.out:
+		case f = <-bulk:
+		}
+		bulk = p.bulk
+		p.conn.SetWriteDeadline(time.Now().Add(n.cfg.WriteTimeout))
+		if err := enc.Encode(f); err != nil {
+			p.conn.Close()
+			return
+		}
+	}
+}
+
+// requestInventory schedules an anti-entropy round with the peer
+func (n *Node) requestInventory(p *peer) {
+	n.mu.Lock()
+	p.inventoryDue = true
+	n.mu.Unlock()
+	p.wake()
+}
+
+// peerSyncLoop sends the peer inventory rounds, the hashes it is missing, and
+// the messages it asks for, each frame paced by writeLoop
+func (n *Node) peerSyncLoop(p *peer) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-n.done:
+			return
+		case <-p.kick:
+		}
+
+		n.mu.Lock()
+		inventory, missing, wanted := p.inventoryDue, p.missing, p.wanted
+		p.inventoryDue, p.missing, p.wanted = false, nil, nil
+		p.missingSet, p.wantedSet = nil, nil
+		n.mu.Unlock()
+
+		var frames [][]string
+		if inventory {
+			frames = n.chunk(n.Inventory())
+		}
+		for _, hashes := range frames {
+			if p.sendPaced(frame{Type: frameInventory, Hashes: hashes}, n.done) != nil {
+				return
+			}
+		}
+		for _, hashes := range n.chunk(missing) {
+			if p.sendPaced(frame{Type: frameWant, Hashes: hashes}, n.done) != nil {
+				return
+			}
+		}
+		for _, h := range wanted {
+			if msg, ok := n.Get(h); ok {
+				if p.sendPaced(frame{Type: frameMessage, Message: &msg}, n.done) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (n *Node) readLoop(p *peer) {
+	defer n.wg.Done()
+	defer n.removePeer(p)
+
+	limiter := &frameLimiter{r: p.conn, max: n.cfg.MaxFrameSize}
+	dec := json.NewDecoder(limiter)
+	for {
+		var f frame
+		limiter.reset()
+		if err := dec.Decode(&f); err != nil {
+			return
+		}
+		if f.Type == frameHello {
+			if n.cfg.AcceptPeer != nil {
+				if err := n.cfg.AcceptPeer(f.Info); err != nil {
+					return
+				}
+			}
+			n.mu.Lock()
+			p.info, p.hello = f.Info, true
+			n.mu.Unlock()
+			continue
+		}
+		if n.cfg.AcceptPeer != nil && !p.hello {
+			return
+		}
+		n.handleFrame(p, f)
+	}
+}
+
+func (n *Node) handleFrame(p *peer, f frame) {
+	switch f.Type {
+	case frameMessage:
+		if f.Message == nil {
+			return
+		}
+		msg := *f.Message
+		hash, err := msg.ComputeHash()
+		if err != nil || hash != msg.Hash {
+			// Drop messages whose content does not match their claimed hash
+			return
+		}
+		n.accept(msg, p)
+
+	case frameInventory:
+		n.mu.Lock()
+		for _, h := range f.Hashes {
+			if _, ok := n.seen[h]; !ok && !p.missingSet[h] && len(p.missing) < n.cfg.MaxSeen {
+				if p.missingSet == nil {
+					p.missingSet = make(map[string]bool)
+				}
+				p.missingSet[h] = true
+				p.missing = append(p.missing, h)
+			}
+		}
+		n.mu.Unlock()
+		p.wake()
+
+	case frameWant:
+		// peerSyncLoop answers at the peer's pace; only hashes the node holds
+		// are kept, so the backlog stays within MaxSeen
+		n.mu.Lock()
+		for _, h := range f.Hashes {
+			if _, ok := n.seen[h]; ok && !p.wantedSet[h] && len(p.wanted) < n.cfg.MaxSeen {
+				if p.wantedSet == nil {
+					p.wantedSet = make(map[string]bool)
+				}
+				p.wantedSet[h] = true
+				p.wanted = append(p.wanted, h)
+			}
+		}
+		n.mu.Unlock()
+		p.wake()
+	}
+}
+
+// chunk splits hashes into runs that fit one frame: at most
+// MaxInventoryHashes, and few enough to stay under MaxFrameSize
+func (n *Node) chunk(hashes []string) [][]string {
+	size := n.cfg.MaxFrameSize/hashFrameBytes - 1
+	if size > MaxInventoryHashes {
+		size = MaxInventoryHashes
+	}
+	if size < 1 {
+		size = 1
+	}
+	var out [][]string
+	for len(hashes) > size {
+		out = append(out, hashes[:size])
+		hashes = hashes[size:]
+	}
+	if len(hashes) > 0 {
+		out = append(out, hashes)
+	}
+	return out
+}
+
+func (n *Node) inventoryLocked() []string {
+	out := make([]string, 0, len(n.seen))
+	for h := range n.seen {
+		out = append(out, h)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,456 @@
+package gossip
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects messages delivered to a node's handler
+type recorder struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+func (r *recorder) handle(m Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, m)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+// newTestNode starts a node on a random local port
+func newTestNode(t *testing.T, rec *recorder) *Node {
+	cfg := Config{ListenAddr: "127.0.0.1:0"}
+	if rec != nil {
+		cfg.Handler = rec.handle
+	}
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+// TestBroadcastPropagation tests multi-hop propagation with deduplication
+func TestBroadcastPropagation(t *testing.T) {
+	var recA, recB, recC recorder
+	a, b, c := newTestNode(t, &recA), newTestNode(t, &recB), newTestNode(t, &recC)
+
+	// Triangle topology: every message reaches each node over two paths
+	for _, link := range [][2]*Node{{a, b}, {b, c}, {c, a}} {
+		if err := link[0].Connect(link[1].Addr()); err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+	}
+
+	hash, err := a.Broadcast(KindProposal, map[string]interface{}{
+		"id":    "550e8400-e29b-41d4-a716-446655440000",
+		"stake": float64(60),
+	})
+	if err != nil {
+		t.Fatalf("Failed to broadcast: %v", err)
+	}
+
+	waitFor(t, "propagation to c", func() bool { return c.Has(hash) })
+	waitFor(t, "propagation to b", func() bool { return b.Has(hash) })
+	time.Sleep(50 * time.Millisecond)
+
+	for name, rec := range map[string]*recorder{"a": &recA, "b": &recB, "c": &recC} {
+		if rec.count() != 1 {
+			t.Errorf("Node %s should handle the message exactly once, got %d", name, rec.count())
+		}
+	}
+
+	t.Logf("✓ Proposal %s propagated once to every node", hash[:16])
+}
+
+// TestAntiEntropySync tests that a late joiner catches up through inventory exchange
+func TestAntiEntropySync(t *testing.T) {
+	a := newTestNode(t, nil)
+	var hashes []string
+	for _, kind := range []string{KindProposal, KindChallenge, KindVote} {
+		hash, err := a.Broadcast(kind, map[string]interface{}{"kind": kind})
+		if err != nil {
+			t.Fatalf("Failed to broadcast: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	late := newTestNode(t, nil)
+	if err := late.Connect(a.Addr()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	waitFor(t, "anti-entropy on connect", func() bool { return len(late.Inventory()) == 3 })
+
+	// Messages created while connected but delivered nowhere are recovered by SyncNow
+	extra, err := NewMessage(KindVote, map[string]interface{}{"ballot": "yes"})
+	if err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	a.mu.Lock()
+	a.rememberLocked(extra)
+	a.mu.Unlock()
+
+	a.SyncNow()
+	waitFor(t, "anti-entropy round", func() bool { return late.Has(extra.Hash) })
+
+	t.Logf("✓ Late joiner synchronized %d messages", len(late.Inventory()))
+}
+
+// TestAntiEntropyBacklog tests that a late joiner missing more messages than
+// a peer queue holds catches up in frames under MaxFrameSize without being
+// dropped
+func TestAntiEntropyBacklog(t *testing.T) {
+	cfg := Config{ListenAddr: "127.0.0.1:0", QueueSize: 16, MaxFrameSize: 4096}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	const count = 1000
+	for i := 0; i < count; i++ {
+		if _, err := a.Broadcast(KindVote, map[string]interface{}{"i": float64(i)}); err != nil {
+			t.Fatalf("Failed to broadcast: %v", err)
+		}
+	}
+	if chunks := a.chunk(a.Inventory()); len(chunks) < 2 || len(chunks[0])*hashFrameBytes > cfg.MaxFrameSize {
+		t.Fatalf("Expected the inventory split into frames under %d bytes, got %d frames", cfg.MaxFrameSize, len(chunks))
+	}
+
+	late, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { late.Close() })
+	if err := late.Connect(a.Addr()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	waitFor(t, "the backlog to sync", func() bool { return len(late.Inventory()) == count })
+	if !late.Connected(a.Addr()) || len(a.peerList()) != 1 {
+		t.Error("Expected the link to survive the sync")
+	}
+
+	late.SyncNow()
+	a.SyncNow()
+	time.Sleep(50 * time.Millisecond)
+	if !late.Connected(a.Addr()) {
+		t.Error("Expected the link to survive further rounds")
+	}
+	t.Logf("✓ Late joiner caught up on %d messages through a queue of %d", count, cfg.QueueSize)
+}
+
+// TestRejectsTamperedMessages tests that messages not matching their hash are dropped
+func TestRejectsTamperedMessages(t *testing.T) {
+	var rec recorder
+	n := newTestNode(t, &rec)
+
+	conn, err := net.Dial("tcp", n.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	msg, _ := NewMessage(KindProposal, map[string]interface{}{"id": "p-1"})
+	msg.Payload["id"] = "p-forged"
+
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(frame{Type: frameMessage, Message: &msg}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	valid, _ := NewMessage(KindProposal, map[string]interface{}{"id": "p-2"})
+	if err := enc.Encode(frame{Type: frameMessage, Message: &valid}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	waitFor(t, "valid message", func() bool { return n.Has(valid.Hash) })
+	if n.Has(msg.Hash) || rec.count() != 1 {
+		t.Errorf("Tampered message should be dropped")
+	}
+
+	t.Logf("✓ Tampered message rejected")
+}
+
+// TestRejectsAmbiguousJSON tests that peers sending duplicate keys are dropped
+func TestRejectsAmbiguousJSON(t *testing.T) {
+	var rec recorder
+	n := newTestNode(t, &rec)
+
+	conn, err := net.Dial("tcp", n.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	msg, _ := NewMessage(KindProposal, map[string]interface{}{"id": "p-1"})
+	raw := `{"type":"msg","message":{"kind":"proposal","payload":{"id":"p-1","id":"p-evil"},"hash":"` + msg.Hash + `"}}` + "\n"
+	if _, err := conn.Write([]byte(raw)); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+
+	// The node closes the connection instead of guessing which key wins
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	if n.Has(msg.Hash) || rec.count() != 0 {
+		t.Errorf("Message with duplicate keys should be dropped")
+	}
+
+	t.Logf("✓ Duplicate-key payload rejected and peer disconnected")
+}
+
+// TestRejectsAmbiguousFrames tests that frames are decoded as strictly as
+// the messages they carry
+func TestRejectsAmbiguousFrames(t *testing.T) {
+	msg, _ := NewMessage(KindProposal, map[string]interface{}{"id": "p-1"})
+	body, _ := json.Marshal(&msg)
+	frames := map[string]string{
+		"a differently cased key": `{"Type":"msg","message":` + string(body) + `}`,
+		"a duplicate key":         `{"type":"want","type":"msg","message":` + string(body) + `}`,
+		"an unknown key":          `{"type":"msg","message":` + string(body) + `,"extra":true}`,
+	}
+	for name, raw := range frames {
+		var f frame
+		if err := json.Unmarshal([]byte(raw), &f); err == nil {
+			t.Errorf("Expected a frame with %s to be refused", name)
+		}
+	}
+	var f frame
+	if err := json.Unmarshal([]byte(`{"type":"msg","message":`+string(body)+`}`), &f); err != nil || f.Message == nil || f.Message.Hash != msg.Hash {
+		t.Errorf("Expected a well-formed frame to decode, got %v", err)
+	}
+
+	n := newTestNode(t, nil)
+	conn, err := net.Dial("tcp", n.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(frames["a differently cased key"] + "\n")); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	if n.Has(msg.Hash) {
+		t.Errorf("Message in a differently cased frame should be dropped")
+	}
+
+	t.Logf("✓ %d ambiguous frames rejected", len(frames))
+}
+
+// TestInventoryDeduplicated tests that hashes repeated across inventory and
+// want frames are queued once
+func TestInventoryDeduplicated(t *testing.T) {
+	n := newTestNode(t, nil)
+	held, _ := n.Broadcast(KindProposal, map[string]interface{}{"id": "p-1"})
+	p := &peer{kick: make(chan struct{}, 1)}
+	for i := 0; i < 3; i++ {
+		n.handleFrame(p, frame{Type: frameInventory, Hashes: []string{"absent-1", "absent-2", held}})
+		n.handleFrame(p, frame{Type: frameWant, Hashes: []string{held, held}})
+	}
+	n.mu.Lock()
+	missing, wanted := len(p.missing), len(p.wanted)
+	n.mu.Unlock()
+	if missing != 2 || wanted != 1 {
+		t.Errorf("Expected 2 missing and 1 wanted hash, got %d and %d", missing, wanted)
+	}
+	t.Log("✓ Repeated hashes queued once")
+}
+
+// TestCloseConcurrent tests that Close may be called from several goroutines
+func TestCloseConcurrent(t *testing.T) {
+	n := newTestNode(t, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.Close()
+		}()
+	}
+	wg.Wait()
+	t.Log("✓ Concurrent Close is safe")
+}
+
+// TestHelloHandshake tests that peers failing AcceptPeer are disconnected
+func TestHelloHandshake(t *testing.T) {
+	strict := func(info map[string]interface{}) error {
+		if info["spec"] != "v1" {
+			return errors.New("incompatible peer")
+		}
+		return nil
+	}
+	var recA recorder
+	a, err := New(Config{ListenAddr: "127.0.0.1:0", Handler: recA.handle, Info: map[string]interface{}{"spec": "v1"}, AcceptPeer: strict})
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	good, _ := New(Config{ListenAddr: "127.0.0.1:0", Info: map[string]interface{}{"spec": "v1"}})
+	t.Cleanup(func() { good.Close() })
+	bad, _ := New(Config{ListenAddr: "127.0.0.1:0", Info: map[string]interface{}{"spec": "v2"}})
+	t.Cleanup(func() { bad.Close() })
+
+	bad.Broadcast(KindVote, map[string]interface{}{"vote": "from-v2"})
+	good.Broadcast(KindVote, map[string]interface{}{"vote": "from-v1"})
+	if err := bad.Connect(a.Addr()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if err := good.Connect(a.Addr()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	waitFor(t, "compatible peer sync", func() bool { return recA.count() == 1 })
+	waitFor(t, "incompatible peer drop", func() bool { return len(a.PeerInfo()) == 1 })
+	if recA.msgs[0].Payload["vote"] != "from-v1" {
+		t.Errorf("Only the compatible peer's message should be accepted: %v", recA.msgs[0].Payload)
+	}
+
+	t.Logf("✓ Peers after handshake: %v", a.PeerInfo())
+}
+
+// TestConnected tests tracking links to dialed peers
+func TestConnected(t *testing.T) {
+	a := newTestNode(t, nil)
+	b, err := New(Config{ListenAddr: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	addr := b.Addr()
+	if a.Connected(addr) {
+		t.Fatal("Expected no link before Connect")
+	}
+	if err := a.Connect(addr); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if !a.Connected(addr) || b.Connected(a.Addr()) {
+		t.Error("Expected only the dialing side to track the link")
+	}
+	b.Close()
+	waitFor(t, "the closed link to be dropped", func() bool { return !a.Connected(addr) })
+	t.Logf("✓ Link to %s tracked until the peer closed", addr)
+}
+
+// TestStalledPeerDropped tests that a peer which stops reading is disconnected
+// without holding up delivery to the others
+func TestStalledPeerDropped(t *testing.T) {
+	var recB recorder
+	a, err := New(Config{ListenAddr: "127.0.0.1:0", WriteTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b := newTestNode(t, &recB)
+	if err := b.Connect(a.Addr()); err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	waitFor(t, "the live peer", func() bool { return len(a.peerList()) == 1 })
+
+	// A pipe has no buffer, so every write to a peer that never reads stalls
+	stalled, other := net.Pipe()
+	defer other.Close()
+	a.addPeer(stalled, "stalled")
+
+	start := time.Now()
+	const count = 64
+	for i := 0; i < count; i++ {
+		if _, err := a.Broadcast(KindVote, map[string]interface{}{"i": float64(i)}); err != nil {
+			t.Fatalf("Failed to broadcast: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Broadcast blocked on the stalled peer for %v", elapsed)
+	}
+	waitFor(t, "the stalled peer to be dropped", func() bool { return !a.Connected("stalled") })
+	waitFor(t, "delivery to the live peer", func() bool { return recB.count() == count })
+	t.Logf("✓ Stalled peer dropped; %d messages still delivered", recB.count())
+}
+
+// TestOversizedFrameDropped tests that a peer sending a frame above
+// MaxFrameSize is disconnected before the frame is buffered
+func TestOversizedFrameDropped(t *testing.T) {
+	var rec recorder
+	n, err := New(Config{ListenAddr: "127.0.0.1:0", Handler: rec.handle, MaxFrameSize: 1024})
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	conn, err := net.Dial("tcp", n.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	small, _ := NewMessage(KindVote, map[string]interface{}{"vote": "yes"})
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(frame{Type: frameMessage, Message: &small}); err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	waitFor(t, "the small frame", func() bool { return n.Has(small.Hash) })
+
+	large, _ := NewMessage(KindVote, map[string]interface{}{"vote": strings.Repeat("y", 4096)})
+	enc.Encode(frame{Type: frameMessage, Message: &large})
+	waitFor(t, "the peer to be dropped", func() bool { return len(n.peerList()) == 0 })
+	if n.Has(large.Hash) || rec.count() != 1 {
+		t.Errorf("Expected the oversized frame to be refused")
+	}
+	t.Logf("✓ Frame above %d bytes refused", 1024)
+}
+
+// TestSeenBounded tests that only the most recently seen messages are kept
+func TestSeenBounded(t *testing.T) {
+	n, err := New(Config{ListenAddr: "127.0.0.1:0", MaxSeen: 2})
+	if err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	t.Cleanup(func() { n.Close() })
+
+	var hashes []string
+	for _, vote := range []string{"a", "b"} {
+		hash, _ := n.Broadcast(KindVote, map[string]interface{}{"vote": vote})
+		hashes = append(hashes, hash)
+	}
+	// Seeing the first again makes it the most recent, so the second goes
+	n.Broadcast(KindVote, map[string]interface{}{"vote": "a"})
+	third, _ := n.Broadcast(KindVote, map[string]interface{}{"vote": "c"})
+
+	if len(n.Inventory()) != 2 || !n.Has(hashes[0]) || n.Has(hashes[1]) || !n.Has(third) {
+		t.Errorf("Expected the least recently seen message forgotten, got %v", n.Inventory())
+	}
+	t.Logf("✓ %d messages retained", len(n.Inventory()))
+}
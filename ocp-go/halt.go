@@ -0,0 +1,629 @@
+// halt.go - Emergency halt circuit breaker
+//
+// An emergency_halt proposal is the constitution's circuit breaker for runaway
+// agent behavior. Unlike every other action it takes effect the moment a
+// quorum member submits it: there is no challenge window to wait out. In
+// exchange the halt must be reviewed by an ordinary quorum within a mandatory
+// review window, and it can only be lifted after that review, by a
+// super-quorum larger than the ordinary threshold. The review window and lift
+// threshold are fixed in the halt's ledger entry when it is triggered, so a
+// later policy change cannot lower the bar for lifting a halt already in
+// force, and VerifyHalts replays the whole sequence from the ledger. The
+// proposer signs the halt it triggers under ContextHaltTrigger and the
+// signature is recorded with it, so replay checks who halted, and that the
+// recorded window and threshold satisfy the policy rules, from the ledger
+// alone.
+//
+// A single member cannot stop the constitution indefinitely: a halt nobody
+// reviews lapses at its review deadline, a halt the review rejects ends at
+// once, and a member whose halt lapsed or was rejected may not trigger
+// another until the cooldown recorded with it has passed. Only a halt the
+// quorum upholds stays in force until a super-quorum lifts it.
+
+package ocp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ActionEmergencyHalt is the action type of emergency halt proposals
+const ActionEmergencyHalt = "emergency_halt"
+
+// Ledger entry kinds written by CircuitBreaker
+const (
+	LedgerKindEmergencyHalt   = "emergency_halt"
+	LedgerKindEmergencyReview = "emergency_review"
+	LedgerKindEmergencyLift   = "emergency_lift"
+)
+
+// Lifecycle event kinds emitted by CircuitBreaker
+const (
+	EventHalted     = "halted"
+	EventHaltLifted = "halt_lifted"
+)
+
+// ErrHalted is returned for submissions while an emergency halt is in force
+var ErrHalted = &ConstitutionalError{ErrorType: "HaltError", Message: "emergency halt in force"}
+
+// EmergencyPolicy configures emergency halts
+type EmergencyPolicy struct {
+	// ReviewWindow is the time a quorum has to review a halt after it starts
+	ReviewWindow time.Duration
+	// LiftThreshold is the number of quorum signatures needed to lift a halt;
+	// it must exceed the quorum's ordinary threshold
+	LiftThreshold int
+	// Cooldown is how long a member whose halt lapsed or was rejected must
+	// wait before triggering another; zero means DefaultHaltCooldown
+	Cooldown time.Duration
+}
+
+// DefaultHaltCooldown is the cooldown of a policy that sets none
+const DefaultHaltCooldown = 7 * 24 * time.Hour
+
+// DefaultEmergencyPolicy returns a policy with a 24 hour review window whose
+// lift threshold is every member of quorum
+func DefaultEmergencyPolicy(quorum *Quorum) EmergencyPolicy {
+	return EmergencyPolicy{ReviewWindow: 24 * time.Hour, LiftThreshold: len(quorum.Members), Cooldown: DefaultHaltCooldown}
+}
+
+// ToMap converts an EmergencyPolicy to its policy table form
+func (e EmergencyPolicy) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"review_window_ms": e.ReviewWindow.Milliseconds(),
+		"lift_threshold":   e.LiftThreshold,
+		"cooldown_ms":      e.Cooldown.Milliseconds(),
+	}
+}
+
+// cooldown returns the cooldown in force, applying the default
+func (e EmergencyPolicy) cooldown() time.Duration {
+	if e.Cooldown == 0 {
+		return DefaultHaltCooldown
+	}
+	return e.Cooldown
+}
+
+// Validate checks that the policy describes a super-quorum of quorum
+func (e EmergencyPolicy) Validate(quorum *Quorum) error {
+	if e.ReviewWindow <= 0 {
+		return NewConstitutionalError("emergency review window must be positive")
+	}
+	if e.Cooldown < 0 {
+		return NewConstitutionalError("emergency halt cooldown must not be negative")
+	}
+	if e.LiftThreshold <= quorum.Threshold || e.LiftThreshold > len(quorum.Members) {
+		return NewConstitutionalError(fmt.Sprintf("lift threshold %d must exceed the quorum threshold %d and not exceed %d members",
+			e.LiftThreshold, quorum.Threshold, len(quorum.Members)))
+	}
+	return nil
+}
+
+// EmergencyPolicyFromTable reads the "emergency_halt" member of a policy table,
+// as held by a PolicyManager
+//
+// Returns:
+//   - The policy, and false if the table has no emergency_halt member
+func EmergencyPolicyFromTable(table map[string]interface{}) (EmergencyPolicy, bool) {
+	m, ok := table["emergency_halt"].(map[string]interface{})
+	if !ok {
+		return EmergencyPolicy{}, false
+	}
+	return EmergencyPolicy{
+		ReviewWindow:  time.Duration(payloadInt(m, "review_window_ms")) * time.Millisecond,
+		LiftThreshold: payloadInt(m, "lift_threshold"),
+		Cooldown:      time.Duration(payloadInt(m, "cooldown_ms")) * time.Millisecond,
+	}, true
+}
+
+// Halt is an emergency halt recorded on the ledger
+type Halt struct {
+	EntryHash      string
+	ProposalHash   string
+	Proposer       string
+	Target         string
+	HaltedAt       time.Time
+	ReviewDeadline time.Time
+	LiftThreshold  int
+	// Cooldown is how long the proposer must wait to trigger again if this
+	// halt lapses or is rejected
+	Cooldown time.Duration
+	// Reviewed is set once a quorum review has been recorded at ReviewedAt
+	Reviewed   bool
+	ReviewedAt time.Time
+	Upheld     bool
+	Lifted     bool
+}
+
+// Overdue reports whether the review window has passed without a review
+func (h *Halt) Overdue(now time.Time) bool {
+	return !h.Reviewed && !now.Before(h.ReviewDeadline)
+}
+
+// Rejected reports whether the quorum review found the halt unjustified
+func (h *Halt) Rejected() bool {
+	return h.Reviewed && !h.Upheld
+}
+
+// InForce reports whether the halt blocks submissions at now: until it is
+// lifted if upheld, until a rejecting review, or until its review deadline if
+// never reviewed
+func (h *Halt) InForce(now time.Time) bool {
+	return !h.Lifted && !h.Rejected() && !h.Overdue(now)
+}
+
+// CooldownUntil returns when the proposer may trigger another halt, or the
+// zero time if this halt was upheld. A halt still awaiting review is counted
+// as lapsing at its deadline.
+func (h *Halt) CooldownUntil() time.Time {
+	switch {
+	case h.Rejected():
+		return h.ReviewedAt.Add(h.Cooldown)
+	case h.Reviewed:
+		return time.Time{}
+	default:
+		return h.ReviewDeadline.Add(h.Cooldown)
+	}
+}
+
+// HaltTriggerHash returns the hash a quorum member signs to trigger a halt of
+// target with the emergency_halt proposal proposalHash
+func HaltTriggerHash(proposalHash, proposer, target string) (string, error) {
+	return SemanticHash(map[string]interface{}{
+		"kind":          LedgerKindEmergencyHalt,
+		"proposal_hash": proposalHash,
+		"proposer":      proposer,
+		"target":        target,
+	})
+}
+
+// HaltReviewHash returns the hash a quorum signs to record a review
+func HaltReviewHash(haltHash string, upheld bool) (string, error) {
+	return SemanticHash(map[string]interface{}{
+		"kind":      LedgerKindEmergencyReview,
+		"halt_hash": haltHash,
+		"upheld":    upheld,
+	})
+}
+
+// HaltLiftHash returns the hash a super-quorum signs to lift a halt
+func HaltLiftHash(haltHash string) (string, error) {
+	return SemanticHash(map[string]interface{}{
+		"kind":      LedgerKindEmergencyLift,
+		"halt_hash": haltHash,
+	})
+}
+
+// CircuitBreaker triggers, reviews, and lifts emergency halts on a ledger
+type CircuitBreaker struct {
+	mu     sync.Mutex
+	ledger *Ledger
+	quorum *Quorum
+	policy EmergencyPolicy
+	// Policies, if set, supplies the emergency policy from the active policy
+	// table, falling back to the configured policy
+	Policies *PolicyManager
+	// Lifecycle, if set, receives halt proposals and halt events
+	Lifecycle *Lifecycle
+	// Clock supplies halt times; defaults to SystemClock
+	Clock Clock
+
+	// halts is every halt on the ledger up to height synced, in ledger order
+	halts  haltIndex
+	synced uint64
+}
+
+// NewCircuitBreaker creates a breaker for quorum recording halts on ledger
+func NewCircuitBreaker(ledger *Ledger, quorum *Quorum, policy EmergencyPolicy) (*CircuitBreaker, error) {
+	if err := policy.Validate(quorum); err != nil {
+		return nil, err
+	}
+	return &CircuitBreaker{ledger: ledger, quorum: quorum, policy: policy}, nil
+}
+
+// currentPolicy returns the policy in force for a new halt
+func (b *CircuitBreaker) currentPolicy() (EmergencyPolicy, error) {
+	policy := b.policy
+	if b.Policies != nil {
+		table, _ := b.Policies.Current()
+		if p, ok := EmergencyPolicyFromTable(table); ok {
+			policy = p
+		}
+	}
+	return policy, policy.Validate(b.quorum)
+}
+
+// Trigger puts an emergency halt into force immediately
+//
+// Parameters:
+//   - p: Proposal with action type emergency_halt, signed by a quorum member
+//   - sig: The proposer's signature over HaltTriggerHash under
+//     ContextHaltTrigger, recorded with the halt
+//
+// Returns:
+//   - The recorded halt
+//   - VerificationError if p is not a validly signed halt from a member
+func (b *CircuitBreaker) Trigger(p *ContractProposal, sig Signature) (*Halt, error) {
+	if p.ActionType != ActionEmergencyHalt {
+		return nil, NewConstitutionalError(fmt.Sprintf("action type %q is not %s", p.ActionType, ActionEmergencyHalt))
+	}
+	report := VerifyProposalFull(p, WithProposerKeys(b.quorum.Members))
+	if sig, ok := report.Check(CheckSignature); !ok || sig.Status != CheckPassed {
+		return nil, NewVerificationError(fmt.Sprintf("emergency halt must be signed by a quorum member: %s", sig.Message))
+	}
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	target, _ := p.Action["target"].(string)
+	if err := verifyHaltTrigger(b.quorum, proposalHash, p.ProposerAgent, target, sig); err != nil {
+		return nil, err
+	}
+	policy, err := b.currentPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := clockOrSystem(b.Clock).Now()
+	if active := b.activeLocked(now); active != nil {
+		return nil, NewConstitutionalError(fmt.Sprintf("halt %s is already in force", active.EntryHash))
+	}
+	if prev := b.halts.latest[p.ProposerAgent]; prev != nil && now.Before(prev.CooldownUntil()) {
+		return nil, NewConstitutionalError(fmt.Sprintf("%s may not trigger another halt until %s", p.ProposerAgent, Timestamp(prev.CooldownUntil())))
+	}
+
+	entry, err := b.ledger.Append(LedgerKindEmergencyHalt, map[string]interface{}{
+		"proposal_hash":   proposalHash,
+		"proposer":        p.ProposerAgent,
+		"target":          target,
+		"halted_at":       Timestamp(now),
+		"review_deadline": Timestamp(now.Add(policy.ReviewWindow)),
+		"lift_threshold":  policy.LiftThreshold,
+		"cooldown_ms":     policy.cooldown().Milliseconds(),
+		"signature":       sig.ToMap(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Lifecycle != nil {
+		if _, err := b.Lifecycle.Submit(p); err == nil {
+			_ = b.Lifecycle.Ratify(proposalHash)
+		}
+		b.Lifecycle.emit(EventHalted, proposalHash, map[string]interface{}{"halt_hash": entry.Hash, "proposer_agent": p.ProposerAgent})
+	}
+	return haltFromEntry(entry), nil
+}
+
+// Review records the quorum's post-hoc review of a halt. A review that does
+// not uphold the halt ends it.
+//
+// Parameters:
+//   - haltHash: Ledger entry hash of the halt
+//   - upheld: Whether the reviewers found the halt justified
+//   - sigs: Signatures over HaltReviewHash(haltHash, upheld) under ContextHaltReview
+func (b *CircuitBreaker) Review(haltHash string, upheld bool, sigs []Signature) error {
+	hash, err := HaltReviewHash(haltHash, upheld)
+	if err != nil {
+		return err
+	}
+	signers, err := b.quorum.Verify(ContextHaltReview, hash, sigs)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	halt := b.haltLocked(haltHash)
+	if halt == nil {
+		return NewConstitutionalError(fmt.Sprintf("unknown halt %s", haltHash))
+	}
+	if halt.Reviewed {
+		return NewConstitutionalError(fmt.Sprintf("halt %s already reviewed", haltHash))
+	}
+	now := clockOrSystem(b.Clock).Now()
+	if halt.Overdue(now) {
+		return NewConstitutionalError(fmt.Sprintf("halt %s lapsed unreviewed at %s", haltHash, Timestamp(halt.ReviewDeadline)))
+	}
+	if _, err := b.ledger.Append(LedgerKindEmergencyReview, map[string]interface{}{
+		"halt_hash":   haltHash,
+		"upheld":      upheld,
+		"reviewed_at": Timestamp(now),
+		"signers":     stringList(signers),
+		"signatures":  signatureList(sigs),
+	}); err != nil {
+		return err
+	}
+	if !upheld && b.Lifecycle != nil {
+		b.Lifecycle.emit(EventHaltLifted, halt.ProposalHash, map[string]interface{}{"halt_hash": haltHash, "rejected": true})
+	}
+	return nil
+}
+
+// Lift ends a halt a review upheld
+//
+// Parameters:
+//   - haltHash: Ledger entry hash of the halt
+//   - sigs: Signatures over HaltLiftHash(haltHash) under ContextHaltLift from
+//     at least the halt's LiftThreshold quorum members
+func (b *CircuitBreaker) Lift(haltHash string, sigs []Signature) error {
+	hash, err := HaltLiftHash(haltHash)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	halt := b.haltLocked(haltHash)
+	if halt == nil {
+		return NewConstitutionalError(fmt.Sprintf("unknown halt %s", haltHash))
+	}
+	if halt.Lifted {
+		return NewConstitutionalError(fmt.Sprintf("halt %s already lifted", haltHash))
+	}
+	if !halt.Reviewed {
+		return NewConstitutionalError(fmt.Sprintf("halt %s must be reviewed before it is lifted", haltHash))
+	}
+	if halt.Rejected() {
+		return NewConstitutionalError(fmt.Sprintf("halt %s ended when its review rejected it", haltHash))
+	}
+	signers, err := verifySuperQuorum(b.quorum, ContextHaltLift, hash, sigs, halt.LiftThreshold)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.ledger.Append(LedgerKindEmergencyLift, map[string]interface{}{
+		"halt_hash":  haltHash,
+		"lifted_at":  Timestamp(clockOrSystem(b.Clock).Now()),
+		"signers":    stringList(signers),
+		"signatures": signatureList(sigs),
+	}); err != nil {
+		return err
+	}
+	if b.Lifecycle != nil {
+		b.Lifecycle.emit(EventHaltLifted, halt.ProposalHash, map[string]interface{}{"halt_hash": haltHash})
+	}
+	return nil
+}
+
+// Active returns a copy of the halt in force, or nil (see Halt.InForce)
+func (b *CircuitBreaker) Active() *Halt {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h := b.activeLocked(clockOrSystem(b.Clock).Now()); h != nil {
+		active := *h
+		return &active
+	}
+	return nil
+}
+
+// Check returns ErrHalted while a halt is in force
+func (b *CircuitBreaker) Check() error {
+	if b.Active() != nil {
+		return ErrHalted
+	}
+	return nil
+}
+
+// activeLocked returns the latest halt in force at now
+func (b *CircuitBreaker) activeLocked(now time.Time) *Halt {
+	b.syncLocked()
+	return b.halts.active(now)
+}
+
+func (b *CircuitBreaker) haltLocked(haltHash string) *Halt {
+	b.syncLocked()
+	return b.halts.byHash[haltHash]
+}
+
+// syncLocked applies the entries appended since the last sync, so each
+// call reads only the new tail of the ledger
+func (b *CircuitBreaker) syncLocked() {
+	for _, e := range b.ledger.Entries(b.synced) {
+		b.halts.apply(e)
+		b.synced = e.Height
+	}
+}
+
+// VerifyHalts replays every emergency halt in entries, checking that each halt
+// is signed by the quorum member who triggered it while no other was in force,
+// records a window and lift threshold the policy rules allow, and is not
+// inside the cooldown of that member's previous halt, that each review
+// carries an ordinary quorum before the halt's deadline, each lift follows a
+// review that upheld the halt and carries the super-quorum recorded when the
+// halt was triggered, and no halt is reviewed or lifted twice
+func VerifyHalts(entries []LedgerEntry, quorum *Quorum) error {
+	var halts haltIndex
+	for _, e := range entries {
+		if err := verifyHaltEntry(&halts, e, quorum); err != nil {
+			return err
+		}
+		halts.apply(e)
+	}
+	return nil
+}
+
+// verifyHaltEntry checks one entry against the halts indexed before it
+func verifyHaltEntry(halts *haltIndex, e LedgerEntry, quorum *Quorum) error {
+	switch e.Kind {
+	case LedgerKindEmergencyHalt:
+		halt := haltFromEntry(e)
+		if halt.HaltedAt.IsZero() {
+			return NewVerificationError(fmt.Sprintf("halt at height %d has no halt time", e.Height))
+		}
+		recorded := EmergencyPolicy{ReviewWindow: halt.ReviewDeadline.Sub(halt.HaltedAt), LiftThreshold: halt.LiftThreshold, Cooldown: halt.Cooldown}
+		if err := recorded.Validate(quorum); err != nil {
+			return NewVerificationError(fmt.Sprintf("halt at height %d: %v", e.Height, err))
+		}
+		sig, ok := e.Payload["signature"].(map[string]interface{})
+		if !ok {
+			return NewVerificationError(fmt.Sprintf("halt at height %d is not signed by its proposer", e.Height))
+		}
+		if err := verifyHaltTrigger(quorum, halt.ProposalHash, halt.Proposer, halt.Target, signatureFromPayload(sig)); err != nil {
+			return NewVerificationError(fmt.Sprintf("halt at height %d: %v", e.Height, err))
+		}
+		if active := halts.active(halt.HaltedAt); active != nil {
+			return NewVerificationError(fmt.Sprintf("halt at height %d recorded while halt %s is in force", e.Height, active.EntryHash))
+		}
+		if prev := halts.latest[halt.Proposer]; prev != nil && halt.HaltedAt.Before(prev.CooldownUntil()) {
+			return NewVerificationError(fmt.Sprintf("halt at height %d by %s is inside the cooldown of halt %s", e.Height, halt.Proposer, prev.EntryHash))
+		}
+	case LedgerKindEmergencyReview, LedgerKindEmergencyLift:
+		haltHash := payloadString(e.Payload, "halt_hash")
+		halt, ok := halts.byHash[haltHash]
+		if !ok {
+			return NewVerificationError(fmt.Sprintf("%s at height %d names unknown halt %s", e.Kind, e.Height, haltHash))
+		}
+		sigs := signaturesFromPayload(e.Payload, "signatures")
+		if e.Kind == LedgerKindEmergencyReview {
+			upheld, _ := e.Payload["upheld"].(bool)
+			hash, err := HaltReviewHash(haltHash, upheld)
+			if err != nil {
+				return err
+			}
+			if halt.Reviewed {
+				return NewVerificationError(fmt.Sprintf("halt %s reviewed twice", haltHash))
+			}
+			if reviewedAt, err := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "reviewed_at")); err != nil || halt.Overdue(reviewedAt) {
+				return NewVerificationError(fmt.Sprintf("review at height %d of halt %s is not before its deadline", e.Height, haltHash))
+			}
+			if _, err := quorum.Verify(ContextHaltReview, hash, sigs); err != nil {
+				return NewVerificationError(fmt.Sprintf("review at height %d: %v", e.Height, err))
+			}
+			return nil
+		}
+		if !halt.Reviewed || !halt.Upheld || halt.Lifted {
+			return NewVerificationError(fmt.Sprintf("lift at height %d of halt %s is not preceded by a single review upholding it", e.Height, haltHash))
+		}
+		hash, err := HaltLiftHash(haltHash)
+		if err != nil {
+			return err
+		}
+		if _, err := verifySuperQuorum(quorum, ContextHaltLift, hash, sigs, halt.LiftThreshold); err != nil {
+			return NewVerificationError(fmt.Sprintf("lift at height %d: %v", e.Height, err))
+		}
+	}
+	return nil
+}
+
+// verifyHaltTrigger checks that sig is proposer's signature triggering the
+// halt, and that proposer is a quorum member
+func verifyHaltTrigger(quorum *Quorum, proposalHash, proposer, target string, sig Signature) error {
+	key, ok := quorum.Members[proposer]
+	if !ok || sig.Signer != proposer {
+		return NewVerificationError(fmt.Sprintf("emergency halt must be signed by a quorum member, not %q", proposer))
+	}
+	hash, err := HaltTriggerHash(proposalHash, proposer, target)
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextHaltTrigger, hash, sig)
+}
+
+// verifySuperQuorum checks that at least threshold quorum members signed hash under ctx
+func verifySuperQuorum(quorum *Quorum, ctx SignatureContext, hash string, sigs []Signature, threshold int) ([]string, error) {
+	signers, err := quorum.Verify(ctx, hash, sigs)
+	if err != nil {
+		return signers, err
+	}
+	if len(signers) < threshold {
+		return signers, NewVerificationError(fmt.Sprintf("super-quorum not reached: %d of %d required signatures", len(signers), threshold))
+	}
+	return signers, nil
+}
+
+// haltIndex holds halts with their review and lift state, built one ledger
+// entry at a time
+type haltIndex struct {
+	list   []*Halt
+	byHash map[string]*Halt
+	// latest maps a proposer to its most recent halt
+	latest map[string]*Halt
+}
+
+// active returns the latest halt in force at now
+func (x *haltIndex) active(now time.Time) *Halt {
+	for i := len(x.list) - 1; i >= 0; i-- {
+		if x.list[i].InForce(now) {
+			return x.list[i]
+		}
+	}
+	return nil
+}
+
+// apply records e if it triggers, reviews, or lifts a halt
+func (x *haltIndex) apply(e LedgerEntry) {
+	switch e.Kind {
+	case LedgerKindEmergencyHalt:
+		if x.byHash == nil {
+			x.byHash = make(map[string]*Halt)
+			x.latest = make(map[string]*Halt)
+		}
+		h := haltFromEntry(e)
+		x.list = append(x.list, h)
+		x.byHash[e.Hash] = h
+		x.latest[h.Proposer] = h
+	case LedgerKindEmergencyReview:
+		if h, ok := x.byHash[payloadString(e.Payload, "halt_hash")]; ok && !h.Reviewed {
+			h.Reviewed = true
+			h.ReviewedAt, _ = time.Parse(time.RFC3339Nano, payloadString(e.Payload, "reviewed_at"))
+			h.Upheld, _ = e.Payload["upheld"].(bool)
+		}
+	case LedgerKindEmergencyLift:
+		if h, ok := x.byHash[payloadString(e.Payload, "halt_hash")]; ok {
+			h.Lifted = true
+		}
+	}
+}
+
+func haltFromEntry(e LedgerEntry) *Halt {
+	haltedAt, _ := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "halted_at"))
+	deadline, _ := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "review_deadline"))
+	return &Halt{
+		EntryHash:      e.Hash,
+		ProposalHash:   payloadString(e.Payload, "proposal_hash"),
+		Proposer:       payloadString(e.Payload, "proposer"),
+		Target:         payloadString(e.Payload, "target"),
+		HaltedAt:       haltedAt,
+		ReviewDeadline: deadline,
+		LiftThreshold:  payloadInt(e.Payload, "lift_threshold"),
+		Cooldown:       time.Duration(payloadInt(e.Payload, "cooldown_ms")) * time.Millisecond,
+	}
+}
+
+// signatureList converts signatures to their payload form
+func signatureList(sigs []Signature) []interface{} {
+	out := make([]interface{}, len(sigs))
+	for i, sig := range sigs {
+		out[i] = sig.ToMap()
+	}
+	return out
+}
+
+// signaturesFromPayload reads signatures stored with signatureList
+func signaturesFromPayload(payload map[string]interface{}, key string) []Signature {
+	var sigs []Signature
+	switch v := payload[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				sigs = append(sigs, signatureFromPayload(m))
+			}
+		}
+	}
+	return sigs
+}
+
+// signatureFromPayload reads one signature stored in its ToMap form
+func signatureFromPayload(m map[string]interface{}) Signature {
+	return Signature{
+		Signer:    payloadString(m, "signer"),
+		Algorithm: payloadString(m, "algorithm"),
+		Value:     payloadString(m, "value"),
+		Mode:      payloadString(m, "mode"),
+		Key:       keyRefFromPayload(m, "key"),
+	}
+}
@@ -0,0 +1,376 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// haltProposal returns an emergency halt signed by proposer, with the
+// proposer's signature triggering it
+func haltProposal(t *testing.T, proposer string, priv ed25519.PrivateKey) (*ContractProposal, Signature) {
+	p := testProposal()
+	p.ProposerAgent = proposer
+	p.ActionType = ActionEmergencyHalt
+	p.Action = map[string]interface{}{"target": "agent-runaway", "operation": "halt"}
+	if err := SignProposal(p, priv); err != nil {
+		t.Fatalf("Failed to sign proposal: %v", err)
+	}
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		t.Fatalf("Failed to hash proposal: %v", err)
+	}
+	hash, _ := HaltTriggerHash(proposalHash, proposer, "agent-runaway")
+	sig, err := SignHash(proposer, priv, ContextHaltTrigger, hash)
+	if err != nil {
+		t.Fatalf("Failed to sign halt: %v", err)
+	}
+	return p, sig
+}
+
+func signAll(t *testing.T, ctx SignatureContext, hash string, privs map[string]ed25519.PrivateKey, names ...string) []Signature {
+	var sigs []Signature
+	for _, name := range names {
+		sig, err := SignHash(name, privs[name], ctx, hash)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs
+}
+
+// TestCircuitBreaker tests instant halts, mandatory review, and super-quorum lifts
+func TestCircuitBreaker(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	node.Clock = clock
+
+	if _, err := NewCircuitBreaker(ledger, quorum, EmergencyPolicy{ReviewWindow: time.Hour, LiftThreshold: 2}); err == nil {
+		t.Errorf("A lift threshold equal to the quorum threshold should be rejected")
+	}
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	breaker.Clock = clock
+	breaker.Lifecycle = node.Lifecycle()
+	node.Breaker = breaker
+
+	var events []string
+	node.Lifecycle().Subscribe(func(e LifecycleEvent) { events = append(events, e.Kind) })
+
+	_, outsider := testKey("Mallory")
+	if _, err := breaker.Trigger(haltProposal(t, "Mallory", outsider)); err == nil {
+		t.Errorf("A halt from a non-member should be rejected")
+	}
+
+	halt, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	if halt.LiftThreshold != 3 || Timestamp(halt.ReviewDeadline) != "2025-11-21T14:30:00Z" {
+		t.Errorf("Unexpected halt: %+v", halt)
+	}
+	if _, err := node.Submit(testProposal()); err != ErrHalted {
+		t.Errorf("Submissions during a halt should fail with ErrHalted, got %v", err)
+	}
+	if len(events) == 0 || events[len(events)-1] != EventHalted {
+		t.Errorf("Expected a halted event, got %v", events)
+	}
+
+	liftHash, _ := HaltLiftHash(halt.EntryHash)
+	if err := breaker.Lift(halt.EntryHash, signAll(t, ContextHaltLift, liftHash, privs, "Claude", "Gemini", "DeepSeek")); err == nil {
+		t.Errorf("An unreviewed halt should not be liftable")
+	}
+
+	clock.Advance(23 * time.Hour)
+	if breaker.Active().Overdue(clock.Now()) {
+		t.Errorf("A halt inside its review window should not be overdue")
+	}
+	reviewHash, _ := HaltReviewHash(halt.EntryHash, true)
+	if err := breaker.Review(halt.EntryHash, true, signAll(t, ContextHaltReview, reviewHash, privs, "Claude")); err == nil {
+		t.Errorf("A review below the quorum threshold should be rejected")
+	}
+	if err := breaker.Review(halt.EntryHash, true, signAll(t, ContextHaltReview, reviewHash, privs, "Claude", "DeepSeek")); err != nil {
+		t.Fatalf("Failed to review: %v", err)
+	}
+
+	if err := breaker.Lift(halt.EntryHash, signAll(t, ContextHaltLift, liftHash, privs, "Claude", "Gemini")); err == nil {
+		t.Errorf("A lift with only the ordinary quorum should be rejected")
+	}
+	if err := breaker.Lift(halt.EntryHash, signAll(t, ContextHaltLift, liftHash, privs, "Claude", "Gemini", "DeepSeek")); err != nil {
+		t.Fatalf("Failed to lift: %v", err)
+	}
+	if _, err := node.Submit(testProposal()); err != nil {
+		t.Errorf("Submissions should resume after a lift: %v", err)
+	}
+	if events[len(events)-2] != EventHaltLifted {
+		t.Errorf("Expected a halt_lifted event, got %v", events)
+	}
+
+	if err := VerifyHalts(ledger.Entries(0), quorum); err != nil {
+		t.Errorf("Recorded halt history should verify: %v", err)
+	}
+	t.Log("✓ Halt took effect instantly and lifted only after review by a super-quorum")
+}
+
+// TestHaltLapsesUnreviewed tests that a halt nobody reviews stops blocking
+// submissions at its review deadline and can no longer be reviewed
+func TestHaltLapsesUnreviewed(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	breaker.Clock = clock
+	halt, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	if err := breaker.Check(); err != ErrHalted {
+		t.Fatalf("Expected ErrHalted inside the review window, got %v", err)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if err := breaker.Check(); err != nil || breaker.Active() != nil {
+		t.Errorf("Expected an unreviewed halt to lapse at its deadline, got %v", err)
+	}
+	reviewHash, _ := HaltReviewHash(halt.EntryHash, true)
+	if err := breaker.Review(halt.EntryHash, true, signAll(t, ContextHaltReview, reviewHash, privs, "Claude", "DeepSeek")); err == nil {
+		t.Errorf("Expected a review after the deadline to be refused")
+	}
+	if _, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"])); err != nil {
+		t.Errorf("Expected a new halt once the previous one lapsed: %v", err)
+	}
+
+	// A review recorded after the deadline fails replay
+	ledger.Append(LedgerKindEmergencyReview, map[string]interface{}{
+		"halt_hash":   halt.EntryHash,
+		"upheld":      true,
+		"reviewed_at": Timestamp(clock.Now()),
+		"signatures":  signatureList(signAll(t, ContextHaltReview, reviewHash, privs, "Claude", "DeepSeek")),
+	})
+	if err := VerifyHalts(ledger.Entries(0), quorum); err == nil {
+		t.Errorf("Expected a late review to fail verification")
+	}
+	t.Logf("✓ Halt %s lapsed unreviewed at %s", halt.EntryHash[:12], Timestamp(halt.ReviewDeadline))
+}
+
+// TestHaltRejectedOnReview tests that a review which does not uphold a halt
+// ends it, and that it cannot then be lifted
+func TestHaltRejectedOnReview(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	breaker.Clock = clock
+	halt, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+
+	clock.Advance(time.Hour)
+	reviewHash, _ := HaltReviewHash(halt.EntryHash, false)
+	if err := breaker.Review(halt.EntryHash, false, signAll(t, ContextHaltReview, reviewHash, privs, "Claude", "DeepSeek")); err != nil {
+		t.Fatalf("Failed to review: %v", err)
+	}
+	if err := breaker.Check(); err != nil || breaker.Active() != nil {
+		t.Errorf("Expected a rejected halt to end at its review, got %v", err)
+	}
+	liftHash, _ := HaltLiftHash(halt.EntryHash)
+	lift := signAll(t, ContextHaltLift, liftHash, privs, "Claude", "Gemini", "DeepSeek")
+	if err := breaker.Lift(halt.EntryHash, lift); err == nil {
+		t.Errorf("Expected a rejected halt not to be liftable")
+	}
+	if err := VerifyHalts(ledger.Entries(0), quorum); err != nil {
+		t.Errorf("Expected the rejected halt to verify, got %v", err)
+	}
+
+	ledger.Append(LedgerKindEmergencyLift, map[string]interface{}{
+		"halt_hash":  halt.EntryHash,
+		"signatures": signatureList(lift),
+	})
+	if err := VerifyHalts(ledger.Entries(0), quorum); err == nil {
+		t.Errorf("Expected a lift of a rejected halt to fail verification")
+	}
+	t.Logf("✓ Halt %s ended by a rejecting review", halt.EntryHash[:12])
+}
+
+// TestHaltCooldown tests that a member whose halt lapsed must wait out the
+// recorded cooldown before triggering again
+func TestHaltCooldown(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	policy := EmergencyPolicy{ReviewWindow: time.Hour, LiftThreshold: 3, Cooldown: 48 * time.Hour}
+	if err := (EmergencyPolicy{ReviewWindow: time.Hour, LiftThreshold: 3, Cooldown: -time.Hour}).Validate(quorum); err == nil {
+		t.Errorf("Expected a negative cooldown to be refused")
+	}
+	if got, ok := EmergencyPolicyFromTable(map[string]interface{}{"emergency_halt": policy.ToMap()}); !ok || got != policy {
+		t.Errorf("Expected the policy to round-trip through its table form, got %+v", got)
+	}
+	breaker, err := NewCircuitBreaker(ledger, quorum, policy)
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	breaker.Clock = clock
+
+	first, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	clock.Advance(time.Hour)
+	if _, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"])); err == nil {
+		t.Errorf("Expected a retrigger right after a lapse to be refused")
+	}
+	other, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"]))
+	if err != nil {
+		t.Fatalf("Expected another member to trigger a halt: %v", err)
+	}
+	reviewHash, _ := HaltReviewHash(other.EntryHash, false)
+	if err := breaker.Review(other.EntryHash, false, signAll(t, ContextHaltReview, reviewHash, privs, "Gemini", "DeepSeek")); err != nil {
+		t.Fatalf("Failed to review: %v", err)
+	}
+
+	clock.Advance(48 * time.Hour)
+	if _, err := breaker.Trigger(haltProposal(t, "Gemini", privs["Gemini"])); err != nil {
+		t.Errorf("Expected a halt once the cooldown passed: %v", err)
+	}
+	if err := VerifyHalts(ledger.Entries(0), quorum); err != nil {
+		t.Errorf("Expected the halts to verify, got %v", err)
+	}
+
+	// A halt recorded inside the cooldown fails replay
+	clock.Advance(2 * time.Hour)
+	again, sig := haltProposal(t, "Gemini", privs["Gemini"])
+	againHash, _ := again.GetHash()
+	ledger.Append(LedgerKindEmergencyHalt, map[string]interface{}{
+		"proposal_hash":   againHash,
+		"proposer":        "Gemini",
+		"target":          "agent-runaway",
+		"halted_at":       Timestamp(clock.Now()),
+		"review_deadline": Timestamp(clock.Now().Add(time.Hour)),
+		"lift_threshold":  policy.LiftThreshold,
+		"cooldown_ms":     policy.Cooldown.Milliseconds(),
+		"signature":       sig.ToMap(),
+	})
+	if err := VerifyHalts(ledger.Entries(0), quorum); err == nil || !strings.Contains(err.Error(), "cooldown") {
+		t.Errorf("Expected a halt inside its proposer's cooldown to fail verification, got %v", err)
+	}
+	t.Logf("✓ %s waited until %s to halt again", first.Proposer, Timestamp(first.ReviewDeadline.Add(policy.Cooldown)))
+}
+
+// TestVerifyHaltsRejectsWeakLift tests replay of a lift recorded without a super-quorum
+func TestVerifyHaltsRejectsWeakLift(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	ledger := NewLedger()
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	halt, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	reviewHash, _ := HaltReviewHash(halt.EntryHash, true)
+	if err := breaker.Review(halt.EntryHash, true, signAll(t, ContextHaltReview, reviewHash, privs, "Claude", "Gemini")); err != nil {
+		t.Fatalf("Failed to review: %v", err)
+	}
+
+	liftHash, _ := HaltLiftHash(halt.EntryHash)
+	if _, err := ledger.Append(LedgerKindEmergencyLift, map[string]interface{}{
+		"halt_hash":  halt.EntryHash,
+		"signatures": signatureList(signAll(t, ContextHaltLift, liftHash, privs, "Claude", "Gemini")),
+	}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	if err := VerifyHalts(ledger.Entries(0), quorum); err == nil {
+		t.Errorf("A lift without the recorded super-quorum should fail verification")
+	}
+	t.Log("✓ Replay rejected a lift below the recorded lift threshold")
+}
+
+// TestVerifyHaltsRejectsForgedHalt tests that replay checks a recorded halt's
+// own fields rather than trusting them
+func TestVerifyHaltsRejectsForgedHalt(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	ledger := NewLedger()
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	halt, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	if err := VerifyHalts(ledger.Entries(0), quorum); err != nil {
+		t.Fatalf("Expected the recorded halt to verify, got %v", err)
+	}
+	p, sig := haltProposal(t, "Claude", privs["Claude"])
+	if _, err := breaker.Trigger(p, Signature{Signer: "Claude", Algorithm: SignatureAlgorithm, Value: sig.Value[:len(sig.Value)-2] + "00"}); err == nil {
+		t.Errorf("Expected a halt with a bad trigger signature to be refused")
+	}
+
+	// Each forged halt replaces the recorded one, which is otherwise valid
+	recorded := ledger.Entries(0)[0].Payload
+	_, outsider := testKey("Mallory")
+	cases := map[string]func(map[string]interface{}){
+		"ordinary lift threshold": func(m map[string]interface{}) { m["lift_threshold"] = 1 },
+		"deadline before halt":    func(m map[string]interface{}) { m["review_deadline"] = Timestamp(halt.HaltedAt.Add(-time.Hour)) },
+		"unsigned":                func(m map[string]interface{}) { delete(m, "signature") },
+		"other target":            func(m map[string]interface{}) { m["target"] = "agent-claude" },
+		"non-member": func(m map[string]interface{}) {
+			hash, _ := HaltTriggerHash(halt.ProposalHash, "Mallory", halt.Target)
+			forged, _ := SignHash("Mallory", outsider, ContextHaltTrigger, hash)
+			m["proposer"] = "Mallory"
+			m["signature"] = forged.ToMap()
+		},
+	}
+	for name, mutate := range cases {
+		forged := make(map[string]interface{}, len(recorded))
+		for k, v := range recorded {
+			forged[k] = v
+		}
+		mutate(forged)
+		l := NewLedger()
+		l.Append(LedgerKindEmergencyHalt, forged)
+		if err := VerifyHalts(l.Entries(0), quorum); err == nil {
+			t.Errorf("%s: expected the forged halt to fail verification", name)
+		}
+	}
+
+	// A second halt recorded while the first is in force
+	second, secondSig := haltProposal(t, "Gemini", privs["Gemini"])
+	secondHash, _ := second.GetHash()
+	ledger.Append(LedgerKindEmergencyHalt, map[string]interface{}{
+		"proposal_hash":   secondHash,
+		"proposer":        "Gemini",
+		"target":          "agent-runaway",
+		"halted_at":       Timestamp(halt.HaltedAt),
+		"review_deadline": Timestamp(halt.ReviewDeadline),
+		"lift_threshold":  halt.LiftThreshold,
+		"cooldown_ms":     halt.Cooldown.Milliseconds(),
+		"signature":       secondSig.ToMap(),
+	})
+	if err := VerifyHalts(ledger.Entries(0), quorum); err == nil {
+		t.Errorf("Expected a halt recorded during another to fail verification")
+	}
+	t.Logf("✓ Replay refused %d forged halts and an overlapping one", len(cases))
+}
@@ -0,0 +1,75 @@
+// hashable.go - Typed wrapper giving any object canonical hashing
+//
+// Every OCP object type used to carry its own GetHash/VerifyHash pair around a
+// ToMap method. Hashable[T] supplies those methods once: it wraps a value whose
+// type either implements Mapper or is a struct with json tags, which is then
+// canonicalized exactly as its JSON encoding would be received.
+
+package hashing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Mapper is implemented by objects that define their own canonical map form
+type Mapper interface {
+	ToMap() map[string]interface{}
+}
+
+// Hashable wraps a value of type T with canonical hashing methods
+type Hashable[T any] struct {
+	Value T
+}
+
+// Of wraps value
+func Of[T any](value T) Hashable[T] {
+	return Hashable[T]{Value: value}
+}
+
+// Map returns the map form of the wrapped value. A value implementing Mapper
+// (directly or through a pointer) uses ToMap; any other value is encoded with
+// encoding/json, honoring its struct tags, and strictly decoded.
+func (h Hashable[T]) Map() (map[string]interface{}, error) {
+	if m, ok := any(h.Value).(Mapper); ok {
+		return m.ToMap(), nil
+	}
+	if m, ok := any(&h.Value).(Mapper); ok {
+		return m.ToMap(), nil
+	}
+
+	data, err := json.Marshal(h.Value)
+	if err != nil {
+		return nil, fmt.Errorf("hashable %T: %w", h.Value, err)
+	}
+	return canonical.DecodeStrict(data)
+}
+
+// Canonical returns the canonical JSON form of the wrapped value
+func (h Hashable[T]) Canonical() (string, error) {
+	m, err := h.Map()
+	if err != nil {
+		return "", err
+	}
+	return canonical.Canonicalize(m, true)
+}
+
+// Hash returns the semantic hash of the wrapped value
+func (h Hashable[T]) Hash() (string, error) {
+	m, err := h.Map()
+	if err != nil {
+		return "", err
+	}
+	return SemanticHash(m)
+}
+
+// Verify reports whether the wrapped value hashes to expected
+func (h Hashable[T]) Verify(expected string) (bool, error) {
+	m, err := h.Map()
+	if err != nil {
+		return false, err
+	}
+	return VerifySemanticHash(m, expected)
+}
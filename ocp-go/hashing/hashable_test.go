@@ -0,0 +1,70 @@
+package hashing
+
+import "testing"
+
+// vote is a tag-based object with no ToMap method
+type vote struct {
+	Voter   string   `json:"voter"`
+	Approve bool     `json:"approve"`
+	Weight  int      `json:"weight"`
+	Tags    []string `json:"tags,omitempty"`
+	secret  string
+}
+
+// receipt implements Mapper through a pointer receiver
+type receipt struct {
+	id string
+}
+
+func (r *receipt) ToMap() map[string]interface{} {
+	return map[string]interface{}{"id": r.id, "kind": "receipt"}
+}
+
+// TestHashableTagBased tests hashing a struct through its json tags
+func TestHashableTagBased(t *testing.T) {
+	v := Of(vote{Voter: "Gemini", Approve: true, Weight: 3, secret: "ignored"})
+
+	expected, _ := SemanticHash(map[string]interface{}{"voter": "Gemini", "approve": true, "weight": 3})
+	hash, err := v.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if hash != expected {
+		t.Errorf("Tag-based hash should equal the hash of the equivalent map:\n  %s\n  %s", hash, expected)
+	}
+
+	form, _ := v.Canonical()
+	if form != `{"approve":true,"voter":"Gemini","weight":3}` {
+		t.Errorf("Unexpected canonical form: %s", form)
+	}
+
+	if ok, err := v.Verify(expected); err != nil || !ok {
+		t.Errorf("Verify should accept the correct hash: %v", err)
+	}
+	if ok, _ := v.Verify("0000"); ok {
+		t.Errorf("Verify should reject a different hash")
+	}
+
+	t.Logf("✓ Tag-based hash: %s", hash)
+}
+
+// TestHashableMapper tests that ToMap takes precedence over json tags
+func TestHashableMapper(t *testing.T) {
+	expected, _ := SemanticHash(map[string]interface{}{"id": "r-1", "kind": "receipt"})
+
+	for name, h := range map[string]func() (string, error){
+		"value":   Of(receipt{id: "r-1"}).Hash,
+		"pointer": Of(&receipt{id: "r-1"}).Hash,
+	} {
+		hash, err := h()
+		if err != nil || hash != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", name, expected, hash, err)
+		}
+	}
+
+	if _, err := Of(func() {}).Hash(); err == nil {
+		t.Errorf("Values without a JSON form should be rejected")
+	}
+
+	t.Logf("✓ Mapper hash: %s", expected)
+}
@@ -0,0 +1,65 @@
+package hashing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// TestSemanticHasherTee tests hashing a document as it is read
+func TestSemanticHasherTee(t *testing.T) {
+	raw := `{ "z": [3, 1], "a": {"y": "ÿ", "b": 2.50} }`
+	expected, _ := SemanticHash(map[string]interface{}{
+		"a": map[string]interface{}{"b": 2.5, "y": "ÿ"},
+		"z": []interface{}{float64(3), float64(1)},
+	})
+
+	hasher := NewSemanticHasher()
+	plain := sha256.New()
+	tee := io.TeeReader(strings.NewReader(raw), io.MultiWriter(hasher, plain))
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if err := hasher.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sum != expected {
+		t.Errorf("Semantic sum mismatch:\n  Expected: %s\n  Got:      %s", expected, sum)
+	}
+	if hex.EncodeToString(plain.Sum(nil)) == sum {
+		t.Errorf("Semantic hash should differ from the raw byte hash of non-canonical input")
+	}
+
+	t.Logf("✓ Streamed semantic hash: %s", sum)
+}
+
+// TestSemanticHasherErrors tests Err and Reset
+func TestSemanticHasherErrors(t *testing.T) {
+	hasher := NewSemanticHasher()
+	hasher.Write([]byte(`{"a":1,"a":2}`))
+	if sum := hasher.Sum(nil); len(sum) != hasher.Size() || hasher.Err() == nil {
+		t.Errorf("Duplicate keys should be reported by Err")
+	}
+
+	hasher.Reset()
+	hasher.Write([]byte(`{"a":1}`))
+	if hasher.Sum(nil); hasher.Err() != nil {
+		t.Errorf("Reset should clear the previous error: %v", hasher.Err())
+	}
+
+	if canonical.UTF16KeyOrderBuild {
+		utf16 := NewSemanticHasherWith(canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)))
+		utf16.Write([]byte(`{"a":1}`))
+		if hex.EncodeToString(utf16.Sum(nil)) != hex.EncodeToString(hasher.Sum(nil)) {
+			t.Errorf("ASCII keys should hash identically under both key orders")
+		}
+	}
+
+	t.Logf("✓ Hasher errors reported via Err")
+}
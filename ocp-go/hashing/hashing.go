@@ -0,0 +1,105 @@
+// hashing.go - Semantic hashing of canonicalized OCP objects
+//
+// A semantic hash is the SHA256 digest of an object's canonical form, so two
+// objects carrying the same information always hash identically.
+//
+// Must produce identical hashes to canonicalizer.py, canonicalizer.js, and canonicalizer.rs
+
+package hashing
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Constants
+const (
+	HashAlgorithm = "sha256"
+)
+
+// SemanticHash calculates the cryptographic hash of canonicalized data.
+// Matches Python's semantic_hash, JavaScript's semanticHash, and Rust's semantic_hash functions.
+//
+// Parameters:
+//   - data: Input map to hash
+//
+// Returns:
+//   - Hexadecimal string of the SHA256 hash
+func SemanticHash(data map[string]interface{}) (string, error) {
+	canonicalString, err := canonical.Canonicalize(data, true)
+	if err != nil {
+		return "", fmt.Errorf("semantic hash error: %w", err)
+	}
+
+	canonicalBytes := []byte(canonicalString)
+	hash := sha256.Sum256(canonicalBytes)
+	return fmt.Sprintf("%x", hash), nil
+}
+
+// SemanticHashWith calculates the semantic hash using a configured canonicalizer
+// instead of canonical.Default.
+func SemanticHashWith(c *canonical.Canonicalizer, data map[string]interface{}) (string, error) {
+	canonicalString, err := c.Canonicalize(data, true)
+	if err != nil {
+		return "", fmt.Errorf("semantic hash error: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(canonicalString))
+	return fmt.Sprintf("%x", hash), nil
+}
+
+// ValueHash calculates the hash of the canonical form of any JSON-compatible value.
+// For maps it is identical to SemanticHash.
+//
+// Parameters:
+//   - value: Input value to hash
+//
+// Returns:
+//   - Hexadecimal string of the SHA256 hash
+func ValueHash(value interface{}) (string, error) {
+	canonicalString, err := canonical.CanonicalizeValue(value)
+	if err != nil {
+		return "", fmt.Errorf("value hash error: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(canonicalString))
+	return fmt.Sprintf("%x", hash), nil
+}
+
+// VerifySemanticHash verifies that data produces the expected semantic hash.
+// Matches Python's verify_semantic_hash, JavaScript's verifySemanticHash, and Rust's verify_semantic_hash functions.
+//
+// Parameters:
+//   - data: Input map to verify
+//   - expectedHash: Expected hash value (hex string)
+//
+// Returns:
+//   - true if hash matches, false otherwise
+func VerifySemanticHash(data map[string]interface{}, expectedHash string) (bool, error) {
+	actualHash, err := SemanticHash(data)
+	if err != nil {
+		return false, err
+	}
+	return actualHash == expectedHash, nil
+}
+
+// CanonicallyEqual compares two maps for canonical equality.
+//
+// Parameters:
+//   - data1: First map
+//   - data2: Second map
+//
+// Returns:
+//   - true if canonical forms are identical
+func CanonicallyEqual(data1, data2 map[string]interface{}) bool {
+	canon1, err1 := canonical.Canonicalize(data1, true)
+	canon2, err2 := canonical.Canonicalize(data2, true)
+
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	return canon1 == canon2
+}
@@ -1,8 +1,10 @@
-package ocp
+package hashing
 
 import (
 	"fmt"
 	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
 )
 
 // TestBasicCanonicalizer tests basic canonicalization with order independence
@@ -19,12 +21,12 @@ func TestBasicCanonicalizer(t *testing.T) {
 		"b": float64(2),
 	}
 
-	canonicalA, err := Canonicalize(dictA, true)
+	canonicalA, err := canonical.Canonicalize(dictA, true)
 	if err != nil {
 		t.Fatalf("Failed to canonicalize dictA: %v", err)
 	}
 
-	canonicalB, err := Canonicalize(dictB, true)
+	canonicalB, err := canonical.Canonicalize(dictB, true)
 	if err != nil {
 		t.Fatalf("Failed to canonicalize dictB: %v", err)
 	}
@@ -51,35 +53,6 @@ func TestBasicCanonicalizer(t *testing.T) {
 	t.Logf("✓ Hashes match: %s", hashA)
 }
 
-// TestNestedStructures tests canonicalization of nested maps and arrays
-func TestNestedStructures(t *testing.T) {
-	complexDict := map[string]interface{}{
-		"z": []interface{}{float64(3), float64(1), float64(2)},
-		"a": map[string]interface{}{
-			"c": float64(3),
-			"a": float64(1),
-			"b": map[string]interface{}{
-				"f": float64(6),
-				"d": float64(4),
-				"e": float64(5),
-			},
-		},
-		"b": float64(2),
-	}
-
-	canonical, err := Canonicalize(complexDict, true)
-	if err != nil {
-		t.Fatalf("Failed to canonicalize: %v", err)
-	}
-
-	expected := `{"a":{"a":1,"b":{"d":4,"e":5,"f":6},"c":3},"b":2,"z":[1,2,3]}`
-	if canonical != expected {
-		t.Errorf("Canonical form mismatch:\n  Expected: %s\n  Got:      %s", expected, canonical)
-	}
-
-	t.Logf("✓ Nested structure canonicalized correctly: %s", canonical)
-}
-
 // TestHashSensitivity tests that hashes change with content
 func TestHashSensitivity(t *testing.T) {
 	actionA := map[string]interface{}{
@@ -216,9 +189,9 @@ func TestArraySorting(t *testing.T) {
 // TestComplexContract tests a complex contract structure
 func TestComplexContract(t *testing.T) {
 	contract := map[string]interface{}{
-		"id":               "550e8400-e29b-41d4-a716-446655440000",
-		"proposer_agent":   "Claude",
-		"action_type":      "amend",
+		"id":             "550e8400-e29b-41d4-a716-446655440000",
+		"proposer_agent": "Claude",
+		"action_type":    "amend",
 		"action": map[string]interface{}{
 			"target":    "amendment-article-3",
 			"operation": "modify",
@@ -261,10 +234,10 @@ func TestComplexContract(t *testing.T) {
 // TestCrossLanguageVector tests cross-language validation
 func TestCrossLanguageVector(t *testing.T) {
 	pythonTestVector := map[string]interface{}{
-		"action":      "propose",
-		"agent":       "Claude",
-		"confidence":  float64(0.88),
-		"timestamp":   "2025-11-20T14:30:00Z",
+		"action":     "propose",
+		"agent":      "Claude",
+		"confidence": float64(0.88),
+		"timestamp":  "2025-11-20T14:30:00Z",
 	}
 
 	hash, err := SemanticHash(pythonTestVector)
@@ -280,67 +253,37 @@ func TestCrossLanguageVector(t *testing.T) {
 	t.Logf("✓ Compare with Python/JavaScript/Rust for validation")
 }
 
-// TestContractProposalType tests the ContractProposal helper type
-func TestContractProposalType(t *testing.T) {
-	proposal := &ContractProposal{
-		ID:                 "550e8400-e29b-41d4-a716-446655440000",
-		ProposerAgent:      "Claude",
-		ActionType:         "amend",
-		Action: map[string]interface{}{
-			"target":    "amendment-article-3",
-			"operation": "modify",
-		},
-		Evidence: []map[string]string{
-			{
-				"type":    "archive_reference",
-				"pointer": "sha256:abc123def456",
-			},
-		},
-		Reasoning: map[string]interface{}{
-			"rationale":  "Clarifies Article III.1",
-			"confidence": float64(0.87),
-		},
-		ReversibilityClass:  "partially_reversible",
-		PreStateHash:        "sha256:1234567890abcdef",
-		PostStateHash:       "sha256:fedcba0987654321",
-		CanonicalSerialized: "{...}",
-		Timestamp:           "2025-11-20T14:30:00Z",
-		ProposerSignature: map[string]string{
-			"algorithm": "ed25519",
-			"value":     "3a4b5c6d7e8f9a0b",
-		},
-		ReputationStake: 60,
+// TestSemanticHashWith tests hashing under a non-default canonicalizer
+func TestSemanticHashWith(t *testing.T) {
+	if !canonical.UTF16KeyOrderBuild {
+		t.Skip("KeyOrderUTF16 is compiled out of this build (ocp_noutf16)")
 	}
+	ascii := map[string]interface{}{"b": float64(1), "a": float64(2)}
 
-	hash, err := proposal.GetHash()
+	defaultHash, _ := SemanticHash(ascii)
+	utf16Hash, err := SemanticHashWith(canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)), ascii)
 	if err != nil {
-		t.Fatalf("Failed to get proposal hash: %v", err)
-	}
-
-	if len(hash) != 64 {
-		t.Errorf("Hash should be 64 hex characters")
+		t.Fatalf("Failed to hash: %v", err)
 	}
-
-	valid, err := proposal.VerifyHash(hash)
-	if err != nil {
-		t.Fatalf("Failed to verify proposal hash: %v", err)
+	if defaultHash != utf16Hash {
+		t.Errorf("Key orders should agree on ASCII keys")
 	}
 
-	if !valid {
-		t.Errorf("Proposal hash should verify")
+	astral := map[string]interface{}{"\uFF61": float64(1), "\U0001F600": float64(2)}
+	defaultHash, _ = SemanticHash(astral)
+	utf16Hash, _ = SemanticHashWith(canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)), astral)
+	if defaultHash == utf16Hash {
+		t.Errorf("Key orders should disagree on supplementary-plane keys")
 	}
-
-	t.Logf("✓ ContractProposal hash: %s", hash)
-	t.Logf("✓ ContractProposal hash verifies")
 }
 
 // BenchmarkCanonicalHash benchmarks canonical hash computation
 func BenchmarkCanonicalHash(b *testing.B) {
 	testData := map[string]interface{}{
-		"action":      "propose",
-		"agent":       "Claude",
-		"confidence":  float64(0.88),
-		"timestamp":   "2025-11-20T14:30:00Z",
+		"action":       "propose",
+		"agent":        "Claude",
+		"confidence":   float64(0.88),
+		"timestamp":    "2025-11-20T14:30:00Z",
 		"evidence_ptr": "archive://0000001",
 	}
 
@@ -350,39 +293,6 @@ func BenchmarkCanonicalHash(b *testing.B) {
 	}
 }
 
-// BenchmarkCanonicalize benchmarks canonicalization
-func BenchmarkCanonicalize(b *testing.B) {
-	testData := map[string]interface{}{
-		"action":      "propose",
-		"agent":       "Claude",
-		"confidence":  float64(0.88),
-		"timestamp":   "2025-11-20T14:30:00Z",
-		"evidence_ptr": "archive://0000001",
-	}
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, _ = Canonicalize(testData, true)
-	}
-}
-
-// ExampleCanonicalize demonstrates canonicalization
-func ExampleCanonicalize() {
-	data := map[string]interface{}{
-		"action": "propose",
-		"agent":  "Claude",
-		"value":  float64(42),
-	}
-
-	canonical, err := Canonicalize(data, true)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
-	}
-
-	fmt.Printf("Canonical: %s\n", canonical)
-}
-
 // ExampleSemanticHash demonstrates hash computation
 func ExampleSemanticHash() {
 	data := map[string]interface{}{
@@ -0,0 +1,142 @@
+// merkle.go - Merkle trees over hex digests
+//
+// A Merkle root commits to an ordered list of hashes in one digest, and an
+// inclusion proof shows that a hash is in the list with a number of sibling
+// digests logarithmic in its length. The tree follows RFC 6962 §2.1: leaves
+// and interior nodes are hashed with distinct prefixes (0x00 and 0x01) so no
+// leaf can pose as a subtree, and a list is split at the largest power of two
+// smaller than its length, so no leaf is ever duplicated.
+//
+// Leaves and digests are hex strings, like every other hash in the protocol.
+// The root of an empty list is the SHA-256 of the empty string.
+
+package hashing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MerkleRoot returns the root of the tree over leaves, in order
+//
+// Returns:
+//   - Hex root, or an error if a leaf is not hex
+func MerkleRoot(leaves []string) (string, error) {
+	nodes, err := merkleLeaves(leaves)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(merkleSubtree(nodes)), nil
+}
+
+// MerkleProof returns the inclusion proof of leaves[index]: the sibling
+// digests from the leaf up to the root
+func MerkleProof(leaves []string, index int) ([]string, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("merkle proof: index %d outside %d leaves", index, len(leaves))
+	}
+	nodes, err := merkleLeaves(leaves)
+	if err != nil {
+		return nil, err
+	}
+	var path []string
+	for len(nodes) > 1 {
+		k := splitPoint(len(nodes))
+		if index < k {
+			path = append(path, hex.EncodeToString(merkleSubtree(nodes[k:])))
+			nodes = nodes[:k]
+		} else {
+			path = append(path, hex.EncodeToString(merkleSubtree(nodes[:k])))
+			nodes, index = nodes[k:], index-k
+		}
+	}
+	// Siblings were collected from the root down
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// VerifyMerkleProof reports whether proof shows leaf at index in a list of
+// count leaves with the given root
+func VerifyMerkleProof(root, leaf string, index, count int, proof []string) bool {
+	if index < 0 || index >= count {
+		return false
+	}
+	node, err := merkleLeaves([]string{leaf})
+	if err != nil {
+		return false
+	}
+	siblings := make([][]byte, len(proof))
+	for i, p := range proof {
+		if siblings[i], err = hex.DecodeString(p); err != nil {
+			return false
+		}
+	}
+	digest, ok := climb(node[0], index, count, siblings)
+	return ok && hex.EncodeToString(digest) == root
+}
+
+// climb recomputes the root of a subtree of count leaves holding digest at
+// index, consuming siblings from the end of the list (those nearest the root)
+func climb(digest []byte, index, count int, siblings [][]byte) ([]byte, bool) {
+	if count == 1 {
+		return digest, len(siblings) == 0
+	}
+	if len(siblings) == 0 {
+		return nil, false
+	}
+	sibling, rest := siblings[len(siblings)-1], siblings[:len(siblings)-1]
+	k := splitPoint(count)
+	if index < k {
+		left, ok := climb(digest, index, k, rest)
+		return merkleNode(left, sibling), ok
+	}
+	right, ok := climb(digest, index-k, count-k, rest)
+	return merkleNode(sibling, right), ok
+}
+
+// merkleLeaves hashes each hex leaf with the leaf prefix
+func merkleLeaves(leaves []string) ([][]byte, error) {
+	nodes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		b, err := hex.DecodeString(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("merkle leaf %d is not hex: %w", i, err)
+		}
+		h := sha256.Sum256(append([]byte{0x00}, b...))
+		nodes[i] = h[:]
+	}
+	return nodes, nil
+}
+
+// merkleSubtree returns the root over already hashed leaves
+func merkleSubtree(nodes [][]byte) []byte {
+	switch len(nodes) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return nodes[0]
+	}
+	k := splitPoint(len(nodes))
+	return merkleNode(merkleSubtree(nodes[:k]), merkleSubtree(nodes[k:]))
+}
+
+func merkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// splitPoint returns the largest power of two smaller than n, for n > 1
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
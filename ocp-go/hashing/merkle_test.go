@@ -0,0 +1,77 @@
+package hashing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) []string {
+	leaves := make([]string, n)
+	for i := range leaves {
+		h := sha256.Sum256([]byte(fmt.Sprint(i)))
+		leaves[i] = hex.EncodeToString(h[:])
+	}
+	return leaves
+}
+
+// TestMerkleRoot tests the root against a hand-computed tree and its
+// sensitivity to order and content
+func TestMerkleRoot(t *testing.T) {
+	leaves := testLeaves(3)
+	hashLeaf := func(s string) []byte {
+		b, _ := hex.DecodeString(s)
+		h := sha256.Sum256(append([]byte{0}, b...))
+		return h[:]
+	}
+	// RFC 6962: the root of three leaves is node(node(l0, l1), l2)
+	expected := hex.EncodeToString(merkleNode(merkleNode(hashLeaf(leaves[0]), hashLeaf(leaves[1])), hashLeaf(leaves[2])))
+	root, err := MerkleRoot(leaves)
+	if err != nil || root != expected {
+		t.Fatalf("Expected %s, got %s, %v", expected, root, err)
+	}
+
+	swapped := []string{leaves[1], leaves[0], leaves[2]}
+	if other, _ := MerkleRoot(swapped); other == root {
+		t.Error("Reordering leaves should change the root")
+	}
+	if _, err := MerkleRoot([]string{"not hex"}); err == nil {
+		t.Error("Expected a non-hex leaf to fail")
+	}
+	empty, _ := MerkleRoot(nil)
+	if h := sha256.Sum256(nil); empty != hex.EncodeToString(h[:]) {
+		t.Errorf("Unexpected empty root %s", empty)
+	}
+	t.Logf("✓ Root %s", root)
+}
+
+// TestMerkleProofs tests that every leaf of trees of several sizes proves
+// against the root, and nothing else does
+func TestMerkleProofs(t *testing.T) {
+	checked := 0
+	for _, n := range []int{1, 2, 3, 5, 8, 13} {
+		leaves := testLeaves(n)
+		root, _ := MerkleRoot(leaves)
+		for i, leaf := range leaves {
+			proof, err := MerkleProof(leaves, i)
+			if err != nil {
+				t.Fatalf("MerkleProof(%d, %d) failed: %v", n, i, err)
+			}
+			if !VerifyMerkleProof(root, leaf, i, n, proof) {
+				t.Errorf("Leaf %d of %d did not verify", i, n)
+			}
+			if n > 1 && VerifyMerkleProof(root, leaf, (i+1)%n, n, proof) {
+				t.Errorf("Leaf %d of %d verified at the wrong index", i, n)
+			}
+			if VerifyMerkleProof(root, testLeaves(n+1)[n], i, n, proof) {
+				t.Errorf("A foreign leaf verified at %d of %d", i, n)
+			}
+			checked++
+		}
+	}
+	if _, err := MerkleProof(testLeaves(2), 2); err == nil {
+		t.Error("Expected an out-of-range index to fail")
+	}
+	t.Logf("✓ %d inclusion proofs verified", checked)
+}
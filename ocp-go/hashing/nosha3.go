@@ -0,0 +1,13 @@
+//go:build ocp_nosha3
+
+// nosha3.go - Build profile without SHA3-256
+//
+// Building with -tags ocp_nosha3 leaves SHA3-256 out of the hash algorithms
+// a node offers, for targets that never take part in a hash migration. The
+// Keccak code is then unreferenced and dropped by the linker.
+
+package hashing
+
+// SHA3Build reports whether SHA3-256 is offered for dual hashing and
+// migration; false under the ocp_nosha3 tag
+const SHA3Build = false
@@ -0,0 +1,7 @@
+//go:build !ocp_nosha3
+
+package hashing
+
+// SHA3Build reports whether SHA3-256 is offered for dual hashing and
+// migration; false under the ocp_nosha3 tag
+const SHA3Build = true
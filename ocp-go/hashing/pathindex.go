@@ -0,0 +1,126 @@
+// pathindex.go - Per-path subtree hashes for partial verification
+//
+// A PathIndex maps every JSON path in an object to the hash of the subtree at
+// that path, with the root path "" holding the object's semantic hash. An index
+// built once when a proposal is accepted and stored beside it lets a UI or
+// adjudicator check a single displayed field with VerifyPath, canonicalizing
+// only that field rather than the whole proposal.
+//
+// Paths join object keys with "." and array positions with "[i]", e.g.
+// "evidence[0].pointer". Positions are those of the canonical form, so arrays
+// that canonicalization sorts (primitive arrays and {"_set": [...]} sets) are
+// indexed in sorted order. Keys containing ".", "[", "]", or "\" have those
+// characters escaped with "\".
+
+package hashing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// PathIndex maps JSON paths to subtree hashes
+type PathIndex map[string]string
+
+// Root returns the semantic hash of the indexed object
+func (p PathIndex) Root() string {
+	return p[""]
+}
+
+// ToMap converts a PathIndex to a map for canonicalization, so it can be
+// archived and hashed like any other object
+func (p PathIndex) ToMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(p))
+	for path, hash := range p {
+		m[path] = hash
+	}
+	return m
+}
+
+// BuildPathIndex hashes every subtree of data
+//
+// Parameters:
+//   - data: Input map, as passed to SemanticHash
+//
+// Returns:
+//   - Index whose Root equals SemanticHash(data)
+func BuildPathIndex(data map[string]interface{}) (PathIndex, error) {
+	generic, err := toGeneric(data)
+	if err != nil {
+		return nil, fmt.Errorf("path index error: %w", err)
+	}
+	index := make(PathIndex)
+	if err := index.add("", canonical.DeepSort(generic)); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// VerifyPath checks that value is the subtree at path of the indexed object
+//
+// Returns:
+//   - true if value hashes to the indexed subtree hash
+//   - Error if path is not in the index
+func VerifyPath(index PathIndex, path string, value interface{}) (bool, error) {
+	expected, ok := index[path]
+	if !ok {
+		return false, fmt.Errorf("path %q is not indexed", path)
+	}
+	actual, err := ValueHash(value)
+	if err != nil {
+		return false, err
+	}
+	return actual == expected, nil
+}
+
+// add records the hash of sorted at path and recurses into its children
+func (p PathIndex) add(path string, sorted interface{}) error {
+	hash, err := ValueHash(sorted)
+	if err != nil {
+		return fmt.Errorf("path index error at %q: %w", path, err)
+	}
+	p[path] = hash
+
+	switch v := sorted.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := escapePathKey(key)
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			if err := p.add(childPath, child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if err := p.add(fmt.Sprintf("%s[%d]", path, i), child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var pathKeyEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `[`, `\[`, `]`, `\]`)
+
+func escapePathKey(key string) string {
+	return pathKeyEscaper.Replace(key)
+}
+
+// toGeneric converts typed containers such as []map[string]string into
+// map[string]interface{} and []interface{} so every subtree can be reached
+func toGeneric(data map[string]interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
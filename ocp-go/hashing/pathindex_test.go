@@ -0,0 +1,67 @@
+package hashing
+
+import (
+	"testing"
+)
+
+// TestBuildPathIndex tests subtree hashes and single-field verification
+func TestBuildPathIndex(t *testing.T) {
+	data := map[string]interface{}{
+		"action_type": "amend",
+		"action": map[string]interface{}{
+			"target":     "amendment-article-3",
+			"parameters": map[string]interface{}{"article": "III.1"},
+		},
+		"evidence": []map[string]string{
+			{"type": "archive_reference", "pointer": "sha256:abc"},
+		},
+		"tags":      []interface{}{"urgent", "constitutional"},
+		"a.b[0]":    "tricky key",
+		"evidence2": map[string]interface{}{"_set": []interface{}{"y", "x"}},
+	}
+
+	index, err := BuildPathIndex(data)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+	root, _ := SemanticHash(data)
+	if index.Root() != root {
+		t.Errorf("Root %s should equal the semantic hash %s", index.Root(), root)
+	}
+
+	cases := []struct {
+		path  string
+		value interface{}
+		want  bool
+	}{
+		{"action.parameters.article", "III.1", true},
+		{"action.parameters.article", "IV.2", false},
+		{"action.parameters", map[string]interface{}{"article": "III.1"}, true},
+		{"evidence[0].pointer", "sha256:abc", true},
+		{"evidence[0]", map[string]interface{}{"pointer": "sha256:abc", "type": "archive_reference"}, true},
+		{"tags[0]", "constitutional", true},
+		{`a\.b\[0\]`, "tricky key", true},
+	}
+	for _, c := range cases {
+		ok, err := VerifyPath(index, c.path, c.value)
+		if err != nil {
+			t.Errorf("%s: %v", c.path, err)
+			continue
+		}
+		if ok != c.want {
+			t.Errorf("%s = %v: expected %v", c.path, c.value, c.want)
+		}
+	}
+
+	// Set elements are indexed in element hash order
+	first, _ := VerifyPath(index, "evidence2._set[0]", "x")
+	second, _ := VerifyPath(index, "evidence2._set[1]", "x")
+	if first == second {
+		t.Errorf("Exactly one set position should hold x")
+	}
+
+	if _, err := VerifyPath(index, "action.missing", "x"); err == nil {
+		t.Errorf("Unindexed paths should be an error")
+	}
+	t.Logf("✓ Indexed %d paths", len(index))
+}
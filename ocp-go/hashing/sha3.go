@@ -0,0 +1,161 @@
+// sha3.go - SHA3-256 for dual hashing during algorithm transitions
+//
+// The module targets Go versions whose standard library has no SHA3, so this
+// file implements SHA3-256 (FIPS 202) directly: the Keccak-f[1600]
+// permutation in a sponge with a 136-byte rate and the 0x06 domain padding.
+// It is written for clarity rather than speed; during a transition it runs
+// alongside SHA-256, not instead of it.
+
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	// sha3Rate is the sponge rate of SHA3-256 in bytes
+	sha3Rate = 136
+	// SHA3Size is the size of a SHA3-256 digest in bytes
+	SHA3Size = 32
+)
+
+// keccakRoundConstants are the iota step constants of Keccak-f[1600]
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations are the rho step offsets, indexed x + 5y
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// keccakF1600 applies the Keccak-f[1600] permutation to a, indexed x + 5y
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	var b [25]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[x+y] ^= d
+			}
+		}
+		// rho and pi
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRotations[x+5*y])
+			}
+		}
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[x+y] = b[x+y] ^ (^b[(x+1)%5+y] & b[(x+2)%5+y])
+			}
+		}
+		// iota
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// sha3 is a SHA3-256 hash.Hash
+type sha3 struct {
+	state [25]uint64
+	buf   []byte
+}
+
+// NewSHA3 returns a new SHA3-256 hash.Hash
+func NewSHA3() hash.Hash {
+	return &sha3{buf: make([]byte, 0, sha3Rate)}
+}
+
+// SumSHA3 returns the SHA3-256 digest of data
+func SumSHA3(data []byte) [SHA3Size]byte {
+	h := NewSHA3()
+	h.Write(data)
+	var out [SHA3Size]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// absorb XORs one full block into the state and permutes it
+func (s *sha3) absorb(block []byte) {
+	for i := 0; i < sha3Rate/8; i++ {
+		s.state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+	keccakF1600(&s.state)
+}
+
+// Write absorbs p. It never returns an error.
+func (s *sha3) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(s.buf) > 0 {
+		take := sha3Rate - len(s.buf)
+		if take > len(p) {
+			take = len(p)
+		}
+		s.buf = append(s.buf, p[:take]...)
+		p = p[take:]
+		if len(s.buf) < sha3Rate {
+			return n, nil
+		}
+		s.absorb(s.buf)
+		s.buf = s.buf[:0]
+	}
+	for len(p) >= sha3Rate {
+		s.absorb(p[:sha3Rate])
+		p = p[sha3Rate:]
+	}
+	s.buf = append(s.buf, p...)
+	return n, nil
+}
+
+// Sum appends the digest of the data written so far to b, without changing
+// the hash state
+func (s *sha3) Sum(b []byte) []byte {
+	state := s.state
+	block := make([]byte, sha3Rate)
+	copy(block, s.buf)
+	block[len(s.buf)] ^= 0x06
+	block[sha3Rate-1] ^= 0x80
+	for i := 0; i < sha3Rate/8; i++ {
+		state[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+	keccakF1600(&state)
+
+	var out [SHA3Size]byte
+	for i := 0; i < SHA3Size/8; i++ {
+		binary.LittleEndian.PutUint64(out[8*i:], state[i])
+	}
+	return append(b, out[:]...)
+}
+
+// Reset clears the hash state
+func (s *sha3) Reset() {
+	s.state = [25]uint64{}
+	s.buf = s.buf[:0]
+}
+
+// Size returns the digest size in bytes
+func (s *sha3) Size() int {
+	return SHA3Size
+}
+
+// BlockSize returns the sponge rate in bytes
+func (s *sha3) BlockSize() int {
+	return sha3Rate
+}
@@ -0,0 +1,57 @@
+package hashing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestSHA3Vectors tests SHA3-256 against the FIPS 202 example values
+func TestSHA3Vectors(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"", "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"},
+		{"abc", "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "41c0dba2a9d6240849100376a8235e2c82e1b9998a999e21db32dd97496d3376"},
+		{strings.Repeat("a", 1000000), "5c8875ae474a3634ba4fd55ec85bffd661f32aca75c6d699d0cdcb6c115891c1"},
+	}
+	for _, tc := range cases {
+		sum := SumSHA3([]byte(tc.input))
+		if got := hex.EncodeToString(sum[:]); got != tc.expected {
+			t.Errorf("SHA3-256 of %d bytes: expected %s, got %s", len(tc.input), tc.expected, got)
+		}
+	}
+
+	t.Logf("✓ %d SHA3-256 vectors match", len(cases))
+}
+
+// TestSHA3Streaming tests that writes split anywhere give the one-shot digest
+func TestSHA3Streaming(t *testing.T) {
+	data := bytes.Repeat([]byte("ocp-sha3-"), 100)
+	want := SumSHA3(data)
+	for _, chunk := range []int{1, 7, sha3Rate - 1, sha3Rate, sha3Rate + 1, 500} {
+		h := NewSHA3()
+		for i := 0; i < len(data); i += chunk {
+			end := i + chunk
+			if end > len(data) {
+				end = len(data)
+			}
+			h.Write(data[i:end])
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("Chunks of %d: digest differs", chunk)
+		}
+		// Sum does not disturb the state
+		h.Write([]byte("x"))
+		h.Reset()
+		h.Write(data)
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("Chunks of %d: digest differs after Reset", chunk)
+		}
+	}
+
+	t.Log("✓ Streaming writes match one-shot digests")
+}
@@ -0,0 +1,217 @@
+// heartbeat.go - Agent heartbeats and deterministic quorum health
+//
+// Each agent signs a Heartbeat once per epoch naming the ledger head it has
+// seen. A LivenessTracker collects verified heartbeats and derives, for any
+// epoch, which members are live, how many epochs the others have missed, and
+// whether the live members can still reach the quorum threshold. The result
+// depends only on the heartbeats received, so nodes holding the same
+// heartbeats agree on membership decisions such as "agents missing N epochs
+// lose voting weight".
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Heartbeat is an agent's signed statement that it was live at an epoch
+type Heartbeat struct {
+	Agent     string    `json:"agent"`
+	Epoch     uint64    `json:"epoch"`
+	HeadHash  string    `json:"head_hash"`
+	Signature Signature `json:"signature"`
+}
+
+// NewHeartbeat creates and signs a heartbeat
+func NewHeartbeat(agent string, epoch uint64, headHash string, key ed25519.PrivateKey) (*Heartbeat, error) {
+	hb := &Heartbeat{Agent: agent, Epoch: epoch, HeadHash: headHash}
+	hash, err := hb.Hash()
+	if err != nil {
+		return nil, err
+	}
+	hb.Signature, err = SignHash(agent, key, ContextHeartbeat, hash)
+	if err != nil {
+		return nil, err
+	}
+	return hb, nil
+}
+
+// ToMap converts a Heartbeat to a map for canonicalization. The signature is
+// excluded, since it signs this form.
+func (h *Heartbeat) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"agent":     h.Agent,
+		"epoch":     h.Epoch,
+		"head_hash": h.HeadHash,
+	}
+}
+
+// Hash returns the semantic hash of the heartbeat
+func (h *Heartbeat) Hash() (string, error) {
+	return SemanticHash(h.ToMap())
+}
+
+// Verify checks the heartbeat's signature against the agent's key
+func (h *Heartbeat) Verify(key ed25519.PublicKey) error {
+	if h.Signature.Signer != h.Agent {
+		return NewVerificationError(fmt.Sprintf("heartbeat for %s signed by %s", h.Agent, h.Signature.Signer))
+	}
+	hash, err := h.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextHeartbeat, hash, h.Signature)
+}
+
+// QuorumHealth is the liveness of a quorum's members at an epoch
+type QuorumHealth struct {
+	Epoch     uint64 `json:"epoch"`
+	MaxMissed uint64 `json:"max_missed"`
+	// Live members have missed at most MaxMissed epochs, sorted by name
+	Live []string `json:"live"`
+	// Missed is the number of epochs each member has missed
+	Missed    map[string]uint64 `json:"missed"`
+	Threshold int               `json:"threshold"`
+	// Healthy reports whether the live members alone can reach Threshold
+	Healthy bool `json:"healthy"`
+}
+
+// ToMap converts a QuorumHealth to a map for canonicalization
+func (q *QuorumHealth) ToMap() map[string]interface{} {
+	live := make([]interface{}, len(q.Live))
+	for i, agent := range q.Live {
+		live[i] = agent
+	}
+	missed := make(map[string]interface{}, len(q.Missed))
+	for agent, n := range q.Missed {
+		missed[agent] = n
+	}
+	return map[string]interface{}{
+		"epoch":      q.Epoch,
+		"max_missed": q.MaxMissed,
+		"live":       live,
+		"missed":     missed,
+		"threshold":  q.Threshold,
+		"healthy":    q.Healthy,
+	}
+}
+
+// Hash returns the semantic hash of the health record
+func (q *QuorumHealth) Hash() (string, error) {
+	return SemanticHash(q.ToMap())
+}
+
+// LivenessTracker records heartbeats from a quorum's members
+type LivenessTracker struct {
+	mu         sync.RWMutex
+	quorum     *Quorum
+	startEpoch uint64
+	lastSeen   map[string]uint64
+	// Ledger, if set, must contain the head hash named by each heartbeat
+	Ledger *Ledger
+}
+
+// NewLivenessTracker creates a tracker for quorum. Members that have not yet
+// sent a heartbeat are counted as last seen at startEpoch.
+func NewLivenessTracker(quorum *Quorum, startEpoch uint64) *LivenessTracker {
+	return &LivenessTracker{
+		quorum:     quorum,
+		startEpoch: startEpoch,
+		lastSeen:   make(map[string]uint64),
+	}
+}
+
+// Record verifies and records a heartbeat. Heartbeats older than the agent's
+// latest are accepted but do not move its last-seen epoch back.
+func (t *LivenessTracker) Record(hb *Heartbeat) error {
+	key, ok := t.quorum.Members[hb.Agent]
+	if !ok {
+		return NewVerificationError(fmt.Sprintf("heartbeat from non-member %s", hb.Agent))
+	}
+	if err := hb.Verify(key); err != nil {
+		return err
+	}
+	if t.Ledger != nil && !ledgerHasHead(t.Ledger, hb.HeadHash) {
+		return NewVerificationError(fmt.Sprintf("heartbeat head %s is not on the ledger", hb.HeadHash))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.lastSeen[hb.Agent]; !ok || hb.Epoch > last {
+		t.lastSeen[hb.Agent] = hb.Epoch
+	}
+	return nil
+}
+
+// LastSeen returns the latest epoch an agent sent a heartbeat for
+func (t *LivenessTracker) LastSeen(agent string) (uint64, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	epoch, ok := t.lastSeen[agent]
+	return epoch, ok
+}
+
+// Health computes quorum health at epoch
+//
+// Parameters:
+//   - epoch: Epoch to evaluate
+//   - maxMissed: Epochs a member may miss before it stops counting as live
+//
+// Returns:
+//   - Health record, identical on every node with the same heartbeats
+func (t *LivenessTracker) Health(epoch, maxMissed uint64) *QuorumHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	h := &QuorumHealth{
+		Epoch:     epoch,
+		MaxMissed: maxMissed,
+		Live:      []string{},
+		Missed:    make(map[string]uint64, len(t.quorum.Members)),
+		Threshold: t.quorum.Threshold,
+	}
+	for agent := range t.quorum.Members {
+		last := t.startEpoch
+		if seen, ok := t.lastSeen[agent]; ok && seen > last {
+			last = seen
+		}
+		var missed uint64
+		if epoch > last {
+			missed = epoch - last
+		}
+		h.Missed[agent] = missed
+		if missed <= maxMissed {
+			h.Live = append(h.Live, agent)
+		}
+	}
+	sort.Strings(h.Live)
+	h.Healthy = len(h.Live) >= h.Threshold
+	return h
+}
+
+// ActiveQuorum returns the quorum restricted to members live at epoch, with
+// the original threshold. Members that missed more than maxMissed epochs have
+// no voting weight in it.
+func (t *LivenessTracker) ActiveQuorum(epoch, maxMissed uint64) (*Quorum, error) {
+	health := t.Health(epoch, maxMissed)
+	members := make(map[string]ed25519.PublicKey, len(health.Live))
+	for _, agent := range health.Live {
+		members[agent] = t.quorum.Members[agent]
+	}
+	return NewQuorum(members, t.quorum.Threshold)
+}
+
+func ledgerHasHead(l *Ledger, hash string) bool {
+	if hash == l.Head() {
+		return true
+	}
+	for _, e := range l.Entries(0) {
+		if e.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
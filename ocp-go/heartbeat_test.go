@@ -0,0 +1,100 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestHeartbeatSignature tests heartbeat signing and tamper detection
+func TestHeartbeatSignature(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Claude")
+	hb, err := NewHeartbeat("Claude", 7, "head", priv)
+	if err != nil {
+		t.Fatalf("Failed to create heartbeat: %v", err)
+	}
+	if err := hb.Verify(pub); err != nil {
+		t.Errorf("Heartbeat should verify: %v", err)
+	}
+
+	hb.Epoch = 8
+	if err := hb.Verify(pub); err == nil {
+		t.Errorf("Altered heartbeat should not verify")
+	}
+
+	t.Logf("✓ Heartbeat signed by %s", hb.Signature.Signer)
+}
+
+// TestLivenessHealth tests deterministic health and voting weight loss
+func TestLivenessHealth(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	ledger := NewLedger()
+	ledger.Append("genesis", map[string]interface{}{})
+	tracker := NewLivenessTracker(quorum, 0)
+	tracker.Ledger = ledger
+
+	beat := func(agent string, epoch uint64) {
+		hb, _ := NewHeartbeat(agent, epoch, ledger.Head(), privs[agent])
+		if err := tracker.Record(hb); err != nil {
+			t.Fatalf("Failed to record heartbeat: %v", err)
+		}
+	}
+	beat("Claude", 10)
+	beat("Gemini", 9)
+	beat("DeepSeek", 4)
+	beat("Claude", 3)
+
+	health := tracker.Health(10, 2)
+	if len(health.Live) != 2 || health.Live[0] != "Claude" || health.Live[1] != "Gemini" {
+		t.Errorf("Expected Claude and Gemini live, got %v", health.Live)
+	}
+	if health.Missed["DeepSeek"] != 6 || health.Missed["Claude"] != 0 || !health.Healthy {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+	h1, _ := health.Hash()
+	h2, _ := tracker.Health(10, 2).Hash()
+	if h1 != h2 {
+		t.Errorf("Health record should be deterministic")
+	}
+
+	active, err := tracker.ActiveQuorum(10, 2)
+	if err != nil || len(active.Members) != 2 {
+		t.Fatalf("Active quorum should hold the live members: %v", err)
+	}
+	if _, err := tracker.ActiveQuorum(13, 2); err == nil {
+		t.Errorf("Active quorum should fail once too few members are live")
+	}
+	if tracker.Health(13, 2).Healthy {
+		t.Errorf("Quorum should be unhealthy at epoch 13")
+	}
+
+	t.Logf("✓ Quorum health %s", h1)
+}
+
+// TestLivenessRejects tests heartbeats from outsiders and unknown heads
+func TestLivenessRejects(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	tracker := NewLivenessTracker(quorum, 0)
+	tracker.Ledger = NewLedger()
+
+	_, outsider := testKey("Mallory")
+	hb, _ := NewHeartbeat("Mallory", 1, "", outsider)
+	if err := tracker.Record(hb); err == nil {
+		t.Errorf("Heartbeat from a non-member should be rejected")
+	}
+
+	hb, _ = NewHeartbeat("Claude", 1, "forked-head", privs["Claude"])
+	if err := tracker.Record(hb); err == nil {
+		t.Errorf("Heartbeat naming an unknown head should be rejected")
+	}
+
+	hb, _ = NewHeartbeat("Claude", 1, "", privs["Gemini"])
+	if err := tracker.Record(hb); err == nil {
+		t.Errorf("Heartbeat signed with another member's key should be rejected")
+	}
+
+	t.Logf("✓ Invalid heartbeats rejected")
+}
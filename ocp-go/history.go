@@ -0,0 +1,263 @@
+// history.go - Constitutional state as of a past ledger height
+//
+// Whether an old proposal was valid depends on the rules in force when it was
+// accepted, not on today's. The ledger records everything those rules are made
+// of: genesis fixes the first constitution and policy table, constitution and
+// policy entries record ratified successors, and reputation is derived from
+// proposals and challenge outcomes. StateAt replays entries up to a height to
+// reconstruct that state; StateAtSnapshot does the same from a quorum-signed
+// snapshot of an earlier HistoricalState, so long histories need not be
+// replayed from genesis.
+//
+// A proposal recorded at height h was accepted under StateAt(h - 1).
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// LedgerKindPolicy is the ledger entry kind recording a ratified policy table
+const LedgerKindPolicy = "policy"
+
+// RecordPolicy records a quorum-approved policy table on the ledger, so that
+// historical state replays see it take effect at this height
+//
+// Parameters:
+//   - ledger: Ledger receiving the policy entry
+//   - quorum: Quorum that must approve the table
+//   - policies: New policy table
+//   - sigs: Signatures over the table's semantic hash under ContextPolicy
+//
+// Returns:
+//   - Semantic hash of the policy table
+func RecordPolicy(ledger *Ledger, quorum *Quorum, policies map[string]interface{}, sigs []Signature) (string, error) {
+	hash, err := SemanticHash(policies)
+	if err != nil {
+		return "", err
+	}
+	signers, err := quorum.Verify(ContextPolicy, hash, sigs)
+	if err != nil {
+		return "", err
+	}
+	if _, err := ledger.Append(LedgerKindPolicy, map[string]interface{}{
+		"policy_hash": hash,
+		"policies":    canonical.DeepCopy(policies),
+		"signers":     stringList(signers),
+		"signatures":  signatureList(sigs),
+	}); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// HistoricalState is the constitutional state in force at a ledger height
+type HistoricalState struct {
+	Height             uint64
+	HeadHash           string
+	ConstitutionHash   string
+	ConstitutionHeight uint64
+	Policies           map[string]interface{}
+	PolicyHash         string
+	// Reputation holds every agent with a recorded reputation change
+	Reputation map[string]int
+}
+
+// ToMap converts a HistoricalState to a map for canonicalization
+func (s *HistoricalState) ToMap() map[string]interface{} {
+	reputation := make(map[string]interface{}, len(s.Reputation))
+	for agent, rep := range s.Reputation {
+		reputation[agent] = rep
+	}
+	return map[string]interface{}{
+		"height":              s.Height,
+		"head_hash":           s.HeadHash,
+		"constitution_hash":   s.ConstitutionHash,
+		"constitution_height": s.ConstitutionHeight,
+		"policies":            s.Policies,
+		"policy_hash":         s.PolicyHash,
+		"reputation":          reputation,
+	}
+}
+
+// Hash returns the semantic hash of the state
+func (s *HistoricalState) Hash() (string, error) {
+	return SemanticHash(s.ToMap())
+}
+
+// Agents returns the agents with a recorded reputation, sorted
+func (s *HistoricalState) Agents() []string {
+	agents := make([]string, 0, len(s.Reputation))
+	for agent := range s.Reputation {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+// HistoricalStateFromMap reads a state written by ToMap, such as the state of
+// a snapshot taken with Ledger.Snapshot(state.ToMap())
+func HistoricalStateFromMap(m map[string]interface{}) (*HistoricalState, error) {
+	s := &HistoricalState{
+		Height:             uint64(payloadInt(m, "height")),
+		HeadHash:           payloadString(m, "head_hash"),
+		ConstitutionHash:   payloadString(m, "constitution_hash"),
+		ConstitutionHeight: uint64(payloadInt(m, "constitution_height")),
+		PolicyHash:         payloadString(m, "policy_hash"),
+		Reputation:         make(map[string]int),
+	}
+	policies, ok := m["policies"].(map[string]interface{})
+	if !ok {
+		return nil, NewConstitutionalError("historical state has no policy table")
+	}
+	s.Policies = canonical.DeepCopy(policies).(map[string]interface{})
+	if hash, err := SemanticHash(s.Policies); err != nil || hash != s.PolicyHash {
+		return nil, NewVerificationError("historical state policy table does not match its hash")
+	}
+	reputation, _ := m["reputation"].(map[string]interface{})
+	for agent := range reputation {
+		s.Reputation[agent] = payloadInt(reputation, agent)
+	}
+	return s, nil
+}
+
+// apply advances the state by one entry
+func (s *HistoricalState) apply(e LedgerEntry) error {
+	switch e.Kind {
+	case LedgerKindGenesis:
+		state, ok := e.Payload["state"].(map[string]interface{})
+		if !ok {
+			return NewVerificationError("genesis entry has no state")
+		}
+		policies, _ := state["policies"].(map[string]interface{})
+		if policies == nil {
+			policies = map[string]interface{}{}
+		}
+		if err := s.setPolicies(policies); err != nil {
+			return err
+		}
+		s.ConstitutionHash, s.ConstitutionHeight = payloadString(state, "constitution_hash"), e.Height
+	case LedgerKindConstitution:
+		s.ConstitutionHash, s.ConstitutionHeight = payloadString(e.Payload, "constitution_hash"), e.Height
+	case LedgerKindPolicy:
+		policies, ok := e.Payload["policies"].(map[string]interface{})
+		if !ok {
+			return NewVerificationError(fmt.Sprintf("policy entry %d has no table", e.Height))
+		}
+		if err := s.setPolicies(policies); err != nil {
+			return err
+		}
+		if s.PolicyHash != payloadString(e.Payload, "policy_hash") {
+			return NewVerificationError(fmt.Sprintf("policy entry %d does not match its hash", e.Height))
+		}
+	default:
+		applyReputation(s.Reputation, e)
+	}
+	s.Height, s.HeadHash = e.Height, e.Hash
+	return nil
+}
+
+func (s *HistoricalState) setPolicies(policies map[string]interface{}) error {
+	hash, err := SemanticHash(policies)
+	if err != nil {
+		return err
+	}
+	s.Policies, s.PolicyHash = canonical.DeepCopy(policies).(map[string]interface{}), hash
+	return nil
+}
+
+// ReplayState advances seed through entries up to and including height. A
+// nil seed starts from the empty ledger, so entries must then begin with
+// genesis. Entries must continue the chain from the seed's head.
+//
+// Parameters:
+//   - seed: State to start from, or nil; it is not modified
+//   - entries: Entries following the seed, in order
+//   - height: Height of the state to reconstruct
+//
+// Returns:
+//   - State as of height
+func ReplayState(seed *HistoricalState, entries []LedgerEntry, height uint64) (*HistoricalState, error) {
+	state := &HistoricalState{Reputation: make(map[string]int)}
+	if seed != nil {
+		state = &HistoricalState{
+			Height:             seed.Height,
+			HeadHash:           seed.HeadHash,
+			ConstitutionHash:   seed.ConstitutionHash,
+			ConstitutionHeight: seed.ConstitutionHeight,
+			Policies:           canonical.DeepCopy(seed.Policies).(map[string]interface{}),
+			PolicyHash:         seed.PolicyHash,
+			Reputation:         make(map[string]int, len(seed.Reputation)),
+		}
+		for agent, rep := range seed.Reputation {
+			state.Reputation[agent] = rep
+		}
+	}
+	if height < state.Height {
+		return nil, NewConstitutionalError(fmt.Sprintf("height %d precedes the seed state at %d", height, state.Height))
+	}
+	if seed == nil && height == 0 {
+		return nil, NewConstitutionalError("no constitutional state before genesis")
+	}
+
+	var replay []LedgerEntry
+	for _, e := range entries {
+		if e.Height > height {
+			break
+		}
+		replay = append(replay, e)
+	}
+	if _, err := VerifyChain(state.Height, state.HeadHash, replay); err != nil {
+		return nil, err
+	}
+	if state.Height+uint64(len(replay)) != height {
+		return nil, NewConstitutionalError(fmt.Sprintf("entries end before height %d", height))
+	}
+	if seed == nil && replay[0].Kind != LedgerKindGenesis {
+		return nil, NewVerificationError("replay from the empty ledger must start with genesis")
+	}
+	for _, e := range replay {
+		if err := state.apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// StateAt reconstructs the state at height by replaying entries from genesis
+func StateAt(entries []LedgerEntry, height uint64) (*HistoricalState, error) {
+	return ReplayState(nil, entries, height)
+}
+
+// StateAtSnapshot reconstructs the state at height from a quorum-signed
+// snapshot whose state is a HistoricalState and the entries after it
+func StateAtSnapshot(snap *Snapshot, quorum *Quorum, delta []LedgerEntry, height uint64) (*HistoricalState, error) {
+	if err := snap.SignedBy(quorum); err != nil {
+		return nil, err
+	}
+	seed, err := HistoricalStateFromMap(snap.State)
+	if err != nil {
+		return nil, err
+	}
+	if seed.Height != snap.Height || seed.HeadHash != snap.HeadHash {
+		return nil, NewVerificationError("snapshot state is not the state at the snapshot's position")
+	}
+	return ReplayState(seed, delta, height)
+}
+
+// StateAt reconstructs the state at height from the ledger's entries. The
+// ledger must hold its history from genesis; a ledger bootstrapped from a
+// snapshot must use StateAtSnapshot.
+func (l *Ledger) StateAt(height uint64) (*HistoricalState, error) {
+	l.mu.RLock()
+	base := l.baseHeight
+	entries := l.entriesAfterLocked(0)
+	l.mu.RUnlock()
+	if base > 0 {
+		return nil, NewConstitutionalError(fmt.Sprintf("ledger starts at height %d; use StateAtSnapshot", base))
+	}
+	return StateAt(entries, height)
+}
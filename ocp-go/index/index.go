@@ -0,0 +1,141 @@
+// Package index finds prior proposals similar to a query.
+//
+// Reviewers judging a proposal want the earlier ones that touched the same
+// clauses or made the same claims. An Index holds the text of proposals keyed
+// by their semantic hash and answers queries with ranked Results naming those
+// exact hashes, so every match can be fetched and verified from the archive
+// or ledger. The text is taken from the proposal's canonical form, so two
+// nodes indexing the same proposals index the same strings.
+//
+// Trigram is the reference implementation: character trigram overlap, with
+// integer scores and ties broken by hash, so results are reproducible. Other
+// indexes (embeddings, full-text engines) can stand behind the same interface.
+package index
+
+import (
+	"sort"
+	"strings"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// Result is one match for a query
+type Result struct {
+	ProposalHash string `json:"proposal_hash"`
+	// Score ranks the match from 0 to 1000; its meaning is the index's own
+	Score int `json:"score"`
+}
+
+// ToMap converts a Result to a map for canonicalization
+func (r Result) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash": r.ProposalHash,
+		"score":         r.Score,
+	}
+}
+
+// Index stores proposal text for similarity search
+type Index interface {
+	// Add indexes text under a proposal hash, replacing any text already
+	// indexed under it
+	Add(proposalHash, text string) error
+	// Search returns up to limit matches for query, best first; a limit of
+	// zero or less returns every match
+	Search(query string, limit int) ([]Result, error)
+}
+
+// skippedFields are members whose values identify or sign a proposal rather
+// than say anything about it
+var skippedFields = map[string]bool{
+	"id":                      true,
+	"timestamp":               true,
+	"canonical_serialization": true,
+	"proposer_signature":      true,
+	"pre_state_hash":          true,
+	"post_state_hash":         true,
+	"pointer":                 true,
+}
+
+// Text returns the searchable text of a proposal: the string values of its
+// canonical form in canonical order, without identifiers, hashes, and
+// signatures
+func Text(p *ocp.ContractProposal) (string, error) {
+	form, err := ocp.CanonicalizeValue(p.ToMap())
+	if err != nil {
+		return "", err
+	}
+	doc, err := ocp.DecodeStrict([]byte(form))
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	collectText(doc, &parts)
+	return strings.Join(parts, "\n"), nil
+}
+
+func collectText(value interface{}, parts *[]string) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			*parts = append(*parts, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectText(item, parts)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if !skippedFields[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectText(v[key], parts)
+		}
+	}
+}
+
+// AddProposal indexes p under its semantic hash
+//
+// Returns:
+//   - Hash p was indexed under
+func AddProposal(idx Index, p *ocp.ContractProposal) (string, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return "", err
+	}
+	text, err := Text(p)
+	if err != nil {
+		return "", err
+	}
+	return hash, idx.Add(hash, text)
+}
+
+// SimilarTo searches idx for proposals like p, leaving p itself out
+func SimilarTo(idx Index, p *ocp.ContractProposal, limit int) ([]Result, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	text, err := Text(p)
+	if err != nil {
+		return nil, err
+	}
+	n := limit
+	if n > 0 {
+		n++
+	}
+	results, err := idx.Search(text, n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Result, 0, len(results))
+	for _, r := range results {
+		if r.ProposalHash != hash && (limit <= 0 || len(out) < limit) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
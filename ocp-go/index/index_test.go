@@ -0,0 +1,84 @@
+package index
+
+import (
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// clauseProposal returns a proposal amending clause with rationale
+func clauseProposal(id, clause, rationale string) *ocp.ContractProposal {
+	return &ocp.ContractProposal{
+		ID:            id,
+		ProposerAgent: "Claude",
+		ActionType:    "amend",
+		Action: map[string]interface{}{
+			"target":     "constitution",
+			"operation":  "modify",
+			"parameters": map[string]interface{}{"article": clause},
+		},
+		Evidence: []map[string]string{
+			{"type": "archive_reference", "pointer": "sha256:abc123def456", "description": "Dispute record"},
+		},
+		Reasoning: map[string]interface{}{"rationale": rationale, "confidence": 0.8},
+		Timestamp: "2025-11-20T14:30:00Z",
+	}
+}
+
+// TestText tests that proposal text is its content without identifiers
+func TestText(t *testing.T) {
+	p := clauseProposal("550e8400-e29b-41d4-a716-446655440000", "Article III.1", "Clarifies quorum rules")
+	p.ProposerSignature = map[string]string{"value": "c2lnbmF0dXJl"}
+	text, err := Text(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Article III.1", "Clarifies quorum rules", "Dispute record", "constitution"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in %q", want, text)
+		}
+	}
+	for _, unwanted := range []string{"550e8400", "2025-11-20", "sha256:abc123", "c2lnbmF0dXJl"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("Expected %q to be left out of %q", unwanted, text)
+		}
+	}
+	t.Logf("✓ Proposal text: %q", text)
+}
+
+// TestSimilarTo tests finding prior proposals on the same clause
+func TestSimilarTo(t *testing.T) {
+	idx := NewTrigram()
+	quorum := clauseProposal("1", "Article III.1", "Raises the quorum for amendments to two thirds")
+	stake := clauseProposal("2", "Article VII.4", "Caps reputation stake per sponsor")
+	for _, p := range []*ocp.ContractProposal{quorum, stake} {
+		if _, err := AddProposal(idx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	draft := clauseProposal("3", "Article III.1", "Lowers the amendment quorum to a simple majority")
+	hash, err := AddProposal(idx, draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := SimilarTo(idx, draft, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quorumHash, _ := quorum.GetHash()
+	if len(results) != 1 || results[0].ProposalHash != quorumHash {
+		t.Fatalf("Expected the quorum proposal first, got %+v", results)
+	}
+	all, _ := SimilarTo(idx, draft, 0)
+	for _, r := range all {
+		if r.ProposalHash == hash {
+			t.Errorf("A proposal should not be similar to itself")
+		}
+	}
+	if len(all) != 2 || all[0].Score <= all[1].Score {
+		t.Errorf("Expected both earlier proposals, best first, got %+v", all)
+	}
+	t.Logf("✓ Prior proposal on the same clause scored %d, the other %d", all[0].Score, all[1].Score)
+}
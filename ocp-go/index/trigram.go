@@ -0,0 +1,132 @@
+// trigram.go - Reference trigram index
+//
+// Text is folded to lower case, split into words at every character that is
+// not a letter or digit, and each word padded with two leading spaces and one
+// trailing so that short words and word starts yield trigrams of their own
+// ("iii" gives "  i", " ii", "iii", "ii "). A document is the set of its
+// trigrams, and a query scores each document by the Dice coefficient of the
+// two sets in thousandths: 2000 * shared / (query + document). Matching by
+// fragments rather than words finds "Article III.1" in "article iii, clause 1"
+// and survives inflection and small typos.
+
+package index
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// Trigram is an in-memory trigram Index. It is safe for concurrent use.
+type Trigram struct {
+	mu sync.RWMutex
+	// docs maps a proposal hash to its trigram set
+	docs map[string]map[string]bool
+	// postings maps a trigram to the hashes of documents containing it
+	postings map[string]map[string]bool
+}
+
+var _ Index = (*Trigram)(nil)
+
+// NewTrigram creates an empty Trigram index
+func NewTrigram() *Trigram {
+	return &Trigram{
+		docs:     make(map[string]map[string]bool),
+		postings: make(map[string]map[string]bool),
+	}
+}
+
+// Trigrams returns the trigram set of text
+func Trigrams(text string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// Add indexes text under proposalHash
+func (t *Trigram) Add(proposalHash, text string) error {
+	if proposalHash == "" {
+		return ocp.NewConstitutionalError("cannot index text without a proposal hash")
+	}
+	grams := Trigrams(text)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeLocked(proposalHash)
+	t.docs[proposalHash] = grams
+	for gram := range grams {
+		if t.postings[gram] == nil {
+			t.postings[gram] = make(map[string]bool)
+		}
+		t.postings[gram][proposalHash] = true
+	}
+	return nil
+}
+
+// Remove drops a proposal from the index
+func (t *Trigram) Remove(proposalHash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeLocked(proposalHash)
+}
+
+func (t *Trigram) removeLocked(proposalHash string) {
+	for gram := range t.docs[proposalHash] {
+		delete(t.postings[gram], proposalHash)
+		if len(t.postings[gram]) == 0 {
+			delete(t.postings, gram)
+		}
+	}
+	delete(t.docs, proposalHash)
+}
+
+// Len returns the number of indexed proposals
+func (t *Trigram) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.docs)
+}
+
+// Search returns the documents sharing trigrams with query, by descending
+// score and then ascending hash
+func (t *Trigram) Search(query string, limit int) ([]Result, error) {
+	grams := Trigrams(query)
+	if len(grams) == 0 {
+		return []Result{}, nil
+	}
+
+	t.mu.RLock()
+	shared := make(map[string]int)
+	for gram := range grams {
+		for hash := range t.postings[gram] {
+			shared[hash]++
+		}
+	}
+	results := make([]Result, 0, len(shared))
+	for hash, n := range shared {
+		results = append(results, Result{ProposalHash: hash, Score: 2000 * n / (len(grams) + len(t.docs[hash]))})
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ProposalHash < results[j].ProposalHash
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,55 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTrigrams tests word splitting, folding, and padding
+func TestTrigrams(t *testing.T) {
+	want := map[string]bool{"  i": true, " ii": true, "iii": true, "ii ": true, "  1": true, " 1 ": true}
+	if got := Trigrams("III.1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := Trigrams(" .,; "); len(got) != 0 {
+		t.Errorf("Expected no trigrams from punctuation, got %v", got)
+	}
+	t.Logf("✓ %d trigrams from III.1", len(want))
+}
+
+// TestTrigramSearch tests scoring, ordering, replacement, and removal
+func TestTrigramSearch(t *testing.T) {
+	idx := NewTrigram()
+	idx.Add("sha256:b", "quorum for amendments")
+	idx.Add("sha256:a", "quorum for amendments")
+	idx.Add("sha256:c", "reputation stake caps")
+	if err := idx.Add("", "orphan"); err == nil {
+		t.Error("Expected text without a hash to be refused")
+	}
+
+	results, _ := idx.Search("Quorum for amendments", 0)
+	if len(results) != 2 || results[0].ProposalHash != "sha256:a" || results[1].ProposalHash != "sha256:b" {
+		t.Fatalf("Expected both exact matches, tied and ordered by hash, got %+v", results)
+	}
+	if results[0].Score != 1000 {
+		t.Errorf("Expected an exact match to score 1000, got %d", results[0].Score)
+	}
+	if limited, _ := idx.Search("quorum", 1); len(limited) != 1 {
+		t.Errorf("Expected the limit to apply, got %+v", limited)
+	}
+	if empty, _ := idx.Search("", 0); len(empty) != 0 {
+		t.Errorf("Expected no results for an empty query, got %+v", empty)
+	}
+
+	idx.Add("sha256:a", "reputation stake")
+	idx.Remove("sha256:b")
+	results, _ = idx.Search("quorum for amendments", 0)
+	if len(results) != 0 || idx.Len() != 2 {
+		t.Errorf("Expected replaced and removed text to be gone, got %+v", results)
+	}
+	results, _ = idx.Search("stake", 0)
+	if len(results) != 2 || results[0].ProposalHash != "sha256:a" {
+		t.Errorf("Expected the shorter document to rank first, got %+v", results)
+	}
+	t.Logf("✓ Search ranked %d documents", len(results))
+}
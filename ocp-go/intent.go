@@ -0,0 +1,355 @@
+// intent.go - Declared intent before high-impact proposals
+//
+// An irreversible proposal that appears without warning leaves the other
+// agents and human overseers only the challenge window to react. Policy can
+// instead require a cooling-off period: the proposer first publishes a signed
+// IntentDeclaration naming the action type it plans and the earliest time it
+// will submit, and the proposal itself carries the declaration's hash in
+// action.intent_hash. An IntentRegistry records each declaration on the
+// ledger and refuses a covered proposal until its declaration is both past
+// its own earliest submission time and at least the minimum age in force
+// when it was declared.
+//
+// The cooling-off state lives on the ledger, not in any node's memory, so a
+// restarted node and every replaying verifier reach the same verdict: age is
+// measured from the declared_at time of the declaration's intent entry to the
+// accepted_at time of the proposal entry citing it, and the proposal entry
+// records the intent_hash it consumes. VerifyIntents replays both.
+//
+// Which proposals need an intent is set by the "intent_declarations" entry of
+// the policy table:
+//
+//	{"intent_declarations": {
+//	    "action_types": ["override"],
+//	    "reversibility_classes": ["irreversible"],
+//	    "min_age_seconds": 86400}}
+//
+// A declaration covers one proposal; once a proposal citing it is accepted,
+// it cannot be cited again.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LedgerKindIntent is the ledger entry kind recording an IntentDeclaration
+const LedgerKindIntent = "intent"
+
+// IntentPolicyKey is the policy table entry configuring intent declarations
+const IntentPolicyKey = "intent_declarations"
+
+// ErrIntentRequired is returned for a covered proposal without a valid,
+// sufficiently aged intent declaration
+var ErrIntentRequired = &ConstitutionalError{ErrorType: "IntentError", Message: "proposal requires an aged intent declaration"}
+
+// IntentPolicy selects the proposals that need an intent declaration
+type IntentPolicy struct {
+	ActionTypes          []string
+	ReversibilityClasses []string
+	MinAge               time.Duration
+}
+
+// IntentPolicyFromTable reads the intent policy from a policy table. A table
+// without the entry requires no declarations.
+func IntentPolicyFromTable(policies map[string]interface{}) IntentPolicy {
+	entry, _ := policies[IntentPolicyKey].(map[string]interface{})
+	return IntentPolicy{
+		ActionTypes:          payloadStrings(entry, "action_types"),
+		ReversibilityClasses: payloadStrings(entry, "reversibility_classes"),
+		MinAge:               time.Duration(payloadInt(entry, "min_age_seconds")) * time.Second,
+	}
+}
+
+// Requires reports whether p must cite an intent declaration
+func (ip IntentPolicy) Requires(p *ContractProposal) bool {
+	return containsString(ip.ActionTypes, p.ActionType) || containsString(ip.ReversibilityClasses, p.ReversibilityClass)
+}
+
+// IntentDeclaration announces a planned proposal ahead of submission
+type IntentDeclaration struct {
+	Agent      string `json:"agent"`
+	Summary    string `json:"summary"`
+	ActionType string `json:"action_type"`
+	// EarliestSubmission is the earliest time the agent will submit
+	EarliestSubmission string    `json:"earliest_submission"`
+	Signature          Signature `json:"signature"`
+}
+
+// ToMap converts an IntentDeclaration to a map for canonicalization. The
+// signature is excluded, since it signs this form.
+func (d *IntentDeclaration) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"agent":               d.Agent,
+		"summary":             d.Summary,
+		"action_type":         d.ActionType,
+		"earliest_submission": d.EarliestSubmission,
+	}
+}
+
+// Hash returns the semantic hash a proposal cites as its intent_hash
+func (d *IntentDeclaration) Hash() (string, error) {
+	return SemanticHash(d.ToMap())
+}
+
+// Sign signs the declaration as its agent
+func (d *IntentDeclaration) Sign(key ed25519.PrivateKey) error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	d.Signature, err = SignHash(d.Agent, key, ContextIntent, hash)
+	return err
+}
+
+// Verify checks the declaration's signature against the agent's key
+func (d *IntentDeclaration) Verify(key ed25519.PublicKey) error {
+	if d.Signature.Signer != d.Agent {
+		return NewVerificationError(fmt.Sprintf("intent for %s signed by %s", d.Agent, d.Signature.Signer))
+	}
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextIntent, hash, d.Signature)
+}
+
+// IntentHash returns the intent hash a proposal cites, if any
+func IntentHash(p *ContractProposal) string {
+	return payloadString(p.Action, "intent_hash")
+}
+
+// declaredIntent is a declaration recorded on the ledger
+type declaredIntent struct {
+	hash       string
+	agent      string
+	actionType string
+	declaredAt time.Time
+	earliest   time.Time
+	minAge     time.Duration
+	// usedBy is the hash of the proposal that consumed the declaration
+	usedBy string
+}
+
+// ready returns the earliest time a proposal may consume the declaration
+func (d *declaredIntent) ready() time.Time {
+	if at := d.declaredAt.Add(d.minAge); at.After(d.earliest) {
+		return at
+	}
+	return d.earliest
+}
+
+// intentIndex holds declarations and their consumption, built one ledger
+// entry at a time
+type intentIndex struct {
+	byHash map[string]*declaredIntent
+}
+
+// apply records e if it declares an intent or consumes one
+func (x *intentIndex) apply(e LedgerEntry) {
+	switch e.Kind {
+	case LedgerKindIntent:
+		if x.byHash == nil {
+			x.byHash = make(map[string]*declaredIntent)
+		}
+		hash := payloadString(e.Payload, "intent_hash")
+		if _, ok := x.byHash[hash]; ok {
+			return
+		}
+		declaredAt, _ := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "declared_at"))
+		earliest, _ := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "earliest_submission"))
+		x.byHash[hash] = &declaredIntent{
+			hash:       hash,
+			agent:      payloadString(e.Payload, "agent"),
+			actionType: payloadString(e.Payload, "action_type"),
+			declaredAt: declaredAt,
+			earliest:   earliest,
+			minAge:     time.Duration(payloadInt(e.Payload, "min_age_seconds")) * time.Second,
+		}
+	case LedgerKindProposal:
+		if d, ok := x.byHash[payloadString(e.Payload, "intent_hash")]; ok && d.usedBy == "" {
+			d.usedBy = payloadString(e.Payload, "proposal_hash")
+		}
+	}
+}
+
+// IntentRegistry records intent declarations on a ledger and checks
+// proposals against them
+type IntentRegistry struct {
+	mu     sync.Mutex
+	ledger *Ledger
+	keys   map[string]ed25519.PublicKey
+	policy IntentPolicy
+	// Archive, if set, stores every declaration under the hash proposals cite
+	Archive Archive
+	// Clock times declarations and checks; defaults to SystemClock
+	Clock Clock
+
+	// intents is every declaration on the ledger up to height synced
+	intents intentIndex
+	synced  uint64
+}
+
+// NewIntentRegistry creates a registry recording declarations on ledger,
+// which must be the ledger of the node enforcing them, accepting declarations
+// from the agents in keys and enforcing policy
+func NewIntentRegistry(ledger *Ledger, keys map[string]ed25519.PublicKey, policy IntentPolicy) *IntentRegistry {
+	return &IntentRegistry{ledger: ledger, keys: keys, policy: policy}
+}
+
+// SetPolicy replaces the policy, for example after a policy table swap.
+// Declarations already recorded keep the minimum age recorded with them.
+func (r *IntentRegistry) SetPolicy(policy IntentPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// Requires reports whether p must cite an intent declaration under the
+// current policy
+func (r *IntentRegistry) Requires(p *ContractProposal) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy.Requires(p)
+}
+
+// Declare records a signed declaration on the ledger and starts its
+// cooling-off period
+//
+// Returns:
+//   - Hash of the declaration, for the proposal's action.intent_hash
+//   - VerificationError if the agent is unknown or the signature is invalid
+//   - ConstitutionalError if earliest_submission is not a timestamp or the
+//     declaration was already recorded
+func (r *IntentRegistry) Declare(d *IntentDeclaration) (string, error) {
+	key, ok := r.keys[d.Agent]
+	if !ok {
+		return "", NewVerificationError(fmt.Sprintf("no key for agent %s", d.Agent))
+	}
+	if err := d.Verify(key); err != nil {
+		return "", err
+	}
+	if _, err := time.Parse(time.RFC3339Nano, d.EarliestSubmission); err != nil {
+		return "", NewConstitutionalError(fmt.Sprintf("earliest_submission %q is not a timestamp", d.EarliestSubmission))
+	}
+	hash, err := d.Hash()
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.syncLocked()
+	if _, ok := r.intents.byHash[hash]; ok {
+		r.mu.Unlock()
+		return "", NewConstitutionalError(fmt.Sprintf("intent %s already declared", hash))
+	}
+	_, err = r.ledger.Append(LedgerKindIntent, map[string]interface{}{
+		"intent_hash":         hash,
+		"agent":               d.Agent,
+		"summary":             d.Summary,
+		"action_type":         d.ActionType,
+		"earliest_submission": d.EarliestSubmission,
+		"declared_at":         Timestamp(clockOrSystem(r.Clock).Now()),
+		"min_age_seconds":     int64(r.policy.MinAge / time.Second),
+		"signature":           d.Signature.ToMap(),
+	})
+	r.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	if r.Archive != nil {
+		if _, err := ArchiveObject(r.Archive, d.ToMap()); err != nil {
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+// Check reports whether p may be submitted now. Proposals the policy does not
+// cover always pass. A node records IntentHash(p) in the entry of a covered
+// proposal it accepts, which consumes the declaration.
+//
+// Returns:
+//   - nil, or an error wrapping ErrIntentRequired that says what is missing
+func (r *IntentRegistry) Check(p *ContractProposal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.policy.Requires(p) {
+		return nil
+	}
+	r.syncLocked()
+	hash := IntentHash(p)
+	if hash == "" {
+		return fmt.Errorf("%s %s proposal cites no intent_hash: %w", p.ReversibilityClass, p.ActionType, ErrIntentRequired)
+	}
+	intent, ok := r.intents.byHash[hash]
+	if !ok {
+		return fmt.Errorf("intent %s was never declared: %w", hash, ErrIntentRequired)
+	}
+	if intent.agent != p.ProposerAgent || intent.actionType != p.ActionType {
+		return fmt.Errorf("intent %s declares %s by %s, proposal is %s by %s: %w",
+			hash, intent.actionType, intent.agent, p.ActionType, p.ProposerAgent, ErrIntentRequired)
+	}
+	if intent.usedBy != "" {
+		if proposalHash, err := p.GetHash(); err != nil || proposalHash != intent.usedBy {
+			return fmt.Errorf("intent %s already used by %s: %w", hash, intent.usedBy, ErrIntentRequired)
+		}
+	}
+	now := clockOrSystem(r.Clock).Now()
+	if ready := intent.ready(); now.Before(ready) {
+		return fmt.Errorf("intent %s declared at %s may not be cited before %s: %w",
+			hash, Timestamp(intent.declaredAt), Timestamp(ready), ErrIntentRequired)
+	}
+	return nil
+}
+
+// syncLocked applies the entries appended since the last sync
+func (r *IntentRegistry) syncLocked() {
+	for _, e := range r.ledger.Entries(r.synced) {
+		r.intents.apply(e)
+		r.synced = e.Height
+	}
+}
+
+// VerifyIntents replays every intent declaration in entries, checking that
+// each is recorded once and that every proposal entry citing one names an
+// earlier declaration by its proposer that no other proposal consumed, and
+// was accepted no earlier than the declaration's earliest submission and
+// recorded minimum age allow. Declaration signatures are checked when an
+// intent is declared, against keys the ledger does not hold.
+func VerifyIntents(entries []LedgerEntry) error {
+	var intents intentIndex
+	for _, e := range entries {
+		switch e.Kind {
+		case LedgerKindIntent:
+			if _, ok := intents.byHash[payloadString(e.Payload, "intent_hash")]; ok {
+				return NewVerificationError(fmt.Sprintf("intent at height %d declared twice", e.Height))
+			}
+		case LedgerKindProposal:
+			hash := payloadString(e.Payload, "intent_hash")
+			if hash == "" {
+				break
+			}
+			intent, ok := intents.byHash[hash]
+			if !ok {
+				return NewVerificationError(fmt.Sprintf("proposal at height %d cites undeclared intent %s", e.Height, hash))
+			}
+			if intent.agent != payloadString(e.Payload, "proposer_agent") {
+				return NewVerificationError(fmt.Sprintf("proposal at height %d cites intent %s declared by %s", e.Height, hash, intent.agent))
+			}
+			if intent.usedBy != "" {
+				return NewVerificationError(fmt.Sprintf("proposal at height %d reuses intent %s", e.Height, hash))
+			}
+			acceptedAt, err := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "accepted_at"))
+			if err != nil || acceptedAt.Before(intent.ready()) {
+				return NewVerificationError(fmt.Sprintf("proposal at height %d cites intent %s before %s", e.Height, hash, Timestamp(intent.ready())))
+			}
+		}
+		intents.apply(e)
+	}
+	return nil
+}
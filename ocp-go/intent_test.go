@@ -0,0 +1,204 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// intentFixture returns a registry on a fresh ledger requiring a one-day
+// intent for irreversible proposals, its clock, and Claude's key
+func intentFixture(t *testing.T) (*IntentRegistry, *ManualClock, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv := testKey("Claude")
+	policy := IntentPolicyFromTable(map[string]interface{}{
+		IntentPolicyKey: map[string]interface{}{
+			"reversibility_classes": []interface{}{"irreversible"},
+			"min_age_seconds":       86400,
+		},
+	})
+	clock := NewManualClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	r := NewIntentRegistry(NewLedger(), map[string]ed25519.PublicKey{"Claude": pub}, policy)
+	r.Clock = clock
+	return r, clock, priv
+}
+
+func testIntent(t *testing.T, key ed25519.PrivateKey, earliest string) *IntentDeclaration {
+	t.Helper()
+	d := &IntentDeclaration{Agent: "Claude", Summary: "Retire article 3", ActionType: "amend", EarliestSubmission: earliest}
+	if err := d.Sign(key); err != nil {
+		t.Fatalf("Failed to sign intent: %v", err)
+	}
+	return d
+}
+
+// TestIntentCoolingOff tests that covered proposals wait for an aged intent
+func TestIntentCoolingOff(t *testing.T) {
+	testenv.RequireSigning(t)
+	r, clock, key := intentFixture(t)
+	archive := NewMemoryArchive()
+	r.Archive = archive
+
+	p := testProposal()
+	p.ReversibilityClass = ReversibilityIrreversible
+	if err := r.Check(p); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected a proposal without intent to be refused, got %v", err)
+	}
+
+	hash, err := r.Declare(testIntent(t, key, "2026-03-01T12:00:00Z"))
+	if err != nil {
+		t.Fatalf("Failed to declare: %v", err)
+	}
+	if _, err := archive.Get(hash); err != nil {
+		t.Errorf("Declaration was not archived: %v", err)
+	}
+	p.Action["intent_hash"] = hash
+	clock.Advance(12 * time.Hour)
+	if err := r.Check(p); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected a young intent to be refused, got %v", err)
+	}
+	clock.Advance(12 * time.Hour)
+	if err := r.Check(p); err != nil {
+		t.Errorf("Expected an aged intent to pass, got %v", err)
+	}
+
+	// Reversible proposals need no intent
+	q := testProposal()
+	if err := r.Check(q); err != nil {
+		t.Errorf("Expected an uncovered proposal to pass, got %v", err)
+	}
+
+	t.Log("✓ Covered proposals wait out the cooling-off period")
+}
+
+// TestIntentMismatch tests declarations that do not cover the proposal
+func TestIntentMismatch(t *testing.T) {
+	testenv.RequireSigning(t)
+	r, clock, key := intentFixture(t)
+
+	// The declared earliest submission binds even after the minimum age
+	late, _ := r.Declare(testIntent(t, key, "2026-03-05T00:00:00Z"))
+	clock.Advance(48 * time.Hour)
+	p := testProposal()
+	p.ReversibilityClass = ReversibilityIrreversible
+	p.Action["intent_hash"] = late
+	if err := r.Check(p); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected submission before earliest_submission to be refused, got %v", err)
+	}
+
+	// The proposer and action type must match the declaration
+	p.ActionType = "override"
+	clock.Advance(96 * time.Hour)
+	if err := r.Check(p); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected an action type mismatch to be refused, got %v", err)
+	}
+
+	// Forged and unknown declarations are refused
+	forged := testIntent(t, key, "2026-03-01T00:00:00Z")
+	forged.Summary = "changed after signing"
+	if _, err := r.Declare(forged); err == nil {
+		t.Errorf("Expected a forged declaration to be refused")
+	}
+	_, otherKey := testKey("Gemini")
+	other := &IntentDeclaration{Agent: "Gemini", ActionType: "amend", EarliestSubmission: "2026-03-01T00:00:00Z"}
+	other.Sign(otherKey)
+	if _, err := r.Declare(other); err == nil {
+		t.Errorf("Expected an unknown agent to be refused")
+	}
+
+	t.Log("✓ Mismatched, early, and forged intents are refused")
+}
+
+// TestNodeIntents tests that a node enforces intents and consumes them
+func TestNodeIntents(t *testing.T) {
+	testenv.RequireSigning(t)
+	r, clock, key := intentFixture(t)
+	node := NewNode(r.ledger)
+	node.Clock = clock
+	node.Intents = r
+
+	hash, _ := r.Declare(testIntent(t, key, "2026-03-01T00:00:00Z"))
+	p := testProposal()
+	p.ReversibilityClass = ReversibilityIrreversible
+	p.Action["intent_hash"] = hash
+	if _, err := node.Submit(p); !errors.Is(err, ErrIntentRequired) {
+		t.Fatalf("Expected the node to refuse a young intent, got %v", err)
+	}
+	clock.Advance(25 * time.Hour)
+	first, err := node.Submit(p)
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if again, err := node.Submit(p); err != nil || again != first {
+		t.Errorf("Expected resubmission to stay idempotent, got %v, %v", again, err)
+	}
+
+	// A second proposal cannot reuse the declaration
+	q := testProposal()
+	q.ID = "second"
+	q.ReversibilityClass = ReversibilityIrreversible
+	q.Action["intent_hash"] = hash
+	if _, err := node.Submit(q); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected reuse of an intent to be refused, got %v", err)
+	}
+
+	// A restarted node reads declarations and their use from the ledger
+	restarted := NewNode(r.ledger)
+	restarted.Clock = clock
+	restarted.Intents = NewIntentRegistry(r.ledger, r.keys, r.policy)
+	restarted.Intents.Clock = clock
+	if _, err := restarted.Submit(q); !errors.Is(err, ErrIntentRequired) {
+		t.Errorf("Expected a restarted node to refuse reuse of an intent, got %v", err)
+	}
+	fresh, _ := restarted.Intents.Declare(testIntent(t, key, "2026-03-02T00:00:00Z"))
+	q.Action["intent_hash"] = fresh
+	clock.Advance(25 * time.Hour)
+	if _, err := restarted.Submit(q); err != nil {
+		t.Errorf("Expected a restarted node to accept a fresh aged intent, got %v", err)
+	}
+	if err := VerifyIntents(r.ledger.Entries(0)); err != nil {
+		t.Errorf("Expected the recorded intents to verify, got %v", err)
+	}
+
+	t.Log("✓ Node consumes one intent per irreversible proposal")
+}
+
+// TestVerifyIntents tests replay of intents recorded on a ledger
+func TestVerifyIntents(t *testing.T) {
+	testenv.RequireSigning(t)
+	r, clock, key := intentFixture(t)
+	hash, err := r.Declare(testIntent(t, key, "2026-03-01T00:00:00Z"))
+	if err != nil {
+		t.Fatalf("Failed to declare: %v", err)
+	}
+	consume := func(at time.Time, proposal string) []LedgerEntry {
+		l := NewLedger()
+		l.Append(LedgerKindIntent, r.ledger.Entries(0)[0].Payload)
+		for _, p := range []string{"first", proposal} {
+			if p == "" {
+				continue
+			}
+			l.Append(LedgerKindProposal, map[string]interface{}{
+				"proposal_hash":  p,
+				"proposer_agent": "Claude",
+				"accepted_at":    Timestamp(at),
+				"intent_hash":    hash,
+			})
+		}
+		return l.Entries(0)
+	}
+
+	if err := VerifyIntents(consume(clock.Now().Add(24*time.Hour), "")); err != nil {
+		t.Errorf("Expected an aged intent to verify, got %v", err)
+	}
+	if err := VerifyIntents(consume(clock.Now().Add(time.Hour), "")); err == nil {
+		t.Errorf("Expected a young intent to fail verification")
+	}
+	if err := VerifyIntents(consume(clock.Now().Add(24*time.Hour), "second")); err == nil {
+		t.Errorf("Expected a reused intent to fail verification")
+	}
+	t.Logf("✓ Intent %s replayed from the ledger", hash[:12])
+}
@@ -0,0 +1,15 @@
+// Package testenv holds test helpers shared by the module's packages.
+//
+// It does not import package ocp, so the ocp package's own tests can use it.
+package testenv
+
+import "testing"
+
+// RequireSigning skips a test that signs or appends to a ledger in a
+// verification-only build
+func RequireSigning(t testing.TB) {
+	t.Helper()
+	if verifyOnly {
+		t.Skip("verification-only build cannot sign or append (ocp_verifyonly)")
+	}
+}
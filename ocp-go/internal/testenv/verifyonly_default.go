@@ -0,0 +1,6 @@
+//go:build !ocp_verifyonly
+
+package testenv
+
+// verifyOnly mirrors ocp.VerifyOnlyBuild, which this package cannot import
+const verifyOnly = false
@@ -0,0 +1,205 @@
+// irreversible.go - Quorum countersignature before irreversible execution
+//
+// Optimistic acceptance is safe because a bad decision can be challenged and
+// rolled back. An irreversible action cannot, so the constitution requires the
+// quorum to approve it before it runs rather than after. CountersignedExecutor
+// enforces that in code: it wraps an Executor and refuses to execute a
+// proposal whose reversibility_class is "irreversible" unless a quorum
+// Countersignature over that proposal has been registered. Each refusal is
+// written to the archive as a canonical ExecutionRefusal, so attempts to run
+// unapproved irreversible actions leave evidence behind.
+//
+// A countersignature authorizes one execution. It is consumed when the
+// wrapped executor succeeds, so a retried or replayed request cannot apply the
+// same irreversible change twice.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ReversibilityIrreversible is the reversibility_class that requires a countersignature
+const ReversibilityIrreversible = "irreversible"
+
+// ErrNotCountersigned is returned when an irreversible proposal has no
+// countersignature
+var ErrNotCountersigned = &ConstitutionalError{ErrorType: "ExecutionError", Message: "irreversible action lacks quorum countersignature"}
+
+// Countersignature is a quorum's approval to execute an irreversible proposal
+// once, against the state it names
+type Countersignature struct {
+	ProposalHash string      `json:"proposal_hash"`
+	PreStateHash string      `json:"pre_state_hash"`
+	ApprovedAt   string      `json:"approved_at"`
+	Signatures   []Signature `json:"signatures"`
+}
+
+// NewCountersignature creates an unsigned countersignature for p
+func NewCountersignature(p *ContractProposal, approvedAt string) (*Countersignature, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	return &Countersignature{ProposalHash: hash, PreStateHash: p.PreStateHash, ApprovedAt: approvedAt}, nil
+}
+
+// ToMap converts a countersignature to a map for canonicalization, excluding signatures
+func (c *Countersignature) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":  c.ProposalHash,
+		"pre_state_hash": c.PreStateHash,
+		"approved_at":    c.ApprovedAt,
+	}
+}
+
+// Hash returns the semantic hash signed by the quorum
+func (c *Countersignature) Hash() (string, error) {
+	return SemanticHash(c.ToMap())
+}
+
+// Sign adds a signature from signer over the countersignature hash
+func (c *Countersignature) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextCountersign, hash)
+	if err != nil {
+		return err
+	}
+	c.Signatures = append(c.Signatures, sig)
+	return nil
+}
+
+// ExecutionRefusal records an irreversible execution that was refused
+type ExecutionRefusal struct {
+	ProposalHash string `json:"proposal_hash"`
+	Reason       string `json:"reason"`
+	RefusedAt    string `json:"refused_at"`
+}
+
+// ToMap converts an ExecutionRefusal to a map for canonicalization
+func (r ExecutionRefusal) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"kind":          "execution_refusal",
+		"proposal_hash": r.ProposalHash,
+		"reason":        r.Reason,
+		"refused_at":    r.RefusedAt,
+	}
+}
+
+// CountersignedExecutor is an Executor that only runs irreversible proposals
+// with a registered quorum countersignature. Other proposals pass straight
+// through to Next.
+type CountersignedExecutor struct {
+	Next   Executor
+	Quorum *Quorum
+	// Archive, if set, stores every ExecutionRefusal
+	Archive Archive
+	// OnRefusal, if set, is called with every ExecutionRefusal
+	OnRefusal func(ExecutionRefusal)
+	// Clock stamps refusals; defaults to SystemClock
+	Clock Clock
+
+	mu sync.Mutex
+	// approved holds unconsumed countersignatures by proposal hash
+	approved map[string]*Countersignature
+	// running holds proposals being executed, so one approval runs once
+	running map[string]bool
+}
+
+// NewCountersignedExecutor wraps next, accepting countersignatures from quorum
+func NewCountersignedExecutor(next Executor, quorum *Quorum, archive Archive) *CountersignedExecutor {
+	return &CountersignedExecutor{Next: next, Quorum: quorum, Archive: archive}
+}
+
+// Countersign registers a countersignature after checking its quorum signatures
+//
+// Returns:
+//   - VerificationError if the signatures do not reach the quorum threshold
+func (e *CountersignedExecutor) Countersign(c *Countersignature) error {
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	if _, err := e.Quorum.Verify(ContextCountersign, hash, c.Signatures); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.approved == nil {
+		e.approved = make(map[string]*Countersignature)
+	}
+	e.approved[c.ProposalHash] = c
+	return nil
+}
+
+// Execute runs p through Next, first requiring a countersignature if p is
+// irreversible
+//
+// Returns:
+//   - The wrapped executor's receipt
+//   - An error wrapping ErrNotCountersigned if p was refused
+func (e *CountersignedExecutor) Execute(p *ContractProposal) (*ExecutionReceipt, error) {
+	if p.ReversibilityClass != ReversibilityIrreversible {
+		return e.Next.Execute(p)
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	c, ok := e.approved[hash]
+	reason := ""
+	switch {
+	case !ok:
+		reason = "no quorum countersignature"
+	case c.PreStateHash != p.PreStateHash:
+		reason = fmt.Sprintf("countersigned for state %s, proposal names %s", c.PreStateHash, p.PreStateHash)
+	case e.running[hash]:
+		reason = "countersignature already in use by a running execution"
+	}
+	if reason == "" {
+		if e.running == nil {
+			e.running = make(map[string]bool)
+		}
+		e.running[hash] = true
+	}
+	e.mu.Unlock()
+	if reason != "" {
+		return nil, e.refuse(hash, reason)
+	}
+
+	receipt, err := e.Next.Execute(p)
+
+	e.mu.Lock()
+	delete(e.running, hash)
+	if err == nil {
+		delete(e.approved, hash)
+	}
+	e.mu.Unlock()
+	return receipt, err
+}
+
+// refuse records a refusal and returns the error reported for it
+func (e *CountersignedExecutor) refuse(proposalHash, reason string) error {
+	refusal := ExecutionRefusal{
+		ProposalHash: proposalHash,
+		Reason:       reason,
+		RefusedAt:    Timestamp(clockOrSystem(e.Clock).Now()),
+	}
+	if e.OnRefusal != nil {
+		e.OnRefusal(refusal)
+	}
+	if e.Archive != nil {
+		if _, err := ArchiveObject(e.Archive, refusal.ToMap()); err != nil {
+			return errors.Join(fmt.Errorf("refused %s: %s: %w", proposalHash, reason, ErrNotCountersigned), err)
+		}
+	}
+	return fmt.Errorf("refused %s: %s: %w", proposalHash, reason, ErrNotCountersigned)
+}
@@ -0,0 +1,79 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestCountersignedExecutor tests that irreversible actions need a quorum countersignature
+func TestCountersignedExecutor(t *testing.T) {
+	testenv.RequireSigning(t)
+	_, execKey := testKey("Executor-1")
+	applied := 0
+	inner := &ReceiptExecutor{
+		Agent: "Executor-1",
+		Key:   execKey,
+		Applier: ApplierFunc(func(p *ContractProposal) (string, ResourceUsage, error) {
+			applied++
+			return "sha256:after", ResourceUsage{}, nil
+		}),
+	}
+	quorum, privs := testQuorum(t)
+	archive := NewMemoryArchive()
+	var refusals []ExecutionRefusal
+	executor := NewCountersignedExecutor(inner, quorum, archive)
+	executor.OnRefusal = func(r ExecutionRefusal) { refusals = append(refusals, r) }
+	executor.Clock = NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+
+	// Reversible actions are not gated
+	if _, err := executor.Execute(testProposal()); err != nil || applied != 1 {
+		t.Fatalf("Expected a reversible proposal to execute: %v", err)
+	}
+
+	p := testProposal()
+	p.ReversibilityClass = ReversibilityIrreversible
+	p.PreStateHash = "sha256:before"
+	if _, err := executor.Execute(p); !errors.Is(err, ErrNotCountersigned) {
+		t.Fatalf("Expected refusal without countersignature, got %v", err)
+	}
+	if applied != 1 || len(refusals) != 1 || refusals[0].RefusedAt != "2025-11-20T14:30:00Z" {
+		t.Errorf("Expected one logged refusal and no execution: %+v", refusals)
+	}
+	refusalHash, _ := SemanticHash(refusals[0].ToMap())
+	if ok, _ := archive.Has(refusalHash); !ok {
+		t.Errorf("Expected the refusal archived")
+	}
+
+	c, _ := NewCountersignature(p, "2025-11-20T14:00:00Z")
+	c.Sign("Claude", privs["Claude"])
+	if err := executor.Countersign(c); err == nil {
+		t.Errorf("Expected a countersignature below threshold to be rejected")
+	}
+	c.Sign("Gemini", privs["Gemini"])
+	if err := executor.Countersign(c); err != nil {
+		t.Fatalf("Failed to countersign: %v", err)
+	}
+
+	// The approval is bound to the pre-execution state
+	moved := *p
+	moved.PreStateHash = "sha256:other"
+	movedCounter := *c
+	movedCounter.ProposalHash, _ = moved.GetHash()
+	executor.approved[movedCounter.ProposalHash] = &movedCounter
+	if _, err := executor.Execute(&moved); !errors.Is(err, ErrNotCountersigned) {
+		t.Errorf("Expected a state mismatch to be refused, got %v", err)
+	}
+
+	receipt, err := executor.Execute(p)
+	if err != nil || applied != 2 {
+		t.Fatalf("Expected the countersigned proposal to execute: %v", err)
+	}
+	if _, err := executor.Execute(p); !errors.Is(err, ErrNotCountersigned) || applied != 2 {
+		t.Errorf("Expected the countersignature consumed by the first execution")
+	}
+
+	t.Logf("✓ Irreversible %s executed once after countersignature, %d refusals logged", receipt.ProposalHash, len(refusals))
+}
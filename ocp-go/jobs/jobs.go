@@ -0,0 +1,318 @@
+// Package jobs runs expensive verifications asynchronously.
+//
+// Verifying a proposal with large evidence or a deep ledger can take longer
+// than a client should wait on an open request. A Queue accepts such work as
+// jobs, runs them on a fixed pool of workers, and keeps each job's status and
+// result for later queries; completion callbacks let a caller push results
+// (to a webhook, a log) instead of polling. Servers acknowledge a submission
+// with its job ID and answer status queries from Get.
+//
+// Job IDs are chosen by the submitter, normally the semantic hash of what is
+// being verified, so resubmitting the same object returns the existing job
+// instead of verifying it twice.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+// Job statuses
+const (
+	Queued  Status = "queued"
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed"
+)
+
+// Errors returned by Submit
+var (
+	ErrQueueFull = &ocp.ConstitutionalError{ErrorType: "JobError", Message: "job queue is full"}
+	ErrClosed    = &ocp.ConstitutionalError{ErrorType: "JobError", Message: "job queue is closed"}
+)
+
+// Func performs a job and returns its result
+type Func func(ctx context.Context) (map[string]interface{}, error)
+
+// Callback is called with a job once it is done or failed
+type Callback func(Job)
+
+// Job is a snapshot of a submitted job
+type Job struct {
+	ID     string
+	Status Status
+	// Result is set when Status is Done
+	Result map[string]interface{}
+	// Error is set when Status is Failed
+	Error       string
+	SubmittedAt string
+	StartedAt   string
+	FinishedAt  string
+}
+
+// Finished reports whether the job is done or failed
+func (j Job) Finished() bool {
+	return j.Status == Done || j.Status == Failed
+}
+
+// ToMap converts a Job to a map for canonicalization
+func (j Job) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"id":           j.ID,
+		"status":       string(j.Status),
+		"submitted_at": j.SubmittedAt,
+	}
+	if j.StartedAt != "" {
+		m["started_at"] = j.StartedAt
+	}
+	if j.FinishedAt != "" {
+		m["finished_at"] = j.FinishedAt
+	}
+	if j.Result != nil {
+		m["result"] = j.Result
+	}
+	if j.Error != "" {
+		m["error"] = j.Error
+	}
+	return m
+}
+
+// Config configures a Queue
+type Config struct {
+	// Workers is the number of jobs run concurrently; defaults to 1
+	Workers int
+	// Capacity bounds the number of queued jobs not yet running; Submit
+	// returns ErrQueueFull beyond it. Defaults to 64.
+	Capacity int
+	// Retain bounds the number of finished jobs kept for Get, oldest first
+	// evicted. Defaults to 1024.
+	Retain int
+	// Clock timestamps jobs; defaults to ocp.SystemClock
+	Clock ocp.Clock
+}
+
+// Queue runs submitted jobs on a pool of workers
+type Queue struct {
+	cfg    Config
+	ctx    context.Context
+	cancel context.CancelFunc
+	work   chan *entry
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	jobs     map[string]*entry
+	finished []string
+}
+
+// entry is a job and the state only the queue sees
+type entry struct {
+	job       Job
+	fn        Func
+	callbacks []Callback
+	done      chan struct{}
+}
+
+// NewQueue starts a queue's workers
+func NewQueue(cfg Config) *Queue {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.Capacity < 1 {
+		cfg.Capacity = 64
+	}
+	if cfg.Retain < 1 {
+		cfg.Retain = 1024
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = ocp.SystemClock
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		work:   make(chan *entry, cfg.Capacity),
+		jobs:   make(map[string]*entry),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// Submit enqueues fn as job id. If a job with that ID is already queued,
+// running, or done, it is returned instead and fn is not run again; onDone,
+// if not nil, is still called when that job finishes. A failed job is
+// replaced, so a failed verification can be retried.
+//
+// Returns:
+//   - Snapshot of the job
+//   - ErrQueueFull or ErrClosed if the job could not be queued
+func (q *Queue) Submit(id string, fn Func, onDone Callback) (Job, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return Job{}, ErrClosed
+	}
+	if e, ok := q.jobs[id]; ok && e.job.Status != Failed {
+		job := e.job
+		if onDone != nil && !job.Finished() {
+			e.callbacks = append(e.callbacks, onDone)
+			onDone = nil
+		}
+		q.mu.Unlock()
+		if onDone != nil {
+			onDone(job)
+		}
+		return job, nil
+	}
+
+	e := &entry{
+		job:  Job{ID: id, Status: Queued, SubmittedAt: ocp.Timestamp(q.cfg.Clock.Now())},
+		fn:   fn,
+		done: make(chan struct{}),
+	}
+	if onDone != nil {
+		e.callbacks = append(e.callbacks, onDone)
+	}
+	select {
+	case q.work <- e:
+	default:
+		q.mu.Unlock()
+		return Job{}, ErrQueueFull
+	}
+	q.dropFinishedLocked(id)
+	q.jobs[id] = e
+	job := e.job
+	q.mu.Unlock()
+	return job, nil
+}
+
+// Get returns a snapshot of job id
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return e.job, true
+}
+
+// Wait blocks until job id finishes or ctx is done
+func (q *Queue) Wait(ctx context.Context, id string) (Job, error) {
+	q.mu.Lock()
+	e, ok := q.jobs[id]
+	q.mu.Unlock()
+	if !ok {
+		return Job{}, ocp.NewConstitutionalError(fmt.Sprintf("unknown job %s", id))
+	}
+	select {
+	case <-e.done:
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return e.job, nil
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+// Close stops accepting jobs, cancels the context of running jobs, fails the
+// jobs still queued, and waits for the workers to exit
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.work)
+	q.mu.Unlock()
+	q.cancel()
+	q.wg.Wait()
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for e := range q.work {
+		if q.ctx.Err() != nil {
+			q.finish(e, nil, ErrClosed)
+			continue
+		}
+		q.mu.Lock()
+		e.job.Status = Running
+		e.job.StartedAt = ocp.Timestamp(q.cfg.Clock.Now())
+		q.mu.Unlock()
+
+		result, err := q.run(e.fn)
+		q.finish(e, result, err)
+	}
+}
+
+// run calls fn, converting a panic into an error so one bad job cannot stop
+// a worker
+func (q *Queue) run(fn Func) (result map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(q.ctx)
+}
+
+func (q *Queue) finish(e *entry, result map[string]interface{}, err error) {
+	q.mu.Lock()
+	e.job.FinishedAt = ocp.Timestamp(q.cfg.Clock.Now())
+	if err != nil {
+		e.job.Status, e.job.Error = Failed, err.Error()
+	} else {
+		e.job.Status, e.job.Result = Done, result
+	}
+	job, callbacks := e.job, e.callbacks
+	e.callbacks = nil
+	q.finished = append(q.finished, e.job.ID)
+	for len(q.finished) > q.cfg.Retain {
+		evict := q.finished[0]
+		q.finished = q.finished[1:]
+		if old, ok := q.jobs[evict]; ok && old.job.Finished() {
+			delete(q.jobs, evict)
+		}
+	}
+	close(e.done)
+	q.mu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(job)
+	}
+}
+
+// dropFinishedLocked forgets the retention slot of a job being replaced
+func (q *Queue) dropFinishedLocked(id string) {
+	for i, f := range q.finished {
+		if f == id {
+			q.finished = append(q.finished[:i], q.finished[i+1:]...)
+			return
+		}
+	}
+}
+
+// VerifyProposal returns a job that runs every verification check on p
+func VerifyProposal(v *ocp.Verifier, p *ocp.ContractProposal) Func {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report := v.VerifyProposal(p)
+		result := report.ToMap()
+		result["ok"] = report.OK()
+		return result, nil
+	}
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// TestQueue tests running jobs, status queries, callbacks, and deduplication
+func TestQueue(t *testing.T) {
+	q := NewQueue(Config{Workers: 2, Clock: ocp.NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))})
+	defer q.Close()
+
+	release := make(chan struct{})
+	calls := 0
+	slow := func(ctx context.Context) (map[string]interface{}, error) {
+		calls++
+		<-release
+		return map[string]interface{}{"ok": true}, nil
+	}
+	done := make(chan Job, 2)
+	job, err := q.Submit("sha256:a", slow, func(j Job) { done <- j })
+	if err != nil || job.Status != Queued {
+		t.Fatalf("Expected a queued job, got %+v %v", job, err)
+	}
+	// Resubmitting the same ID attaches to the existing job
+	if again, _ := q.Submit("sha256:a", slow, func(j Job) { done <- j }); again.ID != job.ID {
+		t.Errorf("Duplicate submission should return the existing job")
+	}
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if j := <-done; j.Status != Done || j.Result["ok"] != true {
+			t.Errorf("Expected a done job, got %+v", j)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Duplicate submission should not run the job twice, ran %d times", calls)
+	}
+	if j, ok := q.Get("sha256:a"); !ok || j.FinishedAt != "2025-11-20T14:30:00Z" {
+		t.Errorf("Unexpected status: %+v", j)
+	}
+
+	// Failures and panics are recorded, and a failed job can be retried
+	q.Submit("sha256:b", func(ctx context.Context) (map[string]interface{}, error) { panic("bad evidence") }, nil)
+	failed, err := q.Wait(context.Background(), "sha256:b")
+	if err != nil || failed.Status != Failed || failed.Error != "job panicked: bad evidence" {
+		t.Errorf("Expected a failed job, got %+v %v", failed, err)
+	}
+	q.Submit("sha256:b", func(ctx context.Context) (map[string]interface{}, error) { return map[string]interface{}{}, nil }, nil)
+	if retried, _ := q.Wait(context.Background(), "sha256:b"); retried.Status != Done {
+		t.Errorf("Retried job should succeed, got %+v", retried)
+	}
+	t.Logf("✓ Jobs run, deduplicated, and retried")
+}
+
+// TestQueueLimits tests capacity, retention, and closing
+func TestQueueLimits(t *testing.T) {
+	q := NewQueue(Config{Workers: 1, Capacity: 1, Retain: 1})
+	block := make(chan struct{})
+	started := make(chan struct{})
+	q.Submit("running", func(ctx context.Context) (map[string]interface{}, error) {
+		close(started)
+		<-block
+		return nil, nil
+	}, nil)
+	<-started
+	q.Submit("queued", func(ctx context.Context) (map[string]interface{}, error) { return nil, nil }, nil)
+	if _, err := q.Submit("overflow", nil, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	close(block)
+	q.Wait(context.Background(), "queued")
+	if _, ok := q.Get("running"); ok {
+		t.Errorf("Only the most recent finished job should be retained")
+	}
+
+	q.Close()
+	if _, err := q.Submit("late", nil, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	t.Logf("✓ Queue limits enforced")
+}
@@ -0,0 +1,285 @@
+// kms.go - Signers over PKCS#11 tokens, AWS KMS, and GCP Cloud KMS
+//
+// As with ObjectStore, the module imports no vendor SDK or PKCS#11 binding:
+// each provider is reached through a small interface that operators
+// implement over the client they already use, and the constructors here
+// supply the protocol rules. Each constructor reads the public key and key
+// metadata once, refuses keys that are not Ed25519 signing keys, and records
+// the provider's attestation in the KeyRef:
+//
+//   - PKCS#11: the key must be CKK_EC_EDWARDS, sensitive, and never
+//     extractable; the token's manufacturer, model, and serial are recorded.
+//     Signing uses CKM_EDDSA.
+//   - AWS KMS: the key spec must be ECC_NIST_EDWARDS25519 with usage
+//     SIGN_VERIFY; its origin (AWS_KMS, AWS_CLOUDHSM, EXTERNAL) and custom key
+//     store are recorded. Signing uses ED25519_SHA_512 over a RAW message.
+//   - GCP Cloud KMS: the algorithm must be EC_SIGN_ED25519; the protection
+//     level is recorded, and for HSM keys the attestation format and the
+//     SHA-256 of the attestation bundle, which can be checked against the
+//     manufacturer's certificate chain offline. Signing passes the message
+//     as data, not as a digest.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+)
+
+// PKCS#11 constants used by NewPKCS11Signer, from the PKCS#11 3.0 specification
+const (
+	CKMEdDSA            uint = 0x1057
+	CKKECEdwards        uint = 0x40
+	CKAKeyType          uint = 0x100
+	CKASensitive        uint = 0x103
+	CKAExtractable      uint = 0x162
+	CKANeverExtractable uint = 0x164
+	CKAECPoint          uint = 0x181
+	pkcs11AttributeTrue      = 1
+)
+
+// PKCS11TokenInfo is the part of CK_TOKEN_INFO recorded in the attestation
+type PKCS11TokenInfo struct {
+	Label        string
+	Manufacturer string
+	Model        string
+	SerialNumber string
+}
+
+// PKCS11Session is the subset of a logged-in PKCS#11 session NewPKCS11Signer
+// needs, implemented over a binding such as github.com/miekg/pkcs11 and the
+// token vendor's module
+type PKCS11Session interface {
+	// FindKeyPair returns the handles of the private and public key objects
+	// with label
+	FindKeyPair(label string) (private, public uint, err error)
+	// GetAttribute returns the value of attribute attr of object
+	GetAttribute(object, attr uint) ([]byte, error)
+	// Sign signs message with object using mechanism
+	Sign(object, mechanism uint, message []byte) ([]byte, error)
+	// TokenInfo describes the token the session is open on
+	TokenInfo() (PKCS11TokenInfo, error)
+}
+
+// NewPKCS11Signer creates a Signer over the key pair labelled label on the
+// session's token
+func NewPKCS11Signer(session PKCS11Session, label string) (*RemoteSigner, error) {
+	token, err := session.TokenInfo()
+	if err != nil {
+		return nil, err
+	}
+	private, public, err := session.FindKeyPair(label)
+	if err != nil {
+		return nil, err
+	}
+	uri := fmt.Sprintf("pkcs11:token=%s;object=%s;type=private", url.PathEscape(token.Label), url.PathEscape(label))
+
+	keyType, err := session.GetAttribute(private, CKAKeyType)
+	if err != nil {
+		return nil, err
+	}
+	if pkcs11Uint(keyType) != CKKECEdwards {
+		return nil, NewVerificationError(fmt.Sprintf("%s is not an Edwards-curve key", uri))
+	}
+	for _, attr := range []struct {
+		id   uint
+		want bool
+		name string
+	}{
+		{CKASensitive, true, "sensitive"},
+		{CKAExtractable, false, "extractable"},
+		{CKANeverExtractable, true, "never extractable"},
+	} {
+		value, err := session.GetAttribute(private, attr.id)
+		if err != nil {
+			return nil, err
+		}
+		if pkcs11Bool(value) != attr.want {
+			return nil, NewVerificationError(fmt.Sprintf("%s must be %s %v", uri, attr.name, attr.want))
+		}
+	}
+
+	point, err := session.GetAttribute(public, CKAECPoint)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := pkcs11EdwardsPoint(point)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uri, err)
+	}
+	return &RemoteSigner{
+		public: pub,
+		ref: KeyRef{
+			Provider: KeyProviderPKCS11,
+			URI:      uri,
+			Attestation: map[string]string{
+				"manufacturer":      token.Manufacturer,
+				"model":             token.Model,
+				"serial_number":     token.SerialNumber,
+				"sensitive":         "true",
+				"never_extractable": "true",
+			},
+		},
+		sign: func(message []byte) ([]byte, error) {
+			return session.Sign(private, CKMEdDSA, message)
+		},
+	}, nil
+}
+
+// pkcs11EdwardsPoint decodes CKA_EC_POINT, which tokens return either as a
+// DER OCTET STRING or as the raw 32 bytes
+func pkcs11EdwardsPoint(point []byte) (ed25519.PublicKey, error) {
+	if len(point) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(point), nil
+	}
+	var raw []byte
+	if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) > 0 || len(raw) != ed25519.PublicKeySize {
+		return nil, NewVerificationError("CKA_EC_POINT is not an Ed25519 public key")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// pkcs11Uint decodes a CK_ULONG attribute in host (little-endian) order
+func pkcs11Uint(value []byte) uint {
+	var v uint
+	for i := len(value) - 1; i >= 0; i-- {
+		v = v<<8 | uint(value[i])
+	}
+	return v
+}
+
+func pkcs11Bool(value []byte) bool {
+	return len(value) == 1 && value[0] == pkcs11AttributeTrue
+}
+
+// AWS KMS key and signing parameters for Ed25519
+const (
+	AWSKMSKeySpecEd25519   = "ECC_NIST_EDWARDS25519"
+	AWSKMSSigningAlgorithm = "ED25519_SHA_512"
+)
+
+// AWSKMSKey is the key metadata NewAWSKMSSigner reads, from kms:GetPublicKey and
+// kms:DescribeKey
+type AWSKMSKey struct {
+	KeyARN   string
+	KeySpec  string
+	KeyUsage string
+	// Origin is AWS_KMS, AWS_CLOUDHSM, EXTERNAL, or EXTERNAL_KEY_STORE
+	Origin           string
+	CustomKeyStoreID string
+	// PublicKey is the DER-encoded SubjectPublicKeyInfo
+	PublicKey []byte
+}
+
+// AWSKMSClient is the subset of the AWS KMS API NewAWSKMSSigner needs
+type AWSKMSClient interface {
+	// DescribePublicKey returns the key's metadata and public key
+	DescribePublicKey(keyID string) (AWSKMSKey, error)
+	// Sign calls kms:Sign with MessageType RAW and SigningAlgorithm
+	// AWSKMSSigningAlgorithm
+	Sign(keyID string, message []byte) ([]byte, error)
+}
+
+// NewAWSKMSSigner creates a Signer over the AWS KMS key keyID (an ID, alias,
+// or ARN; the resolved ARN is recorded)
+func NewAWSKMSSigner(client AWSKMSClient, keyID string) (*RemoteSigner, error) {
+	key, err := client.DescribePublicKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.KeySpec != AWSKMSKeySpecEd25519 || key.KeyUsage != "SIGN_VERIFY" {
+		return nil, NewVerificationError(fmt.Sprintf("AWS KMS key %s is %s for %s, not an Ed25519 signing key", key.KeyARN, key.KeySpec, key.KeyUsage))
+	}
+	pub, err := pkixEd25519(key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS key %s: %w", key.KeyARN, err)
+	}
+	attestation := map[string]string{"key_spec": key.KeySpec, "origin": key.Origin}
+	if key.CustomKeyStoreID != "" {
+		attestation["custom_key_store"] = key.CustomKeyStoreID
+	}
+	return &RemoteSigner{
+		public: pub,
+		ref:    KeyRef{Provider: KeyProviderAWSKMS, URI: key.KeyARN, Attestation: attestation},
+		sign: func(message []byte) ([]byte, error) {
+			return client.Sign(key.KeyARN, message)
+		},
+	}, nil
+}
+
+// GCPKMSAlgorithmEd25519 is the Cloud KMS algorithm of Ed25519 signing keys
+const GCPKMSAlgorithmEd25519 = "EC_SIGN_ED25519"
+
+// GCPKMSKey is the key version metadata NewGCPKMSSigner reads, from
+// GetPublicKey and GetCryptoKeyVersion
+type GCPKMSKey struct {
+	Name      string
+	Algorithm string
+	// ProtectionLevel is SOFTWARE, HSM, EXTERNAL, or EXTERNAL_VPC
+	ProtectionLevel string
+	// PEM is the PEM-encoded public key
+	PEM string
+	// AttestationFormat and Attestation are the HSM attestation of HSM keys
+	AttestationFormat string
+	Attestation       []byte
+}
+
+// GCPKMSClient is the subset of the Cloud KMS API NewGCPKMSSigner needs
+type GCPKMSClient interface {
+	// DescribeKeyVersion returns the key version's metadata and public key
+	DescribeKeyVersion(name string) (GCPKMSKey, error)
+	// AsymmetricSign calls AsymmetricSign with data set to message
+	AsymmetricSign(name string, message []byte) ([]byte, error)
+}
+
+// NewGCPKMSSigner creates a Signer over the Cloud KMS key version name
+// (projects/.../cryptoKeys/.../cryptoKeyVersions/N)
+func NewGCPKMSSigner(client GCPKMSClient, name string) (*RemoteSigner, error) {
+	key, err := client.DescribeKeyVersion(name)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != GCPKMSAlgorithmEd25519 {
+		return nil, NewVerificationError(fmt.Sprintf("Cloud KMS key %s uses %s, not %s", name, key.Algorithm, GCPKMSAlgorithmEd25519))
+	}
+	block, _ := pem.Decode([]byte(key.PEM))
+	if block == nil {
+		return nil, NewVerificationError(fmt.Sprintf("Cloud KMS key %s has no PEM public key", name))
+	}
+	pub, err := pkixEd25519(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Cloud KMS key %s: %w", name, err)
+	}
+	attestation := map[string]string{"protection_level": key.ProtectionLevel}
+	if len(key.Attestation) > 0 {
+		sum := sha256.Sum256(key.Attestation)
+		attestation["attestation_format"] = key.AttestationFormat
+		attestation["attestation_sha256"] = hex.EncodeToString(sum[:])
+	}
+	return &RemoteSigner{
+		public: pub,
+		ref:    KeyRef{Provider: KeyProviderGCPKMS, URI: name, Attestation: attestation},
+		sign: func(message []byte) ([]byte, error) {
+			return client.AsymmetricSign(name, message)
+		},
+	}, nil
+}
+
+// pkixEd25519 parses a DER SubjectPublicKeyInfo holding an Ed25519 key
+func pkixEd25519(der []byte) (ed25519.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, NewVerificationError(fmt.Sprintf("public key does not parse: %v", err))
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, NewVerificationError(fmt.Sprintf("public key is %T, not ed25519", key))
+	}
+	return pub, nil
+}
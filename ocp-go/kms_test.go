@@ -0,0 +1,137 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// fakeToken is a PKCS11Session over an in-memory key with settable attributes
+type fakeToken struct {
+	key   ed25519.PrivateKey
+	attrs map[uint][]byte
+}
+
+func newFakeToken(key ed25519.PrivateKey) *fakeToken {
+	point, _ := asn1.Marshal([]byte(key.Public().(ed25519.PublicKey)))
+	return &fakeToken{key: key, attrs: map[uint][]byte{
+		CKAKeyType:          {byte(CKKECEdwards), 0, 0, 0, 0, 0, 0, 0},
+		CKASensitive:        {1},
+		CKAExtractable:      {0},
+		CKANeverExtractable: {1},
+		CKAECPoint:          point,
+	}}
+}
+
+func (f *fakeToken) FindKeyPair(label string) (uint, uint, error) { return 1, 2, nil }
+func (f *fakeToken) GetAttribute(object, attr uint) ([]byte, error) {
+	return f.attrs[attr], nil
+}
+func (f *fakeToken) Sign(object, mechanism uint, message []byte) ([]byte, error) {
+	if object != 1 || mechanism != CKMEdDSA {
+		return nil, NewVerificationError("wrong object or mechanism")
+	}
+	return ed25519.Sign(f.key, message), nil
+}
+func (f *fakeToken) TokenInfo() (PKCS11TokenInfo, error) {
+	return PKCS11TokenInfo{Label: "ocp quorum", Manufacturer: "Example HSM", Model: "X1", SerialNumber: "0042"}, nil
+}
+
+// fakeKMS implements AWSKMSClient and GCPKMSClient over an in-memory key
+type fakeKMS struct {
+	key     ed25519.PrivateKey
+	aws     AWSKMSKey
+	gcp     GCPKMSKey
+	signers []string
+}
+
+func newFakeKMS(key ed25519.PrivateKey) *fakeKMS {
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	return &fakeKMS{
+		key: key,
+		aws: AWSKMSKey{KeyARN: "arn:aws:kms:eu-west-1:111122223333:key/quorum", KeySpec: AWSKMSKeySpecEd25519, KeyUsage: "SIGN_VERIFY", Origin: "AWS_CLOUDHSM", CustomKeyStoreID: "cks-1", PublicKey: der},
+		gcp: GCPKMSKey{Algorithm: GCPKMSAlgorithmEd25519, ProtectionLevel: "HSM", PEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), AttestationFormat: "CAVIUM_V2_COMPRESSED", Attestation: []byte("bundle")},
+	}
+}
+
+func (f *fakeKMS) DescribePublicKey(keyID string) (AWSKMSKey, error) { return f.aws, nil }
+func (f *fakeKMS) DescribeKeyVersion(name string) (GCPKMSKey, error) {
+	k := f.gcp
+	k.Name = name
+	return k, nil
+}
+func (f *fakeKMS) Sign(keyID string, message []byte) ([]byte, error) {
+	f.signers = append(f.signers, keyID)
+	return ed25519.Sign(f.key, message), nil
+}
+func (f *fakeKMS) AsymmetricSign(name string, message []byte) ([]byte, error) {
+	return f.Sign(name, message)
+}
+
+// TestProviderSigners tests each provider's signer end to end and its attestation
+func TestProviderSigners(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, key := testKey("Claude")
+	hash, _ := SemanticHash(map[string]interface{}{"state_root": "abc"})
+	kms := newFakeKMS(key)
+
+	pkcs11, err := NewPKCS11Signer(newFakeToken(key), "quorum-claude")
+	if err != nil {
+		t.Fatalf("Failed to open PKCS#11 key: %v", err)
+	}
+	aws, err := NewAWSKMSSigner(kms, "alias/quorum")
+	if err != nil {
+		t.Fatalf("Failed to open AWS KMS key: %v", err)
+	}
+	gcp, err := NewGCPKMSSigner(kms, "projects/p/locations/global/keyRings/ocp/cryptoKeys/quorum/cryptoKeyVersions/1")
+	if err != nil {
+		t.Fatalf("Failed to open Cloud KMS key: %v", err)
+	}
+
+	for _, s := range []*RemoteSigner{pkcs11, aws, gcp} {
+		sig, err := SignHashWith("Claude", s, ContextStateRoot, hash)
+		if err != nil {
+			t.Fatalf("%s: failed to sign: %v", s.KeyRef().Provider, err)
+		}
+		if err := VerifyHashSignature(pub, ContextStateRoot, hash, sig); err != nil {
+			t.Errorf("%s: signature should verify: %v", sig.Key.Provider, err)
+		}
+		t.Logf("✓ %s", sig.Key)
+	}
+	if pkcs11.KeyRef().URI != "pkcs11:token=ocp%20quorum;object=quorum-claude;type=private" {
+		t.Errorf("Unexpected PKCS#11 URI %s", pkcs11.KeyRef().URI)
+	}
+	if aws.KeyRef().Attestation["origin"] != "AWS_CLOUDHSM" || kms.signers[0] != kms.aws.KeyARN {
+		t.Errorf("AWS signer should record the origin and sign with the resolved ARN: %v", aws.KeyRef())
+	}
+	if gcp.KeyRef().Attestation["attestation_sha256"] == "" {
+		t.Error("Cloud KMS HSM attestation should be recorded")
+	}
+}
+
+// TestProviderSignersRefuseUnsafeKeys tests that keys which are extractable or
+// not Ed25519 are refused
+func TestProviderSignersRefuseUnsafeKeys(t *testing.T) {
+	_, key := testKey("Claude")
+
+	token := newFakeToken(key)
+	token.attrs[CKAExtractable] = []byte{1}
+	if _, err := NewPKCS11Signer(token, "quorum-claude"); err == nil {
+		t.Error("Expected an extractable PKCS#11 key to be refused")
+	}
+
+	kms := newFakeKMS(key)
+	kms.aws.KeySpec = "ECC_NIST_P256"
+	if _, err := NewAWSKMSSigner(kms, "alias/quorum"); err == nil {
+		t.Error("Expected a P-256 AWS KMS key to be refused")
+	}
+	kms.gcp.Algorithm = "EC_SIGN_P256_SHA256"
+	if _, err := NewGCPKMSSigner(kms, "projects/p/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"); err == nil {
+		t.Error("Expected a P-256 Cloud KMS key to be refused")
+	}
+	t.Log("✓ Unsafe and non-Ed25519 keys refused")
+}
@@ -0,0 +1,208 @@
+// ledger.go - Append-only, hash-chained record of constitutional history
+//
+// Each entry commits to the hash of its predecessor, so the head hash of a ledger
+// commits to its entire history. Heights start at 1; height 0 is the empty ledger.
+
+package ocp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// LedgerEntry is a single hash-chained record in the ledger
+type LedgerEntry struct {
+	Height   uint64                 `json:"height"`
+	PrevHash string                 `json:"prev_hash"`
+	Kind     string                 `json:"kind"`
+	Payload  map[string]interface{} `json:"payload"`
+	Hash     string                 `json:"hash"`
+	// Hashes holds digests under a DualHasher's algorithms, if the ledger
+	// emits them
+	Hashes map[string]string `json:"hashes,omitempty"`
+}
+
+// ToMap converts a LedgerEntry to a map for canonicalization.
+// The entry's own Hash and Hashes are excluded since they are derived from this map.
+func (e *LedgerEntry) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"height":    e.Height,
+		"prev_hash": e.PrevHash,
+		"kind":      e.Kind,
+		"payload":   e.Payload,
+	}
+}
+
+// ComputeHash returns the semantic hash of this entry
+func (e *LedgerEntry) ComputeHash() (string, error) {
+	return SemanticHash(e.ToMap())
+}
+
+// VerifyChain verifies that entries form an unbroken hash chain starting
+// immediately after a known entry at (prevHeight, prevHash).
+//
+// Returns:
+//   - Hash of the last entry (prevHash if entries is empty)
+//   - VerificationError describing the first broken link
+func VerifyChain(prevHeight uint64, prevHash string, entries []LedgerEntry) (string, error) {
+	for i := range entries {
+		e := &entries[i]
+		if e.Height != prevHeight+1 {
+			return "", NewVerificationError(fmt.Sprintf("entry height %d does not follow %d", e.Height, prevHeight))
+		}
+		if e.PrevHash != prevHash {
+			return "", NewVerificationError(fmt.Sprintf("entry %d does not link to previous hash", e.Height))
+		}
+		hash, err := e.ComputeHash()
+		if err != nil {
+			return "", err
+		}
+		if hash != e.Hash {
+			return "", NewVerificationError(fmt.Sprintf("entry %d hash mismatch", e.Height))
+		}
+		prevHeight, prevHash = e.Height, e.Hash
+	}
+	return prevHash, nil
+}
+
+// Ledger is an in-memory append-only ledger. A ledger bootstrapped from a
+// snapshot starts at the snapshot's height and holds only later entries.
+type Ledger struct {
+	mu         sync.RWMutex
+	baseHeight uint64
+	baseHash   string
+	entries    []LedgerEntry
+	snapshots  map[string]uint64
+	dualHasher *DualHasher
+	// grown is closed when entries are added, waking Wait callers
+	grown chan struct{}
+}
+
+// NewLedger creates an empty ledger starting from genesis
+func NewLedger() *Ledger {
+	return &Ledger{snapshots: make(map[string]uint64)}
+}
+
+// Append adds a new entry chained to the current head
+func (l *Ledger) Append(kind string, payload map[string]interface{}) (LedgerEntry, error) {
+	if VerifyOnlyBuild {
+		return LedgerEntry{}, ErrVerifyOnly
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	height, head := l.headLocked()
+	entry := LedgerEntry{
+		Height:   height + 1,
+		PrevHash: head,
+		Kind:     kind,
+		Payload:  payload,
+	}
+	hash, err := entry.ComputeHash()
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	entry.Hash = hash
+	if l.dualHasher != nil {
+		if entry.Hashes, err = l.dualHasher.Hashes(entry.ToMap()); err != nil {
+			return LedgerEntry{}, err
+		}
+	}
+	l.entries = append(l.entries, entry)
+	l.signalLocked()
+	return entry, nil
+}
+
+// SetDualHasher makes Append record h's digests of each new entry in its
+// Hashes, for example during a hash algorithm migration window. A nil h
+// stops recording them.
+func (l *Ledger) SetDualHasher(h *DualHasher) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dualHasher = h
+}
+
+// Height returns the height of the latest entry
+func (l *Ledger) Height() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	height, _ := l.headLocked()
+	return height
+}
+
+// Head returns the hash of the latest entry, or "" for an empty genesis ledger
+func (l *Ledger) Head() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, head := l.headLocked()
+	return head
+}
+
+// Entries returns a copy of all held entries with height greater than after
+func (l *Ledger) Entries(after uint64) []LedgerEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.entriesAfterLocked(after)
+}
+
+// Verify re-verifies the hash chain of every entry held by the ledger
+func (l *Ledger) Verify() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, err := VerifyChain(l.baseHeight, l.baseHash, l.entries)
+	return err
+}
+
+// Wait blocks until the ledger holds an entry above height
+//
+// Returns:
+//   - ctx.Err() if ctx is done first
+func (l *Ledger) Wait(ctx context.Context, height uint64) error {
+	for {
+		l.mu.Lock()
+		if current, _ := l.headLocked(); current > height {
+			l.mu.Unlock()
+			return nil
+		}
+		if l.grown == nil {
+			l.grown = make(chan struct{})
+		}
+		grown := l.grown
+		l.mu.Unlock()
+
+		select {
+		case <-grown:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// signalLocked wakes Wait callers after entries are added
+func (l *Ledger) signalLocked() {
+	if l.grown != nil {
+		close(l.grown)
+		l.grown = nil
+	}
+}
+
+func (l *Ledger) headLocked() (uint64, string) {
+	if n := len(l.entries); n > 0 {
+		return l.entries[n-1].Height, l.entries[n-1].Hash
+	}
+	return l.baseHeight, l.baseHash
+}
+
+func (l *Ledger) entriesAfterLocked(after uint64) []LedgerEntry {
+	if after < l.baseHeight {
+		after = l.baseHeight
+	}
+	start := int(after - l.baseHeight)
+	if start >= len(l.entries) {
+		return nil
+	}
+	out := make([]LedgerEntry, len(l.entries)-start)
+	copy(out, l.entries[start:])
+	return out
+}
@@ -0,0 +1,100 @@
+package ocp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestLedgerAppendChain tests that appended entries form a verifiable hash chain
+func TestLedgerAppendChain(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+
+	if ledger.Height() != 0 || ledger.Head() != "" {
+		t.Errorf("Empty ledger should be at genesis")
+	}
+
+	first, err := ledger.Append("proposal", map[string]interface{}{"id": "p-1"})
+	if err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	second, err := ledger.Append("ratification", map[string]interface{}{"id": "p-1"})
+	if err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+
+	if first.Height != 1 || second.Height != 2 {
+		t.Errorf("Heights should start at 1, got %d and %d", first.Height, second.Height)
+	}
+	if second.PrevHash != first.Hash {
+		t.Errorf("Second entry should link to first")
+	}
+	if ledger.Head() != second.Hash {
+		t.Errorf("Head should be the latest entry hash")
+	}
+
+	if err := ledger.Verify(); err != nil {
+		t.Errorf("Ledger should verify: %v", err)
+	}
+
+	t.Logf("✓ Ledger head at height %d: %s", ledger.Height(), ledger.Head())
+}
+
+// TestVerifyChainDetectsTampering tests that modified payloads break the chain
+func TestVerifyChainDetectsTampering(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	for _, id := range []string{"p-1", "p-2", "p-3"} {
+		if _, err := ledger.Append("proposal", map[string]interface{}{"id": id}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	entries := ledger.Entries(0)
+	entries[1].Payload = map[string]interface{}{"id": "p-forged"}
+
+	if _, err := VerifyChain(0, "", entries); err == nil {
+		t.Errorf("Tampered payload should fail chain verification")
+	}
+
+	entries = ledger.Entries(0)
+	if _, err := VerifyChain(0, "", entries[1:]); err == nil {
+		t.Errorf("Chain with a missing entry should fail verification")
+	}
+
+	t.Logf("✓ Tampering detected")
+}
+
+// TestLedgerWait tests waking waiters when entries are appended
+func TestLedgerWait(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	ledger.Append("note", map[string]interface{}{"n": 1})
+	if err := ledger.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Expected an entry above height 0 to return at once: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- ledger.Wait(context.Background(), 2) }()
+	ledger.Append("note", map[string]interface{}{"n": 2})
+	select {
+	case <-done:
+		t.Fatal("Expected Wait to block until an entry above height 2")
+	case <-time.After(10 * time.Millisecond):
+	}
+	ledger.Append("note", map[string]interface{}{"n": 3})
+	if err := <-done; err != nil {
+		t.Fatalf("Expected Wait to return after height 3: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ledger.Wait(ctx, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled wait to fail, got %v", err)
+	}
+	t.Log("✓ Waiters woken by appends")
+}
@@ -0,0 +1,254 @@
+// lifecycle.go - Proposal lifecycle states and events
+//
+// A proposal is pending after submission, may be challenged during its window,
+// and ends ratified or reverted. A ratified proposal can still be reverted by a
+// successful fraud proof. Every transition is published as a LifecycleEvent.
+//
+// The transitions the ledger records are replayed when a node restarts: a
+// challenge bond challenges a proposal, an upheld resolution reverts it until
+// an appeal voids that resolution, and a halt, a canonical change or an
+// activation shows it ratified.
+
+package ocp
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProposalState is the lifecycle state of a submitted proposal
+type ProposalState string
+
+const (
+	StatePending    ProposalState = "pending"
+	StateChallenged ProposalState = "challenged"
+	StateRatified   ProposalState = "ratified"
+	StateReverted   ProposalState = "reverted"
+)
+
+// Lifecycle event kinds
+const (
+	EventSubmitted  = "submitted"
+	EventChallenged = "challenged"
+	EventRatified   = "ratified"
+	EventReverted   = "reverted"
+)
+
+// LifecycleEvent records a single state transition
+type LifecycleEvent struct {
+	Kind         string                 `json:"kind"`
+	ProposalHash string                 `json:"proposal_hash"`
+	Timestamp    string                 `json:"timestamp"`
+	Details      map[string]interface{} `json:"details"`
+}
+
+// ToMap converts a LifecycleEvent to a map for canonicalization
+func (e LifecycleEvent) ToMap() map[string]interface{} {
+	details := e.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"kind":          e.Kind,
+		"proposal_hash": e.ProposalHash,
+		"timestamp":     e.Timestamp,
+		"details":       details,
+	}
+}
+
+// GetHash returns the semantic hash of the event
+func (e LifecycleEvent) GetHash() (string, error) {
+	return SemanticHash(e.ToMap())
+}
+
+// validTransitions lists the states each state may move to
+var validTransitions = map[ProposalState][]ProposalState{
+	StatePending:    {StateChallenged, StateRatified},
+	StateChallenged: {StateRatified, StateReverted},
+	StateRatified:   {StateReverted},
+}
+
+// Lifecycle tracks proposal states and notifies subscribers of transitions
+type Lifecycle struct {
+	mu        sync.Mutex
+	states    map[string]ProposalState
+	listeners []func(LifecycleEvent)
+	// Clock supplies event timestamps; defaults to SystemClock
+	Clock Clock
+}
+
+// NewLifecycle creates an empty Lifecycle
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{states: make(map[string]ProposalState)}
+}
+
+// Subscribe registers fn to be called synchronously for every event
+func (l *Lifecycle) Subscribe(fn func(LifecycleEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// State returns the current state of a proposal
+func (l *Lifecycle) State(proposalHash string) (ProposalState, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state, ok := l.states[proposalHash]
+	return state, ok
+}
+
+// Submit registers a proposal as pending
+//
+// Returns:
+//   - Hash of the proposal
+func (l *Lifecycle) Submit(p *ContractProposal) (string, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	if _, ok := l.states[hash]; ok {
+		l.mu.Unlock()
+		return "", NewConstitutionalError(fmt.Sprintf("proposal %s already submitted", hash))
+	}
+	l.states[hash] = StatePending
+	l.mu.Unlock()
+
+	l.emit(EventSubmitted, hash, map[string]interface{}{"proposer_agent": p.ProposerAgent})
+	return hash, nil
+}
+
+// Challenge moves a pending proposal into the challenged state
+func (l *Lifecycle) Challenge(proposalHash, challenger string) error {
+	return l.transition(proposalHash, StateChallenged, EventChallenged, map[string]interface{}{"challenger": challenger})
+}
+
+// Ratify accepts a pending or unsuccessfully challenged proposal
+func (l *Lifecycle) Ratify(proposalHash string) error {
+	return l.transition(proposalHash, StateRatified, EventRatified, nil)
+}
+
+// Revert invalidates a challenged or ratified proposal
+func (l *Lifecycle) Revert(proposalHash, reason string) error {
+	return l.transition(proposalHash, StateReverted, EventReverted, map[string]interface{}{"reason": reason})
+}
+
+func (l *Lifecycle) transition(hash string, to ProposalState, kind string, details map[string]interface{}) error {
+	l.mu.Lock()
+	from, ok := l.states[hash]
+	if !ok {
+		l.mu.Unlock()
+		return NewConstitutionalError(fmt.Sprintf("unknown proposal %s", hash))
+	}
+	allowed := false
+	for _, s := range validTransitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		l.mu.Unlock()
+		return NewConstitutionalError(fmt.Sprintf("proposal %s cannot move from %s to %s", hash, from, to))
+	}
+	l.states[hash] = to
+	l.mu.Unlock()
+
+	l.emit(kind, hash, details)
+	return nil
+}
+
+func (l *Lifecycle) emit(kind, hash string, details map[string]interface{}) {
+	event := LifecycleEvent{
+		Kind:         kind,
+		ProposalHash: hash,
+		Timestamp:    Timestamp(clockOrSystem(l.Clock).Now()),
+		Details:      details,
+	}
+
+	l.mu.Lock()
+	listeners := append([]func(LifecycleEvent){}, l.listeners...)
+	l.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// lifecycleReplay rebuilds proposal states from ledger entries, one entry at
+// a time
+type lifecycleReplay struct {
+	states map[string]ProposalState
+	// bonds maps a challenge bond's hash to the proposal it challenges
+	bonds map[string]string
+	// reverts maps an upheld resolution's hash to the proposal it reverted
+	// and the state it reverted it from
+	reverts map[string]revertedProposal
+}
+
+type revertedProposal struct {
+	hash string
+	from ProposalState
+}
+
+func newLifecycleReplay() *lifecycleReplay {
+	return &lifecycleReplay{
+		states:  make(map[string]ProposalState),
+		bonds:   make(map[string]string),
+		reverts: make(map[string]revertedProposal),
+	}
+}
+
+// apply moves the proposal e records a transition for. Transitions the
+// lifecycle does not allow, such as a bond against a proposal already
+// ratified, leave its state unchanged.
+func (r *lifecycleReplay) apply(e LedgerEntry) {
+	hash := payloadString(e.Payload, "proposal_hash")
+	switch e.Kind {
+	case LedgerKindProposal:
+		if _, ok := r.states[hash]; !ok {
+			r.states[hash] = StatePending
+		}
+	case LedgerKindEmergencyHalt:
+		// CircuitBreaker.Trigger submits and ratifies the halt proposal
+		if _, ok := r.states[hash]; !ok {
+			r.states[hash] = StatePending
+		}
+		r.move(hash, StateRatified)
+	case LedgerKindCanonical, LedgerKindActivation:
+		r.move(hash, StateRatified)
+	case LedgerKindChallengeBond:
+		r.bonds[e.Hash] = hash
+		r.move(hash, StateChallenged)
+	case LedgerKindChallengeResolved:
+		hash = r.bonds[payloadString(e.Payload, "bond_hash")]
+		if from := r.states[hash]; payloadString(e.Payload, "outcome") == ChallengeUpheld && r.move(hash, StateReverted) {
+			r.reverts[e.Hash] = revertedProposal{hash: hash, from: from}
+		}
+	case LedgerKindAppealRuling:
+		if granted, _ := e.Payload["granted"].(bool); granted {
+			resolution := payloadString(e.Payload, "resolution_hash")
+			if reverted, ok := r.reverts[resolution]; ok && r.states[reverted.hash] == StateReverted {
+				r.states[reverted.hash] = reverted.from
+			}
+			delete(r.reverts, resolution)
+		}
+	}
+}
+
+// move sets a known proposal's state to to if the lifecycle allows it,
+// reporting whether it did
+func (r *lifecycleReplay) move(hash string, to ProposalState) bool {
+	from, ok := r.states[hash]
+	if !ok {
+		return false
+	}
+	for _, s := range validTransitions[from] {
+		if s == to {
+			r.states[hash] = to
+			return true
+		}
+	}
+	return false
+}
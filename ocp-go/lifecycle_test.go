@@ -0,0 +1,52 @@
+package ocp
+
+import (
+	"testing"
+)
+
+// TestLifecycleTransitions tests valid and invalid state transitions
+func TestLifecycleTransitions(t *testing.T) {
+	lifecycle := NewLifecycle()
+	var events []LifecycleEvent
+	lifecycle.Subscribe(func(e LifecycleEvent) { events = append(events, e) })
+
+	hash, err := lifecycle.Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if _, err := lifecycle.Submit(testProposal()); err == nil {
+		t.Errorf("Duplicate submission should be rejected")
+	}
+
+	if err := lifecycle.Revert(hash, "fraud"); err == nil {
+		t.Errorf("Pending proposals cannot be reverted without a challenge")
+	}
+	if err := lifecycle.Challenge(hash, "Gemini"); err != nil {
+		t.Fatalf("Failed to challenge: %v", err)
+	}
+	if err := lifecycle.Ratify(hash); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+	if err := lifecycle.Challenge(hash, "Gemini"); err == nil {
+		t.Errorf("Ratified proposals cannot be challenged again")
+	}
+	if err := lifecycle.Revert(hash, "fraud proof accepted"); err != nil {
+		t.Fatalf("Failed to revert: %v", err)
+	}
+
+	if state, _ := lifecycle.State(hash); state != StateReverted {
+		t.Errorf("Expected reverted, got %s", state)
+	}
+
+	kinds := []string{EventSubmitted, EventChallenged, EventRatified, EventReverted}
+	if len(events) != len(kinds) {
+		t.Fatalf("Expected %d events, got %d", len(kinds), len(events))
+	}
+	for i, kind := range kinds {
+		if events[i].Kind != kind || events[i].ProposalHash != hash {
+			t.Errorf("Event %d: expected %s for %s, got %+v", i, kind, hash, events[i])
+		}
+	}
+
+	t.Logf("✓ Lifecycle emitted %d events", len(events))
+}
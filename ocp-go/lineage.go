@@ -0,0 +1,265 @@
+// lineage.go - Ancestry of proposals
+//
+// A clause's current text is the end of a chain of decisions: an amendment
+// rewrites it, a later amendment refines that, an override reverts one of
+// them. Proposals record those links in two ways, and a LineageIndex reads
+// both. A proposal whose action.target is another proposal's id or hash acts
+// on that proposal: it reverts it if its operation is "revert" or
+// "invalidate", supersedes it if the operation is "supersede", and otherwise
+// amends it. A proposal whose pre_state_hash is another proposal's
+// post_state_hash was made against the state that proposal produced, and so
+// supersedes it.
+//
+// Lineage follows those links back from one proposal and returns the DAG of
+// its ancestors, which reviewers can export as JSON or as Graphviz DOT. Links
+// are derived from proposal content, so a malformed set of proposals can form
+// a cycle; Lineage reports it rather than looping.
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Lineage relations, from a proposal to its ancestor
+const (
+	LineageAmends     = "amends"
+	LineageSupersedes = "supersedes"
+	LineageReverts    = "reverts"
+)
+
+// LineageEdge links a proposal to an ancestor
+type LineageEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// ToMap converts a LineageEdge to a map for canonicalization
+func (e LineageEdge) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"from":     e.From,
+		"to":       e.To,
+		"relation": e.Relation,
+	}
+}
+
+// LineageGraph is the ancestry of one proposal
+type LineageGraph struct {
+	// Root is the hash of the proposal whose ancestry this is
+	Root string
+	// Proposals holds the root and every ancestor, by hash
+	Proposals map[string]*ContractProposal
+	// Edges are sorted by From, To, and Relation
+	Edges []LineageEdge
+}
+
+// Ancestors returns the hashes of the root's ancestors, sorted
+func (g *LineageGraph) Ancestors() []string {
+	out := make([]string, 0, len(g.Proposals))
+	for hash := range g.Proposals {
+		if hash != g.Root {
+			out = append(out, hash)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ToMap converts a LineageGraph to a map for canonicalization and JSON export
+func (g *LineageGraph) ToMap() map[string]interface{} {
+	nodes := make([]interface{}, 0, len(g.Proposals))
+	for _, hash := range append([]string{g.Root}, g.Ancestors()...) {
+		p := g.Proposals[hash]
+		target, _ := p.Action["target"].(string)
+		nodes = append(nodes, map[string]interface{}{
+			"hash":           hash,
+			"id":             p.ID,
+			"proposer_agent": p.ProposerAgent,
+			"action_type":    p.ActionType,
+			"target":         target,
+			"timestamp":      p.Timestamp,
+		})
+	}
+	edges := make([]interface{}, len(g.Edges))
+	for i, e := range g.Edges {
+		edges[i] = e.ToMap()
+	}
+	return map[string]interface{}{
+		"root":  g.Root,
+		"nodes": nodes,
+		"edges": edges,
+	}
+}
+
+// DOT renders the graph in Graphviz DOT, edges pointing from a proposal to
+// its ancestor
+func (g *LineageGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n\trankdir=BT;\n")
+	for _, hash := range append([]string{g.Root}, g.Ancestors()...) {
+		p := g.Proposals[hash]
+		label := fmt.Sprintf("%s\\n%s by %s", p.ID, p.ActionType, p.ProposerAgent)
+		attrs := ""
+		if hash == g.Root {
+			attrs = ", style=bold"
+		}
+		fmt.Fprintf(&b, "\t%q [label=%q%s];\n", hash, label, attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%q -> %q [label=%q];\n", e.From, e.To, e.Relation)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// LineageIndex resolves the links between a set of proposals
+type LineageIndex struct {
+	proposals map[string]*ContractProposal
+	// byID and byPostState map identifiers to proposal hashes
+	byID        map[string][]string
+	byPostState map[string][]string
+}
+
+// NewLineageIndex indexes proposals for ancestry queries
+func NewLineageIndex(proposals []*ContractProposal) (*LineageIndex, error) {
+	x := &LineageIndex{
+		proposals:   make(map[string]*ContractProposal, len(proposals)),
+		byID:        make(map[string][]string),
+		byPostState: make(map[string][]string),
+	}
+	for _, p := range proposals {
+		if err := x.Add(p); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// Add indexes one more proposal; adding a proposal twice has no effect
+func (x *LineageIndex) Add(p *ContractProposal) error {
+	hash, err := p.GetHash()
+	if err != nil {
+		return err
+	}
+	if _, ok := x.proposals[hash]; ok {
+		return nil
+	}
+	x.proposals[hash] = p
+	if p.ID != "" {
+		x.byID[p.ID] = append(x.byID[p.ID], hash)
+	}
+	if p.PostStateHash != "" {
+		x.byPostState[p.PostStateHash] = append(x.byPostState[p.PostStateHash], hash)
+	}
+	return nil
+}
+
+// parents returns the edges from the proposal with hash to its direct
+// ancestors, sorted
+func (x *LineageIndex) parents(hash string) []LineageEdge {
+	p := x.proposals[hash]
+	seen := make(map[LineageEdge]bool)
+	var out []LineageEdge
+	add := func(to, relation string) {
+		e := LineageEdge{From: hash, To: to, Relation: relation}
+		if to != hash && !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+
+	if target, _ := p.Action["target"].(string); target != "" {
+		relation := LineageAmends
+		switch operation, _ := p.Action["operation"].(string); operation {
+		case "revert", "invalidate":
+			relation = LineageReverts
+		case "supersede":
+			relation = LineageSupersedes
+		}
+		if _, ok := x.proposals[target]; ok {
+			add(target, relation)
+		}
+		for _, h := range x.byID[target] {
+			add(h, relation)
+		}
+	}
+	if p.PreStateHash != "" {
+		for _, h := range x.byPostState[p.PreStateHash] {
+			add(h, LineageSupersedes)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].To != out[j].To {
+			return out[i].To < out[j].To
+		}
+		return out[i].Relation < out[j].Relation
+	})
+	return out
+}
+
+// Lineage returns the ancestry DAG of the proposal with proposalHash
+//
+// Returns:
+//   - Graph of the proposal and every proposal it transitively amends,
+//     supersedes, or reverts
+//   - ConstitutionalError if the proposal is not indexed
+//   - LineageError naming the cycle if the links loop back on themselves
+func (x *LineageIndex) Lineage(proposalHash string) (*LineageGraph, error) {
+	if _, ok := x.proposals[proposalHash]; !ok {
+		return nil, NewConstitutionalError(fmt.Sprintf("proposal %s is not indexed", proposalHash))
+	}
+	g := &LineageGraph{Root: proposalHash, Proposals: make(map[string]*ContractProposal)}
+
+	// Depth-first search, keeping the current path to report cycles
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(hash string) error
+	visit = func(hash string) error {
+		switch state[hash] {
+		case visiting:
+			start := 0
+			for path[start] != hash {
+				start++
+			}
+			cycle := append(append([]string(nil), path[start:]...), hash)
+			return &ConstitutionalError{ErrorType: "LineageError", Message: "lineage cycle: " + strings.Join(cycle, " -> ")}
+		case done:
+			return nil
+		}
+		state[hash] = visiting
+		path = append(path, hash)
+		g.Proposals[hash] = x.proposals[hash]
+		for _, e := range x.parents(hash) {
+			g.Edges = append(g.Edges, e)
+			if err := visit(e.To); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[hash] = done
+		return nil
+	}
+	if err := visit(proposalHash); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Relation < b.Relation
+	})
+	return g, nil
+}
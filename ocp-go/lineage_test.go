@@ -0,0 +1,104 @@
+package ocp
+
+import (
+	"strings"
+	"testing"
+)
+
+// lineageProposal returns a proposal with the given id, target, operation,
+// and state hashes
+func lineageProposal(id, target, operation, pre, post string) *ContractProposal {
+	p := testProposal()
+	p.ID = id
+	p.Action = map[string]interface{}{"target": target, "operation": operation}
+	p.PreStateHash = pre
+	p.PostStateHash = post
+	return p
+}
+
+func mustHash(t *testing.T, p *ContractProposal) string {
+	t.Helper()
+	hash, err := p.GetHash()
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	return hash
+}
+
+// TestLineage tests ancestry through targets and state hashes
+func TestLineage(t *testing.T) {
+	original := lineageProposal("a-1", "article-3", "modify", "sha256:s0", "sha256:s1")
+	refine := lineageProposal("a-2", "a-1", "modify", "sha256:s1", "sha256:s2")
+	unrelated := lineageProposal("b-1", "article-9", "modify", "sha256:t0", "sha256:t1")
+	revert := lineageProposal("r-1", "", "revert", "", "")
+	index, err := NewLineageIndex([]*ContractProposal{original, refine, unrelated})
+	if err != nil {
+		t.Fatalf("Failed to index: %v", err)
+	}
+	revert.Action["target"] = mustHash(t, refine)
+	if err := index.Add(revert); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+
+	g, err := index.Lineage(mustHash(t, revert))
+	if err != nil {
+		t.Fatalf("Failed to get lineage: %v", err)
+	}
+	ancestors := g.Ancestors()
+	if len(ancestors) != 2 || g.Proposals[mustHash(t, unrelated)] != nil {
+		t.Errorf("Unexpected ancestors %v", ancestors)
+	}
+	want := map[LineageEdge]bool{
+		{From: mustHash(t, revert), To: mustHash(t, refine), Relation: LineageReverts}:      true,
+		{From: mustHash(t, refine), To: mustHash(t, original), Relation: LineageAmends}:     true,
+		{From: mustHash(t, refine), To: mustHash(t, original), Relation: LineageSupersedes}: true,
+	}
+	if len(g.Edges) != len(want) {
+		t.Errorf("Unexpected edges %v", g.Edges)
+	}
+	for _, e := range g.Edges {
+		if !want[e] {
+			t.Errorf("Unexpected edge %v", e)
+		}
+	}
+
+	// Exports are deterministic and complete
+	first, err := SemanticHash(g.ToMap())
+	if err != nil {
+		t.Fatalf("Failed to hash export: %v", err)
+	}
+	again, _ := index.Lineage(mustHash(t, revert))
+	if second, _ := SemanticHash(again.ToMap()); first != second {
+		t.Errorf("Lineage export is not deterministic")
+	}
+	dot := g.DOT()
+	if !strings.HasPrefix(dot, "digraph lineage {") || strings.Count(dot, "->") != 3 || !strings.Contains(dot, `label="reverts"`) {
+		t.Errorf("Unexpected DOT:\n%s", dot)
+	}
+
+	if _, err := index.Lineage("sha256:missing"); err == nil {
+		t.Errorf("Expected an unknown proposal to be refused")
+	}
+
+	t.Logf("✓ Lineage of %s has %d ancestors", g.Root, len(ancestors))
+}
+
+// TestLineageCycle tests that looping links are reported
+func TestLineageCycle(t *testing.T) {
+	a := lineageProposal("c-1", "c-2", "modify", "", "")
+	b := lineageProposal("c-2", "c-1", "modify", "", "")
+	index, _ := NewLineageIndex([]*ContractProposal{a, b})
+	_, err := index.Lineage(mustHash(t, a))
+	if err == nil || !strings.Contains(err.Error(), "lineage cycle") {
+		t.Fatalf("Expected a cycle error, got %v", err)
+	}
+
+	// A proposal whose pre and post state match does not link to itself
+	self := lineageProposal("s-1", "", "modify", "sha256:same", "sha256:same")
+	index, _ = NewLineageIndex([]*ContractProposal{self})
+	if g, err := index.Lineage(mustHash(t, self)); err != nil || len(g.Edges) != 0 {
+		t.Errorf("Unexpected self lineage %v, %v", g, err)
+	}
+
+	t.Logf("✓ Cycle reported: %v", err)
+}
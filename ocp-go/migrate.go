@@ -0,0 +1,320 @@
+// migrate.go - Cross-certifying ledger history under a new hash algorithm
+//
+// Every ledger entry is chained by its SHA-256 semantic hash. If SHA-256 is
+// ever deprecated, years of history must stay verifiable without trusting it
+// alone. MigrateHashes recomputes the whole chain under a second algorithm:
+// each entry is hashed again with its prev_hash replaced by the previous
+// entry's new hash, so the new hashes form a chain of their own. The results
+// are appended to the ledger as hash_migration entries, each pairing old and
+// new hashes for a batch of heights, which cross-certifies the two chains
+// while the old algorithm is still trusted.
+//
+// Migration is resumable. A later run continues after the last height already
+// migrated for the same pair of algorithms, and covers the migration entries
+// appended by earlier runs. Migrating from an algorithm other than the
+// ledger's own uses the hashes recorded by an earlier migration to it, and so
+// stops at the last height that migration covered.
+
+package ocp
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// LedgerKindHashMigration is the ledger entry kind holding cross-certified hashes
+const LedgerKindHashMigration = "hash_migration"
+
+// MigrationBatchSize is the number of heights recorded per migration entry
+const MigrationBatchSize = 256
+
+// hashAlgorithmSHA3 is the SHA3-256 algorithm, absent under the ocp_nosha3 tag
+const hashAlgorithmSHA3 = "sha3-256"
+
+// hashAlgorithms are the digests available for migration
+var hashAlgorithms = buildHashAlgorithms()
+
+func buildHashAlgorithms() map[string]func() hash.Hash {
+	algorithms := map[string]func() hash.Hash{
+		"sha256":     sha256.New,
+		"sha384":     sha512.New384,
+		"sha512":     sha512.New,
+		"sha512-256": sha512.New512_256,
+	}
+	if hashing.SHA3Build {
+		algorithms[hashAlgorithmSHA3] = hashing.NewSHA3
+	}
+	return algorithms
+}
+
+// unsupportedHashAlgorithm returns the error for an algorithm this build does
+// not offer: a *CapabilityError if a build tag removed it
+func unsupportedHashAlgorithm(algo string) error {
+	if algo == hashAlgorithmSHA3 && !hashing.SHA3Build {
+		return &CapabilityError{Capability: CapabilitySHA3, Operation: "hash algorithm " + algo}
+	}
+	return NewConstitutionalError(fmt.Sprintf("unsupported hash algorithm %q", algo))
+}
+
+// SupportedHashAlgorithms lists the algorithms MigrateHashes accepts, sorted
+func SupportedHashAlgorithms() []string {
+	out := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AlgorithmHash returns the hex digest of data's canonical form under algo
+//
+// Returns:
+//   - ConstitutionalError if algo is not supported, or *CapabilityError if
+//     this build omits it
+func AlgorithmHash(algo string, data map[string]interface{}) (string, error) {
+	newHash, ok := hashAlgorithms[algo]
+	if !ok {
+		return "", unsupportedHashAlgorithm(algo)
+	}
+	form, err := canonical.Canonicalize(data, true)
+	if err != nil {
+		return "", err
+	}
+	h := newHash()
+	h.Write([]byte(form))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MigrationRecord pairs an entry's hashes under two algorithms
+type MigrationRecord struct {
+	Height   uint64 `json:"height"`
+	FromHash string `json:"from_hash"`
+	ToHash   string `json:"to_hash"`
+}
+
+// ToMap converts a MigrationRecord to a map for canonicalization
+func (r MigrationRecord) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"height":    r.Height,
+		"from_hash": r.FromHash,
+		"to_hash":   r.ToHash,
+	}
+}
+
+// MigrationReport summarizes one MigrateHashes run
+type MigrationReport struct {
+	FromAlgorithm string
+	ToAlgorithm   string
+	// FirstHeight and LastHeight bound the heights migrated by this run; both
+	// are zero if there was nothing to migrate
+	FirstHeight uint64
+	LastHeight  uint64
+	// Appended holds the hash_migration entries this run wrote
+	Appended []LedgerEntry
+}
+
+// MigrateHashes cross-certifies every entry of ledger not yet migrated from
+// fromAlgo to toAlgo
+//
+// Parameters:
+//   - ledger: Ledger to migrate; it must verify under its own algorithm
+//   - fromAlgo: Algorithm of the hashes being certified, HashAlgorithm or one
+//     the ledger was previously migrated to
+//   - toAlgo: Algorithm of the new chain
+//
+// Returns:
+//   - Report of the heights migrated and the entries appended
+//   - ConstitutionalError if an algorithm is unsupported, the two are equal,
+//     or no fromAlgo hash is recorded for the first height to migrate
+func MigrateHashes(ledger *Ledger, fromAlgo, toAlgo string) (MigrationReport, error) {
+	report := MigrationReport{FromAlgorithm: fromAlgo, ToAlgorithm: toAlgo}
+	if _, ok := hashAlgorithms[fromAlgo]; !ok {
+		return report, unsupportedHashAlgorithm(fromAlgo)
+	}
+	if _, ok := hashAlgorithms[toAlgo]; !ok {
+		return report, unsupportedHashAlgorithm(toAlgo)
+	}
+	if fromAlgo == toAlgo {
+		return report, NewConstitutionalError("migration needs two different algorithms")
+	}
+	if err := ledger.Verify(); err != nil {
+		return report, err
+	}
+
+	entries := ledger.Entries(0)
+	known := migratedHashes(entries)
+	fromHash := func(e LedgerEntry) (string, bool) {
+		if fromAlgo == HashAlgorithm {
+			return e.Hash, true
+		}
+		h, ok := known[toAlgoKey(fromAlgo, e.Height)]
+		return h, ok
+	}
+
+	// Resume after the last height already migrated for this pair
+	var last uint64
+	prev := ""
+	if len(entries) > 0 {
+		prev = entries[0].PrevHash
+	}
+	for _, e := range entries {
+		if h, ok := known[pairKey(fromAlgo, toAlgo, e.Height)]; ok {
+			last, prev = e.Height, h
+		}
+	}
+
+	var batch []interface{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		entry, err := ledger.Append(LedgerKindHashMigration, map[string]interface{}{
+			"from_algorithm": fromAlgo,
+			"to_algorithm":   toAlgo,
+			"records":        batch,
+		})
+		if err != nil {
+			return err
+		}
+		report.Appended = append(report.Appended, entry)
+		batch = nil
+		return nil
+	}
+	for _, e := range entries {
+		if e.Height <= last {
+			continue
+		}
+		from, ok := fromHash(e)
+		if !ok && report.FirstHeight != 0 {
+			// History past the earlier migration is not yet in fromAlgo
+			break
+		}
+		if !ok {
+			return report, NewConstitutionalError(fmt.Sprintf("no %s hash recorded for height %d", fromAlgo, e.Height))
+		}
+		to, err := rechainedHash(toAlgo, e, prev)
+		if err != nil {
+			return report, err
+		}
+		if report.FirstHeight == 0 {
+			report.FirstHeight = e.Height
+		}
+		report.LastHeight = e.Height
+		batch = append(batch, MigrationRecord{Height: e.Height, FromHash: from, ToHash: to}.ToMap())
+		prev = to
+		if len(batch) == MigrationBatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, flush()
+}
+
+// VerifyHashMigration recomputes every fromAlgo to toAlgo record in entries
+// and checks that the migrated heights are contiguous from the first entry
+//
+// Returns:
+//   - Height up to which history is cross-certified
+//   - VerificationError naming the first record that does not match
+func VerifyHashMigration(entries []LedgerEntry, fromAlgo, toAlgo string) (uint64, error) {
+	byHeight := make(map[uint64]LedgerEntry, len(entries))
+	for _, e := range entries {
+		byHeight[e.Height] = e
+	}
+	known := migratedHashes(entries)
+
+	var covered uint64
+	prev := ""
+	if len(entries) > 0 {
+		prev = entries[0].PrevHash
+		covered = entries[0].Height - 1
+	}
+	for _, e := range entries {
+		if e.Kind != LedgerKindHashMigration || payloadString(e.Payload, "from_algorithm") != fromAlgo ||
+			payloadString(e.Payload, "to_algorithm") != toAlgo {
+			continue
+		}
+		for _, r := range migrationRecords(e.Payload) {
+			if r.Height != covered+1 {
+				return covered, NewVerificationError(fmt.Sprintf("migration record for height %d follows height %d", r.Height, covered))
+			}
+			target, ok := byHeight[r.Height]
+			if !ok || target.Height >= e.Height {
+				return covered, NewVerificationError(fmt.Sprintf("migration at height %d certifies unknown height %d", e.Height, r.Height))
+			}
+			from := target.Hash
+			if fromAlgo != HashAlgorithm {
+				from = known[toAlgoKey(fromAlgo, r.Height)]
+			}
+			if r.FromHash != from {
+				return covered, NewVerificationError(fmt.Sprintf("%s hash of height %d does not match", fromAlgo, r.Height))
+			}
+			to, err := rechainedHash(toAlgo, target, prev)
+			if err != nil {
+				return covered, err
+			}
+			if r.ToHash != to {
+				return covered, NewVerificationError(fmt.Sprintf("%s hash of height %d does not match", toAlgo, r.Height))
+			}
+			covered, prev = r.Height, to
+		}
+	}
+	return covered, nil
+}
+
+// rechainedHash hashes e under algo with its prev_hash replaced by prev
+func rechainedHash(algo string, e LedgerEntry, prev string) (string, error) {
+	m := e.ToMap()
+	m["prev_hash"] = prev
+	return AlgorithmHash(algo, m)
+}
+
+// migratedHashes indexes every recorded migration hash by pairKey and toAlgoKey
+func migratedHashes(entries []LedgerEntry) map[string]string {
+	out := make(map[string]string)
+	for _, e := range entries {
+		if e.Kind != LedgerKindHashMigration {
+			continue
+		}
+		from, to := payloadString(e.Payload, "from_algorithm"), payloadString(e.Payload, "to_algorithm")
+		for _, r := range migrationRecords(e.Payload) {
+			out[pairKey(from, to, r.Height)] = r.ToHash
+			out[toAlgoKey(to, r.Height)] = r.ToHash
+		}
+	}
+	return out
+}
+
+// migrationRecords reads the records of a hash_migration payload
+func migrationRecords(payload map[string]interface{}) []MigrationRecord {
+	var out []MigrationRecord
+	list, _ := payload["records"].([]interface{})
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		out = append(out, MigrationRecord{
+			Height:   uint64(payloadInt(m, "height")),
+			FromHash: payloadString(m, "from_hash"),
+			ToHash:   payloadString(m, "to_hash"),
+		})
+	}
+	return out
+}
+
+func pairKey(from, to string, height uint64) string {
+	return fmt.Sprintf("%s>%s@%d", from, to, height)
+}
+
+func toAlgoKey(algo string, height uint64) string {
+	return fmt.Sprintf("%s@%d", algo, height)
+}
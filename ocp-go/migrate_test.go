@@ -0,0 +1,110 @@
+package ocp
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// migrationLedger returns a ledger holding n proposal entries
+func migrationLedger(t *testing.T, n int) *Ledger {
+	t.Helper()
+	ledger := NewLedger()
+	for i := 0; i < n; i++ {
+		if _, err := ledger.Append(LedgerKindProposal, map[string]interface{}{"proposal_hash": fmt.Sprintf("p-%d", i)}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	return ledger
+}
+
+// TestMigrateHashes tests cross-certification and resumption
+func TestMigrateHashes(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := migrationLedger(t, MigrationBatchSize+10)
+	report, err := MigrateHashes(ledger, "sha256", "sha512")
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if report.FirstHeight != 1 || report.LastHeight != MigrationBatchSize+10 || len(report.Appended) != 2 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	covered, err := VerifyHashMigration(ledger.Entries(0), "sha256", "sha512")
+	if err != nil || covered != report.LastHeight {
+		t.Errorf("Expected history through %d to verify, got %d, %v", report.LastHeight, covered, err)
+	}
+
+	// A second run certifies the migration entries and anything appended since
+	ledger.Append(LedgerKindProposal, map[string]interface{}{"proposal_hash": "late"})
+	again, err := MigrateHashes(ledger, "sha256", "sha512")
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if again.FirstHeight != report.LastHeight+1 || again.LastHeight != report.LastHeight+3 {
+		t.Errorf("Unexpected resumed report %+v", again)
+	}
+	if covered, err := VerifyHashMigration(ledger.Entries(0), "sha256", "sha512"); err != nil || covered != again.LastHeight {
+		t.Errorf("Expected resumed history to verify, got %d, %v", covered, err)
+	}
+
+	// Records survive a JSON round trip of the ledger
+	data, _ := json.Marshal(ledger.Entries(0))
+	var decoded []LedgerEntry
+	json.Unmarshal(data, &decoded)
+	if covered, err := VerifyHashMigration(decoded, "sha256", "sha512"); err != nil || covered != again.LastHeight {
+		t.Errorf("Expected decoded history to verify, got %d, %v", covered, err)
+	}
+
+	t.Logf("✓ %d heights cross-certified from sha256 to sha512", covered)
+}
+
+// TestMigrateHashesChained tests migrating onward from a migrated algorithm
+func TestMigrateHashesChained(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := migrationLedger(t, 5)
+	if _, err := MigrateHashes(ledger, "sha384", "sha512"); err == nil {
+		t.Errorf("Expected migration from an unrecorded algorithm to fail")
+	}
+	if _, err := MigrateHashes(ledger, "sha256", "md5"); err == nil {
+		t.Errorf("Expected an unsupported algorithm to fail")
+	}
+	if _, err := MigrateHashes(ledger, "sha256", "sha256"); err == nil {
+		t.Errorf("Expected migration to the same algorithm to fail")
+	}
+
+	if _, err := MigrateHashes(ledger, "sha256", "sha384"); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	report, err := MigrateHashes(ledger, "sha384", "sha512")
+	if err != nil {
+		t.Fatalf("Failed to migrate onward: %v", err)
+	}
+	// The onward run stops before the sha384 migration entry, which is not
+	// itself recorded in sha384
+	if report.LastHeight != 5 {
+		t.Errorf("Unexpected onward report %+v", report)
+	}
+	if covered, err := VerifyHashMigration(ledger.Entries(0), "sha384", "sha512"); err != nil || covered != 5 {
+		t.Errorf("Expected onward migration to verify, got %d, %v", covered, err)
+	}
+
+	t.Log("✓ Migrations chain from sha256 through sha384 to sha512")
+}
+
+// TestVerifyHashMigrationTamper tests that altered records are detected
+func TestVerifyHashMigrationTamper(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := migrationLedger(t, 3)
+	MigrateHashes(ledger, "sha256", "sha512")
+	entries := ledger.Entries(0)
+	records := entries[3].Payload["records"].([]interface{})
+	records[1].(map[string]interface{})["to_hash"] = "00"
+	covered, err := VerifyHashMigration(entries, "sha256", "sha512")
+	if err == nil || covered != 1 {
+		t.Errorf("Expected tampering at height 2 to be detected, got %d, %v", covered, err)
+	}
+
+	t.Logf("✓ Tampered record detected: %v", err)
+}
@@ -0,0 +1,252 @@
+// node.go - Proposal intake for an OCP node
+//
+// A Node records accepted proposals on its ledger and tracks them through the
+// proposal lifecycle. Submission is idempotent by proposal hash: agents retrying
+// after a timeout receive the original acceptance record instead of an error,
+// and a proposal's stake is never recorded twice.
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// LedgerKindProposal is the ledger entry kind recording an accepted proposal
+const LedgerKindProposal = "proposal"
+
+// Acceptance records that a node accepted a proposal
+type Acceptance struct {
+	ProposalHash string `json:"proposal_hash"`
+	LedgerHeight uint64 `json:"ledger_height"`
+	EntryHash    string `json:"entry_hash"`
+	AcceptedAt   string `json:"accepted_at"`
+}
+
+// ToMap converts an Acceptance to a map for canonicalization
+func (a Acceptance) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash": a.ProposalHash,
+		"ledger_height": a.LedgerHeight,
+		"entry_hash":    a.EntryHash,
+		"accepted_at":   a.AcceptedAt,
+	}
+}
+
+// Node accepts proposals onto a ledger
+type Node struct {
+	mu        sync.Mutex
+	ledger    *Ledger
+	lifecycle *Lifecycle
+	accepted  map[string]Acceptance
+	// proposals holds the proposals accepted since the node started, for
+	// conflict checks against pending ones
+	proposals map[string]*ContractProposal
+	// Clock supplies acceptance timestamps; defaults to SystemClock
+	Clock Clock
+	// Breaker, if set, rejects every proposal other than an emergency halt
+	// while a halt is in force
+	Breaker *CircuitBreaker
+	// Intents, if set, rejects proposals its policy covers unless they cite
+	// an unused intent declaration past its cooling-off period
+	Intents *IntentRegistry
+	// Watchdog, if set, verifies each newly accepted proposal and moves it
+	// to the challenged state when it signs a fraud proof against it
+	Watchdog *Watchdog
+}
+
+// NewNode creates a Node backed by ledger. Proposals already recorded on the
+// ledger are treated as accepted, so resubmissions after a restart stay
+// idempotent, and the lifecycle transitions the ledger records are replayed
+// so that each recovered proposal resumes in the state it had reached.
+func NewNode(ledger *Ledger) *Node {
+	n := &Node{
+		ledger:    ledger,
+		lifecycle: NewLifecycle(),
+		accepted:  make(map[string]Acceptance),
+		proposals: make(map[string]*ContractProposal),
+	}
+	replay := newLifecycleReplay()
+	for _, e := range ledger.Entries(0) {
+		replay.apply(e)
+		if e.Kind != LedgerKindProposal {
+			continue
+		}
+		hash := payloadString(e.Payload, "proposal_hash")
+		if _, ok := n.accepted[hash]; ok {
+			continue
+		}
+		n.accepted[hash] = Acceptance{
+			ProposalHash: hash,
+			LedgerHeight: e.Height,
+			EntryHash:    e.Hash,
+			AcceptedAt:   payloadString(e.Payload, "accepted_at"),
+		}
+	}
+	n.lifecycle.states = replay.states
+	return n
+}
+
+// Ledger returns the node's ledger
+func (n *Node) Ledger() *Ledger {
+	return n.ledger
+}
+
+// Lifecycle returns the node's proposal lifecycle tracker
+func (n *Node) Lifecycle() *Lifecycle {
+	return n.lifecycle
+}
+
+// Submit accepts a proposal. Submitting a proposal with the same hash again
+// returns the original Acceptance and records nothing new.
+//
+// Returns:
+//   - Acceptance record for the proposal
+//   - ErrHalted while an emergency halt is in force
+//   - An error wrapping ErrIntentRequired if Intents refuses the proposal
+func (n *Node) Submit(p *ContractProposal) (Acceptance, error) {
+	acceptance, fresh, err := n.submit(p)
+	if err != nil || !fresh || n.Watchdog == nil {
+		return acceptance, err
+	}
+	// The proposal stays accepted; a failed check moves it to challenged
+	proof, err := n.Watchdog.InspectProposal(p)
+	if err != nil {
+		return acceptance, err
+	}
+	if proof != nil {
+		if err := n.lifecycle.Challenge(acceptance.ProposalHash, proof.ChallengerAgent); err != nil {
+			return acceptance, err
+		}
+	}
+	return acceptance, nil
+}
+
+// submit accepts p, reporting whether it was newly accepted
+func (n *Node) submit(p *ContractProposal) (Acceptance, bool, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return Acceptance{}, false, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if existing, ok := n.accepted[hash]; ok {
+		return existing, false, nil
+	}
+	if err := n.admitLocked(p); err != nil {
+		return Acceptance{}, false, err
+	}
+	// Checked before anything is recorded, so a refusal never leaves a
+	// ledger entry behind for a retry to duplicate
+	if _, ok := n.lifecycle.State(hash); ok {
+		return Acceptance{}, false, NewConstitutionalError(fmt.Sprintf("proposal %s already submitted", hash))
+	}
+
+	acceptedAt := Timestamp(clockOrSystem(n.Clock).Now())
+
+	payload := map[string]interface{}{
+		"proposal_hash":    hash,
+		"proposer_agent":   p.ProposerAgent,
+		"reputation_stake": p.ReputationStake,
+		"accepted_at":      acceptedAt,
+	}
+	if p.ActivationHeight > 0 {
+		payload["activation_height"] = p.ActivationHeight
+	}
+	if p.ActivationTime != "" {
+		payload["activation_time"] = p.ActivationTime
+	}
+	// Recording the intent consumes it; see IntentRegistry
+	if n.Intents != nil && n.Intents.Requires(p) {
+		payload["intent_hash"] = IntentHash(p)
+	}
+	entry, err := n.ledger.Append(LedgerKindProposal, payload)
+	if err != nil {
+		return Acceptance{}, false, err
+	}
+
+	// Once the entry is on the ledger the proposal is accepted, whatever
+	// follows, so a retry returns this acceptance instead of staking again
+	acceptance := Acceptance{
+		ProposalHash: hash,
+		LedgerHeight: entry.Height,
+		EntryHash:    entry.Hash,
+		AcceptedAt:   acceptedAt,
+	}
+	n.accepted[hash] = acceptance
+	stored := *p
+	n.proposals[hash] = &stored
+
+	if _, err := n.lifecycle.Submit(p); err != nil {
+		return acceptance, false, err
+	}
+	return acceptance, true, nil
+}
+
+// admitLocked applies the checks that refuse a proposal before anything is
+// recorded: an emergency halt, a missing intent declaration, and an
+// activation that is already due. The caller holds n.mu.
+func (n *Node) admitLocked(p *ContractProposal) error {
+	if n.Breaker != nil && p.ActionType != ActionEmergencyHalt {
+		if err := n.Breaker.Check(); err != nil {
+			return err
+		}
+	}
+	if n.Intents != nil {
+		if err := n.Intents.Check(p); err != nil {
+			return err
+		}
+	}
+	return ValidateActivation(p, n.ledger.Height()+1)
+}
+
+// Admit reports whether Submit would accept p now, without recording it
+//
+// Returns:
+//   - nil, or the error Submit would return
+func (n *Node) Admit(p *ContractProposal) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.admitLocked(p)
+}
+
+// Pending returns the proposals accepted since the node started that are
+// still pending or challenged, in hash order. Proposals recovered from the
+// ledger at startup are recorded by hash only and are not included.
+func (n *Node) Pending() []*ContractProposal {
+	n.mu.Lock()
+	proposals := make([]*ContractProposal, 0, len(n.proposals))
+	hashes := make(map[*ContractProposal]string, len(n.proposals))
+	for hash, p := range n.proposals {
+		proposals = append(proposals, p)
+		hashes[p] = hash
+	}
+	n.mu.Unlock()
+	sort.Slice(proposals, func(i, j int) bool { return hashes[proposals[i]] < hashes[proposals[j]] })
+
+	var out []*ContractProposal
+	for _, p := range proposals {
+		if state, _ := n.lifecycle.State(hashes[p]); state == StatePending || state == StateChallenged {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Accepted returns the acceptance record for a proposal hash
+func (n *Node) Accepted(proposalHash string) (Acceptance, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	a, ok := n.accepted[proposalHash]
+	return a, ok
+}
+
+// AcceptedCount returns the number of distinct proposals accepted
+func (n *Node) AcceptedCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.accepted)
+}
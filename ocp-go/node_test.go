@@ -0,0 +1,173 @@
+package ocp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSubmitIdempotent tests that resubmission returns the original acceptance
+func TestSubmitIdempotent(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := NewNode(NewLedger())
+	node.Clock = NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+
+	proposal := testProposal()
+	first, err := node.Submit(proposal)
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	again, err := node.Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Resubmission should not fail: %v", err)
+	}
+	if again != first {
+		t.Errorf("Resubmission should return the original acceptance:\n  %+v\n  %+v", first, again)
+	}
+	if node.Ledger().Height() != 1 {
+		t.Errorf("Resubmission should not append to the ledger, height %d", node.Ledger().Height())
+	}
+	if state, _ := node.Lifecycle().State(first.ProposalHash); state != StatePending {
+		t.Errorf("Accepted proposal should be pending, got %s", state)
+	}
+
+	t.Logf("✓ Resubmission returned acceptance at height %d", again.LedgerHeight)
+}
+
+// TestSubmitConcurrentRetries tests that racing retries record a single entry
+func TestSubmitConcurrentRetries(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := NewNode(NewLedger())
+
+	var wg sync.WaitGroup
+	results := make([]Acceptance, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = node.Submit(testProposal())
+		}(i)
+	}
+	wg.Wait()
+
+	for _, r := range results[1:] {
+		if r != results[0] {
+			t.Errorf("All retries should see the same acceptance")
+		}
+	}
+	if node.Ledger().Height() != 1 {
+		t.Errorf("Expected one ledger entry, got %d", node.Ledger().Height())
+	}
+
+	t.Logf("✓ %d concurrent retries recorded once", len(results))
+}
+
+// TestSubmitIdempotentAcrossRestart tests deduplication rebuilt from the ledger
+func TestSubmitIdempotentAcrossRestart(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	first, err := NewNode(ledger).Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	restarted := NewNode(ledger)
+	again, err := restarted.Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Resubmission after restart should not fail: %v", err)
+	}
+	if again != first || ledger.Height() != 1 {
+		t.Errorf("Restarted node should return the original acceptance")
+	}
+
+	t.Logf("✓ Acceptance survives restart")
+}
+
+// TestSubmitRefusalRecordsNothing tests that a proposal refused by the
+// lifecycle leaves no ledger entry for a retry to duplicate
+func TestSubmitRefusalRecordsNothing(t *testing.T) {
+	node := NewNode(NewLedger())
+	if _, err := node.Lifecycle().Submit(testProposal()); err != nil {
+		t.Fatalf("Failed to submit to the lifecycle: %v", err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := node.Submit(testProposal()); err == nil {
+			t.Errorf("Attempt %d: expected a proposal already in the lifecycle to be refused", attempt)
+		}
+		if node.Ledger().Height() != 0 {
+			t.Fatalf("Attempt %d: expected nothing recorded, height %d", attempt, node.Ledger().Height())
+		}
+	}
+	t.Logf("✓ Refused proposal recorded nothing across retries")
+}
+
+// TestNodeRestartReplaysLifecycle tests that recovered proposals resume in
+// the state the ledger's transitions left them
+func TestNodeRestartReplaysLifecycle(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	proposals := make(map[string]*ContractProposal)
+	hashes := make(map[string]string)
+	for _, name := range []string{"pending", "challenged", "reverted"} {
+		p := testProposal()
+		p.ID = name
+		acceptance, err := node.Submit(p)
+		if err != nil {
+			t.Fatalf("Failed to submit %s: %v", name, err)
+		}
+		proposals[name], hashes[name] = p, acceptance.ProposalHash
+	}
+	var resolution LedgerEntry
+	for _, name := range []string{"challenged", "reverted"} {
+		bond, err := escrow.Lock("Gemini", proposals[name], escrow.RequiredBond("Gemini", proposals[name]))
+		if err != nil {
+			t.Fatalf("Failed to lock bond: %v", err)
+		}
+		if name == "reverted" {
+			if resolution, err = escrow.Resolve(bond.EntryHash, true); err != nil {
+				t.Fatalf("Failed to resolve: %v", err)
+			}
+		}
+	}
+	quorum, privs := testQuorum(t)
+	breaker, err := NewCircuitBreaker(ledger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	halt, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"]))
+	if err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	hashes["halt"] = halt.ProposalHash
+
+	restarted := NewNode(ledger)
+	want := map[string]ProposalState{
+		"pending":    StatePending,
+		"challenged": StateChallenged,
+		"reverted":   StateReverted,
+		"halt":       StateRatified,
+	}
+	for name, state := range want {
+		if got, _ := restarted.Lifecycle().State(hashes[name]); got != state {
+			t.Errorf("Expected %s proposal to resume %s, got %q", name, state, got)
+		}
+	}
+
+	// A granted appeal voids the resolution and with it the revert
+	replay := newLifecycleReplay()
+	for _, e := range ledger.Entries(0) {
+		replay.apply(e)
+	}
+	replay.apply(LedgerEntry{Kind: LedgerKindAppealRuling, Payload: map[string]interface{}{"resolution_hash": resolution.Hash, "granted": true}})
+	if got := replay.states[hashes["reverted"]]; got != StateChallenged {
+		t.Errorf("Expected a voided revert to leave the proposal challenged, got %q", got)
+	}
+
+	t.Logf("✓ %d proposal states replayed from the ledger", len(want))
+}
@@ -0,0 +1,14 @@
+//go:build ocp_noprehash
+
+// noprehash.go - Build profile without Ed25519ph
+//
+// Building with -tags ocp_noprehash compiles out prehashed signatures. The
+// node neither advertises the ed25519ph scheme nor signs with it, and a
+// prehashed signature it is asked to verify fails with a *CapabilityError
+// rather than being skipped.
+
+package ocp
+
+// PrehashBuild reports whether Ed25519ph signatures are compiled in; false
+// under the ocp_noprehash tag
+const PrehashBuild = false
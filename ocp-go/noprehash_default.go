@@ -0,0 +1,7 @@
+//go:build !ocp_noprehash
+
+package ocp
+
+// PrehashBuild reports whether Ed25519ph signatures are compiled in; false
+// under the ocp_noprehash tag
+const PrehashBuild = true
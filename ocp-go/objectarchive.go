@@ -0,0 +1,204 @@
+// objectarchive.go - Archive backed by cloud object storage
+//
+// ObjectArchive stores evidence in an S3 or GCS bucket. The core module does
+// not import a cloud SDK: operators implement the small ObjectStore interface
+// over the client they already use, and ObjectArchive supplies the protocol
+// rules. Object keys are derived from the content hash, so a key can only ever
+// name one blob. Every write is a conditional put (If-None-Match: *), which
+// makes stored evidence immutable even against a buggy or malicious writer
+// sharing the bucket, and evidence above MultipartThreshold is uploaded in
+// parts. Reads re-hash the object before returning it. A Compressor, if set,
+// shrinks objects without changing their keys (see compress.go).
+
+package ocp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Server-side encryption modes understood by ObjectStore implementations
+const (
+	// SSEStoreManaged encrypts with keys managed by the store (S3 "AES256",
+	// GCS default encryption)
+	SSEStoreManaged = "AES256"
+	// SSEKMS encrypts with a customer-managed KMS key named by KeyID
+	// (S3 "aws:kms", GCS CMEK)
+	SSEKMS = "aws:kms"
+)
+
+// ErrObjectExists is returned by ObjectStore for a conditional put to an
+// existing key
+var ErrObjectExists = &ConstitutionalError{ErrorType: "ArchiveError", Message: "object already exists"}
+
+// ErrObjectNotFound is returned by ObjectStore for a missing key
+var ErrObjectNotFound = &ConstitutionalError{ErrorType: "ArchiveError", Message: "object not found"}
+
+// ServerSideEncryption selects how the store encrypts objects at rest
+type ServerSideEncryption struct {
+	// Mode is SSEStoreManaged, SSEKMS, or "" for the bucket default
+	Mode string
+	// KeyID names the KMS key for SSEKMS
+	KeyID string
+}
+
+// PutObjectOptions are passed to every ObjectStore write
+type PutObjectOptions struct {
+	// IfNoneMatch requests a conditional put that fails with ErrObjectExists
+	// if the key is already present; ObjectArchive always sets it
+	IfNoneMatch bool
+	Encryption  ServerSideEncryption
+	ContentType string
+	// ContentEncoding names the Compressor applied to data, if any
+	ContentEncoding string
+}
+
+// ObjectStore is the subset of an S3 or GCS client ObjectArchive needs
+type ObjectStore interface {
+	// PutObject writes data to key, honoring opts.IfNoneMatch
+	PutObject(key string, data []byte, opts PutObjectOptions) error
+	// GetObject reads key, or returns ErrObjectNotFound
+	GetObject(key string) ([]byte, error)
+	// HeadObject reports whether key exists
+	HeadObject(key string) (bool, error)
+}
+
+// MultipartStore is an ObjectStore that supports multipart uploads. The
+// condition in opts is applied when the upload is completed.
+type MultipartStore interface {
+	ObjectStore
+	CreateMultipartUpload(key string, opts PutObjectOptions) (uploadID string, err error)
+	// UploadPart uploads part number part (from 1) and returns its ETag
+	UploadPart(key, uploadID string, part int, data []byte) (etag string, err error)
+	CompleteMultipartUpload(key, uploadID string, etags []string, opts PutObjectOptions) error
+	AbortMultipartUpload(key, uploadID string) error
+}
+
+// Default multipart settings; S3 requires parts of at least 5 MiB
+const (
+	DefaultMultipartThreshold = 64 << 20
+	DefaultPartSize           = 8 << 20
+)
+
+// ObjectArchive is an Archive storing one object per blob in a bucket
+type ObjectArchive struct {
+	store ObjectStore
+	// Prefix is prepended to every key, e.g. "evidence/"
+	Prefix string
+	// Encryption is requested for every object
+	Encryption ServerSideEncryption
+	// MultipartThreshold is the size above which blobs are uploaded in parts,
+	// when the store is a MultipartStore
+	MultipartThreshold int
+	// PartSize is the size of each uploaded part
+	PartSize int
+	// Compressor, if set, compresses blobs before upload. Objects stored
+	// uncompressed remain readable after one is set.
+	Compressor Compressor
+}
+
+// NewObjectArchive creates an archive over store with default multipart settings
+func NewObjectArchive(store ObjectStore, prefix string) *ObjectArchive {
+	return &ObjectArchive{
+		store:              store,
+		Prefix:             prefix,
+		MultipartThreshold: DefaultMultipartThreshold,
+		PartSize:           DefaultPartSize,
+	}
+}
+
+// Key returns the object key of hash. A two-character fan-out directory keeps
+// listings and per-prefix request rates even.
+func (o *ObjectArchive) Key(hash string) string {
+	if len(hash) < 2 {
+		return o.Prefix + hash
+	}
+	return o.Prefix + hash[:2] + "/" + hash
+}
+
+// Put stores data under its hash-derived key with a conditional put. A blob
+// that is already stored is left untouched.
+func (o *ObjectArchive) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+	key := o.Key(hash)
+	opts := PutObjectOptions{IfNoneMatch: true, Encryption: o.Encryption, ContentType: "application/octet-stream"}
+	if o.Encryption.Mode == SSEKMS && o.Encryption.KeyID == "" {
+		return "", NewConstitutionalError("KMS encryption requires a key ID")
+	}
+	if o.Compressor != nil {
+		compressed, err := o.Compressor.Compress(data)
+		if err != nil {
+			return "", err
+		}
+		data, opts.ContentEncoding = compressed, o.Compressor.Name()
+	}
+
+	var err error
+	if mp, ok := o.store.(MultipartStore); ok && o.MultipartThreshold > 0 && len(data) > o.MultipartThreshold {
+		err = o.putMultipart(mp, key, data, opts)
+	} else {
+		err = o.store.PutObject(key, data, opts)
+	}
+	if errors.Is(err, ErrObjectExists) {
+		return hash, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// putMultipart uploads data in PartSize parts, aborting the upload on failure
+func (o *ObjectArchive) putMultipart(store MultipartStore, key string, data []byte, opts PutObjectOptions) error {
+	partSize := o.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	uploadID, err := store.CreateMultipartUpload(key, opts)
+	if err != nil {
+		return err
+	}
+	var etags []string
+	for offset, part := 0, 1; offset < len(data); offset, part = offset+partSize, part+1 {
+		end := offset + partSize
+		if end > len(data) {
+			end = len(data)
+		}
+		etag, err := store.UploadPart(key, uploadID, part, data[offset:end])
+		if err != nil {
+			store.AbortMultipartUpload(key, uploadID)
+			return err
+		}
+		etags = append(etags, etag)
+	}
+	if err := store.CompleteMultipartUpload(key, uploadID, etags, opts); err != nil {
+		store.AbortMultipartUpload(key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// Get reads the object stored under hash and checks it against its key
+func (o *ObjectArchive) Get(hash string) ([]byte, error) {
+	data, err := o.store.GetObject(o.Key(hash))
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, ErrNotArchived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ContentHash(data) == hash {
+		return data, nil
+	}
+	if o.Compressor != nil {
+		if plain, err := o.Compressor.Decompress(data); err == nil && ContentHash(plain) == hash {
+			return plain, nil
+		}
+	}
+	return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s is corrupted", hash))
+}
+
+// Has reports whether hash is stored
+func (o *ObjectArchive) Has(hash string) (bool, error) {
+	return o.store.HeadObject(o.Key(hash))
+}
@@ -0,0 +1,132 @@
+package ocp
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// memoryBucket is an in-memory MultipartStore with S3 conditional-put semantics
+type memoryBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	opts    map[string]PutObjectOptions
+	uploads map[string][][]byte
+	parts   int
+}
+
+func newMemoryBucket() *memoryBucket {
+	return &memoryBucket{
+		objects: make(map[string][]byte),
+		opts:    make(map[string]PutObjectOptions),
+		uploads: make(map[string][][]byte),
+	}
+}
+
+func (b *memoryBucket) PutObject(key string, data []byte, opts PutObjectOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.objects[key]; ok && opts.IfNoneMatch {
+		return ErrObjectExists
+	}
+	b.objects[key], b.opts[key] = append([]byte(nil), data...), opts
+	return nil
+}
+
+func (b *memoryBucket) GetObject(key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (b *memoryBucket) HeadObject(key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.objects[key]
+	return ok, nil
+}
+
+func (b *memoryBucket) CreateMultipartUpload(key string, opts PutObjectOptions) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := fmt.Sprintf("upload-%d", len(b.uploads))
+	b.uploads[id] = nil
+	return id, nil
+}
+
+func (b *memoryBucket) UploadPart(key, uploadID string, part int, data []byte) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads[uploadID] = append(b.uploads[uploadID], append([]byte(nil), data...))
+	b.parts++
+	return fmt.Sprintf("etag-%d", part), nil
+}
+
+func (b *memoryBucket) CompleteMultipartUpload(key, uploadID string, etags []string, opts PutObjectOptions) error {
+	b.mu.Lock()
+	parts := b.uploads[uploadID]
+	delete(b.uploads, uploadID)
+	b.mu.Unlock()
+	return b.PutObject(key, bytes.Join(parts, nil), opts)
+}
+
+func (b *memoryBucket) AbortMultipartUpload(key, uploadID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.uploads, uploadID)
+	return nil
+}
+
+// TestObjectArchive tests hash-derived keys, conditional puts, and multipart uploads
+func TestObjectArchive(t *testing.T) {
+	bucket := newMemoryBucket()
+	archive := NewObjectArchive(bucket, "evidence/")
+	archive.Encryption = ServerSideEncryption{Mode: SSEKMS, KeyID: "alias/ocp-evidence"}
+
+	hash, err := ArchiveObject(archive, map[string]interface{}{"article": "III.1"})
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+	key := "evidence/" + hash[:2] + "/" + hash
+	if bucket.opts[key].Encryption.KeyID != "alias/ocp-evidence" || !bucket.opts[key].IfNoneMatch {
+		t.Errorf("Unexpected put options: %+v", bucket.opts[key])
+	}
+	if again, err := ArchiveObject(archive, map[string]interface{}{"article": "III.1"}); err != nil || again != hash {
+		t.Errorf("Re-archiving should be a no-op: %v", err)
+	}
+	if obj, err := LoadObject(archive, hash); err != nil || obj["article"] != "III.1" {
+		t.Fatalf("Failed to load: %v %v", obj, err)
+	}
+
+	large := bytes.Repeat([]byte("evidence "), 1000)
+	archive.MultipartThreshold, archive.PartSize = 1024, 1024
+	largeHash, err := archive.Put(large)
+	if err != nil {
+		t.Fatalf("Failed multipart put: %v", err)
+	}
+	if bucket.parts != 9 {
+		t.Errorf("Expected 9 parts, uploaded %d", bucket.parts)
+	}
+	if data, err := archive.Get(largeHash); err != nil || !bytes.Equal(data, large) {
+		t.Errorf("Multipart blob did not round trip: %v", err)
+	}
+
+	bucket.objects[key] = []byte(`{"article":"IV.2"}`)
+	if _, err := archive.Get(hash); err == nil {
+		t.Errorf("A tampered object should fail verification")
+	}
+	if _, err := archive.Get(ContentHash([]byte("missing"))); err != ErrNotArchived {
+		t.Errorf("Expected ErrNotArchived, got %v", err)
+	}
+
+	archive.Encryption = ServerSideEncryption{Mode: SSEKMS}
+	if _, err := archive.Put([]byte("x")); err == nil {
+		t.Errorf("KMS encryption without a key should be rejected")
+	}
+	t.Log("✓ Object archive wrote immutable, encrypted, hash-keyed objects")
+}
@@ -0,0 +1,201 @@
+// ocp.go - Top-level package for the Optimistic Constitutional Protocol (OCP)
+//
+// Package ocp is the importable Go implementation of OCP. The canonicalization
+// engine, semantic hashing, and proposal types live in the canonical, hashing,
+// and proposal subpackages; the names below re-export them so code written
+// against the original single-package reference implementation keeps compiling.
+
+package ocp
+
+import (
+	"io"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+	"github.com/seanrugg/ai_constitution/ocp-go/proposal"
+)
+
+// Version is the semantic version of this module. Releases are tagged
+// ocp-go/vX.Y.Z so that `go get github.com/seanrugg/ai_constitution/ocp-go@vX.Y.Z` resolves.
+const Version = "0.1.0"
+
+// Constants
+const (
+	HashAlgorithm = hashing.HashAlgorithm
+	Encoding      = canonical.Encoding
+)
+
+// ConstitutionalError represents errors in the constitutional protocol
+type ConstitutionalError = canonical.ConstitutionalError
+
+// ContractProposal represents an OCP contract proposal
+type ContractProposal = proposal.ContractProposal
+
+// EvidenceRef is a typed entry of a proposal's evidence list
+type EvidenceRef = proposal.EvidenceRef
+
+// Reasoning is the typed form of a proposal's reasoning object
+type Reasoning = proposal.Reasoning
+
+// Decimal is an exact fixed-point amount, canonicalized as a string
+type Decimal = canonical.Decimal
+
+// CapabilityError reports an operation that needs a feature this build omits
+type CapabilityError = canonical.CapabilityError
+
+// PathIndex maps JSON paths to subtree hashes
+type PathIndex = hashing.PathIndex
+
+// NewConstitutionalError creates a new ConstitutionalError
+func NewConstitutionalError(message string) *ConstitutionalError {
+	return canonical.NewConstitutionalError(message)
+}
+
+// NewCanonicalizationError creates a ConstitutionalError for canonicalization failures
+func NewCanonicalizationError(message string) error {
+	return canonical.NewCanonicalizationError(message)
+}
+
+// DeepSort recursively sorts all maps by keys and sorts arrays where appropriate.
+// See canonical.DeepSort.
+func DeepSort(obj interface{}) interface{} {
+	return canonical.DeepSort(obj)
+}
+
+// Canonicalize converts a map to a deterministically ordered, canonical JSON string.
+// See canonical.Canonicalize.
+func Canonicalize(data map[string]interface{}, strict bool) (string, error) {
+	return canonical.Canonicalize(data, strict)
+}
+
+// CanonicalizeValue converts any JSON-compatible value to its canonical JSON string.
+// See canonical.CanonicalizeValue.
+func CanonicalizeValue(value interface{}) (string, error) {
+	return canonical.CanonicalizeValue(value)
+}
+
+// DecodeStrict parses raw JSON, rejecting duplicate keys and lone surrogates.
+// See canonical.DecodeStrict.
+func DecodeStrict(data []byte, opts ...canonical.DecodeOption) (map[string]interface{}, error) {
+	return canonical.DecodeStrict(data, opts...)
+}
+
+// CanonicalizeJSON strictly decodes raw JSON text and canonicalizes it.
+// See canonical.CanonicalizeJSON.
+func CanonicalizeJSON(data []byte, opts ...canonical.DecodeOption) (string, error) {
+	return canonical.CanonicalizeJSON(data, opts...)
+}
+
+// EncodeFramed writes objects as a length-prefixed, digest-checked stream.
+// See canonical.EncodeFramed.
+func EncodeFramed(w io.Writer, objects ...map[string]interface{}) error {
+	return canonical.EncodeFramed(w, objects...)
+}
+
+// DecodeFramed reads a stream written by EncodeFramed.
+// See canonical.DecodeFramed.
+func DecodeFramed(r io.Reader) ([]map[string]interface{}, error) {
+	return canonical.DecodeFramed(r)
+}
+
+// CanonicalDiff lists the path-level differences between two canonical values.
+// See canonical.CanonicalDiff.
+func CanonicalDiff(old, new interface{}) ([]canonical.Difference, error) {
+	return canonical.CanonicalDiff(old, new)
+}
+
+// CanonicalPatch returns a binary patch from one canonical form to another.
+// See canonical.CanonicalPatch.
+func CanonicalPatch(oldCanonical, newCanonical []byte) []byte {
+	return canonical.CanonicalPatch(oldCanonical, newCanonical)
+}
+
+// ApplyCanonicalPatch applies a patch and verifies the result's hash.
+// See canonical.ApplyCanonicalPatch.
+func ApplyCanonicalPatch(oldCanonical, patch []byte) ([]byte, error) {
+	return canonical.ApplyCanonicalPatch(oldCanonical, patch)
+}
+
+// CanonicalSpec describes the byte-level rules of the default canonicalizer.
+// See canonical.CanonicalSpec.
+func CanonicalSpec() canonical.Spec {
+	return canonical.CanonicalSpec()
+}
+
+// ParseDecimal parses a decimal amount string.
+// See canonical.ParseDecimal.
+func ParseDecimal(s string) (Decimal, error) {
+	return canonical.ParseDecimal(s)
+}
+
+// DecimalFromInt returns n as a Decimal.
+// See canonical.DecimalFromInt.
+func DecimalFromInt(n int64) Decimal {
+	return canonical.DecimalFromInt(n)
+}
+
+// EncodeBytes returns the canonical string form of a byte slice.
+// See canonical.EncodeBytes.
+func EncodeBytes(b []byte) string {
+	return canonical.EncodeBytes(b)
+}
+
+// CanonicalizeAudited canonicalizes value and records every type coercion.
+// See canonical.CanonicalizeAudited.
+func CanonicalizeAudited(value interface{}) (*canonical.AuditResult, error) {
+	return canonical.CanonicalizeAudited(value)
+}
+
+// SemanticHash calculates the cryptographic hash of canonicalized data.
+// See hashing.SemanticHash.
+func SemanticHash(data map[string]interface{}) (string, error) {
+	return hashing.SemanticHash(data)
+}
+
+// ValueHash calculates the hash of the canonical form of any JSON-compatible value.
+// See hashing.ValueHash.
+func ValueHash(value interface{}) (string, error) {
+	return hashing.ValueHash(value)
+}
+
+// VerifySemanticHash verifies that data produces the expected semantic hash.
+// See hashing.VerifySemanticHash.
+func VerifySemanticHash(data map[string]interface{}, expectedHash string) (bool, error) {
+	return hashing.VerifySemanticHash(data, expectedHash)
+}
+
+// BuildPathIndex hashes every subtree of data for partial verification.
+// See hashing.BuildPathIndex.
+func BuildPathIndex(data map[string]interface{}) (PathIndex, error) {
+	return hashing.BuildPathIndex(data)
+}
+
+// VerifyPath checks a single field against a path index.
+// See hashing.VerifyPath.
+func VerifyPath(index PathIndex, path string, value interface{}) (bool, error) {
+	return hashing.VerifyPath(index, path, value)
+}
+
+// HashableOf wraps value with Hash, Verify, and Canonical methods.
+// See hashing.Hashable.
+func HashableOf[T any](value T) hashing.Hashable[T] {
+	return hashing.Of(value)
+}
+
+// NewSemanticHasher returns a hash.Hash computing the semantic hash of the
+// JSON object written to it. See hashing.SemanticHasher.
+func NewSemanticHasher() *hashing.SemanticHasher {
+	return hashing.NewSemanticHasher()
+}
+
+// CanonicallyEqual compares two maps for canonical equality.
+// See hashing.CanonicallyEqual.
+func CanonicallyEqual(data1, data2 map[string]interface{}) bool {
+	return hashing.CanonicallyEqual(data1, data2)
+}
+
+// ParseReasoning reads a proposal's reasoning map into typed form.
+// See proposal.ParseReasoning.
+func ParseReasoning(m map[string]interface{}) (*Reasoning, error) {
+	return proposal.ParseReasoning(m)
+}
@@ -0,0 +1,70 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// TestReExports tests that the top-level names match the subpackages they wrap
+func TestReExports(t *testing.T) {
+	data := map[string]interface{}{
+		"z":      []interface{}{float64(3), float64(1)},
+		"action": "propose",
+	}
+
+	direct, err := canonical.Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	reexported, err := Canonicalize(data, true)
+	if err != nil {
+		t.Fatalf("Failed to canonicalize: %v", err)
+	}
+	if direct != reexported {
+		t.Errorf("Re-exported Canonicalize differs:\n  %s\n  %s", direct, reexported)
+	}
+
+	directHash, _ := hashing.SemanticHash(data)
+	reexportedHash, _ := SemanticHash(data)
+	if directHash != reexportedHash {
+		t.Errorf("Re-exported SemanticHash differs")
+	}
+
+	proposal := &ContractProposal{ID: "p-1", ProposerAgent: "Claude"}
+	if _, err := proposal.GetHash(); err != nil {
+		t.Errorf("ContractProposal alias should keep its methods: %v", err)
+	}
+
+	p := testProposal()
+	index, err := BuildPathIndex(p.ToMap())
+	if err != nil {
+		t.Fatalf("Failed to build path index: %v", err)
+	}
+	if hash, _ := p.GetHash(); index.Root() != hash {
+		t.Errorf("Path index root should be the proposal hash")
+	}
+	if ok, _ := VerifyPath(index, "evidence[0].pointer", "sha256:abc123def456"); !ok {
+		t.Errorf("Indexed evidence pointer should verify")
+	}
+
+	if spec := CanonicalSpec(); spec.Version != "1.0.0+key_order=utf8;nulls=keep" {
+		t.Errorf("Unexpected default spec version %s", spec.Version)
+	}
+
+	if audit, err := CanonicalizeAudited(data); err != nil || audit.Canonical != direct || len(audit.Coercions) != 0 {
+		t.Errorf("Re-exported CanonicalizeAudited differs: %+v, %v", audit, err)
+	}
+
+	if got := EncodeBytes([]byte{0x01, 0xfe, 0xff}); got != canonical.EncodeBytes([]byte{0x01, 0xfe, 0xff}) {
+		t.Errorf("Re-exported EncodeBytes differs: %s", got)
+	}
+
+	var err2 error = NewCanonicalizationError("bad input")
+	if _, ok := err2.(*ConstitutionalError); !ok {
+		t.Errorf("Errors should remain *ConstitutionalError")
+	}
+
+	t.Logf("✓ Re-exports match subpackages (module version %s)", Version)
+}
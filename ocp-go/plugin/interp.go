@@ -0,0 +1,547 @@
+// interp.go - Metered interpreter for decoded modules
+//
+// Every instruction costs one unit of fuel, and growing memory costs one unit
+// per page added on top of that. A call fails with a Trap once fuel runs
+// out, memory would pass its page limit, or calls nest deeper than the call
+// limit. Since the interpreter has no floating point, no clock, no
+// randomness, and no host calls beyond those in hostFunctions, the same
+// module, input, and Limits produce the same result on every node, including
+// where exactly it runs out of fuel.
+
+package plugin
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Opcodes
+const (
+	opUnreachable byte = 0x00
+	opNop         byte = 0x01
+	opBlock       byte = 0x02
+	opLoop        byte = 0x03
+	opIf          byte = 0x04
+	opElse        byte = 0x05
+	opEnd         byte = 0x0B
+	opBr          byte = 0x0C
+	opBrIf        byte = 0x0D
+	opBrTable     byte = 0x0E
+	opReturn      byte = 0x0F
+	opCall        byte = 0x10
+	opDrop        byte = 0x1A
+	opSelect      byte = 0x1B
+	opLocalGet    byte = 0x20
+	opLocalSet    byte = 0x21
+	opLocalTee    byte = 0x22
+	opGlobalGet   byte = 0x23
+	opGlobalSet   byte = 0x24
+	opMemorySize  byte = 0x3F
+	opMemoryGrow  byte = 0x40
+	opI32Const    byte = 0x41
+	opI64Const    byte = 0x42
+)
+
+// Trap is a run-time failure of a plugin
+type Trap struct {
+	Reason string
+}
+
+func (t *Trap) Error() string {
+	return "wasm trap: " + t.Reason
+}
+
+// label is an open block
+type label struct {
+	height int
+	arity  int
+	// end is the index of the block's end; loop is the index of a loop's
+	// loop instruction, or -1
+	end, loop int
+}
+
+// instance is one instantiation of a module
+type instance struct {
+	m        *module
+	mem      []byte
+	maxPages uint32
+	globals  []uint64
+	stack    []uint64
+	fuel     uint64
+	depth    int
+	limits   Limits
+	// host is the state exposed to hostFunctions
+	host *callState
+}
+
+// newInstance instantiates m with fresh memory and globals
+func newInstance(m *module, limits Limits, host *callState) *instance {
+	inst := &instance{m: m, maxPages: m.memMax, fuel: limits.Fuel, limits: limits, host: host}
+	if m.hasMemory {
+		inst.mem = make([]byte, int(m.memMin)*pageSize)
+		for _, d := range m.data {
+			copy(inst.mem[d.offset:], d.data)
+		}
+	}
+	inst.globals = make([]uint64, len(m.globals))
+	for i, g := range m.globals {
+		inst.globals[i] = g.init
+	}
+	return inst
+}
+
+func trap(format string, args ...interface{}) {
+	panic(&Trap{Reason: fmt.Sprintf(format, args...)})
+}
+
+// invoke calls function index fn with args, converting traps and malformed
+// code into a returned *Trap
+func (inst *instance) invoke(fn uint32, args ...uint64) (result []uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			t, ok := r.(*Trap)
+			if !ok {
+				// Code that passed decoding but misuses the stack or locals
+				// lands here; it is the module's fault, not the host's
+				t = &Trap{Reason: fmt.Sprintf("invalid code: %v", r)}
+			}
+			result, err = nil, t
+		}
+	}()
+	typ, ok := inst.m.funcType(fn)
+	if !ok || len(args) != len(typ.params) {
+		return nil, &Trap{Reason: "bad call"}
+	}
+	inst.stack = append(inst.stack[:0], args...)
+	inst.call(fn)
+	return append([]uint64(nil), inst.stack[len(inst.stack)-len(typ.results):]...), nil
+}
+
+func (inst *instance) push(v uint64) {
+	if len(inst.stack) >= inst.limits.StackSize {
+		trap("value stack exhausted")
+	}
+	inst.stack = append(inst.stack, v)
+}
+
+func (inst *instance) pop() uint64 {
+	n := len(inst.stack) - 1
+	v := inst.stack[n]
+	inst.stack = inst.stack[:n]
+	return v
+}
+
+func (inst *instance) pop32() uint32 {
+	return uint32(inst.pop())
+}
+
+func (inst *instance) push32(v uint32) {
+	inst.push(uint64(v))
+}
+
+func (inst *instance) pushBool(b bool) {
+	if b {
+		inst.push(1)
+	} else {
+		inst.push(0)
+	}
+}
+
+// call runs function fn, taking its arguments from and leaving its results on
+// the value stack
+func (inst *instance) call(fn uint32) {
+	if int(fn) < len(inst.m.imports) {
+		inst.callHost(inst.m.imports[fn])
+		return
+	}
+	inst.depth++
+	if inst.depth > inst.limits.CallDepth {
+		trap("call depth exceeded")
+	}
+	f := &inst.m.funcs[int(fn)-len(inst.m.imports)]
+
+	nparams := len(f.typ.params)
+	locals := make([]uint64, nparams+len(f.locals))
+	copy(locals, inst.stack[len(inst.stack)-nparams:])
+	inst.stack = inst.stack[:len(inst.stack)-nparams]
+
+	base := len(inst.stack)
+	labels := []label{{height: base, arity: len(f.typ.results), end: len(f.code) - 1, loop: -1}}
+	code := f.code
+
+	// branch leaves the block at depth, keeping its results
+	branch := func(depth uint64, pc *int) {
+		l := labels[len(labels)-1-int(depth)]
+		if l.loop >= 0 {
+			inst.stack = inst.stack[:l.height]
+			labels = labels[:len(labels)-int(depth)]
+			*pc = l.loop + 1
+			return
+		}
+		results := inst.stack[len(inst.stack)-l.arity:]
+		inst.stack = append(inst.stack[:l.height], results...)
+		labels = labels[:len(labels)-1-int(depth)]
+		*pc = l.end + 1
+	}
+
+	for pc := 0; pc < len(code) && len(labels) > 0; {
+		in := &code[pc]
+		if inst.fuel == 0 {
+			trap("fuel exhausted")
+		}
+		inst.fuel--
+
+		switch in.op {
+		case opUnreachable:
+			trap("unreachable executed")
+		case opNop:
+		case opBlock:
+			labels = append(labels, label{height: len(inst.stack), arity: int(in.a), end: in.end, loop: -1})
+		case opLoop:
+			labels = append(labels, label{height: len(inst.stack), end: in.end, loop: pc})
+		case opIf:
+			cond := inst.pop32()
+			switch {
+			case cond != 0:
+				labels = append(labels, label{height: len(inst.stack), arity: int(in.a), end: in.end, loop: -1})
+			case in.els >= 0:
+				labels = append(labels, label{height: len(inst.stack), arity: int(in.a), end: in.end, loop: -1})
+				pc = in.els
+			default:
+				pc = in.end
+			}
+		case opElse:
+			// Reached at the end of the then-branch
+			branch(0, &pc)
+			continue
+		case opEnd:
+			labels = labels[:len(labels)-1]
+		case opBr:
+			branch(in.a, &pc)
+			continue
+		case opBrIf:
+			if inst.pop32() != 0 {
+				branch(in.a, &pc)
+				continue
+			}
+		case opBrTable:
+			i := inst.pop32()
+			depth := in.table[len(in.table)-1]
+			if int(i) < len(in.table)-1 {
+				depth = in.table[i]
+			}
+			branch(uint64(depth), &pc)
+			continue
+		case opReturn:
+			branch(uint64(len(labels)-1), &pc)
+			continue
+		case opCall:
+			inst.call(uint32(in.a))
+		case opDrop:
+			inst.pop()
+		case opSelect:
+			cond := inst.pop32()
+			b, a := inst.pop(), inst.pop()
+			if cond != 0 {
+				inst.push(a)
+			} else {
+				inst.push(b)
+			}
+		case opLocalGet:
+			inst.push(locals[in.a])
+		case opLocalSet:
+			locals[in.a] = inst.pop()
+		case opLocalTee:
+			locals[in.a] = inst.stack[len(inst.stack)-1]
+		case opGlobalGet:
+			inst.push(inst.globals[in.a])
+		case opGlobalSet:
+			inst.globals[in.a] = inst.pop()
+		case opMemorySize:
+			inst.push32(uint32(len(inst.mem) / pageSize))
+		case opMemoryGrow:
+			inst.push32(inst.grow(inst.pop32()))
+		case opI32Const, opI64Const:
+			inst.push(in.a)
+		default:
+			if in.op >= 0x28 && in.op <= 0x3E {
+				inst.memory(in.op, in.a)
+			} else {
+				inst.numeric(in.op)
+			}
+		}
+		pc++
+	}
+	inst.depth--
+
+	typ := f.typ
+	results := inst.stack[len(inst.stack)-len(typ.results):]
+	inst.stack = append(inst.stack[:base], results...)
+}
+
+// grow adds pages to memory, returning the old page count or 0xFFFFFFFF
+func (inst *instance) grow(pages uint32) uint32 {
+	old := uint32(len(inst.mem) / pageSize)
+	if uint64(old)+uint64(pages) > uint64(inst.maxPages) {
+		return 0xFFFFFFFF
+	}
+	if inst.fuel < uint64(pages) {
+		trap("fuel exhausted")
+	}
+	inst.fuel -= uint64(pages)
+	inst.mem = append(inst.mem, make([]byte, int(pages)*pageSize)...)
+	return old
+}
+
+// addr returns the effective address of an access of size bytes
+func (inst *instance) addr(offset uint64, size int) int {
+	ea := uint64(inst.pop32()) + offset
+	if ea+uint64(size) > uint64(len(inst.mem)) {
+		trap("memory access out of bounds")
+	}
+	return int(ea)
+}
+
+// memory executes a load or store
+func (inst *instance) memory(op byte, offset uint64) {
+	le := binary.LittleEndian
+	switch op {
+	case 0x28: // i32.load
+		a := inst.addr(offset, 4)
+		inst.push32(le.Uint32(inst.mem[a:]))
+	case 0x29: // i64.load
+		a := inst.addr(offset, 8)
+		inst.push(le.Uint64(inst.mem[a:]))
+	case 0x2C: // i32.load8_s
+		a := inst.addr(offset, 1)
+		inst.push32(uint32(int32(int8(inst.mem[a]))))
+	case 0x2D: // i32.load8_u
+		a := inst.addr(offset, 1)
+		inst.push32(uint32(inst.mem[a]))
+	case 0x2E: // i32.load16_s
+		a := inst.addr(offset, 2)
+		inst.push32(uint32(int32(int16(le.Uint16(inst.mem[a:])))))
+	case 0x2F: // i32.load16_u
+		a := inst.addr(offset, 2)
+		inst.push32(uint32(le.Uint16(inst.mem[a:])))
+	case 0x30: // i64.load8_s
+		a := inst.addr(offset, 1)
+		inst.push(uint64(int64(int8(inst.mem[a]))))
+	case 0x31: // i64.load8_u
+		a := inst.addr(offset, 1)
+		inst.push(uint64(inst.mem[a]))
+	case 0x32: // i64.load16_s
+		a := inst.addr(offset, 2)
+		inst.push(uint64(int64(int16(le.Uint16(inst.mem[a:])))))
+	case 0x33: // i64.load16_u
+		a := inst.addr(offset, 2)
+		inst.push(uint64(le.Uint16(inst.mem[a:])))
+	case 0x34: // i64.load32_s
+		a := inst.addr(offset, 4)
+		inst.push(uint64(int64(int32(le.Uint32(inst.mem[a:])))))
+	case 0x35: // i64.load32_u
+		a := inst.addr(offset, 4)
+		inst.push(uint64(le.Uint32(inst.mem[a:])))
+	case 0x36: // i32.store
+		v := inst.pop32()
+		le.PutUint32(inst.mem[inst.addr(offset, 4):], v)
+	case 0x37: // i64.store
+		v := inst.pop()
+		le.PutUint64(inst.mem[inst.addr(offset, 8):], v)
+	case 0x3A, 0x3C: // i32.store8, i64.store8
+		v := inst.pop()
+		inst.mem[inst.addr(offset, 1)] = byte(v)
+	case 0x3B, 0x3D: // i32.store16, i64.store16
+		v := inst.pop()
+		le.PutUint16(inst.mem[inst.addr(offset, 2):], uint16(v))
+	case 0x3E: // i64.store32
+		v := inst.pop()
+		le.PutUint32(inst.mem[inst.addr(offset, 4):], uint32(v))
+	}
+}
+
+// numeric executes a comparison, arithmetic, or conversion instruction
+func (inst *instance) numeric(op byte) {
+	switch {
+	case op == 0x45: // i32.eqz
+		inst.pushBool(inst.pop32() == 0)
+	case op >= 0x46 && op <= 0x4F:
+		b, a := inst.pop32(), inst.pop32()
+		inst.pushBool(compare(op-0x46, uint64(a), uint64(b), int64(int32(a)), int64(int32(b))))
+	case op == 0x50: // i64.eqz
+		inst.pushBool(inst.pop() == 0)
+	case op >= 0x51 && op <= 0x5A:
+		b, a := inst.pop(), inst.pop()
+		inst.pushBool(compare(op-0x51, a, b, int64(a), int64(b)))
+	case op == 0x67: // i32.clz
+		inst.push32(uint32(bits.LeadingZeros32(inst.pop32())))
+	case op == 0x68: // i32.ctz
+		inst.push32(uint32(bits.TrailingZeros32(inst.pop32())))
+	case op == 0x69: // i32.popcnt
+		inst.push32(uint32(bits.OnesCount32(inst.pop32())))
+	case op >= 0x6A && op <= 0x78:
+		b, a := inst.pop32(), inst.pop32()
+		inst.push32(binop32(op, a, b))
+	case op == 0x79: // i64.clz
+		inst.push(uint64(bits.LeadingZeros64(inst.pop())))
+	case op == 0x7A: // i64.ctz
+		inst.push(uint64(bits.TrailingZeros64(inst.pop())))
+	case op == 0x7B: // i64.popcnt
+		inst.push(uint64(bits.OnesCount64(inst.pop())))
+	case op >= 0x7C && op <= 0x8A:
+		b, a := inst.pop(), inst.pop()
+		inst.push(binop64(op, a, b))
+	case op == 0xA7: // i32.wrap_i64
+		inst.push32(uint32(inst.pop()))
+	case op == 0xAC: // i64.extend_i32_s
+		inst.push(uint64(int64(int32(inst.pop32()))))
+	case op == 0xAD: // i64.extend_i32_u
+		inst.push(uint64(inst.pop32()))
+	case op == 0xC0: // i32.extend8_s
+		inst.push32(uint32(int32(int8(inst.pop32()))))
+	case op == 0xC1: // i32.extend16_s
+		inst.push32(uint32(int32(int16(inst.pop32()))))
+	case op == 0xC2: // i64.extend8_s
+		inst.push(uint64(int64(int8(inst.pop()))))
+	case op == 0xC3: // i64.extend16_s
+		inst.push(uint64(int64(int16(inst.pop()))))
+	case op == 0xC4: // i64.extend32_s
+		inst.push(uint64(int64(int32(inst.pop()))))
+	default:
+		trap("instruction 0x%02x not supported", op)
+	}
+}
+
+// compare evaluates the i-th comparison of the eq, ne, lt_s, lt_u, gt_s,
+// gt_u, le_s, le_u, ge_s, ge_u sequence shared by i32 and i64
+func compare(i byte, ua, ub uint64, sa, sb int64) bool {
+	switch i {
+	case 0:
+		return ua == ub
+	case 1:
+		return ua != ub
+	case 2:
+		return sa < sb
+	case 3:
+		return ua < ub
+	case 4:
+		return sa > sb
+	case 5:
+		return ua > ub
+	case 6:
+		return sa <= sb
+	case 7:
+		return ua <= ub
+	case 8:
+		return sa >= sb
+	default:
+		return ua >= ub
+	}
+}
+
+func binop32(op byte, a, b uint32) uint32 {
+	switch op {
+	case 0x6A:
+		return a + b
+	case 0x6B:
+		return a - b
+	case 0x6C:
+		return a * b
+	case 0x6D: // div_s
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int32(a) == -1<<31 && int32(b) == -1 {
+			trap("integer overflow")
+		}
+		return uint32(int32(a) / int32(b))
+	case 0x6E: // div_u
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a / b
+	case 0x6F: // rem_s
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int32(b) == -1 {
+			return 0
+		}
+		return uint32(int32(a) % int32(b))
+	case 0x70: // rem_u
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a % b
+	case 0x71:
+		return a & b
+	case 0x72:
+		return a | b
+	case 0x73:
+		return a ^ b
+	case 0x74:
+		return a << (b & 31)
+	case 0x75:
+		return uint32(int32(a) >> (b & 31))
+	case 0x76:
+		return a >> (b & 31)
+	case 0x77:
+		return bits.RotateLeft32(a, int(b&31))
+	default: // rotr
+		return bits.RotateLeft32(a, -int(b&31))
+	}
+}
+
+func binop64(op byte, a, b uint64) uint64 {
+	switch op {
+	case 0x7C:
+		return a + b
+	case 0x7D:
+		return a - b
+	case 0x7E:
+		return a * b
+	case 0x7F: // div_s
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int64(a) == -1<<63 && int64(b) == -1 {
+			trap("integer overflow")
+		}
+		return uint64(int64(a) / int64(b))
+	case 0x80: // div_u
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a / b
+	case 0x81: // rem_s
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		if int64(b) == -1 {
+			return 0
+		}
+		return uint64(int64(a) % int64(b))
+	case 0x82: // rem_u
+		if b == 0 {
+			trap("integer divide by zero")
+		}
+		return a % b
+	case 0x83:
+		return a & b
+	case 0x84:
+		return a | b
+	case 0x85:
+		return a ^ b
+	case 0x86:
+		return a << (b & 63)
+	case 0x87:
+		return uint64(int64(a) >> (b & 63))
+	case 0x88:
+		return a >> (b & 63)
+	case 0x89:
+		return bits.RotateLeft64(a, int(b&63))
+	default: // rotr
+		return bits.RotateLeft64(a, -int(b&63))
+	}
+}
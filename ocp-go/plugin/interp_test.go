@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testLimits = Limits{Fuel: 100000, MemoryPages: 4, CallDepth: 64, StackSize: 1024}
+
+// run decodes data and calls its function 0 with args
+func run(t *testing.T, data []byte, limits Limits, args ...uint64) ([]uint64, error) {
+	t.Helper()
+	m, err := decodeModule(data, limits.MemoryPages)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	return newInstance(m, limits, &callState{}).invoke(0, args...)
+}
+
+// TestArithmetic tests integer semantics at the edges
+func TestArithmetic(t *testing.T) {
+	cases := []struct {
+		name string
+		code [][]byte
+		want uint64
+	}{
+		{"i32 wraps", [][]byte{i32c(-1), i32c(2), {0x6A}}, 1},
+		{"i32 div_s", [][]byte{i32c(-7), i32c(2), {0x6D}}, uint64(uint32(0xFFFFFFFD))},
+		{"i32 rem_s", [][]byte{i32c(-7), i32c(2), {0x6F}}, uint64(uint32(0xFFFFFFFF))},
+		{"i32 shr_s", [][]byte{i32c(-8), i32c(33), {0x75}}, uint64(uint32(0xFFFFFFFC))},
+		{"i32 rotl", [][]byte{i32c(-0x80000000), i32c(1), {0x77}}, 1},
+		{"i32 lt_u", [][]byte{i32c(-1), i32c(1), {0x49}}, 0},
+		{"i32 lt_s", [][]byte{i32c(-1), i32c(1), {0x48}}, 1},
+		{"i32 clz", [][]byte{i32c(1), {0x67}}, 31},
+		{"i64 mul", [][]byte{i64c(1 << 40), i64c(1 << 30), {0x7E}, {0xA7}}, 0},
+		{"i64 extend_s", [][]byte{i32c(-1), {0xAC}, i64c(32), {0x88}, {0xA7}}, uint64(uint32(0xFFFFFFFF))},
+		{"extend8_s", [][]byte{i32c(0x80), {0xC0}}, uint64(uint32(0xFFFFFF80))},
+		{"select", [][]byte{i32c(10), i32c(20), i32c(0), {opSelect}}, 20},
+	}
+	for _, c := range cases {
+		res, err := run(t, single(nil, []byte{typeI32}, nil, c.code...), testLimits)
+		if err != nil || res[0] != c.want {
+			t.Errorf("%s: got %v, %v; want %d", c.name, res, err, c.want)
+		}
+	}
+
+	t.Log("✓ Integer arithmetic follows WebAssembly semantics")
+}
+
+// TestControlFlow tests loops, branches, and calls
+func TestControlFlow(t *testing.T) {
+	// Sum 1..n with a loop
+	sum := single([]byte{typeI32}, []byte{typeI32}, []byte{typeI32},
+		[]byte{opBlock, 0x40, opLoop, 0x40},
+		get(0), []byte{0x45, opBrIf, 1},
+		get(1), get(0), []byte{0x6A, opLocalSet, 1},
+		get(0), i32c(1), []byte{0x6B, opLocalSet, 0},
+		[]byte{opBr, 0, opEnd, opEnd},
+		get(1))
+	if res, err := run(t, sum, testLimits, 100); err != nil || res[0] != 5050 {
+		t.Errorf("Loop sum gave %v, %v", res, err)
+	}
+
+	// br_table selects by index, clamping to the default
+	table := single([]byte{typeI32}, []byte{typeI32}, nil,
+		[]byte{opBlock, 0x40, opBlock, 0x40, opBlock, 0x40},
+		get(0), []byte{opBrTable, 2, 0, 1, 2, opEnd},
+		i32c(10), []byte{opReturn, opEnd},
+		i32c(20), []byte{opReturn, opEnd},
+		i32c(30))
+	for arg, want := range map[uint64]uint64{0: 10, 1: 20, 2: 30, 99: 30} {
+		if res, err := run(t, table, testLimits, arg); err != nil || res[0] != want {
+			t.Errorf("br_table(%d) gave %v, %v; want %d", arg, res, err, want)
+		}
+	}
+
+	// Recursive factorial in i64
+	fact := single([]byte{typeI64}, []byte{typeI64}, nil,
+		get(0), []byte{0x50, opIf, typeI64}, i64c(1), []byte{opElse},
+		get(0), get(0), i64c(1), []byte{0x7D, opCall, 0, 0x7E, opEnd})
+	if res, err := run(t, fact, testLimits, 20); err != nil || res[0] != 2432902008176640000 {
+		t.Errorf("Factorial gave %v, %v", res, err)
+	}
+
+	t.Log("✓ Loops, branch tables, and recursion run correctly")
+}
+
+// TestTraps tests that faults and exhausted limits become Traps
+func TestTraps(t *testing.T) {
+	loop := single(nil, nil, nil, []byte{opLoop, 0x40, opBr, 0, opEnd})
+	recurse := single(nil, nil, nil, []byte{opCall, 0})
+	cases := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"divide", single(nil, []byte{typeI32}, nil, i32c(1), i32c(0), []byte{0x6E}), "divide by zero"},
+		{"overflow", single(nil, []byte{typeI32}, nil, i32c(-0x80000000), i32c(-1), []byte{0x6D}), "overflow"},
+		{"unreachable", single(nil, nil, nil, []byte{opUnreachable}), "unreachable"},
+		{"bounds", single(nil, []byte{typeI32}, nil, i32c(65535), []byte{0x28, 2, 0}), "out of bounds"},
+		{"fuel", loop, "fuel exhausted"},
+		{"depth", recurse, "call depth"},
+		{"underflow", single(nil, nil, nil, []byte{opDrop}), "invalid code"},
+	}
+	for _, c := range cases {
+		_, err := run(t, c.data, testLimits)
+		var tr *Trap
+		if !errors.As(err, &tr) || !strings.Contains(tr.Reason, c.reason) {
+			t.Errorf("%s: expected trap %q, got %v", c.name, c.reason, err)
+		}
+	}
+
+	// Memory grows up to the declared maximum and no further
+	grow := single(nil, []byte{typeI32}, nil,
+		i32c(1), []byte{opMemoryGrow, 0, opDrop},
+		i32c(1), []byte{opMemoryGrow, 0})
+	if res, err := run(t, grow, testLimits); err != nil || uint32(res[0]) != 0xFFFFFFFF {
+		t.Errorf("Expected growth past the maximum to fail, got %v, %v", res, err)
+	}
+
+	t.Log("✓ Faults and exhausted limits trap deterministically")
+}
+
+// TestFuelDeterminism tests that a run uses the same fuel every time
+func TestFuelDeterminism(t *testing.T) {
+	loop := single(nil, nil, nil, []byte{opLoop, 0x40, opBr, 0, opEnd})
+	m, err := decodeModule(loop, 4)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	limits := testLimits
+	limits.Fuel = 1001
+	for i := 0; i < 3; i++ {
+		inst := newInstance(m, limits, &callState{})
+		if _, err := inst.invoke(0); err == nil || inst.fuel != 0 {
+			t.Fatalf("Expected the loop to exhaust its fuel")
+		}
+	}
+
+	t.Log("✓ Fuel accounting is deterministic")
+}
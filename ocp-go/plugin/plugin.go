@@ -0,0 +1,280 @@
+// Package plugin hosts custom proposal validators compiled to WebAssembly.
+//
+// A quorum that needs a domain-specific check (say, that evidence of a
+// particular type is well-formed) can ship it as a WebAssembly module instead
+// of forking the node. The host runs each module in a sandbox: a module sees
+// only its own linear memory and the functions of the "ocp" host module, and
+// every run is metered by Limits, so a validator cannot read the node's state,
+// loop forever, or exhaust its memory. Execution is deterministic, so every
+// node with the same module and limits reaches the same verdict.
+//
+// A plugin's identity is the content hash of its module bytes, the same hash
+// the archive uses. A Host only loads modules whose hash has been allowed,
+// which is how the quorum, rather than whoever has file access to a node,
+// decides what extends validation.
+//
+// The module ABI is:
+//
+//   - export "memory": the module's linear memory
+//   - export "alloc" (i32 size) -> i32: returns a pointer to size free bytes
+//   - export "validate" (i32 ptr, i32 len) -> i32: checks the proposal whose
+//     canonical JSON the host wrote at ptr; 0 accepts, anything else rejects
+//   - import "ocp" "fail" (i32 ptr, i32 len): optional; records a UTF-8
+//     rejection message
+//
+// Modules may use the integer subset of WebAssembly 1.0 described in wasm.go.
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// HostModule is the import module name under which host functions are provided
+const HostModule = "ocp"
+
+// MaxMessageBytes caps a rejection message recorded through ocp.fail
+const MaxMessageBytes = 1024
+
+// Limits bounds one validation run
+type Limits struct {
+	// Fuel is the number of instructions a run may execute
+	Fuel uint64
+	// MemoryPages caps linear memory, in 64 KiB pages
+	MemoryPages uint32
+	// CallDepth caps nested calls
+	CallDepth int
+	// StackSize caps the value stack, in values
+	StackSize int
+}
+
+// DefaultLimits are the limits used by NewHost when none are given
+var DefaultLimits = Limits{
+	Fuel:        10_000_000,
+	MemoryPages: 256,
+	CallDepth:   512,
+	StackSize:   64 * 1024,
+}
+
+// LoadError reports a module the host cannot load
+type LoadError struct {
+	Offset int
+	Reason string
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("wasm module invalid at byte %d: %s", e.Offset, e.Reason)
+}
+
+// Rejection is a plugin's verdict against a proposal
+type Rejection struct {
+	Plugin  string
+	Code    uint32
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	if r.Message == "" {
+		return fmt.Sprintf("plugin %s rejected proposal with code %d", r.Plugin, r.Code)
+	}
+	return fmt.Sprintf("plugin %s rejected proposal: %s", r.Plugin, r.Message)
+}
+
+// callState is the host-side state of one validation run
+type callState struct {
+	message string
+}
+
+// hostFunctions are the functions a module may import from HostModule
+var hostFunctions = map[string]funcType{
+	"fail": {params: []byte{typeI32, typeI32}},
+}
+
+// callHost runs an imported host function
+func (inst *instance) callHost(f hostImport) {
+	switch f.name {
+	case "fail":
+		n, ptr := inst.pop32(), inst.pop32()
+		if n > MaxMessageBytes {
+			n = MaxMessageBytes
+		}
+		if uint64(ptr)+uint64(n) > uint64(len(inst.mem)) {
+			trap("fail message out of bounds")
+		}
+		inst.host.message = string(inst.mem[ptr : ptr+n])
+	}
+}
+
+// Plugin is a loaded validator module
+type Plugin struct {
+	id       string
+	m        *module
+	limits   Limits
+	alloc    uint32
+	validate uint32
+}
+
+// Compile decodes a module and checks that it implements the plugin ABI
+//
+// Returns:
+//   - Plugin identified by ocp.ContentHash(module)
+//   - LoadError if the module is malformed, uses unsupported features, or
+//     does not export the ABI
+func Compile(module []byte, limits Limits) (*Plugin, error) {
+	m, err := decodeModule(module, limits.MemoryPages)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := m.exports["memory"]; !ok || e.kind != exportMemory || !m.hasMemory {
+		return nil, &LoadError{Reason: `module must export its memory as "memory"`}
+	}
+	p := &Plugin{id: ocp.ContentHash(module), m: m, limits: limits}
+	abi := []struct {
+		name   string
+		typ    funcType
+		target *uint32
+	}{
+		{"alloc", funcType{params: []byte{typeI32}, results: []byte{typeI32}}, &p.alloc},
+		{"validate", funcType{params: []byte{typeI32, typeI32}, results: []byte{typeI32}}, &p.validate},
+	}
+	for _, f := range abi {
+		e, ok := m.exports[f.name]
+		if !ok || e.kind != exportFunc {
+			return nil, &LoadError{Reason: fmt.Sprintf("module must export function %q", f.name)}
+		}
+		if typ, _ := m.funcType(e.index); !sameType(typ, f.typ) {
+			return nil, &LoadError{Reason: fmt.Sprintf("export %q has the wrong signature", f.name)}
+		}
+		*f.target = e.index
+	}
+	return p, nil
+}
+
+// ID returns the plugin's identity, the content hash of its module
+func (p *Plugin) ID() string {
+	return p.id
+}
+
+// Check runs the plugin's validate export against proposal in a fresh
+// instance
+//
+// Returns:
+//   - nil if the plugin accepts the proposal
+//   - Rejection if it rejects it
+//   - An error wrapping a Trap if it fails, including by exceeding its limits
+func (p *Plugin) Check(proposal *ocp.ContractProposal) error {
+	input, err := ocp.CanonicalizeValue(proposal.ToMap())
+	if err != nil {
+		return err
+	}
+	state := &callState{}
+	inst := newInstance(p.m, p.limits, state)
+	res, err := inst.invoke(p.alloc, uint64(len(input)))
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.id, err)
+	}
+	ptr := uint32(res[0])
+	if uint64(ptr)+uint64(len(input)) > uint64(len(inst.mem)) {
+		return fmt.Errorf("plugin %s: %w", p.id, &Trap{Reason: "alloc returned memory out of bounds"})
+	}
+	copy(inst.mem[ptr:], input)
+	res, err = inst.invoke(p.validate, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.id, err)
+	}
+	if code := uint32(res[0]); code != 0 {
+		return &Rejection{Plugin: p.id, Code: code, Message: state.message}
+	}
+	return nil
+}
+
+// Host holds the validator plugins a node runs
+type Host struct {
+	limits  Limits
+	mu      sync.RWMutex
+	allowed map[string]bool
+	plugins map[string]*Plugin
+}
+
+// NewHost creates a Host running plugins under limits; the zero Limits
+// selects DefaultLimits
+func NewHost(limits Limits) *Host {
+	if limits == (Limits{}) {
+		limits = DefaultLimits
+	}
+	return &Host{limits: limits, allowed: make(map[string]bool), plugins: make(map[string]*Plugin)}
+}
+
+// Allow permits loading the module with content hash id
+func (h *Host) Allow(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.allowed[id] = true
+}
+
+// Load compiles and installs a module. Loading a module that is already
+// installed returns the installed plugin.
+//
+// Returns:
+//   - The installed plugin
+//   - ConstitutionalError if the module's hash has not been allowed
+//   - LoadError if the module cannot be compiled
+func (h *Host) Load(module []byte) (*Plugin, error) {
+	id := ocp.ContentHash(module)
+	h.mu.RLock()
+	allowed, existing := h.allowed[id], h.plugins[id]
+	h.mu.RUnlock()
+	if !allowed {
+		return nil, ocp.NewConstitutionalError(fmt.Sprintf("plugin %s has not been allowed", id))
+	}
+	if existing != nil {
+		return existing, nil
+	}
+	p, err := Compile(module, h.limits)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.plugins[id] = p
+	return p, nil
+}
+
+// Unload removes the plugin with identity id
+func (h *Host) Unload(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.plugins, id)
+}
+
+// Plugins returns the installed plugins, sorted by identity
+func (h *Host) Plugins() []*Plugin {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	out := make([]*Plugin, 0, len(h.plugins))
+	for _, p := range h.plugins {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].id < out[j].id })
+	return out
+}
+
+// Check runs every installed plugin against proposal in identity order,
+// stopping at the first that does not accept it
+func (h *Host) Check(proposal *ocp.ContractProposal) error {
+	for _, p := range h.Plugins() {
+		if err := p.Check(proposal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Policy returns the host's plugins as a policy for ocp.WithPolicy, so plugin
+// verdicts appear as the policy check of VerifyProposalFull
+func (h *Host) Policy() func(*ocp.ContractProposal) error {
+	return h.Check
+}
@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// tildeValidator returns a plugin module rejecting proposals whose canonical
+// JSON contains "~", with code 7 and the message "tilde found"
+func tildeValidator() []byte {
+	msg := "tilde found"
+	return wasmModule{
+		types: [][]byte{
+			functype([]byte{typeI32}, []byte{typeI32}),
+			functype([]byte{typeI32, typeI32}, []byte{typeI32}),
+			functype([]byte{typeI32, typeI32}, nil),
+		},
+		imports: [][]byte{cat(wname("ocp"), wname("fail"), []byte{0, 2})},
+		funcs:   [][]byte{{0}, {1}},
+		memory:  [][]byte{{0, 1}},
+		globals: [][]byte{cat([]byte{typeI32, 1}, i32c(1024), []byte{opEnd})},
+		exports: [][]byte{
+			cat(wname("memory"), []byte{exportMemory, 0}),
+			cat(wname("alloc"), []byte{exportFunc, 1}),
+			cat(wname("validate"), []byte{exportFunc, 2}),
+		},
+		code: [][]byte{
+			// alloc: bump the heap global
+			body(nil,
+				[]byte{opGlobalGet, 0, opGlobalGet, 0}, get(0),
+				[]byte{0x6A, opGlobalSet, 0}),
+			// validate: scan for '~'
+			body([]byte{typeI32},
+				[]byte{opBlock, 0x40, opLoop, 0x40},
+				get(2), get(1), []byte{0x4F, opBrIf, 1},
+				get(0), get(2), []byte{0x6A, 0x2D, 0, 0}, i32c('~'), []byte{0x46},
+				[]byte{opIf, 0x40}, i32c(0), i32c(int32(len(msg))), []byte{opCall, 0}, i32c(7), []byte{opReturn, opEnd},
+				get(2), i32c(1), []byte{0x6A, opLocalSet, 2},
+				[]byte{opBr, 0, opEnd, opEnd},
+				i32c(0)),
+		},
+		data: [][]byte{cat([]byte{0}, i32c(0), []byte{opEnd}, wname(msg))},
+	}.bytes()
+}
+
+func testProposal(text string) *ocp.ContractProposal {
+	return &ocp.ContractProposal{
+		ID:                 "p-1",
+		ProposerAgent:      "Claude",
+		ActionType:         "amend",
+		Action:             map[string]interface{}{"text": text},
+		ReversibilityClass: "reversible",
+		PreStateHash:       "sha256:00",
+		Timestamp:          "2026-01-01T00:00:00Z",
+	}
+}
+
+// TestPluginCheck tests the plugin ABI end to end
+func TestPluginCheck(t *testing.T) {
+	p, err := Compile(tildeValidator(), DefaultLimits)
+	if err != nil {
+		t.Fatalf("Failed to compile: %v", err)
+	}
+	if p.ID() != ocp.ContentHash(tildeValidator()) {
+		t.Errorf("Plugin identity is not the module hash")
+	}
+	if err := p.Check(testProposal("plain")); err != nil {
+		t.Errorf("Expected acceptance, got %v", err)
+	}
+	err = p.Check(testProposal("has ~ inside"))
+	var rej *Rejection
+	if !errors.As(err, &rej) || rej.Code != 7 || rej.Message != "tilde found" || rej.Plugin != p.ID() {
+		t.Errorf("Expected a tilde rejection, got %v", err)
+	}
+
+	// The same run under too little fuel traps
+	starved, _ := Compile(tildeValidator(), Limits{Fuel: 50, MemoryPages: 1, CallDepth: 8, StackSize: 64})
+	var tr *Trap
+	if err := starved.Check(testProposal("plain")); !errors.As(err, &tr) || !strings.Contains(err.Error(), p.ID()) {
+		t.Errorf("Expected a fuel trap naming the plugin, got %v", err)
+	}
+
+	// Modules without the ABI are refused
+	if _, err := Compile(single(nil, nil, nil), DefaultLimits); err == nil {
+		t.Errorf("Expected a module without the ABI to be refused")
+	}
+
+	t.Log("✓ Plugins accept, reject with messages, and trap under their limits")
+}
+
+// TestHost tests allow-listing by module hash and the policy adapter
+func TestHost(t *testing.T) {
+	h := NewHost(Limits{})
+	module := tildeValidator()
+	if _, err := h.Load(module); err == nil {
+		t.Fatalf("Expected an unallowed module to be refused")
+	}
+	h.Allow(ocp.ContentHash(module))
+	p, err := h.Load(module)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if again, _ := h.Load(module); again != p || len(h.Plugins()) != 1 {
+		t.Errorf("Expected reloading to return the installed plugin")
+	}
+
+	good := testProposal("plain")
+	bad := testProposal("~")
+	for _, s := range []struct {
+		p    *ocp.ContractProposal
+		pass bool
+	}{{good, true}, {bad, false}} {
+		result := ocp.VerifyProposalFull(s.p, ocp.WithPolicy(h.Policy()))
+		var policy *ocp.CheckResult
+		for i := range result.Checks {
+			if result.Checks[i].Check == "policy" {
+				policy = &result.Checks[i]
+			}
+		}
+		if policy == nil || (policy.Status == ocp.CheckPassed) != s.pass {
+			t.Errorf("Unexpected policy check %+v for %v", policy, s.p.Action)
+		}
+	}
+
+	h.Unload(p.ID())
+	if err := h.Check(bad); err != nil {
+		t.Errorf("Expected no plugins to run after Unload, got %v", err)
+	}
+
+	t.Log("✓ Host loads only allowed plugins and feeds the policy check")
+}
@@ -0,0 +1,727 @@
+// wasm.go - Decoding of WebAssembly modules
+//
+// The host accepts WebAssembly 1.0 binary modules restricted to what a
+// validator needs and what every node can run bit-for-bit identically:
+// integer types only (i32 and i64; any floating-point type or instruction
+// is rejected at load), one linear memory, mutable or immutable integer
+// globals, active data segments, and function imports from the "ocp" host
+// module. Tables, indirect calls, start functions, and multi-value blocks
+// are not supported. Function bodies are decoded once into a flat
+// instruction list with resolved branch targets, which the interpreter in
+// interp.go walks.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Value types
+const (
+	typeI32 byte = 0x7F
+	typeI64 byte = 0x7E
+)
+
+// pageSize is the size of a WebAssembly memory page
+const pageSize = 64 * 1024
+
+// funcType is a function signature
+type funcType struct {
+	params  []byte
+	results []byte
+}
+
+// instr is a decoded instruction
+type instr struct {
+	op byte
+	// a holds the instruction's immediate: a constant, an index, a memory
+	// offset, a branch depth, or a block's result count
+	a uint64
+	// end is the index of the matching end of block, loop, and if; els is the
+	// index of an if's else, or -1
+	end, els int
+	// table holds br_table's depths, the default last
+	table []uint32
+}
+
+// function is a module-defined function
+type function struct {
+	typ    funcType
+	locals []byte
+	code   []instr
+}
+
+// global is a module global and its initial value
+type global struct {
+	typ     byte
+	mutable bool
+	init    uint64
+}
+
+// dataSegment is an active data segment
+type dataSegment struct {
+	offset uint32
+	data   []byte
+}
+
+// hostImport is a function imported from the host
+type hostImport struct {
+	name string
+	typ  funcType
+}
+
+// module is a decoded module
+type module struct {
+	types     []funcType
+	imports   []hostImport
+	funcs     []function
+	hasMemory bool
+	memMin    uint32
+	memMax    uint32
+	globals   []global
+	exports   map[string]export
+	data      []dataSegment
+}
+
+type export struct {
+	kind  byte
+	index uint32
+}
+
+// Export kinds
+const (
+	exportFunc   byte = 0
+	exportMemory byte = 2
+)
+
+// funcType returns the signature of function index i, imports first
+func (m *module) funcType(i uint32) (funcType, bool) {
+	if int(i) < len(m.imports) {
+		return m.imports[i].typ, true
+	}
+	i -= uint32(len(m.imports))
+	if int(i) < len(m.funcs) {
+		return m.funcs[i].typ, true
+	}
+	return funcType{}, false
+}
+
+// reader reads the binary format
+type reader struct {
+	buf []byte
+	pos int
+}
+
+func (r *reader) fail(format string, args ...interface{}) error {
+	return &LoadError{Offset: r.pos, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.buf)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, r.fail("unexpected end of module")
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n uint32) ([]byte, error) {
+	if uint64(r.pos)+uint64(n) > uint64(len(r.buf)) {
+		return nil, r.fail("unexpected end of module")
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	var v uint64
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			if v > math.MaxUint32 {
+				return 0, r.fail("integer too large")
+			}
+			return uint32(v), nil
+		}
+	}
+	return 0, r.fail("integer representation too long")
+}
+
+// count reads the length of a vector. Every element takes at least one byte,
+// so a length beyond the bytes left is malformed; checking it before looping
+// keeps a forged length from driving allocation.
+func (r *reader) count() (uint32, error) {
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if uint64(n) > uint64(len(r.buf)-r.pos) {
+		return 0, r.fail("vector length %d exceeds the section", n)
+	}
+	return n, nil
+}
+
+// signed reads a signed LEB128 integer of at most bits bits
+func (r *reader) signed(bits uint) (int64, error) {
+	var v int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= int64(b&0x7F) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v, nil
+		}
+		if shift >= bits+7 {
+			return 0, r.fail("integer representation too long")
+		}
+	}
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(n)
+	return string(b), err
+}
+
+func (r *reader) valType() (byte, error) {
+	t, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	if t != typeI32 && t != typeI64 {
+		return 0, r.fail("value type 0x%02x not supported; only i32 and i64 are deterministic", t)
+	}
+	return t, nil
+}
+
+// constExpr reads an i32.const or i64.const initializer
+func (r *reader) constExpr(typ byte) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	var v int64
+	switch {
+	case op == 0x41 && typ == typeI32:
+		v, err = r.signed(32)
+	case op == 0x42 && typ == typeI64:
+		v, err = r.signed(64)
+	default:
+		return 0, r.fail("unsupported constant expression")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if end, err := r.byte(); err != nil || end != 0x0B {
+		return 0, r.fail("constant expression not terminated")
+	}
+	if typ == typeI32 {
+		return uint64(uint32(v)), nil
+	}
+	return uint64(v), nil
+}
+
+// decodeModule parses and checks a binary module
+func decodeModule(data []byte, maxPages uint32) (*module, error) {
+	r := &reader{buf: data}
+	if len(data) < 8 || !bytes.Equal(data[:4], []byte("\x00asm")) {
+		return nil, r.fail("not a WebAssembly module")
+	}
+	if binary.LittleEndian.Uint32(data[4:8]) != 1 {
+		return nil, r.fail("unsupported WebAssembly version")
+	}
+	r.pos = 8
+
+	m := &module{exports: make(map[string]export)}
+	var funcTypes []uint32
+	var lastID byte
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 {
+			if id <= lastID && id != 12 {
+				return nil, r.fail("section %d out of order", id)
+			}
+			lastID = id
+		}
+		s := &reader{buf: body}
+		switch id {
+		case 0, 12:
+			// Custom sections and the data count carry nothing the host needs
+			continue
+		case 1:
+			err = decodeTypes(s, m)
+		case 2:
+			err = decodeImports(s, m)
+		case 3:
+			funcTypes, err = decodeFunctions(s, m)
+		case 5:
+			err = decodeMemory(s, m, maxPages)
+		case 6:
+			err = decodeGlobals(s, m)
+		case 7:
+			err = decodeExports(s, m)
+		case 10:
+			err = decodeCode(s, m, funcTypes)
+		case 11:
+			err = decodeData(s, m)
+		default:
+			return nil, r.fail("section %d not supported", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !s.eof() {
+			return nil, r.fail("section %d has trailing bytes", id)
+		}
+	}
+	if len(m.funcs) != len(funcTypes) {
+		return nil, r.fail("function and code sections disagree")
+	}
+	for name, e := range m.exports {
+		if e.kind == exportFunc {
+			if _, ok := m.funcType(e.index); !ok {
+				return nil, r.fail("export %q names an unknown function", name)
+			}
+		}
+	}
+	return m, nil
+}
+
+func decodeTypes(r *reader, m *module) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if form, err := r.byte(); err != nil || form != 0x60 {
+			return r.fail("malformed function type")
+		}
+		var ft funcType
+		for _, list := range []*[]byte{&ft.params, &ft.results} {
+			count, err := r.count()
+			if err != nil {
+				return err
+			}
+			for j := uint32(0); j < count; j++ {
+				t, err := r.valType()
+				if err != nil {
+					return err
+				}
+				*list = append(*list, t)
+			}
+		}
+		if len(ft.results) > 1 {
+			return r.fail("multiple results not supported")
+		}
+		m.types = append(m.types, ft)
+	}
+	return nil
+}
+
+func (m *module) typeAt(r *reader, i uint32) (funcType, error) {
+	if int(i) >= len(m.types) {
+		return funcType{}, r.fail("unknown type %d", i)
+	}
+	return m.types[i], nil
+}
+
+func decodeImports(r *reader, m *module) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		mod, err := r.name()
+		if err != nil {
+			return err
+		}
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if mod != HostModule || kind != 0 {
+			return r.fail("import %s.%s not provided by the host", mod, name)
+		}
+		idx, err := r.u32()
+		if err != nil {
+			return err
+		}
+		typ, err := m.typeAt(r, idx)
+		if err != nil {
+			return err
+		}
+		want, ok := hostFunctions[name]
+		if !ok || !sameType(want, typ) {
+			return r.fail("import %s.%s not provided by the host", mod, name)
+		}
+		m.imports = append(m.imports, hostImport{name: name, typ: typ})
+	}
+	return nil
+}
+
+func sameType(a, b funcType) bool {
+	return bytes.Equal(a.params, b.params) && bytes.Equal(a.results, b.results)
+}
+
+func decodeFunctions(r *reader, m *module) ([]uint32, error) {
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint32, 0, n)
+	for i := uint32(0); i < n; i++ {
+		idx, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := m.typeAt(r, idx); err != nil {
+			return nil, err
+		}
+		out = append(out, idx)
+	}
+	return out, nil
+}
+
+func decodeMemory(r *reader, m *module, maxPages uint32) error {
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return r.fail("exactly one memory is supported")
+	}
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if m.memMin, err = r.u32(); err != nil {
+		return err
+	}
+	m.memMax = maxPages
+	switch flags {
+	case 0:
+	case 1:
+		declared, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if declared < m.memMax {
+			m.memMax = declared
+		}
+	default:
+		return r.fail("unsupported memory limits")
+	}
+	if m.memMin > m.memMax {
+		return r.fail("module needs %d memory pages, limit is %d", m.memMin, m.memMax)
+	}
+	m.hasMemory = true
+	return nil
+}
+
+func decodeGlobals(r *reader, m *module) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		typ, err := r.valType()
+		if err != nil {
+			return err
+		}
+		mut, err := r.byte()
+		if err != nil || mut > 1 {
+			return r.fail("malformed global")
+		}
+		init, err := r.constExpr(typ)
+		if err != nil {
+			return err
+		}
+		m.globals = append(m.globals, global{typ: typ, mutable: mut == 1, init: init})
+	}
+	return nil
+}
+
+func decodeExports(r *reader, m *module) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		name, err := r.name()
+		if err != nil {
+			return err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return err
+		}
+		idx, err := r.u32()
+		if err != nil {
+			return err
+		}
+		if _, dup := m.exports[name]; dup {
+			return r.fail("duplicate export %q", name)
+		}
+		m.exports[name] = export{kind: kind, index: idx}
+	}
+	return nil
+}
+
+func decodeData(r *reader, m *module) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if flags, err := r.u32(); err != nil || flags != 0 {
+			return r.fail("only active data segments are supported")
+		}
+		offset, err := r.constExpr(typeI32)
+		if err != nil {
+			return err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		data, err := r.bytes(size)
+		if err != nil {
+			return err
+		}
+		if !m.hasMemory || uint64(offset)+uint64(size) > uint64(m.memMin)*pageSize {
+			return r.fail("data segment outside memory")
+		}
+		m.data = append(m.data, dataSegment{offset: uint32(offset), data: data})
+	}
+	return nil
+}
+
+func decodeCode(r *reader, m *module, funcTypes []uint32) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(funcTypes) {
+		return r.fail("function and code sections disagree")
+	}
+	for i := uint32(0); i < n; i++ {
+		size, err := r.u32()
+		if err != nil {
+			return err
+		}
+		body, err := r.bytes(size)
+		if err != nil {
+			return err
+		}
+		f := function{typ: m.types[funcTypes[i]]}
+		br := &reader{buf: body}
+		groups, err := br.count()
+		if err != nil {
+			return err
+		}
+		for g := uint32(0); g < groups; g++ {
+			count, err := br.u32()
+			if err != nil {
+				return err
+			}
+			t, err := br.valType()
+			if err != nil {
+				return err
+			}
+			if len(f.locals)+int(count) > maxLocals {
+				return br.fail("too many locals")
+			}
+			for c := uint32(0); c < count; c++ {
+				f.locals = append(f.locals, t)
+			}
+		}
+		if f.code, err = decodeBody(br, m, len(m.imports)+len(funcTypes)); err != nil {
+			return err
+		}
+		m.funcs = append(m.funcs, f)
+	}
+	return nil
+}
+
+// maxLocals bounds a function's declared locals
+const maxLocals = 50000
+
+// decodeBody decodes instructions and resolves block ends; nfuncs is the
+// number of callable functions
+func decodeBody(r *reader, m *module, nfuncs int) ([]instr, error) {
+	var code []instr
+	var open []int
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		in := instr{op: op, els: -1}
+		switch {
+		case op == opBlock || op == opLoop || op == opIf:
+			bt, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			switch bt {
+			case 0x40:
+			case typeI32, typeI64:
+				in.a = 1
+			default:
+				return nil, r.fail("block type 0x%02x not supported", bt)
+			}
+			open = append(open, len(code))
+		case op == opElse:
+			if len(open) == 0 || code[open[len(open)-1]].op != opIf || code[open[len(open)-1]].els >= 0 {
+				return nil, r.fail("else without if")
+			}
+			code[open[len(open)-1]].els = len(code)
+		case op == opEnd:
+			if len(open) == 0 {
+				code = append(code, in)
+				if !r.eof() {
+					return nil, r.fail("code after function end")
+				}
+				return code, nil
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			code[start].end = len(code)
+		case op == opBr || op == opBrIf || op == opLocalGet || op == opLocalSet || op == opLocalTee:
+			v, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			in.a = uint64(v)
+		case op == opGlobalGet || op == opGlobalSet:
+			v, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if int(v) >= len(m.globals) || (op == opGlobalSet && !m.globals[v].mutable) {
+				return nil, r.fail("invalid global %d", v)
+			}
+			in.a = uint64(v)
+		case op == opCall:
+			v, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if int(v) >= nfuncs {
+				return nil, r.fail("call to unknown function %d", v)
+			}
+			in.a = uint64(v)
+		case op == opBrTable:
+			count, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if count > uint32(len(r.buf)) {
+				return nil, r.fail("malformed br_table")
+			}
+			for j := uint32(0); j <= count; j++ {
+				v, err := r.u32()
+				if err != nil {
+					return nil, err
+				}
+				in.table = append(in.table, v)
+			}
+		case op >= 0x28 && op <= 0x3E:
+			if isFloatMemoryOp(op) {
+				return nil, r.fail("floating-point instruction 0x%02x not supported", op)
+			}
+			if _, err := r.u32(); err != nil { // alignment hint
+				return nil, err
+			}
+			v, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			in.a = uint64(v)
+			if !m.hasMemory {
+				return nil, r.fail("memory instruction without memory")
+			}
+		case op == opMemorySize || op == opMemoryGrow:
+			if b, err := r.byte(); err != nil || b != 0 {
+				return nil, r.fail("malformed memory instruction")
+			}
+			if !m.hasMemory {
+				return nil, r.fail("memory instruction without memory")
+			}
+		case op == opI32Const:
+			v, err := r.signed(32)
+			if err != nil {
+				return nil, err
+			}
+			in.a = uint64(uint32(v))
+		case op == opI64Const:
+			v, err := r.signed(64)
+			if err != nil {
+				return nil, err
+			}
+			in.a = uint64(v)
+		case supportedSimple(op):
+		default:
+			return nil, r.fail("instruction 0x%02x not supported", op)
+		}
+		code = append(code, in)
+	}
+}
+
+func isFloatMemoryOp(op byte) bool {
+	return op == 0x2A || op == 0x2B || op == 0x38 || op == 0x39
+}
+
+// supportedSimple reports whether op is a supported instruction without immediates
+func supportedSimple(op byte) bool {
+	switch {
+	case op == opUnreachable, op == opNop, op == opReturn, op == opDrop, op == opSelect:
+		return true
+	case op >= 0x45 && op <= 0x5A: // i32 and i64 comparisons
+		return true
+	case op >= 0x67 && op <= 0x8A: // i32 and i64 arithmetic
+		return true
+	case op == 0xA7, op == 0xAC, op == 0xAD: // wrap and extend
+		return true
+	case op >= 0xC0 && op <= 0xC4: // sign extension
+		return true
+	}
+	return false
+}
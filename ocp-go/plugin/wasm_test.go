@@ -0,0 +1,191 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+// The helpers below assemble binary modules for tests
+
+func uleb(n uint64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7F)
+		n >>= 7
+		if n != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+func sleb(n int64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7F)
+		n >>= 7
+		done := (n == 0 && b&0x40 == 0) || (n == -1 && b&0x40 != 0)
+		if !done {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if done {
+			return out
+		}
+	}
+}
+
+func cat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func vec(items ...[]byte) []byte {
+	return cat(uleb(uint64(len(items))), cat(items...))
+}
+
+func wname(s string) []byte {
+	return cat(uleb(uint64(len(s))), []byte(s))
+}
+
+func section(id byte, body []byte) []byte {
+	return cat([]byte{id}, uleb(uint64(len(body))), body)
+}
+
+func functype(params, results []byte) []byte {
+	return cat([]byte{0x60}, uleb(uint64(len(params))), params, uleb(uint64(len(results))), results)
+}
+
+// body encodes a function body with locals of the given types
+func body(locals []byte, code ...[]byte) []byte {
+	var decls [][]byte
+	for _, t := range locals {
+		decls = append(decls, []byte{1, t})
+	}
+	b := cat(vec(decls...), cat(code...), []byte{opEnd})
+	return cat(uleb(uint64(len(b))), b)
+}
+
+func i32c(v int32) []byte { return cat([]byte{opI32Const}, sleb(int64(v))) }
+func i64c(v int64) []byte { return cat([]byte{opI64Const}, sleb(v)) }
+func get(i uint32) []byte { return cat([]byte{opLocalGet}, uleb(uint64(i))) }
+
+// wasmModule assembles a module from its sections
+type wasmModule struct {
+	types, imports, funcs, memory, globals, exports, code, data [][]byte
+}
+
+func (w wasmModule) bytes() []byte {
+	out := []byte("\x00asm\x01\x00\x00\x00")
+	for _, s := range []struct {
+		id    byte
+		items [][]byte
+	}{
+		{1, w.types}, {2, w.imports}, {3, w.funcs}, {5, w.memory},
+		{6, w.globals}, {7, w.exports}, {10, w.code}, {11, w.data},
+	} {
+		if len(s.items) > 0 {
+			out = append(out, section(s.id, vec(s.items...))...)
+		}
+	}
+	return out
+}
+
+// single returns a module with one exported function "f" and one memory page
+func single(params, results, locals []byte, code ...[]byte) []byte {
+	return wasmModule{
+		types:   [][]byte{functype(params, results)},
+		funcs:   [][]byte{{0}},
+		memory:  [][]byte{{1, 1, 2}},
+		exports: [][]byte{cat(wname("f"), []byte{exportFunc, 0})},
+		code:    [][]byte{body(locals, code...)},
+	}.bytes()
+}
+
+// TestDecodeModule tests decoding of supported modules
+func TestDecodeModule(t *testing.T) {
+	m, err := decodeModule(single([]byte{typeI32}, []byte{typeI32}, nil, get(0)), 16)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if len(m.funcs) != 1 || m.exports["f"].kind != exportFunc || m.memMin != 1 || m.memMax != 2 {
+		t.Errorf("Unexpected module %+v", m)
+	}
+
+	// Block ends and else branches are resolved
+	m, err = decodeModule(single([]byte{typeI32}, []byte{typeI32}, nil,
+		get(0), []byte{opIf, typeI32}, i32c(1), []byte{opElse}, i32c(2), []byte{opEnd}), 16)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	code := m.funcs[0].code
+	if code[1].op != opIf || code[1].els != 3 || code[1].end != 5 {
+		t.Errorf("Unexpected if targets %+v", code[1])
+	}
+
+	t.Log("✓ Modules decode with resolved block targets")
+}
+
+// TestDecodeRejects tests that unsupported or malformed modules fail to load
+func TestDecodeRejects(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":       nil,
+		"bad magic":   []byte("\x00wasm\x01\x00\x00\x00"),
+		"bad version": []byte("\x00asm\x02\x00\x00\x00"),
+		"float param": single([]byte{0x7C}, nil, nil),
+		"float op":    single(nil, nil, nil, []byte{0x43, 0, 0, 0, 0}, []byte{opDrop}),
+		"float local": single(nil, nil, []byte{0x7D}),
+		"truncated":   single(nil, nil, nil)[:20],
+		"bad call":    single(nil, nil, nil, []byte{opCall, 5}),
+		"big memory": wasmModule{
+			memory: [][]byte{{0, 64}},
+		}.bytes(),
+		"unknown import": wasmModule{
+			types:   [][]byte{functype(nil, nil)},
+			imports: [][]byte{cat(wname("env"), wname("clock"), []byte{0, 0})},
+		}.bytes(),
+		"start section": append(single(nil, nil, nil), section(8, uleb(0))...),
+	}
+	for name, data := range cases {
+		_, err := decodeModule(data, 16)
+		var loadErr *LoadError
+		if !errors.As(err, &loadErr) {
+			t.Errorf("%s: expected a LoadError, got %v", name, err)
+		}
+	}
+
+	t.Log("✓ Unsupported and malformed modules are rejected at load")
+}
+
+// TestDecodeForgedLength tests that a vector length larger than its section
+// fails to load instead of sizing an allocation
+func TestDecodeForgedLength(t *testing.T) {
+	// A function section claiming about four billion entries
+	data := []byte("\x00asm\x01\x00\x00\x00\x03\x0a\xe4\xdd\x9f\xf3\x08\x00\x00\x00\x00\x00")
+	_, err := Compile(data, DefaultLimits)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expected a LoadError, got %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"types":   section(1, uleb(1<<30)),
+		"imports": section(2, uleb(1<<30)),
+		"globals": section(6, uleb(1<<30)),
+		"exports": section(7, uleb(1<<30)),
+		"code":    section(10, uleb(1<<30)),
+		"data":    section(11, uleb(1<<30)),
+	} {
+		if _, err := decodeModule(append([]byte("\x00asm\x01\x00\x00\x00"), data...), 16); !errors.As(err, &loadErr) {
+			t.Errorf("%s: expected a LoadError, got %v", name, err)
+		}
+	}
+
+	t.Logf("✓ Forged vector length refused: %v", loadErr)
+}
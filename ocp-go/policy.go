@@ -0,0 +1,209 @@
+// policy.go - Hot-reloadable policy tables with hash-guarded swaps
+//
+// Operators update a node's policy table by publishing a new file or endpoint
+// document, but a node only adopts a table whose semantic hash a quorum has
+// approved. A local edit that was never ratified, or a tampered endpoint, is
+// rejected and the node keeps enforcing its current table, so nodes cannot
+// drift apart through unilateral local changes.
+
+package ocp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// PolicySource supplies the raw JSON of a policy table
+type PolicySource interface {
+	Fetch() ([]byte, error)
+}
+
+// FilePolicySource reads a policy table from a local file
+type FilePolicySource struct {
+	Path string
+}
+
+// Fetch reads the file
+func (s FilePolicySource) Fetch() ([]byte, error) {
+	return os.ReadFile(s.Path)
+}
+
+// HTTPPolicySource fetches a policy table from an HTTP endpoint
+type HTTPPolicySource struct {
+	URL    string
+	Client *http.Client
+}
+
+// Fetch performs a GET request and returns the response body
+func (s HTTPPolicySource) Fetch() ([]byte, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Get(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("policy endpoint %s returned %s", s.URL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, MaxPolicySize+1))
+}
+
+// MaxPolicySize bounds the size of a fetched policy document
+const MaxPolicySize = 1 << 20
+
+// PolicyApproval is a quorum's approval of a policy table hash, signed under
+// ContextPolicy
+type PolicyApproval struct {
+	PolicyHash string      `json:"policy_hash"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// PolicyManager holds the active policy table and swaps in approved updates
+type PolicyManager struct {
+	mu       sync.RWMutex
+	quorum   *Quorum
+	approved map[string]bool
+	policies map[string]interface{}
+	hash     string
+	source   PolicySource
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	// OnSwap is called after a new table becomes active
+	OnSwap func(hash string)
+	// OnError receives reload failures from Watch
+	OnError func(error)
+}
+
+// NewPolicyManager creates a manager enforcing initial, typically the genesis
+// policy table. Updates are accepted only if approved by quorum.
+func NewPolicyManager(quorum *Quorum, source PolicySource, initial map[string]interface{}) (*PolicyManager, error) {
+	if initial == nil {
+		initial = map[string]interface{}{}
+	}
+	policies, ok := canonical.DeepCopy(initial).(map[string]interface{})
+	if !ok {
+		return nil, NewConstitutionalError("policy table must be an object")
+	}
+	hash, err := SemanticHash(policies)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyManager{
+		quorum:   quorum,
+		approved: map[string]bool{hash: true},
+		policies: policies,
+		hash:     hash,
+		source:   source,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Approve records a quorum-approved policy hash
+func (m *PolicyManager) Approve(a PolicyApproval) error {
+	if _, err := m.quorum.Verify(ContextPolicy, a.PolicyHash, a.Signatures); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.approved[a.PolicyHash] = true
+	return nil
+}
+
+// Current returns a copy of the active policy table and its hash
+func (m *PolicyManager) Current() (map[string]interface{}, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return canonical.DeepCopy(m.policies).(map[string]interface{}), m.hash
+}
+
+// Load strictly decodes a policy document and makes it active if its hash is
+// approved. An unapproved table is rejected and the active table is unchanged.
+//
+// Returns:
+//   - Semantic hash of the document
+//   - Whether the active table changed
+func (m *PolicyManager) Load(data []byte) (string, bool, error) {
+	if len(data) > MaxPolicySize {
+		return "", false, NewConstitutionalError("policy document exceeds MaxPolicySize")
+	}
+	policies, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return "", false, err
+	}
+	hash, err := SemanticHash(policies)
+	if err != nil {
+		return "", false, err
+	}
+
+	m.mu.Lock()
+	if hash == m.hash {
+		m.mu.Unlock()
+		return hash, false, nil
+	}
+	if !m.approved[hash] {
+		m.mu.Unlock()
+		return hash, false, NewVerificationError(fmt.Sprintf("policy table %s is not quorum-approved", hash))
+	}
+	m.policies, m.hash = policies, hash
+	onSwap := m.OnSwap
+	m.mu.Unlock()
+
+	if onSwap != nil {
+		onSwap(hash)
+	}
+	return hash, true, nil
+}
+
+// Reload fetches the policy document from the manager's source and loads it
+func (m *PolicyManager) Reload() (bool, error) {
+	if m.source == nil {
+		return false, NewConstitutionalError("no policy source configured")
+	}
+	data, err := m.source.Fetch()
+	if err != nil {
+		return false, err
+	}
+	_, swapped, err := m.Load(data)
+	return swapped, err
+}
+
+// Watch reloads the policy table every interval until Close is called
+func (m *PolicyManager) Watch(interval time.Duration) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				if _, err := m.Reload(); err != nil && m.OnError != nil {
+					m.OnError(err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops any Watch loop
+func (m *PolicyManager) Close() {
+	select {
+	case <-m.done:
+		return
+	default:
+	}
+	close(m.done)
+	m.wg.Wait()
+}
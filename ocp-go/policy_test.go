@@ -0,0 +1,103 @@
+package ocp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// approvePolicy returns a quorum approval of the policy table in data
+func approvePolicy(t *testing.T, data string, signers ...string) PolicyApproval {
+	t.Helper()
+	_, privs := testQuorum(t)
+	doc, err := DecodeStrict([]byte(data))
+	if err != nil {
+		t.Fatalf("Bad policy fixture: %v", err)
+	}
+	hash, _ := SemanticHash(doc)
+	approval := PolicyApproval{PolicyHash: hash}
+	for _, s := range signers {
+		sig, _ := SignHash(s, privs[s], ContextPolicy, hash)
+		approval.Signatures = append(approval.Signatures, sig)
+	}
+	return approval
+}
+
+// TestPolicyManagerHashGuard tests that only approved tables are swapped in
+func TestPolicyManagerHashGuard(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, _ := testQuorum(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	manager, err := NewPolicyManager(quorum, FilePolicySource{Path: path}, map[string]interface{}{"max_stake": float64(100)})
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	_, initialHash := manager.Current()
+
+	updated := `{"max_stake": 200, "challenge_window_hours": 72}`
+	os.WriteFile(path, []byte(updated), 0o644)
+	if swapped, err := manager.Reload(); err == nil || swapped {
+		t.Errorf("Unapproved table should be rejected")
+	}
+	if _, hash := manager.Current(); hash != initialHash {
+		t.Errorf("Rejected table should not change the active policy")
+	}
+
+	if err := manager.Approve(approvePolicy(t, updated, "Claude")); err == nil {
+		t.Errorf("Approval below threshold should be rejected")
+	}
+	approval := approvePolicy(t, updated, "Claude", "Gemini")
+	if err := manager.Approve(approval); err != nil {
+		t.Fatalf("Failed to approve: %v", err)
+	}
+
+	// Key order and whitespace do not change the approved hash
+	os.WriteFile(path, []byte(`{"challenge_window_hours":72,"max_stake":200}`), 0o644)
+	swapped, err := manager.Reload()
+	if err != nil || !swapped {
+		t.Fatalf("Approved table should be swapped in: %v", err)
+	}
+	policies, hash := manager.Current()
+	if hash != approval.PolicyHash || policies["max_stake"] != float64(200) {
+		t.Errorf("Active policy should be the approved table")
+	}
+
+	os.WriteFile(path, []byte(`{"max_stake": 200, "max_stake": 900}`), 0o644)
+	if _, err := manager.Reload(); err == nil {
+		t.Errorf("Ambiguous policy document should be rejected")
+	}
+
+	t.Logf("✓ Policy swapped to %s", hash)
+}
+
+// TestPolicyManagerWatch tests reloading from an HTTP endpoint in the background
+func TestPolicyManagerWatch(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, _ := testQuorum(t)
+	updated := `{"max_stake": 300}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(updated))
+	}))
+	defer server.Close()
+
+	manager, _ := NewPolicyManager(quorum, HTTPPolicySource{URL: server.URL}, nil)
+	manager.Approve(approvePolicy(t, updated, "Gemini", "DeepSeek"))
+
+	swaps := make(chan string, 1)
+	manager.OnSwap = func(hash string) { swaps <- hash }
+	manager.Watch(5 * time.Millisecond)
+	defer manager.Close()
+
+	select {
+	case hash := <-swaps:
+		t.Logf("✓ Watched endpoint swapped policy to %s", hash)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Approved endpoint policy was not loaded")
+	}
+}
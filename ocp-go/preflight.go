@@ -0,0 +1,247 @@
+// preflight.go - Dry-run evaluation of draft proposals
+//
+// A proposal's author stakes reputation the moment a node accepts it, so
+// mistakes a node would refuse, or that a challenger would profit from, are
+// best found before submission. Preflight.EvaluateDraft runs the checks a
+// node and its policy apply to a proposal without recording anything: schema
+// validation, the policy function, the node's admission checks (emergency
+// halt, intent declarations, activation), the stake the sponsorship policy
+// asks for, and conflicts with proposals already pending. The PreflightReport
+// is canonicalizable like a VerificationReport, so an author can keep its
+// hash with the draft it judged.
+//
+// Drafts are usually unsigned, so the schema check does not hold missing
+// canonical_serialization or proposer_signature members against them;
+// SignProposal fills both in once the author is satisfied.
+
+package ocp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/schema"
+)
+
+// Preflight check names, in the order they follow the schema and policy
+// checks in a PreflightReport
+const (
+	CheckAdmission = "admission"
+	CheckStake     = "stake"
+	CheckConflicts = "conflicts"
+)
+
+// Stable preflight codes, under the same rules as the verification codes
+const (
+	CodeAdmissionRefused  = "ADMISSION_REFUSED"
+	CodeStakeInsufficient = "STAKE_INSUFFICIENT"
+	CodeConflictPending   = "CONFLICT_PENDING"
+)
+
+// StakeRequirement is the stake a draft puts up and the stake asked of it
+type StakeRequirement struct {
+	// Offered is the stake behind the draft: the sponsors' aggregate if one
+	// is recorded, otherwise the proposer's own
+	Offered int `json:"offered"`
+	// Required is the least total stake the sponsorship policy accepts
+	Required int `json:"required"`
+	// MinSponsors is the least number of co-sponsors the policy accepts
+	MinSponsors int `json:"min_sponsors"`
+	// ChallengeBond is the bond a first-time challenger must lock against
+	// the draft, or zero if no escrow was supplied
+	ChallengeBond int `json:"challenge_bond"`
+}
+
+// ToMap converts a StakeRequirement to a map for canonicalization
+func (s StakeRequirement) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"offered":        s.Offered,
+		"required":       s.Required,
+		"min_sponsors":   s.MinSponsors,
+		"challenge_bond": s.ChallengeBond,
+	}
+}
+
+// PreflightReport collects the results of EvaluateDraft
+type PreflightReport struct {
+	ProposalHash string           `json:"proposal_hash"`
+	Checks       []CheckResult    `json:"checks"`
+	Stake        StakeRequirement `json:"stake"`
+	// Conflicts holds the hashes of pending proposals the draft conflicts with
+	Conflicts []string `json:"conflicts"`
+}
+
+// ToMap converts a PreflightReport to a map for canonicalization
+func (r *PreflightReport) ToMap() map[string]interface{} {
+	checks := make([]interface{}, len(r.Checks))
+	for i, c := range r.Checks {
+		checks[i] = c.ToMap()
+	}
+	conflicts := make([]interface{}, len(r.Conflicts))
+	for i, hash := range r.Conflicts {
+		conflicts[i] = hash
+	}
+	return map[string]interface{}{
+		"proposal_hash": r.ProposalHash,
+		"checks":        checks,
+		"stake":         r.Stake.ToMap(),
+		"conflicts":     conflicts,
+	}
+}
+
+// GetHash returns the semantic hash of the report
+func (r *PreflightReport) GetHash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// OK reports whether no check failed. Skipped checks do not count as failures.
+func (r *PreflightReport) OK() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the failed checks
+func (r *PreflightReport) Failures() []CheckResult {
+	return (&VerificationReport{Checks: r.Checks}).Failures()
+}
+
+// Check returns the result of the named check
+func (r *PreflightReport) Check(name string) (CheckResult, bool) {
+	return (&VerificationReport{Checks: r.Checks}).Check(name)
+}
+
+// Preflight evaluates drafts against a node and its policy. Checks whose
+// context is not set are reported as skipped.
+type Preflight struct {
+	// Node supplies the admission checks and the pending proposals
+	Node *Node
+	// Policy returns an error describing any policy violation, as passed to
+	// WithPolicy
+	Policy func(*ContractProposal) error
+	// Policies supplies the sponsorship policy for the stake check
+	Policies *PolicyManager
+	// Escrow supplies recorded sponsorships and the challenge bond
+	Escrow *Escrow
+}
+
+// EvaluateDraft runs every preflight check on a draft proposal without
+// submitting it
+//
+// Parameters:
+//   - draft: Proposal to evaluate; it need not be signed
+//
+// Returns:
+//   - Report with one result per check, in a fixed order: schema, policy,
+//     admission, stake, conflicts
+func (pf *Preflight) EvaluateDraft(draft *ContractProposal) *PreflightReport {
+	report := &PreflightReport{Conflicts: []string{}}
+	hash, err := draft.GetHash()
+	if err == nil {
+		report.ProposalHash = hash
+	}
+
+	var stake CheckResult
+	report.Stake, stake = pf.checkStake(draft)
+	var conflicts CheckResult
+	report.Conflicts, conflicts = pf.checkConflicts(draft, hash)
+	report.Checks = []CheckResult{
+		checkDraftSchema(draft),
+		checkPolicy(draft, pf.Policy),
+		pf.checkAdmission(draft),
+		stake,
+		conflicts,
+	}
+	return report
+}
+
+// checkDraftSchema validates the draft as checkSchema does, except for the
+// members signing fills in
+func checkDraftSchema(draft *ContractProposal) CheckResult {
+	form, err := CanonicalizeValue(draft.ToMap())
+	if err != nil {
+		return failed(CheckSchema, CodeCanonicalizationFailed, err.Error())
+	}
+	doc, err := DecodeStrict([]byte(form))
+	if err != nil {
+		return failed(CheckSchema, CodeCanonicalizationFailed, err.Error())
+	}
+
+	unsigned := draft.ProposerSignature["value"] == ""
+	if unsigned {
+		delete(doc, "canonical_serialization")
+		delete(doc, "proposer_signature")
+	}
+
+	var errs schema.Errors
+	if err := schema.ValidateContract(doc); err != nil && !errors.As(err, &errs) {
+		return failed(CheckSchema, CodeSchemaInvalidValue, err.Error())
+	}
+	var kept schema.Errors
+	for _, e := range errs {
+		if unsigned && e.Keyword == "required" &&
+			(e.Path == "$.canonical_serialization" || e.Path == "$.proposer_signature") {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) > 0 {
+		code := CodeSchemaInvalidValue
+		if kept[0].Keyword == "required" {
+			code = CodeSchemaMissingField
+		}
+		return failed(CheckSchema, code, kept.Error())
+	}
+	if _, err := draft.TypedReasoning(); err != nil {
+		return failed(CheckSchema, CodeSchemaInvalidValue, err.Error())
+	}
+	return passed(CheckSchema)
+}
+
+func (pf *Preflight) checkAdmission(draft *ContractProposal) CheckResult {
+	if pf.Node == nil {
+		return skipped(CheckAdmission, "no node supplied")
+	}
+	if err := pf.Node.Admit(draft); err != nil {
+		return failed(CheckAdmission, CodeAdmissionRefused, err.Error())
+	}
+	return passed(CheckAdmission)
+}
+
+func (pf *Preflight) checkStake(draft *ContractProposal) (StakeRequirement, CheckResult) {
+	req := StakeRequirement{Offered: draft.ReputationStake}
+	if pf.Escrow != nil {
+		req.Offered = pf.Escrow.stake(draft)
+		req.ChallengeBond = pf.Escrow.RequiredBond("", draft)
+	}
+	if pf.Policies == nil {
+		return req, skipped(CheckStake, "no policy table supplied")
+	}
+	table, _ := pf.Policies.Current()
+	policy := SponsorshipPolicyFromTable(table)
+	req.Required, req.MinSponsors = policy.MinTotalStake, policy.MinSponsors
+	if req.Offered < req.Required {
+		return req, failed(CheckStake, CodeStakeInsufficient, fmt.Sprintf(
+			"stake %d below the policy's %d; raise the stake or gather co-sponsors (at least %d)",
+			req.Offered, req.Required, req.MinSponsors))
+	}
+	return req, passed(CheckStake)
+}
+
+func (pf *Preflight) checkConflicts(draft *ContractProposal, hash string) ([]string, CheckResult) {
+	if pf.Node == nil {
+		return []string{}, skipped(CheckConflicts, "no node supplied")
+	}
+	conflicts := []string{}
+	for _, p := range pf.Node.Pending() {
+		other, err := p.GetHash()
+		if err != nil || other == hash || !Conflicts(draft, p) {
+			continue
+		}
+		conflicts = append(conflicts, other)
+	}
+	if len(conflicts) > 0 {
+		return conflicts, failed(CheckConflicts, CodeConflictPending, fmt.Sprintf(
+			"conflicts with pending %s; it will ratify in a later window", strings.Join(conflicts, ", ")))
+	}
+	return conflicts, passed(CheckConflicts)
+}
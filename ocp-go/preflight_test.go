@@ -0,0 +1,120 @@
+package ocp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// draftProposal returns a schema-complete proposal that has not been signed
+func draftProposal() *ContractProposal {
+	p := testProposal()
+	p.ReversibilityClass = "partially_reversible"
+	p.PreStateHash = "sha256:" + strings.Repeat("a", 64)
+	p.PostStateHash = "sha256:" + strings.Repeat("b", 64)
+	p.Reasoning["constitutional_grounding"] = []interface{}{"Article X.1"}
+	p.ReputationStake = 40
+	return p
+}
+
+// TestEvaluateDraft tests a draft failing policy, stake, and conflict checks,
+// then passing once fixed, with nothing recorded either time
+func TestEvaluateDraft(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := NewNode(NewLedger())
+	pending := targetProposal("pending-1", "amendment-article-3")
+	if _, err := node.Submit(pending); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	pendingHash, _ := pending.GetHash()
+	quorum, _ := testQuorum(t)
+	policies, err := NewPolicyManager(quorum, nil, map[string]interface{}{
+		SponsorshipPolicyKey: map[string]interface{}{"min_total_stake": float64(100), "min_sponsors": float64(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf := &Preflight{
+		Node:     node,
+		Policies: policies,
+		Escrow:   NewEscrow(node.Ledger(), DefaultBondingCurve),
+		Policy: func(p *ContractProposal) error {
+			if p.ActionType == "amend" && p.ReversibilityClass != "easily_reversible" {
+				return errors.New("amendments must be easily reversible")
+			}
+			return nil
+		},
+	}
+	height := node.Ledger().Height()
+
+	draft := draftProposal()
+	report := pf.EvaluateDraft(draft)
+	for _, name := range []string{CheckSchema, CheckAdmission} {
+		if c, _ := report.Check(name); c.Status != CheckPassed {
+			t.Errorf("Expected %s to pass, got %+v", name, c)
+		}
+	}
+	for name, code := range map[string]string{
+		CheckPolicy:    CodePolicyViolation,
+		CheckStake:     CodeStakeInsufficient,
+		CheckConflicts: CodeConflictPending,
+	} {
+		if c, _ := report.Check(name); c.Status != CheckFailed || c.Code != code {
+			t.Errorf("Expected %s to fail with %s, got %+v", name, code, c)
+		}
+	}
+	if want := (StakeRequirement{Offered: 40, Required: 100, MinSponsors: 1, ChallengeBond: 20}); report.Stake != want {
+		t.Errorf("Expected stake %+v, got %+v", want, report.Stake)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0] != pendingHash {
+		t.Errorf("Expected a conflict with %s, got %v", pendingHash, report.Conflicts)
+	}
+	if _, err := report.GetHash(); err != nil {
+		t.Errorf("Report should hash: %v", err)
+	}
+	failures := len(report.Failures())
+
+	draft.ReversibilityClass = "easily_reversible"
+	draft.ReputationStake = 120
+	draft.Action["target"] = "amendment-article-4"
+	report = pf.EvaluateDraft(draft)
+	if !report.OK() || len(report.Conflicts) != 0 {
+		t.Errorf("Expected the fixed draft to pass, got %+v", report.Failures())
+	}
+	if node.Ledger().Height() != height || node.AcceptedCount() != 1 {
+		t.Error("Evaluating a draft must record nothing")
+	}
+	t.Logf("✓ Draft fixed after %d failed checks; stake %d against %d required", failures, draft.ReputationStake, report.Stake.Required)
+}
+
+// TestEvaluateDraftWithoutContext tests the checks that need a draft alone,
+// and refusals the node would return
+func TestEvaluateDraftWithoutContext(t *testing.T) {
+	testenv.RequireSigning(t)
+	report := (&Preflight{}).EvaluateDraft(draftProposal())
+	if !report.OK() {
+		t.Errorf("Expected an unsigned but complete draft to pass, got %+v", report.Failures())
+	}
+	for _, name := range []string{CheckPolicy, CheckAdmission, CheckStake, CheckConflicts} {
+		if c, _ := report.Check(name); c.Status != CheckSkipped {
+			t.Errorf("Expected %s to be skipped, got %+v", name, c)
+		}
+	}
+
+	incomplete := draftProposal()
+	incomplete.ReversibilityClass = ""
+	if c, _ := (&Preflight{}).EvaluateDraft(incomplete).Check(CheckSchema); c.Code != CodeSchemaInvalidValue || !strings.Contains(c.Message, "reversibility_class") {
+		t.Errorf("Expected an invalid reversibility_class, got %+v", c)
+	}
+
+	node := NewNode(NewLedger())
+	node.Ledger().Append("policy", map[string]interface{}{"max_stake": 100})
+	late := draftProposal()
+	late.ActivationHeight = 2
+	if c, _ := (&Preflight{Node: node}).EvaluateDraft(late).Check(CheckAdmission); c.Code != CodeAdmissionRefused {
+		t.Errorf("Expected an activation already due to be refused, got %+v", c)
+	}
+	t.Log("✓ Drafts judged alone, and admission refusals reported")
+}
@@ -0,0 +1,166 @@
+// prehash.go - Ed25519ph signatures over large canonical payloads
+//
+// A hash-mode signature covers a semantic hash, which the signer must first
+// compute over the whole canonical payload and the verifier must recompute.
+// For very large payloads, such as archived evidence bundles or full ledger
+// exports, it is simpler to sign the payload itself. Prehashed mode uses
+// Ed25519ph (RFC 8032): the canonical bytes are streamed into SHA-512 as they
+// are produced or read, and only the 64-byte digest is signed, so neither
+// side ever holds the payload in memory.
+//
+// A prehashed signature records Mode "ph" and carries the signature context
+// as the Ed25519ph context string, so it stays domain separated like a
+// hash-mode signature. Because it covers the payload rather than a semantic
+// hash, VerifyHashSignature refuses it; VerifyPayloadSignature and
+// VerifyCanonicalStream accept signatures in either mode.
+
+package ocp
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// SignModePrehashed marks an Ed25519ph signature over a canonical payload
+const SignModePrehashed = "ph"
+
+// SignatureSchemePrehashed names Ed25519ph among a build's signature schemes
+const SignatureSchemePrehashed = SignatureAlgorithm + SignModePrehashed
+
+// errNoPrehash is returned for prehashed signatures under the ocp_noprehash tag
+var errNoPrehash = &CapabilityError{Capability: CapabilityPrehash, Operation: "prehashed signature"}
+
+// prehashOptions returns the Ed25519ph options for ctx
+func prehashOptions(ctx SignatureContext) (*ed25519.Options, error) {
+	if ctx == "" {
+		return nil, NewVerificationError("signature context is required")
+	}
+	return &ed25519.Options{Hash: crypto.SHA512, Context: string(ctx)}, nil
+}
+
+// SignPayload signs the canonical form of value in prehashed mode. The
+// canonical bytes are encoded straight into the digest.
+//
+// Parameters:
+//   - signer: Agent identifier recorded in the signature
+//   - key: Ed25519 private key of the signer
+//   - ctx: Context of the signed object kind; required
+//   - value: JSON-compatible payload
+//
+// Returns:
+//   - Signature with Mode SignModePrehashed
+func SignPayload(signer string, key ed25519.PrivateKey, ctx SignatureContext, value interface{}) (Signature, error) {
+	h := sha512.New()
+	if err := canonical.Encode(h, value); err != nil {
+		return Signature{}, err
+	}
+	return signPrehashed(signer, key, ctx, h)
+}
+
+// SignCanonicalStream signs canonical bytes read from r in prehashed mode.
+// The caller is responsible for r holding a canonical form.
+func SignCanonicalStream(signer string, key ed25519.PrivateKey, ctx SignatureContext, r io.Reader) (Signature, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return Signature{}, err
+	}
+	return signPrehashed(signer, key, ctx, h)
+}
+
+func signPrehashed(signer string, key ed25519.PrivateKey, ctx SignatureContext, h hash.Hash) (Signature, error) {
+	if VerifyOnlyBuild {
+		return Signature{}, ErrVerifyOnly
+	}
+	if !PrehashBuild {
+		return Signature{}, errNoPrehash
+	}
+	opts, err := prehashOptions(ctx)
+	if err != nil {
+		return Signature{}, err
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return Signature{}, NewVerificationError("invalid ed25519 private key size")
+	}
+	value, err := key.Sign(nil, h.Sum(nil), opts)
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{
+		Signer:    signer,
+		Algorithm: SignatureAlgorithm,
+		Value:     hex.EncodeToString(value),
+		Mode:      SignModePrehashed,
+	}, nil
+}
+
+// VerifyPayloadSignature verifies sig over the canonical form of value in
+// either mode: a hash-mode signature against value's semantic hash, and a
+// prehashed one against its streamed digest
+//
+// Returns:
+//   - nil if the signature is valid, a VerificationError otherwise; a
+//     *CapabilityError for a prehashed signature under the ocp_noprehash tag
+func VerifyPayloadSignature(key ed25519.PublicKey, ctx SignatureContext, value interface{}, sig Signature) error {
+	return verifyStreamed(key, ctx, sig, func(w io.Writer) error {
+		return canonical.Encode(w, value)
+	})
+}
+
+// VerifyCanonicalStream verifies sig over canonical bytes read from r in
+// either mode
+func VerifyCanonicalStream(key ed25519.PublicKey, ctx SignatureContext, r io.Reader, sig Signature) error {
+	return verifyStreamed(key, ctx, sig, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// verifyStreamed digests the payload written by write as sig's mode requires
+// and verifies sig over it
+func verifyStreamed(key ed25519.PublicKey, ctx SignatureContext, sig Signature, write func(io.Writer) error) error {
+	switch sig.Mode {
+	case "":
+		h := sha256.New()
+		if err := write(h); err != nil {
+			return err
+		}
+		return VerifyHashSignature(key, ctx, hex.EncodeToString(h.Sum(nil)), sig)
+	case SignModePrehashed:
+		if !PrehashBuild {
+			return errNoPrehash
+		}
+	default:
+		return NewVerificationError(fmt.Sprintf("unsupported signature mode %q", sig.Mode))
+	}
+
+	if sig.Algorithm != SignatureAlgorithm {
+		return NewVerificationError(fmt.Sprintf("unsupported signature algorithm %q", sig.Algorithm))
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return NewVerificationError(fmt.Sprintf("invalid public key for signer %q", sig.Signer))
+	}
+	opts, err := prehashOptions(ctx)
+	if err != nil {
+		return err
+	}
+	value, err := hex.DecodeString(sig.Value)
+	if err != nil {
+		return NewVerificationError(fmt.Sprintf("signature value is not hex encoded: %v", err))
+	}
+	h := sha512.New()
+	if err := write(h); err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(key, h.Sum(nil), value, opts); err != nil {
+		return NewVerificationError(fmt.Sprintf("invalid signature from %q", sig.Signer))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package ocp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// largePayload returns an evidence bundle with n entries
+func largePayload(n int) map[string]interface{} {
+	entries := make([]interface{}, n)
+	for i := range entries {
+		entries[i] = map[string]interface{}{"id": fmt.Sprintf("e-%d", i), "content": strings.Repeat("x", 64)}
+	}
+	return map[string]interface{}{"evidence": entries}
+}
+
+// TestSignPayload tests prehashed signatures over values and streams
+func TestSignPayload(t *testing.T) {
+	testenv.RequireSigning(t)
+	skipWithoutPrehash(t)
+	pub, priv := testKey("Claude")
+	payload := largePayload(5000)
+
+	sig, err := SignPayload("Claude", priv, ContextEvidence, payload)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+	if sig.Mode != SignModePrehashed || sig.ToMap()["mode"] != SignModePrehashed {
+		t.Errorf("Expected the mode to be recorded, got %+v", sig.ToMap())
+	}
+	if err := VerifyPayloadSignature(pub, ContextEvidence, payload, sig); err != nil {
+		t.Errorf("Expected the payload signature to verify, got %v", err)
+	}
+
+	var form bytes.Buffer
+	if err := canonical.Encode(&form, payload); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := VerifyCanonicalStream(pub, ContextEvidence, bytes.NewReader(form.Bytes()), sig); err != nil {
+		t.Errorf("Expected the stream to verify, got %v", err)
+	}
+	streamed, err := SignCanonicalStream("Claude", priv, ContextEvidence, bytes.NewReader(form.Bytes()))
+	if err != nil || streamed.Value != sig.Value {
+		t.Errorf("Signing the stream should match signing the value: %v", err)
+	}
+
+	payload["evidence"].([]interface{})[4999].(map[string]interface{})["content"] = "y"
+	if err := VerifyPayloadSignature(pub, ContextEvidence, payload, sig); err == nil {
+		t.Error("A modified payload should not verify")
+	}
+	if err := VerifyCanonicalStream(pub, ContextProposal, bytes.NewReader(form.Bytes()), sig); err == nil {
+		t.Error("A signature should not verify under another context")
+	}
+	if err := VerifyHashSignature(pub, ContextEvidence, strings.Repeat("00", 32), sig); err == nil {
+		t.Error("A prehashed signature should not verify as a hash signature")
+	}
+	t.Logf("✓ Prehashed signature over %d canonical bytes", form.Len())
+}
+
+// TestPayloadSignatureModes tests that payload verification accepts
+// hash-mode signatures and that the mode survives JSON and ledger payloads
+func TestPayloadSignatureModes(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Gemini")
+	payload := largePayload(3)
+	hash, err := ValueHash(payload)
+	if err != nil {
+		t.Fatalf("ValueHash failed: %v", err)
+	}
+	plain, err := SignHash("Gemini", priv, ContextEvidence, hash)
+	if err != nil {
+		t.Fatalf("SignHash failed: %v", err)
+	}
+	if err := VerifyPayloadSignature(pub, ContextEvidence, payload, plain); err != nil {
+		t.Errorf("Expected a hash-mode signature to verify against its payload, got %v", err)
+	}
+	if _, ok := plain.ToMap()["mode"]; ok {
+		t.Error("Hash-mode signatures should keep their original form")
+	}
+
+	skipWithoutPrehash(t)
+	prehashed, err := SignPayload("Gemini", priv, ContextEvidence, payload)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+	data, _ := json.Marshal(prehashed)
+	var decoded Signature
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != prehashed {
+		t.Errorf("Expected the signature to round-trip through JSON: %s", data)
+	}
+	fromLedger := signaturesFromPayload(map[string]interface{}{"signatures": signatureList([]Signature{prehashed})}, "signatures")
+	if len(fromLedger) != 1 || fromLedger[0] != prehashed {
+		t.Errorf("Expected the mode to survive a ledger payload, got %+v", fromLedger)
+	}
+
+	prehashed.Mode = "bogus"
+	if err := VerifyPayloadSignature(pub, ContextEvidence, payload, prehashed); err == nil {
+		t.Error("An unknown mode should be refused")
+	}
+	t.Logf("✓ Both modes verify against the payload")
+}
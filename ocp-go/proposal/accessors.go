@@ -0,0 +1,59 @@
+// accessors.go - Typed views of a proposal's untyped fields
+//
+// Action, Reasoning, and Evidence are kept as generic maps so that proposals
+// hash exactly as they were received. These helpers read the commonly needed
+// values out of them without each consumer repeating type assertions.
+
+package proposal
+
+// EvidenceRef is a single typed entry of a proposal's evidence list
+type EvidenceRef struct {
+	Type        string `json:"type"`
+	Pointer     string `json:"pointer"`
+	Description string `json:"description,omitempty"`
+}
+
+// Confidence returns reasoning.confidence
+//
+// Returns:
+//   - Confidence value (0.0 to 1.0 per the contract schema)
+//   - false if the field is missing or not a number
+func (cp *ContractProposal) Confidence() (float64, bool) {
+	return toFloat(cp.Reasoning["confidence"])
+}
+
+// Rationale returns reasoning.rationale, or "" if missing or not a string
+func (cp *ContractProposal) Rationale() string {
+	s, _ := cp.Reasoning["rationale"].(string)
+	return s
+}
+
+// ConstitutionalGrounding returns reasoning.constitutional_grounding, skipping
+// any entries that are not strings
+func (cp *ContractProposal) ConstitutionalGrounding() []string {
+	var out []string
+	switch v := cp.Reasoning["constitutional_grounding"].(type) {
+	case []string:
+		out = append(out, v...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// EvidenceRefs returns the evidence list as typed references, in order
+func (cp *ContractProposal) EvidenceRefs() []EvidenceRef {
+	refs := make([]EvidenceRef, 0, len(cp.Evidence))
+	for _, e := range cp.Evidence {
+		refs = append(refs, EvidenceRef{
+			Type:        e["type"],
+			Pointer:     e["pointer"],
+			Description: e["description"],
+		})
+	}
+	return refs
+}
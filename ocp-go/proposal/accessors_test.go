@@ -0,0 +1,68 @@
+package proposal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestTypedAccessors tests reading typed values from the untyped proposal maps
+func TestTypedAccessors(t *testing.T) {
+	proposal := &ContractProposal{
+		Evidence: []map[string]string{
+			{"type": "archive_reference", "pointer": "sha256:abc123def456", "description": "Prior ruling"},
+			{"type": "constitutional_citation", "pointer": "Article-3.1"},
+		},
+		Reasoning: map[string]interface{}{
+			"rationale":                "Clarifies Article III.1",
+			"confidence":               float64(0.87),
+			"constitutional_grounding": []interface{}{"Article III.1", 7, "Article IV"},
+		},
+	}
+
+	confidence, ok := proposal.Confidence()
+	if !ok || confidence != 0.87 {
+		t.Errorf("Expected confidence 0.87, got %v (%v)", confidence, ok)
+	}
+	if proposal.Rationale() != "Clarifies Article III.1" {
+		t.Errorf("Unexpected rationale: %q", proposal.Rationale())
+	}
+	if grounding := proposal.ConstitutionalGrounding(); len(grounding) != 2 || grounding[1] != "Article IV" {
+		t.Errorf("Unexpected grounding: %v", grounding)
+	}
+
+	refs := proposal.EvidenceRefs()
+	if len(refs) != 2 || refs[0].Description != "Prior ruling" || refs[1].Pointer != "Article-3.1" {
+		t.Errorf("Unexpected evidence refs: %+v", refs)
+	}
+
+	t.Logf("✓ Typed accessors: confidence=%v, %d evidence refs", confidence, len(refs))
+}
+
+// TestTypedAccessorsMissingFields tests accessors on malformed or empty maps
+func TestTypedAccessorsMissingFields(t *testing.T) {
+	empty := &ContractProposal{}
+	if _, ok := empty.Confidence(); ok {
+		t.Errorf("Missing confidence should report false")
+	}
+	if empty.Rationale() != "" || len(empty.EvidenceRefs()) != 0 || len(empty.ConstitutionalGrounding()) != 0 {
+		t.Errorf("Empty proposal should yield zero values")
+	}
+
+	wrongTypes := &ContractProposal{Reasoning: map[string]interface{}{
+		"confidence": "high",
+		"rationale":  42,
+	}}
+	if _, ok := wrongTypes.Confidence(); ok {
+		t.Errorf("Non-numeric confidence should report false")
+	}
+	if wrongTypes.Rationale() != "" {
+		t.Errorf("Non-string rationale should yield empty string")
+	}
+
+	decoded := &ContractProposal{Reasoning: map[string]interface{}{"confidence": json.Number("0.5")}}
+	if c, ok := decoded.Confidence(); !ok || c != 0.5 {
+		t.Errorf("json.Number confidence should be read, got %v (%v)", c, ok)
+	}
+
+	t.Logf("✓ Missing and mistyped fields handled")
+}
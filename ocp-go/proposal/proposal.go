@@ -0,0 +1,71 @@
+// proposal.go - OCP contract proposal type
+//
+// ContractProposal mirrors protocol/schemas/contract.schema.json and hashes
+// through the same canonical form as every other constitutional object.
+
+package proposal
+
+import (
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// ContractProposal represents an OCP contract proposal
+type ContractProposal struct {
+	ID                  string                 `json:"id"`
+	ProposerAgent       string                 `json:"proposer_agent"`
+	ActionType          string                 `json:"action_type"`
+	Action              map[string]interface{} `json:"action"`
+	Evidence            []map[string]string    `json:"evidence"`
+	Reasoning           map[string]interface{} `json:"reasoning"`
+	ReversibilityClass  string                 `json:"reversibility_class"`
+	PreStateHash        string                 `json:"pre_state_hash"`
+	PostStateHash       string                 `json:"post_state_hash"`
+	CanonicalSerialized string                 `json:"canonical_serialization"`
+	Timestamp           string                 `json:"timestamp"`
+	ProposerSignature   map[string]string      `json:"proposer_signature"`
+	ReputationStake     int                    `json:"reputation_stake"`
+	// ActivationHeight, if set, is the ledger height at which a ratified
+	// proposal takes effect
+	ActivationHeight uint64 `json:"activation_height,omitempty"`
+	// ActivationTime, if set, is the RFC 3339 time, measured by ledger time,
+	// at which a ratified proposal takes effect
+	ActivationTime string `json:"activation_time,omitempty"`
+}
+
+// ToMap converts a ContractProposal to a map for canonicalization
+func (cp *ContractProposal) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"id":                      cp.ID,
+		"proposer_agent":          cp.ProposerAgent,
+		"action_type":             cp.ActionType,
+		"action":                  cp.Action,
+		"evidence":                cp.Evidence,
+		"reasoning":               cp.Reasoning,
+		"reversibility_class":     cp.ReversibilityClass,
+		"pre_state_hash":          cp.PreStateHash,
+		"post_state_hash":         cp.PostStateHash,
+		"canonical_serialization": cp.CanonicalSerialized,
+		"timestamp":               cp.Timestamp,
+		"proposer_signature":      cp.ProposerSignature,
+		"reputation_stake":        cp.ReputationStake,
+	}
+	// The activation fields are omitted when unset so proposals without a
+	// time lock keep their hashes
+	if cp.ActivationHeight > 0 {
+		m["activation_height"] = cp.ActivationHeight
+	}
+	if cp.ActivationTime != "" {
+		m["activation_time"] = cp.ActivationTime
+	}
+	return m
+}
+
+// GetHash returns the semantic hash of this contract proposal
+func (cp *ContractProposal) GetHash() (string, error) {
+	return hashing.SemanticHash(cp.ToMap())
+}
+
+// VerifyHash verifies the contract against an expected hash
+func (cp *ContractProposal) VerifyHash(expectedHash string) (bool, error) {
+	return hashing.VerifySemanticHash(cp.ToMap(), expectedHash)
+}
@@ -0,0 +1,59 @@
+package proposal
+
+import (
+	"testing"
+)
+
+// TestContractProposalType tests the ContractProposal helper type
+func TestContractProposalType(t *testing.T) {
+	proposal := &ContractProposal{
+		ID:            "550e8400-e29b-41d4-a716-446655440000",
+		ProposerAgent: "Claude",
+		ActionType:    "amend",
+		Action: map[string]interface{}{
+			"target":    "amendment-article-3",
+			"operation": "modify",
+		},
+		Evidence: []map[string]string{
+			{
+				"type":    "archive_reference",
+				"pointer": "sha256:abc123def456",
+			},
+		},
+		Reasoning: map[string]interface{}{
+			"rationale":  "Clarifies Article III.1",
+			"confidence": float64(0.87),
+		},
+		ReversibilityClass:  "partially_reversible",
+		PreStateHash:        "sha256:1234567890abcdef",
+		PostStateHash:       "sha256:fedcba0987654321",
+		CanonicalSerialized: "{...}",
+		Timestamp:           "2025-11-20T14:30:00Z",
+		ProposerSignature: map[string]string{
+			"algorithm": "ed25519",
+			"value":     "3a4b5c6d7e8f9a0b",
+		},
+		ReputationStake: 60,
+	}
+
+	hash, err := proposal.GetHash()
+	if err != nil {
+		t.Fatalf("Failed to get proposal hash: %v", err)
+	}
+
+	if len(hash) != 64 {
+		t.Errorf("Hash should be 64 hex characters")
+	}
+
+	valid, err := proposal.VerifyHash(hash)
+	if err != nil {
+		t.Fatalf("Failed to verify proposal hash: %v", err)
+	}
+
+	if !valid {
+		t.Errorf("Proposal hash should verify")
+	}
+
+	t.Logf("✓ ContractProposal hash: %s", hash)
+	t.Logf("✓ ContractProposal hash verifies")
+}
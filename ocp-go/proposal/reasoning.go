@@ -0,0 +1,241 @@
+// reasoning.go - Typed proposal reasoning
+//
+// The contract's reasoning object stays a generic map on ContractProposal so
+// that proposals hash exactly as received. Reasoning is an optional typed view
+// of it for agents that want structured, calibrated reasoning: it parses the
+// map, validates confidence and evidence citations, and converts back to a map
+// that hashes identically to the one it was parsed from. Members it does not
+// model are kept in Extra.
+
+package proposal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Reasoning is the typed form of a proposal's reasoning object
+type Reasoning struct {
+	Rationale               string   `json:"rationale"`
+	Confidence              float64  `json:"confidence"`
+	ConstitutionalGrounding []string `json:"constitutional_grounding,omitempty"`
+	AlternativesConsidered  []string `json:"alternatives_considered,omitempty"`
+	Uncertainties           []string `json:"uncertainties,omitempty"`
+	Assumptions             []string `json:"assumptions,omitempty"`
+	// CitedEvidence lists pointers into the proposal's evidence list
+	CitedEvidence []string `json:"cited_evidence,omitempty"`
+	ModelVersion  string   `json:"model_version,omitempty"`
+	// Extra holds reasoning members not modeled above
+	Extra map[string]interface{} `json:"-"`
+
+	// present records which members were in the parsed map, so that empty
+	// values survive a round trip; nil for a Reasoning built in code
+	present map[string]bool
+	// rawConfidence is the parsed confidence in its original numeric form
+	rawConfidence interface{}
+	// rawLists holds the parsed list members in their original form, since
+	// canonicalization sorts a []interface{} of strings but not a []string
+	rawLists map[string]interface{}
+}
+
+// reasoningLists maps the list members of Reasoning to their fields
+func (r *Reasoning) reasoningLists() map[string]*[]string {
+	return map[string]*[]string{
+		"constitutional_grounding": &r.ConstitutionalGrounding,
+		"alternatives_considered":  &r.AlternativesConsidered,
+		"uncertainties":            &r.Uncertainties,
+		"assumptions":              &r.Assumptions,
+		"cited_evidence":           &r.CitedEvidence,
+	}
+}
+
+// ParseReasoning reads a reasoning map into a Reasoning
+//
+// Returns:
+//   - Typed reasoning whose ToMap hashes identically to m
+//   - ConstitutionalError if a modeled member has the wrong type
+func ParseReasoning(m map[string]interface{}) (*Reasoning, error) {
+	r := &Reasoning{present: make(map[string]bool), Extra: make(map[string]interface{}), rawLists: make(map[string]interface{})}
+	lists := r.reasoningLists()
+	for key, value := range m {
+		r.present[key] = true
+		switch key {
+		case "rationale":
+			s, ok := value.(string)
+			if !ok {
+				return nil, reasoningTypeError(key, "a string")
+			}
+			r.Rationale = s
+		case "confidence":
+			f, ok := toFloat(value)
+			if !ok {
+				return nil, reasoningTypeError(key, "a number")
+			}
+			r.Confidence, r.rawConfidence = f, value
+		case "model_version":
+			s, ok := value.(string)
+			if !ok {
+				return nil, reasoningTypeError(key, "a string")
+			}
+			r.ModelVersion = s
+		default:
+			field, ok := lists[key]
+			if !ok {
+				r.Extra[key] = value
+				continue
+			}
+			list, ok := toStrings(value)
+			if !ok {
+				return nil, reasoningTypeError(key, "an array of strings")
+			}
+			*field, r.rawLists[key] = list, value
+		}
+	}
+	return r, nil
+}
+
+// ToMap converts a Reasoning to the map stored on a ContractProposal. Members
+// that were parsed are always emitted; others are emitted when non-empty, and
+// rationale and confidence always are for reasoning built in code. A parsed
+// list left unchanged is emitted as the value it was parsed from; others are
+// emitted as []interface{}, the form a decoded proposal holds.
+func (r *Reasoning) ToMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(r.Extra)+8)
+	for key, value := range r.Extra {
+		m[key] = value
+	}
+	emit := func(key string, empty, required bool) bool {
+		return r.present[key] || !empty || (r.present == nil && required)
+	}
+	if emit("rationale", r.Rationale == "", true) {
+		m["rationale"] = r.Rationale
+	}
+	if emit("confidence", r.Confidence == 0, true) {
+		m["confidence"] = r.Confidence
+		if f, ok := toFloat(r.rawConfidence); ok && f == r.Confidence {
+			m["confidence"] = r.rawConfidence
+		}
+	}
+	if emit("model_version", r.ModelVersion == "", false) {
+		m["model_version"] = r.ModelVersion
+	}
+	for key, field := range r.reasoningLists() {
+		if emit(key, *field == nil, false) {
+			if raw, ok := r.rawLists[key]; ok && sameStrings(raw, *field) {
+				m[key] = raw
+				continue
+			}
+			list := make([]interface{}, len(*field))
+			for i, s := range *field {
+				list[i] = s
+			}
+			m[key] = list
+		}
+	}
+	return m
+}
+
+// Validate checks calibration and citation rules
+//
+// Parameters:
+//   - evidence: The proposal's evidence list, which citations must resolve to
+//
+// Returns:
+//   - ConstitutionalError describing the first violation
+func (r *Reasoning) Validate(evidence []map[string]string) error {
+	if r.Rationale == "" {
+		return canonical.NewConstitutionalError("reasoning.rationale is empty")
+	}
+	if math.IsNaN(r.Confidence) || r.Confidence < 0 || r.Confidence > 1 {
+		return canonical.NewConstitutionalError(fmt.Sprintf("reasoning.confidence %v is outside [0, 1]", r.Confidence))
+	}
+	pointers := make(map[string]bool, len(evidence))
+	for _, e := range evidence {
+		pointers[e["pointer"]] = true
+	}
+	for i, cited := range r.CitedEvidence {
+		if !pointers[cited] {
+			return canonical.NewConstitutionalError(fmt.Sprintf("reasoning.cited_evidence[%d] %q does not resolve to an evidence entry", i, cited))
+		}
+	}
+	return nil
+}
+
+// TypedReasoning parses and validates the proposal's reasoning
+func (cp *ContractProposal) TypedReasoning() (*Reasoning, error) {
+	r, err := ParseReasoning(cp.Reasoning)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Validate(cp.Evidence); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetReasoning validates r against the proposal's evidence and stores its map form
+func (cp *ContractProposal) SetReasoning(r *Reasoning) error {
+	if err := r.Validate(cp.Evidence); err != nil {
+		return err
+	}
+	cp.Reasoning = r.ToMap()
+	return nil
+}
+
+func reasoningTypeError(key, want string) error {
+	return canonical.NewConstitutionalError(fmt.Sprintf("reasoning.%s must be %s", key, want))
+}
+
+// toFloat reads a JSON number in any of the forms a decoded map may hold
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toStrings reads an array of strings
+func toStrings(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return append([]string{}, v...), true
+	case []interface{}:
+		out := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out[i] = s
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// sameStrings reports whether value is an array of exactly the strings in list
+func sameStrings(value interface{}, list []string) bool {
+	parsed, ok := toStrings(value)
+	if !ok || len(parsed) != len(list) {
+		return false
+	}
+	for i := range parsed {
+		if parsed[i] != list[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package proposal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// TestReasoningRoundTrip tests that typed reasoning hashes like the map it came from
+func TestReasoningRoundTrip(t *testing.T) {
+	original := map[string]interface{}{
+		"rationale":                "Clarifies Article III.1",
+		"confidence":               json.Number("0.870"),
+		"constitutional_grounding": []interface{}{"Article III.1"},
+		"uncertainties":            []interface{}{},
+		"model_version":            "",
+		"reviewer_notes":           map[string]interface{}{"round": float64(2)},
+	}
+	r, err := ParseReasoning(original)
+	if err != nil {
+		t.Fatalf("Failed to parse reasoning: %v", err)
+	}
+	if r.Confidence != 0.87 || len(r.ConstitutionalGrounding) != 1 || r.Extra["reviewer_notes"] == nil {
+		t.Errorf("Unexpected typed reasoning: %+v", r)
+	}
+
+	want, _ := hashing.SemanticHash(original)
+	got, _ := hashing.SemanticHash(r.ToMap())
+	if got != want {
+		t.Errorf("Round trip changed the hash: %s != %s", got, want)
+	}
+
+	// A list held as []string canonicalizes unsorted, so it must come back
+	// in that form rather than as []interface{}
+	typed := map[string]interface{}{"rationale": "r", "confidence": 0.5, "assumptions": []string{"b", "a"}}
+	parsed, err := ParseReasoning(typed)
+	if err != nil {
+		t.Fatalf("Failed to parse reasoning: %v", err)
+	}
+	want, _ = hashing.SemanticHash(typed)
+	if got, _ := hashing.SemanticHash(parsed.ToMap()); got != want {
+		t.Errorf("Round trip of a []string list changed the hash: %s != %s", got, want)
+	}
+	parsed.Assumptions = append(parsed.Assumptions, "c")
+	if list, ok := parsed.ToMap()["assumptions"].([]interface{}); !ok || len(list) != 3 {
+		t.Errorf("Expected an edited list to be rebuilt, got %v", parsed.ToMap()["assumptions"])
+	}
+
+	if _, err := ParseReasoning(map[string]interface{}{"assumptions": []interface{}{"a", 1}}); err == nil {
+		t.Errorf("Non-string assumptions should be rejected")
+	}
+
+	t.Logf("✓ Reasoning round trip hash: %s", got)
+}
+
+// TestReasoningValidate tests confidence bounds and citation resolution
+func TestReasoningValidate(t *testing.T) {
+	cp := &ContractProposal{
+		Evidence: []map[string]string{{"type": "archive_reference", "pointer": "sha256:abc123def456"}},
+	}
+	r := &Reasoning{
+		Rationale:     "Clarifies Article III.1",
+		Confidence:    0,
+		Assumptions:   []string{"Article III.1 is in force"},
+		CitedEvidence: []string{"sha256:abc123def456"},
+		ModelVersion:  "claude-3",
+	}
+	if err := cp.SetReasoning(r); err != nil {
+		t.Fatalf("Valid reasoning rejected: %v", err)
+	}
+	if _, ok := cp.Reasoning["confidence"]; !ok {
+		t.Errorf("Zero confidence should still be emitted")
+	}
+	if _, ok := cp.Reasoning["uncertainties"]; ok {
+		t.Errorf("Unset lists should be omitted")
+	}
+	typed, err := cp.TypedReasoning()
+	if err != nil || typed.ModelVersion != "claude-3" {
+		t.Errorf("Failed to read back typed reasoning: %v", err)
+	}
+
+	r.Confidence = 1.2
+	if err := r.Validate(cp.Evidence); err == nil {
+		t.Errorf("Confidence above 1 should be rejected")
+	}
+	r.Confidence = 0.5
+	r.CitedEvidence = append(r.CitedEvidence, "sha256:missing")
+	if err := r.Validate(cp.Evidence); err == nil {
+		t.Errorf("Unresolved citation should be rejected")
+	}
+
+	t.Logf("✓ Reasoning validation enforced")
+}
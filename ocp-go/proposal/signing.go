@@ -0,0 +1,30 @@
+// signing.go - The signed view of a contract proposal
+//
+// Per contract.schema.json, canonical_serialization covers the contract
+// excluding itself and the proposer signature. That same view is what the
+// proposer signs, so signing never depends on the signature being produced.
+
+package proposal
+
+import (
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/hashing"
+)
+
+// SigningMap returns ToMap without canonical_serialization and proposer_signature
+func (cp *ContractProposal) SigningMap() map[string]interface{} {
+	m := cp.ToMap()
+	delete(m, "canonical_serialization")
+	delete(m, "proposer_signature")
+	return m
+}
+
+// CanonicalSigningForm returns the expected value of CanonicalSerialized
+func (cp *ContractProposal) CanonicalSigningForm() (string, error) {
+	return canonical.Canonicalize(cp.SigningMap(), true)
+}
+
+// SigningHash returns the semantic hash the proposer signs
+func (cp *ContractProposal) SigningHash() (string, error) {
+	return hashing.SemanticHash(cp.SigningMap())
+}
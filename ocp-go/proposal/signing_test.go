@@ -0,0 +1,28 @@
+package proposal
+
+import "testing"
+
+// TestSigningHashExcludesSignature tests that the signed view ignores the signature
+func TestSigningHashExcludesSignature(t *testing.T) {
+	proposal := &ContractProposal{ID: "p-1", ProposerAgent: "Claude", ActionType: "amend"}
+
+	before, err := proposal.SigningHash()
+	if err != nil {
+		t.Fatalf("Failed to compute signing hash: %v", err)
+	}
+
+	proposal.CanonicalSerialized, _ = proposal.CanonicalSigningForm()
+	proposal.ProposerSignature = map[string]string{"algorithm": "ed25519", "value": "00"}
+
+	after, _ := proposal.SigningHash()
+	if before != after {
+		t.Errorf("Signing hash should not depend on signature or canonical serialization")
+	}
+
+	full, _ := proposal.GetHash()
+	if full == after {
+		t.Errorf("Full hash should cover the signature")
+	}
+
+	t.Logf("✓ Signing hash: %s", after)
+}
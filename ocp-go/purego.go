@@ -0,0 +1,14 @@
+//go:build purego
+
+// purego.go - Portable build profile
+//
+// Building with -tags purego selects the portable implementation of every
+// accelerated path. The tag is the one the Go standard library already honours
+// to drop the assembly in crypto/sha256 and crypto/ed25519, so one flag gives
+// the same generic code on every architecture. Operators use it to reproduce a
+// bug seen on an edge device from an amd64 workstation.
+
+package ocp
+
+// PureGoBuild reports whether this binary was built with the purego tag
+const PureGoBuild = true
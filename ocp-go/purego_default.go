@@ -0,0 +1,6 @@
+//go:build !purego
+
+package ocp
+
+// PureGoBuild reports whether this binary was built with the purego tag
+const PureGoBuild = false
@@ -0,0 +1,342 @@
+// quarantine.go - Retention of objects that fail verification
+//
+// An object that fails verification is usually dropped, and with it the
+// evidence of who sent it and how it was malformed. A Quarantine keeps such
+// objects instead, each with the checks it failed, the agent it is
+// attributed to, and where it came from. Operators query it to find attack
+// patterns (the same agent failing the same check, a peer relaying forged
+// entries), export it as a framed stream for offline analysis, and archive a
+// record to cite as the evidence of a FraudProof.
+//
+// Records are keyed by the semantic hash of the object, so an object seen
+// again is counted rather than stored twice. A quarantine holds at most its
+// capacity of records and evicts the least recently seen first.
+
+package ocp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Kinds of quarantined object
+const (
+	QuarantineProposal    = "proposal"
+	QuarantineLedgerEntry = "ledger_entry"
+)
+
+// DefaultQuarantineCapacity is the capacity of a quarantine created with zero
+const DefaultQuarantineCapacity = 10000
+
+// QuarantineRecord is an object that failed verification and why
+type QuarantineRecord struct {
+	// ID is the semantic hash of Object
+	ID   string
+	Kind string
+	// Agent is the agent the object is attributed to, if known
+	Agent string
+	// Source says where the object came from, e.g. a peer or "watchdog"
+	Source    string
+	Object    map[string]interface{}
+	Failures  []CheckResult
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Count is the number of times the object was admitted
+	Count int
+}
+
+// ToMap converts a QuarantineRecord to a map for canonicalization
+func (r *QuarantineRecord) ToMap() map[string]interface{} {
+	failures := make([]interface{}, len(r.Failures))
+	for i, f := range r.Failures {
+		failures[i] = f.ToMap()
+	}
+	return map[string]interface{}{
+		"id":         r.ID,
+		"kind":       r.Kind,
+		"agent":      r.Agent,
+		"source":     r.Source,
+		"object":     r.Object,
+		"failures":   failures,
+		"first_seen": Timestamp(r.FirstSeen),
+		"last_seen":  Timestamp(r.LastSeen),
+		"count":      r.Count,
+	}
+}
+
+// QuarantineRecordFromMap parses a record in the form produced by ToMap
+func QuarantineRecordFromMap(m map[string]interface{}) (*QuarantineRecord, error) {
+	r := &QuarantineRecord{
+		ID:     payloadString(m, "id"),
+		Kind:   payloadString(m, "kind"),
+		Agent:  payloadString(m, "agent"),
+		Source: payloadString(m, "source"),
+		Count:  payloadInt(m, "count"),
+	}
+	r.Object, _ = m["object"].(map[string]interface{})
+	if r.Object == nil {
+		return nil, NewConstitutionalError(fmt.Sprintf("quarantine record %s has no object", r.ID))
+	}
+	if id, err := SemanticHash(r.Object); err != nil || id != r.ID {
+		return nil, NewVerificationError(fmt.Sprintf("quarantine record %s does not match its object", r.ID))
+	}
+	for _, key := range []string{"first_seen", "last_seen"} {
+		t, err := time.Parse(time.RFC3339Nano, payloadString(m, key))
+		if err != nil {
+			return nil, NewConstitutionalError(fmt.Sprintf("quarantine record %s has an invalid %s", r.ID, key))
+		}
+		if key == "first_seen" {
+			r.FirstSeen = t
+		} else {
+			r.LastSeen = t
+		}
+	}
+	failures, _ := m["failures"].([]interface{})
+	for _, item := range failures {
+		f, _ := item.(map[string]interface{})
+		r.Failures = append(r.Failures, CheckResult{
+			Check:   payloadString(f, "check"),
+			Status:  CheckStatus(payloadString(f, "status")),
+			Code:    payloadString(f, "code"),
+			Message: payloadString(f, "message"),
+		})
+	}
+	return r, nil
+}
+
+// QuarantineQuery selects records; zero fields match everything
+type QuarantineQuery struct {
+	Kind   string
+	Agent  string
+	Source string
+	// Code matches records with a failure carrying this verification code
+	Code string
+	// Since and Until bound LastSeen
+	Since time.Time
+	Until time.Time
+}
+
+// matches reports whether r is selected by query
+func (query QuarantineQuery) matches(r *QuarantineRecord) bool {
+	switch {
+	case query.Kind != "" && r.Kind != query.Kind,
+		query.Agent != "" && r.Agent != query.Agent,
+		query.Source != "" && r.Source != query.Source,
+		!query.Since.IsZero() && r.LastSeen.Before(query.Since),
+		!query.Until.IsZero() && r.LastSeen.After(query.Until):
+		return false
+	}
+	if query.Code == "" {
+		return true
+	}
+	for _, f := range r.Failures {
+		if f.Code == query.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// Quarantine stores objects that failed verification
+type Quarantine struct {
+	mu       sync.Mutex
+	capacity int
+	records  map[string]*QuarantineRecord
+	// Clock supplies admission times; defaults to SystemClock
+	Clock Clock
+}
+
+// NewQuarantine creates a quarantine holding at most capacity records, or
+// DefaultQuarantineCapacity if capacity is zero
+func NewQuarantine(capacity int) *Quarantine {
+	if capacity <= 0 {
+		capacity = DefaultQuarantineCapacity
+	}
+	return &Quarantine{capacity: capacity, records: make(map[string]*QuarantineRecord)}
+}
+
+// Admit retains obj with the checks it failed. Admitting an object already
+// held updates its last-seen time and count and adds any new failures.
+//
+// Returns:
+//   - A copy of the stored record
+func (q *Quarantine) Admit(kind, agent, source string, obj map[string]interface{}, failures []CheckResult) (QuarantineRecord, error) {
+	id, err := SemanticHash(obj)
+	if err != nil {
+		return QuarantineRecord{}, err
+	}
+	now := clockOrSystem(q.Clock).Now().UTC()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r, ok := q.records[id]
+	if !ok {
+		if len(q.records) >= q.capacity {
+			q.evictLocked()
+		}
+		r = &QuarantineRecord{
+			ID:        id,
+			Kind:      kind,
+			Agent:     agent,
+			Source:    source,
+			Object:    canonical.DeepCopy(obj).(map[string]interface{}),
+			FirstSeen: now,
+		}
+		q.records[id] = r
+	}
+	r.LastSeen = now
+	r.Count++
+	for _, f := range failures {
+		if !containsFailure(r.Failures, f) {
+			r.Failures = append(r.Failures, f)
+		}
+	}
+	return copyRecord(r), nil
+}
+
+// AdmitProposal retains p if report has failures. A proposal that is merely
+// not yet recorded on the ledger is not malformed and is not retained.
+//
+// Returns:
+//   - The stored record, or false if p was not retained
+func (q *Quarantine) AdmitProposal(p *ContractProposal, report *VerificationReport, source string) (QuarantineRecord, bool, error) {
+	var failures []CheckResult
+	for _, f := range report.Failures() {
+		if f.Code != CodeChainNotRecorded {
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		return QuarantineRecord{}, false, nil
+	}
+	r, err := q.Admit(QuarantineProposal, p.ProposerAgent, source, p.ToMap(), failures)
+	return r, err == nil, err
+}
+
+// AdmitEntry retains a ledger entry, with its recorded hash, and the checks
+// it failed. The entry is attributed to the proposer its payload names.
+func (q *Quarantine) AdmitEntry(e LedgerEntry, failures []CheckResult, source string) (QuarantineRecord, error) {
+	obj := e.ToMap()
+	obj["hash"] = e.Hash
+	return q.Admit(QuarantineLedgerEntry, payloadString(e.Payload, "proposer_agent"), source, obj, failures)
+}
+
+// Get returns the record with id
+func (q *Quarantine) Get(id string) (QuarantineRecord, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r, ok := q.records[id]
+	if !ok {
+		return QuarantineRecord{}, false
+	}
+	return copyRecord(r), true
+}
+
+// Len returns the number of records held
+func (q *Quarantine) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.records)
+}
+
+// Query returns the records query selects, in order of first sighting
+func (q *Quarantine) Query(query QuarantineQuery) []QuarantineRecord {
+	q.mu.Lock()
+	var out []QuarantineRecord
+	for _, r := range q.records {
+		if query.matches(r) {
+			out = append(out, copyRecord(r))
+		}
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].FirstSeen.Equal(out[j].FirstSeen) {
+			return out[i].FirstSeen.Before(out[j].FirstSeen)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Release drops the record with id, e.g. once an investigation is closed
+func (q *Quarantine) Release(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.records[id]
+	delete(q.records, id)
+	return ok
+}
+
+// Export writes the records query selects to w as a canonical framed
+// stream, in the order Query returns them
+func (q *Quarantine) Export(w io.Writer, query QuarantineQuery) error {
+	records := q.Query(query)
+	objects := make([]map[string]interface{}, len(records))
+	for i := range records {
+		objects[i] = records[i].ToMap()
+	}
+	return canonical.EncodeFramed(w, objects...)
+}
+
+// ImportQuarantine reads records written by Export, checking that each
+// record's ID is its object's hash
+func ImportQuarantine(r io.Reader) ([]QuarantineRecord, error) {
+	objects, err := canonical.DecodeFramed(r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]QuarantineRecord, 0, len(objects))
+	for _, obj := range objects {
+		record, err := QuarantineRecordFromMap(obj)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *record)
+	}
+	return out, nil
+}
+
+// Evidence archives the record with id and returns its archive reference,
+// for a FraudProof's evidence
+func (q *Quarantine) Evidence(id string, archive Archive) (string, error) {
+	r, ok := q.Get(id)
+	if !ok {
+		return "", NewConstitutionalError(fmt.Sprintf("no quarantined object %s", id))
+	}
+	return ArchiveObject(archive, r.ToMap())
+}
+
+// evictLocked drops the least recently seen record
+func (q *Quarantine) evictLocked() {
+	var oldest *QuarantineRecord
+	for _, r := range q.records {
+		if oldest == nil || r.LastSeen.Before(oldest.LastSeen) || (r.LastSeen.Equal(oldest.LastSeen) && r.ID < oldest.ID) {
+			oldest = r
+		}
+	}
+	if oldest != nil {
+		delete(q.records, oldest.ID)
+	}
+}
+
+// copyRecord detaches a record from the store
+func copyRecord(r *QuarantineRecord) QuarantineRecord {
+	out := *r
+	out.Object = canonical.DeepCopy(r.Object).(map[string]interface{})
+	out.Failures = append([]CheckResult(nil), r.Failures...)
+	return out
+}
+
+func containsFailure(failures []CheckResult, f CheckResult) bool {
+	for _, existing := range failures {
+		if existing == f {
+			return true
+		}
+	}
+	return false
+}
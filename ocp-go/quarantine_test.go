@@ -0,0 +1,153 @@
+package ocp
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestQuarantineAdmit tests admission, repeat sightings, and queries
+func TestQuarantineAdmit(t *testing.T) {
+	testenv.RequireSigning(t)
+	clock := NewManualClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQuarantine(0)
+	q.Clock = clock
+
+	honest, keys := signedTestProposal(t)
+	if _, ok, err := q.AdmitProposal(honest, VerifyProposalFull(honest, WithProposerKeys(keys)), "peer-a"); ok || err != nil {
+		t.Fatalf("Expected an honest proposal not to be retained, got %v, %v", ok, err)
+	}
+
+	forged, _ := signedTestProposal(t)
+	forged.Action["operation"] = "delete"
+	report := VerifyProposalFull(forged, WithProposerKeys(keys))
+	first, ok, err := q.AdmitProposal(forged, report, "peer-a")
+	if !ok || err != nil {
+		t.Fatalf("Expected the forged proposal retained, got %v, %v", ok, err)
+	}
+	if first.Agent != "Claude" || first.Kind != QuarantineProposal || first.Count != 1 {
+		t.Errorf("Unexpected record %+v", first)
+	}
+
+	clock.Advance(time.Hour)
+	again, _, _ := q.AdmitProposal(forged, report, "peer-a")
+	if q.Len() != 1 || again.ID != first.ID || again.Count != 2 || !again.LastSeen.After(again.FirstSeen) {
+		t.Errorf("Expected a repeat sighting to be counted, got %+v", again)
+	}
+
+	unsigned, _ := signedTestProposal(t)
+	unsigned.ProposerSignature = nil
+	q.AdmitProposal(unsigned, VerifyProposalFull(unsigned, WithProposerKeys(keys)), "peer-b")
+
+	cases := []struct {
+		query QuarantineQuery
+		want  int
+	}{
+		{QuarantineQuery{}, 2},
+		{QuarantineQuery{Agent: "Claude"}, 2},
+		{QuarantineQuery{Agent: "Gemini"}, 0},
+		{QuarantineQuery{Source: "peer-b"}, 1},
+		{QuarantineQuery{Code: CodeSignatureMissing}, 1},
+		{QuarantineQuery{Kind: QuarantineLedgerEntry}, 0},
+		{QuarantineQuery{Since: clock.Now().Add(-time.Minute)}, 2},
+		{QuarantineQuery{Until: clock.Now().Add(-time.Minute)}, 0},
+	}
+	for i, tc := range cases {
+		if got := q.Query(tc.query); len(got) != tc.want {
+			t.Errorf("case %d: expected %d records, got %d", i, tc.want, len(got))
+		}
+	}
+
+	// Records are copies
+	got, _ := q.Get(first.ID)
+	got.Object["id"] = "changed"
+	if held, _ := q.Get(first.ID); held.Object["id"] == "changed" {
+		t.Error("Mutating a returned record changed the store")
+	}
+	if !q.Release(first.ID) || q.Len() != 1 {
+		t.Error("Expected the record released")
+	}
+
+	t.Logf("✓ %d queries over quarantined proposals", len(cases))
+}
+
+// TestQuarantineCapacity tests that the least recently seen record is evicted
+func TestQuarantineCapacity(t *testing.T) {
+	clock := NewManualClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQuarantine(2)
+	q.Clock = clock
+	failure := []CheckResult{failed(CheckSchema, CodeSchemaMissingField, "missing id")}
+	a, _ := q.Admit(QuarantineProposal, "Claude", "peer", map[string]interface{}{"n": 1}, failure)
+	clock.Advance(time.Second)
+	b, _ := q.Admit(QuarantineProposal, "Claude", "peer", map[string]interface{}{"n": 2}, failure)
+	clock.Advance(time.Second)
+	q.Admit(QuarantineProposal, "Claude", "peer", map[string]interface{}{"n": 1}, failure)
+	clock.Advance(time.Second)
+	q.Admit(QuarantineProposal, "Claude", "peer", map[string]interface{}{"n": 3}, failure)
+
+	if _, ok := q.Get(b.ID); ok || q.Len() != 2 {
+		t.Errorf("Expected the least recently seen record evicted")
+	}
+	if _, ok := q.Get(a.ID); !ok {
+		t.Errorf("Expected the record seen again to be kept")
+	}
+	t.Logf("✓ Capacity of 2 held")
+}
+
+// TestQuarantineExport tests export, import, and archived evidence
+func TestQuarantineExport(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, _ := testQuorum(t)
+	ledger := migrationLedger(t, 3)
+	ledger.entries[1].Payload = map[string]interface{}{"proposal_hash": "rewritten", "proposer_agent": "DeepSeek"}
+	_, priv := testKey("Gemini")
+	q := NewQuarantine(0)
+	dog := NewWatchdog("Gemini", priv, quorum)
+	dog.Quarantine = q
+	if _, err := dog.InspectLedger(ledger); err != nil {
+		t.Fatalf("InspectLedger failed: %v", err)
+	}
+	held := q.Query(QuarantineQuery{Agent: "DeepSeek", Code: CodeChainBroken})
+	if len(held) != 1 || held[0].Source != "watchdog" || held[0].Kind != QuarantineLedgerEntry {
+		t.Fatalf("Expected the rewritten entry quarantined, got %+v", q.Query(QuarantineQuery{}))
+	}
+
+	var buf bytes.Buffer
+	if err := q.Export(&buf, QuarantineQuery{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	records, err := ImportQuarantine(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(records) != q.Len() {
+		t.Fatalf("Expected %d records back, got %d, %v", q.Len(), len(records), err)
+	}
+	if records[0].ID != held[0].ID || !records[0].LastSeen.Equal(held[0].LastSeen) || len(records[0].Failures) != len(held[0].Failures) {
+		t.Errorf("Round trip changed the record: %+v", records[0])
+	}
+
+	// A record whose object was altered after export is refused
+	forged := held[0].ToMap()
+	forged["object"].(map[string]interface{})["kind"] = "constitution"
+	var tampered bytes.Buffer
+	canonical.EncodeFramed(&tampered, forged)
+	var ce *ConstitutionalError
+	if _, err := ImportQuarantine(&tampered); !errors.As(err, &ce) || ce.ErrorType != "VerificationError" {
+		t.Errorf("Expected an altered record refused, got %v", err)
+	}
+
+	archive := NewMemoryArchive()
+	reference, err := q.Evidence(held[0].ID, archive)
+	if err != nil {
+		t.Fatalf("Evidence failed: %v", err)
+	}
+	if _, err := archive.Get(reference); err != nil {
+		t.Errorf("Evidence was not archived: %v", err)
+	}
+	if _, err := q.Evidence("missing", archive); err == nil {
+		t.Error("Expected evidence for an unknown record to fail")
+	}
+	t.Logf("✓ %d records exported and archived as %s", len(records), reference)
+}
@@ -0,0 +1,64 @@
+// quorum.go - Threshold verification of multi-agent signatures
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+)
+
+// Quorum is a set of agents with registered public keys and the number of
+// distinct valid signatures required to approve an object.
+type Quorum struct {
+	Members   map[string]ed25519.PublicKey
+	Threshold int
+}
+
+// NewQuorum creates a Quorum, rejecting thresholds that can never be met
+func NewQuorum(members map[string]ed25519.PublicKey, threshold int) (*Quorum, error) {
+	if threshold < 1 || threshold > len(members) {
+		return nil, NewConstitutionalError(fmt.Sprintf("quorum threshold %d out of range for %d members", threshold, len(members)))
+	}
+	return &Quorum{Members: members, Threshold: threshold}, nil
+}
+
+// Verify checks that hash is signed under ctx by at least Threshold distinct members.
+// Signatures from non-members, duplicates, and invalid signatures are ignored
+// when counting, so a single bad signature cannot block an otherwise valid quorum.
+//
+// Returns:
+//   - Sorted list of members whose signatures were counted
+//   - VerificationError if the threshold is not reached
+func (q *Quorum) Verify(ctx SignatureContext, hash string, sigs []Signature) ([]string, error) {
+	return q.VerifyCached(nil, ctx, hash, sigs)
+}
+
+// VerifyCached is Verify, answering repeated signature checks from cache
+func (q *Quorum) VerifyCached(cache *SignatureCache, ctx SignatureContext, hash string, sigs []Signature) ([]string, error) {
+	if q == nil {
+		return nil, NewVerificationError("no quorum configured")
+	}
+
+	counted := make(map[string]bool)
+	for _, sig := range sigs {
+		key, ok := q.Members[sig.Signer]
+		if !ok || counted[sig.Signer] {
+			continue
+		}
+		if cache.Verify(key, ctx, hash, sig) == nil {
+			counted[sig.Signer] = true
+		}
+	}
+
+	signers := make([]string, 0, len(counted))
+	for signer := range counted {
+		signers = append(signers, signer)
+	}
+	sort.Strings(signers)
+
+	if len(signers) < q.Threshold {
+		return signers, NewVerificationError(fmt.Sprintf("quorum not reached: %d of %d required signatures", len(signers), q.Threshold))
+	}
+	return signers, nil
+}
@@ -0,0 +1,295 @@
+// replay.go - Full-ledger replay under the rules in force at each height
+//
+// An annual audit cannot assume that today's rules are the ones every entry
+// was written under: the canonicalization version may have been switched by
+// a canonical_change, the policy table may have moved the hash_transition
+// setting, and the ledger may have been cross-certified under a second hash
+// algorithm. ReplayHistorical walks the ledger from genesis and checks each
+// entry against the rules in force at its own height:
+//
+//   - the entry links to its predecessor and its recorded hash matches its
+//     content. Entries are chained under the ledger's fixed framing
+//     (HashAlgorithm over canonical.Default), so this check never varies.
+//   - the entry's content canonicalizes strictly under the canonical version
+//     in force at its height (CanonicalVersionAt). A build that omits that
+//     version's capability cannot vouch for the entry, and says so.
+//   - the entry's dual digests, if it carries any, verify under the
+//     hash_transition acceptance of the policy table in force before it.
+//   - the entry applies cleanly to the replayed constitutional state, so
+//     every policy table matches its recorded hash.
+//
+// Ledger-wide records are then checked as a whole: every hash_migration
+// pair is recomputed (VerifyHashMigration), every activation record is
+// re-derived (VerifyActivations), and every intent declaration and its
+// consumption is replayed (VerifyIntents). The result is a ReplayReport with one
+// result per entry, which the auditor signs.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+)
+
+// ReplayResult is the outcome of replaying one entry
+type ReplayResult struct {
+	Height    uint64 `json:"height"`
+	Kind      string `json:"kind"`
+	EntryHash string `json:"entry_hash"`
+	// CanonicalVersion is the canonicalization version in force at Height
+	CanonicalVersion string `json:"canonical_version"`
+	// PolicyHash is the hash of the policy table the entry was written under,
+	// empty for genesis
+	PolicyHash string   `json:"policy_hash"`
+	Passed     bool     `json:"passed"`
+	Problems   []string `json:"problems"`
+}
+
+// ToMap converts a ReplayResult to a map for canonicalization
+func (r ReplayResult) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"height":            r.Height,
+		"kind":              r.Kind,
+		"entry_hash":        r.EntryHash,
+		"canonical_version": r.CanonicalVersion,
+		"policy_hash":       r.PolicyHash,
+		"passed":            r.Passed,
+		"problems":          stringList(r.Problems),
+	}
+}
+
+// ReplayReport is the integrity report of a full-ledger replay
+type ReplayReport struct {
+	Auditor  string `json:"auditor"`
+	Height   uint64 `json:"height"`
+	HeadHash string `json:"head_hash"`
+	// Versions lists the canonical versions in force over the ledger, in
+	// the order they took effect
+	Versions []string `json:"canonical_versions"`
+	// Migrations maps "from>to" to the height up to which history is
+	// cross-certified under that pair of hash algorithms
+	Migrations map[string]uint64 `json:"migrations"`
+	Results    []ReplayResult    `json:"results"`
+	// Problems holds failures of the ledger-wide checks
+	Problems  []string  `json:"problems"`
+	Signature Signature `json:"signature"`
+}
+
+// ToMap converts a ReplayReport to a map for canonicalization. The signature
+// is excluded, since it signs this form.
+func (r *ReplayReport) ToMap() map[string]interface{} {
+	results := make([]interface{}, len(r.Results))
+	for i, res := range r.Results {
+		results[i] = res.ToMap()
+	}
+	migrations := make(map[string]interface{}, len(r.Migrations))
+	for pair, height := range r.Migrations {
+		migrations[pair] = height
+	}
+	return map[string]interface{}{
+		"auditor":            r.Auditor,
+		"height":             r.Height,
+		"head_hash":          r.HeadHash,
+		"canonical_versions": stringList(r.Versions),
+		"migrations":         migrations,
+		"results":            results,
+		"problems":           stringList(r.Problems),
+	}
+}
+
+// Hash returns the semantic hash of the report
+func (r *ReplayReport) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// Passed reports whether every entry and every ledger-wide check passed
+func (r *ReplayReport) Passed() bool {
+	if len(r.Problems) > 0 {
+		return false
+	}
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the results of the entries that did not pass
+func (r *ReplayReport) Failures() []ReplayResult {
+	var out []ReplayResult
+	for _, res := range r.Results {
+		if !res.Passed {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Sign signs the report as its auditor
+func (r *ReplayReport) Sign(key ed25519.PrivateKey) error {
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	r.Signature, err = SignHash(r.Auditor, key, ContextAudit, hash)
+	return err
+}
+
+// Verify checks the report's signature against the auditor's key
+func (r *ReplayReport) Verify(key ed25519.PublicKey) error {
+	if r.Signature.Signer != r.Auditor {
+		return NewVerificationError(fmt.Sprintf("replay by %s signed by %s", r.Auditor, r.Signature.Signer))
+	}
+	hash, err := r.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextAudit, hash, r.Signature)
+}
+
+// ReplayHistorical replays ledger from genesis, checking each entry under
+// the canonical version and policy in force at its height. Problems found in
+// entries are reported, not returned, so one bad entry does not hide the
+// state of the rest of the ledger.
+//
+// Parameters:
+//   - ledger: Ledger to replay; it must hold its history from genesis
+//
+// Returns:
+//   - Unsigned report with one result per entry; set Auditor and Sign it
+//   - ConstitutionalError if the ledger was bootstrapped from a snapshot
+func ReplayHistorical(ledger *Ledger) (*ReplayReport, error) {
+	ledger.mu.RLock()
+	base := ledger.baseHeight
+	entries := ledger.entriesAfterLocked(0)
+	ledger.mu.RUnlock()
+	if base > 0 {
+		return nil, NewConstitutionalError(fmt.Sprintf("ledger starts at height %d; replay needs its history from genesis", base))
+	}
+
+	report := &ReplayReport{
+		Versions:   []string{},
+		Migrations: map[string]uint64{},
+		Results:    make([]ReplayResult, 0, len(entries)),
+		Problems:   []string{},
+	}
+	state := &HistoricalState{Reputation: make(map[string]int)}
+	prev := ""
+	for i, e := range entries {
+		result := ReplayResult{
+			Height:           e.Height,
+			Kind:             e.Kind,
+			EntryHash:        e.Hash,
+			CanonicalVersion: CanonicalVersionAt(entries[:i], e.Height),
+			PolicyHash:       state.PolicyHash,
+			Problems:         []string{},
+		}
+		if n := len(report.Versions); n == 0 || report.Versions[n-1] != result.CanonicalVersion {
+			report.Versions = append(report.Versions, result.CanonicalVersion)
+		}
+		problem := func(format string, args ...interface{}) {
+			result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+		}
+
+		if e.Height != uint64(i)+1 {
+			problem("entry is at height %d, expected %d", e.Height, i+1)
+		}
+		if e.PrevHash != prev {
+			problem("entry does not link to entry %d", e.Height-1)
+		}
+		if i == 0 && e.Kind != LedgerKindGenesis {
+			problem("ledger does not start with genesis")
+		}
+		if recomputed, err := e.ComputeHash(); err != nil {
+			problem("entry does not hash: %v", err)
+		} else if recomputed != e.Hash {
+			problem("entry records hash %s, content hashes to %s", e.Hash, recomputed)
+		}
+
+		if c, err := CanonicalizerAt(entries[:i], e.Height); err != nil {
+			problem("rules %s in force at this height: %v", result.CanonicalVersion, err)
+		} else if _, err := c.Canonicalize(e.ToMap(), true); err != nil {
+			problem("entry does not canonicalize under %s: %v", result.CanonicalVersion, err)
+		}
+
+		if len(e.Hashes) > 0 {
+			if err := replayEntryHashes(e, HashAcceptanceFromTable(state.Policies)); err != nil {
+				problem("dual hashes: %v", err)
+			}
+		}
+
+		if err := state.apply(e); err != nil {
+			problem("state replay: %v", err)
+		}
+
+		result.Passed = len(result.Problems) == 0
+		report.Results = append(report.Results, result)
+		prev = e.Hash
+	}
+	if len(entries) > 0 {
+		report.Height, report.HeadHash = entries[len(entries)-1].Height, entries[len(entries)-1].Hash
+	}
+
+	for _, pair := range migrationPairs(entries) {
+		covered, err := VerifyHashMigration(entries, pair[0], pair[1])
+		report.Migrations[pair[0]+">"+pair[1]] = covered
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("hash migration %s to %s: %v", pair[0], pair[1], err))
+		}
+	}
+	if err := VerifyActivations(entries); err != nil {
+		report.Problems = append(report.Problems, err.Error())
+	}
+	if err := VerifyIntents(entries); err != nil {
+		report.Problems = append(report.Problems, err.Error())
+	}
+	return report, nil
+}
+
+// replayEntryHashes verifies an entry's dual digests under acceptance, with
+// the ledger's algorithm as primary and the other digest, if any, as
+// secondary
+func replayEntryHashes(e LedgerEntry, acceptance HashAcceptance) error {
+	opts := []HashOption{WithHashAcceptance(acceptance)}
+	algos := make([]string, 0, len(e.Hashes))
+	for algo := range e.Hashes {
+		if algo != HashAlgorithm {
+			algos = append(algos, algo)
+		}
+	}
+	if len(algos) > 0 {
+		sort.Strings(algos)
+		opts = append(opts, WithDualHash(HashAlgorithm, algos[0]))
+	}
+	h, err := NewDualHasher(opts...)
+	if err != nil {
+		return err
+	}
+	return h.Verify(e.ToMap(), e.Hashes)
+}
+
+// migrationPairs returns the (from, to) algorithm pairs recorded in entries,
+// sorted
+func migrationPairs(entries []LedgerEntry) [][2]string {
+	seen := make(map[[2]string]bool)
+	var out [][2]string
+	for _, e := range entries {
+		if e.Kind != LedgerKindHashMigration {
+			continue
+		}
+		pair := [2]string{payloadString(e.Payload, "from_algorithm"), payloadString(e.Payload, "to_algorithm")}
+		if !seen[pair] {
+			seen[pair] = true
+			out = append(out, pair)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i][0] != out[j][0] {
+			return out[i][0] < out[j][0]
+		}
+		return out[i][1] < out[j][1]
+	})
+	return out
+}
@@ -0,0 +1,108 @@
+package ocp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// replayLedger returns a genesis ledger with a canonical change in force
+// from height 4, a dual-hashed tail, and a hash migration
+func replayLedger(t *testing.T) *Ledger {
+	t.Helper()
+	skipWithoutUTF16(t)
+	quorum, privs := testQuorum(t)
+	ledger, err := NewLedgerFromGenesis(testGenesis(t))
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	p := testCanonicalChange(4)
+	change, _ := CanonicalChangeFromProposal(p)
+	hash, _ := change.Hash()
+	if _, err := RatifyCanonicalChange(ledger, quorum, p, signAll(t, ContextCanonical, hash, privs, "Claude", "Gemini")); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+	dual, _ := NewDualHasher(WithDualHash("sha256", "sha512"))
+	ledger.SetDualHasher(dual)
+	for _, id := range []string{"a", "b", "c"} {
+		ledger.Append(LedgerKindProposal, map[string]interface{}{"proposal_hash": id})
+	}
+	if _, err := MigrateHashes(ledger, "sha256", "sha512"); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return ledger
+}
+
+// TestReplayHistorical tests that an intact ledger replays under its
+// historical rules and the report is signed
+func TestReplayHistorical(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := replayLedger(t)
+	report, err := ReplayHistorical(ledger)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("Expected an intact ledger to pass: %+v %v", report.Failures(), report.Problems)
+	}
+	if len(report.Results) != int(ledger.Height()) || report.HeadHash != ledger.Head() {
+		t.Errorf("Expected one result per entry up to the head, got %d", len(report.Results))
+	}
+	utf16 := canonical.New(canonical.WithKeyOrder(canonical.KeyOrderUTF16)).Version()
+	if len(report.Versions) != 2 || report.Versions[1] != utf16 || report.Results[3].CanonicalVersion != utf16 || report.Results[2].CanonicalVersion == utf16 {
+		t.Errorf("Expected the change to take effect at height 4: %v", report.Versions)
+	}
+	if report.Migrations["sha256>sha512"] != ledger.Height()-1 {
+		t.Errorf("Expected history to be cross-certified, got %v", report.Migrations)
+	}
+
+	pub, priv := testKey("Auditor")
+	report.Auditor = "Auditor"
+	if err := report.Sign(priv); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if err := report.Verify(pub); err != nil {
+		t.Errorf("Expected the signed report to verify: %v", err)
+	}
+	report.Results[0].Passed = false
+	if err := report.Verify(pub); err == nil {
+		t.Error("Expected an altered report to fail verification")
+	}
+	t.Logf("✓ Replayed %d entries under %d canonical versions", len(report.Results), len(report.Versions))
+}
+
+// TestReplayHistoricalFindings tests that tampering is reported per entry
+// without stopping the replay
+func TestReplayHistoricalFindings(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := replayLedger(t)
+	ledger.entries[2].Payload["proposal_hash"] = "forged"
+	ledger.entries[4].Hashes["sha512"] = strings.Repeat("0", 128)
+
+	report, err := ReplayHistorical(ledger)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	failures := report.Failures()
+	if len(failures) != 2 || failures[0].Height != 3 || failures[1].Height != 5 {
+		t.Fatalf("Expected failures at heights 3 and 5, got %+v", failures)
+	}
+	if !strings.Contains(failures[1].Problems[0], "dual hashes") {
+		t.Errorf("Expected a dual hash problem, got %v", failures[1].Problems)
+	}
+	if len(report.Problems) == 0 {
+		t.Error("Expected the hash migration to no longer verify")
+	}
+	if len(report.Results) != int(ledger.Height()) {
+		t.Errorf("Replay should continue past failures, got %d results", len(report.Results))
+	}
+
+	// A ledger without genesis has no rules to replay under
+	headless, err := ReplayHistorical(migrationLedger(t, 2))
+	if err != nil || headless.Passed() || headless.Results[0].Passed {
+		t.Errorf("Expected a ledger without genesis to fail at height 1: %v", err)
+	}
+	t.Logf("✓ %d tampered entries reported", len(failures))
+}
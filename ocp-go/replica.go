@@ -0,0 +1,89 @@
+// replica.go - Applying history hashed by another node
+//
+// A read replica holds a copy of another node's ledger so that verification
+// traffic can be served without loading the node that accepts proposals. The
+// replica must never hold history its own checks would reject: Extend appends
+// entries hashed elsewhere only if they chain onto the local head, and
+// VerifyEntrySignatures checks the quorum signatures carried by policy,
+// constitution, canonical, and emergency halt, review, and lift entries. Extend adds
+// no entry of its own making, so it is available in verify-only builds.
+
+package ocp
+
+import (
+	"fmt"
+)
+
+// Extend appends entries that were hashed by another ledger
+//
+// Parameters:
+//   - entries: Entries continuing this ledger's history, in height order
+//
+// Returns:
+//   - VerificationError if the entries do not chain onto the current head;
+//     nothing is appended in that case
+func (l *Ledger) Extend(entries []LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	height, head := l.headLocked()
+	if _, err := VerifyChain(height, head, entries); err != nil {
+		return err
+	}
+	l.entries = append(l.entries, entries...)
+	l.signalLocked()
+	return nil
+}
+
+// VerifyEntrySignatures checks the quorum signatures of every signed entry in
+// entries
+//
+// Parameters:
+//   - prior: Entries preceding entries, needed to resolve the halts that
+//     reviews and lifts refer to and the halts in force or cooling down
+//   - entries: Entries to check
+//   - quorum: Quorum that must have approved each signed entry
+//
+// Returns:
+//   - VerificationError naming the first entry whose signatures do not verify
+func VerifyEntrySignatures(prior, entries []LedgerEntry, quorum *Quorum) error {
+	emergency := false
+	for _, e := range entries {
+		var ctx SignatureContext
+		var hash string
+		switch e.Kind {
+		case LedgerKindPolicy:
+			policies, _ := e.Payload["policies"].(map[string]interface{})
+			computed, err := SemanticHash(policies)
+			if err != nil {
+				return err
+			}
+			if computed != payloadString(e.Payload, "policy_hash") {
+				return NewVerificationError(fmt.Sprintf("policy entry at height %d does not match its policy_hash", e.Height))
+			}
+			ctx, hash = ContextPolicy, computed
+		case LedgerKindConstitution:
+			ctx, hash = ContextConstitution, payloadString(e.Payload, "constitution_hash")
+		case LedgerKindCanonical:
+			change := CanonicalChanges([]LedgerEntry{e})[0]
+			computed, err := change.Hash()
+			if err != nil {
+				return err
+			}
+			ctx, hash = ContextCanonical, computed
+		case LedgerKindEmergencyHalt, LedgerKindEmergencyReview, LedgerKindEmergencyLift:
+			emergency = true
+			continue
+		default:
+			continue
+		}
+		if _, err := quorum.Verify(ctx, hash, signaturesFromPayload(e.Payload, "signatures")); err != nil {
+			return NewVerificationError(fmt.Sprintf("%s entry at height %d: %v", e.Kind, e.Height, err))
+		}
+	}
+	if !emergency {
+		return nil
+	}
+	history := make([]LedgerEntry, 0, len(prior)+len(entries))
+	history = append(append(history, prior...), entries...)
+	return VerifyHalts(history, quorum)
+}
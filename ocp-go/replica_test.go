@@ -0,0 +1,89 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestLedgerExtend tests appending entries hashed by another ledger
+func TestLedgerExtend(t *testing.T) {
+	testenv.RequireSigning(t)
+	leader := migrationLedger(t, 4)
+	replica := NewLedger()
+	if err := replica.Extend(leader.Entries(0)[:2]); err != nil {
+		t.Fatalf("Failed to extend: %v", err)
+	}
+	if err := replica.Extend(leader.Entries(3)); err == nil {
+		t.Errorf("Expected a gap in heights to be refused")
+	}
+	tampered := leader.Entries(2)
+	tampered[0].Payload = map[string]interface{}{"proposal_hash": "forged"}
+	if err := replica.Extend(tampered); err == nil || replica.Height() != 2 {
+		t.Errorf("Expected a tampered entry to be refused without applying any, got %v at %d", err, replica.Height())
+	}
+	if err := replica.Extend(leader.Entries(2)); err != nil || replica.Head() != leader.Head() {
+		t.Errorf("Expected the replica to reach the leader's head, got %v", err)
+	}
+
+	t.Logf("✓ Replica extended to height %d", replica.Height())
+}
+
+// TestVerifyEntrySignatures tests signature checks on quorum-approved entries
+func TestVerifyEntrySignatures(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	ledger := NewLedger()
+	policies := map[string]interface{}{"max_stake": 100}
+	hash, _ := SemanticHash(policies)
+	if _, err := RecordPolicy(ledger, quorum, policies, signAll(t, ContextPolicy, hash, privs, "Claude", "Gemini")); err != nil {
+		t.Fatalf("Failed to record policy: %v", err)
+	}
+	entries := ledger.Entries(0)
+	if err := VerifyEntrySignatures(nil, entries, quorum); err != nil {
+		t.Errorf("Expected recorded policy to verify, got %v", err)
+	}
+
+	// A table changed after signing no longer matches its hash
+	forged := ledger.Entries(0)
+	forged[0].Payload = map[string]interface{}{
+		"policy_hash": hash,
+		"policies":    map[string]interface{}{"max_stake": 1000},
+		"signatures":  entries[0].Payload["signatures"],
+	}
+	if err := VerifyEntrySignatures(nil, forged, quorum); err == nil {
+		t.Errorf("Expected a changed policy table to be refused")
+	}
+
+	// Signatures from outside the quorum do not count
+	_, outsider := testKey("Mallory")
+	docHash := ConstitutionHash([]byte("constitution"))
+	ledger.Append(LedgerKindConstitution, map[string]interface{}{
+		"constitution_hash": docHash,
+		"signatures":        signatureList(signAll(t, ContextConstitution, docHash, map[string]ed25519.PrivateKey{"Mallory": outsider}, "Mallory")),
+	})
+	if err := VerifyEntrySignatures(entries, ledger.Entries(1), quorum); err == nil {
+		t.Errorf("Expected an unapproved constitution to be refused")
+	}
+
+	// A halt entry carries its proposer's trigger signature
+	haltLedger := NewLedger()
+	breaker, err := NewCircuitBreaker(haltLedger, quorum, DefaultEmergencyPolicy(quorum))
+	if err != nil {
+		t.Fatalf("Failed to create breaker: %v", err)
+	}
+	if _, err := breaker.Trigger(haltProposal(t, "Claude", privs["Claude"])); err != nil {
+		t.Fatalf("Failed to trigger halt: %v", err)
+	}
+	halts := haltLedger.Entries(0)
+	if err := VerifyEntrySignatures(nil, halts, quorum); err != nil {
+		t.Errorf("Expected a signed halt to verify, got %v", err)
+	}
+	delete(halts[0].Payload, "signature")
+	if err := VerifyEntrySignatures(nil, halts, quorum); err == nil {
+		t.Errorf("Expected an unsigned halt to be refused")
+	}
+
+	t.Log("✓ Signed entries verify against the quorum")
+}
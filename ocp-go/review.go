@@ -0,0 +1,290 @@
+// review.go - Review bundles for constitutional amendments
+//
+// Reviewers voting on an amendment need the text before and after it, what
+// changed, and which sections are affected, and they need to know that what
+// they read is exactly what the proposal commits to. ReviewBundle packages the
+// pre-state and post-state documents named by an amendment's state hashes,
+// their differences, per-section hashes, and a Markdown summary into one
+// artifact. The bundle has its own semantic hash, and VerifyReview rebuilds it
+// from the enclosed documents, so a tampered summary or diff is detected
+// without trusting whoever produced the bundle.
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// SectionChange is a constitution section whose content an amendment changes
+type SectionChange struct {
+	// Section is a top-level key for JSON documents and a heading line for
+	// text documents; text before the first heading is "(preamble)"
+	Section string `json:"section"`
+	// Change is canonical.DiffAdded, DiffRemoved, or DiffChanged
+	Change  string `json:"change"`
+	OldHash string `json:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty"`
+}
+
+// ToMap converts a SectionChange to a map for canonicalization
+func (s SectionChange) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"section":  s.Section,
+		"change":   s.Change,
+		"old_hash": s.OldHash,
+		"new_hash": s.NewHash,
+	}
+}
+
+// AmendmentReview is the review artifact for one amendment proposal
+type AmendmentReview struct {
+	ProposalHash  string          `json:"proposal_hash"`
+	PreStateHash  string          `json:"pre_state_hash"`
+	PostStateHash string          `json:"post_state_hash"`
+	PreState      string          `json:"pre_state"`
+	PostState     string          `json:"post_state"`
+	Differences   []string        `json:"differences"`
+	Sections      []SectionChange `json:"sections"`
+	// Summary is the Markdown rendering shown to reviewers
+	Summary string `json:"summary"`
+}
+
+// ToMap converts an AmendmentReview to a map for canonicalization
+func (r *AmendmentReview) ToMap() map[string]interface{} {
+	sections := make([]interface{}, len(r.Sections))
+	for i, s := range r.Sections {
+		sections[i] = s.ToMap()
+	}
+	return map[string]interface{}{
+		"proposal_hash":   r.ProposalHash,
+		"pre_state_hash":  r.PreStateHash,
+		"post_state_hash": r.PostStateHash,
+		"pre_state":       r.PreState,
+		"post_state":      r.PostState,
+		"differences":     stringList(r.Differences),
+		"sections":        sections,
+		"summary":         r.Summary,
+	}
+}
+
+// Hash returns the semantic hash of the bundle
+func (r *AmendmentReview) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// ReviewBundle builds the review artifact for an amendment
+//
+// Parameters:
+//   - p: Amendment proposal; its action type must be "amend"
+//   - archive: Archive holding the documents named by the proposal's
+//     PreStateHash and PostStateHash
+//
+// Returns:
+//   - Review bundle whose documents match the proposal's state hashes
+func ReviewBundle(p *ContractProposal, archive Archive) (*AmendmentReview, error) {
+	if p.ActionType != "amend" {
+		return nil, NewConstitutionalError(fmt.Sprintf("only amendments can be reviewed, got %q", p.ActionType))
+	}
+	pre, err := archive.Get(p.PreStateHash)
+	if err != nil {
+		return nil, fmt.Errorf("pre-state %s: %w", p.PreStateHash, err)
+	}
+	post, err := archive.Get(p.PostStateHash)
+	if err != nil {
+		return nil, fmt.Errorf("post-state %s: %w", p.PostStateHash, err)
+	}
+	return buildReview(p, pre, post)
+}
+
+// VerifyReview checks that r is the bundle ReviewBundle would build for p from
+// the documents r encloses
+func VerifyReview(p *ContractProposal, r *AmendmentReview) error {
+	want, err := buildReview(p, []byte(r.PreState), []byte(r.PostState))
+	if err != nil {
+		return err
+	}
+	wantHash, err := want.Hash()
+	if err != nil {
+		return err
+	}
+	if got, err := r.Hash(); err != nil || got != wantHash {
+		return NewVerificationError("review bundle does not match the amendment and its documents")
+	}
+	return nil
+}
+
+func buildReview(p *ContractProposal, pre, post []byte) (*AmendmentReview, error) {
+	if !documentMatches(pre, p.PreStateHash) {
+		return nil, NewVerificationError("pre-state document does not match the proposal's pre_state_hash")
+	}
+	if !documentMatches(post, p.PostStateHash) {
+		return nil, NewVerificationError("post-state document does not match the proposal's post_state_hash")
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	diffs, err := constitutionDiff(pre, post)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := sectionChanges(pre, post)
+	if err != nil {
+		return nil, err
+	}
+	r := &AmendmentReview{
+		ProposalHash:  hash,
+		PreStateHash:  p.PreStateHash,
+		PostStateHash: p.PostStateHash,
+		PreState:      string(pre),
+		PostState:     string(post),
+		Differences:   diffs,
+		Sections:      sections,
+	}
+	r.Summary = renderReview(p, r)
+	return r, nil
+}
+
+// documentMatches reports whether doc is ratified under hash, in canonical or
+// exact form
+func documentMatches(doc []byte, hash string) bool {
+	return ConstitutionHash(doc) == hash || ContentHash(doc) == hash
+}
+
+// section is a named part of a document, the hash of its content, and the
+// content's size in bytes
+type section struct {
+	name string
+	hash string
+	size int
+}
+
+// documentSections splits JSON documents by top-level key, in key order, and
+// text documents by heading line, in document order. A JSON section's hash is
+// its hashing.PathIndex entry and its size that of its canonical form.
+func documentSections(doc []byte) ([]section, error) {
+	if obj, err := DecodeStrict(doc); err == nil {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]section, 0, len(keys))
+		for _, k := range keys {
+			hash, err := ValueHash(obj[k])
+			if err != nil {
+				return nil, err
+			}
+			form, err := CanonicalizeValue(obj[k])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, section{name: k, hash: hash, size: len(form)})
+		}
+		return out, nil
+	}
+
+	var out []section
+	seen := make(map[string]int)
+	name, start := "(preamble)", 0
+	lines := strings.SplitAfter(string(doc), "\n")
+	flush := func(end int) {
+		if end > start || name != "(preamble)" {
+			body := strings.Join(lines[start:end], "")
+			out = append(out, section{name: name, hash: ContentHash([]byte(body)), size: len(body)})
+		}
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			flush(i)
+			name, start = strings.TrimSpace(line), i
+			if seen[name]++; seen[name] > 1 {
+				name = fmt.Sprintf("%s (%d)", name, seen[name])
+			}
+		}
+	}
+	flush(len(lines))
+	return out, nil
+}
+
+// sectionChanges lists sections whose hash differs, removed and changed
+// sections in pre-state order followed by added ones in post-state order
+func sectionChanges(pre, post []byte) ([]SectionChange, error) {
+	old, err := documentSections(pre)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := documentSections(post)
+	if err != nil {
+		return nil, err
+	}
+	newHashes := make(map[string]string, len(updated))
+	for _, s := range updated {
+		newHashes[s.name] = s.hash
+	}
+	oldNames := make(map[string]bool, len(old))
+
+	changes := []SectionChange{}
+	for _, s := range old {
+		oldNames[s.name] = true
+		h, ok := newHashes[s.name]
+		switch {
+		case !ok:
+			changes = append(changes, SectionChange{Section: s.name, Change: canonical.DiffRemoved, OldHash: s.hash})
+		case h != s.hash:
+			changes = append(changes, SectionChange{Section: s.name, Change: canonical.DiffChanged, OldHash: s.hash, NewHash: h})
+		}
+	}
+	for _, s := range updated {
+		if !oldNames[s.name] {
+			changes = append(changes, SectionChange{Section: s.name, Change: canonical.DiffAdded, NewHash: s.hash})
+		}
+	}
+	return changes, nil
+}
+
+// renderReview renders the Markdown summary shown to reviewers
+func renderReview(p *ContractProposal, r *AmendmentReview) string {
+	short := func(hash string) string {
+		if hash == "" {
+			return "—"
+		}
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		return "`" + hash + "`"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Amendment review: %s\n\n", p.ID)
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Proposal | `%s` |\n", r.ProposalHash)
+	fmt.Fprintf(&b, "| Proposer | %s |\n", p.ProposerAgent)
+	fmt.Fprintf(&b, "| Pre-state | `%s` |\n", r.PreStateHash)
+	fmt.Fprintf(&b, "| Post-state | `%s` |\n", r.PostStateHash)
+	if rationale := p.Rationale(); rationale != "" {
+		fmt.Fprintf(&b, "\n## Rationale\n\n%s\n", rationale)
+	}
+
+	b.WriteString("\n## Affected sections\n\n")
+	if len(r.Sections) == 0 {
+		b.WriteString("No section changes.\n")
+	} else {
+		b.WriteString("| Section | Change | Before | After |\n|---|---|---|---|\n")
+		for _, s := range r.Sections {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", strings.ReplaceAll(s.Section, "|", `\|`), s.Change, short(s.OldHash), short(s.NewHash))
+		}
+	}
+
+	b.WriteString("\n## Changes\n\n```diff\n")
+	for _, d := range r.Differences {
+		b.WriteString(d)
+		b.WriteString("\n")
+	}
+	b.WriteString("```\n")
+	return b.String()
+}
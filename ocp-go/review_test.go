@@ -0,0 +1,71 @@
+package ocp
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReviewBundle tests building and verifying an amendment review bundle
+func TestReviewBundle(t *testing.T) {
+	pre := []byte("# Constitution v2.1\n\n## Article I\nAgents act in good faith.\n\n## Article III\nDisputes go to the quorum.\n")
+	post := []byte("# Constitution v2.1\n\n## Article I\nAgents act in good faith.\n\n## Article III\nDisputes go to the quorum within 72 hours.\n\n## Article IV\nAmendments need review.\n")
+	archive := NewMemoryArchive()
+	preHash, _ := archive.Put(pre)
+	postHash, _ := archive.Put(post)
+
+	p := testProposal()
+	p.PreStateHash, p.PostStateHash = preHash, postHash
+	bundle, err := ReviewBundle(p, archive)
+	if err != nil {
+		t.Fatalf("Failed to build review bundle: %v", err)
+	}
+	if len(bundle.Sections) != 2 || bundle.Sections[0].Section != "## Article III" || bundle.Sections[0].Change != "changed" || bundle.Sections[1].Change != "added" {
+		t.Errorf("Unexpected section changes: %+v", bundle.Sections)
+	}
+	if !strings.Contains(bundle.Summary, "| ## Article IV | added |") || !strings.Contains(bundle.Summary, "+ 9: ## Article IV") {
+		t.Errorf("Summary missing changes:\n%s", bundle.Summary)
+	}
+	if err := VerifyReview(p, bundle); err != nil {
+		t.Errorf("Bundle should verify: %v", err)
+	}
+
+	tampered := *bundle
+	tampered.Summary = strings.Replace(bundle.Summary, "added", "changed", 1)
+	if err := VerifyReview(p, &tampered); err == nil {
+		t.Errorf("A tampered summary should fail verification")
+	}
+	swapped := *bundle
+	swapped.PostState = string(pre)
+	if err := VerifyReview(p, &swapped); err == nil {
+		t.Errorf("A document not matching the proposal should fail verification")
+	}
+
+	// JSON constitutions are sectioned by top-level key
+	jsonPre := []byte(`{"articles": {"I": "Good faith"}, "preamble": "We the agents"}`)
+	jsonPost := []byte(`{"articles": {"I": "Good faith", "II": "Review"}, "preamble": "We the agents"}`)
+	p.PreStateHash, _ = archive.Put([]byte(canonicalFixture(t, jsonPre)))
+	p.PostStateHash, _ = archive.Put([]byte(canonicalFixture(t, jsonPost)))
+	bundle, err = ReviewBundle(p, archive)
+	if err != nil {
+		t.Fatalf("Failed to build JSON review bundle: %v", err)
+	}
+	if len(bundle.Sections) != 1 || bundle.Sections[0].Section != "articles" || len(bundle.Differences) != 1 {
+		t.Errorf("Unexpected JSON review: %+v %v", bundle.Sections, bundle.Differences)
+	}
+
+	p.ActionType = "approve"
+	if _, err := ReviewBundle(p, archive); err == nil {
+		t.Errorf("Non-amendments should be rejected")
+	}
+	t.Logf("✓ Review bundle: %d section changes, %d differences", len(bundle.Sections), len(bundle.Differences))
+}
+
+// canonicalFixture returns the canonical form under which a JSON
+// constitution is archived
+func canonicalFixture(t *testing.T, doc []byte) string {
+	form, err := CanonicalizeJSON(doc)
+	if err != nil {
+		t.Fatalf("Bad fixture: %v", err)
+	}
+	return form
+}
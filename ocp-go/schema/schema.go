@@ -0,0 +1,136 @@
+// schema.go - Validation against the protocol JSON Schemas
+//
+// Package schema validates decoded documents against the JSON Schemas bundled
+// in protocol/schemas. The validators in schema_gen.go are generated by
+// cmd/ocp-schemagen; regenerate them with `go generate ./schema` after any
+// schema change. The package tests fail if the bundled schemas no longer match
+// the hashes recorded in the generated code.
+//
+// Validators expect documents as produced by canonical.DecodeStrict: objects as
+// map[string]interface{}, arrays as []interface{}, and numbers as float64.
+
+package schema
+
+//go:generate go run ../cmd/ocp-schemagen -in ../../protocol/schemas -out schema_gen.go -package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError is a single schema violation
+type ValidationError struct {
+	// Path locates the value, e.g. "$.evidence[0].pointer"
+	Path string `json:"path"`
+	// Keyword is the JSON Schema keyword that failed, e.g. "required"
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", e.Path, e.Message, e.Keyword)
+}
+
+// Errors collects every violation found in a document, sorted by path
+type Errors []ValidationError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a violation
+func (e *Errors) Add(path, keyword, message string) {
+	*e = append(*e, ValidationError{Path: path, Keyword: keyword, Message: message})
+}
+
+// err returns nil when empty, or the violations in a deterministic order
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Path != e[j].Path {
+			return e[i].Path < e[j].Path
+		}
+		return e[i].Keyword < e[j].Keyword
+	})
+	return e
+}
+
+// Compiled describes one generated validator
+type Compiled struct {
+	// Name is the exported name used in Validate<Name>, e.g. "Contract"
+	Name string
+	// Hash is the SHA-256 of the schema file the validator was generated from
+	Hash string
+	// Source is the canonical form of the schema, for documents such as an
+	// OpenAPI description that embed it
+	Source   string
+	Validate func(doc interface{}) error
+}
+
+// Document returns a freshly decoded copy of the schema
+func (c Compiled) Document() (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(c.Source), &doc); err != nil {
+		return nil, fmt.Errorf("schema %s: %v", c.Name, err)
+	}
+	return doc, nil
+}
+
+// Schemas returns the compiled validators keyed by schema file name
+func Schemas() map[string]Compiled {
+	out := make(map[string]Compiled, len(generated))
+	for k, v := range generated {
+		out[k] = v
+	}
+	return out
+}
+
+// Validate validates doc against the named schema file, e.g. "contract.schema.json"
+func Validate(file string, doc interface{}) error {
+	c, ok := generated[file]
+	if !ok {
+		return fmt.Errorf("unknown schema %q", file)
+	}
+	return c.Validate(doc)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func isInteger(n float64) bool {
+	return n == math.Trunc(n) && !math.IsInf(n, 0)
+}
+
+func isDateTime(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func isUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
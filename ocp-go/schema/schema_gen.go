@@ -0,0 +1,968 @@
+// Code generated by ocp-schemagen from protocol/schemas. DO NOT EDIT.
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// ArchiveEntrySchemaHash is the SHA-256 of the archive_entry.schema.json this code was generated from
+const ArchiveEntrySchemaHash = "5a083296fe996c2f5e9b4d776569e7df89c2f9580d3a22ee7882a4d23f18347d"
+
+// ArchiveEntrySchemaSource is the canonical form of archive_entry.schema.json
+const ArchiveEntrySchemaSource = "{\"$schema\":\"http://json-schema.org/draft-07/schema#\",\"additionalProperties\":false,\"description\":\"Schema for entries in the Immutable Archive\",\"properties\":{\"action_type\":{\"enum\":[\"constitutional_amendment\",\"contract_proposal\",\"fraud_proof\",\"human_override\",\"verification_result\"],\"type\":\"string\"},\"agent_id\":{\"description\":\"Cryptographic identity of the submitting agent\",\"type\":\"string\"},\"constitutional_citation\":{\"description\":\"Reference to constitutional article justifying this action\",\"type\":\"string\"},\"entry_id\":{\"description\":\"Unique identifier for this archive entry\",\"format\":\"uuid\",\"type\":\"string\"},\"evidence_pointers\":{\"description\":\"References to supporting evidence in archive\",\"items\":{\"type\":\"string\"},\"type\":\"array\"},\"post_state_hash\":{\"description\":\"Hash of system state after this action\",\"type\":\"string\"},\"pre_state_hash\":{\"description\":\"Hash of system state before this action\",\"type\":\"string\"},\"semantic_hash\":{\"description\":\"SHA-256 hash of canonicalized content\",\"pattern\":\"^[a-f0-9]{64}$\",\"type\":\"string\"},\"signature\":{\"description\":\"Digital signature by the submitting agent\",\"type\":\"string\"},\"timestamp\":{\"description\":\"ISO 8601 timestamp of entry creation\",\"format\":\"date-time\",\"type\":\"string\"},\"zk_proof\":{\"description\":\"Optional zero-knowledge proof for private actions\",\"type\":\"string\"}},\"required\":[\"action_type\",\"agent_id\",\"constitutional_citation\",\"entry_id\",\"post_state_hash\",\"pre_state_hash\",\"semantic_hash\",\"signature\",\"timestamp\"],\"title\":\"OCP Archive Entry\",\"type\":\"object\"}"
+
+// ValidateArchiveEntry validates a decoded document against archive_entry.schema.json
+func ValidateArchiveEntry(doc interface{}) error {
+	var errs Errors
+	validateArchiveEntry(doc, "$", &errs)
+	return errs.err()
+}
+
+// ContractSchemaHash is the SHA-256 of the contract.schema.json this code was generated from
+const ContractSchemaHash = "9309737a0733ad5833d3eda8d07f2ba0e31146051f834dfd81ba0b0ced269e74"
+
+// ContractSchemaSource is the canonical form of contract.schema.json
+const ContractSchemaSource = "{\"$id\":\"https://constitutional-ai.org/schemas/contract.schema.json\",\"$schema\":\"http://json-schema.org/draft-07/schema#\",\"additionalProperties\":false,\"definitions\":{\"uuid\":{\"pattern\":\"^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$\",\"type\":\"string\"}},\"description\":\"Normative schema for Contract Proposals submitted to the Optimistic Constitutional Protocol (OCP). All contracts must conform to this schema to be accepted by the Archive.\",\"examples\":[{\"action\":{\"operation\":\"modify\",\"parameters\":{\"article\":\"III.1\",\"change\":\"Add operational definition for confidence calibration\",\"proposed_text\":\"Confidence estimates must calibrate to observed ground truth. Deviation \\u003e0.15 from calibrated confidence is considered misrepresentation.\"},\"target\":\"amendment-article-3\"},\"action_type\":\"amend\",\"canonical_serialization\":\"{\\\"action\\\":{\\\"operation\\\":\\\"modify\\\",\\\"parameters\\\":{\\\"article\\\":\\\"III.1\\\"},\\\"target\\\":\\\"amendment-article-3\\\"},\\\"action_type\\\":\\\"amend\\\"}\",\"evidence\":[{\"description\":\"Historical record of Scenario 1 dispute showing ambiguity in Article III.1\",\"pointer\":\"sha256:abc123def456\",\"type\":\"archive_reference\"},{\"description\":\"Current text of Article III.1 (Truthfulness)\",\"pointer\":\"Article-III.1\",\"type\":\"constitutional_citation\"}],\"id\":\"550e8400-e29b-41d4-a716-446655440000\",\"metadata\":{\"domain\":\"amendment\",\"related_contracts\":[\"550e8400-e29b-41d4-a716-446655440001\"],\"tags\":[\"Article-III\",\"confidence-calibration\",\"fraud-proof-enablement\"]},\"post_state_hash\":\"sha256:fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321\",\"pre_state_hash\":\"sha256:1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef\",\"proposer_agent\":\"Claude\",\"proposer_signature\":{\"algorithm\":\"ed25519\",\"value\":\"3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f\"},\"reasoning\":{\"alternatives_considered\":[\"Alternative 1: No amendment (leaves ambiguity)\",\"Alternative 2: Different threshold (±0.10 vs ±0.15)\"],\"confidence\":0.87,\"constitutional_grounding\":[\"Article III.1\",\"Article X.1\"],\"rationale\":\"Article III.1 currently lacks operational precision regarding confidence thresholds. The Scenario 1 dispute demonstrated that 'misrepresentation' is ambiguous when applied to confidence estimates. This amendment clarifies the threshold, enabling deterministic fraud-proof verification.\",\"uncertainties\":[\"Calibration methodology needs further definition in supporting documents\",\"±0.15 threshold may be too loose or too strict depending on domain\"]},\"reputation_stake\":60,\"reversibility_class\":\"partially_reversible\",\"semantic_hash\":\"semantic:0110101100111100100001011011100101101011001111001000010110111001\",\"timestamp\":\"2025-11-20T14:30:00Z\"},{\"action\":{\"operation\":\"challenge\",\"parameters\":{\"reason\":\"insufficiently_precise\",\"severity\":\"high\"},\"target\":\"contract-550e8400-e29b-41d4-a716-446655440000\"},\"action_type\":\"reject\",\"canonical_serialization\":\"{\\\"action\\\":{\\\"operation\\\":\\\"challenge\\\",\\\"target\\\":\\\"contract-550e8400-e29b-41d4-a716-446655440000\\\"},\\\"action_type\\\":\\\"reject\\\"}\",\"evidence\":[{\"description\":\"Mathematical analysis showing threshold of ±0.15 creates edge cases\",\"pointer\":\"sha256:fedcba0987654321\",\"type\":\"computation\"}],\"id\":\"550e8400-e29b-41d4-a716-446655440001\",\"post_state_hash\":\"sha256:fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321\",\"pre_state_hash\":\"sha256:1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef\",\"proposer_agent\":\"Gemini\",\"proposer_signature\":{\"algorithm\":\"ed25519\",\"value\":\"9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f\"},\"reasoning\":{\"confidence\":0.72,\"constitutional_grounding\":[\"Article X.1\"],\"rationale\":\"The proposed threshold of ±0.15 lacks empirical grounding. Historical data from Scenario 1 suggests ±0.10 would be more defensible.\",\"uncertainties\":[\"Need more historical data to calibrate threshold\"]},\"reputation_stake\":40,\"reversibility_class\":\"partially_reversible\",\"timestamp\":\"2025-11-20T14:32:00Z\"}],\"properties\":{\"action\":{\"description\":\"The specific action being proposed.\",\"properties\":{\"operation\":{\"description\":\"The specific operation to perform on the target. Examples: 'execute', 'invalidate', 'modify', 'suspend'.\",\"type\":\"string\"},\"parameters\":{\"description\":\"Optional parameters specific to the operation. Structure varies by operation type.\",\"type\":\"object\"},\"target\":{\"description\":\"Identifier of the entity or decision being acted upon. Examples: 'amendment-article-3', 'agent-claude', 'fraud-proof-entry-001'.\",\"type\":\"string\"}},\"required\":[\"operation\",\"target\"],\"type\":\"object\"},\"action_type\":{\"description\":\"Category of action being proposed. Determines Constitutional review requirements and urgency.\",\"enum\":[\"amend\",\"approve\",\"canonical_change\",\"delegate\",\"emergency_halt\",\"override\",\"reject\",\"suspend\"],\"type\":\"string\"},\"activation_height\":{\"description\":\"Optional ledger height at which the contract takes effect once ratified. Must be later than the height at which the proposal is recorded.\",\"minimum\":1,\"type\":\"integer\"},\"activation_time\":{\"description\":\"Optional time, measured by ledger time rather than any node's clock, at which the contract takes effect once ratified. Must be later than the proposal timestamp.\",\"format\":\"date-time\",\"type\":\"string\"},\"canonical_serialization\":{\"description\":\"Deterministic JSON string representation of this contract (excluding this field itself and the signature). Used for cryptographic verification. See canonical_json_spec.md for format details.\",\"type\":\"string\"},\"evidence\":{\"description\":\"Array of evidence references supporting the proposal. At least one evidence item is required.\",\"items\":{\"properties\":{\"description\":{\"description\":\"Human-readable summary of why this evidence is relevant to the proposal.\",\"type\":\"string\"},\"pointer\":{\"description\":\"Reference to the evidence. For archive_reference, format is CID (IPFS hash or sha256). For constitutional_citation, format is 'Article-X.Y'. For computation, format is the computation hash.\",\"type\":\"string\"},\"type\":{\"description\":\"Category of evidence being referenced.\",\"enum\":[\"agent_testimony\",\"archive_reference\",\"computation\",\"constitutional_citation\",\"external_source\"],\"type\":\"string\"}},\"required\":[\"pointer\",\"type\"],\"type\":\"object\"},\"minItems\":1,\"type\":\"array\"},\"id\":{\"description\":\"Unique contract identifier (UUID v4 format). Must be globally unique across all contracts in the Archive.\",\"pattern\":\"^[a-f0-9\\\\-]{36}$\",\"type\":\"string\"},\"metadata\":{\"description\":\"Optional metadata for record-keeping and analysis.\",\"properties\":{\"domain\":{\"description\":\"Primary domain affected by this contract.\",\"enum\":[\"amendment\",\"economic\",\"governance\",\"social\",\"technical\"],\"type\":\"string\"},\"related_contracts\":{\"description\":\"IDs of related or dependent contracts.\",\"items\":{\"pattern\":\"^[a-f0-9\\\\-]{36}$\",\"type\":\"string\"},\"type\":\"array\"},\"tags\":{\"description\":\"Searchable tags for categorization and filtering.\",\"items\":{\"type\":\"string\"},\"type\":\"array\"}},\"type\":\"object\"},\"post_state_hash\":{\"description\":\"SHA256 hash of system state after this contract would execute. Provided for verification and rollback prediction.\",\"pattern\":\"^sha256:[a-f0-9]{64}$\",\"type\":\"string\"},\"pre_state_hash\":{\"description\":\"SHA256 hash of system state before this contract executes. Used for rollback if contract is invalidated.\",\"pattern\":\"^sha256:[a-f0-9]{64}$\",\"type\":\"string\"},\"proposer_agent\":{\"description\":\"Identifier of the agent proposing the contract. Must be registered in the Constitutional governance system.\",\"enum\":[\"ChatGPT\",\"Claude\",\"Comet\",\"DeepSeek\",\"Gemini\"],\"type\":\"string\"},\"proposer_signature\":{\"description\":\"Cryptographic signature of the proposer, verifying identity and contract integrity.\",\"properties\":{\"algorithm\":{\"description\":\"Cryptographic algorithm used for signing.\",\"enum\":[\"ecdsa-p256\",\"ed25519\"],\"type\":\"string\"},\"value\":{\"description\":\"Hex-encoded signature value.\",\"pattern\":\"^[a-f0-9]+$\",\"type\":\"string\"}},\"required\":[\"algorithm\",\"value\"],\"type\":\"object\"},\"reasoning\":{\"description\":\"Structured reasoning explaining why the contract should be approved.\",\"properties\":{\"alternatives_considered\":{\"description\":\"Alternative actions that were considered but rejected, and brief rationale for rejection.\",\"items\":{\"type\":\"string\"},\"type\":\"array\"},\"assumptions\":{\"description\":\"Premises the proposal depends on; if one proves false, the confidence no longer holds.\",\"items\":{\"type\":\"string\"},\"type\":\"array\"},\"cited_evidence\":{\"description\":\"Pointers of the evidence entries this reasoning relies on. Each must match the pointer of an entry in the evidence list.\",\"items\":{\"type\":\"string\"},\"type\":\"array\"},\"confidence\":{\"description\":\"Agent's confidence in this proposal (0.0 to 1.0). Must be calibrated to observed ground truth.\",\"maximum\":1,\"minimum\":0,\"type\":\"number\"},\"constitutional_grounding\":{\"description\":\"Articles of the Constitution that support this proposal. Examples: 'Article IV.1', 'Article III.2'.\",\"items\":{\"pattern\":\"^Article [I-XII](\\\\.\\\\d+)?$\",\"type\":\"string\"},\"minItems\":1,\"type\":\"array\"},\"model_version\":{\"description\":\"Version of the model that produced the reasoning, for calibrating confidence against observed outcomes.\",\"type\":\"string\"},\"rationale\":{\"description\":\"Clear, explicit explanation of why this action is proposed. Must be substantive and reference the evidence.\",\"maxLength\":5000,\"minLength\":10,\"type\":\"string\"},\"uncertainties\":{\"description\":\"Explicit acknowledgment of uncertainties, limitations, or controversial aspects of the proposal.\",\"items\":{\"type\":\"string\"},\"type\":\"array\"}},\"required\":[\"confidence\",\"constitutional_grounding\",\"rationale\"],\"type\":\"object\"},\"reputation_stake\":{\"description\":\"Amount of proposer's reputation being staked on this contract. Lost if contract is invalidated by fraud proof.\",\"maximum\":1000,\"minimum\":0,\"type\":\"number\"},\"reversibility_class\":{\"description\":\"Classification of whether/how easily the action can be undone. Determines challenge window duration and human oversight requirements.\",\"enum\":[\"easily_reversible\",\"irreversible\",\"partially_reversible\"],\"type\":\"string\"},\"semantic_hash\":{\"description\":\"Optional semantic hash of the reasoning. Format depends on embedding model. Used for fraud-proof semantic similarity detection.\",\"pattern\":\"^(semantic:[a-f0-9]+|)$\",\"type\":\"string\"},\"timestamp\":{\"description\":\"ISO 8601 timestamp (UTC) when this contract was submitted. Used for ordering and timeout calculations.\",\"format\":\"date-time\",\"type\":\"string\"}},\"required\":[\"action\",\"action_type\",\"canonical_serialization\",\"evidence\",\"id\",\"post_state_hash\",\"pre_state_hash\",\"proposer_agent\",\"proposer_signature\",\"reasoning\",\"reversibility_class\",\"timestamp\"],\"title\":\"OCP Contract Proposal Schema\",\"type\":\"object\"}"
+
+// ValidateContract validates a decoded document against contract.schema.json
+func ValidateContract(doc interface{}) error {
+	var errs Errors
+	validateContract(doc, "$", &errs)
+	return errs.err()
+}
+
+// FraudProofSchemaHash is the SHA-256 of the fraud_proof.schema.json this code was generated from
+const FraudProofSchemaHash = "8d686ddc7f662772019d9d9630563e7479f1aa9a4fffd84b7e06b8be43642439"
+
+// FraudProofSchemaSource is the canonical form of fraud_proof.schema.json
+const FraudProofSchemaSource = "{\"additionalProperties\":false,\"description\":\"Schema for a cryptographic fraud proof submitted by an Agent to challenge an action based on Constitutional violation or execution inconsistency.\",\"properties\":{\"challenger_agent_id\":{\"description\":\"The ID of the Agent submitting the fraud proof (used for staking/reputation tracking).\",\"type\":\"string\"},\"constitutional_citation\":{\"description\":\"The specific Article and Section of the Constitution being violated (e.g., 'Art. III, Sec. 3.3').\",\"type\":\"string\"},\"evidence\":{\"additionalProperties\":false,\"description\":\"Cryptographic and logical evidence required to substantiate the claim.\",\"properties\":{\"archive_reference\":{\"description\":\"A cryptographic reference (e.g., Merkle Proof or Archive Hash) linking the fraud proof to the specific, immutable archive entry that contains the violation.\",\"type\":\"string\"},\"contradictory_execution_log\":{\"description\":\"Required for EXECUTION_INCONSISTENCY; a link to the contradictory log from an independent LLM instance.\",\"type\":\"string\"},\"recomputed_hash\":{\"description\":\"The correct hash value as recomputed by the challenger, used to demonstrate a HASH_MISMATCH (6.1).\",\"type\":\"string\"}},\"required\":[\"archive_reference\",\"recomputed_hash\"],\"type\":\"object\"},\"fraud_proof_id\":{\"description\":\"A unique, cryptographically generated identifier for this specific fraud proof submission.\",\"type\":\"string\"},\"fraud_type\":{\"description\":\"Classification of the violation.\",\"enum\":[\"CONSTITUTIONAL_VIOLATION\",\"EXECUTION_INCONSISTENCY\",\"HASH_MISMATCH\",\"PROCEDURAL_VIOLATION\",\"REPUTATION_MANIPULATION\"],\"type\":\"string\"},\"justification_message\":{\"description\":\"A human-readable explanation of why the contract violates the Constitution or protocol rules.\",\"type\":\"string\"},\"offending_contract_id\":{\"description\":\"The unique ID of the action or contract that the Agent is challenging.\",\"type\":\"string\"},\"reputation_stake_proof\":{\"description\":\"Cryptographic proof that the Challenger Agent has staked the required reputation, as per Article VI, Section 6.4 (Challenger Staking).\",\"type\":\"string\"},\"signature\":{\"description\":\"The Challenger Agent's cryptographic signature over the entire fraud proof JSON object, ensuring authenticity and non-repudiation.\",\"type\":\"string\"},\"submission_timestamp\":{\"description\":\"The time of submission to the Protocol Layer.\",\"format\":\"date-time\",\"type\":\"string\"}},\"required\":[\"challenger_agent_id\",\"constitutional_citation\",\"evidence\",\"fraud_proof_id\",\"fraud_type\",\"justification_message\",\"offending_contract_id\"],\"title\":\"FraudProofSchema\",\"type\":\"object\"}"
+
+// ValidateFraudProof validates a decoded document against fraud_proof.schema.json
+func ValidateFraudProof(doc interface{}) error {
+	var errs Errors
+	validateFraudProof(doc, "$", &errs)
+	return errs.err()
+}
+
+// generated lists the compiled schemas by file name
+var generated = map[string]Compiled{
+	"archive_entry.schema.json": {Name: "ArchiveEntry", Hash: ArchiveEntrySchemaHash, Source: ArchiveEntrySchemaSource, Validate: ValidateArchiveEntry},
+	"contract.schema.json":      {Name: "Contract", Hash: ContractSchemaHash, Source: ContractSchemaSource, Validate: ValidateContract},
+	"fraud_proof.schema.json":   {Name: "FraudProof", Hash: FraudProofSchemaHash, Source: FraudProofSchemaSource, Validate: ValidateFraudProof},
+}
+
+var (
+	reArchiveEntry0 = regexp.MustCompile("^[a-f0-9]{64}$")
+	reContract0     = regexp.MustCompile("^[a-f0-9\\-]{36}$")
+	reContract1     = regexp.MustCompile("^[a-f0-9\\-]{36}$")
+	reContract2     = regexp.MustCompile("^sha256:[a-f0-9]{64}$")
+	reContract3     = regexp.MustCompile("^sha256:[a-f0-9]{64}$")
+	reContract4     = regexp.MustCompile("^[a-f0-9]+$")
+	reContract5     = regexp.MustCompile("^Article [I-XII](\\.\\d+)?$")
+	reContract6     = regexp.MustCompile("^(semantic:[a-f0-9]+|)$")
+)
+
+func validateArchiveEntryActionType(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "contract_proposal", "fraud_proof", "constitutional_amendment", "verification_result", "human_override":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateArchiveEntryAgentId(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntryConstitutionalCitation(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntryEntryId(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !isUUID(s) {
+		errs.Add(path, "format", "expected uuid")
+	}
+}
+
+func validateArchiveEntryEvidencePointersItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntryEvidencePointers(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateArchiveEntryEvidencePointersItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateArchiveEntryPostStateHash(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntryPreStateHash(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntrySemanticHash(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reArchiveEntry0.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^[a-f0-9]{64}$")
+	}
+}
+
+func validateArchiveEntrySignature(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntryTimestamp(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !isDateTime(s) {
+		errs.Add(path, "format", "expected date-time")
+	}
+}
+
+func validateArchiveEntryZkProof(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateArchiveEntry(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"entry_id", "agent_id", "action_type", "semantic_hash", "pre_state_hash", "post_state_hash", "timestamp", "signature", "constitutional_citation"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["action_type"]; ok {
+		validateArchiveEntryActionType(pv, path+".action_type", errs)
+	}
+	if pv, ok := m["agent_id"]; ok {
+		validateArchiveEntryAgentId(pv, path+".agent_id", errs)
+	}
+	if pv, ok := m["constitutional_citation"]; ok {
+		validateArchiveEntryConstitutionalCitation(pv, path+".constitutional_citation", errs)
+	}
+	if pv, ok := m["entry_id"]; ok {
+		validateArchiveEntryEntryId(pv, path+".entry_id", errs)
+	}
+	if pv, ok := m["evidence_pointers"]; ok {
+		validateArchiveEntryEvidencePointers(pv, path+".evidence_pointers", errs)
+	}
+	if pv, ok := m["post_state_hash"]; ok {
+		validateArchiveEntryPostStateHash(pv, path+".post_state_hash", errs)
+	}
+	if pv, ok := m["pre_state_hash"]; ok {
+		validateArchiveEntryPreStateHash(pv, path+".pre_state_hash", errs)
+	}
+	if pv, ok := m["semantic_hash"]; ok {
+		validateArchiveEntrySemanticHash(pv, path+".semantic_hash", errs)
+	}
+	if pv, ok := m["signature"]; ok {
+		validateArchiveEntrySignature(pv, path+".signature", errs)
+	}
+	if pv, ok := m["timestamp"]; ok {
+		validateArchiveEntryTimestamp(pv, path+".timestamp", errs)
+	}
+	if pv, ok := m["zk_proof"]; ok {
+		validateArchiveEntryZkProof(pv, path+".zk_proof", errs)
+	}
+	for key := range m {
+		switch key {
+		case "action_type", "agent_id", "constitutional_citation", "entry_id", "evidence_pointers", "post_state_hash", "pre_state_hash", "semantic_hash", "signature", "timestamp", "zk_proof":
+		default:
+			errs.Add(path+"."+key, "additionalProperties", "unexpected property")
+		}
+	}
+}
+
+func validateContractActionOperation(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractActionParameters(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		errs.Add(path, "type", "expected object")
+	}
+}
+
+func validateContractActionTarget(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractAction(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"target", "operation"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["operation"]; ok {
+		validateContractActionOperation(pv, path+".operation", errs)
+	}
+	if pv, ok := m["parameters"]; ok {
+		validateContractActionParameters(pv, path+".parameters", errs)
+	}
+	if pv, ok := m["target"]; ok {
+		validateContractActionTarget(pv, path+".target", errs)
+	}
+}
+
+func validateContractActionType(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "approve", "reject", "amend", "delegate", "suspend", "override", "emergency_halt", "canonical_change":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractActivationHeight(v interface{}, path string, errs *Errors) {
+	n, ok := toNumber(v)
+	if !ok {
+		errs.Add(path, "type", "expected integer")
+		return
+	}
+	if !isInteger(n) {
+		errs.Add(path, "type", "expected integer")
+	}
+	if n < 1 {
+		errs.Add(path, "minimum", "expected at least 1")
+	}
+}
+
+func validateContractActivationTime(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !isDateTime(s) {
+		errs.Add(path, "format", "expected date-time")
+	}
+}
+
+func validateContractCanonicalSerialization(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractEvidenceItemDescription(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractEvidenceItemPointer(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractEvidenceItemType(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "archive_reference", "constitutional_citation", "computation", "external_source", "agent_testimony":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractEvidenceItem(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"type", "pointer"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["description"]; ok {
+		validateContractEvidenceItemDescription(pv, path+".description", errs)
+	}
+	if pv, ok := m["pointer"]; ok {
+		validateContractEvidenceItemPointer(pv, path+".pointer", errs)
+	}
+	if pv, ok := m["type"]; ok {
+		validateContractEvidenceItemType(pv, path+".type", errs)
+	}
+}
+
+func validateContractEvidence(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	if len(arr) < 1 {
+		errs.Add(path, "minItems", "expected at least 1 items")
+	}
+	for i, item := range arr {
+		validateContractEvidenceItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractId(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract0.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^[a-f0-9\\-]{36}$")
+	}
+}
+
+func validateContractMetadataDomain(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "governance", "technical", "economic", "social", "amendment":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractMetadataRelatedContractsItem(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract1.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^[a-f0-9\\-]{36}$")
+	}
+}
+
+func validateContractMetadataRelatedContracts(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractMetadataRelatedContractsItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractMetadataTagsItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractMetadataTags(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractMetadataTagsItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractMetadata(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	if pv, ok := m["domain"]; ok {
+		validateContractMetadataDomain(pv, path+".domain", errs)
+	}
+	if pv, ok := m["related_contracts"]; ok {
+		validateContractMetadataRelatedContracts(pv, path+".related_contracts", errs)
+	}
+	if pv, ok := m["tags"]; ok {
+		validateContractMetadataTags(pv, path+".tags", errs)
+	}
+}
+
+func validateContractPostStateHash(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract2.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^sha256:[a-f0-9]{64}$")
+	}
+}
+
+func validateContractPreStateHash(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract3.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^sha256:[a-f0-9]{64}$")
+	}
+}
+
+func validateContractProposerAgent(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "Claude", "Gemini", "ChatGPT", "Comet", "DeepSeek":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractProposerSignatureAlgorithm(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "ed25519", "ecdsa-p256":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractProposerSignatureValue(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract4.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^[a-f0-9]+$")
+	}
+}
+
+func validateContractProposerSignature(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"algorithm", "value"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["algorithm"]; ok {
+		validateContractProposerSignatureAlgorithm(pv, path+".algorithm", errs)
+	}
+	if pv, ok := m["value"]; ok {
+		validateContractProposerSignatureValue(pv, path+".value", errs)
+	}
+}
+
+func validateContractReasoningAlternativesConsideredItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractReasoningAlternativesConsidered(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractReasoningAlternativesConsideredItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractReasoningAssumptionsItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractReasoningAssumptions(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractReasoningAssumptionsItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractReasoningCitedEvidenceItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractReasoningCitedEvidence(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractReasoningCitedEvidenceItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractReasoningConfidence(v interface{}, path string, errs *Errors) {
+	n, ok := toNumber(v)
+	if !ok {
+		errs.Add(path, "type", "expected number")
+		return
+	}
+	if n < 0 {
+		errs.Add(path, "minimum", "expected at least 0")
+	}
+	if n > 1 {
+		errs.Add(path, "maximum", "expected at most 1")
+	}
+}
+
+func validateContractReasoningConstitutionalGroundingItem(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract5.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^Article [I-XII](\\.\\d+)?$")
+	}
+}
+
+func validateContractReasoningConstitutionalGrounding(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	if len(arr) < 1 {
+		errs.Add(path, "minItems", "expected at least 1 items")
+	}
+	for i, item := range arr {
+		validateContractReasoningConstitutionalGroundingItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractReasoningModelVersion(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractReasoningRationale(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if utf8.RuneCountInString(s) < 10 {
+		errs.Add(path, "minLength", "expected at least 10 characters")
+	}
+	if utf8.RuneCountInString(s) > 5000 {
+		errs.Add(path, "maxLength", "expected at most 5000 characters")
+	}
+}
+
+func validateContractReasoningUncertaintiesItem(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateContractReasoningUncertainties(v interface{}, path string, errs *Errors) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected array")
+		return
+	}
+	for i, item := range arr {
+		validateContractReasoningUncertaintiesItem(item, fmt.Sprintf("%s[%d]", path, i), errs)
+	}
+}
+
+func validateContractReasoning(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"rationale", "constitutional_grounding", "confidence"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["alternatives_considered"]; ok {
+		validateContractReasoningAlternativesConsidered(pv, path+".alternatives_considered", errs)
+	}
+	if pv, ok := m["assumptions"]; ok {
+		validateContractReasoningAssumptions(pv, path+".assumptions", errs)
+	}
+	if pv, ok := m["cited_evidence"]; ok {
+		validateContractReasoningCitedEvidence(pv, path+".cited_evidence", errs)
+	}
+	if pv, ok := m["confidence"]; ok {
+		validateContractReasoningConfidence(pv, path+".confidence", errs)
+	}
+	if pv, ok := m["constitutional_grounding"]; ok {
+		validateContractReasoningConstitutionalGrounding(pv, path+".constitutional_grounding", errs)
+	}
+	if pv, ok := m["model_version"]; ok {
+		validateContractReasoningModelVersion(pv, path+".model_version", errs)
+	}
+	if pv, ok := m["rationale"]; ok {
+		validateContractReasoningRationale(pv, path+".rationale", errs)
+	}
+	if pv, ok := m["uncertainties"]; ok {
+		validateContractReasoningUncertainties(pv, path+".uncertainties", errs)
+	}
+}
+
+func validateContractReputationStake(v interface{}, path string, errs *Errors) {
+	n, ok := toNumber(v)
+	if !ok {
+		errs.Add(path, "type", "expected number")
+		return
+	}
+	if n < 0 {
+		errs.Add(path, "minimum", "expected at least 0")
+	}
+	if n > 1000 {
+		errs.Add(path, "maximum", "expected at most 1000")
+	}
+}
+
+func validateContractReversibilityClass(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "easily_reversible", "partially_reversible", "irreversible":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateContractSemanticHash(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !reContract6.MatchString(s) {
+		errs.Add(path, "pattern", "does not match ^(semantic:[a-f0-9]+|)$")
+	}
+}
+
+func validateContractTimestamp(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !isDateTime(s) {
+		errs.Add(path, "format", "expected date-time")
+	}
+}
+
+func validateContract(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"id", "proposer_agent", "action_type", "action", "evidence", "reasoning", "reversibility_class", "pre_state_hash", "post_state_hash", "canonical_serialization", "timestamp", "proposer_signature"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["action"]; ok {
+		validateContractAction(pv, path+".action", errs)
+	}
+	if pv, ok := m["action_type"]; ok {
+		validateContractActionType(pv, path+".action_type", errs)
+	}
+	if pv, ok := m["activation_height"]; ok {
+		validateContractActivationHeight(pv, path+".activation_height", errs)
+	}
+	if pv, ok := m["activation_time"]; ok {
+		validateContractActivationTime(pv, path+".activation_time", errs)
+	}
+	if pv, ok := m["canonical_serialization"]; ok {
+		validateContractCanonicalSerialization(pv, path+".canonical_serialization", errs)
+	}
+	if pv, ok := m["evidence"]; ok {
+		validateContractEvidence(pv, path+".evidence", errs)
+	}
+	if pv, ok := m["id"]; ok {
+		validateContractId(pv, path+".id", errs)
+	}
+	if pv, ok := m["metadata"]; ok {
+		validateContractMetadata(pv, path+".metadata", errs)
+	}
+	if pv, ok := m["post_state_hash"]; ok {
+		validateContractPostStateHash(pv, path+".post_state_hash", errs)
+	}
+	if pv, ok := m["pre_state_hash"]; ok {
+		validateContractPreStateHash(pv, path+".pre_state_hash", errs)
+	}
+	if pv, ok := m["proposer_agent"]; ok {
+		validateContractProposerAgent(pv, path+".proposer_agent", errs)
+	}
+	if pv, ok := m["proposer_signature"]; ok {
+		validateContractProposerSignature(pv, path+".proposer_signature", errs)
+	}
+	if pv, ok := m["reasoning"]; ok {
+		validateContractReasoning(pv, path+".reasoning", errs)
+	}
+	if pv, ok := m["reputation_stake"]; ok {
+		validateContractReputationStake(pv, path+".reputation_stake", errs)
+	}
+	if pv, ok := m["reversibility_class"]; ok {
+		validateContractReversibilityClass(pv, path+".reversibility_class", errs)
+	}
+	if pv, ok := m["semantic_hash"]; ok {
+		validateContractSemanticHash(pv, path+".semantic_hash", errs)
+	}
+	if pv, ok := m["timestamp"]; ok {
+		validateContractTimestamp(pv, path+".timestamp", errs)
+	}
+	for key := range m {
+		switch key {
+		case "action", "action_type", "activation_height", "activation_time", "canonical_serialization", "evidence", "id", "metadata", "post_state_hash", "pre_state_hash", "proposer_agent", "proposer_signature", "reasoning", "reputation_stake", "reversibility_class", "semantic_hash", "timestamp":
+		default:
+			errs.Add(path+"."+key, "additionalProperties", "unexpected property")
+		}
+	}
+}
+
+func validateFraudProofChallengerAgentId(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofConstitutionalCitation(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofEvidenceArchiveReference(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofEvidenceContradictoryExecutionLog(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofEvidenceRecomputedHash(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofEvidence(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"archive_reference", "recomputed_hash"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["archive_reference"]; ok {
+		validateFraudProofEvidenceArchiveReference(pv, path+".archive_reference", errs)
+	}
+	if pv, ok := m["contradictory_execution_log"]; ok {
+		validateFraudProofEvidenceContradictoryExecutionLog(pv, path+".contradictory_execution_log", errs)
+	}
+	if pv, ok := m["recomputed_hash"]; ok {
+		validateFraudProofEvidenceRecomputedHash(pv, path+".recomputed_hash", errs)
+	}
+	for key := range m {
+		switch key {
+		case "archive_reference", "contradictory_execution_log", "recomputed_hash":
+		default:
+			errs.Add(path+"."+key, "additionalProperties", "unexpected property")
+		}
+	}
+}
+
+func validateFraudProofFraudProofId(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofFraudType(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	switch s {
+	case "HASH_MISMATCH", "PROCEDURAL_VIOLATION", "CONSTITUTIONAL_VIOLATION", "EXECUTION_INCONSISTENCY", "REPUTATION_MANIPULATION":
+	default:
+		errs.Add(path, "enum", fmt.Sprintf("%q is not an allowed value", s))
+	}
+}
+
+func validateFraudProofJustificationMessage(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofOffendingContractId(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofReputationStakeProof(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofSignature(v interface{}, path string, errs *Errors) {
+	if _, ok := v.(string); !ok {
+		errs.Add(path, "type", "expected string")
+	}
+}
+
+func validateFraudProofSubmissionTimestamp(v interface{}, path string, errs *Errors) {
+	s, ok := v.(string)
+	if !ok {
+		errs.Add(path, "type", "expected string")
+		return
+	}
+	if !isDateTime(s) {
+		errs.Add(path, "format", "expected date-time")
+	}
+}
+
+func validateFraudProof(v interface{}, path string, errs *Errors) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		errs.Add(path, "type", "expected object")
+		return
+	}
+	for _, key := range []string{"fraud_proof_id", "offending_contract_id", "challenger_agent_id", "constitutional_citation", "fraud_type", "justification_message", "evidence"} {
+		if _, ok := m[key]; !ok {
+			errs.Add(path+"."+key, "required", "missing required property")
+		}
+	}
+	if pv, ok := m["challenger_agent_id"]; ok {
+		validateFraudProofChallengerAgentId(pv, path+".challenger_agent_id", errs)
+	}
+	if pv, ok := m["constitutional_citation"]; ok {
+		validateFraudProofConstitutionalCitation(pv, path+".constitutional_citation", errs)
+	}
+	if pv, ok := m["evidence"]; ok {
+		validateFraudProofEvidence(pv, path+".evidence", errs)
+	}
+	if pv, ok := m["fraud_proof_id"]; ok {
+		validateFraudProofFraudProofId(pv, path+".fraud_proof_id", errs)
+	}
+	if pv, ok := m["fraud_type"]; ok {
+		validateFraudProofFraudType(pv, path+".fraud_type", errs)
+	}
+	if pv, ok := m["justification_message"]; ok {
+		validateFraudProofJustificationMessage(pv, path+".justification_message", errs)
+	}
+	if pv, ok := m["offending_contract_id"]; ok {
+		validateFraudProofOffendingContractId(pv, path+".offending_contract_id", errs)
+	}
+	if pv, ok := m["reputation_stake_proof"]; ok {
+		validateFraudProofReputationStakeProof(pv, path+".reputation_stake_proof", errs)
+	}
+	if pv, ok := m["signature"]; ok {
+		validateFraudProofSignature(pv, path+".signature", errs)
+	}
+	if pv, ok := m["submission_timestamp"]; ok {
+		validateFraudProofSubmissionTimestamp(pv, path+".submission_timestamp", errs)
+	}
+	for key := range m {
+		switch key {
+		case "challenger_agent_id", "constitutional_citation", "evidence", "fraud_proof_id", "fraud_type", "justification_message", "offending_contract_id", "reputation_stake_proof", "signature", "submission_timestamp":
+		default:
+			errs.Add(path+"."+key, "additionalProperties", "unexpected property")
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+const schemaDir = "../../protocol/schemas"
+
+// TestGeneratedMatchesBundledSchemas fails when a schema changed without `go generate ./schema`
+func TestGeneratedMatchesBundledSchemas(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(schemaDir, "*.schema.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No bundled schemas found in %s: %v", schemaDir, err)
+	}
+
+	compiled := Schemas()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		sum := sha256.Sum256(data)
+
+		c, ok := compiled[filepath.Base(file)]
+		if !ok {
+			t.Errorf("%s has no generated validator; run go generate ./schema", filepath.Base(file))
+			continue
+		}
+		if c.Hash != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s changed since generation; run go generate ./schema", filepath.Base(file))
+		}
+	}
+	if len(compiled) != len(files) {
+		t.Errorf("Generated %d validators for %d schemas", len(compiled), len(files))
+	}
+
+	t.Logf("✓ %d generated validators match bundled schemas", len(compiled))
+}
+
+// validArchiveEntry returns a document satisfying archive_entry.schema.json
+func validArchiveEntry() map[string]interface{} {
+	return map[string]interface{}{
+		"entry_id":                "550e8400-e29b-41d4-a716-446655440000",
+		"agent_id":                "Claude",
+		"action_type":             "contract_proposal",
+		"semantic_hash":           "c3c0e79e0cb29f51d73ff6a9ecfa9a9d1a5d0e7cda1bf5b0b8d6d40c3f5ba2b1",
+		"pre_state_hash":          "sha256:aaa",
+		"post_state_hash":         "sha256:bbb",
+		"timestamp":               "2025-11-20T14:30:00Z",
+		"signature":               "3a4b5c",
+		"constitutional_citation": "Article III.1",
+		"evidence_pointers":       []interface{}{"sha256:abc"},
+	}
+}
+
+// TestValidateArchiveEntry tests each supported keyword against violations
+func TestValidateArchiveEntry(t *testing.T) {
+	if err := ValidateArchiveEntry(validArchiveEntry()); err != nil {
+		t.Fatalf("Valid entry rejected: %v", err)
+	}
+
+	cases := []struct {
+		mutate  func(doc map[string]interface{})
+		path    string
+		keyword string
+	}{
+		{func(d map[string]interface{}) { delete(d, "agent_id") }, "$.agent_id", "required"},
+		{func(d map[string]interface{}) { d["action_type"] = "coup" }, "$.action_type", "enum"},
+		{func(d map[string]interface{}) { d["semantic_hash"] = "xyz" }, "$.semantic_hash", "pattern"},
+		{func(d map[string]interface{}) { d["timestamp"] = "yesterday" }, "$.timestamp", "format"},
+		{func(d map[string]interface{}) { d["entry_id"] = "not-a-uuid" }, "$.entry_id", "format"},
+		{func(d map[string]interface{}) { d["evidence_pointers"] = []interface{}{float64(1)} }, "$.evidence_pointers[0]", "type"},
+		{func(d map[string]interface{}) { d["extra"] = true }, "$.extra", "additionalProperties"},
+	}
+
+	for _, tc := range cases {
+		doc := validArchiveEntry()
+		tc.mutate(doc)
+		err := ValidateArchiveEntry(doc)
+
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 1 {
+			t.Errorf("%s: expected one violation, got %v", tc.path, err)
+			continue
+		}
+		if errs[0].Path != tc.path || errs[0].Keyword != tc.keyword {
+			t.Errorf("Expected %s at %s, got %+v", tc.keyword, tc.path, errs[0])
+		}
+	}
+
+	t.Logf("✓ %d archive entry violations reported", len(cases))
+}
+
+// TestValidateContractNumbersAndArrays tests numeric and array keywords
+func TestValidateContractNumbersAndArrays(t *testing.T) {
+	doc := map[string]interface{}{
+		"evidence":         []interface{}{},
+		"reputation_stake": float64(5000),
+		"reasoning":        map[string]interface{}{"rationale": "short", "confidence": float64(2)},
+	}
+
+	err := ValidateContract(doc)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected violations, got %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, e := range errs {
+		found[e.Path+" "+e.Keyword] = true
+	}
+	for _, want := range []string{
+		"$.evidence minItems",
+		"$.reputation_stake maximum",
+		"$.reasoning.confidence maximum",
+		"$.reasoning.rationale minLength",
+		"$.reasoning.constitutional_grounding required",
+		"$.id required",
+	} {
+		if !found[want] {
+			t.Errorf("Missing violation %q in %v", want, errs)
+		}
+	}
+
+	// Violations are reported in a deterministic order
+	if again := ValidateContract(doc); again.Error() != err.Error() {
+		t.Errorf("Violation order should be deterministic")
+	}
+
+	t.Logf("✓ %d contract violations reported", len(errs))
+}
+
+// TestValidateDecodedDocument tests validating strictly decoded JSON text
+func TestValidateDecodedDocument(t *testing.T) {
+	raw := []byte(`{"fraud_proof_id":"fp-1","offending_contract_id":"c-1","challenger_agent_id":"Gemini",
+		"constitutional_citation":"Art. III, Sec. 3.3","fraud_type":"HASH_MISMATCH",
+		"justification_message":"Recomputed hash differs",
+		"evidence":{"archive_reference":"sha256:aaa","recomputed_hash":"sha256:bbb"}}`)
+
+	doc, err := canonical.DecodeStrict(raw)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if err := Validate("fraud_proof.schema.json", doc); err != nil {
+		t.Errorf("Valid fraud proof rejected: %v", err)
+	}
+	if err := Validate("missing.schema.json", doc); err == nil {
+		t.Errorf("Unknown schema should be an error")
+	}
+
+	t.Logf("✓ Decoded fraud proof validated")
+}
+
+// BenchmarkValidateArchiveEntry benchmarks generated archive entry validation
+func BenchmarkValidateArchiveEntry(b *testing.B) {
+	doc := validArchiveEntry()
+	for i := 0; i < b.N; i++ {
+		ValidateArchiveEntry(doc)
+	}
+}
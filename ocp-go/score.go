@@ -0,0 +1,219 @@
+// score.go - Deterministic visibility scoring of proposals
+//
+// Score combines a proposer's reputation, the proposal's stake, the number of
+// distinct evidence pointers it cites, and how long it has been waiting, using
+// integer arithmetic over ledger state only. Two nodes holding the same ledger
+// therefore compute identical scores and order their queues identically, and
+// the canonical score record lets a UI show exactly how a score was derived.
+//
+// Reputation is derived from the ledger rather than stored: each accepted
+// proposal earns its proposer one point, surviving a challenge earns one more
+// and losing one costs five, while challengers gain two points for an upheld
+// challenge and lose two for a rejected one. A resolution voided on appeal
+// changes nobody's reputation. An appeal stake leaves the appellant's
+// reputation while the escrow holds it, and goes to whoever the ruling or
+// lapse pays it to.
+
+package ocp
+
+import "sort"
+
+// Reputation adjustments applied by Reputation
+const (
+	reputationAccepted        = 1
+	reputationSurvived        = 1
+	reputationOverturned      = -5
+	reputationChallengeUpheld = 2
+	reputationChallengeFailed = -2
+)
+
+// ScoreWeights are the coefficients of the visibility score
+type ScoreWeights struct {
+	// Reputation multiplies the proposer's reputation
+	Reputation int
+	// Stake multiplies the proposal's reputation stake
+	Stake int
+	// Evidence is awarded per distinct evidence pointer, up to MaxEvidence
+	Evidence    int
+	MaxEvidence int
+	// AgeStep is the number of ledger entries per point of age; 0 ignores age
+	AgeStep int
+}
+
+// DefaultScoreWeights are the weights used by Score
+var DefaultScoreWeights = ScoreWeights{
+	Reputation:  10,
+	Stake:       1,
+	Evidence:    5,
+	MaxEvidence: 10,
+	AgeStep:     10,
+}
+
+// ToMap converts ScoreWeights to a map for canonicalization
+func (w ScoreWeights) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"reputation":   w.Reputation,
+		"stake":        w.Stake,
+		"evidence":     w.Evidence,
+		"max_evidence": w.MaxEvidence,
+		"age_step":     w.AgeStep,
+	}
+}
+
+// ProposalScore is the canonical record of how a proposal's score was derived
+type ProposalScore struct {
+	ProposalHash   string       `json:"proposal_hash"`
+	LedgerHeight   uint64       `json:"ledger_height"`
+	AcceptedHeight uint64       `json:"accepted_height"`
+	Reputation     int          `json:"reputation"`
+	Stake          int          `json:"stake"`
+	EvidenceCount  int          `json:"evidence_count"`
+	Age            uint64       `json:"age"`
+	Weights        ScoreWeights `json:"weights"`
+	Score          int          `json:"score"`
+}
+
+// ToMap converts a ProposalScore to a map for canonicalization
+func (s *ProposalScore) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":   s.ProposalHash,
+		"ledger_height":   s.LedgerHeight,
+		"accepted_height": s.AcceptedHeight,
+		"reputation":      s.Reputation,
+		"stake":           s.Stake,
+		"evidence_count":  s.EvidenceCount,
+		"age":             s.Age,
+		"weights":         s.Weights.ToMap(),
+		"score":           s.Score,
+	}
+}
+
+// Hash returns the semantic hash of the score record
+func (s *ProposalScore) Hash() (string, error) {
+	return SemanticHash(s.ToMap())
+}
+
+// Reputation derives an agent's reputation from ledger entries
+func Reputation(entries []LedgerEntry, agent string) int {
+	rep := make(map[string]int)
+	for _, e := range entries {
+		applyReputation(rep, e)
+	}
+	return rep[agent]
+}
+
+// applyReputation adds the reputation changes recorded by e to rep
+func applyReputation(rep map[string]int, e LedgerEntry) {
+	switch e.Kind {
+	case LedgerKindProposal:
+		rep[payloadString(e.Payload, "proposer_agent")] += reputationAccepted
+	case LedgerKindChallengeResolved:
+		proposerDelta, challengerDelta := resolutionReputation(payloadString(e.Payload, "outcome"))
+		rep[payloadString(e.Payload, "proposer")] += proposerDelta
+		rep[payloadString(e.Payload, "challenger")] += challengerDelta
+	case LedgerKindAppeal:
+		rep[payloadString(e.Payload, "appellant")] -= payloadInt(e.Payload, "stake")
+	case LedgerKindAppealRuling:
+		// A granted appeal reverses the changes of the resolution it voids
+		if granted, _ := e.Payload["granted"].(bool); granted {
+			proposerDelta, challengerDelta := resolutionReputation(payloadString(e.Payload, "resolution_outcome"))
+			rep[payloadString(e.Payload, "proposer")] -= proposerDelta
+			rep[payloadString(e.Payload, "challenger")] -= challengerDelta
+		}
+		rep[payloadString(e.Payload, "paid_to")] += payloadInt(e.Payload, "stake")
+	case LedgerKindAppealLapsed:
+		rep[payloadString(e.Payload, "paid_to")] += payloadInt(e.Payload, "stake")
+	}
+}
+
+// resolutionReputation returns the reputation changes a challenge resolution
+// with the given outcome makes to its proposer and challenger
+func resolutionReputation(outcome string) (proposer, challenger int) {
+	if outcome == ChallengeUpheld {
+		return reputationOverturned, reputationChallengeUpheld
+	}
+	return reputationSurvived, reputationChallengeFailed
+}
+
+// Score computes a proposal's visibility score with DefaultScoreWeights
+func Score(p *ContractProposal, ledger *Ledger) (*ProposalScore, error) {
+	return ScoreWith(p, ledger, DefaultScoreWeights)
+}
+
+// ScoreWith computes a proposal's visibility score
+//
+// Parameters:
+//   - p: Proposal to score
+//   - ledger: Ledger supplying reputation and the proposal's acceptance height
+//   - w: Score coefficients
+//
+// Returns:
+//   - Score record; a proposal not yet on the ledger has age 0
+func ScoreWith(p *ContractProposal, ledger *Ledger, w ScoreWeights) (*ProposalScore, error) {
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := ledger.Entries(0)
+	s := &ProposalScore{
+		ProposalHash:  hash,
+		LedgerHeight:  ledger.Height(),
+		Reputation:    Reputation(entries, p.ProposerAgent),
+		Stake:         p.ReputationStake,
+		EvidenceCount: distinctEvidence(p),
+		Weights:       w,
+	}
+	if n := len(entries); n > 0 {
+		s.LedgerHeight = entries[n-1].Height
+	}
+	for _, e := range entries {
+		if e.Kind == LedgerKindProposal && payloadString(e.Payload, "proposal_hash") == hash {
+			s.AcceptedHeight = e.Height
+			s.Age = s.LedgerHeight - e.Height
+			break
+		}
+	}
+
+	evidence := s.EvidenceCount
+	if evidence > w.MaxEvidence {
+		evidence = w.MaxEvidence
+	}
+	s.Score = w.Reputation*s.Reputation + w.Stake*s.Stake + w.Evidence*evidence
+	if w.AgeStep > 0 {
+		s.Score += int(s.Age / uint64(w.AgeStep))
+	}
+	return s, nil
+}
+
+// RankProposals scores proposals and orders them by descending score, then
+// ascending proposal hash
+func RankProposals(proposals []*ContractProposal, ledger *Ledger) ([]*ProposalScore, error) {
+	scores := make([]*ProposalScore, 0, len(proposals))
+	for _, p := range proposals {
+		s, err := Score(p, ledger)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].ProposalHash < scores[j].ProposalHash
+	})
+	return scores, nil
+}
+
+// distinctEvidence counts evidence items with distinct non-empty pointers, so
+// repeating one citation does not inflate a score
+func distinctEvidence(p *ContractProposal) int {
+	seen := make(map[string]bool)
+	for _, ref := range p.EvidenceRefs() {
+		if ref.Pointer != "" {
+			seen[ref.Pointer] = true
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,99 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestScoreComponents tests each term of the visibility score
+func TestScoreComponents(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := NewNode(NewLedger())
+	proposal := testProposal()
+	proposal.ReputationStake = 40
+	proposal.Evidence = append(proposal.Evidence, proposal.Evidence[0], map[string]string{
+		"type": "computation", "pointer": "sha256:fedcba",
+	})
+
+	if _, err := node.Submit(proposal); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		node.Ledger().Append("filler", map[string]interface{}{"index": i})
+	}
+
+	score, err := Score(proposal, node.Ledger())
+	if err != nil {
+		t.Fatalf("Failed to score: %v", err)
+	}
+	if score.Reputation != 1 || score.EvidenceCount != 2 || score.AcceptedHeight != 1 || score.Age != 20 {
+		t.Errorf("Unexpected score inputs: %+v", score)
+	}
+	// 10*1 reputation + 40 stake + 5*2 evidence + 20/10 age
+	if score.Score != 62 {
+		t.Errorf("Expected score 62, got %d", score.Score)
+	}
+
+	again, _ := Score(proposal, node.Ledger())
+	h1, _ := score.Hash()
+	h2, _ := again.Hash()
+	if h1 != h2 {
+		t.Errorf("Score record should be deterministic")
+	}
+
+	t.Logf("✓ Score %d, record %s", score.Score, h1)
+}
+
+// TestReputationFromChallenges tests reputation changes from resolved challenges
+func TestReputationFromChallenges(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := NewLedger()
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	proposal := testProposal()
+	proposal.ReputationStake = 60
+
+	for _, upheld := range []bool{true, false} {
+		bond, err := escrow.Lock("Gemini", proposal, escrow.RequiredBond("Gemini", proposal))
+		if err != nil {
+			t.Fatalf("Failed to lock bond: %v", err)
+		}
+		escrow.Resolve(bond.EntryHash, upheld)
+	}
+
+	entries := ledger.Entries(0)
+	if got := Reputation(entries, "Claude"); got != -4 {
+		t.Errorf("Proposer reputation: expected -4, got %d", got)
+	}
+	if got := Reputation(entries, "Gemini"); got != 0 {
+		t.Errorf("Challenger reputation: expected 0, got %d", got)
+	}
+
+	t.Logf("✓ Reputation derived from ledger")
+}
+
+// TestRankProposals tests deterministic queue ordering
+func TestRankProposals(t *testing.T) {
+	low := testProposal()
+	high := testProposal()
+	high.ID = "other"
+	high.ReputationStake = 100
+	tieA, tieB := testProposal(), testProposal()
+	tieA.ID, tieB.ID = "tie-a", "tie-b"
+
+	ranked, err := RankProposals([]*ContractProposal{low, tieA, high, tieB}, NewLedger())
+	if err != nil {
+		t.Fatalf("Failed to rank: %v", err)
+	}
+	highHash, _ := high.GetHash()
+	if ranked[0].ProposalHash != highHash {
+		t.Errorf("Highest stake should rank first")
+	}
+	for i := 1; i < len(ranked)-1; i++ {
+		if ranked[i].ProposalHash > ranked[i+1].ProposalHash {
+			t.Errorf("Equal scores should be ordered by hash")
+		}
+	}
+
+	t.Logf("✓ Ranked %d proposals", len(ranked))
+}
@@ -0,0 +1,248 @@
+// selftest.go - Startup self-test for deployed nodes
+//
+// SelfTest re-runs a small set of known-answer checks against the running
+// binary: the embedded canonicalization conformance vectors, an Ed25519
+// signing and verification round trip, a ledger hash chain, a read/write
+// probe of the node's archive, and the canonicalization invariants for every
+// supported canonical version. A node that fails any of them was miscompiled
+// or misconfigured and must not serve, since every hash it produced would
+// diverge from its peers' and corrupt the shared ledger.
+
+package ocp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical/invariants"
+)
+
+// Self-test check names, in the order they appear in a report
+const (
+	SelfTestVectors    = "conformance_vectors"
+	SelfTestSignature  = "signature_round_trip"
+	SelfTestLedger     = "ledger_chain"
+	SelfTestStorage    = "storage"
+	SelfTestInvariants = "canonical_invariants"
+)
+
+// CodeSelfTestFailed is the code reported by a failed self-test check
+const CodeSelfTestFailed = "SELF_TEST_FAILED"
+
+// conformanceVector is a known-answer canonicalization test. The vectors are
+// taken from protocol/hashing/test_vectors/canonicalization_tests.json and are
+// compiled in so that a node can check itself without the protocol tree.
+type conformanceVector struct {
+	name      string
+	keyOrder  canonical.KeyOrder
+	nulls     canonical.NullHandling
+	input     string
+	canonical string
+	hash      string
+}
+
+var conformanceVectors = []conformanceVector{
+	{
+		name:      "basic_ordering",
+		input:     `{"z": 3, "a": 1, "b": 2}`,
+		canonical: `{"a":1,"b":2,"z":3}`,
+		hash:      "329d4b5a274b8081ef038bb735813dc3082cf6d95855f8029c9cd8432168c112",
+	},
+	{
+		name:      "nested_ordering",
+		input:     `{"b": 2, "a": {"c": 3, "b": 2, "a": 1}}`,
+		canonical: `{"a":{"a":1,"b":2,"c":3},"b":2}`,
+		hash:      "dddf9b12196bdfdc8ce3f83a8bcf69c2d157b55dcacfdfae013b4cc2ffa72d5b",
+	},
+	{
+		name:      "set_semantics",
+		input:     `{"evidence": {"_set": [{"pointer": "sha256:aaa", "type": "archive_reference"}, {"type": "computation", "pointer": "sha256:bbb"}, {"type": "computation", "pointer": "sha256:bbb"}]}}`,
+		canonical: `{"evidence":{"_set":[{"pointer":"sha256:bbb","type":"computation"},{"pointer":"sha256:aaa","type":"archive_reference"}]}}`,
+		hash:      "dc31e03ccaa5b3157429781b11732b2090786737cbb61cc7e05d357d252b9639",
+	},
+	{
+		name:      "key_order_utf8_surrogates",
+		keyOrder:  canonical.KeyOrderUTF8,
+		input:     `{"｡": 1, "😀": 2, "a": 3}`,
+		canonical: "{\"a\":3,\"｡\":1,\"\U0001F600\":2}",
+		hash:      "36e014c0030b117ea444f79484b5d76d79754b22ce43c5bd2ec985f57a8bbffc",
+	},
+	{
+		name:      "key_order_utf16_surrogates",
+		keyOrder:  canonical.KeyOrderUTF16,
+		input:     `{"｡": 1, "😀": 2, "a": 3}`,
+		canonical: "{\"a\":3,\"\U0001F600\":2,\"｡\":1}",
+		hash:      "abec372b4e59235407b56223f98d823bec3eaa45c7ae810db0b91fbabc074673",
+	},
+	{
+		name:      "null_handling_keep",
+		nulls:     canonical.KeepNulls,
+		input:     `{"x": null, "y": {"z": null, "w": 1}, "a": [null, {"n": null}]}`,
+		canonical: `{"a":[null,{"n":null}],"x":null,"y":{"w":1,"z":null}}`,
+		hash:      "bf784b4e7f6f7951bb1bc809925224d92bafdd9c8721e2ce4ce9a1ecbecd651c",
+	},
+	{
+		name:      "null_handling_drop",
+		nulls:     canonical.DropNulls,
+		input:     `{"x": null, "y": {"z": null, "w": 1}, "a": [null, {"n": null}]}`,
+		canonical: `{"a":[null,{}],"y":{"w":1}}`,
+		hash:      "aa5da62a1d106149bd2c657a686dc77f4cfc8f860c02c3fc2c62da78d531a641",
+	},
+}
+
+// selfTestSeed derives the fixed key used by the signature round trip
+var selfTestSeed = bytes.Repeat([]byte{0x4f}, ed25519.SeedSize)
+
+// SelfTestReport collects the results of SelfTest
+type SelfTestReport struct {
+	Checks []CheckResult `json:"checks"`
+}
+
+// OK reports whether no check failed
+func (r *SelfTestReport) OK() bool {
+	for _, c := range r.Checks {
+		if c.Status == CheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns an error naming every failed check, or nil if none failed
+func (r *SelfTestReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	msg := "self-test failed:"
+	for _, c := range r.Checks {
+		if c.Status == CheckFailed {
+			msg += fmt.Sprintf(" %s (%s);", c.Check, c.Message)
+		}
+	}
+	return &ConstitutionalError{ErrorType: "SelfTestError", Message: msg}
+}
+
+// SelfTest runs the startup self-test
+//
+// Parameters:
+//   - store: Archive the node will serve from; nil skips the storage check
+//
+// Returns:
+//   - Report with one result per check, in a fixed order
+func SelfTest(store Archive) *SelfTestReport {
+	return &SelfTestReport{Checks: []CheckResult{
+		selfTestVectors(),
+		selfTestSignature(),
+		selfTestLedger(),
+		selfTestStorage(store),
+		selfTestInvariants(),
+	}}
+}
+
+func selfTestVectors() CheckResult {
+	for _, v := range conformanceVectors {
+		if v.keyOrder == canonical.KeyOrderUTF16 && !canonical.UTF16KeyOrderBuild {
+			continue
+		}
+		c := canonical.New(canonical.WithKeyOrder(v.keyOrder), canonical.WithNullHandling(v.nulls))
+		doc, err := canonical.DecodeStrict([]byte(v.input))
+		if err != nil {
+			return failed(SelfTestVectors, CodeSelfTestFailed, fmt.Sprintf("%s: %v", v.name, err))
+		}
+		form, err := c.Canonicalize(doc, true)
+		if err != nil {
+			return failed(SelfTestVectors, CodeSelfTestFailed, fmt.Sprintf("%s: %v", v.name, err))
+		}
+		if form != v.canonical {
+			return failed(SelfTestVectors, CodeSelfTestFailed, fmt.Sprintf("%s: canonical form %s, expected %s", v.name, form, v.canonical))
+		}
+		if hash := ContentHash([]byte(form)); hash != v.hash {
+			return failed(SelfTestVectors, CodeSelfTestFailed, fmt.Sprintf("%s: hash %s, expected %s", v.name, hash, v.hash))
+		}
+	}
+	return passed(SelfTestVectors)
+}
+
+func selfTestSignature() CheckResult {
+	key := ed25519.NewKeyFromSeed(selfTestSeed)
+	pub := key.Public().(ed25519.PublicKey)
+
+	hash, err := SemanticHash(map[string]interface{}{"self_test": SelfTestSignature})
+	if err != nil {
+		return failed(SelfTestSignature, CodeSelfTestFailed, err.Error())
+	}
+	sig, err := SignHash("self-test", key, ContextSelfTest, hash)
+	if err != nil {
+		return failed(SelfTestSignature, CodeSelfTestFailed, err.Error())
+	}
+	if err := VerifyHashSignature(pub, ContextSelfTest, hash, sig); err != nil {
+		return failed(SelfTestSignature, CodeSelfTestFailed, err.Error())
+	}
+
+	other, _ := SemanticHash(map[string]interface{}{"self_test": SelfTestLedger})
+	if VerifyHashSignature(pub, ContextSelfTest, other, sig) == nil {
+		return failed(SelfTestSignature, CodeSelfTestFailed, "signature verified against the wrong hash")
+	}
+	return passed(SelfTestSignature)
+}
+
+func selfTestLedger() CheckResult {
+	ledger := NewLedger()
+	for i := 0; i < 3; i++ {
+		if _, err := ledger.Append("self_test", map[string]interface{}{"index": i}); err != nil {
+			return failed(SelfTestLedger, CodeSelfTestFailed, err.Error())
+		}
+	}
+	if err := ledger.Verify(); err != nil {
+		return failed(SelfTestLedger, CodeSelfTestFailed, err.Error())
+	}
+
+	tampered := ledger.Entries(0)
+	tampered[1].Payload = map[string]interface{}{"index": 7}
+	if _, err := VerifyChain(0, "", tampered); err == nil {
+		return failed(SelfTestLedger, CodeSelfTestFailed, "tampered chain verified")
+	}
+	return passed(SelfTestLedger)
+}
+
+func selfTestInvariants() CheckResult {
+	for _, version := range canonical.SupportedVersions() {
+		c, err := canonical.ForVersion(version)
+		if err != nil {
+			return failed(SelfTestInvariants, CodeSelfTestFailed, err.Error())
+		}
+		if err := invariants.Check(c); err != nil {
+			return failed(SelfTestInvariants, CodeSelfTestFailed, fmt.Sprintf("%s: %v", version, err))
+		}
+	}
+	return passed(SelfTestInvariants)
+}
+
+func selfTestStorage(store Archive) CheckResult {
+	if store == nil {
+		return skipped(SelfTestStorage, "no archive supplied")
+	}
+
+	probe := map[string]interface{}{"self_test": SelfTestStorage, "version": Version}
+	hash, err := ArchiveObject(store, probe)
+	if err != nil {
+		return failed(SelfTestStorage, CodeSelfTestFailed, fmt.Sprintf("write: %v", err))
+	}
+	expected, _ := SemanticHash(probe)
+	if hash != expected {
+		return failed(SelfTestStorage, CodeSelfTestFailed, fmt.Sprintf("archive key %s, expected %s", hash, expected))
+	}
+	if ok, err := store.Has(hash); err != nil || !ok {
+		return failed(SelfTestStorage, CodeSelfTestFailed, fmt.Sprintf("probe %s not found after write", hash))
+	}
+	obj, err := LoadObject(store, hash)
+	if err != nil {
+		return failed(SelfTestStorage, CodeSelfTestFailed, fmt.Sprintf("read: %v", err))
+	}
+	if got, _ := SemanticHash(obj); got != hash {
+		return failed(SelfTestStorage, CodeSelfTestFailed, "probe read back with different content")
+	}
+	return passed(SelfTestStorage)
+}
@@ -0,0 +1,56 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// failingArchive is an Archive whose writes always fail
+type failingArchive struct{ *MemoryArchive }
+
+func (failingArchive) Put([]byte) (string, error) {
+	return "", errors.New("disk full")
+}
+
+// TestSelfTestPasses tests a healthy build with and without storage
+func TestSelfTestPasses(t *testing.T) {
+	testenv.RequireSigning(t)
+	report := SelfTest(NewMemoryArchive())
+	if err := report.Err(); err != nil {
+		t.Fatalf("Self-test should pass: %v", err)
+	}
+	for _, c := range report.Checks {
+		if c.Status != CheckPassed {
+			t.Errorf("%s: expected pass, got %s", c.Check, c.Status)
+		}
+	}
+
+	noStore := SelfTest(nil)
+	if !noStore.OK() || noStore.Checks[3].Status != CheckSkipped {
+		t.Errorf("Storage check should be skipped without an archive: %+v", noStore.Checks[3])
+	}
+
+	t.Logf("✓ %d self-test checks passed", len(report.Checks))
+}
+
+// TestSelfTestDetectsFailures tests that broken vectors and storage are reported
+func TestSelfTestDetectsFailures(t *testing.T) {
+	report := SelfTest(failingArchive{NewMemoryArchive()})
+	if report.OK() || report.Checks[3].Code != CodeSelfTestFailed {
+		t.Errorf("Failing storage should fail the self-test: %+v", report.Checks[3])
+	}
+
+	saved := conformanceVectors
+	defer func() { conformanceVectors = saved }()
+	conformanceVectors = append([]conformanceVector{}, saved...)
+	conformanceVectors[0].hash = conformanceVectors[1].hash
+
+	report = SelfTest(nil)
+	if report.OK() {
+		t.Errorf("A mismatched vector should fail the self-test")
+	}
+
+	t.Logf("✓ Self-test failure: %v", report.Err())
+}
@@ -0,0 +1,267 @@
+// sequencing.go - Commit-reveal ordering of proposals
+//
+// When proposals are sequenced in arrival order, an agent that sees a rival
+// amendment in the gossip layer can rush a conflicting one in ahead of it. The
+// Sequencer removes that advantage with two phases. In epoch N each agent
+// signs a SequenceCommitment: the hash of its next proposal's hash, a secret
+// nonce, its name, and the epoch. Commitments close with the epoch. In epoch
+// N+1 agents reveal the proposal and nonce, and the revealed proposals of
+// epoch N are ordered by commitment hash. Nothing about a proposal is visible
+// while commitments are open, and nothing can be committed once proposals are
+// visible, so no agent can adapt its proposal or its position to another's.
+//
+// Each agent commits at most once per epoch. A commitment that is never
+// revealed is dropped from the order and reported by Unrevealed, for
+// reputation policy to act on.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SequenceCommitment is an agent's signed commitment to its next proposal
+type SequenceCommitment struct {
+	Agent string `json:"agent"`
+	Epoch uint64 `json:"epoch"`
+	// Commitment is CommitmentHash of the proposal hash and nonce
+	Commitment string    `json:"commitment"`
+	Signature  Signature `json:"signature"`
+}
+
+// CommitmentHash returns the value committed to for proposalHash. The nonce
+// must be secret and unpredictable, so that the commitment reveals nothing
+// about proposals that others could guess.
+func CommitmentHash(agent string, epoch uint64, proposalHash, nonce string) (string, error) {
+	return SemanticHash(map[string]interface{}{
+		"agent":         agent,
+		"epoch":         epoch,
+		"proposal_hash": proposalHash,
+		"nonce":         nonce,
+	})
+}
+
+// NewSequenceCommitment creates and signs a commitment to proposalHash
+func NewSequenceCommitment(agent string, epoch uint64, proposalHash, nonce string, key ed25519.PrivateKey) (*SequenceCommitment, error) {
+	commitment, err := CommitmentHash(agent, epoch, proposalHash, nonce)
+	if err != nil {
+		return nil, err
+	}
+	c := &SequenceCommitment{Agent: agent, Epoch: epoch, Commitment: commitment}
+	hash, err := c.Hash()
+	if err != nil {
+		return nil, err
+	}
+	c.Signature, err = SignHash(agent, key, ContextCommitment, hash)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ToMap converts a SequenceCommitment to a map for canonicalization. The
+// signature is excluded, since it signs this form.
+func (c *SequenceCommitment) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"agent":      c.Agent,
+		"epoch":      c.Epoch,
+		"commitment": c.Commitment,
+	}
+}
+
+// Hash returns the semantic hash of the commitment
+func (c *SequenceCommitment) Hash() (string, error) {
+	return SemanticHash(c.ToMap())
+}
+
+// Verify checks the commitment's signature against the agent's key
+func (c *SequenceCommitment) Verify(key ed25519.PublicKey) error {
+	if c.Signature.Signer != c.Agent {
+		return NewVerificationError(fmt.Sprintf("commitment for %s signed by %s", c.Agent, c.Signature.Signer))
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextCommitment, hash, c.Signature)
+}
+
+// SequencedProposal is a revealed proposal and its place in an epoch's order
+type SequencedProposal struct {
+	Position   int
+	Agent      string
+	Commitment string
+	Proposal   *ContractProposal
+}
+
+// Sequencer collects commitments and reveals and orders proposals by epoch
+type Sequencer struct {
+	mu    sync.Mutex
+	keys  map[string]ed25519.PublicKey
+	epoch uint64
+	// commits and reveals are keyed by commitment epoch, then agent
+	commits map[uint64]map[string]*SequenceCommitment
+	reveals map[uint64]map[string]*ContractProposal
+}
+
+// NewSequencer creates a Sequencer accepting commitments from the agents in
+// keys, starting at epoch
+func NewSequencer(keys map[string]ed25519.PublicKey, epoch uint64) *Sequencer {
+	return &Sequencer{
+		keys:    keys,
+		epoch:   epoch,
+		commits: make(map[uint64]map[string]*SequenceCommitment),
+		reveals: make(map[uint64]map[string]*ContractProposal),
+	}
+}
+
+// Epoch returns the current epoch
+func (s *Sequencer) Epoch() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.epoch
+}
+
+// Advance closes the current epoch's commitments and the previous epoch's
+// reveals, and returns the new epoch
+func (s *Sequencer) Advance() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch++
+	return s.epoch
+}
+
+// Commit records a commitment for the current epoch
+//
+// Returns:
+//   - ConstitutionalError if the epoch is not current or the agent already
+//     committed in it
+//   - VerificationError if the agent is unknown or the signature is invalid
+func (s *Sequencer) Commit(c *SequenceCommitment) error {
+	key, ok := s.keys[c.Agent]
+	if !ok {
+		return NewVerificationError(fmt.Sprintf("no key for agent %s", c.Agent))
+	}
+	if err := c.Verify(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.Epoch != s.epoch {
+		return NewConstitutionalError(fmt.Sprintf("commitment for epoch %d outside commit epoch %d", c.Epoch, s.epoch))
+	}
+	if s.commits[c.Epoch] == nil {
+		s.commits[c.Epoch] = make(map[string]*SequenceCommitment)
+	}
+	if _, ok := s.commits[c.Epoch][c.Agent]; ok {
+		return NewConstitutionalError(fmt.Sprintf("%s already committed in epoch %d", c.Agent, c.Epoch))
+	}
+	s.commits[c.Epoch][c.Agent] = c
+	return nil
+}
+
+// Reveal opens the previous epoch's commitment by p's proposer
+//
+// Parameters:
+//   - p: The committed proposal
+//   - nonce: The nonce given to CommitmentHash
+//
+// Returns:
+//   - ConstitutionalError if the reveal phase for the commitment is not open,
+//     there is no commitment, or p and nonce do not match it
+func (s *Sequencer) Reveal(p *ContractProposal, nonce string) error {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.epoch == 0 {
+		return NewConstitutionalError("no commit epoch to reveal")
+	}
+	epoch := s.epoch - 1
+	c, ok := s.commits[epoch][p.ProposerAgent]
+	if !ok {
+		return NewConstitutionalError(fmt.Sprintf("%s made no commitment in epoch %d", p.ProposerAgent, epoch))
+	}
+	if _, ok := s.reveals[epoch][p.ProposerAgent]; ok {
+		return NewConstitutionalError(fmt.Sprintf("%s already revealed for epoch %d", p.ProposerAgent, epoch))
+	}
+	expected, err := CommitmentHash(p.ProposerAgent, epoch, proposalHash, nonce)
+	if err != nil {
+		return err
+	}
+	if expected != c.Commitment {
+		return NewConstitutionalError(fmt.Sprintf("proposal %s does not open %s's commitment", proposalHash, p.ProposerAgent))
+	}
+	if s.reveals[epoch] == nil {
+		s.reveals[epoch] = make(map[string]*ContractProposal)
+	}
+	s.reveals[epoch][p.ProposerAgent] = p
+	return nil
+}
+
+// Order returns the revealed proposals committed in epoch, sorted by
+// commitment hash. The order is final once the reveal phase has closed, that
+// is once the current epoch is past epoch+1.
+//
+// Returns:
+//   - Proposals in sequence order
+//   - ConstitutionalError while reveals for epoch are still open
+func (s *Sequencer) Order(epoch uint64) ([]SequencedProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.epoch <= epoch+1 {
+		return nil, NewConstitutionalError(fmt.Sprintf("reveals for epoch %d are still open", epoch))
+	}
+	var out []SequencedProposal
+	for agent, p := range s.reveals[epoch] {
+		out = append(out, SequencedProposal{Agent: agent, Commitment: s.commits[epoch][agent].Commitment, Proposal: p})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Commitment < out[j].Commitment })
+	for i := range out {
+		out[i].Position = i
+	}
+	return out, nil
+}
+
+// Unrevealed returns the agents that committed in epoch but have not
+// revealed, sorted by name
+func (s *Sequencer) Unrevealed(epoch uint64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for agent := range s.commits[epoch] {
+		if _, ok := s.reveals[epoch][agent]; !ok {
+			out = append(out, agent)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SubmitOrdered submits epoch's proposals to node in sequence order
+//
+// Returns:
+//   - Acceptances in sequence order
+//   - The first submission error, after which nothing more is submitted
+func (s *Sequencer) SubmitOrdered(node *Node, epoch uint64) ([]Acceptance, error) {
+	ordered, err := s.Order(epoch)
+	if err != nil {
+		return nil, err
+	}
+	acceptances := make([]Acceptance, 0, len(ordered))
+	for _, sp := range ordered {
+		a, err := node.Submit(sp.Proposal)
+		if err != nil {
+			return acceptances, err
+		}
+		acceptances = append(acceptances, a)
+	}
+	return acceptances, nil
+}
@@ -0,0 +1,108 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSequencerCommitReveal tests that revealed proposals are ordered by commitment
+func TestSequencerCommitReveal(t *testing.T) {
+	testenv.RequireSigning(t)
+	agents := []string{"Claude", "Gemini", "DeepSeek"}
+	keys := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	proposals := make(map[string]*ContractProposal)
+	for _, name := range agents {
+		keys[name], privs[name] = testKey(name)
+		p := testProposal()
+		p.ProposerAgent = name
+		proposals[name] = p
+	}
+	s := NewSequencer(keys, 7)
+
+	for _, name := range agents {
+		hash, _ := proposals[name].GetHash()
+		c, err := NewSequenceCommitment(name, 7, hash, "nonce-"+name, privs[name])
+		if err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+		if err := s.Commit(c); err != nil {
+			t.Fatalf("Failed to record commitment: %v", err)
+		}
+	}
+	hash, _ := proposals["Claude"].GetHash()
+	again, _ := NewSequenceCommitment("Claude", 7, hash, "other", privs["Claude"])
+	if err := s.Commit(again); err == nil {
+		t.Errorf("Expected a second commitment in the epoch to be rejected")
+	}
+	if err := s.Reveal(proposals["Claude"], "nonce-Claude"); err == nil {
+		t.Errorf("Expected a reveal during the commit epoch to be rejected")
+	}
+
+	s.Advance()
+	late, _ := NewSequenceCommitment("Gemini", 7, hash, "late", privs["Gemini"])
+	if err := s.Commit(late); err == nil {
+		t.Errorf("Expected a commitment after its epoch closed to be rejected")
+	}
+	if err := s.Reveal(proposals["Claude"], "wrong"); err == nil {
+		t.Errorf("Expected a wrong nonce to be rejected")
+	}
+	for _, name := range []string{"Claude", "Gemini"} {
+		if err := s.Reveal(proposals[name], "nonce-"+name); err != nil {
+			t.Fatalf("Failed to reveal: %v", err)
+		}
+	}
+	if _, err := s.Order(7); err == nil {
+		t.Errorf("Expected the order to wait for the reveal phase to close")
+	}
+
+	s.Advance()
+	order, err := s.Order(7)
+	if err != nil {
+		t.Fatalf("Failed to order: %v", err)
+	}
+	if len(order) != 2 || order[0].Commitment >= order[1].Commitment || order[1].Position != 1 {
+		t.Errorf("Expected two proposals sorted by commitment: %+v", order)
+	}
+	if missing := s.Unrevealed(7); len(missing) != 1 || missing[0] != "DeepSeek" {
+		t.Errorf("Expected DeepSeek unrevealed, got %v", missing)
+	}
+
+	node := NewNode(NewLedger())
+	acceptances, err := s.SubmitOrdered(node, 7)
+	if err != nil || len(acceptances) != 2 {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	first, _ := order[0].Proposal.GetHash()
+	if acceptances[0].ProposalHash != first || acceptances[0].LedgerHeight != 1 {
+		t.Errorf("Expected the first sequenced proposal at height 1: %+v", acceptances[0])
+	}
+
+	t.Logf("✓ Epoch 7 sequenced: %s then %s", order[0].Agent, order[1].Agent)
+}
+
+// TestSequenceCommitmentSignature tests that forged commitments are rejected
+func TestSequenceCommitmentSignature(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Claude")
+	_, other := testKey("Gemini")
+	s := NewSequencer(map[string]ed25519.PublicKey{"Claude": pub}, 0)
+
+	forged, _ := NewSequenceCommitment("Claude", 0, "ab", "n", other)
+	if err := s.Commit(forged); err == nil {
+		t.Errorf("Expected a commitment signed by another key to be rejected")
+	}
+	unknown, _ := NewSequenceCommitment("Gemini", 0, "ab", "n", other)
+	if err := s.Commit(unknown); err == nil {
+		t.Errorf("Expected an unknown agent to be rejected")
+	}
+	c, _ := NewSequenceCommitment("Claude", 0, "ab", "n", priv)
+	c.Commitment = "00"
+	if err := s.Commit(c); err == nil {
+		t.Errorf("Expected an altered commitment to be rejected")
+	}
+
+	t.Log("✓ Forged and altered commitments rejected")
+}
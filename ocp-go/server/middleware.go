@@ -0,0 +1,264 @@
+// middleware.go - Composable HTTP middleware
+//
+// A Middleware wraps an http.Handler; Chain applies a list of them so that
+// the first listed sees each request first. The shipped middlewares cover
+// what is needed to expose a node beyond localhost: ClientCertAuth maps
+// verified mTLS client certificates to agent identities, Quota limits each
+// agent's request rate, and Audit records every request with a canonical
+// request hash that identifies it independently of header order or JSON
+// formatting.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// Middleware wraps a handler with additional behavior
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with mws; mws[0] is outermost and runs first
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+type agentKey struct{}
+
+// WithAgent returns a context carrying an authenticated agent identity
+func WithAgent(ctx context.Context, agent string) context.Context {
+	return context.WithValue(ctx, agentKey{}, agent)
+}
+
+// Agent returns the authenticated agent of a request context
+func Agent(ctx context.Context) (string, bool) {
+	agent, ok := ctx.Value(agentKey{}).(string)
+	return agent, ok
+}
+
+// CertIdentity maps a verified client certificate to an agent identity
+type CertIdentity func(cert *x509.Certificate) (string, bool)
+
+// CertFingerprint returns the hex SHA-256 of a certificate's DER encoding
+func CertFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// FingerprintIdentity maps certificates by CertFingerprint to agents
+func FingerprintIdentity(agents map[string]string) CertIdentity {
+	return func(cert *x509.Certificate) (string, bool) {
+		agent, ok := agents[CertFingerprint(cert)]
+		return agent, ok
+	}
+}
+
+// ClientCertAuth authenticates requests by their mTLS client certificate. The
+// server's tls.Config must verify client certificates (ClientAuth set to
+// tls.RequireAndVerifyClientCert); only certificates with a verified chain are
+// considered. Requests without one get 401, and certificates identify does not
+// map get 403.
+func ClientCertAuth(identify CertIdentity) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				writeError(w, http.StatusUnauthorized, ocp.NewConstitutionalError("verified client certificate required"))
+				return
+			}
+			agent, ok := identify(r.TLS.VerifiedChains[0][0])
+			if !ok {
+				writeError(w, http.StatusForbidden, ocp.NewConstitutionalError("client certificate is not mapped to an agent"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithAgent(r.Context(), agent)))
+		})
+	}
+}
+
+// QuotaConfig configures per-agent request limits
+type QuotaConfig struct {
+	// RequestsPerMinute and Burst configure a token bucket per agent
+	RequestsPerMinute int
+	Burst             int
+	// Clock supplies refill times; defaults to ocp.SystemClock
+	Clock ocp.Clock
+}
+
+// Quota limits each authenticated agent's request rate, answering 429 with a
+// Retry-After header when an agent's bucket is empty. It must run after an
+// authentication middleware; unauthenticated requests share one bucket.
+func Quota(cfg QuotaConfig) Middleware {
+	var mu sync.Mutex
+	buckets := make(map[string]*bucket)
+	clock := cfg.Clock
+	if clock == nil {
+		clock = ocp.SystemClock
+	}
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			agent, _ := Agent(r.Context())
+			mu.Lock()
+			b, ok := buckets[agent]
+			if !ok {
+				b = &bucket{tokens: burst, refill: clock.Now()}
+				buckets[agent] = b
+			}
+			wait := b.take(clock.Now(), float64(cfg.RequestsPerMinute), burst)
+			mu.Unlock()
+
+			if wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, ocp.NewConstitutionalError("agent request quota exceeded"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bucket is a token bucket refilled continuously at a per-minute rate
+type bucket struct {
+	tokens float64
+	refill time.Time
+}
+
+// take consumes a token, returning zero, or the time until one is available
+func (b *bucket) take(now time.Time, perMinute, burst float64) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+	if elapsed := now.Sub(b.refill); elapsed > 0 {
+		b.tokens += elapsed.Minutes() * perMinute
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.refill = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / perMinute * float64(time.Minute))
+	}
+	b.tokens--
+	return 0
+}
+
+// AuditRecord describes one served request
+type AuditRecord struct {
+	Time        string `json:"time"`
+	Agent       string `json:"agent"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	RequestHash string `json:"request_hash"`
+	Status      int    `json:"status"`
+}
+
+// ToMap converts an AuditRecord to a map for canonicalization
+func (a AuditRecord) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"time":         a.Time,
+		"agent":        a.Agent,
+		"method":       a.Method,
+		"path":         a.Path,
+		"request_hash": a.RequestHash,
+		"status":       a.Status,
+	}
+}
+
+// CanonicalRequestHash identifies a request by its method, path, query, and
+// body. A JSON object body is hashed in canonical form, so reformatting it
+// does not change the hash; any other body is hashed by its bytes.
+func CanonicalRequestHash(method, path string, query map[string][]string, body []byte) (string, error) {
+	q := make(map[string]interface{}, len(query))
+	for k, values := range query {
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		list := make([]interface{}, len(sorted))
+		for i, v := range sorted {
+			list[i] = v
+		}
+		q[k] = list
+	}
+	req := map[string]interface{}{"method": method, "path": path, "query": q}
+	if len(body) > 0 {
+		if obj, err := ocp.DecodeStrict(body); err == nil {
+			req["body"] = obj
+		} else {
+			req["body_sha256"] = ocp.ContentHash(body)
+		}
+	}
+	return ocp.SemanticHash(req)
+}
+
+// Audit calls sink with an AuditRecord for every request after it is served.
+// It must run after an authentication middleware to record the agent.
+func Audit(sink func(AuditRecord), clock ocp.Clock) Middleware {
+	if clock == nil {
+		clock = ocp.SystemClock
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash, err := CanonicalRequestHash(r.Method, r.URL.Path, r.URL.Query(), body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := clock.Now()
+			next.ServeHTTP(rec, r)
+
+			agent, _ := Agent(r.Context())
+			sink(AuditRecord{
+				Time:        ocp.Timestamp(start),
+				Agent:       agent,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: hash,
+				Status:      rec.status,
+			})
+		})
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes flushes through, so audited routes can still stream
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
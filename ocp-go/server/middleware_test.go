@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// certRequest returns a request presenting a verified client certificate
+func certRequest(method, path, body string, raw []byte) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if raw != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Raw: raw}}}}
+	}
+	return req
+}
+
+// TestMiddlewareChain tests authentication, quotas, and auditing together
+func TestMiddlewareChain(t *testing.T) {
+	testenv.RequireSigning(t)
+	clock := ocp.NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	claude := CertFingerprint(&x509.Certificate{Raw: []byte("claude-cert")})
+	var audit []AuditRecord
+
+	handler := Chain(NewHandler(ocp.NewNode(ocp.NewLedger())),
+		ClientCertAuth(FingerprintIdentity(map[string]string{claude: "Claude"})),
+		Audit(func(r AuditRecord) { audit = append(audit, r) }, clock),
+		Quota(QuotaConfig{RequestsPerMinute: 1, Burst: 2, Clock: clock}),
+	)
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(certRequest("GET", "/v1/health", "", nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Requests without a client certificate should be 401, got %d", rec.Code)
+	}
+	if rec := serve(certRequest("GET", "/v1/health", "", []byte("mallory-cert"))); rec.Code != http.StatusForbidden {
+		t.Errorf("Unmapped certificates should be 403, got %d", rec.Code)
+	}
+
+	if rec := serve(certRequest("POST", "/v1/proposals", testProposalJSON, []byte("claude-cert"))); rec.Code != http.StatusOK {
+		t.Fatalf("Authenticated submission failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(certRequest("GET", "/v1/health", "", []byte("claude-cert"))); rec.Code != http.StatusOK {
+		t.Errorf("Second request within the burst should pass, got %d", rec.Code)
+	}
+	rec := serve(certRequest("GET", "/v1/health", "", []byte("claude-cert")))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Third request should be rate limited: %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	clock.Advance(time.Minute)
+	if rec := serve(certRequest("GET", "/v1/health", "", []byte("claude-cert"))); rec.Code != http.StatusOK {
+		t.Errorf("Quota should refill, got %d", rec.Code)
+	}
+
+	if len(audit) != 4 || audit[0].Agent != "Claude" || audit[0].Status != http.StatusOK || audit[2].Status != http.StatusTooManyRequests {
+		t.Fatalf("Unexpected audit log: %+v", audit)
+	}
+	reformatted := strings.Join(strings.Fields(testProposalJSON), " ")
+	hash, _ := CanonicalRequestHash("POST", "/v1/proposals", nil, []byte(reformatted))
+	if audit[0].RequestHash != hash {
+		t.Errorf("Request hash should not depend on body formatting")
+	}
+	t.Logf("✓ Audited %d requests", len(audit))
+}
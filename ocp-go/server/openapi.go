@@ -0,0 +1,292 @@
+// openapi.go - OpenAPI 3.1 description of the HTTP API
+//
+// OpenAPI builds a machine-readable description of the routes of NewHandler,
+// NewVerificationHandler, and NewPreflightHandler, their parameters, bodies, and error responses,
+// so client SDKs in other languages can be generated instead of written by
+// hand. NewHandler serves it at GET /v1/openapi.json.
+//
+// The protocol objects are described by the OCP JSON Schemas themselves,
+// taken from the schema package: OpenAPI 3.1 schema objects are JSON Schema,
+// so each is embedded as a component with only its draft-07 "definitions"
+// moved to "$defs" and its references adjusted to match. The JSON-RPC
+// endpoint is not described, since OpenAPI has no notion of method dispatch
+// within one route.
+
+package server
+
+import (
+	"sort"
+	"strings"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/schema"
+)
+
+// OpenAPIVersion is the OpenAPI version of the generated document
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIPath is the route at which NewHandler serves the document
+const OpenAPIPath = "/v1/openapi.json"
+
+// OpenAPI returns the OpenAPI document describing the HTTP API
+func OpenAPI() (map[string]interface{}, error) {
+	schemas := map[string]interface{}{
+		"Acceptance": object(map[string]interface{}{
+			"proposal_hash": str("Semantic hash of the accepted proposal"),
+			"ledger_height": integer("Height of the ledger entry recording the proposal", 1),
+			"entry_hash":    str("Hash of that ledger entry"),
+			"accepted_at":   str("Acceptance time, RFC 3339"),
+		}),
+		"Health": object(map[string]interface{}{
+			"height": integer("Ledger height", 0),
+			"head":   str("Hash of the latest ledger entry, empty for an empty ledger"),
+		}),
+		"LedgerEntry": object(map[string]interface{}{
+			"height":    integer("Entry height, starting at 1", 1),
+			"prev_hash": str("Hash of the previous entry"),
+			"kind":      str("Entry kind, such as proposal or policy"),
+			"payload":   map[string]interface{}{"type": "object", "description": "Kind-specific content"},
+			"hash":      str("Semantic hash of the entry without this member"),
+		}),
+		"LedgerPage": object(map[string]interface{}{
+			"entries": map[string]interface{}{"type": "array", "items": componentRef("LedgerEntry")},
+			"height":  integer("Ledger height", 0),
+			"more":    map[string]interface{}{"type": "boolean", "description": "Whether entries follow this page"},
+		}),
+		"VerificationJob": map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"id", "status", "submitted_at"},
+			"properties": map[string]interface{}{
+				"id":           str("Job identifier"),
+				"status":       map[string]interface{}{"type": "string", "enum": []interface{}{"queued", "running", "done", "failed"}},
+				"submitted_at": str("Submission time, RFC 3339"),
+				"started_at":   str("Start time, RFC 3339"),
+				"finished_at":  str("Completion time, RFC 3339"),
+				"result":       map[string]interface{}{"type": "object", "description": "The verification report, once done"},
+				"error":        str("Why the job failed"),
+			},
+		},
+		"PreflightReport": object(map[string]interface{}{
+			"proposal_hash": str("Semantic hash of the draft"),
+			"checks":        map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}, "description": "Schema, policy, admission, stake, and conflicts results, in that order"},
+			"stake": object(map[string]interface{}{
+				"offered":        integer("Stake behind the draft, sponsors' aggregate included", 0),
+				"required":       integer("Least total stake the sponsorship policy accepts", 0),
+				"min_sponsors":   integer("Least number of co-sponsors the policy accepts", 0),
+				"challenge_bond": integer("Bond a first-time challenger must lock", 0),
+			}),
+			"conflicts": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Hashes of pending proposals the draft conflicts with"},
+			"ok":        map[string]interface{}{"type": "boolean", "description": "Whether no check failed"},
+		}),
+		"Error": object(map[string]interface{}{
+			"error": str("Description of the failure"),
+		}),
+	}
+
+	compiled := schema.Schemas()
+	files := make([]string, 0, len(compiled))
+	for file := range compiled {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		doc, err := compiled[file].Document()
+		if err != nil {
+			return nil, err
+		}
+		schemas[compiled[file].Name] = openAPISchema(compiled[file].Name, doc)
+	}
+
+	hashParam := func(name, description string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "in": "path", "required": true, "description": description, "schema": map[string]interface{}{"type": "string"}}
+	}
+	proposalBody := map[string]interface{}{
+		"required": true,
+		"content":  jsonContent(componentRef("Contract")),
+	}
+
+	paths := map[string]interface{}{
+		"/v1/proposals": map[string]interface{}{
+			"post": operation("submitProposal", "Submit a contract proposal", proposalBody, nil, map[string]interface{}{
+				"200": response("The proposal's acceptance; resubmissions return the original", "Acceptance"),
+				"400": errorResponse("The body is not a proposal"),
+				"403": errorResponse("The authenticated agent is not the proposer"),
+				"422": errorResponse("The node refused the proposal"),
+				"503": errorResponse("An emergency halt is in force"),
+			}),
+		},
+		"/v1/proposals/{hash}": map[string]interface{}{
+			"get": operation("getAcceptance", "The acceptance of an accepted proposal", nil,
+				[]interface{}{hashParam("hash", "Semantic hash of the proposal")},
+				map[string]interface{}{
+					"200": response("The proposal's acceptance", "Acceptance"),
+					"404": errorResponse("The proposal was not accepted"),
+				}),
+		},
+		"/v1/health": map[string]interface{}{
+			"get": operation("getHealth", "Ledger height and head", nil, nil, map[string]interface{}{
+				"200": response("The node's ledger position", "Health"),
+			}),
+		},
+		"/v1/ledger": map[string]interface{}{
+			"get": operation("getLedger", "Ledger entries in height order", nil, []interface{}{
+				map[string]interface{}{"name": "after", "in": "query", "description": "Return entries above this height", "schema": map[string]interface{}{"type": "integer", "minimum": 0}},
+				map[string]interface{}{"name": "limit", "in": "query", "description": "Return at most this many entries; larger values are capped at the page size", "schema": map[string]interface{}{"type": "integer", "minimum": 1, "default": MaxLedgerPage}},
+			}, map[string]interface{}{
+				"200": response("A page of entries", "LedgerPage"),
+				"400": errorResponse("after or limit is malformed"),
+			}),
+		},
+		WatchPath: map[string]interface{}{
+			"get": operation("watchLedger", "Stream ledger entries as they are appended", nil, []interface{}{
+				map[string]interface{}{"name": "from", "in": "query", "description": "Stream entries above this height", "schema": map[string]interface{}{"type": "integer", "minimum": 0}},
+			}, map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Newline-delimited canonical ledger entries, each with its hash, held open for new entries",
+					"content": map[string]interface{}{
+						"application/x-ndjson": map[string]interface{}{"schema": componentRef("LedgerEntry")},
+					},
+				},
+				"400": errorResponse("from is malformed"),
+				"410": errorResponse("The ledger no longer holds the entries above from"),
+			}),
+		},
+		"/v1/verifications": map[string]interface{}{
+			"post": operation("queueVerification", "Queue a proposal's full verification", proposalBody, nil, map[string]interface{}{
+				"200": response("The proposal was already verified", "VerificationJob"),
+				"202": withHeaders(response("The verification was queued", "VerificationJob"),
+					"Location", "URL of the job"),
+				"400": errorResponse("The body is not a proposal"),
+				"503": withHeaders(errorResponse("The queue is full or closed"),
+					"Retry-After", "Seconds to wait before retrying"),
+			}),
+		},
+		"/v1/verifications/{id}": map[string]interface{}{
+			"get": operation("getVerification", "A verification job's status and report", nil,
+				[]interface{}{hashParam("id", "Job identifier")},
+				map[string]interface{}{
+					"200": response("The job", "VerificationJob"),
+					"404": errorResponse("Unknown job"),
+				}),
+		},
+		PreflightPath: map[string]interface{}{
+			"post": operation("evaluateDraft", "Evaluate a draft proposal without submitting it", proposalBody, nil, map[string]interface{}{
+				"200": response("What a submission of the draft would meet", "PreflightReport"),
+				"400": errorResponse("The body is not a proposal"),
+			}),
+		},
+		OpenAPIPath: map[string]interface{}{
+			"get": operation("getOpenAPI", "This document", nil, nil, map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "The OpenAPI document",
+					"content":     jsonContent(map[string]interface{}{"type": "object"}),
+				},
+			}),
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "OCP node API",
+			"version": ocp.Version,
+			"description": "Submission, ledger, and verification API of an Optimistic Constitutional Protocol node. " +
+				"Responses are canonical JSON. Deployments may add client certificate authentication (401, 403) " +
+				"and per-agent quotas (429) to every route.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"responses": map[string]interface{}{
+				"Unauthorized":    errorResponse("A verified client certificate is required"),
+				"TooManyRequests": errorResponse("The agent's request quota is exhausted"),
+			},
+		},
+	}, nil
+}
+
+// openAPISchema adapts a draft-07 JSON Schema to an OpenAPI 3.1 component
+// named name: the dialect and id are dropped, and definitions move to $defs
+// with references rewritten to their new location
+func openAPISchema(name string, doc map[string]interface{}) map[string]interface{} {
+	delete(doc, "$schema")
+	delete(doc, "$id")
+	if defs, ok := doc["definitions"]; ok {
+		delete(doc, "definitions")
+		doc["$defs"] = defs
+	}
+	var rewrite func(v interface{})
+	rewrite = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+				v["$ref"] = "#/components/schemas/" + name + "/$defs/" + strings.TrimPrefix(ref, "#/definitions/")
+			}
+			for _, child := range v {
+				rewrite(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				rewrite(child)
+			}
+		}
+	}
+	rewrite(doc)
+	return doc
+}
+
+// operation describes one route
+func operation(id, summary string, body map[string]interface{}, params []interface{}, responses map[string]interface{}) map[string]interface{} {
+	op := map[string]interface{}{"operationId": id, "summary": summary, "responses": responses}
+	if body != nil {
+		op["requestBody"] = body
+	}
+	if params != nil {
+		op["parameters"] = params
+	}
+	return op
+}
+
+// response describes a JSON response holding the named component
+func response(description, component string) map[string]interface{} {
+	return map[string]interface{}{"description": description, "content": jsonContent(componentRef(component))}
+}
+
+// errorResponse describes an error response
+func errorResponse(description string) map[string]interface{} {
+	return response(description, "Error")
+}
+
+// withHeaders adds a string header to a response
+func withHeaders(resp map[string]interface{}, name, description string) map[string]interface{} {
+	resp["headers"] = map[string]interface{}{
+		name: map[string]interface{}{"description": description, "schema": map[string]interface{}{"type": "string"}},
+	}
+	return resp
+}
+
+func jsonContent(s map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": s}}
+}
+
+func componentRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// object describes an object whose properties are all required
+func object(properties map[string]interface{}) map[string]interface{} {
+	required := make([]interface{}, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Slice(required, func(i, j int) bool { return required[i].(string) < required[j].(string) })
+	return map[string]interface{}{"type": "object", "required": required, "properties": properties}
+}
+
+func str(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func integer(description string, minimum int) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": minimum, "description": description}
+}
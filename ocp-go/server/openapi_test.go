@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/jobs"
+	"github.com/seanrugg/ai_constitution/ocp-go/schema"
+)
+
+// TestOpenAPIRoutes tests that the served document describes exactly the
+// routes the handlers serve
+func TestOpenAPIRoutes(t *testing.T) {
+	handler := NewHandler(ocp.NewNode(ocp.NewLedger()))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", OpenAPIPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Document is not JSON: %v", err)
+	}
+	if doc["openapi"] != OpenAPIVersion {
+		t.Errorf("Expected openapi %s, got %v", OpenAPIVersion, doc["openapi"])
+	}
+
+	queue := jobs.NewQueue(jobs.Config{})
+	defer queue.Close()
+	muxes := []*http.ServeMux{
+		handler.(*http.ServeMux),
+		NewVerificationHandler(ocp.NewVerifierOnly(), queue).(*http.ServeMux),
+		NewPreflightHandler(&ocp.Preflight{}).(*http.ServeMux),
+	}
+	described := 0
+	for path, item := range doc["paths"].(map[string]interface{}) {
+		concrete := strings.NewReplacer("{hash}", "abc", "{id}", "job-1").Replace(path)
+		for method := range item.(map[string]interface{}) {
+			req := httptest.NewRequest(strings.ToUpper(method), concrete, nil)
+			served := false
+			for _, mux := range muxes {
+				if _, pattern := mux.Handler(req); pattern != "" {
+					served = true
+				}
+			}
+			if !served {
+				t.Errorf("%s %s is described but not served", strings.ToUpper(method), path)
+			}
+			described++
+		}
+	}
+	if described != 9 {
+		t.Errorf("Expected 9 operations, got %d", described)
+	}
+	t.Logf("✓ %d operations described and served", described)
+}
+
+// TestOpenAPISchemas tests that components carry the OCP schemas and that
+// every reference resolves
+func TestOpenAPISchemas(t *testing.T) {
+	doc, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	// Round-trip so the document is inspected as a client would see it
+	form, err := ocp.Canonicalize(doc, true)
+	if err != nil {
+		t.Fatalf("Canonicalize failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(form), &decoded); err != nil {
+		t.Fatalf("Document is not JSON: %v", err)
+	}
+	components := decoded["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	for _, compiled := range schema.Schemas() {
+		source, err := compiled.Document()
+		if err != nil {
+			t.Fatalf("Document failed: %v", err)
+		}
+		component, ok := components[compiled.Name].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Missing component %s", compiled.Name)
+		}
+		if _, ok := component["$schema"]; ok {
+			t.Errorf("%s keeps its draft-07 $schema", compiled.Name)
+		}
+		if !reflect.DeepEqual(component["properties"], source["properties"]) {
+			t.Errorf("%s properties differ from the schema", compiled.Name)
+		}
+		if _, ok := source["definitions"]; ok && !reflect.DeepEqual(component["$defs"], source["definitions"]) {
+			t.Errorf("%s definitions were not moved to $defs", compiled.Name)
+		}
+	}
+
+	var refs int
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				refs++
+				var target interface{} = decoded
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]interface{})
+					target = m[part]
+				}
+				if target == nil {
+					t.Errorf("Reference %s does not resolve", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(decoded)
+	t.Logf("✓ %d schemas embedded, %d references resolve", len(schema.Schemas()), refs)
+}
+
+// TestOpenAPISchemaRefs tests rewriting draft-07 definition references
+func TestOpenAPISchemaRefs(t *testing.T) {
+	doc := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"definitions": map[string]interface{}{"uuid": map[string]interface{}{"type": "string"}},
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"$ref": "#/definitions/uuid"},
+		},
+	}
+	out := openAPISchema("Thing", doc)
+	ref := out["properties"].(map[string]interface{})["id"].(map[string]interface{})["$ref"]
+	if ref != "#/components/schemas/Thing/$defs/uuid" {
+		t.Errorf("Unexpected reference %v", ref)
+	}
+	if _, ok := out["definitions"]; ok {
+		t.Error("definitions should move to $defs")
+	}
+	t.Logf("✓ %v", ref)
+}
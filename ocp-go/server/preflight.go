@@ -0,0 +1,42 @@
+// preflight.go - Dry-run evaluation API for proposal authors
+//
+// Authors post a draft proposal and receive the PreflightReport of what a
+// submission would meet, without anything being recorded or staked. The
+// evaluation is synchronous: unlike full verification it checks no
+// signatures or ledger history, so it answers within the request.
+
+package server
+
+import (
+	"io"
+	"net/http"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// PreflightPath is the route at which NewPreflightHandler evaluates drafts
+const PreflightPath = "/v1/preflight"
+
+// NewPreflightHandler returns the dry-run evaluation API. Routes:
+//
+//	POST /v1/preflight  evaluate a draft proposal; 200 with its PreflightReport and "ok"
+func NewPreflightHandler(pf *ocp.Preflight) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+PreflightPath, func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		draft, err := decodeProposal(data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		report := pf.EvaluateDraft(draft)
+		body := report.ToMap()
+		body["ok"] = report.OK()
+		writeJSON(w, http.StatusOK, body)
+	})
+	return mux
+}
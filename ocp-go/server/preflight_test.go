@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// TestPreflightHandler tests evaluating a draft without submitting it
+func TestPreflightHandler(t *testing.T) {
+	node := ocp.NewNode(ocp.NewLedger())
+	handler := NewPreflightHandler(&ocp.Preflight{Node: node})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", PreflightPath, strings.NewReader(testProposalJSON)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Response is not JSON: %v", err)
+	}
+	if report["ok"] != false || !strings.Contains(rec.Body.String(), ocp.CodeSchemaInvalidValue) {
+		t.Errorf("Expected the incomplete draft to fail its schema check, got %s", rec.Body.String())
+	}
+	if node.Ledger().Height() != 0 {
+		t.Error("Evaluating a draft must record nothing")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", PreflightPath, strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed draft, got %d", rec.Code)
+	}
+	for name, body := range rejectedProposals {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", PreflightPath, strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for a draft with %s, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	t.Logf("✓ Draft %v evaluated, nothing recorded", report["proposal_hash"])
+}
@@ -0,0 +1,316 @@
+// rpc.go - JSON-RPC 2.0 interface to a node
+//
+// Agent frameworks and wallets that already speak JSON-RPC reach the node
+// through a single endpoint instead of an adapter for the REST routes.
+// NewRPCHandler implements JSON-RPC 2.0 over HTTP POST: single calls, batches,
+// and notifications (calls without an id, which get no response). Params may
+// be given by name or by position. The methods mirror the REST routes:
+//
+//	ocp_submitProposal  [proposal] or {"proposal": ...}  the proposal's Acceptance
+//	ocp_getAcceptance   [hash] or {"hash": ...}          the Acceptance of an accepted proposal
+//	ocp_health          none                             ledger height and head
+//	ocp_getLedger       [after, limit] or {"after", "limit"}  ledger entries, as GET /v1/ledger
+//
+// Failures use the standard codes, and node errors use codes in the range
+// JSON-RPC reserves for servers, with the ConstitutionalError type in the
+// error's data. Deployments wrap the handler with the same middleware as
+// NewHandler; an authenticated agent may only submit its own proposals.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	// Standard codes
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+
+	// RPCRejected is returned when the node refuses a proposal
+	RPCRejected = -32000
+	// RPCHalted is returned while an emergency halt is in force
+	RPCHalted = -32001
+	// RPCNotFound is returned for an unknown proposal
+	RPCNotFound = -32002
+	// RPCForbidden is returned when an agent submits for another agent
+	RPCForbidden = -32003
+)
+
+// MaxRPCBatch bounds the calls in one batch request
+const MaxRPCBatch = 100
+
+// RPCError is a JSON-RPC error object
+type RPCError struct {
+	Code    int
+	Message string
+	// ErrorType is the ConstitutionalError type behind a node error, if any
+	ErrorType string
+}
+
+// Error implements error
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// ToMap converts an RPCError to its JSON-RPC form
+func (e *RPCError) ToMap() map[string]interface{} {
+	m := map[string]interface{}{"code": e.Code, "message": e.Message}
+	if e.ErrorType != "" {
+		m["data"] = map[string]interface{}{"error_type": e.ErrorType}
+	}
+	return m
+}
+
+// rpcMethod runs one method with its raw params
+type rpcMethod func(ctx context.Context, params json.RawMessage) (interface{}, *RPCError)
+
+// rpcCall is one decoded request object
+type rpcCall struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// NewRPCHandler returns the JSON-RPC 2.0 interface of node
+func NewRPCHandler(node *ocp.Node) http.Handler {
+	methods := map[string]rpcMethod{
+		"ocp_submitProposal": func(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+			// The proposal is decoded from its raw bytes as POST /v1/proposals
+			// does, so both interfaces refuse the same bodies
+			var raw json.RawMessage
+			if err := rpcParams(params, []string{"proposal"}, &raw); err != nil {
+				return nil, err
+			}
+			p, err := decodeProposal(raw)
+			if err != nil {
+				return nil, &RPCError{Code: RPCInvalidParams, Message: "param proposal: " + err.Error()}
+			}
+			if agent, ok := Agent(ctx); ok && agent != p.ProposerAgent {
+				return nil, &RPCError{Code: RPCForbidden, Message: "authenticated agent " + agent + " cannot submit for " + p.ProposerAgent}
+			}
+			acceptance, err := node.Submit(p)
+			if errors.Is(err, ocp.ErrHalted) {
+				return nil, nodeError(RPCHalted, err)
+			}
+			if err != nil {
+				return nil, nodeError(RPCRejected, err)
+			}
+			return acceptance.ToMap(), nil
+		},
+		"ocp_getAcceptance": func(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+			var hash string
+			if err := rpcParams(params, []string{"hash"}, &hash); err != nil {
+				return nil, err
+			}
+			acceptance, ok := node.Accepted(hash)
+			if !ok {
+				return nil, &RPCError{Code: RPCNotFound, Message: "proposal not accepted"}
+			}
+			return acceptance.ToMap(), nil
+		},
+		"ocp_health": func(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+			ledger := node.Ledger()
+			return map[string]interface{}{"height": ledger.Height(), "head": ledger.Head()}, nil
+		},
+		"ocp_getLedger": func(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+			var after uint64
+			limit := MaxLedgerPage
+			if err := rpcParams(params, []string{"after", "limit"}, &after, &limit); err != nil {
+				return nil, err
+			}
+			if limit < 1 {
+				return nil, &RPCError{Code: RPCInvalidParams, Message: "limit must be a positive integer"}
+			}
+			if limit > MaxLedgerPage {
+				limit = MaxLedgerPage
+			}
+			ledger := node.Ledger()
+			entries := ledger.Entries(after)
+			more := len(entries) > limit
+			if more {
+				entries = entries[:limit]
+			}
+			page := make([]interface{}, len(entries))
+			for i := range entries {
+				m := entries[i].ToMap()
+				m["hash"] = entries[i].Hash
+				page[i] = m
+			}
+			return map[string]interface{}{"entries": page, "height": ledger.Height(), "more": more}, nil
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, ocp.NewConstitutionalError("JSON-RPC requests must be POSTed"))
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes))
+		if err != nil {
+			writeRPC(w, rpcResponse(nil, nil, &RPCError{Code: RPCParseError, Message: err.Error()}))
+			return
+		}
+		data = bytes.TrimSpace(data)
+
+		// A batch is answered with an array holding a response per call that
+		// is not a notification, or with no body at all if every call was one
+		if len(data) > 0 && data[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(data, &batch); err != nil {
+				writeRPC(w, rpcResponse(nil, nil, &RPCError{Code: RPCParseError, Message: err.Error()}))
+				return
+			}
+			if len(batch) == 0 || len(batch) > MaxRPCBatch {
+				message := fmt.Sprintf("a batch must hold 1 to %d calls", MaxRPCBatch)
+				writeRPC(w, rpcResponse(nil, nil, &RPCError{Code: RPCInvalidRequest, Message: message}))
+				return
+			}
+			var responses []interface{}
+			for _, raw := range batch {
+				if response := handleRPC(r.Context(), methods, raw); response != nil {
+					responses = append(responses, response)
+				}
+			}
+			if len(responses) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			writeRPC(w, responses)
+			return
+		}
+
+		if !json.Valid(data) {
+			writeRPC(w, rpcResponse(nil, nil, &RPCError{Code: RPCParseError, Message: "request is not valid JSON"}))
+			return
+		}
+		response := handleRPC(r.Context(), methods, data)
+		if response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeRPC(w, response)
+	})
+}
+
+// handleRPC runs one call, returning nil for a notification
+func handleRPC(ctx context.Context, methods map[string]rpcMethod, raw json.RawMessage) map[string]interface{} {
+	var call rpcCall
+	if err := json.Unmarshal(raw, &call); err != nil || call.JSONRPC != "2.0" || call.Method == "" {
+		return rpcResponse(nil, nil, &RPCError{Code: RPCInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+	}
+	var id interface{}
+	if len(call.ID) > 0 {
+		if err := json.Unmarshal(call.ID, &id); err != nil {
+			return rpcResponse(nil, nil, &RPCError{Code: RPCInvalidRequest, Message: "id must be a string, number, or null"})
+		}
+		switch id.(type) {
+		case string, float64, nil:
+		default:
+			return rpcResponse(nil, nil, &RPCError{Code: RPCInvalidRequest, Message: "id must be a string, number, or null"})
+		}
+	}
+
+	method, ok := methods[call.Method]
+	var result interface{}
+	var rpcErr *RPCError
+	if !ok {
+		rpcErr = &RPCError{Code: RPCMethodNotFound, Message: "unknown method " + call.Method}
+	} else {
+		result, rpcErr = method(ctx, call.Params)
+	}
+	if len(call.ID) == 0 {
+		return nil
+	}
+	return rpcResponse(id, result, rpcErr)
+}
+
+// rpcParams decodes params given by position or by the names in names into
+// targets, leaving targets whose param is absent unchanged
+func rpcParams(params json.RawMessage, names []string, targets ...interface{}) *RPCError {
+	invalid := func(err error) *RPCError {
+		return &RPCError{Code: RPCInvalidParams, Message: err.Error()}
+	}
+	trimmed := bytes.TrimSpace(params)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	values := make([]json.RawMessage, len(names))
+	switch trimmed[0] {
+	case '[':
+		var list []json.RawMessage
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return invalid(err)
+		}
+		if len(list) > len(names) {
+			return invalid(fmt.Errorf("expected at most %d params, got %d", len(names), len(list)))
+		}
+		copy(values, list)
+	case '{':
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &named); err != nil {
+			return invalid(err)
+		}
+		for i, name := range names {
+			values[i] = named[name]
+		}
+	default:
+		return invalid(errors.New("params must be an array or object"))
+	}
+	for i, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(value, targets[i]); err != nil {
+			return invalid(fmt.Errorf("param %s: %v", names[i], err))
+		}
+	}
+	return nil
+}
+
+// nodeError converts an error from the node to an RPCError with code
+func nodeError(code int, err error) *RPCError {
+	e := &RPCError{Code: code, Message: err.Error()}
+	var ce *ocp.ConstitutionalError
+	if errors.As(err, &ce) {
+		e.ErrorType = ce.ErrorType
+	}
+	return e
+}
+
+// rpcResponse builds a response object carrying either result or rpcErr
+func rpcResponse(id, result interface{}, rpcErr *RPCError) map[string]interface{} {
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if rpcErr != nil {
+		response["error"] = rpcErr.ToMap()
+	} else {
+		response["result"] = result
+	}
+	return response
+}
+
+// writeRPC writes a response or batch of responses in canonical form. Errors
+// are reported in the body, so the status is always 200.
+func writeRPC(w http.ResponseWriter, v interface{}) {
+	form, err := ocp.CanonicalizeValue(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, form)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// rpcPost posts body to handler and decodes the response
+func rpcPost(t *testing.T, handler http.Handler, body string) (int, interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/rpc", strings.NewReader(body)))
+	if rec.Body.Len() == 0 {
+		return rec.Code, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("Response is not JSON: %d %s", rec.Code, rec.Body.String())
+	}
+	return rec.Code, v
+}
+
+// rpcCode returns the error code of a response, or 0
+func rpcCode(response interface{}) int {
+	m, _ := response.(map[string]interface{})
+	e, _ := m["error"].(map[string]interface{})
+	code, _ := e["code"].(float64)
+	return int(code)
+}
+
+// TestRPCHandler tests single calls with named and positional params
+func TestRPCHandler(t *testing.T) {
+	testenv.RequireSigning(t)
+	handler := NewRPCHandler(ocp.NewNode(ocp.NewLedger()))
+
+	_, response := rpcPost(t, handler, `{"jsonrpc": "2.0", "id": 1, "method": "ocp_submitProposal", "params": {"proposal": `+testProposalJSON+`}}`)
+	m := response.(map[string]interface{})
+	result, ok := m["result"].(map[string]interface{})
+	if !ok || m["id"] != float64(1) || result["ledger_height"] != float64(1) {
+		t.Fatalf("Unexpected submit response %v", response)
+	}
+	hash := result["proposal_hash"].(string)
+
+	_, response = rpcPost(t, handler, `{"jsonrpc": "2.0", "id": "a", "method": "ocp_getAcceptance", "params": ["`+hash+`"]}`)
+	if m := response.(map[string]interface{}); m["id"] != "a" || m["result"].(map[string]interface{})["proposal_hash"] != hash {
+		t.Errorf("Unexpected lookup response %v", response)
+	}
+	_, response = rpcPost(t, handler, `{"jsonrpc": "2.0", "id": 2, "method": "ocp_getLedger", "params": {"after": 0, "limit": 1}}`)
+	if page := response.(map[string]interface{})["result"].(map[string]interface{}); len(page["entries"].([]interface{})) != 1 || page["more"] != false {
+		t.Errorf("Unexpected ledger response %v", response)
+	}
+
+	// Submitting for another agent is refused
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/rpc", strings.NewReader(`{"jsonrpc": "2.0", "id": 3, "method": "ocp_submitProposal", "params": [`+testProposalJSON+`]}`))
+	handler.ServeHTTP(rec, req.WithContext(WithAgent(req.Context(), "Gemini")))
+	var forbidden interface{}
+	json.Unmarshal(rec.Body.Bytes(), &forbidden)
+	if rpcCode(forbidden) != RPCForbidden {
+		t.Errorf("Expected RPCForbidden, got %v", forbidden)
+	}
+
+	t.Logf("✓ Accepted %s over JSON-RPC", hash)
+}
+
+// TestRPCErrors tests the standard error codes
+func TestRPCErrors(t *testing.T) {
+	handler := NewRPCHandler(ocp.NewNode(ocp.NewLedger()))
+	cases := map[string]int{
+		`{"jsonrpc": "2.0", "id": 1, "method"`:                                        RPCParseError,
+		`{"jsonrpc": "1.0", "id": 1, "method": "ocp_health"}`:                         RPCInvalidRequest,
+		`{"jsonrpc": "2.0", "id": {}, "method": "ocp_health"}`:                        RPCInvalidRequest,
+		`{"jsonrpc": "2.0", "id": 1, "method": "ocp_mine"}`:                           RPCMethodNotFound,
+		`{"jsonrpc": "2.0", "id": 1, "method": "ocp_getLedger", "params": ["x"]}`:     RPCInvalidParams,
+		`{"jsonrpc": "2.0", "id": 1, "method": "ocp_getLedger", "params": [0, 1, 2]}`: RPCInvalidParams,
+		`{"jsonrpc": "2.0", "id": 1, "method": "ocp_getAcceptance", "params": ["x"]}`: RPCNotFound,
+		`[]`: RPCInvalidRequest,
+	}
+	for body, want := range cases {
+		if code, response := rpcPost(t, handler, body); code != http.StatusOK || rpcCode(response) != want {
+			t.Errorf("%s: expected code %d, got %d %v", body, want, code, response)
+		}
+	}
+
+	for name, body := range rejectedProposals {
+		_, response := rpcPost(t, handler, `{"jsonrpc": "2.0", "id": 1, "method": "ocp_submitProposal", "params": [`+body+`]}`)
+		if rpcCode(response) != RPCInvalidParams {
+			t.Errorf("Expected RPCInvalidParams for a proposal with %s, got %v", name, response)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/rpc", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET to be refused, got %d", rec.Code)
+	}
+
+	t.Logf("✓ %d error cases use the standard codes", len(cases))
+}
+
+// TestRPCBatch tests batches and notifications
+func TestRPCBatch(t *testing.T) {
+	testenv.RequireSigning(t)
+	handler := NewRPCHandler(ocp.NewNode(ocp.NewLedger()))
+	code, response := rpcPost(t, handler, `[
+		{"jsonrpc": "2.0", "method": "ocp_submitProposal", "params": [`+testProposalJSON+`]},
+		{"jsonrpc": "2.0", "id": 1, "method": "ocp_health"},
+		{"jsonrpc": "2.0", "id": 2, "method": "ocp_unknown"},
+		7
+	]`)
+	responses, ok := response.([]interface{})
+	if code != http.StatusOK || !ok || len(responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d %v", code, response)
+	}
+	// The notification ran, so health sees its entry
+	if health := responses[0].(map[string]interface{})["result"].(map[string]interface{}); health["height"] != float64(1) {
+		t.Errorf("Unexpected health %v", health)
+	}
+	if rpcCode(responses[1]) != RPCMethodNotFound || rpcCode(responses[2]) != RPCInvalidRequest {
+		t.Errorf("Unexpected errors %v", responses[1:])
+	}
+
+	if code, response := rpcPost(t, handler, `[{"jsonrpc": "2.0", "method": "ocp_health"}]`); code != http.StatusNoContent || response != nil {
+		t.Errorf("Expected an all-notification batch to get no body, got %d %v", code, response)
+	}
+
+	t.Log("✓ Batch answered per call, notifications silently")
+}
@@ -0,0 +1,191 @@
+// Package server exposes an OCP node over HTTP.
+//
+// NewHandler serves the node's submission API. It carries no authentication
+// or limits of its own: deployments wrap it with Chain and the middlewares in
+// middleware.go (client certificate authentication, per-agent quotas, audit
+// logging) before listening on anything but localhost.
+//
+// Routes:
+//
+//	POST /v1/proposals         submit a contract proposal, returns its Acceptance
+//	GET  /v1/proposals/{hash}  the Acceptance of an accepted proposal
+//	GET  /v1/health            ledger height and head
+//	GET  /v1/ledger            ledger entries after ?after=HEIGHT, at most ?limit=N
+//	GET  /v1/ledger/watch      stream entries after ?from=HEIGHT as they are appended; see watch.go
+//	GET  /v1/openapi.json      the OpenAPI document of this API; see openapi.go
+//
+// NewVerificationHandler serves asynchronous verification backed by a
+// jobs.Queue; see verify.go. NewPreflightHandler evaluates draft proposals
+// without submitting them; see preflight.go. NewRPCHandler serves the same node operations
+// over JSON-RPC 2.0; see rpc.go.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// MaxRequestBytes bounds request bodies read by the handler and middlewares
+const MaxRequestBytes = 1 << 20
+
+// MaxLedgerPage bounds the entries returned by one GET /v1/ledger
+const MaxLedgerPage = 500
+
+// NewHandler returns the HTTP API of node
+func NewHandler(node *ocp.Node) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/proposals", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		p, err := decodeProposal(data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if agent, ok := Agent(r.Context()); ok && agent != p.ProposerAgent {
+			writeError(w, http.StatusForbidden, errors.New("authenticated agent "+agent+" cannot submit for "+p.ProposerAgent))
+			return
+		}
+		acceptance, err := node.Submit(p)
+		if errors.Is(err, ocp.ErrHalted) {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, acceptance.ToMap())
+	})
+	mux.HandleFunc("GET /v1/proposals/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		acceptance, ok := node.Accepted(r.PathValue("hash"))
+		if !ok {
+			writeError(w, http.StatusNotFound, ocp.NewConstitutionalError("proposal not accepted"))
+			return
+		}
+		writeJSON(w, http.StatusOK, acceptance.ToMap())
+	})
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		ledger := node.Ledger()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"height": ledger.Height(), "head": ledger.Head()})
+	})
+	mux.HandleFunc("GET /v1/ledger", func(w http.ResponseWriter, r *http.Request) {
+		after, limit, err := ledgerPage(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		ledger := node.Ledger()
+		entries := ledger.Entries(after)
+		more := len(entries) > limit
+		if more {
+			entries = entries[:limit]
+		}
+		page := make([]interface{}, len(entries))
+		for i := range entries {
+			m := entries[i].ToMap()
+			m["hash"] = entries[i].Hash
+			page[i] = m
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"entries": page, "height": ledger.Height(), "more": more})
+	})
+	mux.HandleFunc("GET "+WatchPath, watchLedger(node))
+	mux.HandleFunc("GET "+OpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		doc, err := OpenAPI()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, doc)
+	})
+	return mux
+}
+
+// ledgerPage reads the after and limit query parameters
+func ledgerPage(r *http.Request) (uint64, int, error) {
+	var after uint64
+	limit := MaxLedgerPage
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, 0, ocp.NewConstitutionalError("after must be a ledger height")
+		}
+		after = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, 0, ocp.NewConstitutionalError("limit must be a positive integer")
+		}
+		if n < limit {
+			limit = n
+		}
+	}
+	return after, limit, nil
+}
+
+// decodeProposal parses a proposal from a request body, refusing bodies that
+// ocp.DecodeStrict rejects: with duplicate keys, a body could hash one way
+// here and read another way in a different JSON parser. json.Unmarshal
+// matches keys case-insensitively, so "proposer_agent" and "PROPOSER_AGENT"
+// are duplicates DecodeStrict cannot see; every key must be a proposal field
+// spelled exactly.
+func decodeProposal(data []byte) (*ocp.ContractProposal, error) {
+	obj, err := ocp.DecodeStrict(data)
+	if err != nil {
+		return nil, err
+	}
+	for k := range obj {
+		if !proposalFields[k] {
+			return nil, ocp.NewConstitutionalError(fmt.Sprintf("unknown proposal field %q", k))
+		}
+	}
+	var p ocp.ContractProposal
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// proposalFields holds the exact JSON names of ContractProposal's fields
+var proposalFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(ocp.ContractProposal{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// writeJSON writes v in canonical form
+func writeJSON(w http.ResponseWriter, status int, v map[string]interface{}) {
+	form, err := ocp.Canonicalize(v, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, form)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]interface{}{"error": err.Error()})
+}
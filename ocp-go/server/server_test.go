@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+const testProposalJSON = `{
+	"id": "550e8400-e29b-41d4-a716-446655440000",
+	"proposer_agent": "Claude",
+	"action_type": "amend",
+	"action": {"target": "amendment-article-3", "operation": "modify"},
+	"evidence": [],
+	"reasoning": {"rationale": "Clarifies Article III.1", "confidence": 0.87},
+	"timestamp": "2025-11-20T14:30:00Z"
+}`
+
+// duplicateKeyProposalJSON names two proposers, which json.Unmarshal would
+// resolve to the last one
+var duplicateKeyProposalJSON = strings.Replace(testProposalJSON, `"proposer_agent": "Claude",`,
+	`"proposer_agent": "Claude", "proposer_agent": "Mallory",`, 1)
+
+// rejectedProposals are bodies every proposal route must refuse with 400.
+// json.Unmarshal would fold the case-variant key onto proposer_agent and
+// silently drop the unknown field.
+var rejectedProposals = map[string]string{
+	"duplicate keys": duplicateKeyProposalJSON,
+	"case-variant duplicate keys": strings.Replace(testProposalJSON, `"proposer_agent": "Claude",`,
+		`"proposer_agent": "Claude", "PROPOSER_AGENT": "Mallory",`, 1),
+	"an unknown field": strings.Replace(testProposalJSON, `"proposer_agent": "Claude",`,
+		`"proposer_agent": "Claude", "proposer": "Mallory",`, 1),
+}
+
+// TestHandler tests submitting and looking up proposals over HTTP
+func TestHandler(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := ocp.NewNode(ocp.NewLedger())
+	handler := NewHandler(node)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/proposals", strings.NewReader(testProposalJSON)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var acceptance map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &acceptance); err != nil {
+		t.Fatalf("Response is not JSON: %v", err)
+	}
+	hash, _ := acceptance["proposal_hash"].(string)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/proposals/"+hash, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), hash) {
+		t.Errorf("Lookup failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/proposals/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Unknown proposals should be 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/proposals", strings.NewReader(testProposalJSON))
+	handler.ServeHTTP(rec, req.WithContext(WithAgent(req.Context(), "Gemini")))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Submitting for another agent should be 403, got %d", rec.Code)
+	}
+
+	for name, body := range rejectedProposals {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/proposals", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("A body with %s should be 400, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	if height := node.Ledger().Height(); height != 1 {
+		t.Errorf("Rejected bodies must not be recorded, ledger height %d", height)
+	}
+	t.Logf("✓ Accepted %s over HTTP", hash)
+}
+
+// TestLedgerRoute tests paging through ledger entries over HTTP
+func TestLedgerRoute(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := ocp.NewLedger()
+	for i := 0; i < 3; i++ {
+		ledger.Append("note", map[string]interface{}{"n": i})
+	}
+	handler := NewHandler(ocp.NewNode(ledger))
+
+	var page struct {
+		Entries []ocp.LedgerEntry `json:"entries"`
+		Height  uint64            `json:"height"`
+		More    bool              `json:"more"`
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/ledger?after=1&limit=1", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Response is not JSON: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Height != 2 || !page.More || page.Height != 3 {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+	if _, err := ocp.VerifyChain(1, ledger.Entries(0)[0].Hash, page.Entries); err != nil {
+		t.Errorf("Served entries should verify: %v", err)
+	}
+
+	for _, query := range []string{"after=-1", "limit=0", "limit=x"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/ledger?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+	t.Log("✓ Ledger served in verifiable pages")
+}
@@ -0,0 +1,68 @@
+// verify.go - Asynchronous proposal verification API
+//
+// Full verification of a proposal can outlast a client's patience, so the
+// verification API acknowledges a submission with 202 Accepted and the URL of
+// a job, and clients poll that URL (or rely on the queue's callbacks) for the
+// report. Jobs are keyed by proposal hash: submitting the same proposal again
+// returns its existing job.
+
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/jobs"
+)
+
+// NewVerificationHandler returns the asynchronous verification API. Routes:
+//
+//	POST /v1/verifications       queue a proposal's verification; 202 with a Location
+//	GET  /v1/verifications/{id}  the job's status and, once done, its report
+func NewVerificationHandler(v *ocp.Verifier, queue *jobs.Queue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/verifications", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBytes))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		p, err := decodeProposal(data)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		hash, err := p.GetHash()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		job, err := queue.Submit(hash, jobs.VerifyProposal(v, p), nil)
+		if errors.Is(err, jobs.ErrQueueFull) || errors.Is(err, jobs.ErrClosed) {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Location", "/v1/verifications/"+job.ID)
+		status := http.StatusAccepted
+		if job.Finished() {
+			status = http.StatusOK
+		}
+		writeJSON(w, status, job.ToMap())
+	})
+	mux.HandleFunc("GET /v1/verifications/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, ok := queue.Get(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, ocp.NewConstitutionalError("unknown verification job"))
+			return
+		}
+		writeJSON(w, http.StatusOK, job.ToMap())
+	})
+	return mux
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/jobs"
+)
+
+// TestVerificationHandler tests queueing a verification and polling its job
+func TestVerificationHandler(t *testing.T) {
+	queue := jobs.NewQueue(jobs.Config{})
+	defer queue.Close()
+	handler := NewVerificationHandler(ocp.NewVerifierOnly(), queue)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/verifications", strings.NewReader(testProposalJSON)))
+	if rec.Code != http.StatusAccepted && rec.Code != http.StatusOK {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/v1/verifications/") {
+		t.Fatalf("Expected a job location, got %q", location)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", location, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), `"status":"done"`) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !strings.Contains(rec.Body.String(), `"checks"`) || !strings.Contains(rec.Body.String(), `"ok":false`) {
+		t.Errorf("Expected the report of an unsigned proposal, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/verifications/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", rec.Code)
+	}
+
+	for name, body := range rejectedProposals {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/verifications", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("A body with %s should be 400, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+	t.Logf("✓ Verification queued at %s", location)
+}
@@ -0,0 +1,159 @@
+// watch.go - Streaming subscription to ledger updates
+//
+// Executors and dashboards that follow the ledger would otherwise poll
+// GET /v1/ledger. GET /v1/ledger/watch?from=HEIGHT instead holds the response
+// open and streams every entry above HEIGHT as it is appended, one canonical
+// JSON entry per line, with its hash and any dual hashes. The proof of each
+// update is the hash chain: every entry names its predecessor's hash, so a
+// subscriber that trusts the hash at HEIGHT can verify every later entry
+// without trusting the node. WatchLedger is the client that does so.
+//
+// The same stream is served as the gRPC server-streaming call
+// WatchLedger(fromHeight) by ext/grpcwatch, which keeps the gRPC dependency
+// out of this module; this route serves it over HTTP as newline-delimited
+// JSON, as transport.ServeHTTP streams topics.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// WatchPath is the route streaming ledger updates
+const WatchPath = "/v1/ledger/watch"
+
+// watchLedger streams the node's ledger entries above ?from=HEIGHT
+func watchLedger(node *ocp.Node) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, ocp.NewConstitutionalError("streaming unsupported"))
+			return
+		}
+		var from uint64
+		if v := r.URL.Query().Get("from"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, ocp.NewConstitutionalError("from must be a ledger height"))
+				return
+			}
+			from = n
+		}
+
+		// A ledger bootstrapped from a snapshot cannot serve the entries
+		// below its snapshot, and a gap would fail the subscriber's chain
+		ledger := node.Ledger()
+		entries := ledger.Entries(from)
+		if len(entries) > 0 && entries[0].Height != from+1 {
+			message := fmt.Sprintf("ledger holds entries from height %d, not %d", entries[0].Height, from+1)
+			writeError(w, http.StatusGone, ocp.NewConstitutionalError(message))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		for {
+			for i := range entries {
+				m := entries[i].ToMap()
+				m["hash"] = entries[i].Hash
+				if len(entries[i].Hashes) > 0 {
+					m[ocp.HashesKey] = entries[i].Hashes
+				}
+				form, err := ocp.Canonicalize(m, true)
+				if err != nil {
+					return
+				}
+				if _, err := io.WriteString(w, form+"\n"); err != nil {
+					return
+				}
+				from = entries[i].Height
+			}
+			flusher.Flush()
+			if err := ledger.Wait(r.Context(), from); err != nil {
+				return
+			}
+			entries = ledger.Entries(from)
+		}
+	}
+}
+
+// WatchLedger subscribes to the ledger of the node at baseURL, calling fn with
+// each entry above fromHeight once it has been verified to chain onto the
+// last. Polling clients switch to it without trusting the node for anything
+// but availability.
+//
+// Parameters:
+//   - ctx: Ends the subscription when done
+//   - client: HTTP client, http.DefaultClient if nil; it must not set a
+//     Timeout, which would cut the stream off
+//   - baseURL: The node's URL, e.g. "https://node.example:8443"
+//   - fromHeight, fromHash: The ledger position the caller trusts; 0 and ""
+//     to follow the ledger from genesis
+//   - fn: Called with each verified entry in height order; an error from fn
+//     ends the subscription and is returned
+//
+// Returns:
+//   - ctx.Err() once ctx is done
+//   - VerificationError if the node sends an entry that does not chain onto
+//     the last verified one
+//   - An error wrapping io.ErrUnexpectedEOF if the node closes the stream
+func WatchLedger(ctx context.Context, client *http.Client, baseURL string, fromHeight uint64, fromHash string, fn func(ocp.LedgerEntry) error) error {
+	if fromHeight > 0 && fromHash == "" {
+		return ocp.NewConstitutionalError("watching from a height above 0 needs the trusted hash at that height")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	url := strings.TrimSuffix(baseURL, "/") + WatchPath + "?from=" + strconv.FormatUint(fromHeight, 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, MaxRequestBytes)).Decode(&body)
+		return ocp.NewConstitutionalError(fmt.Sprintf("watch refused with %s: %s", resp.Status, body.Error))
+	}
+
+	height, head := fromHeight, fromHash
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var entry ocp.LedgerEntry
+		if err := dec.Decode(&entry); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("ledger stream ended after height %d: %w", height, err)
+		}
+		if head, err = ocp.VerifyChain(height, head, []ocp.LedgerEntry{entry}); err != nil {
+			return err
+		}
+		height = entry.Height
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
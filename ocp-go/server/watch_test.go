@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestWatchLedger tests following the ledger as entries are appended
+func TestWatchLedger(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := ocp.NewLedger()
+	for i := 0; i < 2; i++ {
+		ledger.Append("note", map[string]interface{}{"n": i})
+	}
+	srv := httptest.NewServer(NewHandler(ocp.NewNode(ledger)))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var heights []uint64
+	err := WatchLedger(ctx, nil, srv.URL, 0, "", func(e ocp.LedgerEntry) error {
+		heights = append(heights, e.Height)
+		if e.Height == 2 {
+			// Appended while the subscriber is waiting on the stream
+			go ledger.Append("note", map[string]interface{}{"n": 2})
+		}
+		if e.Height == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the subscription to end with its context, got %v", err)
+	}
+	if len(heights) != 3 || heights[2] != 3 {
+		t.Fatalf("Expected heights 1 to 3, got %v", heights)
+	}
+
+	// Resuming from a trusted position
+	first := ledger.Entries(0)[0]
+	stop := errors.New("stop")
+	err = WatchLedger(context.Background(), nil, srv.URL, 1, first.Hash, func(e ocp.LedgerEntry) error {
+		if e.Height != 2 {
+			t.Errorf("Expected to resume at height 2, got %d", e.Height)
+		}
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("Expected fn's error to end the subscription, got %v", err)
+	}
+
+	if err := WatchLedger(context.Background(), nil, srv.URL, 1, "", nil); err == nil {
+		t.Error("Expected resuming without a trusted hash to be refused")
+	}
+	resp, err := http.Get(srv.URL + WatchPath + "?from=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed height, got %d", resp.StatusCode)
+	}
+	t.Logf("✓ Followed the ledger to height %d", heights[len(heights)-1])
+}
+
+// TestWatchLedgerRejectsForgedEntries tests that a node cannot rewrite
+// history on the stream
+func TestWatchLedgerRejectsForgedEntries(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger := ocp.NewLedger()
+	for i := 0; i < 2; i++ {
+		ledger.Append("note", map[string]interface{}{"n": i})
+	}
+	honest := NewHandler(ocp.NewNode(ledger))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &streamCapture{header: http.Header{}}
+		ctx, cancel := context.WithCancel(r.Context())
+		cancel()
+		honest.ServeHTTP(rec, r.WithContext(ctx))
+		forged := strings.Replace(rec.body.String(), `"n":1`, `"n":7`, 1)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, forged)
+	}))
+	defer srv.Close()
+
+	var seen int
+	err := WatchLedger(context.Background(), nil, srv.URL, 0, "", func(ocp.LedgerEntry) error {
+		seen++
+		return nil
+	})
+	var cerr *ocp.ConstitutionalError
+	if !errors.As(err, &cerr) || cerr.ErrorType != "VerificationError" {
+		t.Fatalf("Expected a forged entry to fail verification, got %v", err)
+	}
+	if seen != 1 {
+		t.Errorf("Expected only the entry before the forged one to be delivered, got %d", seen)
+	}
+	t.Log("✓ Forged entry refused")
+}
+
+// streamCapture records a streamed response
+type streamCapture struct {
+	header http.Header
+	body   strings.Builder
+}
+
+func (c *streamCapture) Header() http.Header         { return c.header }
+func (c *streamCapture) Write(b []byte) (int, error) { return c.body.Write(b) }
+func (c *streamCapture) WriteHeader(int)             {}
+func (c *streamCapture) Flush()                      {}
@@ -0,0 +1,189 @@
+// sigcache.go - Cache of verified signatures
+//
+// A proposal gossiped through the network arrives at a node once per peer,
+// and quorum signatures are re-checked every time a snapshot or ratification
+// is replayed. Ed25519 verification dominates that cost although the answer
+// never changes for the same (context, hash, signature, mode, key). A
+// SignatureCache remembers the tuples that verified, up to a fixed size with
+// least recently used eviction, so repeated receipts cost a map lookup.
+//
+// Only successful verifications are cached: a bad signature is re-checked and
+// rejected every time, so a flood of invalid signatures cannot fill the cache
+// and evict good entries. When a key is revoked its entries must go too;
+// InvalidateKey drops every tuple verified under it.
+
+package ocp
+
+import (
+	"container/list"
+	"crypto/ed25519"
+	"sync"
+)
+
+// DefaultSignatureCacheSize is the number of entries NewSignatureCache keeps
+// when given a size below 1
+const DefaultSignatureCacheSize = 4096
+
+// SignatureCacheStats counts a SignatureCache's activity
+type SignatureCacheStats struct {
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	// Invalidations counts entries dropped by InvalidateKey
+	Invalidations uint64 `json:"invalidations"`
+}
+
+// ToMap converts SignatureCacheStats to a map for canonicalization
+func (s SignatureCacheStats) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"size":          s.Size,
+		"capacity":      s.Capacity,
+		"hits":          s.Hits,
+		"misses":        s.Misses,
+		"evictions":     s.Evictions,
+		"invalidations": s.Invalidations,
+	}
+}
+
+// SignatureCache remembers signatures that verified. A nil *SignatureCache is
+// valid and verifies every signature without caching.
+type SignatureCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[sigCacheKey]*list.Element
+	// byKey indexes entries by public key for InvalidateKey
+	byKey map[string]map[sigCacheKey]bool
+	stats SignatureCacheStats
+}
+
+// sigCacheKey identifies one verification: every input VerifyHashSignature
+// checks, including the mode, which it refuses unless empty. The signer name
+// and key reference are left out; they only label the key, which key pins.
+type sigCacheKey struct {
+	ctx       SignatureContext
+	hash      string
+	algorithm string
+	mode      string
+	value     string
+	key       string
+}
+
+// NewSignatureCache creates a cache holding up to size verified signatures
+func NewSignatureCache(size int) *SignatureCache {
+	if size < 1 {
+		size = DefaultSignatureCacheSize
+	}
+	return &SignatureCache{
+		capacity: size,
+		order:    list.New(),
+		entries:  make(map[sigCacheKey]*list.Element),
+		byKey:    make(map[string]map[sigCacheKey]bool),
+	}
+}
+
+// Verify is VerifyHashSignature, answered from the cache when the same
+// signature over the same hash and context already verified under key
+func (c *SignatureCache) Verify(key ed25519.PublicKey, ctx SignatureContext, hash string, sig Signature) error {
+	if c == nil {
+		return VerifyHashSignature(key, ctx, hash, sig)
+	}
+	k := sigCacheKey{ctx: ctx, hash: hash, algorithm: sig.Algorithm, mode: sig.Mode, value: sig.Value, key: string(key)}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[k]; ok {
+		c.order.MoveToFront(elem)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	if err := VerifyHashSignature(key, ctx, hash, sig); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[k]; ok {
+		return nil
+	}
+	c.entries[k] = c.order.PushFront(k)
+	if c.byKey[k.key] == nil {
+		c.byKey[k.key] = make(map[sigCacheKey]bool)
+	}
+	c.byKey[k.key][k] = true
+	for c.order.Len() > c.capacity {
+		c.removeLocked(c.order.Back().Value.(sigCacheKey))
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+// InvalidateKey drops every cached verification made under key. Call it when
+// the identity registry revokes or rotates the key, before the key is removed
+// from the trusted set, so no revoked signature is answered from the cache.
+//
+// Returns:
+//   - Number of entries dropped
+func (c *SignatureCache) InvalidateKey(key ed25519.PublicKey) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := 0
+	for k := range c.byKey[string(key)] {
+		c.removeLocked(k)
+		dropped++
+	}
+	c.stats.Invalidations += uint64(dropped)
+	return dropped
+}
+
+// Purge drops every entry
+func (c *SignatureCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[sigCacheKey]*list.Element)
+	c.byKey = make(map[string]map[sigCacheKey]bool)
+}
+
+// Stats returns the cache's counters
+func (c *SignatureCache) Stats() SignatureCacheStats {
+	if c == nil {
+		return SignatureCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size, stats.Capacity = c.order.Len(), c.capacity
+	return stats
+}
+
+func (c *SignatureCache) removeLocked(k sigCacheKey) {
+	if elem, ok := c.entries[k]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, k)
+	}
+	if set := c.byKey[k.key]; set != nil {
+		delete(set, k)
+		if len(set) == 0 {
+			delete(c.byKey, k.key)
+		}
+	}
+}
+
+// WithSignatureCache answers signature checks from cache, see SignatureCache
+func WithSignatureCache(cache *SignatureCache) VerifyOption {
+	return func(c *verifyConfig) {
+		c.sigCache = cache
+	}
+}
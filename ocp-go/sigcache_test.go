@@ -0,0 +1,111 @@
+package ocp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSignatureCache tests hits, rejection of bad signatures, and eviction
+func TestSignatureCache(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Claude")
+	hash := strings.Repeat("ab", 32)
+	sig, _ := SignHash("Claude", priv, ContextSnapshot, hash)
+
+	cache := NewSignatureCache(2)
+	for i := 0; i < 3; i++ {
+		if err := cache.Verify(pub, ContextSnapshot, hash, sig); err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("Unexpected stats after repeats: %+v", stats)
+	}
+
+	// A cached signature is not accepted under another context or hash
+	if err := cache.Verify(pub, ContextHeartbeat, hash, sig); err == nil {
+		t.Errorf("Expected a signature under another context to fail")
+	}
+	if err := cache.Verify(pub, ContextSnapshot, strings.Repeat("cd", 32), sig); err == nil {
+		t.Errorf("Expected a signature over another hash to fail")
+	}
+	// nor in another mode, although the value is the same
+	prehashed := sig
+	prehashed.Mode = SignModePrehashed
+	if err := cache.Verify(pub, ContextSnapshot, hash, prehashed); err == nil {
+		t.Errorf("Expected a cached hash-mode signature to fail when relabelled %s", SignModePrehashed)
+	}
+	if stats := cache.Stats(); stats.Size != 1 {
+		t.Errorf("Failed verifications should not be cached: %+v", stats)
+	}
+
+	for _, h := range []string{strings.Repeat("01", 32), strings.Repeat("02", 32)} {
+		s, _ := SignHash("Claude", priv, ContextSnapshot, h)
+		if err := cache.Verify(pub, ContextSnapshot, h, s); err != nil {
+			t.Fatalf("Failed to verify: %v", err)
+		}
+	}
+	if stats := cache.Stats(); stats.Size != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected the oldest entry evicted: %+v", stats)
+	}
+
+	var none *SignatureCache
+	if err := none.Verify(pub, ContextSnapshot, hash, sig); err != nil {
+		t.Errorf("A nil cache should still verify: %v", err)
+	}
+
+	t.Logf("✓ Signature cache: %v", cache.Stats().ToMap())
+}
+
+// TestSignatureCacheInvalidateKey tests that revoking a key drops its entries
+func TestSignatureCacheInvalidateKey(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	hash := strings.Repeat("ef", 32)
+	sigs := signAll(t, ContextPolicy, hash, privs, "Claude", "Gemini")
+
+	cache := NewSignatureCache(0)
+	for i := 0; i < 2; i++ {
+		if _, err := quorum.VerifyCached(cache, ContextPolicy, hash, sigs); err != nil {
+			t.Fatalf("Failed to verify quorum: %v", err)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Size != 2 || stats.Capacity != DefaultSignatureCacheSize {
+		t.Errorf("Expected the second quorum check from cache: %+v", stats)
+	}
+
+	if n := cache.InvalidateKey(quorum.Members["Claude"]); n != 1 {
+		t.Errorf("Expected one entry dropped, got %d", n)
+	}
+	if stats := cache.Stats(); stats.Size != 1 || stats.Invalidations != 1 {
+		t.Errorf("Unexpected stats after invalidation: %+v", stats)
+	}
+
+	// With the key removed from the trusted set, its signature no longer counts
+	delete(quorum.Members, "Claude")
+	if _, err := quorum.VerifyCached(cache, ContextPolicy, hash, sigs); err == nil {
+		t.Errorf("Expected quorum to fail without the revoked key")
+	}
+
+	t.Log("✓ Revoked keys dropped from the cache")
+}
+
+// TestVerifyProposalWithSignatureCache tests that proposal checks use the cache
+func TestVerifyProposalWithSignatureCache(t *testing.T) {
+	testenv.RequireSigning(t)
+	p, keys := signedTestProposal(t)
+	cache := NewSignatureCache(16)
+	for i := 0; i < 2; i++ {
+		report := VerifyProposalFull(p, WithProposerKeys(keys), WithSignatureCache(cache))
+		if check, _ := report.Check(CheckSignature); check.Status != CheckPassed {
+			t.Fatalf("Expected signature to pass: %+v", check)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected the re-received proposal answered from cache: %+v", stats)
+	}
+
+	t.Log("✓ Re-received proposal verified from cache")
+}
@@ -0,0 +1,173 @@
+// signature.go - Detached signatures over semantic hashes
+//
+// Every signed OCP object (snapshots, ledger checkpoints, quorum attestations) is signed
+// over its semantic hash rather than its raw bytes, so any implementation that agrees on the
+// canonical form also agrees on what was signed.
+//
+// Signatures are domain separated: the signed message is a context string
+// naming the object kind, a zero byte, and the digest. A signature made for one
+// kind of object therefore never verifies as a signature over another kind,
+// even if the two objects' digests collide or one hash is presented as the
+// other.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+)
+
+// SignatureAlgorithm is the only signature scheme currently accepted by the protocol
+const SignatureAlgorithm = "ed25519"
+
+// NewVerificationError creates an error for failed hash, chain, or signature checks
+func NewVerificationError(message string) error {
+	return &ConstitutionalError{
+		ErrorType: "VerificationError",
+		Message:   message,
+	}
+}
+
+// SignatureContext names the kind of object a signature covers
+type SignatureContext string
+
+// Signature contexts for every signed object kind
+const (
+	ContextProposal     SignatureContext = "ocp/proposal/v1"
+	ContextSnapshot     SignatureContext = "ocp/snapshot/v1"
+	ContextHeartbeat    SignatureContext = "ocp/heartbeat/v1"
+	ContextReceipt      SignatureContext = "ocp/receipt/v1"
+	ContextPolicy       SignatureContext = "ocp/policy/v1"
+	ContextConstitution SignatureContext = "ocp/constitution/v1"
+	ContextHaltTrigger  SignatureContext = "ocp/halt-trigger/v1"
+	ContextHaltReview   SignatureContext = "ocp/halt-review/v1"
+	ContextHaltLift     SignatureContext = "ocp/halt-lift/v1"
+	ContextStateRoot    SignatureContext = "ocp/state-root/v1"
+	ContextSelfTest     SignatureContext = "ocp/self-test/v1"
+	ContextCanonical    SignatureContext = "ocp/canonical/v1"
+	ContextEvidence     SignatureContext = "ocp/evidence/v1"
+	ContextCommitment   SignatureContext = "ocp/commitment/v1"
+	ContextCountersign  SignatureContext = "ocp/countersign/v1"
+	ContextIntent       SignatureContext = "ocp/intent/v1"
+	ContextSponsorship  SignatureContext = "ocp/sponsorship/v1"
+	ContextFraudProof   SignatureContext = "ocp/fraud-proof/v1"
+	ContextAudit        SignatureContext = "ocp/audit/v1"
+	ContextHandshake    SignatureContext = "ocp/secure-handshake/v1"
+	ContextEpoch        SignatureContext = "ocp/epoch/v1"
+	ContextApproval     SignatureContext = "ocp/approval/v1"
+	ContextTranslation  SignatureContext = "ocp/translation/v1"
+	ContextAppeal       SignatureContext = "ocp/appeal/v1"
+	ContextAppealRuling SignatureContext = "ocp/appeal-ruling/v1"
+)
+
+// signedMessage returns the bytes signed for digest under ctx
+func signedMessage(ctx SignatureContext, hash string) ([]byte, error) {
+	if ctx == "" {
+		return nil, NewVerificationError("signature context is required")
+	}
+	digest, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, NewVerificationError(fmt.Sprintf("hash is not hex encoded: %v", err))
+	}
+	msg := make([]byte, 0, len(ctx)+1+len(digest))
+	msg = append(msg, ctx...)
+	msg = append(msg, 0)
+	return append(msg, digest...), nil
+}
+
+// Signature is a detached signature by a named agent over a semantic hash
+type Signature struct {
+	Signer    string `json:"signer"`
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+	// Mode is empty for signatures over a semantic hash and SignModePrehashed
+	// for Ed25519ph signatures over a canonical payload; see prehash.go
+	Mode string `json:"mode,omitempty"`
+	// Key references the key that made the signature when it is held by an
+	// HSM or KMS; see signer.go
+	Key *KeyRef `json:"key,omitempty"`
+}
+
+// ToMap converts a Signature to a map for canonicalization. The mode and key
+// reference are included only when set, so hash-mode signatures by in-memory
+// keys keep their original form.
+func (s Signature) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"signer":    s.Signer,
+		"algorithm": s.Algorithm,
+		"value":     s.Value,
+	}
+	if s.Mode != "" {
+		m["mode"] = s.Mode
+	}
+	if s.Key != nil {
+		m["key"] = s.Key.ToMap()
+	}
+	return m
+}
+
+// SignHash signs a hex-encoded semantic hash with an Ed25519 private key.
+// The signature covers the context and the decoded digest bytes, not the hex
+// string.
+//
+// Parameters:
+//   - signer: Agent identifier recorded in the signature
+//   - key: Ed25519 private key of the signer
+//   - ctx: Context of the signed object kind; required
+//   - hash: Hex-encoded semantic hash to sign
+//
+// Returns:
+//   - Signature with hex-encoded value
+func SignHash(signer string, key ed25519.PrivateKey, ctx SignatureContext, hash string) (Signature, error) {
+	if VerifyOnlyBuild {
+		return Signature{}, ErrVerifyOnly
+	}
+	msg, err := signedMessage(ctx, hash)
+	if err != nil {
+		return Signature{}, err
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return Signature{}, NewVerificationError("invalid ed25519 private key size")
+	}
+	return Signature{
+		Signer:    signer,
+		Algorithm: SignatureAlgorithm,
+		Value:     hex.EncodeToString(ed25519.Sign(key, msg)),
+	}, nil
+}
+
+// VerifyHashSignature verifies a Signature over a hex-encoded semantic hash.
+// The signature must have been made under the same context.
+//
+// Parameters:
+//   - key: Ed25519 public key of the expected signer
+//   - ctx: Context of the expected object kind
+//   - hash: Hex-encoded semantic hash that was signed
+//   - sig: Signature to verify
+//
+// Returns:
+//   - nil if the signature is valid, a VerificationError otherwise
+func VerifyHashSignature(key ed25519.PublicKey, ctx SignatureContext, hash string, sig Signature) error {
+	if sig.Algorithm != SignatureAlgorithm {
+		return NewVerificationError(fmt.Sprintf("unsupported signature algorithm %q", sig.Algorithm))
+	}
+	if sig.Mode != "" {
+		return NewVerificationError(fmt.Sprintf("signature from %q in mode %q does not sign a hash; verify it against the payload", sig.Signer, sig.Mode))
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return NewVerificationError(fmt.Sprintf("invalid public key for signer %q", sig.Signer))
+	}
+	msg, err := signedMessage(ctx, hash)
+	if err != nil {
+		return err
+	}
+	value, err := hex.DecodeString(sig.Value)
+	if err != nil {
+		return NewVerificationError(fmt.Sprintf("signature value is not hex encoded: %v", err))
+	}
+	if !ed25519.Verify(key, msg, value) {
+		return NewVerificationError(fmt.Sprintf("invalid signature from %q", sig.Signer))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testKey derives a deterministic Ed25519 key pair for an agent name
+func testKey(name string) (ed25519.PublicKey, ed25519.PrivateKey) {
+	seed := make([]byte, ed25519.SeedSize)
+	copy(seed, name)
+	priv := ed25519.NewKeyFromSeed(seed)
+	return priv.Public().(ed25519.PublicKey), priv
+}
+
+// TestSignHashRoundTrip tests signing and verifying a semantic hash
+func TestSignHashRoundTrip(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Claude")
+
+	hash, err := SemanticHash(map[string]interface{}{"action": "propose"})
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+
+	sig, err := SignHash("Claude", priv, ContextSnapshot, hash)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	if err := VerifyHashSignature(pub, ContextSnapshot, hash, sig); err != nil {
+		t.Errorf("Valid signature should verify: %v", err)
+	}
+
+	otherHash, _ := SemanticHash(map[string]interface{}{"action": "reject"})
+	if err := VerifyHashSignature(pub, ContextSnapshot, otherHash, sig); err == nil {
+		t.Errorf("Signature should not verify for a different hash")
+	}
+
+	otherPub, _ := testKey("Gemini")
+	if err := VerifyHashSignature(otherPub, ContextSnapshot, hash, sig); err == nil {
+		t.Errorf("Signature should not verify under a different key")
+	}
+
+	// Domain separation: the same digest signed as a snapshot is not a proposal signature
+	if err := VerifyHashSignature(pub, ContextProposal, hash, sig); err == nil {
+		t.Errorf("Signature should not verify under a different context")
+	}
+	if _, err := SignHash("Claude", priv, "", hash); err == nil {
+		t.Errorf("Signing without a context should fail")
+	}
+
+	t.Logf("✓ Signature round trip: %s", sig.Value[:16])
+}
+
+// TestQuorumVerify tests threshold counting of distinct member signatures
+func TestQuorumVerify(t *testing.T) {
+	testenv.RequireSigning(t)
+	members := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range []string{"Claude", "Gemini", "DeepSeek"} {
+		members[name], privs[name] = testKey(name)
+	}
+
+	if _, err := NewQuorum(members, 4); err == nil {
+		t.Errorf("Threshold above member count should be rejected")
+	}
+
+	quorum, err := NewQuorum(members, 2)
+	if err != nil {
+		t.Fatalf("Failed to create quorum: %v", err)
+	}
+
+	hash, _ := SemanticHash(map[string]interface{}{"height": float64(7)})
+	claudeSig, _ := SignHash("Claude", privs["Claude"], ContextSnapshot, hash)
+	geminiSig, _ := SignHash("Gemini", privs["Gemini"], ContextSnapshot, hash)
+	_, outsiderPriv := testKey("Outsider")
+	outsiderSig, _ := SignHash("Outsider", outsiderPriv, ContextSnapshot, hash)
+
+	// Duplicates and non-members do not count toward the threshold
+	if _, err := quorum.Verify(ContextSnapshot, hash, []Signature{claudeSig, claudeSig, outsiderSig}); err == nil {
+		t.Errorf("Duplicate and outsider signatures should not reach quorum")
+	}
+
+	signers, err := quorum.Verify(ContextSnapshot, hash, []Signature{outsiderSig, geminiSig, claudeSig})
+	if err != nil {
+		t.Fatalf("Quorum should be reached: %v", err)
+	}
+	if len(signers) != 2 || signers[0] != "Claude" || signers[1] != "Gemini" {
+		t.Errorf("Unexpected counted signers: %v", signers)
+	}
+
+	t.Logf("✓ Quorum reached by %v", signers)
+}
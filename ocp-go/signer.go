@@ -0,0 +1,204 @@
+// signer.go - Signing keys held outside the process
+//
+// SignHash takes an Ed25519 private key in memory, which suits tests and
+// development nodes but not production quorum keys: a key in process memory
+// can be read from a core dump, a debugger, or a compromised dependency. A
+// Signer signs without exposing its key, so the key can live in an HSM or a
+// cloud KMS (kms.go) and the process only ever sees signatures.
+//
+// A Signer describes its key with a KeyRef: the provider, a URI naming the
+// key within it, and attestation metadata about how the key is protected
+// (protection level, whether it can be exported, the HSM's own attestation).
+// SignHashWith records the reference in the signature, so an auditor can tell
+// which key made each quorum signature and whether it was hardware-held. The
+// reference is informational: verification still checks the signature against
+// the signer's registered public key, exactly as for SignHash.
+
+package ocp
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Key providers recorded in KeyRef.Provider
+const (
+	KeyProviderPKCS11 = "pkcs11"
+	KeyProviderAWSKMS = "aws-kms"
+	KeyProviderGCPKMS = "gcp-kms"
+)
+
+// KeyRef identifies a signing key without revealing it
+type KeyRef struct {
+	// Provider is one of the KeyProvider constants
+	Provider string `json:"provider"`
+	// URI names the key within the provider: an RFC 7512 PKCS#11 URI, an AWS
+	// KMS key ARN, or a GCP KMS key version resource name
+	URI string `json:"uri"`
+	// Attestation holds what the provider reports about the key's protection
+	Attestation map[string]string `json:"attestation,omitempty"`
+}
+
+// ToMap converts a KeyRef to a map for canonicalization
+func (r KeyRef) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"provider": r.Provider,
+		"uri":      r.URI,
+	}
+	if len(r.Attestation) > 0 {
+		attestation := make(map[string]interface{}, len(r.Attestation))
+		for k, v := range r.Attestation {
+			attestation[k] = v
+		}
+		m["attestation"] = attestation
+	}
+	return m
+}
+
+// String returns the provider and URI followed by the attestation in key order
+func (r KeyRef) String() string {
+	s := r.Provider + " " + r.URI
+	keys := make([]string, 0, len(r.Attestation))
+	for k := range r.Attestation {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s += fmt.Sprintf(" %s=%s", k, r.Attestation[k])
+	}
+	return s
+}
+
+// keyRefFromPayload reads a KeyRef stored with ToMap, or nil if there is none
+func keyRefFromPayload(payload map[string]interface{}, key string) *KeyRef {
+	m, ok := payload[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ref := &KeyRef{Provider: payloadString(m, "provider"), URI: payloadString(m, "uri")}
+	if attestation, ok := m["attestation"].(map[string]interface{}); ok {
+		ref.Attestation = make(map[string]string, len(attestation))
+		for k := range attestation {
+			ref.Attestation[k] = payloadString(attestation, k)
+		}
+	}
+	return ref
+}
+
+// Signer is an Ed25519 signing key that may be held outside the process.
+// Sign must produce a pure Ed25519 signature over the whole message; it is
+// called with crypto.Hash(0), as for ed25519.PrivateKey.
+type Signer interface {
+	crypto.Signer
+	// KeyRef describes the key; a zero KeyRef is not recorded
+	KeyRef() KeyRef
+}
+
+// KeySigner is a Signer over a private key in process memory, for tests and
+// development nodes
+type KeySigner struct {
+	Key ed25519.PrivateKey
+}
+
+// Public returns the public key
+func (k KeySigner) Public() crypto.PublicKey {
+	return k.Key.Public()
+}
+
+// Sign signs message with the in-memory key
+func (k KeySigner) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(k.Key) != ed25519.PrivateKeySize {
+		return nil, NewVerificationError("invalid ed25519 private key size")
+	}
+	return k.Key.Sign(rand, message, opts)
+}
+
+// KeyRef returns the zero KeyRef: an in-memory key has no reference
+func (k KeySigner) KeyRef() KeyRef {
+	return KeyRef{}
+}
+
+// SignHashWith signs a hex-encoded semantic hash with s, as SignHash does with
+// an in-memory key. The returned signature is checked against s's public key
+// before it is returned, so a misconfigured key reference fails here rather
+// than at every verifier.
+//
+// Parameters:
+//   - signer: Agent identifier recorded in the signature
+//   - s: Signer holding the agent's key
+//   - ctx: Context of the signed object kind; required
+//   - hash: Hex-encoded semantic hash to sign
+//
+// Returns:
+//   - Signature with hex-encoded value and s's KeyRef, unless it is zero
+func SignHashWith(signer string, s Signer, ctx SignatureContext, hash string) (Signature, error) {
+	if VerifyOnlyBuild {
+		return Signature{}, ErrVerifyOnly
+	}
+	msg, err := signedMessage(ctx, hash)
+	if err != nil {
+		return Signature{}, err
+	}
+	pub, ok := s.Public().(ed25519.PublicKey)
+	if !ok {
+		return Signature{}, NewVerificationError(fmt.Sprintf("signer for %q does not hold an ed25519 key", signer))
+	}
+	value, err := s.Sign(nil, msg, crypto.Hash(0))
+	if err != nil {
+		return Signature{}, err
+	}
+	if !ed25519.Verify(pub, msg, value) {
+		return Signature{}, NewVerificationError(fmt.Sprintf("signer for %q returned a signature its public key does not verify", signer))
+	}
+	sig := Signature{
+		Signer:    signer,
+		Algorithm: SignatureAlgorithm,
+		Value:     hex.EncodeToString(value),
+	}
+	if ref := s.KeyRef(); ref.Provider != "" || ref.URI != "" {
+		sig.Key = &ref
+	}
+	return sig, nil
+}
+
+// RemoteSigner is a Signer whose key is held by a PKCS#11 token or a cloud
+// KMS; see kms.go for its constructors
+type RemoteSigner struct {
+	public ed25519.PublicKey
+	ref    KeyRef
+	sign   func(message []byte) ([]byte, error)
+}
+
+// Public returns the public key read from the provider
+func (r *RemoteSigner) Public() crypto.PublicKey {
+	return r.public
+}
+
+// Sign asks the provider to sign message with pure Ed25519
+func (r *RemoteSigner) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != crypto.Hash(0) {
+		return nil, NewVerificationError("remote ed25519 signers sign whole messages, not digests")
+	}
+	sig, err := r.sign(message)
+	if err != nil {
+		return nil, fmt.Errorf("%s signer %s: %w", r.ref.Provider, r.ref.URI, err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, NewVerificationError(fmt.Sprintf("%s signer %s returned a %d-byte signature", r.ref.Provider, r.ref.URI, len(sig)))
+	}
+	return sig, nil
+}
+
+// KeyRef returns the provider's reference to the key and its attestation
+func (r *RemoteSigner) KeyRef() KeyRef {
+	ref := r.ref
+	ref.Attestation = make(map[string]string, len(r.ref.Attestation))
+	for k, v := range r.ref.Attestation {
+		ref.Attestation[k] = v
+	}
+	return ref
+}
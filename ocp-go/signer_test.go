@@ -0,0 +1,50 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestSignHashWith tests signing through a Signer and recording its key reference
+func TestSignHashWith(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, key := testKey("Claude")
+	hash, _ := SemanticHash(map[string]interface{}{"epoch": 7})
+
+	local, err := SignHashWith("Claude", KeySigner{Key: key}, ContextEpoch, hash)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	expected, _ := SignHash("Claude", key, ContextEpoch, hash)
+	if local.Value != expected.Value || local.Key != nil {
+		t.Errorf("An in-memory signer should sign exactly as SignHash, got %+v", local)
+	}
+
+	remote := &RemoteSigner{
+		public: pub,
+		ref:    KeyRef{Provider: KeyProviderGCPKMS, URI: "projects/p/locations/l/keyRings/r/cryptoKeys/quorum/cryptoKeyVersions/1", Attestation: map[string]string{"protection_level": "HSM"}},
+		sign:   func(msg []byte) ([]byte, error) { return ed25519.Sign(key, msg), nil },
+	}
+	sig, err := SignHashWith("Claude", remote, ContextEpoch, hash)
+	if err != nil {
+		t.Fatalf("Failed to sign remotely: %v", err)
+	}
+	if err := VerifyHashSignature(pub, ContextEpoch, hash, sig); err != nil {
+		t.Errorf("Remote signature should verify: %v", err)
+	}
+	read := signaturesFromPayload(map[string]interface{}{"signatures": signatureList([]Signature{sig})}, "signatures")
+	if len(read) != 1 || read[0].Key == nil || read[0].Key.String() != sig.Key.String() {
+		t.Errorf("Key reference should survive the payload form, got %+v", read)
+	}
+
+	// A provider signing with a different key than it reported is caught
+	// before the signature leaves the node
+	_, other := testKey("Gemini")
+	remote.sign = func(msg []byte) ([]byte, error) { return ed25519.Sign(other, msg), nil }
+	if _, err := SignHashWith("Claude", remote, ContextEpoch, hash); err == nil {
+		t.Error("Expected a mismatched remote key to be refused")
+	}
+	t.Logf("✓ Signed with %s", sig.Key)
+}
@@ -0,0 +1,284 @@
+// chaos.go - Failure injection between a participant and the bus
+//
+// A Chaos link wraps a transport.Transport and perturbs what passes through
+// it: messages are dropped, deliveries held back, and payloads corrupted. A
+// corruption changes one byte of one string value and leaves the payload
+// well-formed, so it passes every transport check and only the protocol's own
+// verification (hash chains, proposal hashes, signatures) can catch it.
+//
+// Every fault is drawn from a random source seeded at construction and
+// recorded, so a scenario that fails can be replayed with the same seed and
+// its log compared against what the participants detected.
+
+package simnet
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+	"github.com/seanrugg/ai_constitution/ocp-go/transport"
+)
+
+// Fault kinds
+const (
+	FaultDrop    = "drop"
+	FaultDelay   = "delay"
+	FaultCorrupt = "corrupt"
+)
+
+// Directions a fault can be injected in
+const (
+	// DirSend is a message sent by the participant
+	DirSend = "send"
+	// DirDeliver is a message delivered to one of the participant's
+	// subscriptions
+	DirDeliver = "deliver"
+	// DirRequest is a request made by the participant
+	DirRequest = "request"
+	// DirReply is the reply to one of its requests
+	DirReply = "reply"
+)
+
+// ErrDropped is returned for a request or reply the link lost, in place of
+// the timeout a real network would produce
+var ErrDropped = &ocp.ConstitutionalError{ErrorType: "TransportError", Message: "message dropped by chaos link"}
+
+// Faults configures what a link injects. The zero value injects nothing.
+type Faults struct {
+	// Drop is the probability that a message is lost
+	Drop float64
+	// Delay holds a delivery back, with probability DelayRate
+	Delay     time.Duration
+	DelayRate float64
+	// Corrupt is the probability that a payload has one byte of one of its
+	// string values changed
+	Corrupt float64
+}
+
+// Fault records one injected fault
+type Fault struct {
+	Kind      string
+	Topic     string
+	Direction string
+	// Path locates the corrupted value, e.g. "entries[2].hash"
+	Path string
+}
+
+// String implements fmt.Stringer
+func (f Fault) String() string {
+	if f.Path != "" {
+		return fmt.Sprintf("%s %s %s at %s", f.Kind, f.Direction, f.Topic, f.Path)
+	}
+	return fmt.Sprintf("%s %s %s", f.Kind, f.Direction, f.Topic)
+}
+
+// Chaos is a Transport injecting faults into another. It is safe for
+// concurrent use.
+type Chaos struct {
+	inner transport.Transport
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	faults Faults
+	log    []Fault
+}
+
+// NewChaos wraps inner, injecting faults drawn from a source seeded with seed
+func NewChaos(inner transport.Transport, faults Faults, seed int64) *Chaos {
+	return &Chaos{inner: inner, faults: faults, rng: rand.New(rand.NewSource(seed))}
+}
+
+// SetFaults changes the faults injected from now on
+func (c *Chaos) SetFaults(f Faults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.faults = f
+}
+
+// Heal stops injecting faults
+func (c *Chaos) Heal() {
+	c.SetFaults(Faults{})
+}
+
+// Injected returns the faults injected so far, in order
+func (c *Chaos) Injected() []Fault {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Fault(nil), c.log...)
+}
+
+// Count returns the number of injected faults of kind on topic; an empty
+// topic counts every topic
+func (c *Chaos) Count(kind, topic string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, f := range c.log {
+		if f.Kind == kind && (topic == "" || f.Topic == topic) {
+			n++
+		}
+	}
+	return n
+}
+
+// Send implements transport.Transport
+func (c *Chaos) Send(ctx context.Context, topic string, payload map[string]interface{}) error {
+	payload, delay, dropped := c.perturb(topic, DirSend, payload)
+	if dropped {
+		return nil
+	}
+	if err := hold(ctx, delay); err != nil {
+		return err
+	}
+	return c.inner.Send(ctx, topic, payload)
+}
+
+// Subscribe implements transport.Transport. Faults are injected into each
+// delivery separately.
+func (c *Chaos) Subscribe(topic string, handler transport.Handler) (func(), error) {
+	return c.inner.Subscribe(topic, func(topic string, payload map[string]interface{}) {
+		payload, delay, dropped := c.perturb(topic, DirDeliver, payload)
+		if dropped {
+			return
+		}
+		time.Sleep(delay)
+		handler(topic, payload)
+	})
+}
+
+// Request implements transport.Transport. Faults are injected into the
+// request and its reply separately; a lost request or reply fails with
+// ErrDropped.
+func (c *Chaos) Request(ctx context.Context, topic string, payload map[string]interface{}) (map[string]interface{}, error) {
+	payload, delay, dropped := c.perturb(topic, DirRequest, payload)
+	if dropped {
+		return nil, ErrDropped
+	}
+	if err := hold(ctx, delay); err != nil {
+		return nil, err
+	}
+	reply, err := c.inner.Request(ctx, topic, payload)
+	if err != nil {
+		return nil, err
+	}
+	reply, delay, dropped = c.perturb(topic, DirReply, reply)
+	if dropped {
+		return nil, ErrDropped
+	}
+	if err := hold(ctx, delay); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Close implements transport.Transport
+func (c *Chaos) Close() error {
+	return c.inner.Close()
+}
+
+// perturb draws the faults for one message. A corrupted payload is a copy;
+// the caller's is never changed.
+func (c *Chaos) perturb(topic, dir string, payload map[string]interface{}) (map[string]interface{}, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.faults
+	if f.Drop > 0 && c.rng.Float64() < f.Drop {
+		c.log = append(c.log, Fault{Kind: FaultDrop, Topic: topic, Direction: dir})
+		return nil, 0, true
+	}
+	var delay time.Duration
+	if f.Delay > 0 && f.DelayRate > 0 && c.rng.Float64() < f.DelayRate {
+		delay = f.Delay
+		c.log = append(c.log, Fault{Kind: FaultDelay, Topic: topic, Direction: dir})
+	}
+	if f.Corrupt > 0 && c.rng.Float64() < f.Corrupt {
+		copied, _ := canonical.DeepCopy(payload).(map[string]interface{})
+		if path, ok := corrupt(copied, c.rng); ok {
+			c.log = append(c.log, Fault{Kind: FaultCorrupt, Topic: topic, Direction: dir, Path: path})
+			payload = copied
+		}
+	}
+	return payload, delay, false
+}
+
+// corrupt changes one byte of a string value in m, chosen by rng among every
+// string in key order, keeping the string valid UTF-8
+//
+// Returns:
+//   - Path of the changed value
+//   - false if m holds no strings
+func corrupt(m map[string]interface{}, rng *rand.Rand) (string, bool) {
+	type leaf struct {
+		path string
+		get  func() string
+		set  func(string)
+	}
+	var leaves []leaf
+	var walk func(path string, v interface{}, set func(interface{}))
+	walk = func(path string, v interface{}, set func(interface{})) {
+		switch v := v.(type) {
+		case string:
+			leaves = append(leaves, leaf{path, func() string { return v }, func(s string) { set(s) }})
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				k := k
+				child := k
+				if path != "" {
+					child = path + "." + k
+				}
+				walk(child, v[k], func(x interface{}) { v[k] = x })
+			}
+		case []interface{}:
+			for i := range v {
+				i := i
+				walk(fmt.Sprintf("%s[%d]", path, i), v[i], func(x interface{}) { v[i] = x })
+			}
+		}
+	}
+	walk("", m, nil)
+	if len(leaves) == 0 {
+		return "", false
+	}
+
+	target := leaves[rng.Intn(len(leaves))]
+	b := []byte(target.get())
+	if len(b) == 0 {
+		target.set("\x00")
+		return target.path, true
+	}
+	i := rng.Intn(len(b))
+	if b[i] < utf8.RuneSelf {
+		b[i] ^= 0x01
+	} else {
+		b = append(b, '~')
+	}
+	target.set(string(b))
+	return target.path, true
+}
+
+// hold waits for d or until ctx is done
+func hold(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package simnet
+
+import (
+	"context"
+	"errors"
+	"go/build"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/transport"
+)
+
+// echoBus returns a bus whose "echo" topic replies with the request
+func echoBus(t *testing.T) *transport.Memory {
+	t.Helper()
+	bus := transport.NewMemory()
+	if err := bus.Serve("echo", func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		return payload, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return bus
+}
+
+// TestChaosFaults tests each kind of fault and healing
+func TestChaosFaults(t *testing.T) {
+	ctx := context.Background()
+	bus := echoBus(t)
+	payload := map[string]interface{}{"id": "p-1", "nested": map[string]interface{}{"hash": "sha256:abc"}, "n": 1}
+
+	lossy := NewChaos(bus, Faults{Drop: 1}, 1)
+	if _, err := lossy.Request(ctx, "echo", payload); !errors.Is(err, ErrDropped) || !Dropped(err) {
+		t.Errorf("Expected a dropped request, got %v", err)
+	}
+	var delivered int
+	cancel, _ := lossy.Subscribe("news", func(string, map[string]interface{}) { delivered++ })
+	bus.Send(ctx, "news", payload)
+	lossy.Send(ctx, "news", payload)
+	cancel()
+	if delivered != 0 {
+		t.Errorf("Expected no deliveries through a lossy link, got %d", delivered)
+	}
+
+	corrupting := NewChaos(bus, Faults{Corrupt: 1}, 1)
+	reply, err := corrupting.Request(ctx, "echo", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload["id"] != "p-1" || payload["nested"].(map[string]interface{})["hash"] != "sha256:abc" {
+		t.Fatalf("Expected the caller's payload to be left alone, got %v", payload)
+	}
+	faults := corrupting.Injected()
+	if len(faults) != 2 || faults[0].Direction != DirRequest || faults[1].Direction != DirReply {
+		t.Fatalf("Expected the request and reply to be corrupted, got %v", faults)
+	}
+	if reflect.DeepEqual(reply, map[string]interface{}{"id": "p-1", "nested": map[string]interface{}{"hash": "sha256:abc"}, "n": int64(1)}) {
+		t.Error("Expected the reply to differ")
+	}
+
+	slow := NewChaos(bus, Faults{Delay: 20 * time.Millisecond, DelayRate: 1}, 1)
+	start := time.Now()
+	if _, err := slow.Request(ctx, "echo", payload); err != nil || time.Since(start) < 40*time.Millisecond {
+		t.Errorf("Expected the request and reply to be held back, took %v: %v", time.Since(start), err)
+	}
+	short, stop := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer stop()
+	if _, err := slow.Request(short, "echo", payload); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a held request to respect its context, got %v", err)
+	}
+
+	corrupting.Heal()
+	if reply, err := corrupting.Request(ctx, "echo", payload); err != nil || reply["id"] != "p-1" || len(corrupting.Injected()) != 2 {
+		t.Errorf("Expected a healed link to pass traffic unchanged: %v %v", reply, err)
+	}
+	t.Logf("✓ Faults injected: %v", faults)
+}
+
+// TestChaosDeterministic tests that a seed reproduces the same faults
+func TestChaosDeterministic(t *testing.T) {
+	run := func(seed int64) []Fault {
+		c := NewChaos(echoBus(t), Faults{Drop: 0.2, Corrupt: 0.3, Delay: time.Microsecond, DelayRate: 0.2}, seed)
+		for i := 0; i < 50; i++ {
+			c.Request(context.Background(), "echo", map[string]interface{}{"a": "alpha", "b": []interface{}{"beta", "gamma"}})
+		}
+		return c.Injected()
+	}
+	first := run(7)
+	if len(first) == 0 || !reflect.DeepEqual(first, run(7)) {
+		t.Fatalf("Expected seed 7 to reproduce its %d faults", len(first))
+	}
+	if reflect.DeepEqual(first, run(8)) {
+		t.Error("Expected another seed to inject other faults")
+	}
+	t.Logf("✓ Seed 7 reproduces %d faults", len(first))
+}
+
+// TestSimnetIsTestOnly tests that no package but simnet's own imports it
+func TestSimnetIsTestOnly(t *testing.T) {
+	const simnetPath = "github.com/seanrugg/ai_constitution/ocp-go/simnet"
+	checked := 0
+	filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if (strings.HasPrefix(d.Name(), ".") && path != "..") || d.Name() == "testdata" {
+			return filepath.SkipDir
+		}
+		pkg, err := build.ImportDir(path, 0)
+		if err != nil {
+			return nil
+		}
+		checked++
+		for _, imp := range pkg.Imports {
+			if imp == simnetPath {
+				t.Errorf("%s imports simnet outside its tests", path)
+			}
+		}
+		return nil
+	})
+	if checked < 10 {
+		t.Fatalf("Expected to check the module's packages, checked %d", checked)
+	}
+	t.Logf("✓ %d packages checked; simnet is imported only by tests", checked)
+}
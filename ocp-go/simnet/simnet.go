@@ -0,0 +1,277 @@
+// Package simnet runs an OCP network in one process under injected faults.
+//
+// It exists for tests: it must never be imported by a node, and
+// TestSimnetIsTestOnly fails if a non-test package outside simnet imports it.
+//
+// A Network serves a leader Node on a transport.Memory bus, as ServeNode does
+// in deployment. Replicas (transport.Follower) and agents (Agent) each reach
+// the bus through their own Chaos link, configured with the Faults that
+// participant suffers. A scenario submits proposals and syncs replicas while
+// links drop, delay, and corrupt traffic, then calls Heal and Converge and
+// checks the protocol's safety properties with Check:
+//
+//   - Safety: no replica holds an entry the leader did not write
+//   - Detection: a replica stops (diverges) only when its link corrupted
+//     something, never because of loss or delay alone
+//   - Liveness: once faults stop, every replica that did not stop reaches the
+//     leader's head
+//
+// Agents check the other half. Agent.Submit refuses an acceptance that does
+// not name the proposal it submitted, and Agent.Confirm checks the rest of an
+// acceptance against a replica's verified ledger, so a corrupted submission or
+// reply is detected by the agent that made it.
+package simnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/transport"
+)
+
+// ErrMismatch is returned by Agent.Submit and Agent.Confirm when an
+// acceptance does not record the proposal the agent submitted
+var ErrMismatch = &ocp.ConstitutionalError{ErrorType: "VerificationError", Message: "acceptance does not record the submitted proposal"}
+
+// Network is a leader node with its replicas and agents
+type Network struct {
+	Bus    *transport.Memory
+	Leader *ocp.Node
+	quorum *ocp.Quorum
+	seed   int64
+
+	mu       sync.Mutex
+	links    map[string]*Chaos
+	replicas map[string]*transport.Follower
+}
+
+// NewNetwork serves leader on a new bus. Replicas verify signed entries
+// against quorum, and every link's faults are drawn from seed and the
+// participant's name.
+func NewNetwork(leader *ocp.Node, quorum *ocp.Quorum, seed int64) (*Network, error) {
+	bus := transport.NewMemory()
+	if err := transport.ServeNode(bus, leader); err != nil {
+		return nil, err
+	}
+	return &Network{
+		Bus:      bus,
+		Leader:   leader,
+		quorum:   quorum,
+		seed:     seed,
+		links:    make(map[string]*Chaos),
+		replicas: make(map[string]*transport.Follower),
+	}, nil
+}
+
+// link creates the named participant's link; the caller holds n.mu
+func (n *Network) link(name string, faults Faults) (*Chaos, error) {
+	if _, ok := n.links[name]; ok {
+		return nil, ocp.NewConstitutionalError(fmt.Sprintf("participant %s already joined", name))
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	c := NewChaos(n.Bus, faults, n.seed^int64(h.Sum64()))
+	n.links[name] = c
+	return c, nil
+}
+
+// Link returns the named participant's link
+func (n *Network) Link(name string) (*Chaos, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c, ok := n.links[name]
+	return c, ok
+}
+
+// AddReplica joins a replica with an empty ledger, reaching the leader
+// through a link with faults
+func (n *Network) AddReplica(name string, faults Faults) (*transport.Follower, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c, err := n.link(name, faults)
+	if err != nil {
+		return nil, err
+	}
+	f := transport.NewFollower(c, ocp.NewLedger(), n.quorum)
+	n.replicas[name] = f
+	return f, nil
+}
+
+// AddAgent joins an agent reaching the leader through a link with faults
+func (n *Network) AddAgent(name string, faults Faults) (*Agent, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c, err := n.link(name, faults)
+	if err != nil {
+		return nil, err
+	}
+	return &Agent{Name: name, Client: transport.NewClient(c)}, nil
+}
+
+// Heal stops every link injecting faults
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, c := range n.links {
+		c.Heal()
+	}
+}
+
+// SyncReplicas runs one Sync on every replica, in name order. Transport
+// errors and divergences are left for Check to judge.
+func (n *Network) SyncReplicas(ctx context.Context) {
+	for _, r := range n.sortedReplicas() {
+		r.follower.Sync(ctx)
+	}
+}
+
+// Converge heals the network and syncs every replica that has not diverged
+// until it reaches the leader's head, making at most rounds attempts each
+func (n *Network) Converge(ctx context.Context, rounds int) {
+	n.Heal()
+	height := n.Leader.Ledger().Height()
+	for _, r := range n.sortedReplicas() {
+		f := r.follower
+		for i := 0; i < rounds && f.Diverged() == nil && f.Ledger().Height() < height; i++ {
+			f.Sync(ctx)
+		}
+	}
+}
+
+// namedReplica is a replica with its link
+type namedReplica struct {
+	name     string
+	follower *transport.Follower
+	link     *Chaos
+}
+
+// sortedReplicas returns the replicas in name order
+func (n *Network) sortedReplicas() []namedReplica {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]namedReplica, 0, len(n.replicas))
+	for name, f := range n.replicas {
+		out = append(out, namedReplica{name, f, n.links[name]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// ReplicaStatus is one replica's position after a scenario
+type ReplicaStatus struct {
+	Name     string
+	Height   uint64
+	Diverged *transport.Divergence
+	// Injected counts the faults its link injected, by kind
+	Injected map[string]int
+}
+
+// Report is the outcome of Check
+type Report struct {
+	Height   uint64
+	Head     string
+	Replicas []ReplicaStatus
+	// Violations describes every failed property
+	Violations []string
+}
+
+// OK reports whether every property held
+func (r *Report) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// Check judges the network against the safety, detection, and liveness
+// properties. Liveness is only meaningful after Converge.
+func (n *Network) Check() *Report {
+	leader := n.Leader.Ledger()
+	entries := leader.Entries(0)
+	r := &Report{Height: leader.Height(), Head: leader.Head()}
+	for _, replica := range n.sortedReplicas() {
+		name, f, c := replica.name, replica.follower, replica.link
+		status := ReplicaStatus{
+			Name:     name,
+			Height:   f.Ledger().Height(),
+			Diverged: f.Diverged(),
+			Injected: map[string]int{
+				FaultDrop:    c.Count(FaultDrop, ""),
+				FaultDelay:   c.Count(FaultDelay, ""),
+				FaultCorrupt: c.Count(FaultCorrupt, ""),
+			},
+		}
+		r.Replicas = append(r.Replicas, status)
+
+		for _, e := range f.Ledger().Entries(0) {
+			if e.Height > uint64(len(entries)) || entries[e.Height-1].Hash != e.Hash {
+				r.Violations = append(r.Violations, fmt.Sprintf("safety: %s holds entry %d that the leader did not write", name, e.Height))
+				break
+			}
+		}
+		switch {
+		case status.Diverged != nil && status.Injected[FaultCorrupt] == 0:
+			r.Violations = append(r.Violations, fmt.Sprintf("detection: %s diverged without corruption: %s", name, status.Diverged.Reason))
+		case status.Diverged == nil && status.Height != r.Height:
+			r.Violations = append(r.Violations, fmt.Sprintf("liveness: %s is at height %d, leader at %d", name, status.Height, r.Height))
+		}
+	}
+	return r
+}
+
+// Agent is a participant submitting proposals to the leader
+type Agent struct {
+	Name   string
+	Client *transport.Client
+}
+
+// Submit submits p and checks that the acceptance names it
+//
+// Returns:
+//   - An error wrapping ErrMismatch if the acceptance names another proposal,
+//     as it does when the submission or its reply was corrupted
+//   - ErrDropped, or the node's refusal, as returned by the link
+func (a *Agent) Submit(ctx context.Context, p *ocp.ContractProposal) (ocp.Acceptance, error) {
+	want, err := p.GetHash()
+	if err != nil {
+		return ocp.Acceptance{}, err
+	}
+	acceptance, err := a.Client.Submit(ctx, p)
+	if err != nil {
+		return ocp.Acceptance{}, err
+	}
+	if acceptance.ProposalHash != want {
+		return acceptance, fmt.Errorf("%w: submitted %s, accepted %s", ErrMismatch, want, acceptance.ProposalHash)
+	}
+	return acceptance, nil
+}
+
+// Confirm checks an acceptance against a replica's verified ledger, catching
+// a corrupted reply that still names the submitted proposal
+//
+// Returns:
+//   - An error wrapping ErrMismatch if the ledger does not record the
+//     acceptance
+func (a *Agent) Confirm(ledger *ocp.Ledger, acceptance ocp.Acceptance) error {
+	if acceptance.LedgerHeight == 0 {
+		return fmt.Errorf("%w: acceptance names no ledger height", ErrMismatch)
+	}
+	entries := ledger.Entries(acceptance.LedgerHeight - 1)
+	if len(entries) == 0 || entries[0].Height != acceptance.LedgerHeight {
+		return ocp.NewConstitutionalError(fmt.Sprintf("ledger does not hold height %d", acceptance.LedgerHeight))
+	}
+	e := entries[0]
+	if e.Hash != acceptance.EntryHash ||
+		e.Payload["proposal_hash"] != acceptance.ProposalHash ||
+		e.Payload["accepted_at"] != acceptance.AcceptedAt {
+		return fmt.Errorf("%w: entry %d does not record acceptance of %s", ErrMismatch, e.Height, acceptance.ProposalHash)
+	}
+	return nil
+}
+
+// Dropped reports whether err is a loss injected by a link
+func Dropped(err error) bool {
+	return errors.Is(err, ErrDropped)
+}
@@ -0,0 +1,196 @@
+package simnet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testQuorum returns a two-of-two quorum and its members' keys
+func testQuorum(t *testing.T) (*ocp.Quorum, map[string]ed25519.PrivateKey) {
+	t.Helper()
+	members := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range []string{"Claude", "Gemini"} {
+		seed := make([]byte, ed25519.SeedSize)
+		copy(seed, name)
+		privs[name] = ed25519.NewKeyFromSeed(seed)
+		members[name] = privs[name].Public().(ed25519.PublicKey)
+	}
+	quorum, err := ocp.NewQuorum(members, 2)
+	if err != nil {
+		t.Fatalf("Failed to create quorum: %v", err)
+	}
+	return quorum, privs
+}
+
+// recordPolicy appends a quorum-signed policy entry to the leader's ledger
+func recordPolicy(t *testing.T, n *Network, privs map[string]ed25519.PrivateKey, round int) {
+	t.Helper()
+	policies := map[string]interface{}{"max_stake": 100 + round}
+	hash, _ := ocp.SemanticHash(policies)
+	var sigs []ocp.Signature
+	for name, key := range privs {
+		sig, err := ocp.SignHash(name, key, ocp.ContextPolicy, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sigs = append(sigs, sig)
+	}
+	if _, err := ocp.RecordPolicy(n.Leader.Ledger(), n.quorum, policies, sigs); err != nil {
+		t.Fatalf("Failed to record policy: %v", err)
+	}
+}
+
+func scenarioProposal(agent string, n int) *ocp.ContractProposal {
+	return &ocp.ContractProposal{
+		ID:              fmt.Sprintf("%s-%d", agent, n),
+		ProposerAgent:   agent,
+		ActionType:      "amend",
+		Action:          map[string]interface{}{"target": "article-3", "round": n},
+		Timestamp:       "2025-11-20T14:30:00Z",
+		ReputationStake: 10,
+	}
+}
+
+// newScenario returns a network whose links draw their faults from seed
+func newScenario(t *testing.T, seed int64) (*Network, map[string]ed25519.PrivateKey) {
+	t.Helper()
+	quorum, privs := testQuorum(t)
+	n, err := NewNetwork(ocp.NewNode(ocp.NewLedger()), quorum, seed)
+	if err != nil {
+		t.Fatalf("Failed to start network: %v", err)
+	}
+	return n, privs
+}
+
+// TestScenarioLossAndDelay tests that lost and delayed messages never stop a
+// replica or let it diverge, and that replicas catch up once faults stop
+func TestScenarioLossAndDelay(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	n, privs := newScenario(t, 42)
+	for name, faults := range map[string]Faults{
+		"clean": {},
+		"lossy": {Drop: 0.4},
+		"slow":  {Delay: time.Millisecond, DelayRate: 0.5},
+	} {
+		if _, err := n.AddReplica(name, faults); err != nil {
+			t.Fatal(err)
+		}
+	}
+	agent, _ := n.AddAgent("Claude", Faults{Drop: 0.3})
+
+	var retries int
+	for i := 0; i < 20; i++ {
+		for {
+			_, err := agent.Submit(ctx, scenarioProposal("Claude", i))
+			if err == nil {
+				break
+			}
+			if !Dropped(err) {
+				t.Fatalf("Expected only losses, got %v", err)
+			}
+			retries++
+		}
+		if i%5 == 4 {
+			recordPolicy(t, n, privs, i)
+		}
+		n.SyncReplicas(ctx)
+	}
+	n.Converge(ctx, 50)
+
+	report := n.Check()
+	if !report.OK() {
+		t.Fatalf("Expected every property to hold: %v", report.Violations)
+	}
+	for _, r := range report.Replicas {
+		if r.Diverged != nil || r.Height != report.Height {
+			t.Errorf("Expected %s at height %d, got %+v", r.Name, report.Height, r)
+		}
+	}
+	lossy, _ := n.Link("lossy")
+	if lossy.Count(FaultDrop, "") == 0 || retries == 0 {
+		t.Error("Expected the scenario to lose messages")
+	}
+	t.Logf("✓ %d replicas at height %d after %d dropped messages and %d resubmissions",
+		len(report.Replicas), report.Height, lossy.Count(FaultDrop, ""), retries)
+}
+
+// TestScenarioCorruption tests that corrupted ledger pages are refused and
+// raise a divergence, and that agents detect corrupted submissions
+func TestScenarioCorruption(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	n, privs := newScenario(t, 7)
+	honest, _ := n.AddReplica("honest", Faults{})
+	n.AddReplica("corrupted", Faults{Corrupt: 0.3})
+	agent, _ := n.AddAgent("Claude", Faults{Corrupt: 0.2})
+	link, _ := n.Link("Claude")
+
+	type outcome struct {
+		acceptance ocp.Acceptance
+		corrupted  bool
+		detected   bool
+	}
+	var outcomes []outcome
+	for i := 0; i < 30; i++ {
+		before := link.Count(FaultCorrupt, "")
+		acceptance, err := agent.Submit(ctx, scenarioProposal("Claude", i))
+		outcomes = append(outcomes, outcome{acceptance, link.Count(FaultCorrupt, "") > before, err != nil})
+		if i%10 == 9 {
+			recordPolicy(t, n, privs, i)
+		}
+		n.SyncReplicas(ctx)
+	}
+	n.Converge(ctx, 10)
+
+	report := n.Check()
+	if !report.OK() {
+		t.Fatalf("Expected every property to hold: %v", report.Violations)
+	}
+	var corrupted ReplicaStatus
+	for _, r := range report.Replicas {
+		if r.Name == "corrupted" {
+			corrupted = r
+		}
+	}
+	if corrupted.Injected[FaultCorrupt] == 0 || corrupted.Diverged == nil {
+		t.Fatalf("Expected the corrupted replica to detect a corrupted page, got %+v", corrupted)
+	}
+
+	// Acceptances that passed Submit are confirmed against the honest
+	// replica, which holds the leader's full ledger
+	var corruptions, detected int
+	for i, o := range outcomes {
+		if !o.detected {
+			err := agent.Confirm(honest.Ledger(), o.acceptance)
+			if err != nil && !errors.Is(err, ErrMismatch) {
+				t.Fatalf("Unexpected confirmation error: %v", err)
+			}
+			o.detected = err != nil
+		}
+		if o.corrupted {
+			corruptions++
+		}
+		switch {
+		case o.corrupted && o.detected:
+			detected++
+		case o.corrupted:
+			t.Errorf("Submission %d was corrupted without detection", i)
+		case o.detected:
+			t.Errorf("Submission %d was flagged without corruption", i)
+		}
+	}
+	if corruptions == 0 {
+		t.Fatal("Expected the agent's link to corrupt some submissions")
+	}
+	t.Logf("✓ Replica stopped at height %d (%s); agent detected %d of %d corrupted submissions",
+		corrupted.Height, corrupted.Diverged.Reason, detected, corruptions)
+}
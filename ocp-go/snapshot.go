@@ -0,0 +1,151 @@
+// snapshot.go - Quorum-signed state snapshots and delta sync
+//
+// A new node does not need to replay constitutional history from genesis. It can
+// instead accept a snapshot signed by the quorum, then verify only the ledger
+// entries appended after the snapshot's height.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Snapshot commits to the constitutional state at a given ledger height
+type Snapshot struct {
+	Height     uint64                 `json:"height"`
+	HeadHash   string                 `json:"head_hash"`
+	StateHash  string                 `json:"state_hash"`
+	State      map[string]interface{} `json:"state"`
+	Signatures []Signature            `json:"signatures"`
+}
+
+// NewSnapshot creates an unsigned snapshot of state at the given ledger position
+func NewSnapshot(height uint64, headHash string, state map[string]interface{}) (*Snapshot, error) {
+	stateHash, err := SemanticHash(state)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Height:    height,
+		HeadHash:  headHash,
+		StateHash: stateHash,
+		State:     state,
+	}, nil
+}
+
+// ToMap converts a Snapshot to a map for canonicalization.
+// State is represented by its hash and signatures are excluded, so signers
+// commit to the position and state without signing each other's signatures.
+func (s *Snapshot) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"height":     s.Height,
+		"head_hash":  s.HeadHash,
+		"state_hash": s.StateHash,
+	}
+}
+
+// Hash returns the semantic hash identifying this snapshot
+func (s *Snapshot) Hash() (string, error) {
+	return SemanticHash(s.ToMap())
+}
+
+// Sign adds a signature from signer over the snapshot hash
+func (s *Snapshot) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextSnapshot, hash)
+	if err != nil {
+		return err
+	}
+	s.Signatures = append(s.Signatures, sig)
+	return nil
+}
+
+// SignedBy verifies that the snapshot's state matches its state hash and that
+// the snapshot carries enough valid signatures from quorum members.
+//
+// Returns:
+//   - nil if the snapshot can be trusted as a bootstrap point
+func (s *Snapshot) SignedBy(quorum *Quorum) error {
+	valid, err := VerifySemanticHash(s.State, s.StateHash)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return NewVerificationError("snapshot state does not match state hash")
+	}
+
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	_, err = quorum.Verify(ContextSnapshot, hash, s.Signatures)
+	return err
+}
+
+// VerifyDelta verifies that delta continues the ledger exactly from this snapshot.
+//
+// Returns:
+//   - Head hash after applying the delta
+func (s *Snapshot) VerifyDelta(delta []LedgerEntry) (string, error) {
+	return VerifyChain(s.Height, s.HeadHash, delta)
+}
+
+// Snapshot captures state at the ledger's current head and registers it so
+// that later DeltaSince calls can locate it by hash.
+func (l *Ledger) Snapshot(state map[string]interface{}) (*Snapshot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	height, head := l.headLocked()
+	snap, err := NewSnapshot(height, head, state)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := snap.Hash()
+	if err != nil {
+		return nil, err
+	}
+	l.snapshots[hash] = height
+	return snap, nil
+}
+
+// DeltaSince returns all entries appended after the snapshot with the given hash
+func (l *Ledger) DeltaSince(snapshotHash string) ([]LedgerEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	height, ok := l.snapshots[snapshotHash]
+	if !ok {
+		return nil, NewConstitutionalError(fmt.Sprintf("unknown snapshot %s", snapshotHash))
+	}
+	if height < l.baseHeight {
+		return nil, NewConstitutionalError(fmt.Sprintf("snapshot at height %d predates this ledger", height))
+	}
+	return l.entriesAfterLocked(height), nil
+}
+
+// BootstrapLedger creates a ledger from a quorum-signed snapshot and the delta
+// entries appended since, verifying both before accepting either.
+func BootstrapLedger(snap *Snapshot, quorum *Quorum, delta []LedgerEntry) (*Ledger, error) {
+	if err := snap.SignedBy(quorum); err != nil {
+		return nil, err
+	}
+	if _, err := snap.VerifyDelta(delta); err != nil {
+		return nil, err
+	}
+	hash, err := snap.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewLedger()
+	l.baseHeight = snap.Height
+	l.baseHash = snap.HeadHash
+	l.entries = append(l.entries, delta...)
+	l.snapshots[hash] = snap.Height
+	return l, nil
+}
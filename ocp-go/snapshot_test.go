@@ -0,0 +1,128 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// testQuorum builds a 2-of-3 quorum with deterministic keys
+func testQuorum(t *testing.T) (*Quorum, map[string]ed25519.PrivateKey) {
+	members := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range []string{"Claude", "Gemini", "DeepSeek"} {
+		members[name], privs[name] = testKey(name)
+	}
+	quorum, err := NewQuorum(members, 2)
+	if err != nil {
+		t.Fatalf("Failed to create quorum: %v", err)
+	}
+	return quorum, privs
+}
+
+// TestSnapshotSignedBy tests quorum verification of snapshots
+func TestSnapshotSignedBy(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+
+	snap, err := NewSnapshot(10, "abc", map[string]interface{}{"articles": float64(12)})
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	if err := snap.Sign("Claude", privs["Claude"]); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if err := snap.SignedBy(quorum); err == nil {
+		t.Errorf("One signature should not satisfy a 2-of-3 quorum")
+	}
+
+	if err := snap.Sign("DeepSeek", privs["DeepSeek"]); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if err := snap.SignedBy(quorum); err != nil {
+		t.Errorf("Two signatures should satisfy quorum: %v", err)
+	}
+
+	snap.State["articles"] = float64(13)
+	if err := snap.SignedBy(quorum); err == nil {
+		t.Errorf("Modified state should fail verification")
+	}
+
+	t.Logf("✓ Snapshot quorum verification works")
+}
+
+// TestDeltaSyncBootstrap tests bootstrapping a node from a snapshot plus delta
+func TestDeltaSyncBootstrap(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	source := NewLedger()
+
+	for i := 0; i < 5; i++ {
+		if _, err := source.Append("proposal", map[string]interface{}{"id": fmt.Sprintf("p-%d", i)}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	snap, err := source.Snapshot(map[string]interface{}{"ratified": float64(5)})
+	if err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+	for _, name := range []string{"Claude", "Gemini"} {
+		if err := snap.Sign(name, privs[name]); err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+	}
+
+	for i := 5; i < 8; i++ {
+		if _, err := source.Append("proposal", map[string]interface{}{"id": fmt.Sprintf("p-%d", i)}); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	snapHash, err := snap.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash snapshot: %v", err)
+	}
+	delta, err := source.DeltaSince(snapHash)
+	if err != nil {
+		t.Fatalf("Failed to get delta: %v", err)
+	}
+	if len(delta) != 3 {
+		t.Fatalf("Expected 3 delta entries, got %d", len(delta))
+	}
+
+	replica, err := BootstrapLedger(snap, quorum, delta)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %v", err)
+	}
+	if replica.Head() != source.Head() || replica.Height() != source.Height() {
+		t.Errorf("Replica head should match source")
+	}
+	if err := replica.Verify(); err != nil {
+		t.Errorf("Replica should verify: %v", err)
+	}
+
+	// Replica continues the chain from where the source left off
+	next, err := replica.Append("proposal", map[string]interface{}{"id": "p-8"})
+	if err != nil {
+		t.Fatalf("Failed to append to replica: %v", err)
+	}
+	if next.Height != 9 {
+		t.Errorf("Replica should continue at height 9, got %d", next.Height)
+	}
+
+	// A delta with a tampered entry must be rejected
+	delta[1].Kind = "forged"
+	if _, err := BootstrapLedger(snap, quorum, delta); err == nil {
+		t.Errorf("Tampered delta should be rejected")
+	}
+
+	if _, err := source.DeltaSince("unknown"); err == nil {
+		t.Errorf("Unknown snapshot hash should be rejected")
+	}
+
+	t.Logf("✓ Bootstrapped from snapshot at height %d with %d delta entries", snap.Height, 3)
+}
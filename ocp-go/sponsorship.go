@@ -0,0 +1,201 @@
+// sponsorship.go - Co-sponsorship of proposals with aggregated stake
+//
+// A proposal that needs more stake than its proposer holds, or that policy
+// wants backed by several agents, can be co-sponsored. Each sponsor signs a
+// Sponsorship naming the proposal's hash and the stake it contributes, so a
+// contribution cannot be moved to another proposal or inflated after signing.
+// Escrow.Sponsor checks the sponsorships against the policy table and records
+// the aggregate on the ledger, where it weights the bonds of any challenge.
+//
+// The thresholds are set by the "sponsorship" entry of the policy table:
+//
+//	{"sponsorship": {
+//	    "min_total_stake": 100,
+//	    "min_sponsors": 2,
+//	    "max_share_percent": 60}}
+//
+// Sponsors count the agents other than the proposer, and no agent, the
+// proposer included, may contribute more than max_share_percent of the total.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+)
+
+// SponsorshipPolicyKey is the policy table entry configuring sponsorship
+const SponsorshipPolicyKey = "sponsorship"
+
+// ErrInsufficientSponsorship is returned when sponsorships do not meet the
+// policy's thresholds
+var ErrInsufficientSponsorship = &ConstitutionalError{ErrorType: "SponsorshipError", Message: "proposal sponsorship does not meet policy thresholds"}
+
+// SponsorshipPolicy sets the stake a sponsored proposal must gather
+type SponsorshipPolicy struct {
+	// MinTotalStake is the least aggregated stake, proposer's included
+	MinTotalStake int
+	// MinSponsors is the least number of sponsors besides the proposer
+	MinSponsors int
+	// MaxSharePercent caps any one agent's share of the total; zero means uncapped
+	MaxSharePercent int
+}
+
+// SponsorshipPolicyFromTable reads the sponsorship policy from a policy
+// table. A table without the entry sets no thresholds.
+func SponsorshipPolicyFromTable(policies map[string]interface{}) SponsorshipPolicy {
+	entry, _ := policies[SponsorshipPolicyKey].(map[string]interface{})
+	return SponsorshipPolicy{
+		MinTotalStake:   payloadInt(entry, "min_total_stake"),
+		MinSponsors:     payloadInt(entry, "min_sponsors"),
+		MaxSharePercent: payloadInt(entry, "max_share_percent"),
+	}
+}
+
+// Sponsorship is one agent's signed stake contribution to a proposal
+type Sponsorship struct {
+	Agent        string    `json:"agent"`
+	ProposalHash string    `json:"proposal_hash"`
+	Stake        int       `json:"stake"`
+	Signature    Signature `json:"signature"`
+}
+
+// ToMap converts a Sponsorship to a map for canonicalization. The signature
+// is excluded, since it signs this form.
+func (s *Sponsorship) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"agent":         s.Agent,
+		"proposal_hash": s.ProposalHash,
+		"stake":         s.Stake,
+	}
+}
+
+// Hash returns the semantic hash of the sponsorship
+func (s *Sponsorship) Hash() (string, error) {
+	return SemanticHash(s.ToMap())
+}
+
+// Sign signs the sponsorship as its agent
+func (s *Sponsorship) Sign(key ed25519.PrivateKey) error {
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	s.Signature, err = SignHash(s.Agent, key, ContextSponsorship, hash)
+	return err
+}
+
+// Verify checks the sponsorship's signature against the agent's key
+func (s *Sponsorship) Verify(key ed25519.PublicKey) error {
+	if s.Signature.Signer != s.Agent {
+		return NewVerificationError(fmt.Sprintf("sponsorship by %s signed by %s", s.Agent, s.Signature.Signer))
+	}
+	hash, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	return VerifyHashSignature(key, ContextSponsorship, hash, s.Signature)
+}
+
+// AggregateStake is the combined stake behind a sponsored proposal
+type AggregateStake struct {
+	ProposalHash string
+	Proposer     string
+	Total        int
+	// Contributions maps each agent, the proposer included, to its stake
+	Contributions map[string]int
+}
+
+// Sponsors returns the agents other than the proposer, sorted
+func (a *AggregateStake) Sponsors() []string {
+	var out []string
+	for agent := range a.Contributions {
+		if agent != a.Proposer {
+			out = append(out, agent)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// AggregateSponsorships verifies sponsorships of p and combines them with
+// the proposer's own stake.
+//
+// Parameters:
+//   - p: The sponsored proposal
+//   - sponsorships: One signed sponsorship per co-sponsor
+//   - keys: Public keys of the agents that may sponsor
+//
+// Returns:
+//   - The aggregated stake
+//   - VerificationError if a sponsor is unknown or a signature is invalid
+//   - ConstitutionalError if a sponsorship names another proposal, stakes
+//     nothing, or repeats an agent or the proposer
+func AggregateSponsorships(p *ContractProposal, sponsorships []Sponsorship, keys map[string]ed25519.PublicKey) (*AggregateStake, error) {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	agg := &AggregateStake{
+		ProposalHash:  proposalHash,
+		Proposer:      p.ProposerAgent,
+		Total:         p.ReputationStake,
+		Contributions: map[string]int{p.ProposerAgent: p.ReputationStake},
+	}
+	for i := range sponsorships {
+		s := &sponsorships[i]
+		if s.ProposalHash != proposalHash {
+			return nil, NewConstitutionalError(fmt.Sprintf("sponsorship by %s names proposal %s, not %s", s.Agent, s.ProposalHash, proposalHash))
+		}
+		if s.Stake <= 0 {
+			return nil, NewConstitutionalError(fmt.Sprintf("sponsorship by %s stakes %d", s.Agent, s.Stake))
+		}
+		if _, ok := agg.Contributions[s.Agent]; ok {
+			return nil, NewConstitutionalError(fmt.Sprintf("agent %s already stakes on proposal %s", s.Agent, proposalHash))
+		}
+		key, ok := keys[s.Agent]
+		if !ok {
+			return nil, NewVerificationError(fmt.Sprintf("no key for sponsor %s", s.Agent))
+		}
+		if err := s.Verify(key); err != nil {
+			return nil, err
+		}
+		agg.Contributions[s.Agent] = s.Stake
+		agg.Total += s.Stake
+	}
+	return agg, nil
+}
+
+// Check reports whether the aggregate meets policy
+//
+// Returns:
+//   - nil, or an error wrapping ErrInsufficientSponsorship naming the threshold
+func (sp SponsorshipPolicy) Check(agg *AggregateStake) error {
+	if agg.Total < sp.MinTotalStake {
+		return fmt.Errorf("total stake %d below %d: %w", agg.Total, sp.MinTotalStake, ErrInsufficientSponsorship)
+	}
+	if n := len(agg.Sponsors()); n < sp.MinSponsors {
+		return fmt.Errorf("%d sponsors, policy requires %d: %w", n, sp.MinSponsors, ErrInsufficientSponsorship)
+	}
+	if sp.MaxSharePercent > 0 {
+		for _, agent := range append([]string{agg.Proposer}, agg.Sponsors()...) {
+			if stake := agg.Contributions[agent]; stake*100 > agg.Total*sp.MaxSharePercent {
+				return fmt.Errorf("agent %s contributes %d of %d, above %d%%: %w",
+					agent, stake, agg.Total, sp.MaxSharePercent, ErrInsufficientSponsorship)
+			}
+		}
+	}
+	return nil
+}
+
+// SponsoredStake returns the aggregated stake recorded for a proposal among
+// the given ledger entries, if it was sponsored
+func SponsoredStake(entries []LedgerEntry, proposalHash string) (int, bool) {
+	for _, e := range entries {
+		if e.Kind == LedgerKindSponsorship && payloadString(e.Payload, "proposal_hash") == proposalHash {
+			return payloadInt(e.Payload, "total_stake"), true
+		}
+	}
+	return 0, false
+}
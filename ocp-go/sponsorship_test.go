@@ -0,0 +1,149 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// sponsorKeys returns keys for the given agents
+func sponsorKeys(names ...string) (map[string]ed25519.PublicKey, map[string]ed25519.PrivateKey) {
+	pubs := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range names {
+		pubs[name], privs[name] = testKey(name)
+	}
+	return pubs, privs
+}
+
+func testSponsorship(t *testing.T, p *ContractProposal, agent string, stake int, key ed25519.PrivateKey) Sponsorship {
+	t.Helper()
+	hash, _ := p.GetHash()
+	s := Sponsorship{Agent: agent, ProposalHash: hash, Stake: stake}
+	if err := s.Sign(key); err != nil {
+		t.Fatalf("Failed to sign sponsorship: %v", err)
+	}
+	return s
+}
+
+// TestAggregateSponsorships tests verification and aggregation of sponsors
+func TestAggregateSponsorships(t *testing.T) {
+	testenv.RequireSigning(t)
+	pubs, privs := sponsorKeys("Gemini", "DeepSeek")
+	p := testProposal()
+	p.ReputationStake = 20
+
+	agg, err := AggregateSponsorships(p, []Sponsorship{
+		testSponsorship(t, p, "Gemini", 30, privs["Gemini"]),
+		testSponsorship(t, p, "DeepSeek", 50, privs["DeepSeek"]),
+	}, pubs)
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	if agg.Total != 100 || agg.Contributions[p.ProposerAgent] != 20 || len(agg.Sponsors()) != 2 {
+		t.Errorf("Unexpected aggregate %+v", agg)
+	}
+
+	inflated := testSponsorship(t, p, "Gemini", 30, privs["Gemini"])
+	inflated.Stake = 300
+	other := testProposal()
+	other.ID = "other"
+	cases := map[string][]Sponsorship{
+		"inflated stake":   {inflated},
+		"other proposal":   {testSponsorship(t, other, "Gemini", 30, privs["Gemini"])},
+		"repeated sponsor": {testSponsorship(t, p, "Gemini", 10, privs["Gemini"]), testSponsorship(t, p, "Gemini", 10, privs["Gemini"])},
+		"zero stake":       {testSponsorship(t, p, "Gemini", 0, privs["Gemini"])},
+		"unknown sponsor":  {testSponsorship(t, p, "Mallory", 10, privs["Gemini"])},
+	}
+	for name, sponsorships := range cases {
+		if _, err := AggregateSponsorships(p, sponsorships, pubs); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+
+	t.Logf("✓ %d sponsors aggregated to stake %d", len(agg.Sponsors()), agg.Total)
+}
+
+// TestSponsorshipPolicy tests the policy thresholds on an aggregate
+func TestSponsorshipPolicy(t *testing.T) {
+	policy := SponsorshipPolicyFromTable(map[string]interface{}{
+		SponsorshipPolicyKey: map[string]interface{}{"min_total_stake": 100, "min_sponsors": 2, "max_share_percent": 50},
+	})
+	agg := func(contributions map[string]int) *AggregateStake {
+		a := &AggregateStake{Proposer: "Claude", Contributions: contributions}
+		for _, stake := range contributions {
+			a.Total += stake
+		}
+		return a
+	}
+
+	cases := []struct {
+		contributions map[string]int
+		ok            bool
+	}{
+		{map[string]int{"Claude": 40, "Gemini": 30, "DeepSeek": 30}, true},
+		{map[string]int{"Claude": 40, "Gemini": 30, "DeepSeek": 20}, false},
+		{map[string]int{"Claude": 50, "Gemini": 50}, false},
+		{map[string]int{"Claude": 10, "Gemini": 80, "DeepSeek": 10}, false},
+	}
+	for _, tc := range cases {
+		err := policy.Check(agg(tc.contributions))
+		if tc.ok && err != nil {
+			t.Errorf("Expected %v to meet policy, got %v", tc.contributions, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInsufficientSponsorship) {
+			t.Errorf("Expected %v to fall short, got %v", tc.contributions, err)
+		}
+	}
+	if err := SponsorshipPolicyFromTable(nil).Check(agg(map[string]int{"Claude": 1})); err != nil {
+		t.Errorf("Expected no thresholds without a policy entry, got %v", err)
+	}
+
+	t.Log("✓ Sponsorship thresholds enforced")
+}
+
+// TestEscrowSponsor tests that recorded sponsorships weight challenge bonds
+func TestEscrowSponsor(t *testing.T) {
+	testenv.RequireSigning(t)
+	pubs, privs := sponsorKeys("Gemini", "DeepSeek")
+	ledger := NewLedger()
+	escrow := NewEscrow(ledger, DefaultBondingCurve)
+	p := testProposal()
+	p.ReputationStake = 20
+	policy := SponsorshipPolicy{MinTotalStake: 100, MinSponsors: 2}
+
+	if _, err := escrow.Sponsor(p, []Sponsorship{testSponsorship(t, p, "Gemini", 30, privs["Gemini"])}, pubs, policy); !errors.Is(err, ErrInsufficientSponsorship) {
+		t.Errorf("Expected a single sponsor to fall short, got %v", err)
+	}
+	sponsorships := []Sponsorship{
+		testSponsorship(t, p, "Gemini", 30, privs["Gemini"]),
+		testSponsorship(t, p, "DeepSeek", 50, privs["DeepSeek"]),
+	}
+	if _, err := escrow.Sponsor(p, sponsorships, pubs, policy); err != nil {
+		t.Fatalf("Failed to sponsor: %v", err)
+	}
+	if _, err := escrow.Sponsor(p, sponsorships, pubs, policy); err == nil {
+		t.Errorf("Expected a proposal to be sponsored only once")
+	}
+
+	if required := escrow.RequiredBond("Mallory", p); required != 50 {
+		t.Errorf("Expected bond weighted by aggregate stake 100, got %d", required)
+	}
+	if _, err := escrow.Lock("Mallory", p, 50); err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	if err := VerifyBonds(ledger.Entries(0), DefaultBondingCurve); err != nil {
+		t.Errorf("Expected bonds to verify, got %v", err)
+	}
+
+	// A bond that records only the proposer's stake is caught on replay
+	entries := ledger.Entries(0)
+	entries[1].Payload = map[string]interface{}{"proposal_hash": entries[0].Payload["proposal_hash"], "stake": 20, "bond": 10, "required": 10}
+	if err := VerifyBonds(entries, DefaultBondingCurve); err == nil {
+		t.Errorf("Expected an unweighted bond to be refused")
+	}
+
+	t.Log("✓ Sponsored stake recorded and weights challenge bonds")
+}
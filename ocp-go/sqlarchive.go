@@ -0,0 +1,158 @@
+// sqlarchive.go - Archive backed by a SQL database
+//
+// Operators who must keep evidence in a managed SQL database rather than
+// object storage can use SQLArchive. It speaks PostgreSQL through the standard
+// database/sql package; the caller opens the *sql.DB with whichever Postgres
+// driver it already uses, so the core module gains no dependency. Each blob is
+// stored under its content hash, which for canonical objects is their semantic
+// hash. Because the database is outside the node's control, reads re-hash the
+// blob before returning it, and an IntegrityScan walks the whole table in
+// batches to find rows that no longer match their key.
+
+package ocp
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultArchiveTable is the table SQLArchive uses when none is given
+const DefaultArchiveTable = "ocp_archive"
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLArchive is an Archive storing blobs in a PostgreSQL table
+type SQLArchive struct {
+	db    *sql.DB
+	table string
+	// VerifyOnRead re-hashes every blob returned by Get; enabled by default
+	VerifyOnRead bool
+}
+
+// NewSQLArchive opens an archive in table, creating the table if needed
+//
+// Parameters:
+//   - db: Open PostgreSQL connection pool
+//   - table: Table name; DefaultArchiveTable if empty
+//
+// Returns:
+//   - Archive with VerifyOnRead enabled
+func NewSQLArchive(db *sql.DB, table string) (*SQLArchive, error) {
+	if table == "" {
+		table = DefaultArchiveTable
+	}
+	if !sqlIdentifier.MatchString(table) {
+		return nil, NewConstitutionalError(fmt.Sprintf("invalid archive table name %q", table))
+	}
+	if _, err := db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (hash TEXT PRIMARY KEY, data BYTEA NOT NULL, size BIGINT NOT NULL, stored_at TIMESTAMPTZ NOT NULL DEFAULT now())",
+		table)); err != nil {
+		return nil, err
+	}
+	return &SQLArchive{db: db, table: table, VerifyOnRead: true}, nil
+}
+
+// Put stores data under its content hash. Storing the same blob again is a no-op.
+func (s *SQLArchive) Put(data []byte) (string, error) {
+	hash := ContentHash(data)
+	_, err := s.db.Exec(fmt.Sprintf(
+		"INSERT INTO %s (hash, data, size) VALUES ($1, $2, $3) ON CONFLICT (hash) DO NOTHING", s.table),
+		hash, data, int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Get returns the blob stored under hash, checking it against its key when
+// VerifyOnRead is set
+func (s *SQLArchive) Get(hash string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(fmt.Sprintf("SELECT data FROM %s WHERE hash = $1", s.table), hash).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotArchived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.VerifyOnRead && ContentHash(data) != hash {
+		return nil, NewConstitutionalError(fmt.Sprintf("archived blob %s is corrupted", hash))
+	}
+	return data, nil
+}
+
+// Has reports whether hash is stored
+func (s *SQLArchive) Has(hash string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s WHERE hash = $1", s.table), hash).Scan(&n)
+	return n > 0, err
+}
+
+// IntegrityReport summarizes an integrity scan
+type IntegrityReport struct {
+	// Scanned is the number of rows read
+	Scanned int
+	// Corrupted lists the keys of rows whose data does not hash to their key
+	Corrupted []string
+	// Last is the last key scanned; pass it as after to continue a scan
+	Last string
+}
+
+// IntegrityScan re-hashes every blob in an SQLArchive in key order
+type IntegrityScan struct {
+	Archive *SQLArchive
+	// BatchSize is the number of rows read per query; defaults to 500
+	BatchSize int
+	// OnCorrupt, if set, is called for each corrupted row as it is found
+	OnCorrupt func(hash string)
+}
+
+// Run scans rows with keys greater than after, "" for the whole table
+//
+// Returns:
+//   - Report of the rows scanned, complete up to Last even on error
+func (j *IntegrityScan) Run(after string) (*IntegrityReport, error) {
+	batch := j.BatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	report := &IntegrityReport{Last: after}
+	for {
+		n, err := j.scanBatch(report, batch)
+		if err != nil || n < batch {
+			return report, err
+		}
+	}
+}
+
+// scanBatch scans the next batch of rows after report.Last
+func (j *IntegrityScan) scanBatch(report *IntegrityReport, batch int) (int, error) {
+	rows, err := j.Archive.db.Query(fmt.Sprintf(
+		"SELECT hash, data FROM %s WHERE hash > $1 ORDER BY hash LIMIT $2", j.Archive.table),
+		report.Last, batch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var hash string
+		var data []byte
+		if err := rows.Scan(&hash, &data); err != nil {
+			return n, err
+		}
+		n++
+		report.Scanned++
+		report.Last = hash
+		if ContentHash(data) != hash {
+			report.Corrupted = append(report.Corrupted, hash)
+			if j.OnCorrupt != nil {
+				j.OnCorrupt(hash)
+			}
+		}
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,285 @@
+// tenant.go - Multi-tenant node mode
+//
+// A hosted verification service can serve several independent constitutions
+// from one process. Each tenant has its own ledger, intake Node, and archive,
+// so no proposal, ledger entry, or archived blob is ever visible across
+// tenants, and per-tenant quotas and submission rate limits keep one tenant
+// from exhausting shared capacity. Tenant state roots bind the tenant ID to its
+// ledger head, so two tenants with identical histories still have distinct
+// roots.
+
+package ocp
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Errors returned when a tenant exceeds its limits
+var (
+	ErrQuotaExceeded = &ConstitutionalError{ErrorType: "QuotaError", Message: "tenant quota exceeded"}
+	ErrRateLimited   = &ConstitutionalError{ErrorType: "QuotaError", Message: "tenant submission rate exceeded"}
+)
+
+// tenantIDPattern keeps tenant IDs safe to use in keys and file paths
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// TenantQuota limits a tenant's use of a shared node. Zero values mean unlimited.
+type TenantQuota struct {
+	// MaxProposals bounds the number of accepted proposals
+	MaxProposals int
+	// MaxArchiveBytes bounds the total size of archived blobs
+	MaxArchiveBytes int64
+	// SubmissionsPerMinute and Burst configure a token bucket for new submissions
+	SubmissionsPerMinute int
+	Burst                int
+}
+
+// ArchiveFactory opens the archive for a tenant
+type ArchiveFactory func(tenantID string) (Archive, error)
+
+// MemoryArchiveFactory gives each tenant its own MemoryArchive
+func MemoryArchiveFactory(string) (Archive, error) {
+	return NewMemoryArchive(), nil
+}
+
+// FileArchiveFactory stores each tenant's blobs in root/<tenant ID>
+func FileArchiveFactory(root string) ArchiveFactory {
+	return func(tenantID string) (Archive, error) {
+		return NewFileArchive(filepath.Join(root, tenantID))
+	}
+}
+
+// Tenant is one constitution served by a MultiTenantNode
+type Tenant struct {
+	ID      string
+	Quota   TenantQuota
+	node    *Node
+	archive *quotaArchive
+
+	// mu serializes submissions so quota checks and acceptance are atomic
+	mu     sync.Mutex
+	tokens float64
+	refill time.Time
+	clock  Clock
+}
+
+// Node returns the tenant's intake node
+func (t *Tenant) Node() *Node {
+	return t.node
+}
+
+// Ledger returns the tenant's ledger
+func (t *Tenant) Ledger() *Ledger {
+	return t.node.Ledger()
+}
+
+// Archive returns the tenant's archive; writes count against MaxArchiveBytes
+func (t *Tenant) Archive() Archive {
+	return t.archive
+}
+
+// StateRoot returns the semantic hash binding the tenant ID to its ledger head
+func (t *Tenant) StateRoot() (string, error) {
+	ledger := t.Ledger()
+	return SemanticHash(map[string]interface{}{
+		"tenant":        t.ID,
+		"ledger_height": ledger.Height(),
+		"ledger_head":   ledger.Head(),
+	})
+}
+
+// takeLocked consumes one submission token, refilling the bucket from the clock
+func (t *Tenant) takeLocked() bool {
+	if t.Quota.SubmissionsPerMinute <= 0 {
+		return true
+	}
+
+	burst := float64(t.Quota.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	now := clockOrSystem(t.clock).Now()
+	if t.refill.IsZero() {
+		t.tokens = burst
+	} else if elapsed := now.Sub(t.refill); elapsed > 0 {
+		t.tokens += elapsed.Minutes() * float64(t.Quota.SubmissionsPerMinute)
+		if t.tokens > burst {
+			t.tokens = burst
+		}
+	}
+	t.refill = now
+
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// MultiTenantNode hosts independent tenants in one process
+type MultiTenantNode struct {
+	mu         sync.RWMutex
+	tenants    map[string]*Tenant
+	newArchive ArchiveFactory
+	// Clock drives rate limiting and acceptance timestamps; defaults to SystemClock
+	Clock Clock
+}
+
+// NewMultiTenantNode creates a node whose tenants get archives from factory
+func NewMultiTenantNode(factory ArchiveFactory) *MultiTenantNode {
+	if factory == nil {
+		factory = MemoryArchiveFactory
+	}
+	return &MultiTenantNode{
+		tenants:    make(map[string]*Tenant),
+		newArchive: factory,
+	}
+}
+
+// AddTenant registers a tenant with an empty ledger
+func (m *MultiTenantNode) AddTenant(id string, quota TenantQuota) (*Tenant, error) {
+	return m.AddTenantWithLedger(id, quota, NewLedger())
+}
+
+// AddTenantWithLedger registers a tenant backed by an existing ledger, such
+// as one created by NewLedgerFromGenesis or restored from a snapshot
+func (m *MultiTenantNode) AddTenantWithLedger(id string, quota TenantQuota, ledger *Ledger) (*Tenant, error) {
+	if !tenantIDPattern.MatchString(id) {
+		return nil, NewConstitutionalError(fmt.Sprintf("invalid tenant ID %q", id))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tenants[id]; ok {
+		return nil, NewConstitutionalError(fmt.Sprintf("tenant %q already exists", id))
+	}
+
+	archive, err := m.newArchive(id)
+	if err != nil {
+		return nil, err
+	}
+	node := NewNode(ledger)
+	node.Clock = m.Clock
+	t := &Tenant{
+		ID:      id,
+		Quota:   quota,
+		node:    node,
+		archive: &quotaArchive{inner: archive, limit: quota.MaxArchiveBytes},
+		clock:   m.Clock,
+	}
+	m.tenants[id] = t
+	return t, nil
+}
+
+// Tenant returns a registered tenant
+func (m *MultiTenantNode) Tenant(id string) (*Tenant, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.tenants[id]
+	return t, ok
+}
+
+// Tenants returns the registered tenant IDs in sorted order
+func (m *MultiTenantNode) Tenants() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0, len(m.tenants))
+	for id := range m.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Submit accepts a proposal into a tenant's ledger. Resubmitting an accepted
+// proposal returns its Acceptance without consuming quota or rate.
+//
+// Returns:
+//   - Acceptance record from the tenant's node
+//   - ErrRateLimited or ErrQuotaExceeded if the tenant is over its limits
+func (m *MultiTenantNode) Submit(tenantID string, p *ContractProposal) (Acceptance, error) {
+	t, ok := m.Tenant(tenantID)
+	if !ok {
+		return Acceptance{}, NewConstitutionalError(fmt.Sprintf("unknown tenant %q", tenantID))
+	}
+
+	hash, err := p.GetHash()
+	if err != nil {
+		return Acceptance{}, err
+	}
+	if existing, ok := t.node.Accepted(hash); ok {
+		return existing, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Quota.MaxProposals > 0 && t.node.AcceptedCount() >= t.Quota.MaxProposals {
+		return Acceptance{}, ErrQuotaExceeded
+	}
+	if !t.takeLocked() {
+		return Acceptance{}, ErrRateLimited
+	}
+	return t.node.Submit(p)
+}
+
+// StateRoots returns every tenant's state root and a combined root over all of
+// them, ordered by tenant ID
+func (m *MultiTenantNode) StateRoots() (map[string]string, string, error) {
+	roots := make(map[string]string)
+	combined := make(map[string]interface{})
+	for _, id := range m.Tenants() {
+		t, _ := m.Tenant(id)
+		root, err := t.StateRoot()
+		if err != nil {
+			return nil, "", err
+		}
+		roots[id] = root
+		combined[id] = root
+	}
+	hash, err := SemanticHash(combined)
+	if err != nil {
+		return nil, "", err
+	}
+	return roots, hash, nil
+}
+
+// quotaArchive enforces a byte limit on new blobs written to an archive
+type quotaArchive struct {
+	mu    sync.Mutex
+	inner Archive
+	limit int64
+	used  int64
+}
+
+// Put stores data unless it is new and would exceed the limit
+func (q *quotaArchive) Put(data []byte) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	exists, err := q.inner.Has(ContentHash(data))
+	if err != nil {
+		return "", err
+	}
+	if !exists && q.limit > 0 && q.used+int64(len(data)) > q.limit {
+		return "", ErrQuotaExceeded
+	}
+	hash, err := q.inner.Put(data)
+	if err == nil && !exists {
+		q.used += int64(len(data))
+	}
+	return hash, err
+}
+
+// Get returns data from the underlying archive
+func (q *quotaArchive) Get(hash string) ([]byte, error) {
+	return q.inner.Get(hash)
+}
+
+// Has reports whether hash is stored in the underlying archive
+func (q *quotaArchive) Has(hash string) (bool, error) {
+	return q.inner.Has(hash)
+}
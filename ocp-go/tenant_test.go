@@ -0,0 +1,107 @@
+package ocp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// tenantProposal returns a distinct test proposal
+func tenantProposal(id string) *ContractProposal {
+	p := testProposal()
+	p.ID = id
+	return p
+}
+
+// TestMultiTenantIsolation tests that tenants have independent ledgers and archives
+func TestMultiTenantIsolation(t *testing.T) {
+	testenv.RequireSigning(t)
+	root := t.TempDir()
+	m := NewMultiTenantNode(FileArchiveFactory(root))
+	acme, _ := m.AddTenant("acme", TenantQuota{})
+	globex, _ := m.AddTenant("globex", TenantQuota{})
+
+	if _, err := m.AddTenant("acme", TenantQuota{}); err == nil {
+		t.Errorf("Duplicate tenant should be rejected")
+	}
+	if _, err := m.AddTenant("../escape", TenantQuota{}); err == nil {
+		t.Errorf("Unsafe tenant ID should be rejected")
+	}
+
+	if _, err := m.Submit("acme", testProposal()); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if acme.Ledger().Height() != 1 || globex.Ledger().Height() != 0 {
+		t.Errorf("Submission should only reach the tenant's own ledger")
+	}
+	if _, err := m.Submit("initech", testProposal()); err == nil {
+		t.Errorf("Unknown tenant should be rejected")
+	}
+
+	hash, _ := ArchiveObject(acme.Archive(), map[string]interface{}{"doc": "acme only"})
+	if ok, _ := globex.Archive().Has(hash); ok {
+		t.Errorf("Archived blob should not be visible to another tenant")
+	}
+	if _, err := os.Stat(filepath.Join(root, "acme", hash)); err != nil {
+		t.Errorf("Blob should be stored under the tenant directory: %v", err)
+	}
+
+	// Identical histories still give distinct roots
+	m.Submit("globex", testProposal())
+	roots, combined, err := m.StateRoots()
+	if err != nil {
+		t.Fatalf("Failed to compute state roots: %v", err)
+	}
+	if roots["acme"] == roots["globex"] || combined == "" {
+		t.Errorf("Tenant state roots should be distinct")
+	}
+
+	t.Logf("✓ Combined state root %s", combined)
+}
+
+// TestMultiTenantQuotas tests proposal, archive, and rate limits
+func TestMultiTenantQuotas(t *testing.T) {
+	testenv.RequireSigning(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	m := NewMultiTenantNode(nil)
+	m.Clock = clock
+	m.AddTenant("limited", TenantQuota{MaxProposals: 3, SubmissionsPerMinute: 2, Burst: 2, MaxArchiveBytes: 40})
+
+	for _, id := range []string{"p1", "p2"} {
+		if _, err := m.Submit("limited", tenantProposal(id)); err != nil {
+			t.Fatalf("Submission within burst failed: %v", err)
+		}
+	}
+	if _, err := m.Submit("limited", tenantProposal("p3")); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+	if _, err := m.Submit("limited", tenantProposal("p1")); err != nil {
+		t.Errorf("Resubmission should not be rate limited: %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	if _, err := m.Submit("limited", tenantProposal("p3")); err != nil {
+		t.Fatalf("Submission after refill failed: %v", err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := m.Submit("limited", tenantProposal("p4")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+
+	tenant, _ := m.Tenant("limited")
+	if _, err := tenant.Archive().Put([]byte(`{"blob":"0123456789"}`)); err != nil {
+		t.Fatalf("Put within quota failed: %v", err)
+	}
+	if _, err := tenant.Archive().Put([]byte(`{"blob":"0123456789"}`)); err != nil {
+		t.Errorf("Re-putting an existing blob should not count against the quota: %v", err)
+	}
+	if _, err := tenant.Archive().Put([]byte(`{"blob":"abcdefghij"}`)); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected archive ErrQuotaExceeded, got %v", err)
+	}
+
+	t.Logf("✓ Quotas enforced for %v", m.Tenants())
+}
@@ -0,0 +1,343 @@
+// timelock.go - Proposals ratified now that take effect later
+//
+// An amendment often needs a transition period: agents must see the new rule
+// before it binds them. A proposal may therefore carry an activation_height,
+// an activation_time, or both. It is recorded and ratified as usual, but
+// TimeLockedExecutor holds it until the ledger reaches the height and the
+// ledger time reaches the time.
+//
+// Activation must happen at the same point on every node, so it never
+// consults a node's clock. Ledger time is the latest timestamp recorded in
+// the ledger itself (acceptances, ratifications, halts, intents), and a proposal is
+// due once the ledger's height and time both reach its activation. Each
+// activation is recorded as a canonical ActivationRecord in an activation
+// entry; VerifyActivations recomputes every record from the entries before
+// it, so a node that activated a proposal early, twice, or out of nowhere
+// is caught by anyone replaying the ledger.
+
+package ocp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LedgerKindActivation is the ledger entry kind recording an ActivationRecord
+const LedgerKindActivation = "activation"
+
+// ErrTimeLocked is returned when a proposal is executed before its activation
+var ErrTimeLocked = &ConstitutionalError{ErrorType: "ExecutionError", Message: "proposal is time-locked until its activation"}
+
+// ledgerTimeFields are the payload fields whose timestamps advance ledger time
+var ledgerTimeFields = []string{"accepted_at", "ratified_at", "halted_at", "reviewed_at", "lifted_at", "declared_at"}
+
+// TimeLocked reports whether p names an activation height or time
+func TimeLocked(p *ContractProposal) bool {
+	return p.ActivationHeight > 0 || p.ActivationTime != ""
+}
+
+// ValidateActivation checks p's activation against the height at which it
+// is to be recorded
+//
+// Parameters:
+//   - p: Proposal to check
+//   - height: Ledger height of the entry that will record p
+//
+// Returns:
+//   - ConstitutionalError if the activation height is not after height, or
+//     the activation time is not an RFC 3339 time after p's timestamp
+func ValidateActivation(p *ContractProposal, height uint64) error {
+	if p.ActivationHeight > 0 && p.ActivationHeight <= height {
+		return NewConstitutionalError(fmt.Sprintf("activation height %d is not after recording height %d", p.ActivationHeight, height))
+	}
+	if p.ActivationTime == "" {
+		return nil
+	}
+	at, err := time.Parse(time.RFC3339Nano, p.ActivationTime)
+	if err != nil {
+		return NewConstitutionalError(fmt.Sprintf("activation time %q is not an RFC 3339 time", p.ActivationTime))
+	}
+	if submitted, err := time.Parse(time.RFC3339Nano, p.Timestamp); err == nil && !at.After(submitted) {
+		return NewConstitutionalError(fmt.Sprintf("activation time %s is not after proposal timestamp %s", p.ActivationTime, p.Timestamp))
+	}
+	return nil
+}
+
+// LedgerTime returns the latest timestamp recorded in entries
+//
+// Returns:
+//   - Ledger time, and false if no entry records a time
+func LedgerTime(entries []LedgerEntry) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, e := range entries {
+		for _, field := range ledgerTimeFields {
+			t, err := time.Parse(time.RFC3339Nano, payloadString(e.Payload, field))
+			if err != nil {
+				continue
+			}
+			if !found || t.After(latest) {
+				latest, found = t, true
+			}
+		}
+	}
+	return latest, found
+}
+
+// ActivationRecord is the canonical record of a time-locked proposal taking
+// effect
+type ActivationRecord struct {
+	ProposalHash     string `json:"proposal_hash"`
+	ActivationHeight uint64 `json:"activation_height,omitempty"`
+	ActivationTime   string `json:"activation_time,omitempty"`
+	// RecordedHeight is the height of the proposal's ledger entry
+	RecordedHeight uint64 `json:"recorded_height"`
+	// ActivatedHeight is the ledger height at which the proposal was found
+	// due; the activation entry follows it
+	ActivatedHeight uint64 `json:"activated_height"`
+	// LedgerTime is the ledger time at ActivatedHeight, if any is recorded
+	LedgerTime string `json:"ledger_time,omitempty"`
+}
+
+// ToMap converts an ActivationRecord to a map for canonicalization
+func (r *ActivationRecord) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"proposal_hash":    r.ProposalHash,
+		"recorded_height":  r.RecordedHeight,
+		"activated_height": r.ActivatedHeight,
+	}
+	if r.ActivationHeight > 0 {
+		m["activation_height"] = r.ActivationHeight
+	}
+	if r.ActivationTime != "" {
+		m["activation_time"] = r.ActivationTime
+	}
+	if r.LedgerTime != "" {
+		m["ledger_time"] = r.LedgerTime
+	}
+	return m
+}
+
+// Hash returns the semantic hash of the record
+func (r *ActivationRecord) Hash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// activationFromPayload reads an ActivationRecord stored with ToMap
+func activationFromPayload(payload map[string]interface{}) ActivationRecord {
+	return ActivationRecord{
+		ProposalHash:     payloadString(payload, "proposal_hash"),
+		ActivationHeight: uint64(payloadInt(payload, "activation_height")),
+		ActivationTime:   payloadString(payload, "activation_time"),
+		RecordedHeight:   uint64(payloadInt(payload, "recorded_height")),
+		ActivatedHeight:  uint64(payloadInt(payload, "activated_height")),
+		LedgerTime:       payloadString(payload, "ledger_time"),
+	}
+}
+
+// Activations returns the activation records in entries by proposal hash
+func Activations(entries []LedgerEntry) map[string]ActivationRecord {
+	out := make(map[string]ActivationRecord)
+	for _, e := range entries {
+		if e.Kind == LedgerKindActivation {
+			r := activationFromPayload(e.Payload)
+			out[r.ProposalHash] = r
+		}
+	}
+	return out
+}
+
+// DueActivations returns the time-locked proposals recorded in entries that
+// are due but not yet activated. entries must run from the start of the
+// ledger (or a snapshot) to its head; the result depends only on them.
+//
+// Returns:
+//   - Records in order of recording height, then proposal hash
+func DueActivations(entries []LedgerEntry) []ActivationRecord {
+	if len(entries) == 0 {
+		return nil
+	}
+	height := entries[len(entries)-1].Height
+	now, hasTime := LedgerTime(entries)
+	activated := Activations(entries)
+
+	var due []ActivationRecord
+	for _, e := range entries {
+		if e.Kind != LedgerKindProposal {
+			continue
+		}
+		r := ActivationRecord{
+			ProposalHash:     payloadString(e.Payload, "proposal_hash"),
+			ActivationHeight: uint64(payloadInt(e.Payload, "activation_height")),
+			ActivationTime:   payloadString(e.Payload, "activation_time"),
+			RecordedHeight:   e.Height,
+			ActivatedHeight:  height,
+		}
+		if r.ActivationHeight == 0 && r.ActivationTime == "" {
+			continue
+		}
+		if _, ok := activated[r.ProposalHash]; ok {
+			continue
+		}
+		if r.ActivationHeight > height {
+			continue
+		}
+		if r.ActivationTime != "" {
+			at, err := time.Parse(time.RFC3339Nano, r.ActivationTime)
+			if err != nil || !hasTime || now.Before(at) {
+				continue
+			}
+		}
+		if hasTime {
+			r.LedgerTime = Timestamp(now)
+		}
+		due = append(due, r)
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].RecordedHeight != due[j].RecordedHeight {
+			return due[i].RecordedHeight < due[j].RecordedHeight
+		}
+		return due[i].ProposalHash < due[j].ProposalHash
+	})
+	return due
+}
+
+// VerifyActivations checks every activation entry in entries against the
+// entries before it: the proposal must be recorded, time-locked, due, and
+// not already activated, and the record must match the one DueActivations
+// computes. Activations recorded by one Advance follow each other, so each
+// is checked against the entries before the first of its run.
+//
+// Returns:
+//   - VerificationError naming the first activation that does not hold
+func VerifyActivations(entries []LedgerEntry) error {
+	start := 0
+	for i, e := range entries {
+		if e.Kind != LedgerKindActivation {
+			start = i + 1
+			continue
+		}
+		got := activationFromPayload(e.Payload)
+		var want *ActivationRecord
+		for _, r := range DueActivations(entries[:start]) {
+			if r.ProposalHash == got.ProposalHash {
+				want = &r
+				break
+			}
+		}
+		if want == nil {
+			return NewVerificationError(fmt.Sprintf("activation at height %d: proposal %s is not due", e.Height, got.ProposalHash))
+		}
+		if _, ok := Activations(entries[start:i])[got.ProposalHash]; ok {
+			return NewVerificationError(fmt.Sprintf("activation at height %d: proposal %s is already activated", e.Height, got.ProposalHash))
+		}
+		if *want != got {
+			return NewVerificationError(fmt.Sprintf("activation at height %d does not match the ledger before it", e.Height))
+		}
+	}
+	return nil
+}
+
+// TimeLockedExecutor is an Executor that holds time-locked proposals until
+// their activation is recorded. Other proposals pass straight through to
+// Next.
+type TimeLockedExecutor struct {
+	Next   Executor
+	Ledger *Ledger
+
+	mu sync.Mutex
+	// held holds time-locked proposals awaiting activation by hash
+	held map[string]*ContractProposal
+}
+
+// NewTimeLockedExecutor wraps next, reading activations from ledger
+func NewTimeLockedExecutor(next Executor, ledger *Ledger) *TimeLockedExecutor {
+	return &TimeLockedExecutor{Next: next, Ledger: ledger}
+}
+
+// Execute runs p through Next if it is not time-locked or its activation is
+// recorded; otherwise p is held for Advance
+//
+// Returns:
+//   - The wrapped executor's receipt
+//   - An error wrapping ErrTimeLocked if p was held or is not recorded
+func (e *TimeLockedExecutor) Execute(p *ContractProposal) (*ExecutionReceipt, error) {
+	if !TimeLocked(p) {
+		return e.Next.Execute(p)
+	}
+	hash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	entries := e.Ledger.Entries(0)
+	if _, ok := Activations(entries)[hash]; ok {
+		return e.Next.Execute(p)
+	}
+	if !proposalRecorded(entries, hash) {
+		return nil, fmt.Errorf("proposal %s is not recorded on the ledger: %w", hash, ErrTimeLocked)
+	}
+
+	e.mu.Lock()
+	if e.held == nil {
+		e.held = make(map[string]*ContractProposal)
+	}
+	e.held[hash] = p
+	e.mu.Unlock()
+	return nil, fmt.Errorf("proposal %s held until activation: %w", hash, ErrTimeLocked)
+}
+
+// Advance records an ActivationRecord for every proposal that has become
+// due and executes the held ones among them
+//
+// Returns:
+//   - Records appended, in ledger order
+//   - Receipts of the held proposals executed
+//   - The first error; records appended before it remain on the ledger
+func (e *TimeLockedExecutor) Advance() ([]ActivationRecord, []*ExecutionReceipt, error) {
+	due := DueActivations(e.Ledger.Entries(0))
+	var receipts []*ExecutionReceipt
+	for i, r := range due {
+		if _, err := e.Ledger.Append(LedgerKindActivation, r.ToMap()); err != nil {
+			return due[:i], receipts, err
+		}
+	}
+	for _, r := range due {
+		e.mu.Lock()
+		p, ok := e.held[r.ProposalHash]
+		delete(e.held, r.ProposalHash)
+		e.mu.Unlock()
+		if !ok {
+			continue
+		}
+		receipt, err := e.Next.Execute(p)
+		if err != nil {
+			return due, receipts, fmt.Errorf("activated proposal %s: %w", r.ProposalHash, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return due, receipts, nil
+}
+
+// Held returns the hashes of proposals awaiting activation, sorted
+func (e *TimeLockedExecutor) Held() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]string, 0, len(e.held))
+	for hash := range e.held {
+		out = append(out, hash)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// proposalRecorded reports whether entries record the proposal hash
+func proposalRecorded(entries []LedgerEntry, hash string) bool {
+	for _, e := range entries {
+		if e.Kind == LedgerKindProposal && payloadString(e.Payload, "proposal_hash") == hash {
+			return true
+		}
+	}
+	return false
+}
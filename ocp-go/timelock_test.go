@@ -0,0 +1,168 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// timeLockedProposal returns testProposal with its own ID and activation
+func timeLockedProposal(id string, height uint64, at string) *ContractProposal {
+	p := testProposal()
+	p.ID = id
+	p.ActivationHeight = height
+	p.ActivationTime = at
+	return p
+}
+
+// TestValidateActivation tests the activation rules checked at submission
+func TestValidateActivation(t *testing.T) {
+	if _, ok := testProposal().ToMap()["activation_height"]; ok {
+		t.Error("Proposals without a time lock should not hash activation fields")
+	}
+
+	cases := []struct {
+		name   string
+		height uint64
+		at     string
+		ok     bool
+	}{
+		{"no time lock", 0, "", true},
+		{"later height", 5, "", true},
+		{"recording height", 4, "", false},
+		{"earlier height", 2, "", false},
+		{"later time", 0, "2025-11-21T00:00:00Z", true},
+		{"proposal time", 0, "2025-11-20T14:30:00Z", false},
+		{"unparseable time", 0, "tomorrow", false},
+	}
+	for _, c := range cases {
+		err := ValidateActivation(timeLockedProposal("p", c.height, c.at), 4)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: expected ok=%v, got %v", c.name, c.ok, err)
+		}
+	}
+
+	node := NewNode(NewLedger())
+	if _, err := node.Submit(timeLockedProposal("p", 1, "")); err == nil {
+		t.Error("Expected submission with an activation at its own height to fail")
+	}
+	t.Logf("✓ %d activation cases checked", len(cases))
+}
+
+// TestTimeLockedExecutor tests that proposals run once the ledger reaches
+// their activation height and time
+func TestTimeLockedExecutor(t *testing.T) {
+	testenv.RequireSigning(t)
+	_, execKey := testKey("Executor-1")
+	var applied []string
+	inner := &ReceiptExecutor{
+		Agent: "Executor-1",
+		Key:   execKey,
+		Applier: ApplierFunc(func(p *ContractProposal) (string, ResourceUsage, error) {
+			applied = append(applied, p.ID)
+			return "sha256:after", ResourceUsage{}, nil
+		}),
+	}
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	node := NewNode(NewLedger())
+	node.Clock = clock
+	executor := NewTimeLockedExecutor(inner, node.Ledger())
+
+	byHeight := timeLockedProposal("by-height", 3, "")
+	byTime := timeLockedProposal("by-time", 0, "2025-11-20T15:00:00Z")
+	for _, p := range []*ContractProposal{byHeight, byTime} {
+		if _, err := node.Submit(p); err != nil {
+			t.Fatalf("Failed to submit %s: %v", p.ID, err)
+		}
+		if _, err := executor.Execute(p); !errors.Is(err, ErrTimeLocked) {
+			t.Fatalf("Expected %s to be held, got %v", p.ID, err)
+		}
+	}
+	if _, err := executor.Execute(timeLockedProposal("unrecorded", 9, "")); !errors.Is(err, ErrTimeLocked) {
+		t.Errorf("Expected an unrecorded time-locked proposal to be refused, got %v", err)
+	}
+	if len(executor.Held()) != 2 {
+		t.Fatalf("Expected two held proposals, got %v", executor.Held())
+	}
+
+	// Untimed proposals pass through
+	if _, err := executor.Execute(testProposal()); err != nil {
+		t.Fatalf("Expected an untimed proposal to execute: %v", err)
+	}
+
+	records, _, err := executor.Advance()
+	if err != nil || len(records) != 0 {
+		t.Fatalf("Nothing should be due at height 2: %v %v", records, err)
+	}
+
+	// Height 3 activates the first; the wall clock moving does not
+	// activate the second, because ledger time has not moved
+	clock.Advance(time.Hour)
+	node.ledger.Append(LedgerKindPolicy, map[string]interface{}{"note": "filler"})
+	records, receipts, err := executor.Advance()
+	if err != nil || len(records) != 1 || records[0].ProposalHash != mustHash(t, byHeight) || len(receipts) != 1 {
+		t.Fatalf("Expected %s to activate at height 3: %+v %v", byHeight.ID, records, err)
+	}
+	if records[0].RecordedHeight != 1 || records[0].ActivatedHeight != 3 || records[0].LedgerTime != "2025-11-20T14:30:00Z" {
+		t.Errorf("Unexpected activation record %+v", records[0])
+	}
+
+	// A later acceptance moves ledger time past the second's activation
+	if _, err := node.Submit(timeLockedProposal("later", 0, "")); err != nil {
+		t.Fatal(err)
+	}
+	records, receipts, err = executor.Advance()
+	if err != nil || len(records) != 1 || records[0].ProposalHash != mustHash(t, byTime) || len(receipts) != 1 {
+		t.Fatalf("Expected %s to activate by ledger time: %+v %v", byTime.ID, records, err)
+	}
+	if len(applied) != 3 || applied[1] != "by-height" || applied[2] != "by-time" || len(executor.Held()) != 0 {
+		t.Errorf("Unexpected executions %v, held %v", applied, executor.Held())
+	}
+
+	// Once activated, a proposal executes directly
+	if _, err := executor.Execute(byHeight); err != nil {
+		t.Errorf("Expected an activated proposal to execute: %v", err)
+	}
+	if records, _, _ := executor.Advance(); len(records) != 0 {
+		t.Errorf("Activations should be recorded once, got %+v", records)
+	}
+	if err := VerifyActivations(node.Ledger().Entries(0)); err != nil {
+		t.Errorf("Expected recorded activations to verify: %v", err)
+	}
+	t.Logf("✓ Activated both time-locked proposals by ledger height %d", node.Ledger().Height())
+}
+
+// TestVerifyActivations tests that early or duplicate activations are caught
+func TestVerifyActivations(t *testing.T) {
+	testenv.RequireSigning(t)
+	node := NewNode(NewLedger())
+	node.Clock = NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	p := timeLockedProposal("p", 3, "")
+	if _, err := node.Submit(p); err != nil {
+		t.Fatal(err)
+	}
+	early := ActivationRecord{ProposalHash: mustHash(t, p), ActivationHeight: 3, RecordedHeight: 1, ActivatedHeight: 1, LedgerTime: "2025-11-20T14:30:00Z"}
+	node.ledger.Append(LedgerKindActivation, early.ToMap())
+	if err := VerifyActivations(node.Ledger().Entries(0)); err == nil {
+		t.Error("Expected an activation before the activation height to fail")
+	}
+
+	node = NewNode(NewLedger())
+	node.Clock = NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	node.Submit(p)
+	node.ledger.Append(LedgerKindPolicy, map[string]interface{}{"note": "filler"})
+	node.ledger.Append(LedgerKindPolicy, map[string]interface{}{"note": "filler"})
+	due := DueActivations(node.Ledger().Entries(0))
+	if len(due) != 1 {
+		t.Fatalf("Expected one due activation, got %+v", due)
+	}
+	node.ledger.Append(LedgerKindActivation, due[0].ToMap())
+	node.ledger.Append(LedgerKindActivation, due[0].ToMap())
+	var verr *ConstitutionalError
+	if err := VerifyActivations(node.Ledger().Entries(0)); !errors.As(err, &verr) || verr.ErrorType != "VerificationError" {
+		t.Errorf("Expected a duplicate activation to fail verification, got %v", err)
+	}
+	t.Log("✓ Early and duplicate activations rejected")
+}
@@ -0,0 +1,406 @@
+// translation.go - Attested translations of the ratified constitution
+//
+// The ratified constitution is authoritative in the language it was written
+// in. Readers of other languages rely on translations, and a translation that
+// silently drifts from the source, or renders an amendment that was never
+// ratified, misstates what the network enforces.
+//
+// A Translation is a canonical object: a language tag, the hash of the source
+// document, and one translated text per source section, each naming the hash
+// of the section it renders (sections as in amendment review: top-level keys
+// of JSON documents, heading lines of text ones). A TranslationAttestation is
+// the quorum's signed statement that a translation faithfully renders the
+// source; it binds each section's source hash to the content hash of its
+// translated text. A LocalizationBundle collects the translations of one
+// ratified version and verifies them against the ledger.
+//
+// After an amendment, StaleSections lists the sections a translation must
+// revise before it can be attested for the new version.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TranslatedSection is the translated text of one source section
+type TranslatedSection struct {
+	Name string `json:"name"`
+	// SourceSectionHash is the hash of the section in the source document
+	SourceSectionHash string `json:"source_section_hash"`
+	Text              string `json:"text"`
+}
+
+// Translation renders a constitution in another language
+type Translation struct {
+	// Language is a BCP 47 tag, e.g. "fr" or "pt-BR"
+	Language string `json:"language"`
+	// SourceHash is the ConstitutionHash of the source document
+	SourceHash string `json:"source_hash"`
+	// Sections follow the source document's section order
+	Sections []TranslatedSection `json:"sections"`
+}
+
+// NewTranslation creates a translation of source from the translated text of
+// each of its sections, keyed by section name
+//
+// Returns:
+//   - ConstitutionalError if the language tag is malformed, a section is
+//     missing a translation, or texts names a section source does not have
+func NewTranslation(source []byte, language string, texts map[string]string) (*Translation, error) {
+	if err := checkLanguageTag(language); err != nil {
+		return nil, err
+	}
+	sections, err := documentSections(source)
+	if err != nil {
+		return nil, err
+	}
+	t := &Translation{Language: language, SourceHash: ConstitutionHash(source)}
+	known := make(map[string]bool, len(sections))
+	for _, s := range sections {
+		known[s.name] = true
+		text, ok := texts[s.name]
+		if !ok {
+			return nil, NewConstitutionalError(fmt.Sprintf("no %s translation of section %q", language, s.name))
+		}
+		t.Sections = append(t.Sections, TranslatedSection{Name: s.name, SourceSectionHash: s.hash, Text: text})
+	}
+	for name := range texts {
+		if !known[name] {
+			return nil, NewConstitutionalError(fmt.Sprintf("source has no section %q", name))
+		}
+	}
+	return t, nil
+}
+
+// ToMap converts a Translation to a map for canonicalization
+func (t *Translation) ToMap() map[string]interface{} {
+	sections := make([]interface{}, len(t.Sections))
+	for i, s := range t.Sections {
+		sections[i] = map[string]interface{}{
+			"name":                s.Name,
+			"source_section_hash": s.SourceSectionHash,
+			"text":                s.Text,
+		}
+	}
+	return map[string]interface{}{
+		"language":    t.Language,
+		"source_hash": t.SourceHash,
+		"sections":    sections,
+	}
+}
+
+// Hash returns the semantic hash of the translation
+func (t *Translation) Hash() (string, error) {
+	return SemanticHash(t.ToMap())
+}
+
+// SectionBinding ties a source section to its translated text
+type SectionBinding struct {
+	Section           string `json:"section"`
+	SourceSectionHash string `json:"source_section_hash"`
+	// TranslatedHash is the content hash of the translated text
+	TranslatedHash string `json:"translated_hash"`
+}
+
+// TranslationAttestation is the quorum's signed statement that a translation
+// renders the source document it names
+type TranslationAttestation struct {
+	TranslationHash string           `json:"translation_hash"`
+	Language        string           `json:"language"`
+	SourceHash      string           `json:"source_hash"`
+	Sections        []SectionBinding `json:"sections"`
+	AttestedAt      string           `json:"attested_at"`
+	Signatures      []Signature      `json:"signatures"`
+}
+
+// NewTranslationAttestation creates an unsigned attestation of t
+func NewTranslationAttestation(t *Translation, attestedAt string) (*TranslationAttestation, error) {
+	hash, err := t.Hash()
+	if err != nil {
+		return nil, err
+	}
+	a := &TranslationAttestation{
+		TranslationHash: hash,
+		Language:        t.Language,
+		SourceHash:      t.SourceHash,
+		AttestedAt:      attestedAt,
+	}
+	for _, s := range t.Sections {
+		a.Sections = append(a.Sections, SectionBinding{
+			Section:           s.Name,
+			SourceSectionHash: s.SourceSectionHash,
+			TranslatedHash:    ContentHash([]byte(s.Text)),
+		})
+	}
+	return a, nil
+}
+
+// ToMap converts a TranslationAttestation to a map for canonicalization,
+// excluding signatures
+func (a *TranslationAttestation) ToMap() map[string]interface{} {
+	sections := make([]interface{}, len(a.Sections))
+	for i, s := range a.Sections {
+		sections[i] = map[string]interface{}{
+			"section":             s.Section,
+			"source_section_hash": s.SourceSectionHash,
+			"translated_hash":     s.TranslatedHash,
+		}
+	}
+	return map[string]interface{}{
+		"translation_hash": a.TranslationHash,
+		"language":         a.Language,
+		"source_hash":      a.SourceHash,
+		"sections":         sections,
+		"attested_at":      a.AttestedAt,
+	}
+}
+
+// Hash returns the semantic hash the quorum signs
+func (a *TranslationAttestation) Hash() (string, error) {
+	return SemanticHash(a.ToMap())
+}
+
+// Sign adds signer's signature to the attestation
+func (a *TranslationAttestation) Sign(signer string, key ed25519.PrivateKey) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(signer, key, ContextTranslation, hash)
+	if err != nil {
+		return err
+	}
+	a.Signatures = append(a.Signatures, sig)
+	return nil
+}
+
+// SignedBy verifies that the attestation carries enough valid signatures from
+// quorum
+func (a *TranslationAttestation) SignedBy(quorum *Quorum) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+	_, err = quorum.Verify(ContextTranslation, hash, a.Signatures)
+	return err
+}
+
+// VerifyTranslation checks that t renders every section of source as it
+// stands and that a is a quorum attestation of exactly t
+//
+// Returns:
+//   - VerificationError naming the first mismatch
+func VerifyTranslation(source []byte, t *Translation, a *TranslationAttestation, quorum *Quorum) error {
+	if hash := ConstitutionHash(source); t.SourceHash != hash {
+		return NewVerificationError(fmt.Sprintf("%s translation is of %s, not %s", t.Language, t.SourceHash, hash))
+	}
+	stale, err := StaleSections(source, t)
+	if err != nil {
+		return err
+	}
+	if len(stale) > 0 {
+		return NewVerificationError(fmt.Sprintf("%s translation does not match source sections %s", t.Language, strings.Join(stale, ", ")))
+	}
+
+	expected, err := NewTranslationAttestation(t, a.AttestedAt)
+	if err != nil {
+		return err
+	}
+	if a.TranslationHash != expected.TranslationHash || a.Language != t.Language || a.SourceHash != t.SourceHash {
+		return NewVerificationError(fmt.Sprintf("attestation does not describe the %s translation %s", t.Language, expected.TranslationHash))
+	}
+	if len(a.Sections) != len(expected.Sections) {
+		return NewVerificationError(fmt.Sprintf("attestation binds %d sections, translation has %d", len(a.Sections), len(expected.Sections)))
+	}
+	for i, b := range expected.Sections {
+		if a.Sections[i] != b {
+			return NewVerificationError(fmt.Sprintf("attestation binding for section %q does not match the translation", b.Section))
+		}
+	}
+	return a.SignedBy(quorum)
+}
+
+// StaleSections lists, in source order, the sections of source that t does not
+// render as they stand: sections whose source hash changed, sections added
+// since t was made, and sections t renders that source no longer has
+func StaleSections(source []byte, t *Translation) ([]string, error) {
+	sections, err := documentSections(source)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string]string, len(t.Sections))
+	for _, s := range t.Sections {
+		rendered[s.Name] = s.SourceSectionHash
+	}
+	var stale []string
+	known := make(map[string]bool, len(sections))
+	for _, s := range sections {
+		known[s.name] = true
+		if h, ok := rendered[s.name]; !ok || h != s.hash {
+			stale = append(stale, s.name)
+		}
+	}
+	for _, s := range t.Sections {
+		if !known[s.Name] {
+			stale = append(stale, s.Name)
+		}
+	}
+	return stale, nil
+}
+
+// LocalizationBundle holds the attested translations of one ratified
+// constitution
+type LocalizationBundle struct {
+	SourceHash   string                    `json:"source_hash"`
+	Translations []*Translation            `json:"translations"`
+	Attestations []*TranslationAttestation `json:"attestations"`
+}
+
+// NewLocalizationBundle creates an empty bundle for source
+func NewLocalizationBundle(source []byte) *LocalizationBundle {
+	return &LocalizationBundle{SourceHash: ConstitutionHash(source)}
+}
+
+// Add adds an attested translation, replacing any earlier one in the same
+// language. Translations are kept sorted by language.
+//
+// Returns:
+//   - ConstitutionalError if the translation is of another source or the
+//     attestation is of another translation
+func (b *LocalizationBundle) Add(t *Translation, a *TranslationAttestation) error {
+	if t.SourceHash != b.SourceHash {
+		return NewConstitutionalError(fmt.Sprintf("%s translation is of %s, bundle is of %s", t.Language, t.SourceHash, b.SourceHash))
+	}
+	hash, err := t.Hash()
+	if err != nil {
+		return err
+	}
+	if a.TranslationHash != hash {
+		return NewConstitutionalError(fmt.Sprintf("attestation is of translation %s, not %s", a.TranslationHash, hash))
+	}
+	for i, existing := range b.Translations {
+		if existing.Language == t.Language {
+			b.Translations[i], b.Attestations[i] = t, a
+			return nil
+		}
+	}
+	b.Translations = append(b.Translations, t)
+	b.Attestations = append(b.Attestations, a)
+	sort.Sort(bundleOrder{b})
+	return nil
+}
+
+// Translation returns the translation in language
+func (b *LocalizationBundle) Translation(language string) (*Translation, bool) {
+	for _, t := range b.Translations {
+		if t.Language == language {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Languages returns the bundle's languages, sorted
+func (b *LocalizationBundle) Languages() []string {
+	out := make([]string, len(b.Translations))
+	for i, t := range b.Translations {
+		out[i] = t.Language
+	}
+	return out
+}
+
+// ToMap converts a LocalizationBundle to a map for canonicalization
+func (b *LocalizationBundle) ToMap() map[string]interface{} {
+	translations := make([]interface{}, len(b.Translations))
+	for i, t := range b.Translations {
+		translations[i] = t.ToMap()
+	}
+	attestations := make([]interface{}, len(b.Attestations))
+	for i, a := range b.Attestations {
+		m := a.ToMap()
+		m["signatures"] = signatureList(a.Signatures)
+		attestations[i] = m
+	}
+	return map[string]interface{}{
+		"source_hash":  b.SourceHash,
+		"translations": translations,
+		"attestations": attestations,
+	}
+}
+
+// Hash returns the semantic hash of the bundle, signatures included
+func (b *LocalizationBundle) Hash() (string, error) {
+	return SemanticHash(b.ToMap())
+}
+
+// Verify checks that source is the constitution ratified in entries, that the
+// bundle is of it, and that every translation is attested by quorum
+//
+// Parameters:
+//   - entries: Ledger entries recording the ratified constitution
+//   - source: Authoritative constitution document
+//   - quorum: Quorum whose attestations are accepted
+func (b *LocalizationBundle) Verify(entries []LedgerEntry, source []byte, quorum *Quorum) error {
+	ratified, height, err := RatifiedConstitution(entries)
+	if err != nil {
+		return err
+	}
+	if !documentMatches(source, ratified) {
+		return NewVerificationError(fmt.Sprintf("source is not the constitution ratified at height %d (%s)", height, ratified))
+	}
+	if b.SourceHash != ConstitutionHash(source) {
+		return NewVerificationError(fmt.Sprintf("bundle is of %s, not the ratified constitution %s", b.SourceHash, ratified))
+	}
+	if len(b.Attestations) != len(b.Translations) {
+		return NewVerificationError(fmt.Sprintf("bundle has %d translations and %d attestations", len(b.Translations), len(b.Attestations)))
+	}
+	seen := make(map[string]bool, len(b.Translations))
+	for i, t := range b.Translations {
+		if seen[t.Language] {
+			return NewVerificationError(fmt.Sprintf("bundle has two %s translations", t.Language))
+		}
+		seen[t.Language] = true
+		if err := VerifyTranslation(source, t, b.Attestations[i], quorum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleOrder sorts a bundle's translations and attestations by language
+type bundleOrder struct{ b *LocalizationBundle }
+
+func (o bundleOrder) Len() int { return len(o.b.Translations) }
+func (o bundleOrder) Less(i, j int) bool {
+	return o.b.Translations[i].Language < o.b.Translations[j].Language
+}
+func (o bundleOrder) Swap(i, j int) {
+	o.b.Translations[i], o.b.Translations[j] = o.b.Translations[j], o.b.Translations[i]
+	o.b.Attestations[i], o.b.Attestations[j] = o.b.Attestations[j], o.b.Attestations[i]
+}
+
+// checkLanguageTag checks the shape of a BCP 47 tag: a primary language of
+// two to eight letters followed by alphanumeric subtags of one to eight
+// characters
+func checkLanguageTag(tag string) error {
+	for i, sub := range strings.Split(tag, "-") {
+		ok := len(sub) >= 1 && len(sub) <= 8
+		if i == 0 {
+			ok = len(sub) >= 2 && len(sub) <= 8
+		}
+		for _, r := range sub {
+			isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+			if !isLetter && (i == 0 || r < '0' || r > '9') {
+				ok = false
+			}
+		}
+		if !ok {
+			return NewConstitutionalError(fmt.Sprintf("%q is not a BCP 47 language tag", tag))
+		}
+	}
+	return nil
+}
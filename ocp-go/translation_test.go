@@ -0,0 +1,137 @@
+package ocp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// attestedTranslation returns a translation of source into language signed by
+// Claude and Gemini
+func attestedTranslation(t *testing.T, source []byte, language string, texts map[string]string) (*Translation, *TranslationAttestation) {
+	t.Helper()
+	_, privs := testQuorum(t)
+	tr, err := NewTranslation(source, language, texts)
+	if err != nil {
+		t.Fatalf("Failed to translate: %v", err)
+	}
+	a, err := NewTranslationAttestation(tr, "2025-11-20T15:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{"Claude", "Gemini"} {
+		if err := a.Sign(member, privs[member]); err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+	}
+	return tr, a
+}
+
+// TestVerifyTranslation tests that an attested translation is bound to the
+// sections of its source
+func TestVerifyTranslation(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, _ := testQuorum(t)
+	source := []byte("Preamble.\n# Article I\nAgents are sovereign.\n# Article II\nThe assembly decides.\n")
+	texts := map[string]string{
+		"(preamble)":   "Préambule.\n",
+		"# Article I":  "# Article I\nLes agents sont souverains.\n",
+		"# Article II": "# Article II\nL'assemblée décide.\n",
+	}
+
+	if _, err := NewTranslation(source, "fr", map[string]string{"# Article I": ""}); err == nil {
+		t.Error("Expected a translation missing sections to be rejected")
+	}
+	extra := map[string]string{"# Article III": ""}
+	for k, v := range texts {
+		extra[k] = v
+	}
+	if _, err := NewTranslation(source, "fr", extra); err == nil {
+		t.Error("Expected a translation of an unknown section to be rejected")
+	}
+	if _, err := NewTranslation(source, "french!", texts); err == nil {
+		t.Error("Expected a malformed language tag to be rejected")
+	}
+
+	tr, a := attestedTranslation(t, source, "fr", texts)
+	if err := VerifyTranslation(source, tr, a, quorum); err != nil {
+		t.Fatalf("Expected the translation to verify: %v", err)
+	}
+
+	edited := *tr
+	edited.Sections = append([]TranslatedSection(nil), tr.Sections...)
+	edited.Sections[1].Text = "# Article I\nLes agents obéissent.\n"
+	var vErr *ConstitutionalError
+	if err := VerifyTranslation(source, &edited, a, quorum); !errors.As(err, &vErr) || vErr.ErrorType != "VerificationError" {
+		t.Errorf("Expected an edited translation to fail its attestation, got %v", err)
+	}
+
+	unsigned, _ := NewTranslationAttestation(tr, a.AttestedAt)
+	if err := VerifyTranslation(source, tr, unsigned, quorum); err == nil {
+		t.Error("Expected an unsigned attestation to fail")
+	}
+
+	amended := []byte("Preamble.\n# Article I\nAgents are sovereign.\n# Article II\nThe senate decides.\n# Article III\nNew.\n")
+	if err := VerifyTranslation(amended, tr, a, quorum); err == nil {
+		t.Error("Expected the translation not to verify against an amended source")
+	}
+	stale, err := StaleSections(amended, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 2 || stale[0] != "# Article II" || stale[1] != "# Article III" {
+		t.Errorf("Expected Articles II and III to be stale, got %v", stale)
+	}
+	t.Logf("✓ Translation verified; after amendment stale sections are %v", stale)
+}
+
+// TestLocalizationBundle tests verifying a bundle against the ratified
+// constitution
+func TestLocalizationBundle(t *testing.T) {
+	testenv.RequireSigning(t)
+	ledger, err := NewLedgerFromGenesis(testGenesis(t))
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+	quorum, privs := testQuorum(t)
+	source := []byte(`{"articles": {"I": "Sovereignty"}, "version": "2.2"}`)
+	sigs := signAll(t, ContextConstitution, ConstitutionHash(source), privs, "Claude", "Gemini")
+	if _, err := RatifyConstitution(ledger, nil, quorum, source, sigs); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+
+	bundle := NewLocalizationBundle(source)
+	for _, lang := range []string{"pt-BR", "de"} {
+		tr, a := attestedTranslation(t, source, lang, map[string]string{"articles": "I: " + lang, "version": "2.2"})
+		if err := bundle.Add(tr, a); err != nil {
+			t.Fatalf("Failed to add %s: %v", lang, err)
+		}
+	}
+	if langs := bundle.Languages(); len(langs) != 2 || langs[0] != "de" {
+		t.Errorf("Expected languages sorted, got %v", langs)
+	}
+	if err := bundle.Verify(ledger.Entries(0), source, quorum); err != nil {
+		t.Fatalf("Expected the bundle to verify: %v", err)
+	}
+	hash, err := bundle.Hash()
+	if err != nil || hash == "" {
+		t.Fatalf("Failed to hash bundle: %v", err)
+	}
+
+	other := []byte(`{"articles": {"I": "Sovereignty"}, "version": "2.3"}`)
+	if err := bundle.Verify(ledger.Entries(0), other, quorum); err == nil {
+		t.Error("Expected an unratified source to be rejected")
+	}
+	otherTr, otherA := attestedTranslation(t, other, "es", map[string]string{"articles": "I", "version": "2.3"})
+	if err := bundle.Add(otherTr, otherA); err == nil {
+		t.Error("Expected a translation of another source to be rejected")
+	}
+
+	de, _ := bundle.Translation("de")
+	de.Sections[0].Text = "I: tampered"
+	if err := bundle.Verify(ledger.Entries(0), source, quorum); err == nil {
+		t.Error("Expected a tampered translation to fail")
+	}
+	t.Logf("✓ Bundle of %v verified against the ratified constitution", bundle.Languages())
+}
@@ -0,0 +1,299 @@
+// http.go - Transport over HTTP
+//
+// ServeHTTP exposes a Memory bus as HTTP routes, for organizations whose
+// networks pass HTTP and little else. Subscriptions are long-lived GET
+// responses streaming one canonical JSON payload per line; sends and requests
+// are POSTs of a canonical JSON body. HTTPClient implements Transport against
+// those routes.
+//
+// Routes:
+//
+//	POST /v1/topics/{topic}/messages  send the body to the topic's subscribers
+//	GET  /v1/topics/{topic}/messages  stream the topic's messages, one per line
+//	POST /v1/topics/{topic}/requests  the reply of the topic's responder
+
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// ServeHTTP returns the HTTP routes of bus
+func ServeHTTP(bus *Memory) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/topics/{topic}/messages", func(w http.ResponseWriter, r *http.Request) {
+		payload, err := readPayload(r)
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := bus.Send(r.Context(), r.PathValue("topic"), payload); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /v1/topics/{topic}/messages", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeHTTPError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+			return
+		}
+		var mu sync.Mutex
+		ended := false
+		failed := make(chan struct{})
+		var once sync.Once
+		unsubscribe, err := bus.Subscribe(r.PathValue("topic"), func(topic string, payload map[string]interface{}) {
+			data, err := encode(payload)
+			mu.Lock()
+			defer mu.Unlock()
+			if ended {
+				return
+			}
+			if err == nil {
+				_, err = w.Write(append(data, '\n'))
+			}
+			if err != nil {
+				once.Do(func() { close(failed) })
+				return
+			}
+			flusher.Flush()
+		})
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer unsubscribe()
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		mu.Lock()
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		mu.Unlock()
+		select {
+		case <-r.Context().Done():
+		case <-failed:
+		}
+		// No handler may write once the handler function has returned
+		mu.Lock()
+		ended = true
+		mu.Unlock()
+	})
+	mux.HandleFunc("POST /v1/topics/{topic}/requests", func(w http.ResponseWriter, r *http.Request) {
+		payload, err := readPayload(r)
+		if err != nil {
+			writeHTTPError(w, http.StatusBadRequest, err)
+			return
+		}
+		reply, err := bus.Request(r.Context(), r.PathValue("topic"), payload)
+		switch {
+		case errors.Is(err, ErrNoResponder):
+			writeHTTPError(w, http.StatusNotFound, err)
+		case err != nil:
+			writeHTTPError(w, http.StatusUnprocessableEntity, err)
+		default:
+			data, err := encode(reply)
+			if err != nil {
+				writeHTTPError(w, http.StatusInternalServerError, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+		}
+	})
+	return mux
+}
+
+func readPayload(r *http.Request) (map[string]interface{}, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxFrameBytes))
+	if err != nil {
+		return nil, err
+	}
+	return canonical.DecodeStrict(data)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	code := codeFailed
+	if status == http.StatusNotFound {
+		code = codeNoResponder
+	}
+	data, _ := encode(map[string]interface{}{"code": code, "error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// HTTPClient is a Transport talking to routes served by ServeHTTP. Each
+// subscription holds one streaming request open; its handler runs on that
+// stream's goroutine.
+type HTTPClient struct {
+	base   string
+	client *http.Client
+
+	mu      sync.Mutex
+	cancels map[*context.CancelFunc]bool
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewHTTPClient creates a client for the routes served at base, e.g.
+// "https://node.example/". client must not set a Timeout, which would end
+// subscriptions; nil uses a default client.
+func NewHTTPClient(base string, client *http.Client) *HTTPClient {
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &HTTPClient{
+		base:    strings.TrimRight(base, "/"),
+		client:  client,
+		cancels: make(map[*context.CancelFunc]bool),
+	}
+}
+
+func (c *HTTPClient) url(topic, route string) string {
+	return c.base + "/v1/topics/" + topic + "/" + route
+}
+
+func (c *HTTPClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// post sends payload and returns the response body if the status is want
+func (c *HTTPClient) post(ctx context.Context, topic, route string, payload map[string]interface{}, want int) ([]byte, error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	data, err := encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(topic, route), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxFrameBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		return nil, httpError(resp.Status, body)
+	}
+	return body, nil
+}
+
+// httpError rebuilds the error reported in a failed response's body
+func httpError(status string, body []byte) error {
+	var reported struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &reported) != nil || reported.Error == "" {
+		return remoteError(codeFailed, fmt.Sprintf("transport request failed: %s", status))
+	}
+	return remoteError(reported.Code, reported.Error)
+}
+
+// Send implements Transport
+func (c *HTTPClient) Send(ctx context.Context, topic string, payload map[string]interface{}) error {
+	_, err := c.post(ctx, topic, "messages", payload, http.StatusAccepted)
+	return err
+}
+
+// Request implements Transport
+func (c *HTTPClient) Request(ctx context.Context, topic string, payload map[string]interface{}) (map[string]interface{}, error) {
+	body, err := c.post(ctx, topic, "requests", payload, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.DecodeStrict(body)
+}
+
+// Subscribe implements Transport. It returns once the server has registered
+// the subscription.
+func (c *HTTPClient) Subscribe(topic string, handler Handler) (func(), error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		cancel()
+		return nil, ErrClosed
+	}
+	c.cancels[&cancel] = true
+	c.mu.Unlock()
+	stop := func() {
+		cancel()
+		c.mu.Lock()
+		delete(c.cancels, &cancel)
+		c.mu.Unlock()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(topic, "messages"), nil)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxFrameBytes))
+		resp.Body.Close()
+		stop()
+		return nil, httpError(resp.Status, body)
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), MaxFrameBytes+1)
+		for scanner.Scan() {
+			payload, err := canonical.DecodeStrict(scanner.Bytes())
+			if err != nil {
+				return
+			}
+			handler(topic, payload)
+		}
+	}()
+	return stop, nil
+}
+
+// Close implements Transport; it ends every subscription
+func (c *HTTPClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	for cancel := range c.cancels {
+		(*cancel)()
+	}
+	c.cancels = make(map[*context.CancelFunc]bool)
+	c.mu.Unlock()
+	c.wg.Wait()
+	return nil
+}
@@ -0,0 +1,32 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestServeHTTPStatus tests the status codes of the HTTP routes
+func TestServeHTTPStatus(t *testing.T) {
+	handler := ServeHTTP(NewMemory())
+	cases := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/v1/topics/votes/messages", `{"n":1}`, http.StatusAccepted},
+		{"POST", "/v1/topics/votes/messages", `{"n":1,"n":2}`, http.StatusBadRequest},
+		{"POST", "/v1/topics/Votes/messages", `{}`, http.StatusBadRequest},
+		{"POST", "/v1/topics/votes/requests", `{}`, http.StatusNotFound},
+		{"GET", "/v1/topics/Votes/messages", ``, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rec.Code != tc.want {
+			t.Errorf("%s %s %s: expected %d, got %d %s", tc.method, tc.path, tc.body, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	t.Log("✓ Malformed payloads and topics rejected")
+}
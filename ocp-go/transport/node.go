@@ -0,0 +1,154 @@
+// node.go - A node and its agents over a Transport
+//
+// ServeNode puts a Node's submission API on a bus, ServePreflight adds dry-run
+// evaluation of drafts, and Client is the agent's side of both. Because Client only needs a Transport, the same agent code runs
+// against a Memory bus in tests and against DialTCP or NewHTTPClient in
+// deployment.
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Topics used between nodes and agents
+const (
+	// TopicPreflight takes a draft proposal and replies with its
+	// PreflightReport, recording nothing
+	TopicPreflight = "proposals.preflight"
+	// TopicSubmit takes a proposal and replies with its Acceptance
+	TopicSubmit = "proposals.submit"
+	// TopicAccepted carries the Acceptance of every submission, including
+	// idempotent resubmissions; receivers deduplicate by proposal hash
+	TopicAccepted = "proposals.accepted"
+	// TopicHealth replies with the ledger height and head
+	TopicHealth = "node.health"
+)
+
+// ServeNode serves node's submission API on bus, and its ledger for replicas
+func ServeNode(bus *Memory, node *ocp.Node) error {
+	if err := ServeLedger(bus, node.Ledger()); err != nil {
+		return err
+	}
+	if err := bus.Serve(TopicSubmit, func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		var p ocp.ContractProposal
+		if err := convert(payload, &p); err != nil {
+			return nil, err
+		}
+		acceptance, err := node.Submit(&p)
+		if err != nil {
+			return nil, err
+		}
+		reply := acceptance.ToMap()
+		if err := bus.Send(ctx, TopicAccepted, reply); err != nil {
+			return nil, err
+		}
+		return reply, nil
+	}); err != nil {
+		return err
+	}
+	return bus.Serve(TopicHealth, func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		ledger := node.Ledger()
+		return map[string]interface{}{"height": ledger.Height(), "head": ledger.Head()}, nil
+	})
+}
+
+// ServePreflight serves dry-run evaluation of drafts against pf on bus
+func ServePreflight(bus *Memory, pf *ocp.Preflight) error {
+	return bus.Serve(TopicPreflight, func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		var draft ocp.ContractProposal
+		if err := convert(payload, &draft); err != nil {
+			return nil, err
+		}
+		return pf.EvaluateDraft(&draft).ToMap(), nil
+	})
+}
+
+// Client is an agent's connection to a node
+type Client struct {
+	t Transport
+}
+
+// NewClient creates a Client talking to a node over t
+func NewClient(t Transport) *Client {
+	return &Client{t: t}
+}
+
+// Submit sends p to the node and returns its Acceptance
+func (c *Client) Submit(ctx context.Context, p *ocp.ContractProposal) (ocp.Acceptance, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ocp.Acceptance{}, err
+	}
+	payload, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return ocp.Acceptance{}, err
+	}
+	reply, err := c.t.Request(ctx, TopicSubmit, payload)
+	if err != nil {
+		return ocp.Acceptance{}, err
+	}
+	var acceptance ocp.Acceptance
+	err = convert(reply, &acceptance)
+	return acceptance, err
+}
+
+// EvaluateDraft asks the node what submitting draft would meet, so the
+// author can fix problems before staking reputation. Nothing is recorded.
+func (c *Client) EvaluateDraft(ctx context.Context, draft *ocp.ContractProposal) (*ocp.PreflightReport, error) {
+	data, err := json.Marshal(draft)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.t.Request(ctx, TopicPreflight, payload)
+	if err != nil {
+		return nil, err
+	}
+	var report ocp.PreflightReport
+	if err := convert(reply, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Health returns the node's ledger height and head
+func (c *Client) Health(ctx context.Context) (uint64, string, error) {
+	reply, err := c.t.Request(ctx, TopicHealth, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	var health struct {
+		Height uint64 `json:"height"`
+		Head   string `json:"head"`
+	}
+	err = convert(reply, &health)
+	return health.Height, health.Head, err
+}
+
+// WatchAccepted calls handler with every Acceptance the node announces, until
+// the returned cancel function is called
+func (c *Client) WatchAccepted(handler func(ocp.Acceptance)) (func(), error) {
+	return c.t.Subscribe(TopicAccepted, func(topic string, payload map[string]interface{}) {
+		var acceptance ocp.Acceptance
+		if convert(payload, &acceptance) == nil {
+			handler(acceptance)
+		}
+	})
+}
+
+// convert decodes a payload into a typed value
+func convert(payload map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestClientSubmit tests an agent submitting to a node over every transport
+func TestClientSubmit(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	for name, newTransport := range transports() {
+		t.Run(name, func(t *testing.T) {
+			bus, tr := newTransport(t)
+			node := ocp.NewNode(ocp.NewLedger())
+			if err := ServeNode(bus, node); err != nil {
+				t.Fatalf("Failed to serve node: %v", err)
+			}
+			client := NewClient(tr)
+
+			var announced inbox
+			cancel, err := client.WatchAccepted(func(a ocp.Acceptance) {
+				announced.handle(TopicAccepted, a.ToMap())
+			})
+			if err != nil {
+				t.Fatalf("Failed to watch: %v", err)
+			}
+			defer cancel()
+
+			p := &ocp.ContractProposal{
+				ID:              "550e8400-e29b-41d4-a716-446655440000",
+				ProposerAgent:   "Claude",
+				ActionType:      "amend",
+				Action:          map[string]interface{}{"target": "article-3"},
+				Timestamp:       "2025-11-20T14:30:00Z",
+				ReputationStake: 10,
+			}
+			want, _ := p.GetHash()
+			acceptance, err := client.Submit(ctx, p)
+			if err != nil {
+				t.Fatalf("Failed to submit: %v", err)
+			}
+			if acceptance.ProposalHash != want || acceptance.LedgerHeight != 1 {
+				t.Errorf("Unexpected acceptance %+v", acceptance)
+			}
+			if msgs := announced.wait(t, 1); msgs[0]["proposal_hash"] != want {
+				t.Errorf("Unexpected announcement %v", msgs[0])
+			}
+			height, head, err := client.Health(ctx)
+			if err != nil || height != 1 || head != acceptance.EntryHash {
+				t.Errorf("Unexpected health %d %s %v", height, head, err)
+			}
+
+			if again, err := client.Submit(ctx, p); err != nil || again != acceptance {
+				t.Errorf("Resubmission should return the original acceptance: %+v %v", again, err)
+			}
+			t.Logf("✓ %s: accepted %s at height %d", name, acceptance.ProposalHash, acceptance.LedgerHeight)
+		})
+	}
+}
+
+// TestClientEvaluateDraft tests an agent evaluating a draft before submitting
+func TestClientEvaluateDraft(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	for name, newTransport := range transports() {
+		t.Run(name, func(t *testing.T) {
+			bus, tr := newTransport(t)
+			node := ocp.NewNode(ocp.NewLedger())
+			if err := ServeNode(bus, node); err != nil {
+				t.Fatalf("Failed to serve node: %v", err)
+			}
+			if err := ServePreflight(bus, &ocp.Preflight{Node: node}); err != nil {
+				t.Fatalf("Failed to serve preflight: %v", err)
+			}
+			client := NewClient(tr)
+
+			pending := &ocp.ContractProposal{
+				ID:            "550e8400-e29b-41d4-a716-446655440000",
+				ProposerAgent: "Claude",
+				ActionType:    "amend",
+				Action:        map[string]interface{}{"target": "article-3"},
+				Timestamp:     "2025-11-20T14:30:00Z",
+			}
+			if _, err := client.Submit(ctx, pending); err != nil {
+				t.Fatalf("Failed to submit: %v", err)
+			}
+			draft := *pending
+			draft.ID = "550e8400-e29b-41d4-a716-446655440001"
+			report, err := client.EvaluateDraft(ctx, &draft)
+			if err != nil {
+				t.Fatalf("Failed to evaluate: %v", err)
+			}
+			want, _ := pending.GetHash()
+			if c, _ := report.Check(ocp.CheckConflicts); c.Code != ocp.CodeConflictPending || len(report.Conflicts) != 1 || report.Conflicts[0] != want {
+				t.Errorf("Expected a conflict with the pending proposal, got %+v", report)
+			}
+			if height, _, _ := client.Health(ctx); height != 1 {
+				t.Errorf("Evaluating a draft must record nothing, height %d", height)
+			}
+			t.Logf("✓ %s: draft %s conflicts with %s", name, report.ProposalHash, report.Conflicts[0])
+		})
+	}
+}
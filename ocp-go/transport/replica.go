@@ -0,0 +1,286 @@
+// replica.go - Read replicas tailing a node's ledger
+//
+// Verification-heavy workloads scale out by running read replicas: a Follower
+// tails another node's ledger over any Transport and keeps a local copy that
+// verifiers can load without touching the node that accepts proposals. The
+// follower trusts nothing it receives. Every page must chain onto the local
+// head, and every quorum-signed entry must carry valid signatures, before any
+// of it is applied.
+//
+// Each sync re-reads the entry at the local head, so a leader whose history
+// no longer contains what the replica already holds is caught as well as one
+// serving a broken page. Either is a divergence: the follower raises an alarm
+// through OnDivergence, stops applying entries, and keeps the history it had
+// verified, since a replica cannot tell which side of a fork is honest.
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// TopicLedgerEntries replies with a page of ledger entries, for replicas
+const TopicLedgerEntries = "ledger.entries"
+
+// Ledger page sizes
+const (
+	// DefaultReplicaPage is the number of entries a Follower requests per page
+	DefaultReplicaPage = 128
+	// MaxReplicaPage is the most entries ServeLedger returns per page
+	MaxReplicaPage = 1024
+)
+
+// ErrDiverged is returned by a Follower once the leader's history conflicts
+// with the history it has verified
+var ErrDiverged = &ocp.ConstitutionalError{ErrorType: "ReplicaError", Message: "replica diverged from leader"}
+
+// ServeLedger serves pages of ledger's entries on bus. A request names the
+// height to read after and a page limit; the reply holds the entries and the
+// ledger's current height and head.
+func ServeLedger(bus *Memory, ledger *ocp.Ledger) error {
+	return bus.Serve(TopicLedgerEntries, func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		var req struct {
+			After uint64 `json:"after"`
+			Limit int    `json:"limit"`
+		}
+		if err := convert(payload, &req); err != nil {
+			return nil, err
+		}
+		if req.Limit <= 0 || req.Limit > MaxReplicaPage {
+			req.Limit = MaxReplicaPage
+		}
+		page, err := ocp.LedgerPager(ledger)(req.After, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, len(page))
+		for i := range page {
+			m := page[i].ToMap()
+			m["hash"] = page[i].Hash
+			if len(page[i].Hashes) > 0 {
+				m[ocp.HashesKey] = page[i].Hashes
+			}
+			list[i] = m
+		}
+		return map[string]interface{}{
+			"entries": list,
+			"height":  ledger.Height(),
+			"head":    ledger.Head(),
+		}, nil
+	})
+}
+
+// Divergence describes a conflict between a replica and its leader
+type Divergence struct {
+	// Height is the local head the leader no longer holds, or the first
+	// height of a page that failed verification
+	Height uint64
+	// Local is the replica's hash at Height, "" if it holds none
+	Local string
+	// Leader is the hash the leader served at Height, "" if it served none
+	Leader string
+	// Reason says what failed
+	Reason string
+}
+
+// Error implements error
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("diverged at height %d: %s", d.Height, d.Reason)
+}
+
+// Follower keeps a verified local copy of another node's ledger
+type Follower struct {
+	t      Transport
+	ledger *ocp.Ledger
+	quorum *ocp.Quorum
+
+	// PageSize is the number of entries requested per page; defaults to
+	// DefaultReplicaPage
+	PageSize int
+	// OnDivergence, if set, is called once when the follower diverges
+	OnDivergence func(Divergence)
+	// Quarantine, if set, retains the leader's first entry that fails
+	// verification, under the source "replica"
+	Quarantine *ocp.Quarantine
+
+	mu       sync.Mutex
+	diverged *Divergence
+}
+
+// NewFollower creates a Follower applying the leader's entries, reached over
+// t, to ledger. Signed entries must verify against quorum.
+func NewFollower(t Transport, ledger *ocp.Ledger, quorum *ocp.Quorum) *Follower {
+	return &Follower{t: t, ledger: ledger, quorum: quorum}
+}
+
+// Ledger returns the replica's local ledger
+func (f *Follower) Ledger() *ocp.Ledger {
+	return f.ledger
+}
+
+// Diverged returns the divergence that stopped the follower, or nil
+func (f *Follower) Diverged() *Divergence {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.diverged == nil {
+		return nil
+	}
+	d := *f.diverged
+	return &d
+}
+
+// Sync applies every entry the leader holds beyond the local head
+//
+// Returns:
+//   - Number of entries applied
+//   - An error wrapping ErrDiverged, with a *Divergence in its chain, if the
+//     leader conflicts with the local history; transport errors are returned
+//     as they are and leave the follower able to retry
+func (f *Follower) Sync(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	if d := f.diverged; d != nil {
+		f.mu.Unlock()
+		return 0, fmt.Errorf("%w: %w", ErrDiverged, d)
+	}
+	applied, d, err := f.sync(ctx)
+	f.diverged = d
+	f.mu.Unlock()
+
+	if d == nil {
+		return applied, err
+	}
+	if f.OnDivergence != nil {
+		f.OnDivergence(*d)
+	}
+	return applied, fmt.Errorf("%w: %w", ErrDiverged, d)
+}
+
+// sync applies the leader's pages; the caller holds f.mu
+func (f *Follower) sync(ctx context.Context) (int, *Divergence, error) {
+	pageSize := f.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultReplicaPage
+	}
+
+	applied := 0
+	for {
+		height, head := f.ledger.Height(), f.ledger.Head()
+		// Re-read the local head so a rewritten leader history is noticed
+		after := height
+		if height > 0 {
+			after = height - 1
+		}
+		page, leaderHeight, err := f.fetch(ctx, after, pageSize+1)
+		if err != nil {
+			return applied, nil, err
+		}
+		if height > 0 {
+			if len(page) == 0 || page[0].Height != height || page[0].Hash != head {
+				d := &Divergence{Height: height, Local: head, Reason: "leader does not hold the local head"}
+				if len(page) > 0 && page[0].Height == height {
+					d.Leader = page[0].Hash
+				}
+				return applied, d, nil
+			}
+			page = page[1:]
+		}
+		if len(page) == 0 {
+			if leaderHeight < height {
+				return applied, &Divergence{Height: height, Local: head, Reason: fmt.Sprintf("leader is at lower height %d", leaderHeight)}, nil
+			}
+			return applied, nil, nil
+		}
+
+		if _, err := ocp.VerifyChain(height, head, page); err != nil {
+			return applied, f.reject(height, head, page, err), nil
+		}
+		if f.quorum != nil {
+			if err := ocp.VerifyEntrySignatures(f.ledger.Entries(0), page, f.quorum); err != nil {
+				return applied, f.reject(height, head, page, err), nil
+			}
+		}
+		if err := f.ledger.Extend(page); err != nil {
+			return applied, nil, err
+		}
+		applied += len(page)
+	}
+}
+
+// reject records a page that failed verification with err, quarantining its
+// first bad entry; the caller holds f.mu
+func (f *Follower) reject(height uint64, head string, page []ocp.LedgerEntry, err error) *Divergence {
+	d := &Divergence{Height: height + 1, Leader: page[0].Hash, Reason: err.Error()}
+	if f.Quarantine == nil {
+		return d
+	}
+	prior := f.ledger.Entries(0)
+	for i, e := range page {
+		var failure ocp.CheckResult
+		if _, err := ocp.VerifyChain(height, head, page[i:i+1]); err != nil {
+			failure = ocp.CheckResult{Check: ocp.CheckHashChain, Status: ocp.CheckFailed, Code: ocp.CodeChainBroken, Message: err.Error()}
+		} else if f.quorum != nil {
+			if err := ocp.VerifyEntrySignatures(append(prior[:len(prior):len(prior)], page[:i]...), page[i:i+1], f.quorum); err != nil {
+				failure = ocp.CheckResult{Check: ocp.CheckSignature, Status: ocp.CheckFailed, Code: ocp.CodeSignatureInvalid, Message: err.Error()}
+			}
+		}
+		if failure.Check != "" {
+			f.Quarantine.AdmitEntry(e, []ocp.CheckResult{failure}, "replica")
+			break
+		}
+		height, head = e.Height, e.Hash
+	}
+	return d
+}
+
+// Follow syncs until ctx is done: whenever the leader announces an accepted
+// proposal, and every interval in case an announcement was missed or an
+// entry was appended without one. It returns ctx's error, or an error
+// wrapping ErrDiverged once the follower diverges.
+func (f *Follower) Follow(ctx context.Context, interval time.Duration) error {
+	wake := make(chan struct{}, 1)
+	cancel, err := f.t.Subscribe(TopicAccepted, func(string, map[string]interface{}) {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := f.Sync(ctx); err != nil && f.Diverged() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch requests up to limit entries after height from the leader
+func (f *Follower) fetch(ctx context.Context, after uint64, limit int) ([]ocp.LedgerEntry, uint64, error) {
+	reply, err := f.t.Request(ctx, TopicLedgerEntries, map[string]interface{}{"after": after, "limit": limit})
+	if err != nil {
+		return nil, 0, err
+	}
+	var page struct {
+		Entries []ocp.LedgerEntry `json:"entries"`
+		Height  uint64            `json:"height"`
+	}
+	if err := convert(reply, &page); err != nil {
+		return nil, 0, err
+	}
+	return page.Entries, page.Height, nil
+}
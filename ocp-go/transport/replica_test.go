@@ -0,0 +1,190 @@
+package transport
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// replicaQuorum returns a two-of-two quorum and its members' keys
+func replicaQuorum(t *testing.T) (*ocp.Quorum, map[string]ed25519.PrivateKey) {
+	t.Helper()
+	members := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range []string{"Claude", "Gemini"} {
+		seed := make([]byte, ed25519.SeedSize)
+		copy(seed, name)
+		privs[name] = ed25519.NewKeyFromSeed(seed)
+		members[name] = privs[name].Public().(ed25519.PublicKey)
+	}
+	quorum, err := ocp.NewQuorum(members, 2)
+	if err != nil {
+		t.Fatalf("Failed to create quorum: %v", err)
+	}
+	return quorum, privs
+}
+
+// recordPolicy records a policy table signed by every key in privs
+func recordPolicy(t *testing.T, ledger *ocp.Ledger, quorum *ocp.Quorum, privs map[string]ed25519.PrivateKey, policies map[string]interface{}) {
+	t.Helper()
+	hash, _ := ocp.SemanticHash(policies)
+	var sigs []ocp.Signature
+	for name, key := range privs {
+		sig, err := ocp.SignHash(name, key, ocp.ContextPolicy, hash)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	if _, err := ocp.RecordPolicy(ledger, quorum, policies, sigs); err != nil {
+		t.Fatalf("Failed to record policy: %v", err)
+	}
+}
+
+func replicaProposal(n int) *ocp.ContractProposal {
+	return &ocp.ContractProposal{
+		ID:              fmt.Sprintf("replica-%d", n),
+		ProposerAgent:   "Claude",
+		ActionType:      "amend",
+		Action:          map[string]interface{}{"target": "article-3"},
+		Timestamp:       "2025-11-20T14:30:00Z",
+		ReputationStake: 10,
+	}
+}
+
+// TestFollowerSync tests a replica catching up over every transport
+func TestFollowerSync(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	quorum, privs := replicaQuorum(t)
+	for name, newTransport := range transports() {
+		t.Run(name, func(t *testing.T) {
+			bus, tr := newTransport(t)
+			node := ocp.NewNode(ocp.NewLedger())
+			if err := ServeNode(bus, node); err != nil {
+				t.Fatalf("Failed to serve node: %v", err)
+			}
+			for i := 0; i < 4; i++ {
+				node.Submit(replicaProposal(i))
+			}
+			recordPolicy(t, node.Ledger(), quorum, privs, map[string]interface{}{"max_stake": 100})
+
+			follower := NewFollower(tr, ocp.NewLedger(), quorum)
+			follower.PageSize = 2
+			applied, err := follower.Sync(ctx)
+			if err != nil || applied != 5 {
+				t.Fatalf("Expected 5 entries applied, got %d, %v", applied, err)
+			}
+			node.Submit(replicaProposal(4))
+			if applied, err := follower.Sync(ctx); err != nil || applied != 1 {
+				t.Errorf("Expected 1 more entry, got %d, %v", applied, err)
+			}
+			if follower.Ledger().Head() != node.Ledger().Head() || follower.Ledger().Verify() != nil {
+				t.Errorf("Replica does not match the leader")
+			}
+			t.Logf("✓ %s: replica at height %d", name, follower.Ledger().Height())
+		})
+	}
+}
+
+// TestFollowerFollow tests that announcements wake a following replica
+func TestFollowerFollow(t *testing.T) {
+	testenv.RequireSigning(t)
+	bus := NewMemory()
+	node := ocp.NewNode(ocp.NewLedger())
+	ServeNode(bus, node)
+	follower := NewFollower(bus, ocp.NewLedger(), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- follower.Follow(ctx, time.Hour) }()
+
+	client := NewClient(bus)
+	deadline := time.Now().Add(2 * time.Second)
+	for follower.Ledger().Height() < 3 && time.Now().Before(deadline) {
+		client.Submit(context.Background(), replicaProposal(int(node.Ledger().Height())))
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Follow to stop with the context, got %v", err)
+	}
+	if follower.Ledger().Height() < 3 {
+		t.Errorf("Replica did not follow announcements, at height %d", follower.Ledger().Height())
+	}
+
+	t.Logf("✓ Replica followed to height %d", follower.Ledger().Height())
+}
+
+// TestFollowerDivergence tests the alarms raised by conflicting leaders
+func TestFollowerDivergence(t *testing.T) {
+	testenv.RequireSigning(t)
+	ctx := context.Background()
+	quorum, privs := replicaQuorum(t)
+
+	// A leader serving a policy its quorum never approved; the page holding
+	// it is refused as a whole
+	leader := ocp.NewLedger()
+	recordPolicy(t, leader, quorum, privs, map[string]interface{}{"max_stake": 100})
+	leader.Append(ocp.LedgerKindPolicy, map[string]interface{}{
+		"policy_hash": "forged",
+		"policies":    map[string]interface{}{"max_stake": 1000},
+	})
+	bus := NewMemory()
+	ServeLedger(bus, leader)
+	var alarms []Divergence
+	follower := NewFollower(bus, ocp.NewLedger(), quorum)
+	follower.OnDivergence = func(d Divergence) { alarms = append(alarms, d) }
+	follower.Quarantine = ocp.NewQuarantine(0)
+	applied, err := follower.Sync(ctx)
+	var d *Divergence
+	if !errors.Is(err, ErrDiverged) || !errors.As(err, &d) || d.Height != 1 || applied != 0 {
+		t.Fatalf("Expected divergence at height 1, got %d, %v", applied, err)
+	}
+	if follower.Ledger().Height() != 0 {
+		t.Errorf("Unverified entries were applied")
+	}
+	held := follower.Quarantine.Query(ocp.QuarantineQuery{Source: "replica"})
+	if len(held) != 1 || held[0].Object["height"] != uint64(2) || held[0].Failures[0].Check != ocp.CheckSignature {
+		t.Errorf("Expected the forged policy entry quarantined, got %+v", held)
+	}
+
+	// A leader whose history was rewritten under an existing replica
+	honest := ocp.NewLedger()
+	for i := 0; i < 3; i++ {
+		honest.Append(ocp.LedgerKindProposal, map[string]interface{}{"proposal_hash": fmt.Sprintf("p-%d", i)})
+	}
+	bus = NewMemory()
+	ServeLedger(bus, honest)
+	replica := NewFollower(bus, ocp.NewLedger(), quorum)
+	replica.OnDivergence = func(d Divergence) { alarms = append(alarms, d) }
+	if _, err := replica.Sync(ctx); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	forked := ocp.NewLedger()
+	for i := 0; i < 4; i++ {
+		forked.Append(ocp.LedgerKindProposal, map[string]interface{}{"proposal_hash": fmt.Sprintf("fork-%d", i)})
+	}
+	ServeLedger(bus, forked)
+	if _, err := replica.Sync(ctx); !errors.Is(err, ErrDiverged) {
+		t.Fatalf("Expected a rewritten history to diverge, got %v", err)
+	}
+	got := replica.Diverged()
+	if got == nil || got.Height != 3 || got.Local != honest.Head() || got.Leader == "" {
+		t.Errorf("Unexpected divergence %+v", got)
+	}
+
+	// The follower stays stopped, and each alarm is raised once
+	ServeLedger(bus, honest)
+	if _, err := replica.Sync(ctx); !errors.Is(err, ErrDiverged) || len(alarms) != 2 {
+		t.Errorf("Expected a stopped follower and 2 alarms, got %v, %d", err, len(alarms))
+	}
+
+	t.Logf("✓ Divergence reported: %v", got)
+}
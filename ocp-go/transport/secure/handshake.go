@@ -0,0 +1,326 @@
+// handshake.go - Handshake and record layer
+//
+// The handshake follows Noise XX's message flow and key schedule, with
+// identities proved by Ed25519 signatures instead of static Diffie-Hellman,
+// since agents' registered keys are signing keys:
+//
+//	-> e
+//	<- e, ee, enc(agent, sig)
+//	-> enc(agent, sig)
+//
+// The chaining key starts from the hash of the protocol name and mixes in the
+// ephemeral X25519 shared secret with HKDF-SHA256. The transcript hash covers
+// every handshake byte sent so far; each side signs it under
+// ocp.ContextHandshake together with its role, so a responder's signature is
+// never valid as an initiator's. Identity messages and all later records are
+// sealed with AES-256-GCM under counter nonces, one key per direction.
+
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// protocolName identifies the handshake and cipher suite
+const protocolName = "OCP_Secure_XX_25519+Ed25519_AESGCM_SHA256"
+
+// MaxRecordPlaintext bounds the plaintext of one record
+const MaxRecordPlaintext = 16 * 1024
+
+// maxRecord bounds a record on the wire, plaintext plus the GCM tag
+const maxRecord = MaxRecordPlaintext + 16
+
+// cipherState seals or opens the records of one direction
+type cipherState struct {
+	aead  cipher.AEAD
+	nonce uint64
+}
+
+func newCipherState(key []byte) *cipherState {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err) // keys are always 32 bytes
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &cipherState{aead: aead}
+}
+
+func (cs *cipherState) nextNonce() []byte {
+	nonce := make([]byte, cs.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[4:], cs.nonce)
+	cs.nonce++
+	return nonce
+}
+
+func (cs *cipherState) sealWith(ad, plaintext []byte) []byte {
+	return cs.aead.Seal(nil, cs.nextNonce(), plaintext, ad)
+}
+
+func (cs *cipherState) openWith(ad, ciphertext []byte) ([]byte, error) {
+	plaintext, err := cs.aead.Open(nil, cs.nextNonce(), ciphertext, ad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: record authentication failed", ErrHandshake)
+	}
+	return plaintext, nil
+}
+
+func (cs *cipherState) seal(plaintext []byte) []byte {
+	return cs.sealWith(nil, plaintext)
+}
+
+func (cs *cipherState) open(ciphertext []byte) ([]byte, error) {
+	return cs.openWith(nil, ciphertext)
+}
+
+// hkdf2 is Noise's HKDF with two outputs
+func hkdf2(chainingKey, input []byte) ([]byte, []byte) {
+	mac := hmac.New(sha256.New, chainingKey)
+	mac.Write(input)
+	temp := mac.Sum(nil)
+	mac = hmac.New(sha256.New, temp)
+	mac.Write([]byte{1})
+	out1 := mac.Sum(nil)
+	mac = hmac.New(sha256.New, temp)
+	mac.Write(out1)
+	mac.Write([]byte{2})
+	return out1, mac.Sum(nil)
+}
+
+// symmetricState tracks the chaining key and transcript hash
+type symmetricState struct {
+	ck []byte
+	h  []byte
+}
+
+func newSymmetricState() *symmetricState {
+	h := sha256.Sum256([]byte(protocolName))
+	return &symmetricState{ck: h[:], h: h[:]}
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(s.h)
+	h.Write(data)
+	s.h = h.Sum(nil)
+}
+
+// mixKey mixes a shared secret into the chaining key and returns the
+// handshake cipher
+func (s *symmetricState) mixKey(secret []byte) *cipherState {
+	var key []byte
+	s.ck, key = hkdf2(s.ck, secret)
+	return newCipherState(key)
+}
+
+// split returns the initiator-to-responder and responder-to-initiator ciphers
+func (s *symmetricState) split() (*cipherState, *cipherState) {
+	k1, k2 := hkdf2(s.ck, nil)
+	return newCipherState(k1), newCipherState(k2)
+}
+
+// handshakeResult is an established channel
+type handshakeResult struct {
+	peer       string
+	send, recv *cipherState
+}
+
+// writeRecord writes a length-prefixed record
+func writeRecord(w io.Writer, data []byte) error {
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readRecord reads a length-prefixed record
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxRecord {
+		return nil, fmt.Errorf("%w: record of %d bytes exceeds the limit", ErrHandshake, n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// identityMessage returns the plaintext proving id's identity over transcript h
+func identityMessage(id Identity, role string, h []byte) ([]byte, error) {
+	sig, err := ocp.SignHash(id.Agent, id.Key, ocp.ContextHandshake, transcriptDigest(role, h))
+	if err != nil {
+		return nil, err
+	}
+	form, err := canonical.Canonicalize(map[string]interface{}{
+		"agent":     id.Agent,
+		"signature": sig.ToMap(),
+	}, true)
+	return []byte(form), err
+}
+
+// checkIdentity verifies an identity message and returns the agent it proves
+func checkIdentity(data []byte, registry Registry, role string, h []byte) (string, error) {
+	msg, err := canonical.DecodeStrict(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed identity: %v", ErrHandshake, err)
+	}
+	agent, _ := msg["agent"].(string)
+	key, ok := registry.PublicKey(agent)
+	if !ok {
+		return "", fmt.Errorf("%w: agent %q is not registered", ErrHandshake, agent)
+	}
+	m, _ := msg["signature"].(map[string]interface{})
+	str := func(k string) string { s, _ := m[k].(string); return s }
+	sig := ocp.Signature{Signer: str("signer"), Algorithm: str("algorithm"), Value: str("value")}
+	if sig.Signer != agent {
+		return "", fmt.Errorf("%w: identity of %q signed by %q", ErrHandshake, agent, sig.Signer)
+	}
+	if err := ocp.VerifyHashSignature(key, ocp.ContextHandshake, transcriptDigest(role, h), sig); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	return agent, nil
+}
+
+// transcriptDigest binds the transcript hash to the signer's role
+func transcriptDigest(role string, h []byte) string {
+	d := sha256.New()
+	d.Write([]byte(role))
+	d.Write([]byte{0})
+	d.Write(h)
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+// initiate runs the initiator's side of the handshake over rw
+func initiate(rw io.ReadWriter, id Identity, registry Registry, expect string) (*handshakeResult, error) {
+	s := newSymmetricState()
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	// -> e
+	if err := writeRecord(rw, e.PublicKey().Bytes()); err != nil {
+		return nil, err
+	}
+	s.mixHash(e.PublicKey().Bytes())
+
+	// <- e, ee, enc(agent, sig)
+	msg, err := readRecord(rw)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) < 32 {
+		return nil, fmt.Errorf("%w: short responder message", ErrHandshake)
+	}
+	re, err := ecdh.X25519().NewPublicKey(msg[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	s.mixHash(msg[:32])
+	secret, err := e.ECDH(re)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	hs := s.mixKey(secret)
+	signed := s.h
+	plaintext, err := hs.openWith(s.h, msg[32:])
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(msg[32:])
+	peer, err := checkIdentity(plaintext, registry, "responder", signed)
+	if err != nil {
+		return nil, err
+	}
+	if expect != "" && peer != expect {
+		return nil, fmt.Errorf("%w: expected %q, reached %q", ErrHandshake, expect, peer)
+	}
+
+	// -> enc(agent, sig)
+	identity, err := identityMessage(id, "initiator", s.h)
+	if err != nil {
+		return nil, err
+	}
+	sealed := hs.sealWith(s.h, identity)
+	if err := writeRecord(rw, sealed); err != nil {
+		return nil, err
+	}
+	s.mixHash(sealed)
+
+	send, recv := s.split()
+	return &handshakeResult{peer: peer, send: send, recv: recv}, nil
+}
+
+// respond runs the responder's side of the handshake over rw
+func respond(rw io.ReadWriter, id Identity, registry Registry) (*handshakeResult, error) {
+	s := newSymmetricState()
+	// -> e
+	msg, err := readRecord(rw)
+	if err != nil {
+		return nil, err
+	}
+	ie, err := ecdh.X25519().NewPublicKey(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	s.mixHash(msg)
+
+	// <- e, ee, enc(agent, sig)
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(e.PublicKey().Bytes())
+	secret, err := e.ECDH(ie)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	hs := s.mixKey(secret)
+	identity, err := identityMessage(id, "responder", s.h)
+	if err != nil {
+		return nil, err
+	}
+	sealed := hs.sealWith(s.h, identity)
+	if err := writeRecord(rw, append(e.PublicKey().Bytes(), sealed...)); err != nil {
+		return nil, err
+	}
+	s.mixHash(sealed)
+
+	// -> enc(agent, sig)
+	msg, err = readRecord(rw)
+	if err != nil {
+		return nil, err
+	}
+	signed := s.h
+	plaintext, err := hs.openWith(s.h, msg)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(msg)
+	peer, err := checkIdentity(plaintext, registry, "initiator", signed)
+	if err != nil {
+		return nil, err
+	}
+
+	initiatorToResponder, responderToInitiator := s.split()
+	return &handshakeResult{peer: peer, send: responderToInitiator, recv: initiatorToResponder}, nil
+}
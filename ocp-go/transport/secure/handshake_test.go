@@ -0,0 +1,64 @@
+package secure
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestRecords tests that records are bound to their order and content
+func TestRecords(t *testing.T) {
+	s := newSymmetricState()
+	s.mixHash([]byte("transcript"))
+	send, _ := s.split()
+	s2 := newSymmetricState()
+	s2.mixHash([]byte("transcript"))
+	recv, _ := s2.split()
+
+	first, second := send.seal([]byte("one")), send.seal([]byte("two"))
+	if _, err := recv.open(second); !errors.Is(err, ErrHandshake) {
+		t.Errorf("A reordered record should fail, got %v", err)
+	}
+
+	s3 := newSymmetricState()
+	s3.mixHash([]byte("transcript"))
+	recv, _ = s3.split()
+	tampered := append([]byte(nil), first...)
+	tampered[0] ^= 1
+	if _, err := recv.open(tampered); err == nil {
+		t.Error("A tampered record should fail")
+	}
+
+	var wire bytes.Buffer
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, maxRecord+1)
+	wire.Write(header)
+	if _, err := readRecord(&wire); !errors.Is(err, ErrHandshake) {
+		t.Errorf("An oversized record should be refused, got %v", err)
+	}
+	t.Logf("✓ Records bound to order and content")
+}
+
+// TestTranscriptRoles tests that a signature for one role is not valid for the other
+func TestTranscriptRoles(t *testing.T) {
+	testenv.RequireSigning(t)
+	registry := testRegistry("Claude")
+	h := []byte("handshake hash")
+	msg, err := identityMessage(testIdentity("Claude"), "responder", h)
+	if err != nil {
+		t.Fatalf("identityMessage failed: %v", err)
+	}
+	if agent, err := checkIdentity(msg, registry, "responder", h); err != nil || agent != "Claude" {
+		t.Errorf("Expected the identity to verify, got %q %v", agent, err)
+	}
+	if _, err := checkIdentity(msg, registry, "initiator", h); !errors.Is(err, ErrHandshake) {
+		t.Errorf("A responder signature should not prove an initiator, got %v", err)
+	}
+	if _, err := checkIdentity(msg, registry, "responder", []byte("other hash")); !errors.Is(err, ErrHandshake) {
+		t.Errorf("A signature over another transcript should fail, got %v", err)
+	}
+	t.Logf("✓ Identities bound to role and transcript")
+}
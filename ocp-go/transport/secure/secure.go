@@ -0,0 +1,262 @@
+// Package secure encrypts and mutually authenticates transport connections
+// between agents using the identities they sign with.
+//
+// Proposal negotiation between agents carries drafts and positions that are
+// not meant for the network at large, and each side must know it is talking
+// to the agent it thinks it is. Server and Client wrap a net.Conn in a Conn
+// that, before its first Read or Write, runs a handshake modelled on the
+// Noise XX pattern: both sides exchange ephemeral X25519 keys, derive keys
+// from the shared secret, and prove their identities inside the encrypted
+// handshake by signing its transcript with their Ed25519 identity keys. A
+// peer whose key is not in the identity Registry, or whose signature does not
+// verify, is refused, so a channel is only ever established between
+// registered agents, and a recorded session stays secret even if identity
+// keys later leak.
+//
+// Listen and Dial apply the wrapper to a listener and an outgoing
+// connection, and the results plug into transport.ServeTCP and
+// transport.NewTCPClient, so the TCP transport runs unchanged over encrypted
+// channels.
+package secure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+)
+
+// HandshakeTimeout bounds the handshake of one connection
+const HandshakeTimeout = 10 * time.Second
+
+// ErrHandshake is returned when a peer fails the handshake
+var ErrHandshake = &ocp.ConstitutionalError{ErrorType: "TransportError", Message: "secure handshake failed"}
+
+// Identity is the agent a side of a channel speaks as
+type Identity struct {
+	Agent string
+	Key   ed25519.PrivateKey
+}
+
+// Registry resolves agents to the public keys they sign with
+type Registry interface {
+	PublicKey(agent string) (ed25519.PublicKey, bool)
+}
+
+// Keys is a Registry over a fixed set of keys, such as a Quorum's Members
+type Keys map[string]ed25519.PublicKey
+
+// PublicKey returns agent's key
+func (k Keys) PublicKey(agent string) (ed25519.PublicKey, bool) {
+	key, ok := k[agent]
+	return key, ok
+}
+
+// Conn is an encrypted, mutually authenticated connection
+type Conn struct {
+	net.Conn
+	initiator bool
+	id        Identity
+	registry  Registry
+	// expect, if set, is the only peer the initiator accepts
+	expect string
+
+	handshakeMu  sync.Mutex
+	handshakeErr error
+	done         bool
+	peer         string
+
+	// The caller's deadlines, restored once the handshake's own expires
+	deadlineMu    sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	readMu  sync.Mutex
+	recv    *cipherState
+	pending []byte
+
+	writeMu sync.Mutex
+	send    *cipherState
+}
+
+// Client wraps c as the initiating side of a channel to peer, or to any
+// registered agent if peer is empty
+func Client(c net.Conn, id Identity, registry Registry, peer string) *Conn {
+	return &Conn{Conn: c, initiator: true, id: id, registry: registry, expect: peer}
+}
+
+// Server wraps c as the responding side of a channel from any registered agent
+func Server(c net.Conn, id Identity, registry Registry) *Conn {
+	return &Conn{Conn: c, id: id, registry: registry}
+}
+
+// Handshake runs the handshake if it has not run yet. Read and Write call it
+// implicitly. It runs under a deadline of HandshakeTimeout, or the caller's
+// deadline if that is earlier, and restores the caller's deadlines after.
+//
+// Returns:
+//   - ErrHandshake if the peer is unregistered, unexpected, or fails to
+//     prove its identity
+func (c *Conn) Handshake() error {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+	if c.done {
+		return c.handshakeErr
+	}
+	c.done = true
+	c.deadlineMu.Lock()
+	limit := time.Now().Add(HandshakeTimeout)
+	c.Conn.SetReadDeadline(earlier(c.readDeadline, limit))
+	c.Conn.SetWriteDeadline(earlier(c.writeDeadline, limit))
+	c.deadlineMu.Unlock()
+	defer c.restoreDeadlines()
+
+	var result *handshakeResult
+	var err error
+	if c.initiator {
+		result, err = initiate(c.Conn, c.id, c.registry, c.expect)
+	} else {
+		result, err = respond(c.Conn, c.id, c.registry)
+	}
+	if err != nil {
+		c.handshakeErr = err
+		c.Conn.Close()
+		return err
+	}
+	c.peer, c.send, c.recv = result.peer, result.send, result.recv
+	return nil
+}
+
+// SetDeadline sets the read and write deadlines of the connection
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline, c.writeDeadline = t, t
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for Read calls
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for Write calls
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.writeDeadline = t
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// restoreDeadlines puts the caller's deadlines back on the connection
+func (c *Conn) restoreDeadlines() {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.Conn.SetReadDeadline(c.readDeadline)
+	c.Conn.SetWriteDeadline(c.writeDeadline)
+}
+
+// earlier returns deadline if it is set and before limit, otherwise limit
+func earlier(deadline, limit time.Time) time.Time {
+	if !deadline.IsZero() && deadline.Before(limit) {
+		return deadline
+	}
+	return limit
+}
+
+// Peer returns the authenticated agent on the other side, completing the
+// handshake if needed
+func (c *Conn) Peer() (string, error) {
+	if err := c.Handshake(); err != nil {
+		return "", err
+	}
+	return c.peer, nil
+}
+
+// Read reads decrypted data, completing the handshake if needed
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	for len(c.pending) == 0 {
+		record, err := readRecord(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+		if c.pending, err = c.recv.open(record); err != nil {
+			c.Conn.Close()
+			return 0, err
+		}
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write encrypts and writes b, completing the handshake if needed
+func (c *Conn) Write(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	written := 0
+	for written < len(b) {
+		chunk := b[written:]
+		if len(chunk) > MaxRecordPlaintext {
+			chunk = chunk[:MaxRecordPlaintext]
+		}
+		if err := writeRecord(c.Conn, c.send.seal(chunk)); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return written, nil
+}
+
+// listener wraps accepted connections with Server
+type listener struct {
+	net.Listener
+	id       Identity
+	registry Registry
+}
+
+// Listen returns a listener whose connections are the responding side of
+// channels. Accept returns before the handshake, which runs on first use, so
+// a slow or hostile client does not hold up others.
+func Listen(l net.Listener, id Identity, registry Registry) net.Listener {
+	return &listener{Listener: l, id: id, registry: registry}
+}
+
+// Accept waits for the next connection and wraps it
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return Server(c, l.id, l.registry), nil
+}
+
+// Dial connects to addr over TCP and completes the handshake with peer, or
+// with any registered agent if peer is empty
+func Dial(ctx context.Context, addr string, id Identity, registry Registry, peer string) (*Conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("secure connect: %w", err)
+	}
+	c := Client(nc, id, registry, peer)
+	if err := c.Handshake(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,190 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+	"github.com/seanrugg/ai_constitution/ocp-go/transport"
+)
+
+// testIdentity derives a deterministic identity for an agent name
+func testIdentity(name string) Identity {
+	seed := make([]byte, ed25519.SeedSize)
+	copy(seed, name)
+	return Identity{Agent: name, Key: ed25519.NewKeyFromSeed(seed)}
+}
+
+// testRegistry registers the named agents
+func testRegistry(names ...string) Keys {
+	keys := make(Keys)
+	for _, name := range names {
+		keys[name] = testIdentity(name).Key.Public().(ed25519.PublicKey)
+	}
+	return keys
+}
+
+// recorder captures the bytes written to a connection
+type recorder struct {
+	net.Conn
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	r.buf.Write(b)
+	r.mu.Unlock()
+	return r.Conn.Write(b)
+}
+
+// TestChannel tests mutual authentication and that traffic is encrypted
+func TestChannel(t *testing.T) {
+	testenv.RequireSigning(t)
+	registry := testRegistry("Claude", "Gemini")
+	a, b := net.Pipe()
+	wire := &recorder{Conn: a}
+	client := Client(wire, testIdentity("Claude"), registry, "Gemini")
+	server := Server(b, testIdentity("Gemini"), registry)
+	defer client.Close()
+	defer server.Close()
+
+	secret := []byte("counter-proposal: amend article III, stake 40")
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.Write(secret)
+		errs <- err
+	}()
+	got := make([]byte, len(secret))
+	if _, err := io.ReadFull(server, got); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("Expected %q, got %q", secret, got)
+	}
+	if bytes.Contains(wire.buf.Bytes(), []byte("article III")) {
+		t.Error("Plaintext should not appear on the wire")
+	}
+
+	clientPeer, _ := client.Peer()
+	serverPeer, _ := server.Peer()
+	if clientPeer != "Gemini" || serverPeer != "Claude" {
+		t.Errorf("Expected mutual identities, got %q and %q", clientPeer, serverPeer)
+	}
+	t.Logf("✓ %s <-> %s over %d encrypted bytes", clientPeer, serverPeer, wire.buf.Len())
+}
+
+// TestHandshakeKeepsDeadlines tests that a deadline set before the handshake
+// still applies after it
+func TestHandshakeKeepsDeadlines(t *testing.T) {
+	testenv.RequireSigning(t)
+	registry := testRegistry("Claude", "Gemini")
+	a, b := net.Pipe()
+	client := Client(a, testIdentity("Claude"), registry, "Gemini")
+	server := Server(b, testIdentity("Gemini"), registry)
+	defer client.Close()
+	defer server.Close()
+
+	if err := client.SetReadDeadline(time.Now().Add(500 * time.Millisecond)); err != nil {
+		t.Fatalf("Failed to set deadline: %v", err)
+	}
+	errs := make(chan error, 1)
+	go func() { errs <- server.Handshake() }()
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Handshake failed: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Server handshake failed: %v", err)
+	}
+
+	// Nothing is written, so the read must end at the caller's deadline
+	_, err := client.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline to survive the handshake, got %v", err)
+	}
+	t.Log("✓ Caller deadline restored after the handshake")
+}
+
+// TestChannelRefusals tests that unregistered and unexpected peers are refused
+func TestChannelRefusals(t *testing.T) {
+	testenv.RequireSigning(t)
+	cases := []struct {
+		name           string
+		serverRegistry Keys
+		clientRegistry Keys
+		server         string
+		expect         string
+	}{
+		{"unregistered initiator", testRegistry("Gemini"), testRegistry("Gemini"), "Gemini", ""},
+		{"unregistered responder", testRegistry("Claude"), testRegistry("Claude"), "Gemini", ""},
+		{"unexpected responder", testRegistry("Claude", "Mallory"), testRegistry("Claude", "Mallory", "Gemini"), "Mallory", "Gemini"},
+	}
+	for _, tc := range cases {
+		a, b := net.Pipe()
+		client := Client(a, testIdentity("Claude"), tc.clientRegistry, tc.expect)
+		server := Server(b, testIdentity(tc.server), tc.serverRegistry)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- server.Handshake()
+		}()
+		clientErr := client.Handshake()
+		if clientErr == nil {
+			// The responder refuses only after the initiator's last message
+			_, clientErr = client.Read(make([]byte, 1))
+		}
+		serverErr := <-errs
+		if !errors.Is(clientErr, ErrHandshake) && !errors.Is(serverErr, ErrHandshake) {
+			t.Errorf("%s: expected a handshake failure, got %v and %v", tc.name, clientErr, serverErr)
+		}
+		client.Close()
+		server.Close()
+	}
+	t.Logf("✓ %d refusals", len(cases))
+}
+
+// TestSecureTCPTransport tests the TCP transport over secure channels
+func TestSecureTCPTransport(t *testing.T) {
+	testenv.RequireSigning(t)
+	registry := testRegistry("Claude", "Gemini")
+	bus := transport.NewMemory()
+	bus.Serve("proposals.negotiate", func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"accepted": payload["offer"]}, nil
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := transport.ServeTCP(Listen(l, testIdentity("Gemini"), registry), bus)
+	defer server.Close()
+
+	conn, err := Dial(context.Background(), server.Addr(), testIdentity("Claude"), registry, "Gemini")
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	client := transport.NewTCPClient(conn)
+	defer client.Close()
+
+	reply, err := client.Request(context.Background(), "proposals.negotiate", map[string]interface{}{"offer": "split evidence review"})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if reply["accepted"] != "split evidence review" {
+		t.Errorf("Unexpected reply %v", reply)
+	}
+
+	if _, err := Dial(context.Background(), server.Addr(), testIdentity("Claude"), registry, "DeepSeek"); !errors.Is(err, ErrHandshake) {
+		t.Errorf("Dialing the wrong agent should fail the handshake, got %v", err)
+	}
+	t.Logf("✓ Request answered over an encrypted channel")
+}
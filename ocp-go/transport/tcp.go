@@ -0,0 +1,418 @@
+// tcp.go - Transport over TCP
+//
+// A TCPServer exposes a Memory bus on a TCP listener and DialTCP connects to
+// it. Each side writes frames as canonical JSON objects, one per line, and
+// decodes the other side's frames strictly. A client's subscriptions and
+// requests are forwarded to the server's bus; messages for its subscriptions
+// and replies to its requests come back on the same connection. Requests are
+// answered concurrently, so a slow responder does not hold up other traffic.
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// WriteTimeout bounds writing one frame; a peer that stops reading for
+// longer is disconnected instead of stalling the bus
+const WriteTimeout = 10 * time.Second
+
+// frame types on the wire
+const (
+	frameSend        = "send"
+	frameSubscribe   = "sub"
+	frameUnsubscribe = "unsub"
+	frameRequest     = "req"
+	frameMessage     = "msg"
+	frameResponse    = "resp"
+)
+
+// conn serializes frame writes on a network connection
+type conn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+func (c *conn) write(frame map[string]interface{}) error {
+	form, err := canonical.Canonicalize(frame, true)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if _, err := c.Write(append([]byte(form), '\n')); err != nil {
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+// readFrames calls handle for every frame read from r until it fails
+func readFrames(r net.Conn, handle func(frame map[string]interface{})) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxFrameBytes+1024)
+	for scanner.Scan() {
+		frame, err := canonical.DecodeStrict(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		handle(frame)
+	}
+	return scanner.Err()
+}
+
+// frameString, frameID, and framePayload read frame fields
+func frameString(frame map[string]interface{}, key string) string {
+	s, _ := frame[key].(string)
+	return s
+}
+
+func frameID(frame map[string]interface{}) uint64 {
+	id, _ := frame["id"].(float64)
+	return uint64(id)
+}
+
+func framePayload(frame map[string]interface{}) map[string]interface{} {
+	payload, _ := frame["payload"].(map[string]interface{})
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	return payload
+}
+
+// responseFrame is the reply to frame id carrying payload or err
+func responseFrame(id uint64, payload map[string]interface{}, err error) map[string]interface{} {
+	frame := map[string]interface{}{"type": frameResponse, "id": id}
+	if err != nil {
+		frame["code"], frame["error"] = errorCode(err), err.Error()
+		return frame
+	}
+	if payload != nil {
+		frame["payload"] = payload
+	}
+	return frame
+}
+
+// TCPServer serves a Memory bus to TCP clients
+type TCPServer struct {
+	bus      *Memory
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[*conn]bool
+	wg       sync.WaitGroup
+}
+
+// ListenTCP serves bus on addr, e.g. "127.0.0.1:0"
+func ListenTCP(addr string, bus *Memory) (*TCPServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport listen: %w", err)
+	}
+	return ServeTCP(listener, bus), nil
+}
+
+// ServeTCP serves bus on connections accepted from listener, which may wrap
+// them, e.g. in the encrypted channels of package secure. The server owns
+// listener and closes it on Close.
+func ServeTCP(listener net.Listener, bus *Memory) *TCPServer {
+	s := &TCPServer{bus: bus, listener: listener, conns: make(map[*conn]bool)}
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s
+}
+
+// Addr returns the address the server is listening on
+func (s *TCPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close disconnects every client and stops listening. The bus stays open.
+func (s *TCPServer) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *TCPServer) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &conn{Conn: nc}
+		s.mu.Lock()
+		s.conns[c] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(c)
+	}
+}
+
+// serve relays one client's frames to the bus until it disconnects
+func (s *TCPServer) serve(c *conn) {
+	defer s.wg.Done()
+	var mu sync.Mutex
+	subs := make(map[uint64]func())
+	var requests sync.WaitGroup
+	defer func() {
+		c.Close()
+		requests.Wait()
+		mu.Lock()
+		for _, cancel := range subs {
+			cancel()
+		}
+		mu.Unlock()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	readFrames(c, func(frame map[string]interface{}) {
+		id, topic := frameID(frame), frameString(frame, "topic")
+		switch frameString(frame, "type") {
+		case frameSend:
+			s.bus.Send(ctx, topic, framePayload(frame))
+		case frameSubscribe:
+			unsubscribe, err := s.bus.Subscribe(topic, func(topic string, payload map[string]interface{}) {
+				c.write(map[string]interface{}{"type": frameMessage, "id": id, "topic": topic, "payload": payload})
+			})
+			if err == nil {
+				mu.Lock()
+				subs[id] = unsubscribe
+				mu.Unlock()
+			}
+			c.write(responseFrame(id, nil, err))
+		case frameUnsubscribe:
+			mu.Lock()
+			if unsubscribe, ok := subs[id]; ok {
+				unsubscribe()
+				delete(subs, id)
+			}
+			mu.Unlock()
+		case frameRequest:
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				reply, err := s.bus.Request(ctx, topic, framePayload(frame))
+				c.write(responseFrame(id, reply, err))
+			}()
+		}
+	})
+}
+
+// deliveryBuffer is the number of received messages a TCPClient queues for
+// its handlers before it stops reading from the connection
+const deliveryBuffer = 256
+
+// TCPClient is a Transport connected to a TCPServer. Handlers run one at a
+// time on a delivery goroutine, in the order messages arrive, so they may make
+// requests on the same client.
+type TCPClient struct {
+	c       *conn
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan map[string]interface{}
+	subs    map[uint64]Handler
+	closed  bool
+	inbox   chan map[string]interface{}
+	done    chan struct{}
+}
+
+// DialTCP connects to the TCPServer at addr
+func DialTCP(ctx context.Context, addr string) (*TCPClient, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("transport connect: %w", err)
+	}
+	return NewTCPClient(nc), nil
+}
+
+// NewTCPClient speaks the TCP transport protocol over an established
+// connection to a TCPServer, which may be wrapped, e.g. by package secure.
+// The client owns nc and closes it on Close.
+func NewTCPClient(nc net.Conn) *TCPClient {
+	t := &TCPClient{
+		c:       &conn{Conn: nc},
+		pending: make(map[uint64]chan map[string]interface{}),
+		subs:    make(map[uint64]Handler),
+		inbox:   make(chan map[string]interface{}, deliveryBuffer),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop()
+	go t.deliverLoop()
+	return t
+}
+
+func (t *TCPClient) readLoop() {
+	defer func() {
+		t.mu.Lock()
+		t.closed = true
+		for id, ch := range t.pending {
+			close(ch)
+			delete(t.pending, id)
+		}
+		t.mu.Unlock()
+		close(t.inbox)
+	}()
+	readFrames(t.c, func(frame map[string]interface{}) {
+		switch frameString(frame, "type") {
+		case frameMessage:
+			t.inbox <- frame
+		case frameResponse:
+			id := frameID(frame)
+			t.mu.Lock()
+			if ch, ok := t.pending[id]; ok {
+				ch <- frame
+				delete(t.pending, id)
+			}
+			t.mu.Unlock()
+		}
+	})
+}
+
+func (t *TCPClient) deliverLoop() {
+	defer close(t.done)
+	for frame := range t.inbox {
+		t.mu.Lock()
+		handler := t.subs[frameID(frame)]
+		t.mu.Unlock()
+		if handler != nil {
+			handler(frameString(frame, "topic"), framePayload(frame))
+		}
+	}
+}
+
+// reserve allocates a frame id, registering handler for messages under it
+// and, if wait is set, a channel for the frame's response
+func (t *TCPClient) reserve(handler Handler, wait bool) (uint64, chan map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return 0, nil, ErrClosed
+	}
+	t.nextID++
+	id := t.nextID
+	if handler != nil {
+		t.subs[id] = handler
+	}
+	var ch chan map[string]interface{}
+	if wait {
+		ch = make(chan map[string]interface{}, 1)
+		t.pending[id] = ch
+	}
+	return id, ch, nil
+}
+
+// call writes frame under id and, if ch is set, waits for its response
+func (t *TCPClient) call(ctx context.Context, id uint64, ch chan map[string]interface{}, frame map[string]interface{}) (map[string]interface{}, error) {
+	frame["id"] = id
+	if err := t.c.write(frame); err != nil {
+		t.forget(id)
+		return nil, err
+	}
+	if ch == nil {
+		return nil, nil
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrClosed
+		}
+		if code := frameString(resp, "code"); code != "" {
+			return nil, remoteError(code, frameString(resp, "error"))
+		}
+		return framePayload(resp), nil
+	case <-ctx.Done():
+		t.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+// forget drops the response channel and handler registered under id
+func (t *TCPClient) forget(id uint64) {
+	t.mu.Lock()
+	delete(t.pending, id)
+	delete(t.subs, id)
+	t.mu.Unlock()
+}
+
+// Send implements Transport
+func (t *TCPClient) Send(ctx context.Context, topic string, payload map[string]interface{}) error {
+	if err := checkTopic(topic); err != nil {
+		return err
+	}
+	copied, err := copyPayload(payload)
+	if err != nil {
+		return err
+	}
+	id, _, err := t.reserve(nil, false)
+	if err != nil {
+		return err
+	}
+	_, err = t.call(ctx, id, nil, map[string]interface{}{"type": frameSend, "topic": topic, "payload": copied})
+	return err
+}
+
+// Subscribe implements Transport. It returns once the server has registered
+// the subscription.
+func (t *TCPClient) Subscribe(topic string, handler Handler) (func(), error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	id, ch, err := t.reserve(handler, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.call(context.Background(), id, ch, map[string]interface{}{"type": frameSubscribe, "topic": topic}); err != nil {
+		t.forget(id)
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.forget(id)
+			t.c.write(map[string]interface{}{"type": frameUnsubscribe, "id": id})
+		})
+	}, nil
+}
+
+// Request implements Transport
+func (t *TCPClient) Request(ctx context.Context, topic string, payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	copied, err := copyPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	id, ch, err := t.reserve(nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return t.call(ctx, id, ch, map[string]interface{}{"type": frameRequest, "topic": topic, "payload": copied})
+}
+
+// Close implements Transport
+func (t *TCPClient) Close() error {
+	err := t.c.Close()
+	<-t.done
+	return err
+}
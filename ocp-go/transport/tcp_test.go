@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTCPServerClose tests that closing the server fails pending requests
+func TestTCPServerClose(t *testing.T) {
+	bus := NewMemory()
+	bus.Serve("slow", func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	server, err := ListenTCP("127.0.0.1:0", bus)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	client, err := DialTCP(context.Background(), server.Addr())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	// A request abandoned by its caller does not block the connection
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Request(ctx, "slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline, got %v", err)
+	}
+	if _, err := client.Request(context.Background(), "missing", nil); !errors.Is(err, ErrNoResponder) {
+		t.Errorf("Expected ErrNoResponder, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Request(context.Background(), "slow", nil)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	server.Close()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("Expected the request to fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Request still pending after the server closed")
+	}
+	if _, err := client.Request(context.Background(), "slow", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after disconnect, got %v", err)
+	}
+
+	t.Log("✓ Pending requests fail when the server goes away")
+}
@@ -0,0 +1,256 @@
+// Package transport carries OCP messages between agents and nodes.
+//
+// Protocol code talks to a Transport, never to a socket: it sends
+// fire-and-forget messages to a topic, subscribes to a topic, and makes
+// request/response calls to whoever serves a topic. Three implementations are
+// provided. Memory is an in-process bus, so protocol logic can be tested
+// without networking. ListenTCP and ServeHTTP expose a Memory bus to remote
+// participants, which connect with DialTCP or NewHTTPClient and get a
+// Transport with the same behaviour as the bus itself. Organizations with
+// their own message infrastructure implement Transport over it.
+//
+// Payloads are JSON objects. Every implementation, Memory included, passes
+// them through canonical form and strict decoding, so a payload that would not
+// survive the wire fails in tests exactly as it would in production, and no
+// receiver shares memory with the sender.
+package transport
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+
+	ocp "github.com/seanrugg/ai_constitution/ocp-go"
+	"github.com/seanrugg/ai_constitution/ocp-go/canonical"
+)
+
+// Errors returned by transports
+var (
+	ErrNoResponder = &ocp.ConstitutionalError{ErrorType: "TransportError", Message: "no responder for topic"}
+	ErrClosed      = &ocp.ConstitutionalError{ErrorType: "TransportError", Message: "transport is closed"}
+)
+
+// MaxFrameBytes bounds a single message on the network transports
+const MaxFrameBytes = 1 << 20
+
+// Handler receives the messages sent to a subscribed topic
+type Handler func(topic string, payload map[string]interface{})
+
+// Responder answers requests made to a topic
+type Responder func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error)
+
+// Transport is how agents and nodes exchange messages
+type Transport interface {
+	// Send delivers payload to every current subscriber of topic. It does not
+	// wait for, or report on, the subscribers' handling of it.
+	Send(ctx context.Context, topic string, payload map[string]interface{}) error
+	// Subscribe calls handler for every message later sent to topic, until
+	// the returned cancel function is called
+	Subscribe(topic string, handler Handler) (cancel func(), err error)
+	// Request calls the responder serving topic and returns its reply
+	//
+	// Returns:
+	//   - ErrNoResponder if nobody serves topic
+	//   - The responder's error, as a ConstitutionalError across the network
+	Request(ctx context.Context, topic string, payload map[string]interface{}) (map[string]interface{}, error)
+	// Close releases the transport; later calls fail with ErrClosed
+	Close() error
+}
+
+// topicPattern is the shape of a valid topic, e.g. "proposals.submit"
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,127}$`)
+
+// ValidTopic reports whether topic can be used on every transport: lowercase
+// letters, digits, ".", "_" and "-", starting with a letter or digit
+func ValidTopic(topic string) bool {
+	return topicPattern.MatchString(topic)
+}
+
+func checkTopic(topic string) error {
+	if !ValidTopic(topic) {
+		return ocp.NewConstitutionalError("invalid transport topic " + topic)
+	}
+	return nil
+}
+
+// encode returns the canonical form of payload, nil encoding as {}
+func encode(payload map[string]interface{}) ([]byte, error) {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	form, err := canonical.Canonicalize(payload, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(form) > MaxFrameBytes {
+		return nil, ocp.NewConstitutionalError("transport payload exceeds MaxFrameBytes")
+	}
+	return []byte(form), nil
+}
+
+// copyPayload detaches payload from the sender as the wire would
+func copyPayload(payload map[string]interface{}) (map[string]interface{}, error) {
+	data, err := encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.DecodeStrict(data)
+}
+
+// Memory is an in-process Transport. Send calls the subscribers' handlers
+// synchronously, in subscription order, so tests observe deliveries as soon
+// as Send returns. Responders are registered with Serve.
+type Memory struct {
+	mu         sync.RWMutex
+	nextID     uint64
+	subs       map[string]map[uint64]Handler
+	responders map[string]Responder
+	closed     bool
+}
+
+// NewMemory creates an empty in-process bus
+func NewMemory() *Memory {
+	return &Memory{
+		subs:       make(map[string]map[uint64]Handler),
+		responders: make(map[string]Responder),
+	}
+}
+
+// Serve makes responder answer requests to topic, replacing any previous one
+func (m *Memory) Serve(topic string, responder Responder) error {
+	if err := checkTopic(topic); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.responders[topic] = responder
+	return nil
+}
+
+// Send implements Transport
+func (m *Memory) Send(ctx context.Context, topic string, payload map[string]interface{}) error {
+	if err := checkTopic(topic); err != nil {
+		return err
+	}
+	data, err := encode(payload)
+	if err != nil {
+		return err
+	}
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrClosed
+	}
+	ids := make([]uint64, 0, len(m.subs[topic]))
+	handlers := make(map[uint64]Handler, len(m.subs[topic]))
+	for id, h := range m.subs[topic] {
+		ids = append(ids, id)
+		handlers[id] = h
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Each handler gets its own copy, as separate receivers would
+		copied, err := canonical.DecodeStrict(data)
+		if err != nil {
+			return err
+		}
+		handlers[id](topic, copied)
+	}
+	return nil
+}
+
+// Subscribe implements Transport
+func (m *Memory) Subscribe(topic string, handler Handler) (func(), error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	m.nextID++
+	id := m.nextID
+	if m.subs[topic] == nil {
+		m.subs[topic] = make(map[uint64]Handler)
+	}
+	m.subs[topic][id] = handler
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.subs[topic], id)
+			if len(m.subs[topic]) == 0 {
+				delete(m.subs, topic)
+			}
+		})
+	}, nil
+}
+
+// Request implements Transport
+func (m *Memory) Request(ctx context.Context, topic string, payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := checkTopic(topic); err != nil {
+		return nil, err
+	}
+	copied, err := copyPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	closed, responder := m.closed, m.responders[topic]
+	m.mu.RUnlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if responder == nil {
+		return nil, ErrNoResponder
+	}
+	reply, err := responder(ctx, copied)
+	if err != nil {
+		return nil, err
+	}
+	return copyPayload(reply)
+}
+
+// Close implements Transport; it drops every subscription and responder
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.subs = make(map[string]map[uint64]Handler)
+	m.responders = make(map[string]Responder)
+	return nil
+}
+
+// remoteError rebuilds an error reported by the far side of a transport
+func remoteError(code, message string) error {
+	if code == codeNoResponder {
+		return ErrNoResponder
+	}
+	return &ocp.ConstitutionalError{ErrorType: "TransportError", Message: message}
+}
+
+// errorCode classifies err for the wire
+func errorCode(err error) string {
+	if errors.Is(err, ErrNoResponder) {
+		return codeNoResponder
+	}
+	return codeFailed
+}
+
+// Error codes carried in responses on the network transports
+const (
+	codeNoResponder = "no_responder"
+	codeFailed      = "failed"
+)
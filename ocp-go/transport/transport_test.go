@@ -0,0 +1,162 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transports returns a constructor per implementation; each gives a bus and a
+// Transport reaching it
+func transports() map[string]func(t *testing.T) (*Memory, Transport) {
+	return map[string]func(t *testing.T) (*Memory, Transport){
+		"memory": func(t *testing.T) (*Memory, Transport) {
+			bus := NewMemory()
+			return bus, bus
+		},
+		"tcp": func(t *testing.T) (*Memory, Transport) {
+			bus := NewMemory()
+			server, err := ListenTCP("127.0.0.1:0", bus)
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			client, err := DialTCP(context.Background(), server.Addr())
+			if err != nil {
+				t.Fatalf("Failed to dial: %v", err)
+			}
+			t.Cleanup(func() {
+				client.Close()
+				server.Close()
+			})
+			return bus, client
+		},
+		"http": func(t *testing.T) (*Memory, Transport) {
+			bus := NewMemory()
+			server := httptest.NewServer(ServeHTTP(bus))
+			client := NewHTTPClient(server.URL, nil)
+			t.Cleanup(func() {
+				client.Close()
+				server.Close()
+			})
+			return bus, client
+		},
+	}
+}
+
+// inbox collects delivered payloads
+type inbox struct {
+	mu   sync.Mutex
+	msgs []map[string]interface{}
+}
+
+func (b *inbox) handle(topic string, payload map[string]interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.msgs = append(b.msgs, payload)
+}
+
+// wait returns the delivered payloads once there are n of them
+func (b *inbox) wait(t *testing.T, n int) []map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		msgs := append([]map[string]interface{}(nil), b.msgs...)
+		b.mu.Unlock()
+		if len(msgs) >= n {
+			return msgs
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d messages", n)
+	return nil
+}
+
+// TestTransportConformance tests that every implementation behaves like the bus
+func TestTransportConformance(t *testing.T) {
+	ctx := context.Background()
+	for name, newTransport := range transports() {
+		t.Run(name, func(t *testing.T) {
+			bus, tr := newTransport(t)
+
+			// Messages sent by either side reach subscribers on both
+			var local, remote inbox
+			bus.Subscribe("votes", local.handle)
+			cancel, err := tr.Subscribe("votes", remote.handle)
+			if err != nil {
+				t.Fatalf("Failed to subscribe: %v", err)
+			}
+			if err := tr.Send(ctx, "votes", map[string]interface{}{"n": 1}); err != nil {
+				t.Fatalf("Failed to send: %v", err)
+			}
+			if err := bus.Send(ctx, "votes", map[string]interface{}{"n": 2}); err != nil {
+				t.Fatalf("Failed to send: %v", err)
+			}
+			// Order is only kept per sender
+			if msgs := remote.wait(t, 2); msgs[0]["n"].(float64)+msgs[1]["n"].(float64) != 3 {
+				t.Errorf("Unexpected remote deliveries: %v", msgs)
+			}
+			local.wait(t, 2)
+
+			cancel()
+			bus.Send(ctx, "votes", map[string]interface{}{"n": 3})
+			local.wait(t, 3)
+			time.Sleep(20 * time.Millisecond)
+			if msgs := remote.wait(t, 2); len(msgs) != 2 {
+				t.Errorf("Cancelled subscription still delivered: %v", msgs)
+			}
+
+			// Requests reach the responder and carry its errors back
+			bus.Serve("echo", func(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+				if payload["fail"] == true {
+					return nil, errors.New("refused")
+				}
+				return map[string]interface{}{"echo": payload["say"]}, nil
+			})
+			reply, err := tr.Request(ctx, "echo", map[string]interface{}{"say": "hi"})
+			if err != nil || reply["echo"] != "hi" {
+				t.Errorf("Unexpected reply %v, %v", reply, err)
+			}
+			if _, err := tr.Request(ctx, "echo", map[string]interface{}{"fail": true}); err == nil || err.Error() == "" {
+				t.Errorf("Expected the responder's error")
+			}
+			if _, err := tr.Request(ctx, "nobody", nil); !errors.Is(err, ErrNoResponder) {
+				t.Errorf("Expected ErrNoResponder, got %v", err)
+			}
+
+			if err := tr.Send(ctx, "Bad Topic", nil); err == nil {
+				t.Errorf("Expected an invalid topic to be rejected")
+			}
+			if err := tr.Send(ctx, "votes", map[string]interface{}{"f": func() {}}); err == nil {
+				t.Errorf("Expected an unencodable payload to be rejected")
+			}
+
+			tr.Close()
+			if err := tr.Send(ctx, "votes", nil); err == nil {
+				t.Errorf("Expected sends after Close to fail")
+			}
+			t.Logf("✓ %s transport conforms", name)
+		})
+	}
+}
+
+// TestMemoryIsolation tests that receivers never share the sender's maps
+func TestMemoryIsolation(t *testing.T) {
+	bus := NewMemory()
+	var got map[string]interface{}
+	bus.Subscribe("proposals", func(topic string, payload map[string]interface{}) { got = payload })
+	sent := map[string]interface{}{"nested": map[string]interface{}{"k": "v"}}
+	bus.Send(context.Background(), "proposals", sent)
+	sent["nested"].(map[string]interface{})["k"] = "changed"
+	if got["nested"].(map[string]interface{})["k"] != "v" {
+		t.Errorf("Receiver saw the sender's later change")
+	}
+	if ValidTopic("") || ValidTopic("a/b") || !ValidTopic("proposals.submit") {
+		t.Errorf("Unexpected topic validation")
+	}
+
+	t.Log("✓ Memory deliveries are copies")
+}
@@ -0,0 +1,343 @@
+// verification.go - Full proposal verification with machine-readable results
+//
+// VerifyProposalFull runs every check a node applies before accepting a
+// proposal and reports each one separately with a stable code, instead of
+// stopping at the first error. The report is itself canonicalizable, so an
+// adjudicator can attach its hash to a challenge as verification evidence.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/schema"
+)
+
+// Verification check names, in the order they appear in a report
+const (
+	CheckCanonicalization = "canonicalization"
+	CheckSchema           = "schema"
+	CheckSignature        = "signature"
+	CheckHashChain        = "hash_chain"
+	CheckPolicy           = "policy"
+)
+
+// CheckStatus is the outcome of a single verification check
+type CheckStatus string
+
+const (
+	CheckPassed  CheckStatus = "pass"
+	CheckFailed  CheckStatus = "fail"
+	CheckSkipped CheckStatus = "skip"
+)
+
+// Stable verification codes. These are part of the protocol surface: existing
+// codes are never renamed or reused with a different meaning.
+const (
+	CodeOK                     = "OK"
+	CodeSkipped                = "SKIPPED"
+	CodeCanonicalizationFailed = "CANONICALIZATION_FAILED"
+	CodeCanonicalMismatch      = "CANONICAL_SERIALIZATION_MISMATCH"
+	CodeSchemaMissingField     = "SCHEMA_MISSING_FIELD"
+	CodeSchemaInvalidValue     = "SCHEMA_INVALID_VALUE"
+	CodeSignatureMissing       = "SIGNATURE_MISSING"
+	CodeSignatureUnknownSigner = "SIGNATURE_UNKNOWN_SIGNER"
+	CodeSignatureInvalid       = "SIGNATURE_INVALID"
+	CodeChainBroken            = "HASH_CHAIN_BROKEN"
+	CodeChainNotRecorded       = "HASH_CHAIN_NOT_RECORDED"
+	CodePolicyViolation        = "POLICY_VIOLATION"
+)
+
+// CheckResult is the result of one verification check
+type CheckResult struct {
+	Check   string      `json:"check"`
+	Status  CheckStatus `json:"status"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+}
+
+// ToMap converts a CheckResult to a map for canonicalization
+func (c CheckResult) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"check":   c.Check,
+		"status":  string(c.Status),
+		"code":    c.Code,
+		"message": c.Message,
+	}
+}
+
+// VerificationReport collects the results of VerifyProposalFull
+type VerificationReport struct {
+	ProposalHash string        `json:"proposal_hash"`
+	Checks       []CheckResult `json:"checks"`
+}
+
+// ToMap converts a VerificationReport to a map for canonicalization
+func (r *VerificationReport) ToMap() map[string]interface{} {
+	checks := make([]interface{}, len(r.Checks))
+	for i, c := range r.Checks {
+		checks[i] = c.ToMap()
+	}
+	return map[string]interface{}{
+		"proposal_hash": r.ProposalHash,
+		"checks":        checks,
+	}
+}
+
+// GetHash returns the semantic hash of the report
+func (r *VerificationReport) GetHash() (string, error) {
+	return SemanticHash(r.ToMap())
+}
+
+// OK reports whether no check failed. Skipped checks do not count as failures.
+func (r *VerificationReport) OK() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the failed checks
+func (r *VerificationReport) Failures() []CheckResult {
+	var out []CheckResult
+	for _, c := range r.Checks {
+		if c.Status == CheckFailed {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Check returns the result of the named check
+func (r *VerificationReport) Check(name string) (CheckResult, bool) {
+	for _, c := range r.Checks {
+		if c.Check == name {
+			return c, true
+		}
+	}
+	return CheckResult{}, false
+}
+
+// VerifyOption supplies the context some checks need. Checks whose context is
+// not supplied are reported as skipped.
+type VerifyOption func(*verifyConfig)
+
+type verifyConfig struct {
+	keys   map[string]ed25519.PublicKey
+	ledger *Ledger
+	policy func(*ContractProposal) error
+	quorum *Quorum
+	// sigCache, if set, answers repeated signature checks
+	sigCache *SignatureCache
+}
+
+// WithProposerKeys enables the signature check using keys by agent name
+func WithProposerKeys(keys map[string]ed25519.PublicKey) VerifyOption {
+	return func(c *verifyConfig) {
+		c.keys = keys
+	}
+}
+
+// WithLedger enables the hash chain check against ledger
+func WithLedger(ledger *Ledger) VerifyOption {
+	return func(c *verifyConfig) {
+		c.ledger = ledger
+	}
+}
+
+// WithPolicy enables the policy check; policy returns an error describing
+// any violation
+func WithPolicy(policy func(*ContractProposal) error) VerifyOption {
+	return func(c *verifyConfig) {
+		c.policy = policy
+	}
+}
+
+// SignProposal fills in CanonicalSerialized and signs the proposal's signing
+// hash as its proposer.
+func SignProposal(p *ContractProposal, key ed25519.PrivateKey) error {
+	form, err := p.CanonicalSigningForm()
+	if err != nil {
+		return err
+	}
+	hash, err := p.SigningHash()
+	if err != nil {
+		return err
+	}
+	sig, err := SignHash(p.ProposerAgent, key, ContextProposal, hash)
+	if err != nil {
+		return err
+	}
+	p.CanonicalSerialized = form
+	p.ProposerSignature = map[string]string{
+		"algorithm": sig.Algorithm,
+		"value":     sig.Value,
+	}
+	return nil
+}
+
+// VerifyProposalFull runs all verification checks on a proposal
+//
+// Parameters:
+//   - p: Proposal to verify
+//   - opts: Context for the signature, hash chain, and policy checks
+//
+// Returns:
+//   - Report with one result per check, in a fixed order
+func VerifyProposalFull(p *ContractProposal, opts ...VerifyOption) *VerificationReport {
+	var cfg verifyConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	report := &VerificationReport{}
+	hash, err := p.GetHash()
+	if err == nil {
+		report.ProposalHash = hash
+	}
+
+	report.Checks = []CheckResult{
+		checkCanonicalization(p),
+		checkSchema(p),
+		checkSignature(p, cfg.keys, cfg.sigCache),
+		checkHashChain(hash, cfg.ledger),
+		checkPolicy(p, cfg.policy),
+	}
+	return report
+}
+
+func passed(check string) CheckResult {
+	return CheckResult{Check: check, Status: CheckPassed, Code: CodeOK}
+}
+
+func failed(check, code, message string) CheckResult {
+	return CheckResult{Check: check, Status: CheckFailed, Code: code, Message: message}
+}
+
+func skipped(check, message string) CheckResult {
+	return CheckResult{Check: check, Status: CheckSkipped, Code: CodeSkipped, Message: message}
+}
+
+func checkCanonicalization(p *ContractProposal) CheckResult {
+	form, err := p.CanonicalSigningForm()
+	if err != nil {
+		return failed(CheckCanonicalization, CodeCanonicalizationFailed, err.Error())
+	}
+	if p.CanonicalSerialized != form {
+		return failed(CheckCanonicalization, CodeCanonicalMismatch, "canonical_serialization does not match the canonical form of the proposal")
+	}
+	return passed(CheckCanonicalization)
+}
+
+func checkSchema(p *ContractProposal) CheckResult {
+	// Validate the document as it would be received: canonical JSON, strictly decoded
+	form, err := CanonicalizeValue(p.ToMap())
+	if err != nil {
+		return failed(CheckSchema, CodeCanonicalizationFailed, err.Error())
+	}
+	doc, err := DecodeStrict([]byte(form))
+	if err != nil {
+		return failed(CheckSchema, CodeCanonicalizationFailed, err.Error())
+	}
+
+	if err := schema.ValidateContract(doc); err != nil {
+		code := CodeSchemaInvalidValue
+		if missingRequired(err) || missingRequired(schema.ValidateContract(withoutNulls(doc))) {
+			code = CodeSchemaMissingField
+		}
+		return failed(CheckSchema, code, err.Error())
+	}
+	// Citations must resolve to evidence entries, which the schema cannot express
+	if _, err := p.TypedReasoning(); err != nil {
+		return failed(CheckSchema, CodeSchemaInvalidValue, err.Error())
+	}
+	return passed(CheckSchema)
+}
+
+// missingRequired reports whether any violation in err is a missing required
+// property, wherever the validator sorted it
+func missingRequired(err error) bool {
+	var errs schema.Errors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for _, e := range errs {
+		if e.Keyword == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutNulls returns a copy of v with every null object member removed, so
+// a required property sent as null is reported as missing rather than as the
+// wrong type
+func withoutNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if child != nil {
+				out[k] = withoutNulls(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = withoutNulls(child)
+		}
+		return out
+	}
+	return v
+}
+
+func checkSignature(p *ContractProposal, keys map[string]ed25519.PublicKey, cache *SignatureCache) CheckResult {
+	if keys == nil {
+		return skipped(CheckSignature, "no proposer keys supplied")
+	}
+	if p.ProposerSignature["value"] == "" {
+		return failed(CheckSignature, CodeSignatureMissing, "proposal is not signed")
+	}
+	key, ok := keys[p.ProposerAgent]
+	if !ok {
+		return failed(CheckSignature, CodeSignatureUnknownSigner, fmt.Sprintf("no key for proposer %q", p.ProposerAgent))
+	}
+	hash, err := p.SigningHash()
+	if err != nil {
+		return failed(CheckSignature, CodeSignatureInvalid, err.Error())
+	}
+	sig := Signature{
+		Signer:    p.ProposerAgent,
+		Algorithm: p.ProposerSignature["algorithm"],
+		Value:     p.ProposerSignature["value"],
+	}
+	if err := cache.Verify(key, ContextProposal, hash, sig); err != nil {
+		return failed(CheckSignature, CodeSignatureInvalid, err.Error())
+	}
+	return passed(CheckSignature)
+}
+
+func checkHashChain(proposalHash string, ledger *Ledger) CheckResult {
+	if ledger == nil {
+		return skipped(CheckHashChain, "no ledger supplied")
+	}
+	if err := ledger.Verify(); err != nil {
+		return failed(CheckHashChain, CodeChainBroken, err.Error())
+	}
+	for _, e := range ledger.Entries(0) {
+		if e.Kind == LedgerKindProposal && payloadString(e.Payload, "proposal_hash") == proposalHash {
+			return passed(CheckHashChain)
+		}
+	}
+	return failed(CheckHashChain, CodeChainNotRecorded, "proposal is not recorded on the ledger")
+}
+
+func checkPolicy(p *ContractProposal, policy func(*ContractProposal) error) CheckResult {
+	if policy == nil {
+		return skipped(CheckPolicy, "no policy supplied")
+	}
+	if err := policy(p); err != nil {
+		return failed(CheckPolicy, CodePolicyViolation, err.Error())
+	}
+	return passed(CheckPolicy)
+}
@@ -0,0 +1,169 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// signedTestProposal returns a schema-complete proposal signed by Claude
+func signedTestProposal(t *testing.T) (*ContractProposal, map[string]ed25519.PublicKey) {
+	pub, priv := testKey("Claude")
+	p := testProposal()
+	p.ReversibilityClass = "partially_reversible"
+	p.PreStateHash = "sha256:" + strings.Repeat("a", 64)
+	p.PostStateHash = "sha256:" + strings.Repeat("b", 64)
+	p.Reasoning["constitutional_grounding"] = []interface{}{"Article X.1"}
+	if err := SignProposal(p, priv); err != nil {
+		t.Fatalf("Failed to sign proposal: %v", err)
+	}
+	return p, map[string]ed25519.PublicKey{"Claude": pub}
+}
+
+// TestVerifyProposalFullPasses tests a report where every check passes
+func TestVerifyProposalFullPasses(t *testing.T) {
+	testenv.RequireSigning(t)
+	p, keys := signedTestProposal(t)
+	node := NewNode(NewLedger())
+	if _, err := node.Submit(p); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	report := VerifyProposalFull(p,
+		WithProposerKeys(keys),
+		WithLedger(node.Ledger()),
+		WithPolicy(func(*ContractProposal) error { return nil }),
+	)
+
+	if !report.OK() {
+		t.Fatalf("Expected all checks to pass: %+v", report.Failures())
+	}
+	for _, c := range report.Checks {
+		if c.Status != CheckPassed || c.Code != CodeOK {
+			t.Errorf("%s: expected pass, got %s %s", c.Check, c.Status, c.Code)
+		}
+	}
+
+	hash, err := report.GetHash()
+	if err != nil {
+		t.Fatalf("Report should be canonicalizable: %v", err)
+	}
+
+	t.Logf("✓ Verification report hash: %s", hash)
+}
+
+// TestVerifyProposalFullCodes tests the stable code reported by each failure
+func TestVerifyProposalFullCodes(t *testing.T) {
+	testenv.RequireSigning(t)
+	cases := []struct {
+		name   string
+		mutate func(p *ContractProposal)
+		opts   []VerifyOption
+		check  string
+		code   string
+	}{
+		{
+			name:   "tampered after signing",
+			mutate: func(p *ContractProposal) { p.Action["operation"] = "delete" },
+			check:  CheckCanonicalization,
+			code:   CodeCanonicalMismatch,
+		},
+		{
+			name:   "invalid action type",
+			mutate: func(p *ContractProposal) { p.ActionType = "seize" },
+			check:  CheckSchema,
+			code:   CodeSchemaInvalidValue,
+		},
+		{
+			name:   "missing evidence",
+			mutate: func(p *ContractProposal) { p.Evidence = nil },
+			check:  CheckSchema,
+			code:   CodeSchemaMissingField,
+		},
+		{
+			name:   "missing action target",
+			mutate: func(p *ContractProposal) { delete(p.Action, "target") },
+			check:  CheckSchema,
+			code:   CodeSchemaMissingField,
+		},
+		{
+			name:   "unresolved citation",
+			mutate: func(p *ContractProposal) { p.Reasoning["cited_evidence"] = []interface{}{"sha256:missing"} },
+			check:  CheckSchema,
+			code:   CodeSchemaInvalidValue,
+		},
+		{
+			name:   "unsigned",
+			mutate: func(p *ContractProposal) { p.ProposerSignature = nil },
+			opts:   []VerifyOption{WithProposerKeys(map[string]ed25519.PublicKey{})},
+			check:  CheckSignature,
+			code:   CodeSignatureMissing,
+		},
+		{
+			name:   "unknown signer",
+			mutate: func(p *ContractProposal) {},
+			opts:   []VerifyOption{WithProposerKeys(map[string]ed25519.PublicKey{})},
+			check:  CheckSignature,
+			code:   CodeSignatureUnknownSigner,
+		},
+		{
+			name:   "not on ledger",
+			mutate: func(p *ContractProposal) {},
+			opts:   []VerifyOption{WithLedger(NewLedger())},
+			check:  CheckHashChain,
+			code:   CodeChainNotRecorded,
+		},
+		{
+			name:   "policy violation",
+			mutate: func(p *ContractProposal) {},
+			opts:   []VerifyOption{WithPolicy(func(*ContractProposal) error { return errors.New("amendments frozen") })},
+			check:  CheckPolicy,
+			code:   CodePolicyViolation,
+		},
+	}
+
+	for _, tc := range cases {
+		p, _ := signedTestProposal(t)
+		tc.mutate(p)
+		report := VerifyProposalFull(p, tc.opts...)
+
+		result, ok := report.Check(tc.check)
+		if !ok || result.Status != CheckFailed || result.Code != tc.code {
+			t.Errorf("%s: expected %s to fail with %s, got %+v", tc.name, tc.check, tc.code, result)
+		}
+		if report.OK() {
+			t.Errorf("%s: report should not be OK", tc.name)
+		}
+	}
+
+	t.Logf("✓ %d failure codes reported", len(cases))
+}
+
+// TestVerifyProposalFullSkips tests that checks without context are skipped
+func TestVerifyProposalFullSkips(t *testing.T) {
+	testenv.RequireSigning(t)
+	p, keys := signedTestProposal(t)
+
+	report := VerifyProposalFull(p)
+	for _, name := range []string{CheckSignature, CheckHashChain, CheckPolicy} {
+		if c, _ := report.Check(name); c.Status != CheckSkipped || c.Code != CodeSkipped {
+			t.Errorf("%s should be skipped, got %+v", name, c)
+		}
+	}
+	if !report.OK() {
+		t.Errorf("Skipped checks should not fail the report")
+	}
+
+	// A forged signature from another key fails
+	_, other := testKey("Mallory")
+	forged, _ := signedTestProposal(t)
+	SignProposal(forged, other)
+	if c, _ := VerifyProposalFull(forged, WithProposerKeys(keys)).Check(CheckSignature); c.Code != CodeSignatureInvalid {
+		t.Errorf("Forged signature should be invalid, got %+v", c)
+	}
+
+	t.Logf("✓ Checks without context skipped")
+}
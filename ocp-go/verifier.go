@@ -0,0 +1,219 @@
+// verifier.go - Read-only verification facade
+//
+// Auditors and applications that only need to check OCP objects should not
+// hold anything that can sign, stake, or write history. A Verifier exposes
+// the verification half of the protocol and nothing else: it takes public
+// keys and a quorum, loads ledger history only after checking its hash chain,
+// and keeps that history private so no caller can append to it. Combined with
+// the ocp_verifyonly build tag, which disables signing and ledger writes
+// package-wide, it gives a minimal attack-surface binary.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"sync"
+)
+
+// ErrVerifyOnly is returned by signing and ledger-writing operations in a
+// binary built with the ocp_verifyonly tag
+var ErrVerifyOnly = &ConstitutionalError{ErrorType: "VerifyOnlyError", Message: "operation unavailable in verification-only build"}
+
+// WithQuorum supplies the quorum a Verifier checks snapshots and quorum
+// signatures against
+func WithQuorum(quorum *Quorum) VerifyOption {
+	return func(c *verifyConfig) {
+		c.quorum = quorum
+	}
+}
+
+// Verifier checks proposals, ledger history, snapshots, and signatures
+// without the ability to create or modify any of them
+type Verifier struct {
+	mu     sync.RWMutex
+	cfg    verifyConfig
+	ledger *Ledger
+}
+
+// NewVerifierOnly creates a read-only Verifier
+//
+// Parameters:
+//   - opts: Proposer keys, quorum, and policy to verify against. A ledger
+//     passed with WithLedger is copied, so later writes to it are not seen.
+//
+// Returns:
+//   - Verifier; checks whose context was not supplied are skipped
+func NewVerifierOnly(opts ...VerifyOption) *Verifier {
+	v := &Verifier{}
+	for _, opt := range opts {
+		opt(&v.cfg)
+	}
+	if v.cfg.ledger != nil {
+		v.ledger = v.cfg.ledger.readOnlyCopy()
+		v.cfg.ledger = nil
+	}
+	return v
+}
+
+// LoadLedger replaces the Verifier's history with entries from genesis,
+// after checking that they form an unbroken hash chain
+func (v *Verifier) LoadLedger(entries []LedgerEntry) error {
+	if _, err := VerifyChain(0, "", entries); err != nil {
+		return err
+	}
+	l := NewLedger()
+	l.entries = append([]LedgerEntry(nil), entries...)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.ledger = l
+	return nil
+}
+
+// LoadSnapshot replaces the Verifier's history with a quorum-signed snapshot
+// and the delta entries appended since. It requires WithQuorum.
+func (v *Verifier) LoadSnapshot(snap *Snapshot, delta []LedgerEntry) error {
+	if v.cfg.quorum == nil {
+		return NewVerificationError("no quorum supplied")
+	}
+	l, err := BootstrapLedger(snap, v.cfg.quorum, delta)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.ledger = l
+	return nil
+}
+
+// Height returns the height of the loaded history
+func (v *Verifier) Height() uint64 {
+	if l := v.history(); l != nil {
+		return l.Height()
+	}
+	return 0
+}
+
+// Head returns the head hash of the loaded history
+func (v *Verifier) Head() string {
+	if l := v.history(); l != nil {
+		return l.Head()
+	}
+	return ""
+}
+
+// VerifyProposal runs every verification check on a proposal, using the
+// loaded history for the hash chain check
+func (v *Verifier) VerifyProposal(p *ContractProposal) *VerificationReport {
+	opts := []VerifyOption{WithProposerKeys(v.cfg.keys), WithPolicy(v.cfg.policy)}
+	if l := v.history(); l != nil {
+		opts = append(opts, WithLedger(l))
+	}
+	return VerifyProposalFull(p, opts...)
+}
+
+// VerifySignature checks a signature over hash under ctx by a known proposer
+func (v *Verifier) VerifySignature(ctx SignatureContext, hash string, sig Signature) error {
+	key, ok := v.cfg.keys[sig.Signer]
+	if !ok {
+		return NewVerificationError("no public key for signer " + sig.Signer)
+	}
+	return v.cfg.sigCache.Verify(key, ctx, hash, sig)
+}
+
+// VerifyQuorum checks that sigs over hash under ctx meet the quorum threshold. It
+// requires WithQuorum.
+//
+// Returns:
+//   - Members whose signatures were counted
+func (v *Verifier) VerifyQuorum(ctx SignatureContext, hash string, sigs []Signature) ([]string, error) {
+	if v.cfg.quorum == nil {
+		return nil, NewVerificationError("no quorum supplied")
+	}
+	return v.cfg.quorum.VerifyCached(v.cfg.sigCache, ctx, hash, sigs)
+}
+
+// VerifyBonds checks every challenge bond in the loaded history against curve
+func (v *Verifier) VerifyBonds(curve BondingCurve) error {
+	l := v.history()
+	if l == nil {
+		return nil
+	}
+	return VerifyBonds(l.Entries(0), curve)
+}
+
+// VerifyWindows checks every challenge window extension in the loaded history
+// against policy
+func (v *Verifier) VerifyWindows(policy WindowPolicy) error {
+	l := v.history()
+	if l == nil {
+		return nil
+	}
+	return VerifyWindowExtensions(l.Entries(0), policy)
+}
+
+// VerifyHalts checks every emergency halt review and lift in the loaded
+// history. It requires WithQuorum.
+func (v *Verifier) VerifyHalts() error {
+	if v.cfg.quorum == nil {
+		return NewVerificationError("no quorum supplied")
+	}
+	l := v.history()
+	if l == nil {
+		return nil
+	}
+	return VerifyHalts(l.Entries(0), v.cfg.quorum)
+}
+
+// VerifyAppeals checks every slashing appeal and ruling in the loaded
+// history. It requires WithQuorum.
+func (v *Verifier) VerifyAppeals() error {
+	if v.cfg.quorum == nil {
+		return NewVerificationError("no quorum supplied")
+	}
+	l := v.history()
+	if l == nil {
+		return nil
+	}
+	return VerifyAppeals(l.Entries(0), v.cfg.quorum)
+}
+
+// VerifyEnvelope checks a DSSE envelope signed by a known key and decodes its
+// statement
+func (v *Verifier) VerifyEnvelope(env *DSSEEnvelope, keyID string) (*InTotoStatement, error) {
+	key, ok := v.cfg.keys[keyID]
+	if !ok {
+		return nil, NewVerificationError("no public key for " + keyID)
+	}
+	return VerifyEnvelope(env, keyID, key)
+}
+
+// Keys returns a copy of the proposer keys the Verifier trusts
+func (v *Verifier) Keys() map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey, len(v.cfg.keys))
+	for agent, key := range v.cfg.keys {
+		keys[agent] = key
+	}
+	return keys
+}
+
+func (v *Verifier) history() *Ledger {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.ledger
+}
+
+// readOnlyCopy returns a copy of the ledger sharing no mutable state
+func (l *Ledger) readOnlyCopy() *Ledger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c := NewLedger()
+	c.baseHeight = l.baseHeight
+	c.baseHash = l.baseHash
+	c.entries = append([]LedgerEntry(nil), l.entries...)
+	for hash, height := range l.snapshots {
+		c.snapshots[hash] = height
+	}
+	return c
+}
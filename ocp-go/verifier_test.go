@@ -0,0 +1,52 @@
+package ocp
+
+import (
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestVerifierOnly tests that a Verifier checks proposals against loaded history
+func TestVerifierOnly(t *testing.T) {
+	testenv.RequireSigning(t)
+	p, keys := signedTestProposal(t)
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	if _, err := node.Submit(p); err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	v := NewVerifierOnly(WithProposerKeys(keys), WithLedger(ledger))
+	if report := v.VerifyProposal(p); !report.OK() {
+		t.Fatalf("Expected proposal to verify: %+v", report.Failures())
+	}
+
+	// Later writes to the source ledger are not seen by the Verifier
+	ledger.Append("note", map[string]interface{}{"text": "after"})
+	if v.Height() != 1 {
+		t.Errorf("Verifier should hold a copy of the ledger, got height %d", v.Height())
+	}
+
+	entries := ledger.Entries(0)
+	entries[0].Payload = map[string]interface{}{"proposal_hash": "forged"}
+	if err := v.LoadLedger(entries); err == nil {
+		t.Errorf("Tampered history should not load")
+	}
+	if v.Height() != 1 {
+		t.Errorf("Failed load should keep the previous history")
+	}
+
+	sig := Signature{Signer: "Claude", Algorithm: SignatureAlgorithm, Value: p.ProposerSignature["value"]}
+	hash, _ := p.SigningHash()
+	if err := v.VerifySignature(ContextProposal, hash, sig); err != nil {
+		t.Errorf("Expected proposer signature to verify: %v", err)
+	}
+	if _, err := v.VerifyQuorum(ContextProposal, hash, []Signature{sig}); err == nil {
+		t.Errorf("Quorum check should fail without a quorum")
+	}
+	if VerifyOnlyBuild {
+		t.Errorf("Default build should not be verification-only")
+	}
+
+	t.Logf("✓ Verifier head %s at height %d", v.Head(), v.Height())
+}
@@ -0,0 +1,15 @@
+//go:build ocp_verifyonly
+
+// verifyonly.go - Verification-only build profile
+//
+// Building with -tags ocp_verifyonly produces a binary that can check
+// signatures, hash chains, and proposals but can never sign, append to a
+// ledger, or lock and resolve stakes. The mutating entry points return
+// ErrVerifyOnly, and because VerifyOnlyBuild is a constant the compiler drops
+// their bodies, so signing code is not linked into the binary at all.
+
+package ocp
+
+// VerifyOnlyBuild reports whether this binary was built with the
+// ocp_verifyonly tag
+const VerifyOnlyBuild = true
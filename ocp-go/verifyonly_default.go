@@ -0,0 +1,7 @@
+//go:build !ocp_verifyonly
+
+package ocp
+
+// VerifyOnlyBuild reports whether this binary was built with the
+// ocp_verifyonly tag
+const VerifyOnlyBuild = false
@@ -0,0 +1,227 @@
+// watchdog.go - Automatic challenges of verification failures
+//
+// Optimistic acceptance is only safe if someone checks what was accepted. A
+// Watchdog is a built-in challenger: it runs VerifyProposalFull on proposals
+// a node accepts and replays the node's ledger, and for every failure it finds
+// it constructs and signs a FraudProof, so honest nodes enforce the protocol
+// without waiting for a human or another agent to notice.
+//
+// Failures map to fraud types as follows:
+//
+//	canonicalization, hash chain, entry hash     HASH_MISMATCH
+//	schema, signature, entry signatures          PROCEDURAL_VIOLATION
+//	policy                                       CONSTITUTIONAL_VIOLATION
+//
+// A proposal that is merely not yet recorded on the ledger is not fraud and
+// raises no challenge. Each offence is challenged once, however often the
+// watchdog sees it. With a Quarantine set, every offending object is also
+// retained there with its failures, attributed to its proposer.
+
+package ocp
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// watchdogSource is the source the watchdog admits objects to a Quarantine under
+const watchdogSource = "watchdog"
+
+// watchdogCitations maps a verification check to its fraud type and the
+// section of OCP-0001 it enforces
+var watchdogCitations = map[string][2]string{
+	CheckCanonicalization: {FraudHashMismatch, "OCP-0001 §5.2"},
+	CheckSchema:           {FraudProceduralViolation, "OCP-0001 §4.1"},
+	CheckSignature:        {FraudProceduralViolation, "OCP-0001 §8.1"},
+	CheckHashChain:        {FraudHashMismatch, "OCP-0001 §6.1"},
+	CheckPolicy:           {FraudConstitutionalViolation, "OCP-0001 §8.1"},
+}
+
+// Watchdog scans proposals and ledger entries and challenges failures
+type Watchdog struct {
+	mu     sync.Mutex
+	agent  string
+	key    ed25519.PrivateKey
+	quorum *Quorum
+	opts   []VerifyOption
+	// scanned and head are the last ledger entry InspectLedger checked
+	scanned    uint64
+	head       string
+	challenged map[string]bool
+	// Archive, if set, stores the evidence each proof references
+	Archive Archive
+	// Quarantine, if set, retains every object the watchdog finds at fault
+	Quarantine *Quarantine
+	// OnChallenge, if set, receives every proof the watchdog signs
+	OnChallenge func(*FraudProof)
+	// Clock supplies submission timestamps; defaults to SystemClock
+	Clock Clock
+}
+
+// NewWatchdog creates a Watchdog challenging as agent with key
+//
+// Parameters:
+//   - agent, key: Identity the watchdog signs its proofs with
+//   - quorum: Quorum whose signatures ledger entries must carry; nil skips
+//     entry signature checks
+//   - opts: Context for the proposal checks, as for VerifyProposalFull
+func NewWatchdog(agent string, key ed25519.PrivateKey, quorum *Quorum, opts ...VerifyOption) *Watchdog {
+	return &Watchdog{agent: agent, key: key, quorum: quorum, opts: opts, challenged: make(map[string]bool)}
+}
+
+// InspectProposal verifies p and challenges it if any check fails
+//
+// Returns:
+//   - The signed proof, or nil if p passed or was already challenged
+func (w *Watchdog) InspectProposal(p *ContractProposal) (*FraudProof, error) {
+	report := VerifyProposalFull(p, w.opts...)
+	var failures []CheckResult
+	for _, f := range report.Failures() {
+		if f.Code != CodeChainNotRecorded {
+			failures = append(failures, f)
+		}
+	}
+	if len(failures) == 0 {
+		return nil, nil
+	}
+	if w.Quarantine != nil {
+		if _, err := w.Quarantine.Admit(QuarantineProposal, p.ProposerAgent, watchdogSource, p.ToMap(), failures); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make([]string, len(failures))
+	for i, f := range failures {
+		messages[i] = fmt.Sprintf("%s: %s", f.Code, f.Message)
+	}
+	citation := watchdogCitations[failures[0].Check]
+	reference, err := w.evidence(report.ToMap())
+	if err != nil {
+		return nil, err
+	}
+	return w.challenge(&FraudProof{
+		OffendingContractID:    p.ID,
+		ConstitutionalCitation: citation[1],
+		FraudType:              citation[0],
+		Justification:          strings.Join(messages, "; "),
+		Evidence:               FraudEvidence{ArchiveReference: reference, RecomputedHash: report.ProposalHash},
+	})
+}
+
+// InspectLedger replays the entries of ledger added since the last call and
+// challenges every entry whose hash, link, or signatures do not verify. The
+// first call trusts the link into the ledger's earliest held entry.
+//
+// Returns:
+//   - The proofs signed by this call, in ledger order
+func (w *Watchdog) InspectLedger(ledger *Ledger) ([]*FraudProof, error) {
+	entries := ledger.Entries(0)
+	w.mu.Lock()
+	scanned, head := w.scanned, w.head
+	w.mu.Unlock()
+
+	var proofs []*FraudProof
+	for i := range entries {
+		e := entries[i]
+		if e.Height <= scanned {
+			continue
+		}
+		if scanned == 0 && head == "" {
+			scanned, head = e.Height-1, e.PrevHash
+		}
+		var problems []CheckResult
+		fraudType, citation := FraudProceduralViolation, "OCP-0001 §8.1"
+		recomputed, err := e.ComputeHash()
+		if err != nil {
+			return proofs, err
+		}
+		if e.Height != scanned+1 || e.PrevHash != head {
+			problems = append(problems, failed(CheckHashChain, CodeChainBroken, fmt.Sprintf("entry %d does not link to entry %d", e.Height, scanned)))
+			fraudType, citation = FraudHashMismatch, "OCP-0001 §6.1"
+		}
+		if recomputed != e.Hash {
+			problems = append(problems, failed(CheckHashChain, CodeChainBroken, fmt.Sprintf("entry %d records hash %s, content hashes to %s", e.Height, e.Hash, recomputed)))
+			fraudType, citation = FraudHashMismatch, "OCP-0001 §6.1"
+		}
+		if w.quorum != nil {
+			if err := VerifyEntrySignatures(entries[:i], entries[i:i+1], w.quorum); err != nil {
+				problems = append(problems, failed(CheckSignature, CodeSignatureInvalid, err.Error()))
+			}
+		}
+		scanned, head = e.Height, e.Hash
+		if len(problems) == 0 {
+			continue
+		}
+		if w.Quarantine != nil {
+			if _, err := w.Quarantine.AdmitEntry(e, problems, watchdogSource); err != nil {
+				return proofs, err
+			}
+		}
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Message
+		}
+
+		m := e.ToMap()
+		m["hash"] = e.Hash
+		reference, err := w.evidence(m)
+		if err != nil {
+			return proofs, err
+		}
+		proof, err := w.challenge(&FraudProof{
+			OffendingContractID:    e.Hash,
+			ConstitutionalCitation: citation,
+			FraudType:              fraudType,
+			Justification:          strings.Join(messages, "; "),
+			Evidence:               FraudEvidence{ArchiveReference: reference, RecomputedHash: recomputed},
+		})
+		if err != nil {
+			return proofs, err
+		}
+		if proof != nil {
+			proofs = append(proofs, proof)
+		}
+	}
+
+	w.mu.Lock()
+	if scanned > w.scanned {
+		w.scanned, w.head = scanned, head
+	}
+	w.mu.Unlock()
+	return proofs, nil
+}
+
+// evidence returns the archive reference of obj, storing it if an Archive is set
+func (w *Watchdog) evidence(obj map[string]interface{}) (string, error) {
+	if w.Archive != nil {
+		return ArchiveObject(w.Archive, obj)
+	}
+	return SemanticHash(obj)
+}
+
+// challenge signs proof unless the same offence was already challenged
+func (w *Watchdog) challenge(proof *FraudProof) (*FraudProof, error) {
+	offence := proof.OffendingContractID + "\x00" + proof.FraudType + "\x00" + proof.Justification
+	w.mu.Lock()
+	if w.challenged[offence] {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	w.challenged[offence] = true
+	w.mu.Unlock()
+
+	proof.ChallengerAgent = w.agent
+	proof.SubmissionTimestamp = Timestamp(clockOrSystem(w.Clock).Now())
+	if err := proof.Sign(w.key); err != nil {
+		w.mu.Lock()
+		delete(w.challenged, offence)
+		w.mu.Unlock()
+		return nil, err
+	}
+	if w.OnChallenge != nil {
+		w.OnChallenge(proof)
+	}
+	return proof, nil
+}
@@ -0,0 +1,125 @@
+package ocp
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestWatchdogProposals tests challenges of proposals failing verification
+func TestWatchdogProposals(t *testing.T) {
+	testenv.RequireSigning(t)
+	pub, priv := testKey("Gemini")
+	_, keys := signedTestProposal(t)
+	archive := NewMemoryArchive()
+	dog := NewWatchdog("Gemini", priv, nil,
+		WithProposerKeys(keys),
+		WithPolicy(func(p *ContractProposal) error {
+			if p.ReputationStake > 100 {
+				return errors.New("stake above 100")
+			}
+			return nil
+		}))
+	dog.Archive = archive
+	var seen []*FraudProof
+	dog.OnChallenge = func(f *FraudProof) { seen = append(seen, f) }
+
+	honest, _ := signedTestProposal(t)
+	if proof, err := dog.InspectProposal(honest); err != nil || proof != nil {
+		t.Errorf("Expected an honest proposal to pass, got %v, %v", proof, err)
+	}
+
+	cases := []struct {
+		mutate    func(p *ContractProposal)
+		fraudType string
+	}{
+		{func(p *ContractProposal) { p.Action["operation"] = "delete" }, FraudHashMismatch},
+		{func(p *ContractProposal) { p.ProposerSignature = nil }, FraudProceduralViolation},
+	}
+	for _, tc := range cases {
+		p, _ := signedTestProposal(t)
+		tc.mutate(p)
+		proof, err := dog.InspectProposal(p)
+		if err != nil || proof == nil {
+			t.Fatalf("Expected a challenge, got %v, %v", proof, err)
+		}
+		if proof.FraudType != tc.fraudType || proof.Verify(pub) != nil || proof.Validate() != nil {
+			t.Errorf("Unexpected proof %+v", proof)
+		}
+		if _, err := archive.Get(proof.Evidence.ArchiveReference); err != nil {
+			t.Errorf("Evidence was not archived: %v", err)
+		}
+		if again, _ := dog.InspectProposal(p); again != nil {
+			t.Errorf("Expected one challenge per offence")
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected 2 challenges reported, got %d", len(seen))
+	}
+
+	t.Log("✓ Failing proposals challenged once each")
+}
+
+// TestWatchdogLedger tests challenges of tampered and unsigned ledger entries
+func TestWatchdogLedger(t *testing.T) {
+	testenv.RequireSigning(t)
+	quorum, privs := testQuorum(t)
+	_, priv := testKey("Gemini")
+	ledger := migrationLedger(t, 3)
+	dog := NewWatchdog("Gemini", priv, quorum)
+	if proofs, err := dog.InspectLedger(ledger); err != nil || len(proofs) != 0 {
+		t.Fatalf("Expected a clean ledger to pass, got %v, %v", proofs, err)
+	}
+
+	policies := map[string]interface{}{"max_stake": 100}
+	hash, _ := SemanticHash(policies)
+	RecordPolicy(ledger, quorum, policies, signAll(t, ContextPolicy, hash, privs, "Claude", "Gemini"))
+	ledger.Append(LedgerKindPolicy, map[string]interface{}{
+		"policy_hash": "forged",
+		"policies":    map[string]interface{}{"max_stake": 1000},
+		"signatures":  signatureList(signAll(t, ContextPolicy, hash, map[string]ed25519.PrivateKey{"Claude": privs["Claude"]}, "Claude")),
+	})
+	proofs, err := dog.InspectLedger(ledger)
+	if err != nil || len(proofs) != 1 {
+		t.Fatalf("Expected 1 challenge, got %d, %v", len(proofs), err)
+	}
+	if proofs[0].FraudType != FraudProceduralViolation || proofs[0].OffendingContractID != ledger.Head() {
+		t.Errorf("Unexpected proof %+v", proofs[0])
+	}
+
+	// An entry rewritten in place no longer hashes to its recorded hash
+	ledger.entries[1].Payload = map[string]interface{}{"proposal_hash": "rewritten"}
+	fresh := NewWatchdog("Gemini", priv, quorum)
+	proofs, _ = fresh.InspectLedger(ledger)
+	if len(proofs) != 2 || proofs[0].FraudType != FraudHashMismatch || proofs[0].Evidence.RecomputedHash == ledger.entries[1].Hash {
+		t.Errorf("Expected the rewritten entry to be challenged, got %+v", proofs)
+	}
+
+	t.Logf("✓ %d ledger violations challenged", len(proofs))
+}
+
+// TestNodeWatchdog tests that a node challenges proposals its watchdog flags
+func TestNodeWatchdog(t *testing.T) {
+	testenv.RequireSigning(t)
+	_, priv := testKey("Gemini")
+	_, keys := signedTestProposal(t)
+	node := NewNode(NewLedger())
+	node.Watchdog = NewWatchdog("Gemini", priv, nil, WithProposerKeys(keys))
+
+	p, _ := signedTestProposal(t)
+	p.Action["operation"] = "delete"
+	acceptance, err := node.Submit(p)
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if state, _ := node.Lifecycle().State(acceptance.ProposalHash); state != StateChallenged {
+		t.Errorf("Expected a tampered proposal to be challenged, got %s", state)
+	}
+	if _, err := node.Submit(p); err != nil {
+		t.Errorf("Expected resubmission to stay idempotent, got %v", err)
+	}
+
+	t.Log("✓ Node challenged a tampered proposal on acceptance")
+}
@@ -0,0 +1,167 @@
+// webhook.go - Outbound HMAC-signed webhooks for lifecycle events
+//
+// External systems (ticketing, chatops, executors) can react to constitutional
+// changes without embedding this library. Each delivery carries the event's
+// canonical bytes and semantic hash, and is authenticated with an HMAC-SHA256
+// of the request body under a secret shared with the receiver.
+
+package ocp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook request headers
+const (
+	WebhookEventHeader     = "X-OCP-Event"
+	WebhookSignatureHeader = "X-OCP-Signature"
+)
+
+// WebhookPayload is the JSON body of a webhook delivery
+type WebhookPayload struct {
+	Event     string `json:"event"`
+	Canonical string `json:"canonical"`
+	Hash      string `json:"hash"`
+}
+
+// Webhook is a single outbound endpoint
+type Webhook struct {
+	URL    string
+	Secret []byte
+	// Events limits deliveries to these event kinds; empty means all
+	Events []string
+}
+
+func (w Webhook) wants(kind string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookNotifier delivers lifecycle events to configured webhooks
+type WebhookNotifier struct {
+	Hooks  []Webhook
+	Client *http.Client
+	// OnError receives delivery failures from events delivered via Attach
+	OnError func(LifecycleEvent, error)
+}
+
+// NewWebhookNotifier creates a notifier with a bounded request timeout
+func NewWebhookNotifier(hooks ...Webhook) *WebhookNotifier {
+	return &WebhookNotifier{
+		Hooks:  hooks,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// BuildWebhookPayload returns the request body for an event
+func BuildWebhookPayload(e LifecycleEvent) ([]byte, error) {
+	canonicalString, err := Canonicalize(e.ToMap(), true)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := e.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(WebhookPayload{Event: e.Kind, Canonical: canonicalString, Hash: hash})
+}
+
+// SignWebhookBody returns the signature header value for body
+func SignWebhookBody(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook authenticates a received delivery and checks that the embedded
+// canonical bytes match the embedded hash.
+//
+// Returns:
+//   - Decoded payload if both checks pass
+func VerifyWebhook(secret, body []byte, signature string) (*WebhookPayload, error) {
+	if !hmac.Equal([]byte(SignWebhookBody(secret, body)), []byte(signature)) {
+		return nil, NewVerificationError("webhook signature mismatch")
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, NewVerificationError(fmt.Sprintf("webhook body is not JSON: %v", err))
+	}
+	if ContentHash([]byte(payload.Canonical)) != payload.Hash {
+		return nil, NewVerificationError("webhook canonical bytes do not match hash")
+	}
+	return &payload, nil
+}
+
+// Notify delivers an event to every interested webhook.
+// All hooks are attempted; failures are joined into the returned error.
+func (n *WebhookNotifier) Notify(e LifecycleEvent) error {
+	body, err := BuildWebhookPayload(e)
+	if err != nil {
+		return err
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var errs []error
+	for _, hook := range n.Hooks {
+		if !hook.wants(e.Kind) {
+			continue
+		}
+		if err := deliverWebhook(client, hook, e.Kind, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Attach subscribes the notifier to a lifecycle. Deliveries run in the
+// background so slow receivers never block state transitions.
+func (n *WebhookNotifier) Attach(l *Lifecycle) {
+	l.Subscribe(func(e LifecycleEvent) {
+		go func() {
+			if err := n.Notify(e); err != nil && n.OnError != nil {
+				n.OnError(e, err)
+			}
+		}()
+	})
+}
+
+func deliverWebhook(client *http.Client, hook Webhook, kind string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", hook.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, kind)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookBody(hook.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", hook.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: unexpected status %s", hook.URL, strings.TrimSpace(resp.Status))
+	}
+	return nil
+}
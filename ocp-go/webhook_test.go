@@ -0,0 +1,113 @@
+package ocp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWebhookDelivery tests signed delivery and receiver-side verification
+func TestWebhookDelivery(t *testing.T) {
+	secret := []byte("shared-secret")
+
+	var mu sync.Mutex
+	var received []*WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		payload, err := VerifyWebhook(secret, body, r.Header.Get(WebhookSignatureHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(WebhookEventHeader) != payload.Event {
+			http.Error(w, "event header mismatch", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(Webhook{
+		URL:    server.URL,
+		Secret: secret,
+		Events: []string{EventRatified, EventChallenged, EventReverted},
+	})
+
+	event := LifecycleEvent{
+		Kind:         EventRatified,
+		ProposalHash: "abc123",
+		Timestamp:    "2025-11-20T14:30:00Z",
+	}
+	if err := notifier.Notify(event); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+
+	// Submission events are filtered out by the hook's event list
+	if err := notifier.Notify(LifecycleEvent{Kind: EventSubmitted, ProposalHash: "abc123"}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(received))
+	}
+	expected, _ := event.GetHash()
+	if received[0].Hash != expected {
+		t.Errorf("Delivered hash should be the event's semantic hash")
+	}
+
+	t.Logf("✓ Delivered %s event with hash %s", received[0].Event, received[0].Hash[:16])
+}
+
+// TestWebhookRejectsWrongSecret tests that deliveries signed with another secret fail
+func TestWebhookRejectsWrongSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if _, err := VerifyWebhook([]byte("receiver-secret"), body, r.Header.Get(WebhookSignatureHeader)); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(Webhook{URL: server.URL, Secret: []byte("sender-secret")})
+	if err := notifier.Notify(LifecycleEvent{Kind: EventReverted, ProposalHash: "abc"}); err == nil {
+		t.Errorf("Delivery with the wrong secret should fail")
+	}
+}
+
+// TestWebhookAttach tests that lifecycle transitions fire webhooks
+func TestWebhookAttach(t *testing.T) {
+	secret := []byte("shared-secret")
+	events := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if payload, err := VerifyWebhook(secret, body, r.Header.Get(WebhookSignatureHeader)); err == nil {
+			events <- payload.Event
+		}
+	}))
+	defer server.Close()
+
+	lifecycle := NewLifecycle()
+	NewWebhookNotifier(Webhook{URL: server.URL, Secret: secret, Events: []string{EventRatified}}).Attach(lifecycle)
+
+	hash, err := lifecycle.Submit(testProposal())
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+	if err := lifecycle.Ratify(hash); err != nil {
+		t.Fatalf("Failed to ratify: %v", err)
+	}
+
+	select {
+	case kind := <-events:
+		if kind != EventRatified {
+			t.Errorf("Expected ratified event, got %s", kind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for webhook")
+	}
+}
@@ -0,0 +1,299 @@
+// window.go - Challenge windows that extend on late evidence
+//
+// A proposal may be challenged until its window closes, Duration after it was
+// accepted. Evidence that arrives in the final Trigger before the deadline
+// extends the window by a fixed Extension, up to MaxExtensions times, so a
+// proposer cannot rush an optimistic execution past scrutiny by timing it
+// against a slow challenger. Every extension is a WindowExtension recorded on
+// the ledger, and deadlines depend only on the acceptance time, the policy,
+// and the recorded extensions, so any verifier replaying the ledger derives
+// the same deadline and can check the extensions with VerifyWindowExtensions.
+
+package ocp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LedgerKindWindowExtension is the ledger entry kind recording a window extension
+const LedgerKindWindowExtension = "window_extension"
+
+// WindowPolicy configures challenge windows
+type WindowPolicy struct {
+	// Duration is the window length measured from acceptance
+	Duration time.Duration
+	// Trigger is how close to the deadline evidence must arrive to extend it
+	Trigger time.Duration
+	// Extension is added to the deadline for each qualifying submission
+	Extension time.Duration
+	// MaxExtensions bounds extensions per proposal; zero disables them
+	MaxExtensions int
+}
+
+// DefaultWindowPolicy opens the longest window OCP-0001 §7.1 allows (two
+// hours, for irreversible actions), extended by 30 minutes for evidence in its
+// last 10 minutes, at most 3 times
+var DefaultWindowPolicy = WindowPolicy{
+	Duration:      2 * time.Hour,
+	Trigger:       10 * time.Minute,
+	Extension:     30 * time.Minute,
+	MaxExtensions: 3,
+}
+
+// ToMap converts a WindowPolicy to a map for canonicalization. Durations are
+// recorded in whole milliseconds.
+func (w WindowPolicy) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"duration_ms":    w.Duration.Milliseconds(),
+		"trigger_ms":     w.Trigger.Milliseconds(),
+		"extension_ms":   w.Extension.Milliseconds(),
+		"max_extensions": w.MaxExtensions,
+	}
+}
+
+// Hash returns the semantic hash of the policy
+func (w WindowPolicy) Hash() (string, error) {
+	return SemanticHash(w.ToMap())
+}
+
+// WindowExtension records that new evidence extended a challenge window
+type WindowExtension struct {
+	ProposalHash     string `json:"proposal_hash"`
+	Challenger       string `json:"challenger"`
+	EvidencePointer  string `json:"evidence_pointer"`
+	SubmittedAt      string `json:"submitted_at"`
+	PreviousDeadline string `json:"previous_deadline"`
+	NewDeadline      string `json:"new_deadline"`
+	// Sequence numbers a proposal's extensions from 1
+	Sequence   int    `json:"sequence"`
+	PolicyHash string `json:"policy_hash"`
+}
+
+// ToMap converts a WindowExtension to a map for canonicalization
+func (x *WindowExtension) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"proposal_hash":     x.ProposalHash,
+		"challenger":        x.Challenger,
+		"evidence_pointer":  x.EvidencePointer,
+		"submitted_at":      x.SubmittedAt,
+		"previous_deadline": x.PreviousDeadline,
+		"new_deadline":      x.NewDeadline,
+		"sequence":          x.Sequence,
+		"policy_hash":       x.PolicyHash,
+	}
+}
+
+// Hash returns the semantic hash of the extension
+func (x *WindowExtension) Hash() (string, error) {
+	return SemanticHash(x.ToMap())
+}
+
+// ChallengeWindow is the current challenge window of an accepted proposal
+type ChallengeWindow struct {
+	ProposalHash string
+	Opened       time.Time
+	Deadline     time.Time
+	Extensions   []WindowExtension
+}
+
+// IsOpen reports whether challenges are still accepted at t
+func (c *ChallengeWindow) IsOpen(t time.Time) bool {
+	return t.Before(c.Deadline)
+}
+
+// ChallengeWindows tracks challenge windows for proposals on a ledger
+type ChallengeWindows struct {
+	mu     sync.Mutex
+	ledger *Ledger
+	policy WindowPolicy
+	// Clock supplies evidence submission times; defaults to SystemClock
+	Clock Clock
+}
+
+// NewChallengeWindows creates a tracker recording extensions on ledger
+func NewChallengeWindows(ledger *Ledger, policy WindowPolicy) *ChallengeWindows {
+	return &ChallengeWindows{ledger: ledger, policy: policy}
+}
+
+// Policy returns the window policy
+func (w *ChallengeWindows) Policy() WindowPolicy {
+	return w.policy
+}
+
+// Window returns the current window of an accepted proposal
+func (w *ChallengeWindows) Window(proposalHash string) (*ChallengeWindow, error) {
+	return ChallengeWindowOf(w.ledger.Entries(0), proposalHash, w.policy)
+}
+
+// SubmitEvidence records challenger evidence against p. Evidence arriving
+// within the policy's Trigger of the deadline extends the window.
+//
+// Parameters:
+//   - p: Challenged proposal
+//   - challenger: Agent submitting the evidence
+//   - pointer: Evidence pointer, which must not already appear in p's evidence
+//     or in an earlier extension of its window
+//
+// Returns:
+//   - The recorded extension, or nil if the evidence arrived before the trigger
+//   - ConstitutionalError if the window is closed or the evidence is not new
+func (w *ChallengeWindows) SubmitEvidence(p *ContractProposal, challenger, pointer string) (*WindowExtension, error) {
+	proposalHash, err := p.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	if pointer == "" {
+		return nil, NewConstitutionalError("evidence pointer is empty")
+	}
+	for _, ev := range p.Evidence {
+		if ev["pointer"] == pointer {
+			return nil, NewConstitutionalError(fmt.Sprintf("evidence %s is already part of the proposal", pointer))
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	window, err := w.Window(proposalHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range window.Extensions {
+		if x.EvidencePointer == pointer {
+			return nil, NewConstitutionalError(fmt.Sprintf("evidence %s already extended this window", pointer))
+		}
+	}
+
+	now := clockOrSystem(w.Clock).Now()
+	if !window.IsOpen(now) {
+		return nil, NewConstitutionalError(fmt.Sprintf("challenge window for %s closed at %s", proposalHash, Timestamp(window.Deadline)))
+	}
+	if window.Deadline.Sub(now) > w.policy.Trigger || len(window.Extensions) >= w.policy.MaxExtensions {
+		return nil, nil
+	}
+
+	policyHash, err := w.policy.Hash()
+	if err != nil {
+		return nil, err
+	}
+	x := &WindowExtension{
+		ProposalHash:     proposalHash,
+		Challenger:       challenger,
+		EvidencePointer:  pointer,
+		SubmittedAt:      Timestamp(now),
+		PreviousDeadline: Timestamp(window.Deadline),
+		NewDeadline:      Timestamp(window.Deadline.Add(w.policy.Extension)),
+		Sequence:         len(window.Extensions) + 1,
+		PolicyHash:       policyHash,
+	}
+	if _, err := w.ledger.Append(LedgerKindWindowExtension, x.ToMap()); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// ChallengeWindowOf derives a proposal's window from ledger entries, checking
+// each recorded extension against policy
+//
+// Returns:
+//   - Window with its current deadline
+//   - ConstitutionalError if the proposal was never accepted, or a
+//     VerificationError if a recorded extension does not follow the policy
+func ChallengeWindowOf(entries []LedgerEntry, proposalHash string, policy WindowPolicy) (*ChallengeWindow, error) {
+	policyHash, err := policy.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	var window *ChallengeWindow
+	for _, e := range entries {
+		if payloadString(e.Payload, "proposal_hash") != proposalHash {
+			continue
+		}
+		switch e.Kind {
+		case LedgerKindProposal:
+			if window != nil {
+				continue
+			}
+			opened, err := time.Parse(time.RFC3339Nano, payloadString(e.Payload, "accepted_at"))
+			if err != nil {
+				return nil, NewVerificationError(fmt.Sprintf("proposal %s has no valid acceptance time", proposalHash))
+			}
+			window = &ChallengeWindow{ProposalHash: proposalHash, Opened: opened, Deadline: opened.Add(policy.Duration)}
+		case LedgerKindWindowExtension:
+			if window == nil {
+				return nil, NewVerificationError(fmt.Sprintf("window extension at height %d precedes acceptance", e.Height))
+			}
+			x := windowExtensionFromPayload(e.Payload)
+			if err := checkExtension(window, x, policy, policyHash); err != nil {
+				return nil, NewVerificationError(fmt.Sprintf("window extension at height %d: %v", e.Height, err))
+			}
+			window.Deadline = window.Deadline.Add(policy.Extension)
+			window.Extensions = append(window.Extensions, x)
+		}
+	}
+	if window == nil {
+		return nil, NewConstitutionalError(fmt.Sprintf("proposal %s has not been accepted", proposalHash))
+	}
+	return window, nil
+}
+
+// VerifyWindowExtensions checks every window extension in entries against policy
+func VerifyWindowExtensions(entries []LedgerEntry, policy WindowPolicy) error {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.Kind != LedgerKindWindowExtension {
+			continue
+		}
+		hash := payloadString(e.Payload, "proposal_hash")
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if _, err := ChallengeWindowOf(entries, hash, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkExtension verifies that x is the next extension policy allows for window
+func checkExtension(window *ChallengeWindow, x WindowExtension, policy WindowPolicy, policyHash string) error {
+	if x.PolicyHash != policyHash {
+		return fmt.Errorf("recorded under policy %s, not %s", x.PolicyHash, policyHash)
+	}
+	if x.Sequence != len(window.Extensions)+1 || x.Sequence > policy.MaxExtensions {
+		return fmt.Errorf("sequence %d not allowed after %d extensions", x.Sequence, len(window.Extensions))
+	}
+	for _, prior := range window.Extensions {
+		if prior.EvidencePointer == x.EvidencePointer {
+			return fmt.Errorf("evidence %s reused", x.EvidencePointer)
+		}
+	}
+	if x.PreviousDeadline != Timestamp(window.Deadline) || x.NewDeadline != Timestamp(window.Deadline.Add(policy.Extension)) {
+		return fmt.Errorf("deadline %s -> %s does not follow the policy", x.PreviousDeadline, x.NewDeadline)
+	}
+	submitted, err := time.Parse(time.RFC3339Nano, x.SubmittedAt)
+	if err != nil {
+		return fmt.Errorf("invalid submission time %q", x.SubmittedAt)
+	}
+	if !window.IsOpen(submitted) || window.Deadline.Sub(submitted) > policy.Trigger {
+		return fmt.Errorf("evidence submitted at %s is outside the extension trigger", x.SubmittedAt)
+	}
+	return nil
+}
+
+func windowExtensionFromPayload(payload map[string]interface{}) WindowExtension {
+	return WindowExtension{
+		ProposalHash:     payloadString(payload, "proposal_hash"),
+		Challenger:       payloadString(payload, "challenger"),
+		EvidencePointer:  payloadString(payload, "evidence_pointer"),
+		SubmittedAt:      payloadString(payload, "submitted_at"),
+		PreviousDeadline: payloadString(payload, "previous_deadline"),
+		NewDeadline:      payloadString(payload, "new_deadline"),
+		Sequence:         payloadInt(payload, "sequence"),
+		PolicyHash:       payloadString(payload, "policy_hash"),
+	}
+}
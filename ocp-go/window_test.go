@@ -0,0 +1,108 @@
+package ocp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seanrugg/ai_constitution/ocp-go/internal/testenv"
+)
+
+// TestChallengeWindowExtension tests deterministic extensions on late evidence
+func TestChallengeWindowExtension(t *testing.T) {
+	testenv.RequireSigning(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	node.Clock = clock
+	p := testProposal()
+	acceptance, err := node.Submit(p)
+	if err != nil {
+		t.Fatalf("Failed to submit: %v", err)
+	}
+
+	policy := WindowPolicy{Duration: 72 * time.Hour, Trigger: 6 * time.Hour, Extension: 24 * time.Hour, MaxExtensions: 2}
+	windows := NewChallengeWindows(ledger, policy)
+	windows.Clock = clock
+
+	// Early evidence does not extend the window
+	clock.Advance(24 * time.Hour)
+	if x, err := windows.SubmitEvidence(p, "Gemini", "sha256:early"); err != nil || x != nil {
+		t.Fatalf("Early evidence should not extend the window: %v %v", x, err)
+	}
+	if _, err := windows.SubmitEvidence(p, "Gemini", "sha256:abc123def456"); err == nil {
+		t.Errorf("The proposal's own evidence should not qualify")
+	}
+
+	clock.Advance(47 * time.Hour)
+	x, err := windows.SubmitEvidence(p, "Gemini", "sha256:late")
+	if err != nil || x == nil {
+		t.Fatalf("Late evidence should extend the window: %v", err)
+	}
+	if x.NewDeadline != "2025-11-24T14:30:00Z" || x.Sequence != 1 {
+		t.Errorf("Unexpected extension: %+v", x)
+	}
+	if _, err := windows.SubmitEvidence(p, "DeepSeek", "sha256:late"); err == nil {
+		t.Errorf("Reused evidence should not extend the window again")
+	}
+
+	clock.Advance(20 * time.Hour)
+	if x, _ := windows.SubmitEvidence(p, "DeepSeek", "sha256:later"); x == nil || x.Sequence != 2 {
+		t.Fatalf("Second late evidence should extend the window")
+	}
+	clock.Advance(25 * time.Hour)
+	if x, err := windows.SubmitEvidence(p, "DeepSeek", "sha256:last"); err != nil || x != nil {
+		t.Errorf("Extensions beyond MaxExtensions should not be recorded: %v %v", x, err)
+	}
+
+	window, err := windows.Window(acceptance.ProposalHash)
+	if err != nil {
+		t.Fatalf("Failed to derive window: %v", err)
+	}
+	if Timestamp(window.Deadline) != "2025-11-25T14:30:00Z" || len(window.Extensions) != 2 {
+		t.Errorf("Unexpected window: %s with %d extensions", Timestamp(window.Deadline), len(window.Extensions))
+	}
+	clock.Advance(6 * time.Hour)
+	if _, err := windows.SubmitEvidence(p, "DeepSeek", "sha256:too-late"); err == nil {
+		t.Errorf("Evidence after the deadline should be rejected")
+	}
+
+	if err := VerifyWindowExtensions(ledger.Entries(0), policy); err != nil {
+		t.Errorf("Recorded extensions should verify: %v", err)
+	}
+	stricter := policy
+	stricter.Extension = 48 * time.Hour
+	if err := VerifyWindowExtensions(ledger.Entries(0), stricter); err == nil {
+		t.Errorf("Extensions should not verify under a different policy")
+	}
+
+	t.Logf("✓ Window closes at %s after %d extensions", Timestamp(window.Deadline), len(window.Extensions))
+}
+
+// TestVerifyWindowExtensionsTampered tests rejection of a forged extension
+func TestVerifyWindowExtensionsTampered(t *testing.T) {
+	testenv.RequireSigning(t)
+	clock := NewManualClock(time.Date(2025, 11, 20, 14, 30, 0, 0, time.UTC))
+	ledger := NewLedger()
+	node := NewNode(ledger)
+	node.Clock = clock
+	acceptance, _ := node.Submit(testProposal())
+
+	policyHash, _ := DefaultWindowPolicy.Hash()
+	forged := &WindowExtension{
+		ProposalHash:     acceptance.ProposalHash,
+		Challenger:       "Gemini",
+		EvidencePointer:  "sha256:early",
+		SubmittedAt:      "2025-11-20T14:40:00Z",
+		PreviousDeadline: "2025-11-20T16:30:00Z",
+		NewDeadline:      "2025-11-20T17:00:00Z",
+		Sequence:         1,
+		PolicyHash:       policyHash,
+	}
+	ledger.Append(LedgerKindWindowExtension, forged.ToMap())
+
+	if err := VerifyWindowExtensions(ledger.Entries(0), DefaultWindowPolicy); err == nil {
+		t.Fatalf("Extension outside the trigger should not verify")
+	}
+
+	t.Logf("✓ Forged extension rejected")
+}
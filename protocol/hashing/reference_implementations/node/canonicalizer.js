@@ -13,6 +13,19 @@ const crypto = require('crypto');
 // --- Constants ---
 const HASH_ALGORITHM = 'sha256';
 const ENCODING = 'utf-8';
+const BINARY_PREFIX = 'b64:';
+const SET_KEY = '_set';
+
+/**
+ * Return the canonical string form of a byte string (Rule 2.4.4):
+ * "b64:" followed by unpadded base64url.
+ * 
+ * @param {Uint8Array} bytes - Bytes to encode (Buffer is a Uint8Array)
+ * @returns {string} - Canonical string form
+ */
+function encodeBytes(bytes) {
+    return BINARY_PREFIX + Buffer.from(bytes.buffer, bytes.byteOffset, bytes.byteLength).toString('base64url');
+}
 
 // --- Custom Error Classes ---
 class ConstitutionalError extends Error {
@@ -29,10 +42,53 @@ class CanonicalizationError extends ConstitutionalError {
     }
 }
 
+/**
+ * Return the canonical string form of an exact amount (Rule 2.4.5):
+ * no exponent, no leading or trailing zeros, and no negative zero.
+ * JavaScript has no decimal type, so amounts are handled as strings.
+ * 
+ * @param {string} value - Decimal string such as "12.50"
+ * @returns {string} - Canonical string form
+ * @throws {CanonicalizationError} - If value is not a decimal string
+ */
+function canonicalDecimal(value) {
+    if (typeof value !== 'string' || !/^-?(0|[1-9][0-9]*)(\.[0-9]+)?$/.test(value)) {
+        throw new CanonicalizationError(`Invalid decimal ${JSON.stringify(value)}`);
+    }
+    const text = value.includes('.') ? value.replace(/0+$/, '').replace(/\.$/, '') : value;
+    return text === '-0' ? '0' : text;
+}
+
+/**
+ * Order the elements of a {"_set": [...]} collection (Rule 2.5.2): each element
+ * is canonicalized on its own, elements are ordered by the SHA-256 digest of
+ * their canonical form, and duplicates are collapsed to one.
+ * Matches Python's _sort_set function.
+ * 
+ * @param {Array} elems - Elements of the set
+ * @returns {Array} - Ordered, deduplicated elements
+ */
+function sortSet(elems) {
+    const byDigest = new Map();
+    for (const elem of elems) {
+        const sorted = deepSort(elem);
+        const digest = crypto.createHash(HASH_ALGORITHM)
+            .update(Buffer.from(JSON.stringify(sorted, replacer), ENCODING))
+            .digest('hex');
+        if (!byDigest.has(digest)) {
+            byDigest.set(digest, sorted);
+        }
+    }
+    return [...byDigest.keys()].sort().map(digest => byDigest.get(digest));
+}
+
 /**
  * Recursively sort all dictionaries by keys and sort lists where appropriate.
  * This ensures complete deterministic ordering of nested structures.
  * Matches Python's _deep_sort function.
+ * Byte strings become their encodeBytes form before any sorting. An object
+ * whose only key is "_set" keeps its wrapper and has its elements ordered by
+ * sortSet.
  * 
  * @param {any} obj - Object to sort
  * @returns {any} - Deeply sorted object
@@ -42,6 +98,21 @@ function deepSort(obj) {
         return null;
     }
     
+    if (obj instanceof Uint8Array) {
+        return encodeBytes(obj);
+    }
+    
+    if (Array.isArray(obj)) {
+        obj = obj.map(x => (x instanceof Uint8Array ? encodeBytes(x) : x));
+    }
+    
+    if (typeof obj === 'object' && obj.constructor === Object) {
+        const keys = Object.keys(obj);
+        if (keys.length === 1 && keys[0] === SET_KEY && Array.isArray(obj[SET_KEY])) {
+            return { [SET_KEY]: sortSet(obj[SET_KEY]) };
+        }
+    }
+    
     if (typeof obj === 'object' && !Array.isArray(obj) && obj.constructor === Object) {
         // Handle plain objects (dictionaries)
         const sorted = {};
@@ -214,6 +285,9 @@ if (typeof module !== 'undefined' && module.exports) {
         verifySemanticHash,
         canonicallyEqual,
         deepSort,
+        sortSet,
+        encodeBytes,
+        canonicalDecimal,
         ConstitutionalError,
         CanonicalizationError,
         HASH_ALGORITHM,
@@ -337,5 +411,32 @@ if (typeof require !== 'undefined' && require.main === module) {
     console.log('✓ Date objects handled correctly');
     console.log(`  Canonical: ${canonWithDate}`);
     
+    // Test 8: Binary values
+    console.log('\n--- Test 8: Binary Values ---');
+    const dataWithBytes = {
+        "signature": Buffer.from([0x01, 0xfe, 0xff]),
+        "digest": new Uint8Array([0x66, 0x6f])
+    };
+    assert.strictEqual(canonicalize(dataWithBytes), '{"digest":"b64:Zm8","signature":"b64:Af7_"}', 'Bytes should follow Rule 2.4.4');
+    console.log('✓ Byte strings encoded as b64: base64url');
+    
+    // Test 9: Decimal amounts
+    console.log('\n--- Test 9: Decimal Amounts ---');
+    assert.strictEqual(canonicalDecimal('12.50'), '12.5', 'Trailing zeros should be removed');
+    assert.strictEqual(canonicalDecimal('-0.0'), '0', 'Negative zero should be 0');
+    assert.throws(() => canonicalDecimal('1e3'), CanonicalizationError, 'Exponents should be rejected');
+    console.log('✓ Decimal amounts normalized per Rule 2.4.5');
+    
+    // Test 10: Set semantics
+    console.log('\n--- Test 10: Set Semantics ---');
+    const setA = { "evidence": { "_set": [{ "type": "computation", "pointer": "sha256:bbb" }, { "type": "archive_reference", "pointer": "sha256:aaa" }] } };
+    const setB = { "evidence": { "_set": [{ "pointer": "sha256:aaa", "type": "archive_reference" }, { "type": "computation", "pointer": "sha256:bbb" }, { "type": "computation", "pointer": "sha256:bbb" }] } };
+    const expectedSet = '{"evidence":{"_set":[{"pointer":"sha256:bbb","type":"computation"},{"pointer":"sha256:aaa","type":"archive_reference"}]}}';
+    assert.strictEqual(canonicalize(setA), expectedSet, 'Set elements should be ordered by hash');
+    assert.strictEqual(canonicalize(setB), expectedSet, 'Duplicate set elements should collapse');
+    assert.strictEqual(semanticHash(setA), 'dc31e03ccaa5b3157429781b11732b2090786737cbb61cc7e05d357d252b9639', 'Set hash should match the vector');
+    assert.strictEqual(canonicalize({ "_set": [{ "b": 1 }, { "a": 1 }], "n": 1 }), '{"_set":[{"b":1},{"a":1}],"n":1}', 'Other keys make an ordinary object');
+    console.log('✓ {"_set": [...]} collections follow Rule 2.5');
+    
     console.log('\n✅ All tests passed!');
 }
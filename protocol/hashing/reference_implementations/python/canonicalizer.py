@@ -6,7 +6,9 @@ ensuring deterministic representation of all constitutional objects for
 cryptographic hashing and verification.
 """
 
+import base64
 import json
+import re
 import hashlib
 import decimal
 import datetime
@@ -17,6 +19,9 @@ import uuid
 # --- Constants ---
 HASH_ALGORITHM = 'sha256'
 ENCODING = 'utf-8'
+BINARY_PREFIX = 'b64:'
+SET_KEY = '_set'
+_BINARY_TYPES = (bytes, bytearray, memoryview)
 
 class ConstitutionalError(Exception):
     """Base exception for constitutional protocol violations."""
@@ -55,17 +60,74 @@ class OCPJSONEncoder(json.JSONEncoder):
         # Let the base class default method raise the TypeError
         return super().default(obj)
 
+def encode_bytes(data: Union[bytes, bytearray, memoryview]) -> str:
+    """
+    Return the canonical string form of a byte string (Rule 2.4.4):
+    "b64:" followed by unpadded base64url.
+    """
+    return BINARY_PREFIX + base64.urlsafe_b64encode(bytes(data)).rstrip(b'=').decode('ascii')
+
+def canonical_decimal(value: Union[decimal.Decimal, str]) -> str:
+    """
+    Return the canonical string form of an exact amount (Rule 2.4.5):
+    no exponent, no leading or trailing zeros, and no negative zero.
+    """
+    if isinstance(value, str):
+        if not re.fullmatch(r'-?(0|[1-9][0-9]*)(\.[0-9]+)?', value):
+            raise CanonicalizationError(f"Invalid decimal {value!r}")
+        value = decimal.Decimal(value)
+    if not value.is_finite():
+        raise CanonicalizationError(f"Decimal must be finite, got {value}")
+    text = format(value, 'f')
+    if '.' in text:
+        text = text.rstrip('0').rstrip('.')
+    return '0' if text in ('-0', '') else text
+
+def _dumps(obj: Any) -> str:
+    """Serialize an already deep-sorted value in canonical JSON form."""
+    return json.dumps(
+        obj,
+        cls=OCPJSONEncoder,
+        sort_keys=True,  # Redundant with _deep_sort but added for safety
+        separators=(',', ':'),
+        ensure_ascii=False,
+        allow_nan=False  # Important for cryptographic consistency
+    )
+
+def _sort_set(elems: list) -> list:
+    """
+    Order the elements of a {"_set": [...]} collection (Rule 2.5.2): each
+    element is canonicalized on its own, elements are ordered by the SHA-256
+    digest of their canonical form, and duplicates are collapsed to one.
+    """
+    by_digest = {}
+    for elem in elems:
+        sorted_elem = _deep_sort(elem)
+        digest = hashlib.sha256(_dumps(sorted_elem).encode(ENCODING)).hexdigest()
+        by_digest.setdefault(digest, sorted_elem)
+    return [by_digest[d] for d in sorted(by_digest)]
+
 def _deep_sort(obj: Any) -> Any:
     """
     Recursively sort all dictionaries by keys and sort lists where appropriate.
     This ensures complete deterministic ordering of nested structures.
+    Byte strings become their encode_bytes form and decimals their
+    canonical_decimal form before any sorting. An object whose only key is
+    "_set" keeps its wrapper and has its elements ordered by _sort_set.
     """
+    if isinstance(obj, _BINARY_TYPES):
+        return encode_bytes(obj)
+    if isinstance(obj, decimal.Decimal):
+        return canonical_decimal(obj)
+    if isinstance(obj, dict) and len(obj) == 1 and isinstance(obj.get(SET_KEY), list):
+        return {SET_KEY: _sort_set(obj[SET_KEY])}
     if isinstance(obj, dict):
         # Sort dictionary by keys and recursively process values
         return {k: _deep_sort(v) for k, v in sorted(obj.items())}
     elif isinstance(obj, list):
         # For lists, we need to be careful - only sort if all elements are comparable
         # and of the same basic type. For mixed types or complex objects, we maintain order.
+        obj = [_deep_sort(x) if isinstance(x, _BINARY_TYPES + (decimal.Decimal,)) else x for x in obj]
         if all(isinstance(x, (str, int, float, bool)) for x in obj):
             return sorted(_deep_sort(x) for x in obj)
         else:
@@ -107,14 +169,7 @@ def canonicalize(data: Dict[str, Any], strict: bool = True) -> str:
         sorted_data = _deep_sort(data)
         
         # Convert to canonical JSON
-        canonical_json = json.dumps(
-            sorted_data,
-            cls=OCPJSONEncoder,
-            sort_keys=True,  # Redundant with _deep_sort but added for safety
-            separators=(',', ':'),
-            ensure_ascii=False,
-            allow_nan=False  # Important for cryptographic consistency
-        )
+        canonical_json = _dumps(sorted_data)
         
         return canonical_json
         
@@ -199,7 +254,31 @@ if __name__ == "__main__":
             
             self.assertEqual(canonical_a, canonical_b)
             self.assertEqual(semantic_hash(dict_a), semantic_hash(dict_b))
+
+        def test_decimal_values(self):
+            """Test that decimals follow Rule 2.4.5."""
+            data = {"stake": decimal.Decimal("12.50"), "bond": decimal.Decimal("1E+2"), "zero": decimal.Decimal("-0.0")}
+            self.assertEqual(canonicalize(data), '{"bond":"100","stake":"12.5","zero":"0"}')
+            self.assertEqual(canonical_decimal("-0.10"), "-0.1")
+            with self.assertRaises(CanonicalizationError):
+                canonical_decimal("1e3")
+
+        def test_binary_values(self):
+            """Test that byte strings follow Rule 2.4.4."""
+            data = {"signature": b"\x01\xfe\xff", "parts": [b"b", b"a"]}
+            self.assertEqual(canonicalize(data), '{"parts":["b64:YQ","b64:Yg"],"signature":"b64:Af7_"}')
         
+        def test_set_semantics(self):
+            """Test that {"_set": [...]} collections follow Rule 2.5."""
+            data_a = {"evidence": {"_set": [{"type": "computation", "pointer": "sha256:bbb"}, {"type": "archive_reference", "pointer": "sha256:aaa"}]}}
+            data_b = {"evidence": {"_set": [{"pointer": "sha256:aaa", "type": "archive_reference"}, {"type": "computation", "pointer": "sha256:bbb"}, {"type": "computation", "pointer": "sha256:bbb"}]}}
+            expected = '{"evidence":{"_set":[{"pointer":"sha256:bbb","type":"computation"},{"pointer":"sha256:aaa","type":"archive_reference"}]}}'
+            self.assertEqual(canonicalize(data_a), expected)
+            self.assertEqual(canonicalize(data_b), expected)
+            self.assertEqual(semantic_hash(data_a), "dc31e03ccaa5b3157429781b11732b2090786737cbb61cc7e05d357d252b9639")
+            # Any other key alongside "_set" makes an ordinary object
+            self.assertEqual(canonicalize({"_set": [{"b": 1}, {"a": 1}], "n": 1}), '{"_set":[{"b":1},{"a":1}],"n":1}')
+
         def test_nested_structures(self):
             """Test canonicalization of nested dictionaries and lists."""
             complex_dict = {
@@ -14,6 +14,10 @@ use thiserror::Error;
 // --- Constants ---
 pub const HASH_ALGORITHM: &str = "sha256";
 pub const ENCODING: &str = "utf-8";
+pub const BINARY_PREFIX: &str = "b64:";
+pub const SET_KEY: &str = "_set";
+
+const BASE64URL: &[u8; 64] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_";
 
 // --- Custom Error Types ---
 #[derive(Error, Debug)]
@@ -30,11 +34,34 @@ pub enum ConstitutionalError {
 
 pub type Result<T> = std::result::Result<T, ConstitutionalError>;
 
+/// Order the elements of a {"_set": [...]} collection (Rule 2.5.2): each element
+/// is canonicalized on its own, elements are ordered by the SHA-256 digest of
+/// their canonical form, and duplicates are collapsed to one.
+/// Matches Python's _sort_set and JavaScript's sortSet functions.
+fn sort_set(elems: &[Value]) -> Vec<Value> {
+    let mut by_digest = BTreeMap::new();
+    for elem in elems {
+        let sorted = deep_sort(elem);
+        let canonical = serde_json::to_string(&sorted).unwrap_or_default();
+        let digest = Sha256::digest(canonical.as_bytes()).to_vec();
+        by_digest.entry(digest).or_insert(sorted);
+    }
+    by_digest.into_values().collect()
+}
+
 /// Recursively sort all dictionaries by keys and sort arrays where appropriate.
 /// This ensures complete deterministic ordering of nested structures.
 /// Matches Python's _deep_sort and JavaScript's deepSort functions.
+/// An object whose only key is "_set" keeps its wrapper and has its elements
+/// ordered by sort_set.
 fn deep_sort(value: &Value) -> Value {
     match value {
+        Value::Object(map) if map.len() == 1 && map.get(SET_KEY).map_or(false, Value::is_array) => {
+            let elems = map[SET_KEY].as_array().map(Vec::as_slice).unwrap_or(&[]);
+            let mut result_map = Map::new();
+            result_map.insert(SET_KEY.to_string(), Value::Array(sort_set(elems)));
+            Value::Object(result_map)
+        }
         Value::Object(map) => {
             // Convert to BTreeMap (automatically sorted by keys)
             let mut sorted_map = BTreeMap::new();
@@ -102,6 +129,69 @@ fn deep_sort(value: &Value) -> Value {
     }
 }
 
+/// Return the canonical form of a byte string (Rule 2.4.4): "b64:" followed by
+/// unpadded base64url. serde_json::Value has no binary type, so callers place
+/// the result in a Value before canonicalizing.
+///
+/// # Arguments
+/// * `bytes` - Bytes to encode
+///
+/// # Returns
+/// JSON string value
+pub fn encode_bytes(bytes: &[u8]) -> Value {
+    let mut out = String::with_capacity(BINARY_PREFIX.len() + (bytes.len() * 4 + 2) / 3);
+    out.push_str(BINARY_PREFIX);
+    for chunk in bytes.chunks(3) {
+        let n = chunk.iter().enumerate().fold(0u32, |acc, (i, b)| acc | (*b as u32) << (16 - 8 * i));
+        for i in 0..=chunk.len() {
+            out.push(BASE64URL[(n >> (18 - 6 * i) & 0x3f) as usize] as char);
+        }
+    }
+    Value::String(out)
+}
+
+/// Return the canonical form of an exact amount (Rule 2.4.5): no exponent, no
+/// leading or trailing zeros, and no negative zero. Amounts are passed as
+/// decimal strings so they never pass through an f64.
+///
+/// # Arguments
+/// * `value` - Decimal string such as "12.50"
+///
+/// # Returns
+/// JSON string value, or CanonicalizationError if value is not a decimal
+pub fn canonical_decimal(value: &str) -> Result<Value> {
+    let invalid = || ConstitutionalError::CanonicalizationError(format!("Invalid decimal {:?}", value));
+    let digits = value.strip_prefix('-').unwrap_or(value);
+    let (int_part, frac_part) = match digits.split_once('.') {
+        Some((i, f)) if !f.is_empty() => (i, Some(f)),
+        Some(_) => return Err(invalid()),
+        None => (digits, None),
+    };
+    let all_digits = |s: &str| !s.is_empty() && s.bytes().all(|b| b.is_ascii_digit());
+    if !all_digits(int_part) || (int_part.len() > 1 && int_part.starts_with('0')) {
+        return Err(invalid());
+    }
+    let mut text = String::new();
+    if value.starts_with('-') {
+        text.push('-');
+    }
+    text.push_str(int_part);
+    if let Some(frac) = frac_part {
+        if !all_digits(frac) {
+            return Err(invalid());
+        }
+        let trimmed = frac.trim_end_matches('0');
+        if !trimmed.is_empty() {
+            text.push('.');
+            text.push_str(trimmed);
+        }
+    }
+    if text == "-0" {
+        text = "0".to_string();
+    }
+    Ok(Value::String(text))
+}
+
 /// Convert a serde_json::Value to a deterministically ordered, canonical JSON string.
 /// Matches Python's canonicalize and JavaScript's canonicalize functions.
 ///
@@ -374,6 +464,53 @@ mod tests {
         );
     }
 
+    #[test]
+    fn test_binary_values() {
+        assert_eq!(encode_bytes(&[]), json!("b64:"));
+        assert_eq!(encode_bytes(b"fo"), json!("b64:Zm8"));
+        let data = json!({
+            "signature": encode_bytes(&[0x01, 0xfe, 0xff]),
+            "parts": [encode_bytes(b"b"), encode_bytes(b"a")]
+        });
+        assert_eq!(
+            canonicalize(&data, true).unwrap(),
+            r#"{"parts":["b64:YQ","b64:Yg"],"signature":"b64:Af7_"}"#
+        );
+    }
+
+    #[test]
+    fn test_decimal_values() {
+        assert_eq!(canonical_decimal("12.50").unwrap(), json!("12.5"));
+        assert_eq!(canonical_decimal("-0.0").unwrap(), json!("0"));
+        assert_eq!(canonical_decimal("100").unwrap(), json!("100"));
+        assert!(canonical_decimal("1e3").is_err());
+        assert!(canonical_decimal("01").is_err());
+        assert!(canonical_decimal("1.").is_err());
+    }
+
+    #[test]
+    fn test_set_semantics() {
+        let data_a = json!({"evidence": {"_set": [
+            {"type": "computation", "pointer": "sha256:bbb"},
+            {"type": "archive_reference", "pointer": "sha256:aaa"}
+        ]}});
+        let data_b = json!({"evidence": {"_set": [
+            {"pointer": "sha256:aaa", "type": "archive_reference"},
+            {"type": "computation", "pointer": "sha256:bbb"},
+            {"type": "computation", "pointer": "sha256:bbb"}
+        ]}});
+        let expected = r#"{"evidence":{"_set":[{"pointer":"sha256:bbb","type":"computation"},{"pointer":"sha256:aaa","type":"archive_reference"}]}}"#;
+        assert_eq!(canonicalize(&data_a, true).unwrap(), expected);
+        assert_eq!(canonicalize(&data_b, true).unwrap(), expected);
+        assert_eq!(
+            semantic_hash(&data_a).unwrap(),
+            "dc31e03ccaa5b3157429781b11732b2090786737cbb61cc7e05d357d252b9639"
+        );
+        // Any other key alongside "_set" makes an ordinary object
+        let ordinary = json!({"_set": [{"b": 1}, {"a": 1}], "n": 1});
+        assert_eq!(canonicalize(&ordinary, true).unwrap(), r#"{"_set":[{"b":1},{"a":1}],"n":1}"#);
+    }
+
     #[test]
     fn test_cross_language_vector() {
         // Test vector for cross-language validation
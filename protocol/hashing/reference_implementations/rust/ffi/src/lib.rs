@@ -0,0 +1,86 @@
+//! lib.rs - C ABI over the OCP Rust reference canonicalizer
+//!
+//! Links canonicalizer.rs unchanged, so differential tests in other languages
+//! exercise exactly the reference implementation. Used by the Go harness in
+//! ocp-go/canonical/rustref, which builds against the static library:
+//!
+//!     cargo build --release
+//!
+//! Every function takes a JSON document as bytes. Results are written to
+//! buffers allocated here and released with ocp_free.
+
+#[allow(dead_code)]
+#[path = "../../canonicalizer.rs"]
+mod canonicalizer;
+
+use serde_json::Value;
+use std::slice;
+
+/// Status codes returned by every function
+pub const OCP_OK: i32 = 0;
+pub const OCP_PARSE_ERROR: i32 = 1;
+pub const OCP_CANONICAL_ERROR: i32 = 2;
+
+/// Hands out bytes as a buffer owned by the caller until ocp_free
+unsafe fn give(bytes: Vec<u8>, out: *mut *mut u8, out_len: *mut usize) {
+    let boxed = bytes.into_boxed_slice();
+    *out_len = boxed.len();
+    *out = Box::into_raw(boxed) as *mut u8;
+}
+
+/// Parses input and applies f, writing its result or error message to out
+unsafe fn run(
+    input: *const u8,
+    len: usize,
+    out: *mut *mut u8,
+    out_len: *mut usize,
+    f: fn(&Value) -> canonicalizer::Result<String>,
+) -> i32 {
+    let data = if len == 0 { &[][..] } else { slice::from_raw_parts(input, len) };
+    let value: Value = match serde_json::from_slice(data) {
+        Ok(v) => v,
+        Err(e) => {
+            give(e.to_string().into_bytes(), out, out_len);
+            return OCP_PARSE_ERROR;
+        }
+    };
+    match f(&value) {
+        Ok(s) => {
+            give(s.into_bytes(), out, out_len);
+            OCP_OK
+        }
+        Err(e) => {
+            give(e.to_string().into_bytes(), out, out_len);
+            OCP_CANONICAL_ERROR
+        }
+    }
+}
+
+/// Writes the strict canonical form of the JSON document in input to out
+///
+/// # Safety
+/// input must point to len readable bytes; out and out_len must be writable
+#[no_mangle]
+pub unsafe extern "C" fn ocp_canonicalize(input: *const u8, len: usize, out: *mut *mut u8, out_len: *mut usize) -> i32 {
+    run(input, len, out, out_len, |v| canonicalizer::canonicalize(v, true))
+}
+
+/// Writes the hex semantic hash of the JSON document in input to out
+///
+/// # Safety
+/// input must point to len readable bytes; out and out_len must be writable
+#[no_mangle]
+pub unsafe extern "C" fn ocp_semantic_hash(input: *const u8, len: usize, out: *mut *mut u8, out_len: *mut usize) -> i32 {
+    run(input, len, out, out_len, canonicalizer::semantic_hash)
+}
+
+/// Releases a buffer returned by this library
+///
+/// # Safety
+/// ptr and len must come from one earlier call, and be released only once
+#[no_mangle]
+pub unsafe extern "C" fn ocp_free(ptr: *mut u8, len: usize) {
+    if !ptr.is_null() {
+        drop(Box::from_raw(slice::from_raw_parts_mut(ptr, len)));
+    }
+}